@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	signerapi "github.com/aegis-sign/wallet/internal/api"
+	"github.com/aegis-sign/wallet/internal/api/admin"
+	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+	"github.com/aegis-sign/wallet/internal/infra/server"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type adminTestPool struct{ targets map[string]string }
+
+func (p *adminTestPool) Stats() []enclaveclient.TargetStats {
+	var out []enclaveclient.TargetStats
+	for id, endpoint := range p.targets {
+		out = append(out, enclaveclient.TargetStats{ID: id, Endpoint: endpoint})
+	}
+	return out
+}
+
+func (p *adminTestPool) Config() enclaveclient.Config          { return enclaveclient.DefaultConfig() }
+func (p *adminTestPool) UpdateConfig(enclaveclient.Config)     {}
+func (p *adminTestPool) RegisterTarget(t enclaveclient.Target) { p.targets[t.ID] = t.Endpoint }
+func (p *adminTestPool) RemoveTarget(id string)                { delete(p.targets, id) }
+func (p *adminTestPool) Drain(string) error                    { return nil }
+
+func TestConfigureAdminGRPC(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	t.Setenv("SIGNER_ADMIN_GRPC_ADDR", "127.0.0.1:0")
+	_, _, err := configureAdminGRPC(context.Background(), logger, nil, server.DefaultConfig())
+	require.ErrorContains(t, err, "SIGNER_ADMIN_CREDENTIALS_FILE")
+
+	credentials := filepath.Join(t.TempDir(), "admin.json")
+	require.NoError(t, os.WriteFile(credentials, []byte(`{"apiKeys":[{"subject":"ops","key":"admin-key","roles":["admin"]}]}`), 0o600))
+	t.Setenv("SIGNER_ADMIN_CREDENTIALS_FILE", credentials)
+	api, _, err := configureAdminAPI(signerapi.NewRoutes(), logger, admin.Config{
+		Role:      "admin",
+		Pool:      &adminTestPool{targets: map[string]string{"e1": "vsock://3:5000"}},
+		Reloaders: map[string]admin.Reloader{},
+		Logger:    logger,
+	}, nil)
+	require.NoError(t, err)
+	srv, lis, err := configureAdminGRPC(context.Background(), logger, api, server.DefaultConfig())
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	client := signerv1.NewAdminServiceClient(conn)
+	_, err = client.ListTargets(context.Background(), &signerv1.ListTargetsRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	// 业务 gRPC 服务不在管理监听器上注册。
+	_, err = signerv1.NewSignerServiceClient(conn).Sign(context.Background(), &signerv1.SignRequest{})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+	list, err := client.ListTargets(metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "admin-key"), &signerv1.ListTargetsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetTargets(), 1)
+	require.Equal(t, "vsock://3:5000", list.GetTargets()[0].GetEndpoint())
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"log/slog"
+
+	signerapi "github.com/aegis-sign/wallet/internal/api"
+	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// keyCacheDrainReason 是 Enclave 排空触发失效时的原因，解锁事件为 enclave_relocate:drain。
+const keyCacheDrainReason = "drain"
+
+// configureKeyCache 在 SIGNER_KEY_CACHE=true 时构造父机侧 key cache，未开启时返回 nil。
+// Store 接到连接池排空、key 停用与管理 API 上，entry 由加载方写入。
+func configureKeyCache(logger *slog.Logger, registry prometheus.Registerer, metricsOpts metricsopts.Options) (*keycache.Store, error) {
+	if !envBool("SIGNER_KEY_CACHE", false) {
+		return nil, nil
+	}
+	metrics, err := keycache.NewMetricsWithOptions(registry, metricsOpts)
+	if err != nil {
+		return nil, err
+	}
+	return keycache.NewStore(keycache.StoreConfig{Logger: logger, Metrics: metrics}), nil
+}
+
+// keyCacheDrainHook 返回连接池的排空回调：目标被排空或移除时将其上的 entry 降为 COOL 并发出迁移解锁事件。
+func keyCacheDrainHook(store *keycache.Store) func(enclaveID string) {
+	return func(enclaveID string) {
+		store.InvalidateEnclave(enclaveID, keyCacheDrainReason)
+	}
+}
+
+// newDisabledKeys 构造停用表；开启 key cache 时停用/删除同时从 Store 清除该 key 的 entry 并清零明文。
+func newDisabledKeys(keyCache *keycache.Store) *signerapi.DisabledKeys {
+	if keyCache == nil {
+		return signerapi.NewDisabledKeys(nil)
+	}
+	return signerapi.NewDisabledKeys(keyCache)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	signerapi "github.com/aegis-sign/wallet/internal/api"
+	"github.com/aegis-sign/wallet/internal/api/admin"
+	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/aegis-sign/wallet/internal/infra/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func newTestKeyCache(t *testing.T) *keycache.Store {
+	t.Helper()
+	t.Setenv("SIGNER_KEY_CACHE", "true")
+	store, err := configureKeyCache(slog.New(slog.NewTextHandler(io.Discard, nil)), prometheus.NewRegistry(), metricsopts.Options{})
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	return store
+}
+
+func putWarmEntry(t *testing.T, store *keycache.Store, keyID, enclave string) *keycache.Entry {
+	t.Helper()
+	e, err := keycache.NewEntry(keycache.EntryConfig{
+		KeyID:       keyID,
+		Enclave:     enclave,
+		Keyspace:    "prod",
+		HasPlainKey: true,
+		CipherBlob:  []byte("blob"),
+	})
+	require.NoError(t, err)
+	store.Put(e)
+	return e
+}
+
+func TestConfigureKeyCacheDisabled(t *testing.T) {
+	store, err := configureKeyCache(slog.Default(), prometheus.NewRegistry(), metricsopts.Options{})
+	require.NoError(t, err)
+	require.Nil(t, store)
+}
+
+func TestKeyCacheDrainHookCoolsDrainedEnclave(t *testing.T) {
+	store := newTestKeyCache(t)
+	drained := putWarmEntry(t, store, "k1", "enclave-a")
+	other := putWarmEntry(t, store, "k2", "enclave-b")
+
+	cfg := enclaveclient.DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	pool, err := enclaveclient.NewPool(cfg,
+		enclaveclient.WithRegisterer(prometheus.NewRegistry()),
+		enclaveclient.WithDrainHook(keyCacheDrainHook(store)),
+		enclaveclient.WithDialer(func(context.Context, enclaveclient.Target, enclaveclient.Config) (*grpc.ClientConn, error) {
+			return grpc.NewClient("passthrough:///unused", grpc.WithTransportCredentials(insecure.NewCredentials()))
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	pool.RegisterTarget(enclaveclient.Target{ID: "enclave-a", Endpoint: "unused"})
+	pool.RegisterTarget(enclaveclient.Target{ID: "enclave-b", Endpoint: "unused"})
+
+	require.NoError(t, pool.Drain("enclave-a"))
+	require.Equal(t, keycache.StateCool, drained.State())
+	require.Equal(t, keycache.StateWarm, other.State())
+	require.Equal(t, map[string]int{"enclave-a": 1, "enclave-b": 1}, store.CountByEnclave())
+}
+
+func TestAdminKeyCacheEndpoints(t *testing.T) {
+	store := newTestKeyCache(t)
+	k1 := putWarmEntry(t, store, "k1", "enclave-a")
+	k2 := putWarmEntry(t, store, "k2", "enclave-b")
+
+	credentials := filepath.Join(t.TempDir(), "admin.json")
+	require.NoError(t, os.WriteFile(credentials, []byte(`{"apiKeys":[{"subject":"ops","key":"admin-key","roles":["admin"]}]}`), 0o600))
+	t.Setenv("SIGNER_ADMIN_CREDENTIALS_FILE", credentials)
+	t.Setenv("SIGNER_ADMIN_ADDR", "127.0.0.1:0")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	routes := signerapi.NewRoutes()
+	_, spec, err := configureAdminAPI(routes, logger, admin.Config{
+		Role:      "admin",
+		KeyCache:  store,
+		Reloaders: map[string]admin.Reloader{},
+		Logger:    logger,
+	}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, spec)
+
+	mgr := newHTTPManager(logger, server.DefaultConfig().HTTP, server.TLSConfig{}, routes)
+	require.NoError(t, mgr.Listen([]listenerSpec{*spec}))
+	mgr.Serve(func(err error) { t.Errorf("serve: %v", err) })
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, mgr.Shutdown(ctx))
+	})
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	post := func(path, key, body string) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, "http://"+mgr.Addrs()[0].String()+admin.Prefix+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if key != "" {
+			req.Header.Set(signerapi.APIKeyHeader, key)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		raw, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, strings.TrimSpace(string(raw))
+	}
+
+	code, _ := post("/keycache/flush", "", "")
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	code, body := post("/keycache/flush", "admin-key", "")
+	require.Equal(t, http.StatusOK, code, body)
+	require.JSONEq(t, `{"action":"flush","affected":2}`, body)
+	require.Equal(t, keycache.StateCool, k1.State())
+	require.Equal(t, keycache.StateCool, k2.State())
+
+	code, body = post("/keycache/invalidate", "admin-key", `{"keyIds":["k2","missing"]}`)
+	require.Equal(t, http.StatusOK, code, body)
+	require.JSONEq(t, `{"action":"invalidate","affected":1}`, body)
+	require.Equal(t, keycache.StateInvalid, k2.State())
+
+	// INVALID 的 key 不会被安排刷新。
+	code, body = post("/keycache/refresh", "admin-key", `{"keyIds":["k1","k2"]}`)
+	require.Equal(t, http.StatusOK, code, body)
+	require.JSONEq(t, `{"action":"refresh","affected":1}`, body)
+}
+
+func TestDisabledKeysPurgeKeyCache(t *testing.T) {
+	store := newTestKeyCache(t)
+	disabled := putWarmEntry(t, store, "k1", "enclave-a")
+	kept := putWarmEntry(t, store, "k2", "enclave-a")
+	var forwarded []string
+	enclave := signerapi.BackendFuncs{
+		DisableFunc: func(_ context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+			forwarded = append(forwarded, req.GetKeyId())
+			return &signerv1.DisableKeyResponse{KeyId: req.GetKeyId()}, nil
+		},
+	}
+	backend := signerapi.Chain(enclave, signerapi.KeyDisableMiddleware(newDisabledKeys(store)))
+
+	_, err := backend.DisableKey(context.Background(), &signerv1.DisableKeyRequest{KeyId: "k1"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"k1"}, forwarded)
+	_, ok := store.Get("k1")
+	require.False(t, ok)
+	require.Equal(t, keycache.StateInvalid, disabled.State())
+	require.Equal(t, keycache.StateWarm, kept.State())
+
+	// 未开启 key cache 时停用只拦截签名并转发，不触及 Store。
+	backend = signerapi.Chain(enclave, signerapi.KeyDisableMiddleware(newDisabledKeys(nil)))
+	_, err = backend.DisableKey(context.Background(), &signerv1.DisableKeyRequest{KeyId: "k2"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"k1", "k2"}, forwarded)
+	require.Equal(t, keycache.StateWarm, kept.State())
+}
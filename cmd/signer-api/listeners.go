@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	signerapi "github.com/aegis-sign/wallet/internal/api"
+	"github.com/aegis-sign/wallet/internal/infra/server"
+)
+
+// listenerSpec 描述一个 HTTP 监听器：地址、可选 TLS 与暴露的路由组。
+type listenerSpec struct {
+	server.Endpoint
+	CertFile string
+	KeyFile  string
+	Routes   []signerapi.RouteSet
+}
+
+// parseListenerSpecs 解析 SIGNER_HTTP_LISTENERS，条目以 ";" 分隔，格式为
+// `addr|routes[|cert,key]`，如 `:8080|public,internal,debug;unix:///run/signer.sock|public;vsock://:8080|public`。
+// raw 为空时退化为 fallbackAddr 上暴露全部路由组；internalAddr 非空时（显式开启）fallbackAddr 只暴露 public，
+// internal 与 debug 改在 internalAddr 上暴露。两个地址同样支持 unix:// 与 vsock://。
+func parseListenerSpecs(raw, fallbackAddr, internalAddr string) ([]listenerSpec, error) {
+	if strings.TrimSpace(raw) == "" {
+		ep, err := server.ParseEndpoint(fallbackAddr)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(internalAddr) == "" {
+			return []listenerSpec{{Endpoint: ep, Routes: signerapi.AllRouteSets()}}, nil
+		}
+		internal, err := server.ParseEndpoint(internalAddr)
+		if err != nil {
+			return nil, fmt.Errorf("internal listener: %w", err)
+		}
+		return []listenerSpec{
+			{Endpoint: ep, Routes: []signerapi.RouteSet{signerapi.RoutePublic}},
+			{Endpoint: internal, Routes: []signerapi.RouteSet{signerapi.RouteInternal, signerapi.RouteDebug}},
+		}, nil
+	}
+	var specs []listenerSpec
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.Split(entry, "|")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("invalid listener entry: %s", entry)
+		}
+		ep, err := server.ParseEndpoint(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("listener %s: %w", entry, err)
+		}
+		spec := listenerSpec{Endpoint: ep}
+		routes, err := signerapi.ParseRouteSets(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("listener %s: %w", spec, err)
+		}
+		spec.Routes = routes
+		if len(fields) == 3 {
+			cert, key, found := strings.Cut(fields[2], ",")
+			if !found || strings.TrimSpace(cert) == "" || strings.TrimSpace(key) == "" {
+				return nil, fmt.Errorf("listener %s: tls must be cert,key", spec)
+			}
+			spec.CertFile, spec.KeyFile = strings.TrimSpace(cert), strings.TrimSpace(key)
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("no listener provided")
+	}
+	return specs, nil
+}
+
+type managedServer struct {
+	spec listenerSpec
+	srv  *http.Server
+	lis  net.Listener
+	tls  bool
+}
+
+// httpManager 管理多个共享同一路由表的 HTTP 监听器。
+type httpManager struct {
+	logger    *slog.Logger
+	cfg       server.HTTPConfig
+	tls       server.TLSConfig
+	routes    *signerapi.Routes
+	servers   []*managedServer
+	reloaders []*server.CertReloader
+}
+
+// newHTTPManager 构造管理器；tlsCfg 作用于未单独配置证书的 TCP 监听器，unix socket 与 vsock 不启用 TLS。
+func newHTTPManager(logger *slog.Logger, cfg server.HTTPConfig, tlsCfg server.TLSConfig, routes *signerapi.Routes) *httpManager {
+	return &httpManager{logger: logger, cfg: cfg, tls: tlsCfg, routes: routes}
+}
+
+// listenerTLS 返回 spec 适用的 TLS 配置；监听器自带的证书沿用全局的客户端证书校验设置。
+func (m *httpManager) listenerTLS(spec listenerSpec) (server.TLSConfig, bool) {
+	if spec.CertFile != "" {
+		cfg := m.tls
+		cfg.CertFile, cfg.KeyFile = spec.CertFile, spec.KeyFile
+		return cfg, true
+	}
+	return m.tls, spec.Network == "tcp" && m.tls.Enabled()
+}
+
+// Listen 为 spec 绑定地址；任一失败时关闭已绑定的监听器。
+func (m *httpManager) Listen(specs []listenerSpec) error {
+	for _, spec := range specs {
+		lis, err := server.Listen(spec.Endpoint)
+		if err != nil {
+			m.closeListeners()
+			return fmt.Errorf("listen %s: %w", spec, err)
+		}
+		srv := server.NewHTTPServer(lis.Addr().String(), signerapi.RequestIDMiddleware(signerapi.HTTPTracingMiddleware(m.routes.Mux(spec.Routes...))), m.cfg)
+		tlsCfg, useTLS := m.listenerTLS(spec)
+		if useTLS {
+			reloader, err := server.NewCertReloader(tlsCfg, m.logger)
+			if err != nil {
+				_ = lis.Close()
+				m.closeListeners()
+				return fmt.Errorf("listener %s: %w", spec, err)
+			}
+			nextProtos := []string{"h2", "http/1.1"}
+			if m.cfg.DisableHTTP2 {
+				nextProtos = []string{"http/1.1"}
+			}
+			srv.TLSConfig = reloader.ServerConfig(nextProtos...)
+			m.reloaders = append(m.reloaders, reloader)
+		}
+		m.servers = append(m.servers, &managedServer{
+			spec: spec,
+			srv:  srv,
+			lis:  server.LimitListener(lis, m.cfg.MaxConns),
+			tls:  useTLS,
+		})
+	}
+	return nil
+}
+
+// Serve 在后台启动全部监听器，意外退出时调用 onError。
+func (m *httpManager) Serve(onError func(error)) {
+	for _, s := range m.servers {
+		go func(s *managedServer) {
+			m.logger.Info("HTTP server listening", "addr", s.spec.String(), "routes", s.spec.Routes, "tls", s.tls)
+			var err error
+			if s.tls {
+				// 证书由 TLSConfig 按握手动态提供，以支持热更新。
+				err = s.srv.ServeTLS(s.lis, "", "")
+			} else {
+				err = s.srv.Serve(s.lis)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				onError(fmt.Errorf("http server %s: %w", s.spec, err))
+			}
+		}(s)
+	}
+}
+
+// RunCertReload 为各 TLS 监听器轮询证书变更，直到 ctx 结束。
+func (m *httpManager) RunCertReload(ctx context.Context) {
+	for _, r := range m.reloaders {
+		go r.Run(ctx)
+	}
+}
+
+// Shutdown 并发关闭全部监听器，共享 ctx 的截止时间。
+func (m *httpManager) Shutdown(ctx context.Context) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, s := range m.servers {
+		wg.Add(1)
+		go func(s *managedServer) {
+			defer wg.Done()
+			if err := s.srv.Shutdown(ctx); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", s.spec, err))
+				mu.Unlock()
+			}
+			// Serve 尚未启动时 Shutdown 不会关闭监听器。
+			_ = s.lis.Close()
+		}(s)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Addrs 返回实际绑定的地址，便于测试使用 :0 端口。
+func (m *httpManager) Addrs() []net.Addr {
+	addrs := make([]net.Addr, len(m.servers))
+	for i, s := range m.servers {
+		addrs[i] = s.lis.Addr()
+	}
+	return addrs
+}
+
+func (m *httpManager) closeListeners() {
+	for _, s := range m.servers {
+		_ = s.lis.Close()
+	}
+	m.servers = nil
+}
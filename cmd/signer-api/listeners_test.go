@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	signerapi "github.com/aegis-sign/wallet/internal/api"
+	"github.com/aegis-sign/wallet/internal/infra/server"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPManagerRouteSetsPerListener(t *testing.T) {
+	routes := signerapi.NewRoutes()
+	routes.Group(signerapi.RoutePublic).HandleFunc("/version", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	routes.Group(signerapi.RouteDebug).HandleFunc("/debug/unlock", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mgr := newHTTPManager(slog.New(slog.NewTextHandler(io.Discard, nil)), server.DefaultConfig().HTTP, server.TLSConfig{}, routes)
+	require.NoError(t, mgr.Listen([]listenerSpec{
+		{Endpoint: server.Endpoint{Network: "tcp", Addr: "127.0.0.1:0"}, Routes: signerapi.AllRouteSets()},
+		{Endpoint: server.Endpoint{Network: "tcp", Addr: "127.0.0.1:0"}, Routes: []signerapi.RouteSet{signerapi.RoutePublic}},
+	}))
+	mgr.Serve(func(err error) { t.Errorf("serve: %v", err) })
+
+	addrs := mgr.Addrs()
+	// 不复用连接：残留的 keep-alive 连接会拖慢 Shutdown。
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func(i int, path string) int {
+		resp, err := client.Get("http://" + addrs[i].String() + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	require.Equal(t, http.StatusOK, get(0, "/debug/unlock"))
+	require.Equal(t, http.StatusNotFound, get(1, "/debug/unlock"))
+	require.Equal(t, http.StatusOK, get(1, "/version"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, mgr.Shutdown(ctx))
+	_, err := client.Get("http://" + addrs[0].String() + "/version")
+	require.Error(t, err)
+}
+
+func TestParseListenerSpecs(t *testing.T) {
+	specs, err := parseListenerSpecs("", ":8080", "")
+	require.NoError(t, err)
+	require.Len(t, specs, 1)
+	require.Equal(t, signerapi.AllRouteSets(), specs[0].Routes)
+
+	// 设置 SIGNER_HTTP_INTERNAL_ADDR 后，运维与排障路由移到独立地址。
+	specs, err = parseListenerSpecs("", ":8080", "127.0.0.1:8081")
+	require.NoError(t, err)
+	require.Len(t, specs, 2)
+	require.Equal(t, []signerapi.RouteSet{signerapi.RoutePublic}, specs[0].Routes)
+	require.Equal(t, "127.0.0.1:8081", specs[1].Addr)
+	require.Equal(t, []signerapi.RouteSet{signerapi.RouteInternal, signerapi.RouteDebug}, specs[1].Routes)
+
+	specs, err = parseListenerSpecs("10.0.0.1:8080|public,internal,debug|/tls/cert.pem,/tls/key.pem; unix:///run/signer.sock|public", ":8080", "")
+	require.NoError(t, err)
+	require.Len(t, specs, 2)
+	require.Equal(t, "/tls/cert.pem", specs[0].CertFile)
+	require.Equal(t, "unix", specs[1].Network)
+	require.Equal(t, "/run/signer.sock", specs[1].Addr)
+	require.Equal(t, []signerapi.RouteSet{signerapi.RoutePublic}, specs[1].Routes)
+
+	specs, err = parseListenerSpecs("127.0.0.1:9091|admin", ":8080", "")
+	require.NoError(t, err)
+	require.Equal(t, []signerapi.RouteSet{signerapi.RouteAdmin}, specs[0].Routes)
+
+	_, err = parseListenerSpecs(":8080|ops", ":8080", "")
+	require.Error(t, err)
+	_, err = parseListenerSpecs(":8080", ":8080", "")
+	require.Error(t, err)
+}
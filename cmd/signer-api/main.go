@@ -2,96 +2,605 @@ package main
 
 import (
 	"context"
+	"crypto/x509"
 	"fmt"
 	"log/slog"
 	"net"
-	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
-	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
 	signerapi "github.com/aegis-sign/wallet/internal/api"
+	"github.com/aegis-sign/wallet/internal/api/admin"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/aegis-sign/wallet/internal/app/backend/keyusage"
+	"github.com/aegis-sign/wallet/internal/audit"
 	"github.com/aegis-sign/wallet/internal/gateway/unlock"
 	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+	"github.com/aegis-sign/wallet/internal/infra/envcompat"
 	"github.com/aegis-sign/wallet/internal/infra/kms"
 	"github.com/aegis-sign/wallet/internal/infra/kms/mockkms"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/aegis-sign/wallet/internal/infra/server"
+	"github.com/aegis-sign/wallet/internal/infra/tracing"
+	"github.com/aegis-sign/wallet/internal/policy"
+	"github.com/aegis-sign/wallet/internal/status"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
+)
+
+// version 与 commit 通过 -ldflags "-X main.version=... -X main.commit=..." 注入。
+var (
+	version = "dev"
+	commit  = ""
 )
 
 func main() {
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	// 上下文中带请求 ID 的日志自动附加 request_id，便于与 Enclave 日志关联。
+	logger := slog.New(reqctx.NewLogHandler(slog.NewTextHandler(os.Stdout, nil)))
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	backend, poolCloser, err := configureEnclaveBackend(logger)
+	// 兼容旧变量名须在读取任何配置之前完成。
+	if _, err := envcompat.Apply(envcompat.Config{Strict: envBool("SIGNER_ENV_STRICT", false), Logger: logger}); err != nil {
+		logger.Error("invalid environment", "error", err)
+		os.Exit(1)
+	}
+	metricsOpts, err := metricsOptionsFromEnv()
+	if err != nil {
+		logger.Error("invalid metrics options", "error", err)
+		os.Exit(1)
+	}
+	// 传播器总是安装，未配置 collector 时仍把上游 traceparent 透传给 Enclave。
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Endpoint:       os.Getenv("SIGNER_TRACING_ENDPOINT"),
+		Insecure:       envBool("SIGNER_TRACING_INSECURE", false),
+		SampleRatio:    envFloat("SIGNER_TRACING_SAMPLE_RATIO", 0.01),
+		ServiceName:    envOrDefault("SIGNER_TRACING_SERVICE_NAME", "signer-api"),
+		ServiceVersion: version,
+	})
+	if err != nil {
+		logger.Error("failed to configure tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			logger.Warn("tracing shutdown failed", "error", err)
+		}
+	}()
+	// 全部模块的指标注册到同一个 registry，由 /metrics 一次性暴露。
+	registry := newMetricsRegistry()
+	keyCache, err := configureKeyCache(logger, registry, metricsOpts)
+	if err != nil {
+		logger.Error("failed to configure key cache", "error", err)
+		os.Exit(1)
+	}
+	enclaves, err := configureEnclaveBackend(logger, registry, metricsOpts, keyCache)
 	if err != nil {
 		logger.Error("failed to configure enclave backend", "error", err)
 		os.Exit(1)
 	}
-	defer poolCloser()
-
-	unlockResponder, unlockDispatcher, unlockCleanup, err := configureUnlockSystem(logger)
+	defer enclaves.Close()
+	backendMetrics, err := signerapi.NewBackendMetricsWithOptions(registry, metricsOpts)
+	if err != nil {
+		logger.Error("failed to register backend metrics", "error", err)
+		os.Exit(1)
+	}
+	backend := signerapi.Chain(enclaves.backend,
+		signerapi.LoggingMiddleware(logger),
+		signerapi.MetricsMiddleware(backendMetrics),
+		signerapi.TimeoutMiddleware(envDuration("SIGNER_CALL_TIMEOUT_MS", 2*time.Second)),
+		// 位于最内层，span 只覆盖 Enclave 调用本身，与入口 span 的差值即排队与中间件耗时。
+		signerapi.TracingMiddleware(),
+	)
+	// 只读开关只作用于业务入口，自检仍可为新 Enclave 创建金丝雀 key。
+	readOnly := signerapi.NewReadOnlyMode(envBool("SIGNER_READ_ONLY", false))
+	keyUsageMetrics, err := keyusage.NewMetricsWithOptions(registry, metricsOpts)
+	if err != nil {
+		logger.Error("failed to register key usage metrics", "error", err)
+		os.Exit(1)
+	}
+	keyUsage := keyusage.NewTracker(keyusage.Config{
+		MaxKeys:          envInt("SIGNER_KEY_USAGE_MAX_KEYS", 100000),
+		SnapshotPath:     os.Getenv("SIGNER_KEY_USAGE_SNAPSHOT_PATH"),
+		SnapshotInterval: envDuration("SIGNER_KEY_USAGE_SNAPSHOT_INTERVAL_MS", 5*time.Minute),
+		Metrics:          keyUsageMetrics,
+		Logger:           logger,
+	})
+	if err := keyUsage.Load(); err != nil {
+		logger.Warn("key usage snapshot not restored", "error", err)
+	}
+	go keyUsage.Run(ctx)
+	defer func() {
+		if err := keyUsage.Save(); err != nil {
+			logger.Warn("key usage snapshot failed", "error", err)
+		}
+	}()
+	// 影子镜像只作用于业务入口，自检流量不会被转发。
+	mirror, err := signerapi.NewMirror(signerapi.MirrorConfig{
+		ShadowURL:      os.Getenv("SIGNER_SHADOW_URL"),
+		SampleRate:     envFloat("SIGNER_SHADOW_SAMPLE_RATE", 0.01),
+		Timeout:        envDuration("SIGNER_SHADOW_TIMEOUT_MS", 2*time.Second),
+		Logger:         logger,
+		Registerer:     registry,
+		MetricsOptions: metricsOpts,
+	})
+	if err != nil {
+		logger.Error("failed to configure shadow mirroring", "error", err)
+		os.Exit(1)
+	}
+	defer mirror.Close()
+	auditor, err := configureAuditor(os.Getenv("SIGNER_AUDIT_SINKS"))
+	if err != nil {
+		logger.Error("failed to configure audit sinks", "error", err)
+		os.Exit(1)
+	}
+	if auditor != nil {
+		defer auditor.Close()
+	}
+	tenantPolicy, err := configurePolicy()
+	if err != nil {
+		logger.Error("failed to load tenant policy", "error", err)
+		os.Exit(1)
+	}
+	tenantRates, err := signerapi.ParseTenantRateOverrides(os.Getenv("SIGNER_TENANT_RATE_OVERRIDES"))
+	if err != nil {
+		logger.Error("invalid tenant rate overrides", "error", err)
+		os.Exit(1)
+	}
+	signLimiter, err := signerapi.NewSignRateLimiter(signerapi.SignRateLimitConfig{
+		Tenant:          signerapi.RateLimit{Rate: envFloat("SIGNER_TENANT_RATE_LIMIT", 0), Burst: envInt("SIGNER_TENANT_RATE_BURST", 0)},
+		TenantOverrides: tenantRates,
+		Key:             signerapi.RateLimit{Rate: envFloat("SIGNER_KEY_RATE_LIMIT", 0), Burst: envInt("SIGNER_KEY_RATE_BURST", 0)},
+		Registerer:      registry,
+		MetricsOptions:  metricsOpts,
+	})
+	if err != nil {
+		logger.Error("failed to configure sign rate limiting", "error", err)
+		os.Exit(1)
+	}
+	tenantQuotas, err := signerapi.ParseTenantQuotaOverrides(os.Getenv("SIGNER_TENANT_QUOTA_OVERRIDES"))
+	if err != nil {
+		logger.Error("invalid tenant quota overrides", "error", err)
+		os.Exit(1)
+	}
+	accounting, err := signerapi.NewSignAccounting(signerapi.SignAccountingConfig{
+		Enabled:         envBool("SIGNER_USAGE_ACCOUNTING", true),
+		Window:          envDuration("SIGNER_USAGE_WINDOW_MS", time.Hour),
+		TenantQuota:     int64(envInt("SIGNER_TENANT_QUOTA", 0)),
+		TenantOverrides: tenantQuotas,
+		KeyQuota:        int64(envInt("SIGNER_KEY_QUOTA", 0)),
+		MaxKeys:         envInt("SIGNER_USAGE_MAX_KEYS", 100000),
+		Registerer:      registry,
+		MetricsOptions:  metricsOpts,
+	})
+	if err != nil {
+		logger.Error("failed to configure usage accounting", "error", err)
+		os.Exit(1)
+	}
+	idemCfg := signerapi.IdempotencyConfig{Logger: logger, Registerer: registry, MetricsOptions: metricsOpts}
+	if envBool("SIGNER_IDEMPOTENCY_ENABLED", true) {
+		idemCfg.Store = signerapi.NewMemoryIdempotencyStore(
+			envDuration("SIGNER_IDEMPOTENCY_TTL_MS", 24*time.Hour),
+			envInt("SIGNER_IDEMPOTENCY_MAX_KEYS", 100000),
+		)
+	}
+	idempotency, err := signerapi.NewIdempotency(idemCfg)
+	if err != nil {
+		logger.Error("failed to configure create idempotency", "error", err)
+		os.Exit(1)
+	}
+	// WatchUnlock 与 /unlock/events 订阅 Dispatcher 回传的解锁终态。
+	var unlockWatcher *signerapi.UnlockWatcher
+	var unlockSink keycache.ResultSink
+	if envBool("UNLOCK_WATCH", true) {
+		unlockWatcher = signerapi.NewUnlockWatcher(signerapi.UnlockWatcherConfig{
+			Retention:   envDuration("UNLOCK_WATCH_RETENTION_MS", 5*time.Second),
+			MaxWait:     envDuration("UNLOCK_WATCH_MAX_WAIT_MS", 30*time.Second),
+			MaxWatchers: envInt("UNLOCK_WATCH_MAX_WATCHERS", 4096),
+		})
+		unlockSink = unlockWatcher
+	}
+	unlockDispatcher, kmsClient, unlockCleanup, err := configureUnlockSystem(logger, registry, metricsOpts, unlockSink)
 	if err != nil {
 		logger.Warn("unlock dispatcher disabled", "error", err)
+		unlockWatcher = nil
 	} else if unlockCleanup != nil {
 		defer unlockCleanup()
 	}
+	drainer := signerapi.NewDrainer(signerapi.DrainConfig{
+		Timeout: envDuration("SIGNER_DRAIN_TIMEOUT_MS", 30*time.Second),
+		Queue:   nilIfTyped[signerapi.UnlockQueueDepth](unlockDispatcher),
+	})
+	// 审计与导入审计位于最外层，只读模式、租户策略与限流拒绝的调用同样留痕。
+	apiBackend := signerapi.Chain(backend,
+		signerapi.AuditMiddleware(signerapi.AuditConfig{
+			Auditor:    auditor,
+			FailClosed: envBool("SIGNER_AUDIT_FAIL_CLOSED", false),
+			Logger:     logger,
+		}),
+		signerapi.DrainMiddleware(drainer),
+		signerapi.PolicyMiddleware(signerapi.PolicyConfig{Policy: tenantPolicy, Logger: logger}),
+		signerapi.KeyspaceMiddleware(keyspaceConfig()),
+		signerapi.RateLimitMiddleware(signLimiter),
+		signerapi.AccountingMiddleware(accounting),
+		// 幂等重放位于只读模式之前，重试的 Create 在只读期间仍能取回首次生成的 key。
+		signerapi.IdempotencyMiddleware(idempotency),
+		signerapi.ImportMiddleware(signerapi.ImportConfig{
+			RateLimit: envFloat("SIGNER_IMPORT_RATE_LIMIT", 5),
+			RateBurst: envInt("SIGNER_IMPORT_RATE_BURST", 5),
+			Logger:    logger,
+		}),
+		signerapi.ReadOnlyMiddleware(readOnly),
+		signerapi.KeyDisableMiddleware(newDisabledKeys(keyCache)),
+		signerapi.UsageMiddleware(keyUsage),
+		signerapi.MirrorMiddleware(mirror),
+	)
+
+	hintCfg := signerapi.RetryHintConfig{
+		Pool:    enclaves.pool,
+		Queue:   nilIfTyped[signerapi.UnlockQueueStats](unlockDispatcher),
+		Limiter: nilIfTyped[signerapi.RateLimitStats](unlockDispatcher),
+		Min:     envDuration("SIGNER_RETRY_HINT_MIN_MS", 50*time.Millisecond),
+		Max:     envDuration("SIGNER_RETRY_HINT_MAX_MS", 5*time.Second),
+	}
+	var unlockResponder *signerapi.UnlockResponder
+	var unlockStatus signerapi.HTTPOption
 	if unlockDispatcher != nil {
+		unlockStatus = signerapi.WithUnlockStatus(unlockDispatcher)
 		keycache.SetUnlockNotifier(unlock.NewDispatcherNotifier(unlockDispatcher))
 	} else {
 		keycache.SetUnlockNotifier(nil)
 	}
-
-	// HTTP server wiring
-	mux := http.NewServeMux()
-	signerapi.NewHTTPHandler(backend, unlockResponder).Register(mux)
+	retryHints := signerapi.NewRetryHintProvider(hintCfg)
 	if unlockDispatcher != nil {
-		mux.Handle("/debug/unlock", unlockDispatcher.DebugHandler())
-	}
-	httpSrv := &http.Server{
-		Addr:    envOrDefault("SIGNER_HTTP_ADDR", ":8080"),
-		Handler: mux,
+		unlockResponder = newUnlockResponder(unlockDispatcher, retryHints)
 	}
 
-	go func() {
-		logger.Info("HTTP server listening", "addr", httpSrv.Addr)
-		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("http server closed unexpectedly", "error", err)
-			stop()
+	// HTTP 路由按 RouteSet 分组，各监听器从同一批 handler 实例中选择暴露面。
+	routes := signerapi.NewRoutes()
+	httpMetrics, err := signerapi.NewHTTPMetricsWithOptions(registry, metricsOpts)
+	if err != nil {
+		logger.Error("failed to register http metrics", "error", err)
+		os.Exit(1)
+	}
+	batchCfg := signerapi.BatchConfig{
+		MaxItems:       envInt("SIGNER_BATCH_MAX_ITEMS", 64),
+		Concurrency:    envInt("SIGNER_BATCH_CONCURRENCY", 16),
+		StreamMaxItems: envInt("SIGNER_BATCH_STREAM_MAX_ITEMS", 100000),
+	}
+	legacyRoutes := signerapi.LegacyRoutesConfig{Disabled: !envBool("SIGNER_HTTP_LEGACY_ROUTES", true)}
+	if raw := os.Getenv("SIGNER_HTTP_LEGACY_SUNSET"); raw != "" {
+		if legacyRoutes.Sunset, err = time.Parse(time.RFC3339, raw); err != nil {
+			logger.Error("invalid SIGNER_HTTP_LEGACY_SUNSET, want RFC 3339", "value", raw, "error", err)
+			os.Exit(1)
 		}
-	}()
+	}
+	var compression signerapi.HTTPOption
+	if envBool("SIGNER_HTTP_COMPRESSION", true) {
+		compression = signerapi.WithCompression(signerapi.CompressionConfig{
+			MinSize: envInt("SIGNER_HTTP_COMPRESSION_MIN_BYTES", 1024),
+		})
+	}
+	authCredentials, err := configureCredentials("SIGNER_AUTH_CREDENTIALS_FILE")
+	if err != nil {
+		logger.Error("failed to load auth credentials", "error", err)
+		os.Exit(1)
+	}
+	authVerifier := nilIfTyped[signerapi.TokenVerifier](authCredentials)
+	reloaders := map[string]admin.Reloader{}
+	if authCredentials != nil {
+		reloaders["credentials"] = authCredentials.Reload
+	}
+	interceptors, err := grpcInterceptors(logger, registry, metricsOpts, authVerifier)
+	if err != nil {
+		logger.Error("invalid gRPC interceptors", "error", err)
+		os.Exit(1)
+	}
+	grpcHandler := signerapi.NewGRPCServer(apiBackend, unlockResponder)
+	grpcHandler.SetRetryHints(retryHints)
+	grpcHandler.SetBatchConfig(batchCfg)
+	grpcHandler.SetUnlockWatcher(unlockWatcher)
+	// 备份导出默认关闭；开启后仅限管理员角色，且每次尝试都必须落审计。
+	if envBool("SIGNER_BACKUP_EXPORT", false) {
+		if auditor == nil {
+			logger.Warn("key backup export requires SIGNER_AUDIT_SINKS, export stays disabled")
+		} else {
+			grpcHandler.SetKeyBackup(signerapi.NewKeyBackup(signerapi.KeyBackupConfig{
+				Exporter:  enclaves.backend,
+				AdminRole: os.Getenv("SIGNER_BACKUP_ADMIN_ROLE"),
+				Policy:    tenantPolicy,
+				Auditor:   auditor,
+				Logger:    logger,
+			}))
+		}
+	}
+	// /v2 由 proto 服务描述派生，/v1 中对应 SignerService 方法的路由同样经网关，直接复用 gRPC 实现与拦截器链，保证契约一致。
+	var gateway signerapi.HTTPOption
+	if envBool("SIGNER_HTTP_GATEWAY", true) {
+		gateway = signerapi.WithGateway(grpcHandler, interceptors.gateway)
+	}
+	// /ws/sign 映射到同一 SignStream，共享流许可与 in-flight 窗口。
+	var websocket signerapi.HTTPOption
+	if envBool("SIGNER_HTTP_WEBSOCKET", true) {
+		websocket = signerapi.WithWebSocket(grpcHandler, signerapi.WebSocketConfig{
+			MaxMessageBytes: int64(envInt("SIGNER_HTTP_WEBSOCKET_MAX_MESSAGE_BYTES", 64<<10)),
+			PingInterval:    envDuration("SIGNER_HTTP_WEBSOCKET_PING_INTERVAL_MS", 30*time.Second),
+			WriteTimeout:    envDuration("SIGNER_HTTP_WEBSOCKET_WRITE_TIMEOUT_MS", 10*time.Second),
+			AllowedOrigins:  strings.Split(os.Getenv("SIGNER_HTTP_WEBSOCKET_ALLOWED_ORIGINS"), ","),
+		})
+	}
+	httpHandler := signerapi.NewHTTPHandler(apiBackend,
+		signerapi.WithUnlockResponder(unlockResponder),
+		signerapi.WithBatchConfig(batchCfg),
+		signerapi.WithBodyLimits(signerapi.BodyLimits{
+			MaxBytes:           int64(envInt("SIGNER_HTTP_MAX_BODY_BYTES", 256<<10)),
+			BatchMaxBytes:      int64(envInt("SIGNER_HTTP_MAX_BATCH_BODY_BYTES", 1<<20)),
+			StreamMaxBytes:     int64(envInt("SIGNER_HTTP_MAX_STREAM_BODY_BYTES", 32<<20)),
+			AllowUnknownFields: envBool("SIGNER_HTTP_ALLOW_UNKNOWN_FIELDS", false),
+		}),
+		compression,
+		signerapi.WithLegacyRoutes(legacyRoutes),
+		gateway,
+		websocket,
+		signerapi.WithUnlockWatcher(unlockWatcher),
+		unlockStatus,
+		signerapi.WithRetryHints(retryHints),
+		signerapi.WithLogger(logger),
+		signerapi.WithMetrics(httpMetrics),
+	)
+	// 认证只覆盖业务路由，探针、版本与内部管理路由仍按监听器隔离。
+	httpHandler.Register(signerapi.RequireAuth(routes.Group(signerapi.RoutePublic), authVerifier))
+	statusHandler := signerapi.NewStatusHandler(signerapi.BuildInfo{Version: version, Commit: commit}, readOnly)
+	readiness := signerapi.ReadinessConfig{
+		Pool:            enclaves.pool,
+		QueueSaturation: envFloat("SIGNER_READY_QUEUE_SATURATION", 0.9),
+		Queue:           nilIfTyped[signerapi.UnlockQueueCapacity](unlockDispatcher),
+		KMS:             nilIfTyped[signerapi.KMSHealthSource](kmsClient),
+		Drain:           drainer,
+	}
+	statusHandler.SetReadiness(readiness)
+	statusHandler.Register(routes.Group(signerapi.RoutePublic))
+	internalRoutes := routes.Group(signerapi.RouteInternal)
+	internalRoutes.Handle("/admin/readonly", readOnly)
+	internalRoutes.Handle("/admin/drain", drainer)
+	internalRoutes.Handle("/admin/keys/idle", keyUsage.IdleHandler())
+	if accounting != nil {
+		internalRoutes.Handle("/admin/usage", accounting.Handler())
+	}
+	internalRoutes.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry}))
+	debugRoutes := routes.Group(signerapi.RouteDebug)
+	debugRoutes.Handle("/debug/enclaves", enclaves.pool.DebugHandler())
+	if unlockDispatcher != nil {
+		debugRoutes.Handle("/debug/unlock", unlockDispatcher.DebugHandler())
+	}
+	selfChecker, err := signerapi.NewSelfChecker(backend, signerapi.SelfCheckConfig{
+		Targets:  enclaves.targetIDs,
+		Interval: envDuration("SIGNER_SELFCHECK_INTERVAL_MS", 30*time.Second),
+		Curve:    envOrDefault("SIGNER_SELFCHECK_CURVE", "secp256k1"),
+	})
+	if err != nil {
+		logger.Warn("selfcheck disabled", "error", err)
+	} else {
+		internalRoutes.Handle("/selfcheck", selfChecker)
+		if enclaves.watcher != nil {
+			enclaves.watcher.Subscribe(func(ts []enclaveclient.Target) { selfChecker.SetTargets(targetIDs(ts)) })
+		}
+	}
+	if enclaves.watcher != nil {
+		go enclaves.watcher.Run(ctx)
+	}
+	if enclaves.autoscaler != nil {
+		go enclaves.autoscaler.Run(ctx)
+	}
+	internalRoutes.Handle("/admin/status", status.NewCollector(status.Config{
+		Version:    version,
+		Commit:     commit,
+		Pool:       enclaves.pool,
+		Dispatcher: nilIfTyped[status.DispatcherSource](unlockDispatcher),
+		KMS:        nilIfTyped[status.KMSSource](kmsClient),
+		MaxKeys:    envInt("SIGNER_STATUS_MAX_KEYS", 100),
+	}))
+	adminAPI, adminListener, err := configureAdminAPI(routes, logger, admin.Config{
+		Role:    os.Getenv("SIGNER_ADMIN_ROLE"),
+		Pool:    enclaves.pool,
+		Routing: enclaves.selector,
+		// 动态发现开启时路由成员由 watcher 维护，管理 API 不能增删。
+		DiscoveryManaged: enclaves.watcher != nil,
+		OnTargetsChanged: func(ids []string) {
+			if selfChecker != nil {
+				selfChecker.SetTargets(ids)
+			}
+		},
+		Drain:     drainer,
+		Reloaders: reloaders,
+		// 未开启 SIGNER_KEY_CACHE 时为 nil，/admin/v1/keycache 系列端点不注册。
+		KeyCache: keyCache,
+		Logger:   logger,
+	}, unlockDispatcher)
+	if err != nil {
+		logger.Error("failed to configure admin api", "error", err)
+		os.Exit(1)
+	}
+	serverCfg := server.LoadConfigFromEnv()
+	listenerSpecs, err := parseListenerSpecs(os.Getenv("SIGNER_HTTP_LISTENERS"), envOrDefault("SIGNER_HTTP_ADDR", ":8080"), os.Getenv("SIGNER_HTTP_INTERNAL_ADDR"))
+	if err != nil {
+		logger.Error("invalid SIGNER_HTTP_LISTENERS", "error", err)
+		os.Exit(1)
+	}
+	if adminListener != nil {
+		listenerSpecs = append(listenerSpecs, *adminListener)
+	}
+	adminGRPC, adminGRPCListener, err := configureAdminGRPC(ctx, logger, adminAPI, serverCfg)
+	if err != nil {
+		logger.Error("failed to configure admin gRPC", "error", err)
+		os.Exit(1)
+	}
+	httpServers := newHTTPManager(logger, serverCfg.HTTP, serverCfg.TLS, routes)
+	if err := httpServers.Listen(listenerSpecs); err != nil {
+		logger.Error("failed to listen for HTTP", "error", err)
+		os.Exit(1)
+	}
+	httpServers.RunCertReload(ctx)
+	httpServers.Serve(func(err error) {
+		logger.Error("http server closed unexpectedly", "error", err)
+		stop()
+	})
 
 	// gRPC server wiring (primarily for integration tests)
 	grpcAddr := envOrDefault("SIGNER_GRPC_ADDR", ":9090")
-	lis, err := net.Listen("tcp", grpcAddr)
+	grpcEndpoint, err := server.ParseEndpoint(grpcAddr)
+	if err != nil {
+		logger.Error("invalid SIGNER_GRPC_ADDR", "error", err)
+		os.Exit(1)
+	}
+	lis, err := server.Listen(grpcEndpoint)
 	if err != nil {
 		logger.Error("failed to listen for gRPC", "error", err)
 		os.Exit(1)
 	}
-	grpcSrv := grpc.NewServer()
-	signerv1.RegisterSignerServiceServer(grpcSrv, signerapi.NewGRPCServer(backend, unlockResponder))
+	lis = server.LimitListener(lis, serverCfg.GRPC.MaxConns)
+	grpcOpts := append(server.GRPCServerOptions(serverCfg.GRPC), interceptors.server...)
+	// 与 HTTP 一致，unix socket 与 vsock 上不启用 TLS。
+	grpcTLS := serverCfg.TLS.Enabled() && grpcEndpoint.Network == "tcp"
+	if grpcTLS {
+		grpcCerts, err := server.NewCertReloader(serverCfg.TLS, logger)
+		if err != nil {
+			logger.Error("failed to load gRPC TLS certificate", "error", err)
+			os.Exit(1)
+		}
+		go grpcCerts.Run(ctx)
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(grpcCerts.ServerConfig("h2"))))
+	}
+	grpcSrv := grpc.NewServer(grpcOpts...)
+	// SignStream 共享许可按连接池总容量（MaxConns × Enclave 数）× 倍数估算，倍数 <=0 关闭背压。
+	streamPermits := 0
+	if multiplier := envFloat("SIGNER_STREAM_PERMIT_MULTIPLIER", 2); multiplier > 0 {
+		streamPermits = max(1, int(float64(enclaves.pool.Config().MaxConns*len(enclaves.targetIDs))*multiplier))
+	}
+	grpcHandler.SetStreamLimiter(signerapi.NewStreamLimiter(signerapi.StreamLimiterConfig{
+		Permits:           streamPermits,
+		StreamMaxInFlight: envInt("SIGNER_STREAM_MAX_INFLIGHT", 32),
+		Registerer:        registry,
+		MetricsOptions:    metricsOpts,
+	}))
+	signerv1.RegisterSignerServiceServer(grpcSrv, grpcHandler)
+	// 健康检查供 Kubernetes gRPC 探针使用，反射便于 grpcurl 调试。
+	healthReporter := signerapi.NewHealthReporter(signerapi.HealthConfig{
+		Pool:     enclaves.pool,
+		Interval: envDuration("SIGNER_GRPC_HEALTH_INTERVAL_MS", 5*time.Second),
+	})
+	healthReporter.Register(grpcSrv)
+	go healthReporter.Run(ctx)
+	if envBool("SIGNER_GRPC_REFLECTION", true) {
+		reflection.Register(grpcSrv)
+	}
 	go func() {
-		logger.Info("gRPC server listening", "addr", grpcAddr)
+		logger.Info("gRPC server listening", "addr", grpcEndpoint.String(), "tls", grpcTLS)
 		if err := grpcSrv.Serve(lis); err != nil {
 			logger.Error("grpc server closed unexpectedly", "error", err)
 			stop()
 		}
 	}()
 
+	if adminGRPC != nil {
+		go func() {
+			logger.Info("admin gRPC server listening", "addr", adminGRPCListener.Addr().String())
+			if err := adminGRPC.Serve(adminGRPCListener); err != nil {
+				logger.Error("admin grpc server closed unexpectedly", "error", err)
+				stop()
+			}
+		}()
+	}
+
 	<-ctx.Done()
 	logger.Info("shutting down servers")
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	if err := httpSrv.Shutdown(shutdownCtx); err != nil {
+	if err := httpServers.Shutdown(shutdownCtx); err != nil {
 		logger.Error("http shutdown error", "error", err)
 	}
+	healthReporter.Shutdown()
 	grpcSrv.GracefulStop()
+	if adminGRPC != nil {
+		adminGRPC.GracefulStop()
+	}
+}
+
+// configurePolicy 按 SIGNER_POLICY_FILE 加载租户策略，未设置时返回 nil（不做租户校验）。
+func configurePolicy() (*policy.Policy, error) {
+	path := strings.TrimSpace(os.Getenv("SIGNER_POLICY_FILE"))
+	if path == "" {
+		return nil, nil
+	}
+	return policy.Load(path)
+}
+
+// configureAuditor 按逗号分隔的 sink 列表构造审计器，空串表示不启用。
+// kafka sink 需由嵌入方提供 audit.Producer，signer-api 本身不内置 Kafka 客户端。
+func configureAuditor(raw string) (audit.Auditor, error) {
+	var sinks audit.Multi
+	for _, name := range strings.Split(raw, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "":
+		case "stdout":
+			sinks = append(sinks, audit.NewWriterAuditor(os.Stdout))
+		case "file":
+			fileSink, err := audit.NewFileAuditor(audit.FileConfig{
+				Path: os.Getenv("SIGNER_AUDIT_FILE"),
+				Sync: envBool("SIGNER_AUDIT_FILE_SYNC", false),
+			})
+			if err != nil {
+				_ = sinks.Close()
+				return nil, err
+			}
+			sinks = append(sinks, fileSink)
+		case "kafka":
+			_ = sinks.Close()
+			return nil, fmt.Errorf("audit sink %q requires an audit.Producer and is not built into signer-api", name)
+		default:
+			_ = sinks.Close()
+			return nil, fmt.Errorf("unknown audit sink %q", name)
+		}
+	}
+	switch len(sinks) {
+	case 0:
+		return nil, nil
+	case 1:
+		return sinks[0], nil
+	}
+	return sinks, nil
+}
+
+// newMetricsRegistry 构造进程内共享的 registry，附带 Go 运行时与进程指标。
+func newMetricsRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return registry
+}
+
+// nilIfTyped 将 v 转为接口类型 T；v 为 nil 指针时返回 nil 接口。
+// 可选组件以具体指针类型构造，直接赋给接口字段会得到非 nil 的 typed nil，下游的 != nil 判断会把缺失的组件当作有效来源。
+func nilIfTyped[T any](v T) T {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		var zero T
+		return zero
+	}
+	return v
 }
 
 func envOrDefault(key, def string) string {
@@ -101,19 +610,32 @@ func envOrDefault(key, def string) string {
 	return def
 }
 
-func configureUnlockSystem(logger *slog.Logger) (*signerapi.UnlockResponder, *unlock.Dispatcher, func(), error) {
+func configureUnlockSystem(logger *slog.Logger, registry prometheus.Registerer, metricsOpts metricsopts.Options, sink keycache.ResultSink) (*unlock.Dispatcher, *kms.Client, func(), error) {
 	maxQueue := envInt("UNLOCK_MAX_QUEUE", 2048)
 	workers := envInt("UNLOCK_WORKERS", 16)
 	rateLimit := envFloat("UNLOCK_RATE_LIMIT", 0)
 	rateBurst := envInt("UNLOCK_RATE_BURST", 1)
 	cfg := unlock.Config{
-		MaxQueue:  maxQueue,
-		Workers:   workers,
-		RateLimit: rateLimit,
-		RateBurst: rateBurst,
-		Logger:    logger,
+		MaxQueue:    maxQueue,
+		Workers:     workers,
+		RateLimit:   rateLimit,
+		RateBurst:   rateBurst,
+		Logger:      logger,
+		WALPath:     os.Getenv("UNLOCK_WAL_PATH"),
+		WALSync:     unlock.WALSyncPolicy(os.Getenv("UNLOCK_WAL_SYNC")),
+		WALMaxBytes: int64(envInt("UNLOCK_WAL_MAX_BYTES", 0)),
+
+		RetryHorizonMin: envDuration("UNLOCK_RETRY_HORIZON_MIN_MS", 0),
+		RetryHorizonMax: envDuration("UNLOCK_RETRY_HORIZON_MAX_MS", 0),
+		ResultSink:      sink,
+
+		StatusRetention:  envDuration("UNLOCK_STATUS_RETENTION_MS", 0),
+		StatusMaxEntries: envInt("UNLOCK_STATUS_MAX_ENTRIES", 0),
+
+		MetricsOptions: metricsOpts,
+		Registerer:     registry,
 	}
-	executor, execErr := configureKMSEnclaveExecutor(logger)
+	executor, kmsClient, execErr := configureKMSEnclaveExecutor(logger)
 	if execErr != nil {
 		logger.Warn("unlock executor fallback to noop", "error", execErr)
 		executor = unlock.NewNoopExecutor(logger)
@@ -122,29 +644,189 @@ func configureUnlockSystem(logger *slog.Logger) (*signerapi.UnlockResponder, *un
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	responder := signerapi.NewUnlockResponder(signerapi.UnlockResponderConfig{
-		Queue:    dispatcher,
-		Keyspace: envOrDefault("UNLOCK_KEYSPACE", "default"),
-		MinRetry: envDuration("UNLOCK_RETRY_MIN_MS", 50*time.Millisecond),
-		MaxRetry: envDuration("UNLOCK_RETRY_MAX_MS", 200*time.Millisecond),
+	shutdownTimeout := envDuration("UNLOCK_SHUTDOWN_TIMEOUT_MS", 10*time.Second)
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		report := dispatcher.Shutdown(ctx)
+		attrs := []any{"executed", report.Executed, "failed", report.Failed, "remaining", report.Remaining}
+		if report.Remaining > 0 {
+			logger.Warn("unlock dispatcher stopped with pending jobs", append(attrs, "remaining_keys", report.RemainingKeys)...)
+			return
+		}
+		logger.Info("unlock dispatcher drained", attrs...)
+	}
+	return dispatcher, kmsClient, cleanup, nil
+}
+
+// grpcInterceptorSet 为 gRPC 服务端选项与 HTTP 网关（/v1、/v2）复用的 unary 拦截器链。
+type grpcInterceptorSet struct {
+	server  []grpc.ServerOption
+	gateway grpc.UnaryServerInterceptor
+}
+
+// grpcInterceptors 登记内置拦截器并按 SIGNER_GRPC_INTERCEPTORS 的顺序组装；
+// auth 仅在设置 SIGNER_GRPC_AUTH_TOKENS 或凭证文件时登记，未登记却被引用会在启动时报错。
+func grpcInterceptors(logger *slog.Logger, reg prometheus.Registerer, metricsOpts metricsopts.Options, verifier signerapi.TokenVerifier) (grpcInterceptorSet, error) {
+	grpcMetrics, err := signerapi.NewGRPCMetricsWithOptions(reg, metricsOpts)
+	if err != nil {
+		return grpcInterceptorSet{}, err
+	}
+	registry := signerapi.NewInterceptorRegistry()
+	registry.Register(signerapi.InterceptorRequestID, signerapi.RequestIDInterceptor())
+	registry.Register(signerapi.InterceptorTracing, signerapi.TracingInterceptor())
+	registry.Register(signerapi.InterceptorRecovery, signerapi.RecoveryInterceptor(logger))
+	registry.Register(signerapi.InterceptorLogging, signerapi.LoggingInterceptor(logger))
+	registry.Register(signerapi.InterceptorMetrics, signerapi.MetricsInterceptor(grpcMetrics))
+	var verifiers signerapi.Verifiers
+	if raw := os.Getenv("SIGNER_GRPC_AUTH_TOKENS"); raw != "" {
+		tokens, err := signerapi.ParseAuthTokens(raw)
+		if err != nil {
+			return grpcInterceptorSet{}, err
+		}
+		static, err := signerapi.NewStaticTokenVerifier(tokens)
+		if err != nil {
+			return grpcInterceptorSet{}, err
+		}
+		verifiers = append(verifiers, static)
+	}
+	if verifier != nil {
+		verifiers = append(verifiers, verifier)
+	}
+	if len(verifiers) > 0 {
+		registry.Register(signerapi.InterceptorAuth, signerapi.AuthInterceptor(signerapi.MetadataAuthenticator(verifiers)))
+	}
+	raw := os.Getenv("SIGNER_GRPC_INTERCEPTORS")
+	names := signerapi.ParseInterceptorNames(raw)
+	if verifier != nil {
+		if strings.TrimSpace(raw) == "" {
+			names = append([]string(nil), signerapi.AuthenticatedGRPCInterceptors...)
+		} else if !slices.Contains(names, signerapi.InterceptorAuth) {
+			// 凭证文件启用时拒绝以未认证的 gRPC 入口启动。
+			return grpcInterceptorSet{}, fmt.Errorf("SIGNER_AUTH_CREDENTIALS_FILE is set but SIGNER_GRPC_INTERCEPTORS omits %q", signerapi.InterceptorAuth)
+		}
+	}
+	var set grpcInterceptorSet
+	if set.server, err = registry.ServerOptions(names); err != nil {
+		return grpcInterceptorSet{}, err
+	}
+	// 网关路由已由 HTTP 的 RequireAuth 认证，网关链路去掉 auth，避免重复校验并接受仅限 gRPC 的静态 token。
+	gatewayNames := slices.DeleteFunc(slices.Clone(names), func(name string) bool { return name == signerapi.InterceptorAuth })
+	if set.gateway, err = registry.UnaryChain(gatewayNames); err != nil {
+		return grpcInterceptorSet{}, err
+	}
+	return set, nil
+}
+
+// configureCredentials 从 name 指向的凭证文件构造可重新加载的 verifier，未设置时返回 nil。
+func configureCredentials(name string) (*signerapi.CredentialsReloader, error) {
+	path := strings.TrimSpace(os.Getenv(name))
+	if path == "" {
+		return nil, nil
+	}
+	return signerapi.NewCredentialsReloader(path)
+}
+
+// configureAdminAPI 在 admin 路由组注册管理 API，并在设置 SIGNER_ADMIN_ADDR 时返回其专用监听器；
+// 未设置 SIGNER_ADMIN_CREDENTIALS_FILE 时不启用管理 API，返回的 API 为 nil。
+// 管理 API 必须使用独立凭证：设置了监听地址却缺少 SIGNER_ADMIN_CREDENTIALS_FILE 时启动失败。
+func configureAdminAPI(routes *signerapi.Routes, logger *slog.Logger, cfg admin.Config, dispatcher *unlock.Dispatcher) (*admin.API, *listenerSpec, error) {
+	addr := strings.TrimSpace(os.Getenv("SIGNER_ADMIN_ADDR"))
+	credentials, err := configureCredentials("SIGNER_ADMIN_CREDENTIALS_FILE")
+	if err != nil {
+		return nil, nil, err
+	}
+	if credentials == nil {
+		if addr != "" {
+			return nil, nil, fmt.Errorf("SIGNER_ADMIN_ADDR requires SIGNER_ADMIN_CREDENTIALS_FILE")
+		}
+		return nil, nil, nil
+	}
+	cfg.Verifier = credentials
+	cfg.Reloaders["admin_credentials"] = credentials.Reload
+	cfg.Dispatcher = nilIfTyped[admin.DispatcherSource](dispatcher)
+	api, err := admin.New(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	api.Register(routes.Group(signerapi.RouteAdmin))
+	if addr == "" {
+		logger.Info("admin api is only served on listeners that declare the admin route set")
+		return api, nil, nil
+	}
+	ep, err := server.ParseEndpoint(addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid SIGNER_ADMIN_ADDR: %w", err)
+	}
+	return api, &listenerSpec{Endpoint: ep, Routes: []signerapi.RouteSet{signerapi.RouteAdmin}}, nil
+}
+
+// configureAdminGRPC 在设置 SIGNER_ADMIN_GRPC_ADDR 时于独立监听器提供 gRPC AdminService，
+// 凭证与角色检查同管理 HTTP API；管理 API 未启用（缺少 SIGNER_ADMIN_CREDENTIALS_FILE）时启动失败。
+func configureAdminGRPC(ctx context.Context, logger *slog.Logger, api *admin.API, serverCfg server.Config) (*grpc.Server, net.Listener, error) {
+	addr := strings.TrimSpace(os.Getenv("SIGNER_ADMIN_GRPC_ADDR"))
+	if addr == "" {
+		return nil, nil, nil
+	}
+	if api == nil {
+		return nil, nil, fmt.Errorf("SIGNER_ADMIN_GRPC_ADDR requires SIGNER_ADMIN_CREDENTIALS_FILE")
+	}
+	ep, err := server.ParseEndpoint(addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid SIGNER_ADMIN_GRPC_ADDR: %w", err)
+	}
+	opts := append(server.GRPCServerOptions(serverCfg.GRPC), api.GRPCServerOptions()...)
+	// 与业务 gRPC 一致，unix socket 与 vsock 上不启用 TLS。
+	if serverCfg.TLS.Enabled() && ep.Network == "tcp" {
+		certs, err := server.NewCertReloader(serverCfg.TLS, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("admin gRPC TLS: %w", err)
+		}
+		go certs.Run(ctx)
+		opts = append(opts, grpc.Creds(credentials.NewTLS(certs.ServerConfig("h2"))))
+	}
+	lis, err := server.Listen(ep)
+	if err != nil {
+		return nil, nil, err
+	}
+	srv := grpc.NewServer(opts...)
+	api.RegisterGRPC(srv)
+	return srv, lis, nil
+}
+
+// keyspaceConfig 默认按凭证租户划分 keyspace，UNLOCK_KEYSPACE 仅作为未认证或无租户请求的回退值。
+func keyspaceConfig() signerapi.KeyspaceConfig {
+	return signerapi.KeyspaceConfig{
+		Default:   envOrDefault("UNLOCK_KEYSPACE", signerapi.DefaultKeyspace),
+		PerTenant: envBool("SIGNER_TENANT_KEYSPACES", true),
+	}
+}
+
+func newUnlockResponder(dispatcher *unlock.Dispatcher, hints *signerapi.RetryHintProvider) *signerapi.UnlockResponder {
+	keyspaces := keyspaceConfig()
+	return signerapi.NewUnlockResponder(signerapi.UnlockResponderConfig{
+		Queue:             dispatcher,
+		Keyspace:          keyspaces.Default,
+		PerTenantKeyspace: keyspaces.PerTenant,
+		MinRetry:          envDuration("UNLOCK_RETRY_MIN_MS", 50*time.Millisecond),
+		MaxRetry:          envDuration("UNLOCK_RETRY_MAX_MS", 200*time.Millisecond),
+		Hints:             hints,
 	})
-	cleanup := func() { dispatcher.Close() }
-	return responder, dispatcher, cleanup, nil
 }
 
-func configureKMSEnclaveExecutor(logger *slog.Logger) (unlock.Executor, error) {
+func configureKMSEnclaveExecutor(logger *slog.Logger) (unlock.Executor, *kms.Client, error) {
 	mockKey := os.Getenv("UNLOCK_KMS_MOCK_KEY")
 	if strings.TrimSpace(mockKey) == "" {
-		return nil, fmt.Errorf("UNLOCK_KMS_MOCK_KEY not set")
+		return nil, nil, fmt.Errorf("UNLOCK_KMS_MOCK_KEY not set")
 	}
 	provider := mockkms.NewStaticProvider([]byte(mockKey))
 	attestor := mockkms.NewStaticAttestor(nil)
 	client, err := kms.NewClient(provider, attestor, kms.Config{Logger: logger})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	executor := unlock.NewKMSEnclaveExecutor(client, logger)
-	return executor, nil
+	return executor, client, nil
 }
 
 func envInt(key string, def int) int {
@@ -165,6 +847,15 @@ func envFloat(key string, def float64) float64 {
 	return def
 }
 
+func envBool(key string, def bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if parsed, err := strconv.ParseBool(v); err == nil {
+			return parsed
+		}
+	}
+	return def
+}
+
 func envDuration(key string, def time.Duration) time.Duration {
 	if v := os.Getenv(key); v != "" {
 		if parsed, err := strconv.Atoi(v); err == nil {
@@ -174,31 +865,244 @@ func envDuration(key string, def time.Duration) time.Duration {
 	return def
 }
 
-func configureEnclaveBackend(logger *slog.Logger) (signerapi.Backend, func(), error) {
-	targets, err := parseEnclaveTargets(os.Getenv("SIGNER_ENCLAVES"))
+// enclaveStack 汇总 Enclave 连接池及其上层 backend，供 main 组装其他组件。
+type enclaveStack struct {
+	pool     *enclaveclient.Pool
+	backend  *signerapi.EnclaveBackend
+	selector *signerapi.StickySelector
+	// watcher 在启用动态发现时非 nil，由 main 订阅并启动。
+	watcher *enclaveclient.TargetWatcher
+	// autoscaler 在启用连接上限自适应伸缩时非 nil。
+	autoscaler *enclaveclient.Autoscaler
+	// targetIDs 为启动时的目标集合。
+	targetIDs []string
+}
+
+// Close 关闭底层连接池。
+func (s *enclaveStack) Close() {
+	_ = s.pool.Close()
+}
+
+// metricsOptionsFromEnv 读取 SIGNER_METRICS_NAMESPACE / SIGNER_METRICS_CONST_LABELS，
+// 未设置时各模块保持原有指标名。
+func metricsOptionsFromEnv() (metricsopts.Options, error) {
+	labels, err := metricsopts.ParseConstLabels(os.Getenv("SIGNER_METRICS_CONST_LABELS"))
 	if err != nil {
-		return nil, func() {}, fmt.Errorf("failed to parse SIGNER_ENCLAVES: %w", err)
+		return metricsopts.Options{}, fmt.Errorf("SIGNER_METRICS_CONST_LABELS: %w", err)
+	}
+	return metricsopts.Options{
+		Namespace:   strings.TrimSpace(os.Getenv("SIGNER_METRICS_NAMESPACE")),
+		ConstLabels: labels,
+	}, nil
+}
+
+// configureEnclaveBackend 构造连接池、路由与 Backend；keyCache 非 nil 时目标排空或移除会失效其上的 entry。
+func configureEnclaveBackend(logger *slog.Logger, registry prometheus.Registerer, metricsOpts metricsopts.Options, keyCache *keycache.Store) (*enclaveStack, error) {
+	source, err := enclaveTargetSource()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure SIGNER_ENCLAVE_DISCOVERY: %w", err)
+	}
+	var targets []enclaveclient.Target
+	if source == nil {
+		targets, err = parseEnclaveTargets(os.Getenv("SIGNER_ENCLAVES"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SIGNER_ENCLAVES: %w", err)
+		}
+	}
+	weights, err := signerapi.ParseTargetWeights(os.Getenv("SIGNER_ENCLAVE_WEIGHTS"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SIGNER_ENCLAVE_WEIGHTS: %w", err)
+	}
+	// 静态目标下权重 ID 须存在，尽早发现拼写错误；动态发现时允许为尚未出现的目标预置权重。
+	if source == nil {
+		for id := range weights {
+			if !slices.Contains(targetIDs(targets), id) {
+				return nil, fmt.Errorf("SIGNER_ENCLAVE_WEIGHTS: unknown enclave target %q", id)
+			}
+		}
+	}
+	attestation, err := enclaveAttestationFromEnv()
+	if err != nil {
+		return nil, err
 	}
 	poolCfg := enclaveclient.LoadConfigFromEnv()
-	pool, err := enclaveclient.NewPool(poolCfg, enclaveclient.WithLogger(logger))
+	poolOpts := []enclaveclient.Option{
+		enclaveclient.WithLogger(logger),
+		enclaveclient.WithRegisterer(registry),
+		enclaveclient.WithMetricsOptions(metricsOpts),
+		enclaveclient.WithAttestation(attestation),
+		enclaveclient.WithOutlierDetection(enclaveclient.LoadOutlierConfigFromEnv()),
+	}
+	if keyCache != nil {
+		poolOpts = append(poolOpts, enclaveclient.WithDrainHook(keyCacheDrainHook(keyCache)))
+	}
+	pool, err := enclaveclient.NewPool(poolCfg, poolOpts...)
 	if err != nil {
-		return nil, func() {}, err
+		return nil, err
+	}
+	var watcher *enclaveclient.TargetWatcher
+	if source != nil {
+		watcher, err = enclaveclient.NewTargetWatcher(pool, enclaveclient.WatcherConfig{
+			Source:   source,
+			Interval: envDuration("SIGNER_ENCLAVE_DISCOVERY_INTERVAL_MS", 30*time.Second),
+			Logger:   logger,
+		})
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		// 首次发现须成功，否则没有可路由的目标。
+		syncCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = watcher.Sync(syncCtx)
+		cancel()
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("initial enclave discovery: %w", err)
+		}
+		targets = watcher.Targets()
+	} else {
+		for _, target := range targets {
+			pool.RegisterTarget(target)
+		}
+	}
+	ids := targetIDs(targets)
+	selectorOpts := []signerapi.StickySelectorOption{
+		signerapi.WithVirtualNodes(envInt("SIGNER_STICKY_VNODES", signerapi.DefaultVirtualNodes)),
+		signerapi.WithTargetWeights(weights),
 	}
-	for _, target := range targets {
-		pool.RegisterTarget(target)
+	if envBool("SIGNER_STICKY_FAILOVER", true) {
+		selectorOpts = append(selectorOpts, signerapi.WithTargetHealth(pool))
 	}
-	selector, err := signerapi.NewStickySelector(targetIDs(targets))
+	selector, err := signerapi.NewStickySelector(ids, selectorOpts...)
 	if err != nil {
 		pool.Close()
-		return nil, func() {}, err
+		return nil, err
 	}
-	backend, err := signerapi.NewEnclaveBackend(pool, selector)
+	budget := signerapi.NewLatencyBudget(signerapi.LatencyBudgetConfig{
+		Enabled:      envBool("SIGNER_DEADLINE_PRECHECK", false),
+		Alpha:        envFloat("SIGNER_DEADLINE_EWMA_ALPHA", 0.2),
+		SafetyMargin: envDuration("SIGNER_DEADLINE_SAFETY_MARGIN_MS", 0),
+		MinSamples:   envInt("SIGNER_DEADLINE_MIN_SAMPLES", 10),
+
+		Registerer:     registry,
+		MetricsOptions: metricsOpts,
+	})
+	hedger := signerapi.NewHedger(signerapi.HedgeConfig{
+		Enabled:     envBool("SIGNER_HEDGE_ENABLED", false),
+		Delay:       envDuration("SIGNER_HEDGE_DELAY_MS", 0),
+		Quantile:    envFloat("SIGNER_HEDGE_QUANTILE", 0.95),
+		MinDelay:    envDuration("SIGNER_HEDGE_MIN_DELAY_MS", 0),
+		MaxInFlight: envInt("SIGNER_HEDGE_MAX_IN_FLIGHT", 64),
+
+		Registerer:     registry,
+		MetricsOptions: metricsOpts,
+	})
+	retryCodes, err := signerapi.ParseRetryCodes(os.Getenv("SIGNER_RETRY_CODES"))
 	if err != nil {
 		pool.Close()
-		return nil, func() {}, err
+		return nil, fmt.Errorf("failed to parse SIGNER_RETRY_CODES: %w", err)
+	}
+	retrier := signerapi.NewRetrier(signerapi.RetryConfig{
+		Enabled:      envBool("SIGNER_RETRY_ENABLED", false),
+		MaxAttempts:  envInt("SIGNER_RETRY_MAX_ATTEMPTS", 3),
+		Codes:        retryCodes,
+		BudgetRatio:  envFloat("SIGNER_RETRY_BUDGET_RATIO", 0.1),
+		BudgetBurst:  envInt("SIGNER_RETRY_BUDGET_BURST", 10),
+		Backoff:      envDuration("SIGNER_RETRY_BACKOFF_MS", 10*time.Millisecond),
+		SignFailover: envBool("SIGNER_RETRY_SIGN_FAILOVER", false),
+
+		Registerer:     registry,
+		MetricsOptions: metricsOpts,
+	})
+	// 开启副本组时 Sign 跟随 key 的创建者，创建者不可用时改走副本；管理 API 仍管理 hash 环成员。
+	var routing signerapi.TargetSelector = selector
+	if envBool("SIGNER_REPLICA_GROUPS", false) {
+		routing, err = signerapi.NewReplicaSelector(selector, enclaveclient.NewReplicaGroup(pool), envInt("SIGNER_REPLICA_COUNT", 1))
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("failed to configure SIGNER_REPLICA_GROUPS: %w", err)
+		}
+	}
+	backend, err := signerapi.NewEnclaveBackend(pool, routing,
+		signerapi.WithLatencyBudget(budget),
+		signerapi.WithHedging(hedger),
+		signerapi.WithRetries(retrier),
+	)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if watcher != nil {
+		watcher.Subscribe(func(ts []enclaveclient.Target) {
+			if err := selector.SetTargets(targetIDs(ts)); err != nil {
+				logger.Warn("enclave routing not updated", "error", err)
+			}
+		})
+	}
+	autoscaleCfg := enclaveclient.LoadAutoscaleConfigFromEnv()
+	autoscaleCfg.Logger = logger
+	autoscaler := enclaveclient.NewAutoscaler(pool, autoscaleCfg)
+	return &enclaveStack{pool: pool, backend: backend, selector: selector, watcher: watcher, autoscaler: autoscaler, targetIDs: ids}, nil
+}
+
+// enclaveAttestationFromEnv 在 SIGNER_ENCLAVE_ATTESTATION=true 时以 Nitro 根证书与 PCR 白名单构造建连校验，
+// 未启用时返回零值（不校验）。
+func enclaveAttestationFromEnv() (enclaveclient.AttestationConfig, error) {
+	if !envBool("SIGNER_ENCLAVE_ATTESTATION", false) {
+		return enclaveclient.AttestationConfig{}, nil
+	}
+	rootFile := strings.TrimSpace(os.Getenv("SIGNER_ENCLAVE_ATTESTATION_ROOT_FILE"))
+	if rootFile == "" {
+		return enclaveclient.AttestationConfig{}, fmt.Errorf("SIGNER_ENCLAVE_ATTESTATION_ROOT_FILE is required when SIGNER_ENCLAVE_ATTESTATION is enabled")
+	}
+	pem, err := os.ReadFile(rootFile)
+	if err != nil {
+		return enclaveclient.AttestationConfig{}, fmt.Errorf("SIGNER_ENCLAVE_ATTESTATION_ROOT_FILE: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return enclaveclient.AttestationConfig{}, fmt.Errorf("SIGNER_ENCLAVE_ATTESTATION_ROOT_FILE: no certificates found")
+	}
+	pcrs, err := kms.ParsePCRAllowlist(os.Getenv("SIGNER_ENCLAVE_ATTESTATION_PCRS"))
+	if err != nil {
+		return enclaveclient.AttestationConfig{}, fmt.Errorf("SIGNER_ENCLAVE_ATTESTATION_PCRS: %w", err)
+	}
+	if len(pcrs) == 0 {
+		return enclaveclient.AttestationConfig{}, fmt.Errorf("SIGNER_ENCLAVE_ATTESTATION_PCRS is required when SIGNER_ENCLAVE_ATTESTATION is enabled")
+	}
+	return enclaveclient.AttestationConfig{
+		Verifier:   &kms.NitroVerifier{Roots: roots, PCRs: pcrs},
+		Timeout:    envDuration("SIGNER_ENCLAVE_ATTESTATION_TIMEOUT_MS", 2*time.Second),
+		Quarantine: envDuration("SIGNER_ENCLAVE_ATTESTATION_QUARANTINE_MS", time.Minute),
+	}, nil
+}
+
+// enclaveTargetSource 按 SIGNER_ENCLAVE_DISCOVERY（dns / file / k8s）构造动态发现来源，未设置时返回 nil。
+func enclaveTargetSource() (enclaveclient.TargetSource, error) {
+	mode := strings.TrimSpace(os.Getenv("SIGNER_ENCLAVE_DISCOVERY"))
+	target := strings.TrimSpace(os.Getenv("SIGNER_ENCLAVE_DISCOVERY_TARGET"))
+	if mode == "" {
+		return nil, nil
+	}
+	if target == "" {
+		return nil, fmt.Errorf("SIGNER_ENCLAVE_DISCOVERY_TARGET is required")
+	}
+	switch mode {
+	case "dns":
+		return enclaveclient.DNSSRVSource{Name: target}, nil
+	case "file":
+		return enclaveclient.FileSource{Path: target}, nil
+	case "k8s":
+		// 格式 [namespace/]service[:port-name]，namespace 缺省为 Pod 所在命名空间。
+		namespace, service, found := strings.Cut(target, "/")
+		if !found {
+			namespace, service = "", target
+		}
+		service, port, _ := strings.Cut(service, ":")
+		return enclaveclient.NewInClusterKubernetesSource(namespace, service, port)
+	default:
+		return nil, fmt.Errorf("unknown discovery mode %q (want dns, file or k8s)", mode)
 	}
-	cleanup := func() { _ = pool.Close() }
-	return backend, cleanup, nil
 }
 
 func parseEnclaveTargets(raw string) ([]enclaveclient.Target, error) {
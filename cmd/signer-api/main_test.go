@@ -0,0 +1,20 @@
+package main
+
+import (
+	"testing"
+
+	signerapi "github.com/aegis-sign/wallet/internal/api"
+	"github.com/aegis-sign/wallet/internal/gateway/unlock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNilIfTyped(t *testing.T) {
+	var dispatcher *unlock.Dispatcher
+	// testify 的 Nil 会把 typed nil 也视为 nil，这里直接与 nil 接口比较。
+	var typed signerapi.UnlockQueueDepth = dispatcher
+	require.True(t, typed != nil)
+	require.True(t, nilIfTyped[signerapi.UnlockQueueDepth](dispatcher) == nil)
+
+	readOnly := signerapi.NewReadOnlyMode(false)
+	require.Same(t, readOnly, nilIfTyped[any](readOnly))
+}
@@ -0,0 +1,230 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: admin.proto
+
+package signerv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	AdminService_ListTargets_FullMethodName    = "/signer.v1.AdminService/ListTargets"
+	AdminService_RegisterTarget_FullMethodName = "/signer.v1.AdminService/RegisterTarget"
+	AdminService_DrainTarget_FullMethodName    = "/signer.v1.AdminService/DrainTarget"
+	AdminService_RemoveTarget_FullMethodName   = "/signer.v1.AdminService/RemoveTarget"
+)
+
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdminServiceClient interface {
+	// ListTargets 返回路由成员与连接池目标。
+	ListTargets(ctx context.Context, in *ListTargetsRequest, opts ...grpc.CallOption) (*ListTargetsResponse, error)
+	// RegisterTarget 以 endpoint 重建目标，未知 id 作为新成员加入路由；
+	// 路由成员由动态发现维护时新增成员返回 FAILED_PRECONDITION。
+	RegisterTarget(ctx context.Context, in *RegisterTargetRequest, opts ...grpc.CallOption) (*AdminTarget, error)
+	// DrainTarget 摘除目标并关闭其连接，请求顺延到 hash 环上的下一个目标；未知 id 返回 NOT_FOUND。
+	DrainTarget(ctx context.Context, in *DrainTargetRequest, opts ...grpc.CallOption) (*AdminTarget, error)
+	// RemoveTarget 将目标移出路由并关闭其连接；不能移除最后一个路由成员。
+	RemoveTarget(ctx context.Context, in *RemoveTargetRequest, opts ...grpc.CallOption) (*AdminTarget, error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) ListTargets(ctx context.Context, in *ListTargetsRequest, opts ...grpc.CallOption) (*ListTargetsResponse, error) {
+	out := new(ListTargetsResponse)
+	err := c.cc.Invoke(ctx, AdminService_ListTargets_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) RegisterTarget(ctx context.Context, in *RegisterTargetRequest, opts ...grpc.CallOption) (*AdminTarget, error) {
+	out := new(AdminTarget)
+	err := c.cc.Invoke(ctx, AdminService_RegisterTarget_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) DrainTarget(ctx context.Context, in *DrainTargetRequest, opts ...grpc.CallOption) (*AdminTarget, error) {
+	out := new(AdminTarget)
+	err := c.cc.Invoke(ctx, AdminService_DrainTarget_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) RemoveTarget(ctx context.Context, in *RemoveTargetRequest, opts ...grpc.CallOption) (*AdminTarget, error) {
+	out := new(AdminTarget)
+	err := c.cc.Invoke(ctx, AdminService_RemoveTarget_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations must embed UnimplementedAdminServiceServer
+// for forward compatibility
+type AdminServiceServer interface {
+	// ListTargets 返回路由成员与连接池目标。
+	ListTargets(context.Context, *ListTargetsRequest) (*ListTargetsResponse, error)
+	// RegisterTarget 以 endpoint 重建目标，未知 id 作为新成员加入路由；
+	// 路由成员由动态发现维护时新增成员返回 FAILED_PRECONDITION。
+	RegisterTarget(context.Context, *RegisterTargetRequest) (*AdminTarget, error)
+	// DrainTarget 摘除目标并关闭其连接，请求顺延到 hash 环上的下一个目标；未知 id 返回 NOT_FOUND。
+	DrainTarget(context.Context, *DrainTargetRequest) (*AdminTarget, error)
+	// RemoveTarget 将目标移出路由并关闭其连接；不能移除最后一个路由成员。
+	RemoveTarget(context.Context, *RemoveTargetRequest) (*AdminTarget, error)
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+// UnimplementedAdminServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedAdminServiceServer struct {
+}
+
+func (UnimplementedAdminServiceServer) ListTargets(context.Context, *ListTargetsRequest) (*ListTargetsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTargets not implemented")
+}
+func (UnimplementedAdminServiceServer) RegisterTarget(context.Context, *RegisterTargetRequest) (*AdminTarget, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterTarget not implemented")
+}
+func (UnimplementedAdminServiceServer) DrainTarget(context.Context, *DrainTargetRequest) (*AdminTarget, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DrainTarget not implemented")
+}
+func (UnimplementedAdminServiceServer) RemoveTarget(context.Context, *RemoveTargetRequest) (*AdminTarget, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveTarget not implemented")
+}
+func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_ListTargets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTargetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListTargets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListTargets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListTargets(ctx, req.(*ListTargetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_RegisterTarget_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterTargetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).RegisterTarget(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_RegisterTarget_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).RegisterTarget(ctx, req.(*RegisterTargetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_DrainTarget_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainTargetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).DrainTarget(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_DrainTarget_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).DrainTarget(ctx, req.(*DrainTargetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_RemoveTarget_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveTargetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).RemoveTarget(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_RemoveTarget_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).RemoveTarget(ctx, req.(*RemoveTargetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "signer.v1.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListTargets",
+			Handler:    _AdminService_ListTargets_Handler,
+		},
+		{
+			MethodName: "RegisterTarget",
+			Handler:    _AdminService_RegisterTarget_Handler,
+		},
+		{
+			MethodName: "DrainTarget",
+			Handler:    _AdminService_DrainTarget_Handler,
+		},
+		{
+			MethodName: "RemoveTarget",
+			Handler:    _AdminService_RemoveTarget_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "admin.proto",
+}
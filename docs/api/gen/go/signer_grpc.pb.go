@@ -19,9 +19,17 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	SignerService_Create_FullMethodName     = "/signer.v1.SignerService/Create"
-	SignerService_Sign_FullMethodName       = "/signer.v1.SignerService/Sign"
-	SignerService_SignStream_FullMethodName = "/signer.v1.SignerService/SignStream"
+	SignerService_Create_FullMethodName          = "/signer.v1.SignerService/Create"
+	SignerService_ImportKey_FullMethodName       = "/signer.v1.SignerService/ImportKey"
+	SignerService_GetPublicKey_FullMethodName    = "/signer.v1.SignerService/GetPublicKey"
+	SignerService_Sign_FullMethodName            = "/signer.v1.SignerService/Sign"
+	SignerService_SignStream_FullMethodName      = "/signer.v1.SignerService/SignStream"
+	SignerService_BatchSign_FullMethodName       = "/signer.v1.SignerService/BatchSign"
+	SignerService_DisableKey_FullMethodName      = "/signer.v1.SignerService/DisableKey"
+	SignerService_SignTransaction_FullMethodName = "/signer.v1.SignerService/SignTransaction"
+	SignerService_WatchUnlock_FullMethodName     = "/signer.v1.SignerService/WatchUnlock"
+	SignerService_ExportKeyBackup_FullMethodName = "/signer.v1.SignerService/ExportKeyBackup"
+	SignerService_GetAttestation_FullMethodName  = "/signer.v1.SignerService/GetAttestation"
 )
 
 // SignerServiceClient is the client API for SignerService service.
@@ -29,8 +37,35 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type SignerServiceClient interface {
 	Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	// ImportKey 导入外部生成的私钥，响应与 Create 一致。
+	ImportKey(ctx context.Context, in *ImportKeyRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	// GetPublicKey 按 key_id 重新获取公钥与地址，不执行签名；响应与 Create 一致。
+	GetPublicKey(ctx context.Context, in *GetPublicKeyRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	// Sign 在返回 UNLOCK_REQUIRED 时会附带 metadata：
+	// - retry-after-ms: string (毫秒)
+	// - x-unlock-request-id: string
 	Sign(ctx context.Context, in *SignRequest, opts ...grpc.CallOption) (*SignResponse, error)
+	// SignStream 并发处理流内请求，单个请求失败（含共享许可耗尽时的 RETRY_LATER）
+	// 以带 error 的 SignResponse 返回，不中断流。
 	SignStream(ctx context.Context, opts ...grpc.CallOption) (SignerService_SignStreamClient, error)
+	// BatchSign 一次提交多条 digest，按 key_id 分组保持粘性路由并经连接池并发处理；
+	// 单项失败以带 error 的 SignResponse 返回，仅条数为 0 或超限时整体返回 INVALID_ARGUMENT。
+	BatchSign(ctx context.Context, in *BatchSignRequest, opts ...grpc.CallOption) (*BatchSignResponse, error)
+	// DisableKey 立即拒绝该 key 的后续签名、清除父机 key cache，并转发至所属 Enclave 停用或删除；
+	// 可重复调用，转发失败时本地停用状态保持不变。
+	DisableKey(ctx context.Context, in *DisableKeyRequest, opts ...grpc.CallOption) (*DisableKeyResponse, error)
+	// SignTransaction 在服务端解析以太坊交易并计算签名哈希，签名后返回序列化的已签名交易；
+	// 交易非法或 chainId 不一致返回 INVALID_ARGUMENT，其余错误语义与 Sign 相同。
+	SignTransaction(ctx context.Context, in *SignTransactionRequest, opts ...grpc.CallOption) (*SignTransactionResponse, error)
+	// WatchUnlock 订阅 key 的后台解锁结果：任务成功或最终失败时推送一条 UnlockEvent 后结束流，
+	// 订阅前刚完成的结果立即返回；超过服务端等待上限返回 DEADLINE_EXCEEDED。
+	WatchUnlock(ctx context.Context, in *WatchUnlockRequest, opts ...grpc.CallOption) (SignerService_WatchUnlockClient, error)
+	// ExportKeyBackup 仅限管理员：从 key 所属 Enclave 取回加密的 key blob 与包裹元数据，用于复制到备用区域；
+	// 永不返回明文，reason 必填并写入审计，审计写入失败时不返回备份。
+	ExportKeyBackup(ctx context.Context, in *ExportKeyBackupRequest, opts ...grpc.CallOption) (*ExportKeyBackupResponse, error)
+	// GetAttestation 仅由 Enclave 实现：返回绑定请求 nonce 的 Nitro attestation 文档，
+	// 父机连接池在交付新连接前据此校验 Enclave 的度量值（PCR）。
+	GetAttestation(ctx context.Context, in *AttestationRequest, opts ...grpc.CallOption) (*AttestationResponse, error)
 }
 
 type signerServiceClient struct {
@@ -50,6 +85,24 @@ func (c *signerServiceClient) Create(ctx context.Context, in *CreateRequest, opt
 	return out, nil
 }
 
+func (c *signerServiceClient) ImportKey(ctx context.Context, in *ImportKeyRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	out := new(CreateResponse)
+	err := c.cc.Invoke(ctx, SignerService_ImportKey_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *signerServiceClient) GetPublicKey(ctx context.Context, in *GetPublicKeyRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	out := new(CreateResponse)
+	err := c.cc.Invoke(ctx, SignerService_GetPublicKey_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *signerServiceClient) Sign(ctx context.Context, in *SignRequest, opts ...grpc.CallOption) (*SignResponse, error) {
 	out := new(SignResponse)
 	err := c.cc.Invoke(ctx, SignerService_Sign_FullMethodName, in, out, opts...)
@@ -90,13 +143,117 @@ func (x *signerServiceSignStreamClient) Recv() (*SignResponse, error) {
 	return m, nil
 }
 
+func (c *signerServiceClient) BatchSign(ctx context.Context, in *BatchSignRequest, opts ...grpc.CallOption) (*BatchSignResponse, error) {
+	out := new(BatchSignResponse)
+	err := c.cc.Invoke(ctx, SignerService_BatchSign_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *signerServiceClient) DisableKey(ctx context.Context, in *DisableKeyRequest, opts ...grpc.CallOption) (*DisableKeyResponse, error) {
+	out := new(DisableKeyResponse)
+	err := c.cc.Invoke(ctx, SignerService_DisableKey_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *signerServiceClient) SignTransaction(ctx context.Context, in *SignTransactionRequest, opts ...grpc.CallOption) (*SignTransactionResponse, error) {
+	out := new(SignTransactionResponse)
+	err := c.cc.Invoke(ctx, SignerService_SignTransaction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *signerServiceClient) WatchUnlock(ctx context.Context, in *WatchUnlockRequest, opts ...grpc.CallOption) (SignerService_WatchUnlockClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SignerService_ServiceDesc.Streams[1], SignerService_WatchUnlock_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &signerServiceWatchUnlockClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SignerService_WatchUnlockClient interface {
+	Recv() (*UnlockEvent, error)
+	grpc.ClientStream
+}
+
+type signerServiceWatchUnlockClient struct {
+	grpc.ClientStream
+}
+
+func (x *signerServiceWatchUnlockClient) Recv() (*UnlockEvent, error) {
+	m := new(UnlockEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *signerServiceClient) ExportKeyBackup(ctx context.Context, in *ExportKeyBackupRequest, opts ...grpc.CallOption) (*ExportKeyBackupResponse, error) {
+	out := new(ExportKeyBackupResponse)
+	err := c.cc.Invoke(ctx, SignerService_ExportKeyBackup_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *signerServiceClient) GetAttestation(ctx context.Context, in *AttestationRequest, opts ...grpc.CallOption) (*AttestationResponse, error) {
+	out := new(AttestationResponse)
+	err := c.cc.Invoke(ctx, SignerService_GetAttestation_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SignerServiceServer is the server API for SignerService service.
 // All implementations must embed UnimplementedSignerServiceServer
 // for forward compatibility
 type SignerServiceServer interface {
 	Create(context.Context, *CreateRequest) (*CreateResponse, error)
+	// ImportKey 导入外部生成的私钥，响应与 Create 一致。
+	ImportKey(context.Context, *ImportKeyRequest) (*CreateResponse, error)
+	// GetPublicKey 按 key_id 重新获取公钥与地址，不执行签名；响应与 Create 一致。
+	GetPublicKey(context.Context, *GetPublicKeyRequest) (*CreateResponse, error)
+	// Sign 在返回 UNLOCK_REQUIRED 时会附带 metadata：
+	// - retry-after-ms: string (毫秒)
+	// - x-unlock-request-id: string
 	Sign(context.Context, *SignRequest) (*SignResponse, error)
+	// SignStream 并发处理流内请求，单个请求失败（含共享许可耗尽时的 RETRY_LATER）
+	// 以带 error 的 SignResponse 返回，不中断流。
 	SignStream(SignerService_SignStreamServer) error
+	// BatchSign 一次提交多条 digest，按 key_id 分组保持粘性路由并经连接池并发处理；
+	// 单项失败以带 error 的 SignResponse 返回，仅条数为 0 或超限时整体返回 INVALID_ARGUMENT。
+	BatchSign(context.Context, *BatchSignRequest) (*BatchSignResponse, error)
+	// DisableKey 立即拒绝该 key 的后续签名、清除父机 key cache，并转发至所属 Enclave 停用或删除；
+	// 可重复调用，转发失败时本地停用状态保持不变。
+	DisableKey(context.Context, *DisableKeyRequest) (*DisableKeyResponse, error)
+	// SignTransaction 在服务端解析以太坊交易并计算签名哈希，签名后返回序列化的已签名交易；
+	// 交易非法或 chainId 不一致返回 INVALID_ARGUMENT，其余错误语义与 Sign 相同。
+	SignTransaction(context.Context, *SignTransactionRequest) (*SignTransactionResponse, error)
+	// WatchUnlock 订阅 key 的后台解锁结果：任务成功或最终失败时推送一条 UnlockEvent 后结束流，
+	// 订阅前刚完成的结果立即返回；超过服务端等待上限返回 DEADLINE_EXCEEDED。
+	WatchUnlock(*WatchUnlockRequest, SignerService_WatchUnlockServer) error
+	// ExportKeyBackup 仅限管理员：从 key 所属 Enclave 取回加密的 key blob 与包裹元数据，用于复制到备用区域；
+	// 永不返回明文，reason 必填并写入审计，审计写入失败时不返回备份。
+	ExportKeyBackup(context.Context, *ExportKeyBackupRequest) (*ExportKeyBackupResponse, error)
+	// GetAttestation 仅由 Enclave 实现：返回绑定请求 nonce 的 Nitro attestation 文档，
+	// 父机连接池在交付新连接前据此校验 Enclave 的度量值（PCR）。
+	GetAttestation(context.Context, *AttestationRequest) (*AttestationResponse, error)
 	mustEmbedUnimplementedSignerServiceServer()
 }
 
@@ -107,12 +264,36 @@ type UnimplementedSignerServiceServer struct {
 func (UnimplementedSignerServiceServer) Create(context.Context, *CreateRequest) (*CreateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
 }
+func (UnimplementedSignerServiceServer) ImportKey(context.Context, *ImportKeyRequest) (*CreateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportKey not implemented")
+}
+func (UnimplementedSignerServiceServer) GetPublicKey(context.Context, *GetPublicKeyRequest) (*CreateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPublicKey not implemented")
+}
 func (UnimplementedSignerServiceServer) Sign(context.Context, *SignRequest) (*SignResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Sign not implemented")
 }
 func (UnimplementedSignerServiceServer) SignStream(SignerService_SignStreamServer) error {
 	return status.Errorf(codes.Unimplemented, "method SignStream not implemented")
 }
+func (UnimplementedSignerServiceServer) BatchSign(context.Context, *BatchSignRequest) (*BatchSignResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchSign not implemented")
+}
+func (UnimplementedSignerServiceServer) DisableKey(context.Context, *DisableKeyRequest) (*DisableKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableKey not implemented")
+}
+func (UnimplementedSignerServiceServer) SignTransaction(context.Context, *SignTransactionRequest) (*SignTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SignTransaction not implemented")
+}
+func (UnimplementedSignerServiceServer) WatchUnlock(*WatchUnlockRequest, SignerService_WatchUnlockServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchUnlock not implemented")
+}
+func (UnimplementedSignerServiceServer) ExportKeyBackup(context.Context, *ExportKeyBackupRequest) (*ExportKeyBackupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportKeyBackup not implemented")
+}
+func (UnimplementedSignerServiceServer) GetAttestation(context.Context, *AttestationRequest) (*AttestationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAttestation not implemented")
+}
 func (UnimplementedSignerServiceServer) mustEmbedUnimplementedSignerServiceServer() {}
 
 // UnsafeSignerServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -144,6 +325,42 @@ func _SignerService_Create_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SignerService_ImportKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SignerServiceServer).ImportKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SignerService_ImportKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SignerServiceServer).ImportKey(ctx, req.(*ImportKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SignerService_GetPublicKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPublicKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SignerServiceServer).GetPublicKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SignerService_GetPublicKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SignerServiceServer).GetPublicKey(ctx, req.(*GetPublicKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _SignerService_Sign_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SignRequest)
 	if err := dec(in); err != nil {
@@ -188,6 +405,117 @@ func (x *signerServiceSignStreamServer) Recv() (*SignRequest, error) {
 	return m, nil
 }
 
+func _SignerService_BatchSign_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchSignRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SignerServiceServer).BatchSign(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SignerService_BatchSign_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SignerServiceServer).BatchSign(ctx, req.(*BatchSignRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SignerService_DisableKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DisableKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SignerServiceServer).DisableKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SignerService_DisableKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SignerServiceServer).DisableKey(ctx, req.(*DisableKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SignerService_SignTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SignerServiceServer).SignTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SignerService_SignTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SignerServiceServer).SignTransaction(ctx, req.(*SignTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SignerService_WatchUnlock_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchUnlockRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SignerServiceServer).WatchUnlock(m, &signerServiceWatchUnlockServer{stream})
+}
+
+type SignerService_WatchUnlockServer interface {
+	Send(*UnlockEvent) error
+	grpc.ServerStream
+}
+
+type signerServiceWatchUnlockServer struct {
+	grpc.ServerStream
+}
+
+func (x *signerServiceWatchUnlockServer) Send(m *UnlockEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _SignerService_ExportKeyBackup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportKeyBackupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SignerServiceServer).ExportKeyBackup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SignerService_ExportKeyBackup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SignerServiceServer).ExportKeyBackup(ctx, req.(*ExportKeyBackupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SignerService_GetAttestation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AttestationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SignerServiceServer).GetAttestation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SignerService_GetAttestation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SignerServiceServer).GetAttestation(ctx, req.(*AttestationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // SignerService_ServiceDesc is the grpc.ServiceDesc for SignerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -199,10 +527,38 @@ var SignerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Create",
 			Handler:    _SignerService_Create_Handler,
 		},
+		{
+			MethodName: "ImportKey",
+			Handler:    _SignerService_ImportKey_Handler,
+		},
+		{
+			MethodName: "GetPublicKey",
+			Handler:    _SignerService_GetPublicKey_Handler,
+		},
 		{
 			MethodName: "Sign",
 			Handler:    _SignerService_Sign_Handler,
 		},
+		{
+			MethodName: "BatchSign",
+			Handler:    _SignerService_BatchSign_Handler,
+		},
+		{
+			MethodName: "DisableKey",
+			Handler:    _SignerService_DisableKey_Handler,
+		},
+		{
+			MethodName: "SignTransaction",
+			Handler:    _SignerService_SignTransaction_Handler,
+		},
+		{
+			MethodName: "ExportKeyBackup",
+			Handler:    _SignerService_ExportKeyBackup_Handler,
+		},
+		{
+			MethodName: "GetAttestation",
+			Handler:    _SignerService_GetAttestation_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -211,6 +567,11 @@ var SignerService_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "WatchUnlock",
+			Handler:       _SignerService_WatchUnlock_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "signer.proto",
 }
@@ -3,8 +3,11 @@ package tests
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"testing"
 
+	"github.com/aegis-sign/wallet/pkg/curves"
 	"gopkg.in/yaml.v3"
 )
 
@@ -64,9 +67,33 @@ func TestInvalidKeyResponseDocumented(t *testing.T) {
 		t.Fatal("InvalidKey response missing")
 	}
 	paths := doc["paths"].(map[string]any)
-	sign := paths["/sign"].(map[string]any)["post"].(map[string]any)
+	sign := paths["/v1/sign"].(map[string]any)["post"].(map[string]any)
 	signResponses := sign["responses"].(map[string]any)
 	if _, ok := signResponses["404"]; !ok {
-		t.Fatal("/sign must document 404 InvalidKey response")
+		t.Fatal("/v1/sign must document 404 InvalidKey response")
+	}
+}
+
+func TestCreateCurveEnumMatchesRegistry(t *testing.T) {
+	doc := loadOpenAPI(t)
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	for _, name := range []string{"CreateRequest", "ImportKeyRequest", "SignRequest"} {
+		curve := schemas[name].(map[string]any)["properties"].(map[string]any)["curve"].(map[string]any)
+		enum, ok := curve["enum"].([]any)
+		if !ok {
+			t.Fatalf("%s.curve must declare enum", name)
+		}
+		var documented []string
+		for _, v := range enum {
+			documented = append(documented, v.(string))
+		}
+		sort.Strings(documented)
+		if !reflect.DeepEqual(documented, curves.Names()) {
+			t.Fatalf("OpenAPI %s curve enum %v drifted from registry %v", name, documented, curves.Names())
+		}
+		// SignRequest 省略 curve 时按所有曲线摘要长度的并集校验，不声明默认值。
+		if name != "SignRequest" && curve["default"] != curves.DefaultName {
+			t.Fatalf("OpenAPI %s curve default %v, want %s", name, curve["default"], curves.DefaultName)
+		}
 	}
 }
@@ -0,0 +1,370 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultAccountingWindow  = time.Hour
+	defaultAccountingMaxKeys = 100000
+	defaultUsageReportLimit  = 1000
+)
+
+// SignAccountingConfig 配置按租户与 keyId 的签名计数及每窗口配额。
+type SignAccountingConfig struct {
+	// Enabled 为 false 时既不计数也不执行配额。
+	Enabled bool
+	// Window 为计数窗口长度，按 Unix 时间对齐，默认 1h。
+	Window time.Duration
+	// TenantQuota 为每个租户每窗口的签名数上限；<=0 表示只计数不限额。
+	TenantQuota int64
+	// TenantOverrides 覆盖指定租户的配额，0 表示该租户不限额。
+	TenantOverrides map[string]int64
+	// KeyQuota 为每个 keyId 每窗口的签名数上限；<=0 表示只计数不限额。
+	KeyQuota int64
+	// MaxKeys 限制单个窗口内逐 key 计数的数量，默认 100000；超出的 key 只计入租户与 overflow。
+	MaxKeys int
+
+	Registerer     prometheus.Registerer
+	MetricsOptions metricsopts.Options
+	// Now 默认 time.Now。
+	Now func() time.Time
+}
+
+func (c SignAccountingConfig) withDefaults() SignAccountingConfig {
+	if c.Window <= 0 {
+		c.Window = defaultAccountingWindow
+	}
+	if c.MaxKeys <= 0 {
+		c.MaxKeys = defaultAccountingMaxKeys
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+	return c
+}
+
+func (c SignAccountingConfig) tenantQuota(tenant string) int64 {
+	if quota, ok := c.TenantOverrides[tenant]; ok {
+		return quota
+	}
+	return c.TenantQuota
+}
+
+// usageCount 是窗口内的签名计数；dry-run 与真实签名一同占用配额，但分开计数，便于计费排除。
+type usageCount struct {
+	signatures int64
+	dryRuns    int64
+}
+
+func (c usageCount) total() int64 {
+	return c.signatures + c.dryRuns
+}
+
+// add 按 dryRun 累加 delta，返回累加后的总数。
+func (c *usageCount) add(dryRun bool, delta int64) int64 {
+	if dryRun {
+		c.dryRuns += delta
+	} else {
+		c.signatures += delta
+	}
+	return c.total()
+}
+
+type keyUsage struct {
+	tenant string
+	usageCount
+}
+
+// usageWindow 是一个计数窗口，start 按 Window 对齐。
+type usageWindow struct {
+	start    time.Time
+	tenants  map[string]*usageCount
+	keys     map[string]*keyUsage
+	overflow int64
+}
+
+func newUsageWindow(start time.Time) *usageWindow {
+	return &usageWindow{start: start, tenants: make(map[string]*usageCount), keys: make(map[string]*keyUsage)}
+}
+
+// SignAccounting 按窗口统计每个租户与 keyId 的签名数并执行配额。nil 表示关闭。
+type SignAccounting struct {
+	cfg SignAccountingConfig
+
+	mu       sync.Mutex
+	current  *usageWindow
+	previous *usageWindow
+
+	signatures *prometheus.CounterVec
+	rejected   *prometheus.CounterVec
+}
+
+// NewSignAccounting 构造 SignAccounting，未启用时返回 nil。
+func NewSignAccounting(cfg SignAccountingConfig) (*SignAccounting, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	cfg = cfg.withDefaults()
+	reg := cfg.Registerer
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	opts := cfg.MetricsOptions.WithDefaults("signer", "usage")
+	start := cfg.Now().Truncate(cfg.Window)
+	a := &SignAccounting{
+		cfg:      cfg,
+		current:  newUsageWindow(start),
+		previous: newUsageWindow(start.Add(-cfg.Window)),
+		signatures: prometheus.NewCounterVec(opts.Counter("signatures_total",
+			"Number of successful signatures by tenant; dry_run=\"true\" marks dry-run calls that billing should exclude"), []string{"tenant", "dry_run"}),
+		rejected: prometheus.NewCounterVec(opts.Counter("quota_rejected_total",
+			"Number of sign requests rejected by quota by scope"), []string{"scope"}),
+	}
+	if err := metricsopts.Register(reg, a.signatures, a.rejected); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// rotateLocked 在跨窗口时滚动计数；中间若有空窗口，previous 置为空窗口。
+func (a *SignAccounting) rotateLocked(now time.Time) {
+	start := now.Truncate(a.cfg.Window)
+	if !start.After(a.current.start) {
+		return
+	}
+	if start.Sub(a.current.start) == a.cfg.Window {
+		a.previous = a.current
+	} else {
+		a.previous = newUsageWindow(start.Add(-a.cfg.Window))
+	}
+	a.current = newUsageWindow(start)
+}
+
+// reserve 为一次签名预占租户与 key 的额度，dryRun 计入 dry-run 计数；超额时不计数，返回窗口剩余时长与受限范围。
+func (a *SignAccounting) reserve(tenant, keyID string, dryRun bool) (time.Time, time.Duration, string) {
+	now := a.cfg.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rotateLocked(now)
+	w := a.current
+	retry := w.start.Add(a.cfg.Window).Sub(now)
+
+	tenantUsage, ok := w.tenants[tenant]
+	if quota := a.cfg.tenantQuota(tenant); quota > 0 && ok && tenantUsage.total() >= quota {
+		return w.start, retry, "tenant"
+	}
+	usage, tracked := w.keys[keyID]
+	if a.cfg.KeyQuota > 0 && tracked && usage.total() >= a.cfg.KeyQuota {
+		return w.start, retry, "key"
+	}
+	if !ok {
+		tenantUsage = &usageCount{}
+		w.tenants[tenant] = tenantUsage
+	}
+	tenantUsage.add(dryRun, 1)
+	switch {
+	case tracked:
+		usage.add(dryRun, 1)
+		usage.tenant = tenant
+	case len(w.keys) < a.cfg.MaxKeys:
+		usage = &keyUsage{tenant: tenant}
+		usage.add(dryRun, 1)
+		w.keys[keyID] = usage
+	default:
+		w.overflow++
+	}
+	return w.start, 0, ""
+}
+
+// release 退还签名失败的预占额度；预占所在窗口已滚出 previous 时忽略。
+func (a *SignAccounting) release(start time.Time, tenant, keyID string, dryRun bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var w *usageWindow
+	switch {
+	case a.current.start.Equal(start):
+		w = a.current
+	case a.previous.start.Equal(start):
+		w = a.previous
+	default:
+		return
+	}
+	if usage, ok := w.tenants[tenant]; ok && usage.add(dryRun, -1) <= 0 {
+		delete(w.tenants, tenant)
+	}
+	if usage, ok := w.keys[keyID]; ok {
+		if usage.add(dryRun, -1) <= 0 {
+			delete(w.keys, keyID)
+		}
+	} else if w.overflow > 0 {
+		w.overflow--
+	}
+}
+
+// AccountingMiddleware 统计成功的 Sign 并在租户或 key 超出窗口配额时返回带 Retry-After 的 RETRY_LATER；
+// 失败的签名退还额度。dry-run 同样预占配额，便于压测配额行为，但单独计数并以 dry_run 标签区分。
+// a 为 nil 时返回 nil，由 Chain 跳过。
+func AccountingMiddleware(a *SignAccounting) BackendMiddleware {
+	if a == nil {
+		return nil
+	}
+	return func(next Backend) Backend {
+		return BackendFuncs{
+			Next: next,
+			SignFunc: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+				tenant, _ := requestTenant(ctx, req.GetAuditContext())
+				keyID, dryRun := req.GetKeyId(), req.GetDryRun()
+				start, wait, scope := a.reserve(tenant, keyID, dryRun)
+				if scope != "" {
+					a.rejected.WithLabelValues(scope).Inc()
+					return nil, apierrors.New(apierrors.CodeRetryLater, scope+" sign quota exhausted").WithRetryAfter(wait)
+				}
+				resp, err := next.Sign(ctx, req)
+				if err != nil {
+					a.release(start, tenant, keyID, dryRun)
+					return nil, err
+				}
+				a.signatures.WithLabelValues(tenant, strconv.FormatBool(dryRun)).Inc()
+				return resp, nil
+			},
+		}
+	}
+}
+
+// TenantUsage 是单个租户在窗口内的签名数，Quota 为 0 表示不限额；配额按 Signatures 与 DryRuns 之和计算。
+type TenantUsage struct {
+	TenantID   string `json:"tenantId"`
+	Signatures int64  `json:"signatures"`
+	DryRuns    int64  `json:"dryRuns"`
+	Quota      int64  `json:"quota"`
+}
+
+// KeyUsage 是单个 key 在窗口内的签名数，TenantID 为最近一次签名的租户。
+type KeyUsage struct {
+	KeyID      string `json:"keyId"`
+	TenantID   string `json:"tenantId"`
+	Signatures int64  `json:"signatures"`
+	DryRuns    int64  `json:"dryRuns"`
+}
+
+// WindowUsage 是一个计数窗口的快照。
+type WindowUsage struct {
+	Start   time.Time     `json:"start"`
+	End     time.Time     `json:"end"`
+	Tenants []TenantUsage `json:"tenants"`
+	Keys    []KeyUsage    `json:"keys"`
+	// Overflow 为超出 MaxKeys 未逐 key 计数的签名数（含 dry-run）。
+	Overflow int64 `json:"overflow"`
+}
+
+// UsageReport 是 /admin/usage 的响应。
+type UsageReport struct {
+	Window   string      `json:"window"`
+	KeyQuota int64       `json:"keyQuota"`
+	Current  WindowUsage `json:"current"`
+	Previous WindowUsage `json:"previous"`
+}
+
+// Report 返回当前与上一个窗口的用量；tenant 非空时只返回该租户及其 key，keys 按签名数降序截取前 limit 个。
+func (a *SignAccounting) Report(tenant string, limit int) UsageReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rotateLocked(a.cfg.Now())
+	return UsageReport{
+		Window:   a.cfg.Window.String(),
+		KeyQuota: max(a.cfg.KeyQuota, 0),
+		Current:  a.windowUsageLocked(a.current, tenant, limit),
+		Previous: a.windowUsageLocked(a.previous, tenant, limit),
+	}
+}
+
+func (a *SignAccounting) windowUsageLocked(w *usageWindow, tenant string, limit int) WindowUsage {
+	out := WindowUsage{
+		Start:    w.start.UTC(),
+		End:      w.start.Add(a.cfg.Window).UTC(),
+		Tenants:  []TenantUsage{},
+		Keys:     []KeyUsage{},
+		Overflow: w.overflow,
+	}
+	for id, usage := range w.tenants {
+		if tenant == "" || id == tenant {
+			out.Tenants = append(out.Tenants, TenantUsage{TenantID: id, Signatures: usage.signatures, DryRuns: usage.dryRuns, Quota: max(a.cfg.tenantQuota(id), 0)})
+		}
+	}
+	sort.Slice(out.Tenants, func(i, j int) bool { return out.Tenants[i].TenantID < out.Tenants[j].TenantID })
+	for id, usage := range w.keys {
+		if tenant == "" || usage.tenant == tenant {
+			out.Keys = append(out.Keys, KeyUsage{KeyID: id, TenantID: usage.tenant, Signatures: usage.signatures, DryRuns: usage.dryRuns})
+		}
+	}
+	sort.Slice(out.Keys, func(i, j int) bool {
+		if ti, tj := out.Keys[i].Signatures+out.Keys[i].DryRuns, out.Keys[j].Signatures+out.Keys[j].DryRuns; ti != tj {
+			return ti > tj
+		}
+		return out.Keys[i].KeyID < out.Keys[j].KeyID
+	})
+	if len(out.Keys) > limit {
+		out.Keys = out.Keys[:limit]
+	}
+	return out
+}
+
+// Handler 返回用量查询的管理端点。查询参数：tenant（只看该租户），limit（每个窗口返回的 key 数，默认 1000）。
+func (a *SignAccounting) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "GET required", http.StatusMethodNotAllowed)
+			return
+		}
+		query := r.URL.Query()
+		limit := defaultUsageReportLimit
+		if v := query.Get("limit"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			limit = parsed
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(a.Report(query.Get("tenant"), limit))
+	})
+}
+
+// ParseTenantQuotaOverrides 解析 tenant:quota[,tenant:quota] 形式的租户配额，quota 为 0 表示该租户不限额。
+func ParseTenantQuotaOverrides(raw string) (map[string]int64, error) {
+	overrides := make(map[string]int64)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		tenant, value, ok := strings.Cut(part, ":")
+		if !ok || tenant == "" {
+			return nil, fmt.Errorf("invalid tenant quota override %q, want tenant:quota", part)
+		}
+		quota, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || quota < 0 {
+			return nil, fmt.Errorf("invalid quota in tenant quota override %q", part)
+		}
+		if _, dup := overrides[tenant]; dup {
+			return nil, fmt.Errorf("tenant quota override for %q is declared twice", tenant)
+		}
+		overrides[tenant] = quota
+	}
+	return overrides, nil
+}
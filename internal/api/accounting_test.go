@@ -0,0 +1,162 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newAccountedBackend(t *testing.T, cfg SignAccountingConfig) (Backend, *SignAccounting, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	cfg.Enabled = true
+	cfg.Now = clock.Now
+	cfg.Registerer = prometheus.NewRegistry()
+	accounting, err := NewSignAccounting(cfg)
+	require.NoError(t, err)
+	backend := Chain(&stubBackend{signFn: func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		if req.GetKeyId() == "bad" {
+			return nil, apierrors.New(apierrors.CodeInvalidKey, "unknown key")
+		}
+		return &signerv1.SignResponse{}, nil
+	}}, AccountingMiddleware(accounting))
+	return backend, accounting, clock
+}
+
+func TestAccountingMiddlewareTenantQuota(t *testing.T) {
+	backend, accounting, clock := newAccountedBackend(t, SignAccountingConfig{
+		Window:          time.Minute,
+		TenantQuota:     2,
+		TenantOverrides: map[string]int64{"vip": 0},
+	})
+	tenantA := reqctx.WithTenantID(context.Background(), "a")
+	vip := reqctx.WithTenantID(context.Background(), "vip")
+
+	for i := 0; i < 2; i++ {
+		_, err := backend.Sign(tenantA, &signerv1.SignRequest{KeyId: "k1"})
+		require.NoError(t, err)
+	}
+	// 1_700_000_000 距下一个整分钟还有 40s。
+	_, err := backend.Sign(tenantA, &signerv1.SignRequest{KeyId: "k2"})
+	requireRetryLater(t, err, 40*time.Second)
+	for i := 0; i < 5; i++ {
+		_, err = backend.Sign(vip, &signerv1.SignRequest{KeyId: "k3"})
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2.0, testutil.ToFloat64(accounting.signatures.WithLabelValues("a", "false")))
+	require.Equal(t, 1.0, testutil.ToFloat64(accounting.rejected.WithLabelValues("tenant")))
+
+	clock.Advance(40 * time.Second)
+	_, err = backend.Sign(tenantA, &signerv1.SignRequest{KeyId: "k1"})
+	require.NoError(t, err)
+
+	report := accounting.Report("a", 10)
+	require.Equal(t, []TenantUsage{{TenantID: "a", Signatures: 1, Quota: 2}}, report.Current.Tenants)
+	require.Equal(t, []TenantUsage{{TenantID: "a", Signatures: 2, Quota: 2}}, report.Previous.Tenants)
+	require.Equal(t, []KeyUsage{{KeyID: "k1", TenantID: "a", Signatures: 2}}, report.Previous.Keys)
+}
+
+func TestAccountingMiddlewareKeyQuotaAndRefund(t *testing.T) {
+	backend, accounting, _ := newAccountedBackend(t, SignAccountingConfig{KeyQuota: 1})
+	ctx := reqctx.WithTenantID(context.Background(), "a")
+
+	// 失败的签名不消耗额度。
+	_, err := backend.Sign(ctx, &signerv1.SignRequest{KeyId: "bad"})
+	require.Error(t, err)
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "hot"})
+	require.NoError(t, err)
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "hot"})
+	apiErr, ok := apierrors.FromError(err)
+	require.True(t, ok, err)
+	require.Equal(t, apierrors.CodeRetryLater, apiErr.Code)
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "cold"})
+	require.NoError(t, err)
+
+	report := accounting.Report("", 10)
+	require.Equal(t, []TenantUsage{{TenantID: "a", Signatures: 2}}, report.Current.Tenants)
+	require.Len(t, report.Current.Keys, 2)
+	require.Equal(t, 1.0, testutil.ToFloat64(accounting.rejected.WithLabelValues("key")))
+}
+
+func TestAccountingCountsDryRunSeparately(t *testing.T) {
+	backend, accounting, _ := newAccountedBackend(t, SignAccountingConfig{KeyQuota: 2})
+	ctx := reqctx.WithTenantID(context.Background(), "a")
+
+	// dry-run 与真实签名共用配额，但单独计数。
+	_, err := backend.Sign(ctx, &signerv1.SignRequest{KeyId: "hot", DryRun: true})
+	require.NoError(t, err)
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "hot"})
+	require.NoError(t, err)
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "hot", DryRun: true})
+	apiErr, ok := apierrors.FromError(err)
+	require.True(t, ok, err)
+	require.Equal(t, apierrors.CodeRetryLater, apiErr.Code)
+	// 失败的 dry-run 同样退还额度。
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "bad", DryRun: true})
+	require.Error(t, err)
+
+	report := accounting.Report("", 10)
+	require.Equal(t, []TenantUsage{{TenantID: "a", Signatures: 1, DryRuns: 1}}, report.Current.Tenants)
+	require.Equal(t, []KeyUsage{{KeyID: "hot", TenantID: "a", Signatures: 1, DryRuns: 1}}, report.Current.Keys)
+	require.Equal(t, 1.0, testutil.ToFloat64(accounting.signatures.WithLabelValues("a", "false")))
+	require.Equal(t, 1.0, testutil.ToFloat64(accounting.signatures.WithLabelValues("a", "true")))
+}
+
+func TestAccountingBoundsTrackedKeys(t *testing.T) {
+	backend, accounting, _ := newAccountedBackend(t, SignAccountingConfig{MaxKeys: 1})
+	for _, key := range []string{"k1", "k2", "k3"} {
+		_, err := backend.Sign(context.Background(), &signerv1.SignRequest{KeyId: key})
+		require.NoError(t, err)
+	}
+	report := accounting.Report("", 10)
+	require.Equal(t, []KeyUsage{{KeyID: "k1", Signatures: 1}}, report.Current.Keys)
+	require.EqualValues(t, 2, report.Current.Overflow)
+	require.Equal(t, []TenantUsage{{Signatures: 3}}, report.Current.Tenants)
+}
+
+func TestAccountingHandler(t *testing.T) {
+	backend, accounting, _ := newAccountedBackend(t, SignAccountingConfig{Window: time.Minute})
+	_, err := backend.Sign(reqctx.WithTenantID(context.Background(), "a"), &signerv1.SignRequest{KeyId: "k1"})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	accounting.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/usage?tenant=a", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var report UsageReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	require.Equal(t, "1m0s", report.Window)
+	require.Equal(t, time.Unix(1_699_999_980, 0).UTC(), report.Current.Start)
+	require.Equal(t, []KeyUsage{{KeyID: "k1", TenantID: "a", Signatures: 1}}, report.Current.Keys)
+
+	rec = httptest.NewRecorder()
+	accounting.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/usage?limit=0", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestNewSignAccountingDisabled(t *testing.T) {
+	accounting, err := NewSignAccounting(SignAccountingConfig{TenantQuota: 10})
+	require.NoError(t, err)
+	require.Nil(t, accounting)
+	require.Nil(t, AccountingMiddleware(nil))
+}
+
+func TestParseTenantQuotaOverrides(t *testing.T) {
+	overrides, err := ParseTenantQuotaOverrides("a:100, b:0,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"a": 100, "b": 0}, overrides)
+
+	for _, raw := range []string{"a", ":1", "a:x", "a:-1", "a:1.5", "a:1,a:2"} {
+		_, err := ParseTenantQuotaOverrides(raw)
+		require.Error(t, err, raw)
+	}
+}
@@ -0,0 +1,55 @@
+package signerapi
+
+import (
+	"fmt"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/pkg/address"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+)
+
+// parseAddressFormats 规范化 Create 请求的地址格式并去重；未知格式或曲线不支持地址派生时返回 INVALID_ARGUMENT，
+// 在创建 key 之前拒绝，避免生成调用方无法使用的 key。
+func parseAddressFormats(curve string, names []string) ([]address.Format, *apierrors.Error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	if curve != address.Curve {
+		return nil, apierrors.New(apierrors.CodeInvalidArgument, fmt.Sprintf("addressFormats requires curve %s, got %s", address.Curve, curve))
+	}
+	formats := make([]address.Format, 0, len(names))
+	seen := make(map[address.Format]struct{}, len(names))
+	for _, name := range names {
+		f, err := address.ParseFormat(name)
+		if err != nil {
+			return nil, apierrors.New(apierrors.CodeInvalidArgument, err.Error())
+		}
+		if _, ok := seen[f]; ok {
+			continue
+		}
+		seen[f] = struct{}{}
+		formats = append(formats, f)
+	}
+	return formats, nil
+}
+
+// deriveAddresses 按 formats 顺序由 Enclave 返回的公钥派生地址，写入 resp.Addresses。
+func deriveAddresses(resp *signerv1.CreateResponse, formats []address.Format) error {
+	for _, f := range formats {
+		addr, err := address.Derive(f, resp.GetPublicKey())
+		if err != nil {
+			return fmt.Errorf("derive %s address for key %s: %w", f, resp.GetKeyId(), err)
+		}
+		resp.Addresses = append(resp.Addresses, &signerv1.DerivedAddress{Format: string(f), Address: addr})
+	}
+	return nil
+}
+
+// formatStrings 将规范化后的格式写回请求。
+func formatStrings(formats []address.Format) []string {
+	out := make([]string, len(formats))
+	for i, f := range formats {
+		out[i] = string(f)
+	}
+	return out
+}
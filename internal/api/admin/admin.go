@@ -0,0 +1,544 @@
+// Package admin 提供独立监听器上的管理 API（/admin/v1/*）：连接池与 Enclave 目标管理、
+// key cache 检查、解锁队列快照、配置重新加载与摘流；目标管理另以 gRPC AdminService 提供（见 grpc.go）。
+// 该 API 使用单独的凭证，不与业务入口共享。
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	signerapi "github.com/aegis-sign/wallet/internal/api"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/aegis-sign/wallet/internal/gateway/unlock"
+	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+)
+
+// Prefix 是管理 API 的路径前缀。
+const Prefix = "/admin/v1"
+
+const defaultEntryLimit = 100
+
+// PoolSource 提供连接池状态与目标管理，*enclaveclient.Pool 满足。
+type PoolSource interface {
+	Stats() []enclaveclient.TargetStats
+	Config() enclaveclient.Config
+	UpdateConfig(cfg enclaveclient.Config)
+	RegisterTarget(target enclaveclient.Target)
+	RemoveTarget(id string)
+	Drain(id string) error
+}
+
+// DispatcherSource 提供解锁队列状态，*unlock.Dispatcher 满足。
+type DispatcherSource interface {
+	Snapshot() unlock.Snapshot
+	History() unlock.History
+}
+
+// RoutingSource 提供并替换参与粘性路由的 Enclave ID，*signerapi.StickySelector 实现了该接口。
+type RoutingSource interface {
+	Targets() []string
+	SetTargets(ids []string) error
+}
+
+// Reloader 重新加载一项配置，失败时应保留原配置。
+type Reloader func(ctx context.Context) error
+
+// Config 配置管理 API，未设置的来源对应端点不注册。
+type Config struct {
+	// Verifier 为管理 API 专用凭证，必填；不应与业务入口共用。
+	Verifier signerapi.TokenVerifier
+	// Role 非空时调用方凭证的 roles 须包含该角色。
+	Role string
+
+	Pool PoolSource
+	// Routing 提供参与粘性路由的 Enclave ID；drain 只允许操作其中的 ID。
+	Routing RoutingSource
+	// DiscoveryManaged 为 true 时路由成员由动态发现维护，API 拒绝增删成员，只允许重建与摘除。
+	DiscoveryManaged bool
+	// OnTargetsChanged 在经 API 增删路由成员后调用（如更新自检目标）。
+	OnTargetsChanged func(ids []string)
+	Dispatcher       DispatcherSource
+	KeyCache         *keycache.Store
+	// Drain 为摘流 handler（GET/POST/DELETE），通常为 *signerapi.Drainer。
+	Drain     http.Handler
+	Reloaders map[string]Reloader
+	Logger    *slog.Logger
+}
+
+// API 是管理 API 的 handler 集合。
+type API struct {
+	cfg Config
+	// membership 串行化路由成员的读改写。
+	membership sync.Mutex
+}
+
+// New 构造管理 API，未配置 Verifier 时返回错误，避免管理端口在无认证的情况下暴露。
+func New(cfg Config) (*API, error) {
+	if cfg.Verifier == nil {
+		return nil, errors.New("admin api requires its own credentials")
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	return &API{cfg: cfg}, nil
+}
+
+// Register 在 router 上注册全部管理端点，每个端点都先经 Verifier 认证并校验 Role。
+func (a *API) Register(router signerapi.Router) {
+	router = signerapi.RequireAuth(router, a.cfg.Verifier)
+	handle := func(pattern string, h http.Handler) {
+		router.Handle(Prefix+pattern, a.requireRole(h))
+	}
+	if a.cfg.Pool != nil {
+		handle("/pool", http.HandlerFunc(a.pool))
+		handle("/pool/config", http.HandlerFunc(a.poolConfig))
+		handle("/targets", http.HandlerFunc(a.targets))
+		handle("/targets/", http.HandlerFunc(a.target))
+	}
+	if a.cfg.Dispatcher != nil {
+		handle("/dispatcher", http.HandlerFunc(a.dispatcher))
+	}
+	if a.cfg.KeyCache != nil {
+		handle("/keycache", http.HandlerFunc(a.keyCache))
+		handle("/keycache/flush", a.audited("keycache_flush", a.cfg.KeyCache.FlushHandler()))
+		handle("/keycache/invalidate", a.audited("keycache_invalidate", a.cfg.KeyCache.InvalidateHandler()))
+		handle("/keycache/refresh", a.audited("keycache_refresh", a.cfg.KeyCache.RefreshHandler()))
+	}
+	if a.cfg.Drain != nil {
+		handle("/drain", a.audited("drain", a.cfg.Drain))
+	}
+	if len(a.cfg.Reloaders) > 0 {
+		handle("/reload", http.HandlerFunc(a.reload))
+	}
+}
+
+func (a *API) requireRole(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.hasRole(r.Context()) {
+			writeError(w, http.StatusForbidden, "admin role required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasRole 判断已认证的调用方是否具备 Role，Role 为空时总是通过。
+func (a *API) hasRole(ctx context.Context) bool {
+	if a.cfg.Role == "" {
+		return true
+	}
+	p, _ := reqctx.PrincipalFrom(ctx)
+	return slices.Contains(p.Roles, a.cfg.Role)
+}
+
+// audited 为变更类请求输出审计日志，GET 查询不记录。
+func (a *API) audited(action string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			a.audit(r.Context(), action, slog.String("method", r.Method))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *API) audit(ctx context.Context, action string, attrs ...slog.Attr) {
+	principal := "anonymous"
+	if p, ok := reqctx.PrincipalFrom(ctx); ok {
+		principal = p.Subject
+	}
+	attrs = append([]slog.Attr{slog.String("action", action), slog.String("principal", principal)}, attrs...)
+	a.cfg.Logger.LogAttrs(ctx, slog.LevelInfo, "admin api audit", attrs...)
+}
+
+type poolResponse struct {
+	Config  poolConfig                  `json:"config"`
+	Targets []enclaveclient.TargetStats `json:"targets"`
+}
+
+// poolConfig 是可热更新的连接池参数，时长以毫秒表示；更新时零值字段保持不变。
+type poolConfig struct {
+	MinConns         int   `json:"minConns,omitempty"`
+	MaxConns         int   `json:"maxConns,omitempty"`
+	AcquireTimeoutMs int64 `json:"acquireTimeoutMs,omitempty"`
+	DialTimeoutMs    int64 `json:"dialTimeoutMs,omitempty"`
+}
+
+func toPoolConfig(cfg enclaveclient.Config) poolConfig {
+	return poolConfig{
+		MinConns:         cfg.MinConns,
+		MaxConns:         cfg.MaxConns,
+		AcquireTimeoutMs: cfg.AcquireTimeout.Milliseconds(),
+		DialTimeoutMs:    cfg.DialTimeout.Milliseconds(),
+	}
+}
+
+// pool 处理 GET /admin/v1/pool：当前连接池参数与各目标状态。
+func (a *API) pool(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "GET required")
+		return
+	}
+	writeJSON(w, http.StatusOK, poolResponse{Config: toPoolConfig(a.cfg.Pool.Config()), Targets: a.cfg.Pool.Stats()})
+}
+
+// poolConfig 处理 PUT /admin/v1/pool/config：热更新连接数与超时，返回更新后的参数。
+func (a *API) poolConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, http.StatusMethodNotAllowed, "PUT required")
+		return
+	}
+	var req poolConfig
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.MinConns < 0 || req.MaxConns < 0 || req.AcquireTimeoutMs < 0 || req.DialTimeoutMs < 0 {
+		writeError(w, http.StatusBadRequest, "pool config values must not be negative")
+		return
+	}
+	cfg := a.cfg.Pool.Config()
+	if req.MinConns > 0 {
+		cfg.MinConns = req.MinConns
+	}
+	if req.MaxConns > 0 {
+		cfg.MaxConns = req.MaxConns
+	}
+	if cfg.MaxConns < cfg.MinConns {
+		writeError(w, http.StatusBadRequest, "maxConns must not be less than minConns")
+		return
+	}
+	if req.AcquireTimeoutMs > 0 {
+		cfg.AcquireTimeout = time.Duration(req.AcquireTimeoutMs) * time.Millisecond
+	}
+	if req.DialTimeoutMs > 0 {
+		cfg.DialTimeout = time.Duration(req.DialTimeoutMs) * time.Millisecond
+	}
+	a.cfg.Pool.UpdateConfig(cfg)
+	a.audit(r.Context(), "pool_config", slog.Int("min_conns", cfg.MinConns), slog.Int("max_conns", cfg.MaxConns))
+	writeJSON(w, http.StatusOK, toPoolConfig(a.cfg.Pool.Config()))
+}
+
+type targetStatus struct {
+	ID string `json:"id"`
+	// Routed 表示该 ID 参与粘性路由。
+	Routed bool `json:"routed"`
+	// Registered 表示连接池中存在该目标，已摘除的目标仍在池中，熔断状态见 Stats。
+	Registered bool                       `json:"registered"`
+	Stats      *enclaveclient.TargetStats `json:"stats,omitempty"`
+}
+
+// targets 处理 GET /admin/v1/targets：路由成员与连接池目标的并集。
+func (a *API) targets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "GET required")
+		return
+	}
+	writeJSON(w, http.StatusOK, a.listTargets())
+}
+
+func (a *API) routed() []string {
+	if a.cfg.Routing == nil {
+		return nil
+	}
+	return a.cfg.Routing.Targets()
+}
+
+type targetRequest struct {
+	Endpoint string `json:"endpoint"`
+}
+
+// target 处理 /admin/v1/targets/{id}：
+//   - PUT 以 endpoint 重建目标（省略 endpoint 时沿用当前地址，用于恢复已摘除的目标），未知 ID 作为新成员加入路由；
+//   - DELETE 将目标移出路由并关闭其连接；
+//   - POST /admin/v1/targets/{id}/drain 摘除目标并关闭其连接，请求顺延到 hash 环上的下一个目标。
+func (a *API) target(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, Prefix+"/targets/")
+	id, action, _ := strings.Cut(rest, "/")
+	if id == "" {
+		writeError(w, http.StatusNotFound, "target id is required")
+		return
+	}
+	switch {
+	case action == "" && r.Method == http.MethodPut:
+		var req targetRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		st, err := a.registerTarget(r.Context(), id, req.Endpoint)
+		writeTarget(w, st, err)
+	case action == "" && r.Method == http.MethodDelete:
+		st, err := a.removeTarget(r.Context(), id)
+		writeTarget(w, st, err)
+	case action == "drain" && r.Method == http.MethodPost:
+		st, err := a.drainTarget(r.Context(), id)
+		writeTarget(w, st, err)
+	case action == "" || action == "drain":
+		writeError(w, http.StatusMethodNotAllowed, "PUT/DELETE /targets/{id} or POST /targets/{id}/drain required")
+	default:
+		writeError(w, http.StatusNotFound, "unknown target action")
+	}
+}
+
+// targetError 是目标管理操作的失败，HTTP 与 gRPC 入口分别将 status 映射为各自的状态码。
+type targetError struct {
+	status int
+	msg    string
+}
+
+func (e *targetError) Error() string { return e.msg }
+
+func writeTarget(w http.ResponseWriter, st targetStatus, err error) {
+	var te *targetError
+	switch {
+	case errors.As(err, &te):
+		writeError(w, te.status, te.msg)
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, st)
+	}
+}
+
+// listTargets 返回路由成员与连接池目标的并集，按 ID 排序。
+func (a *API) listTargets() []*targetStatus {
+	byID := make(map[string]*targetStatus)
+	for _, id := range a.routed() {
+		byID[id] = &targetStatus{ID: id, Routed: true}
+	}
+	for _, st := range a.cfg.Pool.Stats() {
+		st := st
+		t, ok := byID[st.ID]
+		if !ok {
+			t = &targetStatus{ID: st.ID}
+			byID[st.ID] = t
+		}
+		t.Registered, t.Stats = true, &st
+	}
+	out := make([]*targetStatus, 0, len(byID))
+	for _, t := range byID {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (a *API) drainTarget(ctx context.Context, id string) (targetStatus, error) {
+	if !slices.Contains(a.routed(), id) {
+		return targetStatus{}, &targetError{http.StatusNotFound, "unknown routed target"}
+	}
+	if err := a.cfg.Pool.Drain(id); err != nil {
+		if errors.Is(err, enclaveclient.ErrTargetNotFound) {
+			return targetStatus{}, &targetError{http.StatusNotFound, "target is not registered"}
+		}
+		return targetStatus{}, err
+	}
+	a.audit(ctx, "target_drain", slog.String("target", id))
+	return targetStatus{ID: id, Routed: true}, nil
+}
+
+func (a *API) registerTarget(ctx context.Context, id, endpoint string) (targetStatus, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		endpoint = a.registeredEndpoint(id)
+	}
+	if endpoint == "" {
+		return targetStatus{}, &targetError{http.StatusBadRequest, "endpoint is required"}
+	}
+	a.membership.Lock()
+	defer a.membership.Unlock()
+	routed := a.routed()
+	added := !slices.Contains(routed, id)
+	if added && a.cfg.Routing == nil {
+		return targetStatus{}, &targetError{http.StatusNotFound, "unknown routed target"}
+	}
+	if added && a.cfg.DiscoveryManaged {
+		return targetStatus{}, &targetError{http.StatusConflict, "routing membership is managed by discovery"}
+	}
+	// 已摘除的目标连接已关闭，直接更新无效，需移除后重新注册；新成员先建连再加入路由。
+	a.cfg.Pool.RemoveTarget(id)
+	a.cfg.Pool.RegisterTarget(enclaveclient.Target{ID: id, Endpoint: endpoint})
+	if added {
+		if err := a.setRouted(append(routed, id)); err != nil {
+			a.cfg.Pool.RemoveTarget(id)
+			return targetStatus{}, err
+		}
+	}
+	a.audit(ctx, "target_register", slog.String("target", id), slog.String("endpoint", endpoint), slog.Bool("added", added))
+	return targetStatus{ID: id, Routed: true, Registered: true}, nil
+}
+
+func (a *API) removeTarget(ctx context.Context, id string) (targetStatus, error) {
+	a.membership.Lock()
+	defer a.membership.Unlock()
+	routed := a.routed()
+	if !slices.Contains(routed, id) {
+		return targetStatus{}, &targetError{http.StatusNotFound, "unknown routed target"}
+	}
+	if a.cfg.DiscoveryManaged {
+		return targetStatus{}, &targetError{http.StatusConflict, "routing membership is managed by discovery"}
+	}
+	remaining := slices.DeleteFunc(slices.Clone(routed), func(t string) bool { return t == id })
+	if len(remaining) == 0 {
+		return targetStatus{}, &targetError{http.StatusConflict, "cannot remove the last routed target"}
+	}
+	// 先移出路由再关闭连接，避免新请求落到正在关闭的目标。
+	if err := a.setRouted(remaining); err != nil {
+		return targetStatus{}, err
+	}
+	a.cfg.Pool.RemoveTarget(id)
+	a.audit(ctx, "target_remove", slog.String("target", id))
+	return targetStatus{ID: id}, nil
+}
+
+func (a *API) setRouted(ids []string) error {
+	if err := a.cfg.Routing.SetTargets(ids); err != nil {
+		return &targetError{http.StatusBadRequest, err.Error()}
+	}
+	if a.cfg.OnTargetsChanged != nil {
+		a.cfg.OnTargetsChanged(ids)
+	}
+	return nil
+}
+
+func (a *API) registeredEndpoint(id string) string {
+	if st, ok := a.targetStats(id); ok {
+		return st.Endpoint
+	}
+	return ""
+}
+
+func (a *API) targetStats(id string) (enclaveclient.TargetStats, bool) {
+	for _, st := range a.cfg.Pool.Stats() {
+		if st.ID == id {
+			return st, true
+		}
+	}
+	return enclaveclient.TargetStats{}, false
+}
+
+type dispatcherResponse struct {
+	unlock.Snapshot
+	History unlock.History `json:"history"`
+}
+
+// dispatcher 处理 GET /admin/v1/dispatcher：解锁队列快照与累计结果。
+func (a *API) dispatcher(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "GET required")
+		return
+	}
+	writeJSON(w, http.StatusOK, dispatcherResponse{Snapshot: a.cfg.Dispatcher.Snapshot(), History: a.cfg.Dispatcher.History()})
+}
+
+type keyCacheResponse struct {
+	keycache.StoreStats
+	Entries          []keycache.EntryInfo `json:"entries"`
+	EntriesTruncated bool                 `json:"entriesTruncated,omitempty"`
+}
+
+// keyCache 处理 GET /admin/v1/keycache?keyId=&limit=：按 key 排序的 entry 明细，不含明文。
+func (a *API) keyCache(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "GET required")
+		return
+	}
+	query := r.URL.Query()
+	limit := defaultEntryLimit
+	if v := query.Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+	keyID := query.Get("keyId")
+	resp := keyCacheResponse{StoreStats: a.cfg.KeyCache.Stats(), Entries: []keycache.EntryInfo{}}
+	a.cfg.KeyCache.Range(func(e *keycache.Entry) bool {
+		if keyID == "" || e.KeyID() == keyID {
+			resp.Entries = append(resp.Entries, e.Info())
+		}
+		return true
+	})
+	sort.Slice(resp.Entries, func(i, j int) bool {
+		if resp.Entries[i].KeyID != resp.Entries[j].KeyID {
+			return resp.Entries[i].KeyID < resp.Entries[j].KeyID
+		}
+		return resp.Entries[i].DerivationPath < resp.Entries[j].DerivationPath
+	})
+	if len(resp.Entries) > limit {
+		resp.Entries, resp.EntriesTruncated = resp.Entries[:limit], true
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type reloadResult struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// reload 处理 POST /admin/v1/reload[?name=]：依次执行全部或指定的 Reloader，任一失败返回 500。
+func (a *API) reload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+	names := make([]string, 0, len(a.cfg.Reloaders))
+	if name := r.URL.Query().Get("name"); name != "" {
+		if _, ok := a.cfg.Reloaders[name]; !ok {
+			writeError(w, http.StatusNotFound, "unknown reloader")
+			return
+		}
+		names = append(names, name)
+	} else {
+		for name := range a.cfg.Reloaders {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	status := http.StatusOK
+	results := make([]reloadResult, 0, len(names))
+	for _, name := range names {
+		res := reloadResult{Name: name, OK: true}
+		if err := a.cfg.Reloaders[name](r.Context()); err != nil {
+			res.OK, res.Error, status = false, err.Error(), http.StatusInternalServerError
+		}
+		a.audit(r.Context(), "reload", slog.String("name", name), slog.Bool("ok", res.OK))
+		results = append(results, res)
+	}
+	writeJSON(w, status, results)
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,203 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	signerapi "github.com/aegis-sign/wallet/internal/api"
+	"github.com/aegis-sign/wallet/internal/gateway/unlock"
+	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+	"github.com/stretchr/testify/require"
+)
+
+type stubPool struct {
+	cfg     enclaveclient.Config
+	targets map[string]string
+	drained []string
+}
+
+func (p *stubPool) Stats() []enclaveclient.TargetStats {
+	var out []enclaveclient.TargetStats
+	for id, endpoint := range p.targets {
+		out = append(out, enclaveclient.TargetStats{ID: id, Endpoint: endpoint})
+	}
+	return out
+}
+
+func (p *stubPool) Config() enclaveclient.Config          { return p.cfg }
+func (p *stubPool) UpdateConfig(cfg enclaveclient.Config) { p.cfg = cfg }
+func (p *stubPool) RegisterTarget(t enclaveclient.Target) { p.targets[t.ID] = t.Endpoint }
+func (p *stubPool) RemoveTarget(id string)                { delete(p.targets, id) }
+func (p *stubPool) Drain(id string) error {
+	if _, ok := p.targets[id]; !ok {
+		return enclaveclient.ErrTargetNotFound
+	}
+	p.drained = append(p.drained, id)
+	return nil
+}
+
+type stubRouting struct{ ids []string }
+
+func (r *stubRouting) Targets() []string { return append([]string(nil), r.ids...) }
+func (r *stubRouting) SetTargets(ids []string) error {
+	if len(ids) == 0 {
+		return errors.New("at least one enclave target is required")
+	}
+	r.ids = ids
+	return nil
+}
+
+type stubDispatcher struct{}
+
+func (stubDispatcher) Snapshot() unlock.Snapshot { return unlock.Snapshot{QueueDepth: 3, Workers: 2} }
+func (stubDispatcher) History() unlock.History   { return unlock.History{Executed: 7} }
+
+func newTestAPI(t *testing.T, cfg Config) http.Handler {
+	t.Helper()
+	verifier, err := signerapi.NewAPIKeyVerifier([]signerapi.APIKey{
+		{Subject: "ops", Key: "admin-key", Roles: []string{"admin"}},
+		{Subject: "viewer", Key: "viewer-key"},
+	})
+	require.NoError(t, err)
+	cfg.Verifier = verifier
+	cfg.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	api, err := New(cfg)
+	require.NoError(t, err)
+	routes := signerapi.NewRoutes()
+	api.Register(routes.Group(signerapi.RouteAdmin))
+	return routes.Mux(signerapi.RouteAdmin)
+}
+
+func do(t *testing.T, h http.Handler, method, path, key, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(signerapi.APIKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminRequiresOwnCredentialsAndRole(t *testing.T) {
+	_, err := New(Config{})
+	require.Error(t, err)
+
+	h := newTestAPI(t, Config{Role: "admin", Pool: &stubPool{targets: map[string]string{}}})
+	require.Equal(t, http.StatusUnauthorized, do(t, h, http.MethodGet, "/admin/v1/pool", "", "").Code)
+	require.Equal(t, http.StatusForbidden, do(t, h, http.MethodGet, "/admin/v1/pool", "viewer-key", "").Code)
+	require.Equal(t, http.StatusOK, do(t, h, http.MethodGet, "/admin/v1/pool", "admin-key", "").Code)
+	// 未配置的来源不注册端点。
+	require.Equal(t, http.StatusNotFound, do(t, h, http.MethodGet, "/admin/v1/dispatcher", "admin-key", "").Code)
+}
+
+func TestAdminPoolConfig(t *testing.T) {
+	pool := &stubPool{cfg: enclaveclient.DefaultConfig(), targets: map[string]string{}}
+	h := newTestAPI(t, Config{Pool: pool})
+
+	rec := do(t, h, http.MethodPut, "/admin/v1/pool/config", "admin-key", `{"maxConns":64,"acquireTimeoutMs":100}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, 64, pool.cfg.MaxConns)
+	require.Equal(t, 16, pool.cfg.MinConns)
+	require.Equal(t, 100*time.Millisecond, pool.cfg.AcquireTimeout)
+
+	require.Equal(t, http.StatusBadRequest, do(t, h, http.MethodPut, "/admin/v1/pool/config", "admin-key", `{"minConns":128}`).Code)
+	require.Equal(t, http.StatusBadRequest, do(t, h, http.MethodPut, "/admin/v1/pool/config", "admin-key", `{"unknown":1}`).Code)
+	require.Equal(t, 64, pool.cfg.MaxConns)
+}
+
+func TestAdminTargets(t *testing.T) {
+	pool := &stubPool{targets: map[string]string{"e1": "vsock://3:5000", "e2": "vsock://4:5000"}}
+	h := newTestAPI(t, Config{Pool: pool, Routing: &stubRouting{ids: []string{"e1", "e2"}}})
+
+	rec := do(t, h, http.MethodPost, "/admin/v1/targets/e1/drain", "admin-key", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, []string{"e1"}, pool.drained)
+
+	rec = do(t, h, http.MethodPut, "/admin/v1/targets/e2", "admin-key", `{"endpoint":"vsock://9:5000"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "vsock://9:5000", pool.targets["e2"])
+
+	// 省略 endpoint 时沿用当前地址。
+	rec = do(t, h, http.MethodPut, "/admin/v1/targets/e1", "admin-key", `{}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "vsock://3:5000", pool.targets["e1"])
+	require.Equal(t, http.StatusNotFound, do(t, h, http.MethodPost, "/admin/v1/targets/e3/drain", "admin-key", "").Code)
+	require.Equal(t, http.StatusMethodNotAllowed, do(t, h, http.MethodGet, "/admin/v1/targets/e1/drain", "admin-key", "").Code)
+
+	rec = do(t, h, http.MethodGet, "/admin/v1/targets", "admin-key", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var targets []targetStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &targets))
+	require.Len(t, targets, 2)
+	require.True(t, targets[0].Routed && targets[0].Registered)
+}
+
+func TestAdminTargetMembership(t *testing.T) {
+	pool := &stubPool{targets: map[string]string{"e1": "vsock://3:5000", "e2": "vsock://4:5000"}}
+	routing := &stubRouting{ids: []string{"e1", "e2"}}
+	var changed []string
+	h := newTestAPI(t, Config{Pool: pool, Routing: routing, OnTargetsChanged: func(ids []string) { changed = ids }})
+
+	require.Equal(t, http.StatusBadRequest, do(t, h, http.MethodPut, "/admin/v1/targets/e3", "admin-key", `{}`).Code)
+	rec := do(t, h, http.MethodPut, "/admin/v1/targets/e3", "admin-key", `{"endpoint":"vsock://5:5000"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, []string{"e1", "e2", "e3"}, routing.ids)
+	require.Equal(t, "vsock://5:5000", pool.targets["e3"])
+	require.Equal(t, routing.ids, changed)
+
+	rec = do(t, h, http.MethodDelete, "/admin/v1/targets/e1", "admin-key", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, []string{"e2", "e3"}, routing.ids)
+	require.NotContains(t, pool.targets, "e1")
+	require.Equal(t, http.StatusNotFound, do(t, h, http.MethodDelete, "/admin/v1/targets/e1", "admin-key", "").Code)
+
+	require.Equal(t, http.StatusOK, do(t, h, http.MethodDelete, "/admin/v1/targets/e2", "admin-key", "").Code)
+	require.Equal(t, http.StatusConflict, do(t, h, http.MethodDelete, "/admin/v1/targets/e3", "admin-key", "").Code)
+	require.Equal(t, []string{"e3"}, routing.ids)
+
+	// 成员由动态发现维护时只允许重建与摘除。
+	managed := newTestAPI(t, Config{Pool: pool, Routing: routing, DiscoveryManaged: true})
+	require.Equal(t, http.StatusConflict, do(t, managed, http.MethodPut, "/admin/v1/targets/e9", "admin-key", `{"endpoint":"x"}`).Code)
+	require.Equal(t, http.StatusConflict, do(t, managed, http.MethodDelete, "/admin/v1/targets/e3", "admin-key", "").Code)
+	require.Equal(t, http.StatusOK, do(t, managed, http.MethodPut, "/admin/v1/targets/e3", "admin-key", `{}`).Code)
+	require.NotContains(t, pool.targets, "e9")
+}
+
+func TestAdminDispatcherAndReload(t *testing.T) {
+	calls := 0
+	h := newTestAPI(t, Config{
+		Dispatcher: stubDispatcher{},
+		Reloaders: map[string]Reloader{
+			"ok":     func(context.Context) error { calls++; return nil },
+			"broken": func(context.Context) error { return errors.New("bad file") },
+		},
+	})
+
+	rec := do(t, h, http.MethodGet, "/admin/v1/dispatcher", "admin-key", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var snap dispatcherResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &snap))
+	require.Equal(t, 3, snap.QueueDepth)
+	require.EqualValues(t, 7, snap.History.Executed)
+
+	rec = do(t, h, http.MethodPost, "/admin/v1/reload?name=ok", "admin-key", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, 1, calls)
+
+	rec = do(t, h, http.MethodPost, "/admin/v1/reload", "admin-key", "")
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	var results []reloadResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+	require.Equal(t, []reloadResult{{Name: "broken", Error: "bad file"}, {Name: "ok", OK: true}}, results)
+
+	require.Equal(t, http.StatusNotFound, do(t, h, http.MethodPost, "/admin/v1/reload?name=missing", "admin-key", "").Code)
+}
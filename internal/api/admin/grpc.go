@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	signerapi "github.com/aegis-sign/wallet/internal/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCServerOptions 返回管理 gRPC 服务端的拦截器：与业务入口相同的 RecoveryInterceptor 与 AuthInterceptor，
+// 但以管理凭证认证，随后按 Role 校验角色，与 HTTP 管理端点的检查一致。
+func (a *API) GRPCServerOptions() []grpc.ServerOption {
+	recovery := signerapi.RecoveryInterceptor(a.cfg.Logger)
+	auth := signerapi.AuthInterceptor(signerapi.MetadataAuthenticator(a.cfg.Verifier))
+	return []grpc.ServerOption{grpc.ChainUnaryInterceptor(recovery.Unary, auth.Unary, a.requireRoleUnary)}
+}
+
+func (a *API) requireRoleUnary(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if !a.hasRole(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "admin role required")
+	}
+	return handler(ctx, req)
+}
+
+// RegisterGRPC 注册 AdminService，未配置 Pool 时不注册。s 须使用 GRPCServerOptions 构造，否则调用不经认证。
+func (a *API) RegisterGRPC(s grpc.ServiceRegistrar) {
+	if a.cfg.Pool == nil {
+		return
+	}
+	signerv1.RegisterAdminServiceServer(s, &grpcServer{api: a})
+}
+
+// grpcServer 以 gRPC 暴露目标管理，与 /admin/v1/targets 共用实现与审计日志。
+type grpcServer struct {
+	signerv1.UnimplementedAdminServiceServer
+	api *API
+}
+
+func (s *grpcServer) ListTargets(context.Context, *signerv1.ListTargetsRequest) (*signerv1.ListTargetsResponse, error) {
+	targets := s.api.listTargets()
+	resp := &signerv1.ListTargetsResponse{Targets: make([]*signerv1.AdminTarget, 0, len(targets))}
+	for _, t := range targets {
+		resp.Targets = append(resp.Targets, toAdminTarget(*t))
+	}
+	return resp, nil
+}
+
+func (s *grpcServer) RegisterTarget(ctx context.Context, req *signerv1.RegisterTargetRequest) (*signerv1.AdminTarget, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "target id is required")
+	}
+	return s.result(s.api.registerTarget(ctx, req.GetId(), req.GetEndpoint()))
+}
+
+func (s *grpcServer) DrainTarget(ctx context.Context, req *signerv1.DrainTargetRequest) (*signerv1.AdminTarget, error) {
+	return s.result(s.api.drainTarget(ctx, req.GetId()))
+}
+
+func (s *grpcServer) RemoveTarget(ctx context.Context, req *signerv1.RemoveTargetRequest) (*signerv1.AdminTarget, error) {
+	return s.result(s.api.removeTarget(ctx, req.GetId()))
+}
+
+// result 补全目标当前的连接池状态，并将 targetError 映射为 gRPC 状态码。
+func (s *grpcServer) result(st targetStatus, err error) (*signerv1.AdminTarget, error) {
+	var te *targetError
+	if errors.As(err, &te) {
+		return nil, status.Error(grpcCode(te.status), te.msg)
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if stats, ok := s.api.targetStats(st.ID); ok {
+		st.Registered, st.Stats = true, &stats
+	}
+	return toAdminTarget(st), nil
+}
+
+func toAdminTarget(st targetStatus) *signerv1.AdminTarget {
+	t := &signerv1.AdminTarget{Id: st.ID, Routed: st.Routed, Registered: st.Registered}
+	if st.Stats != nil {
+		t.Endpoint, t.Breaker = st.Stats.Endpoint, st.Stats.Breaker
+	}
+	return t
+}
+
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.FailedPrecondition
+	default:
+		return codes.Internal
+	}
+}
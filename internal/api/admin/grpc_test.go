@@ -0,0 +1,103 @@
+package admin
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	signerapi "github.com/aegis-sign/wallet/internal/api"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func dialAdminGRPC(t *testing.T, cfg Config) signerv1.AdminServiceClient {
+	t.Helper()
+	verifier, err := signerapi.NewAPIKeyVerifier([]signerapi.APIKey{
+		{Subject: "ops", Key: "admin-key", Roles: []string{"admin"}},
+		{Subject: "viewer", Key: "viewer-key"},
+	})
+	require.NoError(t, err)
+	cfg.Verifier = verifier
+	cfg.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	api, err := New(cfg)
+	require.NoError(t, err)
+	srv := grpc.NewServer(api.GRPCServerOptions()...)
+	api.RegisterGRPC(srv)
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///admin",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return signerv1.NewAdminServiceClient(conn)
+}
+
+func withKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+}
+
+func TestAdminGRPCRequiresCredentialsAndRole(t *testing.T) {
+	client := dialAdminGRPC(t, Config{Role: "admin", Pool: &stubPool{targets: map[string]string{}}})
+
+	_, err := client.ListTargets(context.Background(), &signerv1.ListTargetsRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.ListTargets(withKey("viewer-key"), &signerv1.ListTargetsRequest{})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.ListTargets(withKey("admin-key"), &signerv1.ListTargetsRequest{})
+	require.NoError(t, err)
+}
+
+func TestAdminGRPCTargets(t *testing.T) {
+	pool := &stubPool{targets: map[string]string{"e1": "vsock://3:5000", "e2": "vsock://4:5000"}}
+	routing := &stubRouting{ids: []string{"e1", "e2"}}
+	client := dialAdminGRPC(t, Config{Role: "admin", Pool: pool, Routing: routing})
+	ctx := withKey("admin-key")
+
+	list, err := client.ListTargets(ctx, &signerv1.ListTargetsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetTargets(), 2)
+	require.Equal(t, "e1", list.GetTargets()[0].GetId())
+	require.Equal(t, "vsock://3:5000", list.GetTargets()[0].GetEndpoint())
+
+	target, err := client.DrainTarget(ctx, &signerv1.DrainTargetRequest{Id: "e1"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"e1"}, pool.drained)
+	require.True(t, target.GetRouted())
+	_, err = client.DrainTarget(ctx, &signerv1.DrainTargetRequest{Id: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	target, err = client.RegisterTarget(ctx, &signerv1.RegisterTargetRequest{Id: "e3", Endpoint: "vsock://5:5000"})
+	require.NoError(t, err)
+	require.True(t, target.GetRegistered())
+	require.Equal(t, "vsock://5:5000", target.GetEndpoint())
+	require.Equal(t, []string{"e1", "e2", "e3"}, routing.ids)
+	_, err = client.RegisterTarget(ctx, &signerv1.RegisterTargetRequest{Id: "e4"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	target, err = client.RemoveTarget(ctx, &signerv1.RemoveTargetRequest{Id: "e2"})
+	require.NoError(t, err)
+	require.False(t, target.GetRegistered())
+	require.Equal(t, []string{"e1", "e3"}, routing.ids)
+	require.NotContains(t, pool.targets, "e2")
+}
+
+func TestAdminGRPCDiscoveryManaged(t *testing.T) {
+	pool := &stubPool{targets: map[string]string{"e1": "vsock://3:5000", "e2": "vsock://4:5000"}}
+	client := dialAdminGRPC(t, Config{Pool: pool, Routing: &stubRouting{ids: []string{"e1", "e2"}}, DiscoveryManaged: true})
+
+	_, err := client.RemoveTarget(withKey("admin-key"), &signerv1.RemoveTargetRequest{Id: "e1"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = client.RegisterTarget(withKey("admin-key"), &signerv1.RegisterTargetRequest{Id: "e9", Endpoint: "vsock://9:5000"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
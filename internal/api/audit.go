@@ -0,0 +1,124 @@
+package signerapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/internal/audit"
+)
+
+// withAuditContext 将请求携带的审计字段写入上下文，中间件与 handler 统一经 reqctx 读取。
+func withAuditContext(ctx context.Context, audit *signerv1.AuditContext) context.Context {
+	if audit == nil {
+		return ctx
+	}
+	ctx = reqctx.WithRequestID(ctx, audit.GetRequestId())
+	return reqctx.WithTenantID(ctx, audit.GetTenantId())
+}
+
+// withAuditHeaders 将 body 中的审计字段直接写入上下文，不再构造中间的 AuditContext。
+func withAuditHeaders(ctx context.Context, headers *auditHeaders) context.Context {
+	if headers == nil || (headers.RequestID == "" && headers.TenantID == "") {
+		return ctx
+	}
+	ctx = reqctx.WithRequestID(ctx, headers.RequestID)
+	return reqctx.WithTenantID(ctx, headers.TenantID)
+}
+
+// auditContextFrom 从上下文还原下发给 Enclave 的审计字段，均为空时返回 nil。
+func auditContextFrom(ctx context.Context) *signerv1.AuditContext {
+	requestID, hasRequest := reqctx.RequestIDFrom(ctx)
+	tenantID, hasTenant := reqctx.TenantIDFrom(ctx)
+	if !hasRequest && !hasTenant {
+		return nil
+	}
+	return &signerv1.AuditContext{RequestId: requestID, TenantId: tenantID}
+}
+
+// AuditConfig 配置 AuditMiddleware。
+type AuditConfig struct {
+	Auditor audit.Auditor
+	// FailClosed 为 true 时审计写入失败使本次调用返回错误（即使 Enclave 已完成操作），否则仅记录日志。
+	FailClosed bool
+	Logger     *slog.Logger
+}
+
+// AuditMiddleware 为每次 Create/ImportKey/Sign（含失败与被拒绝的调用）写入审计记录，
+// 记录实际路由到的 Enclave；Auditor 为空时返回 nil，由 Chain 跳过。
+func AuditMiddleware(cfg AuditConfig) BackendMiddleware {
+	if cfg.Auditor == nil {
+		return nil
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	// sign 仅在签名时非空，用于记录摘要、派生路径与服务端哈希算法。
+	record := func(ctx context.Context, op, keyID string, sign *signerv1.SignRequest, start time.Time, err error) error {
+		ev := audit.Event{
+			Time:           start.UTC(),
+			Operation:      op,
+			KeyID:          keyID,
+			DigestSHA256:   audit.DigestHash(sign.GetDigest()),
+			DerivationPath: sign.GetDerivationPath(),
+			HashAlgorithm:  sign.GetHashAlgorithm(),
+			Enclave:        audit.TargetFrom(ctx),
+			Result:         errorCodeLabel(err),
+			LatencyMs:      float64(time.Since(start).Microseconds()) / 1000,
+		}
+		ev.TenantID, _ = reqctx.TenantIDFrom(ctx)
+		ev.RequestID, _ = reqctx.RequestIDFrom(ctx)
+		if p, ok := reqctx.PrincipalFrom(ctx); ok {
+			ev.Principal = p.Subject
+		}
+		// 审计写入不受调用方取消影响。
+		recErr := cfg.Auditor.Record(context.WithoutCancel(ctx), ev)
+		if recErr == nil {
+			return nil
+		}
+		cfg.Logger.ErrorContext(ctx, "audit record failed", "operation", op, "key", keyID, "error", recErr)
+		if cfg.FailClosed {
+			return fmt.Errorf("audit record failed: %w", recErr)
+		}
+		return nil
+	}
+	return func(next Backend) Backend {
+		return BackendFuncs{
+			Next: next,
+			CreateFunc: func(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+				ctx = audit.TrackTarget(ctx)
+				start := time.Now()
+				resp, err := next.Create(ctx, req)
+				if recErr := record(ctx, audit.OpCreate, resp.GetKeyId(), nil, start, err); recErr != nil && err == nil {
+					return nil, recErr
+				}
+				return resp, err
+			},
+			ImportFunc: func(ctx context.Context, req *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+				ctx = audit.TrackTarget(ctx)
+				start := time.Now()
+				resp, err := next.ImportKey(ctx, req)
+				if recErr := record(ctx, audit.OpImport, resp.GetKeyId(), nil, start, err); recErr != nil && err == nil {
+					return nil, recErr
+				}
+				return resp, err
+			},
+			SignFunc: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+				ctx = audit.TrackTarget(ctx)
+				start := time.Now()
+				resp, err := next.Sign(ctx, req)
+				op := audit.OpSign
+				if req.GetDryRun() {
+					op = audit.OpSignDryRun
+				}
+				if recErr := record(ctx, op, req.GetKeyId(), req, start, err); recErr != nil && err == nil {
+					return nil, recErr
+				}
+				return resp, err
+			},
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package signerapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/internal/audit"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryAuditor struct {
+	mu     sync.Mutex
+	events []audit.Event
+	err    error
+}
+
+func (a *memoryAuditor) Record(_ context.Context, ev audit.Event) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, ev)
+	return a.err
+}
+
+func (a *memoryAuditor) Close() error { return nil }
+
+func TestAuditMiddlewareRecordsEnclaveAndResult(t *testing.T) {
+	pool, _, _ := newTestPool(t)
+	enclave, err := NewEnclaveBackend(pool, StaticTargetSelector{TargetID: "enclave-1"})
+	require.NoError(t, err)
+	auditor := &memoryAuditor{}
+	readOnly := NewReadOnlyMode(true)
+	backend := Chain(enclave, AuditMiddleware(AuditConfig{Auditor: auditor}), ReadOnlyMiddleware(readOnly))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx = reqctx.WithTenantID(reqctx.WithRequestID(ctx, "req-1"), "tenant-a")
+	ctx = reqctx.WithPrincipal(ctx, reqctx.Principal{Subject: "svc-a"})
+
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "k1", Digest: []byte("payload")})
+	require.NoError(t, err)
+	_, err = backend.Create(ctx, &signerv1.CreateRequest{})
+	require.Error(t, err)
+
+	require.Len(t, auditor.events, 2)
+	sign := auditor.events[0]
+	require.Equal(t, audit.OpSign, sign.Operation)
+	require.Equal(t, "k1", sign.KeyID)
+	require.Equal(t, "tenant-a", sign.TenantID)
+	require.Equal(t, "req-1", sign.RequestID)
+	require.Equal(t, "svc-a", sign.Principal)
+	require.Equal(t, "enclave-1", sign.Enclave)
+	require.Equal(t, audit.DigestHash([]byte("payload")), sign.DigestSHA256)
+	require.Equal(t, "OK", sign.Result)
+
+	create := auditor.events[1]
+	require.Equal(t, audit.OpCreate, create.Operation)
+	require.Equal(t, string(apierrors.CodeReadOnly), create.Result)
+	require.Empty(t, create.Enclave)
+}
+
+func TestAuditMiddlewareFailClosed(t *testing.T) {
+	require.Nil(t, AuditMiddleware(AuditConfig{}))
+
+	auditor := &memoryAuditor{err: errors.New("disk full")}
+	stub := &stubBackend{signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		return &signerv1.SignResponse{Signature: []byte{1}}, nil
+	}}
+	open := Chain(stub, AuditMiddleware(AuditConfig{Auditor: auditor}))
+	resp, err := open.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k1"})
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+
+	closed := Chain(stub, AuditMiddleware(AuditConfig{Auditor: auditor, FailClosed: true}))
+	resp, err = closed.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k1"})
+	require.ErrorContains(t, err, "disk full")
+	require.Nil(t, resp)
+}
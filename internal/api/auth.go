@@ -0,0 +1,457 @@
+package signerapi
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+)
+
+// APIKeyHeader 是 HTTP 请求中携带 API key 的头，等价于 Authorization: Bearer <key>。
+const APIKeyHeader = "X-API-Key"
+
+const (
+	defaultTenantClaim = "tenant_id"
+	defaultJWTLeeway   = 30 * time.Second
+)
+
+// ErrUnauthenticated 表示凭证缺失或无效；具体原因只写日志，不返回给调用方。
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// TokenVerifier 校验 API key 或 JWT bearer token。
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (reqctx.Principal, error)
+}
+
+// Verifiers 依次尝试各 verifier，任一通过即认证成功。
+type Verifiers []TokenVerifier
+
+// Verify 实现 TokenVerifier。
+func (vs Verifiers) Verify(ctx context.Context, token string) (reqctx.Principal, error) {
+	for _, v := range vs {
+		if id, err := v.Verify(ctx, token); err == nil {
+			return id, nil
+		}
+	}
+	return reqctx.Principal{}, ErrUnauthenticated
+}
+
+// APIKey 描述一个静态 API key；Key 与 KeySHA256（十六进制）二选一，推荐只保存哈希。
+type APIKey struct {
+	Subject   string   `json:"subject"`
+	Key       string   `json:"key,omitempty"`
+	KeySHA256 string   `json:"keySha256,omitempty"`
+	TenantID  string   `json:"tenantId,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+}
+
+type apiKeyEntry struct {
+	hash      [sha256.Size]byte
+	principal reqctx.Principal
+}
+
+// APIKeyVerifier 以常数时间比较 key 的 SHA-256 校验静态 API key。
+type APIKeyVerifier struct {
+	entries []apiKeyEntry
+}
+
+// NewAPIKeyVerifier 构造 APIKeyVerifier，subject 缺失、key 缺失或重复时返回错误。
+func NewAPIKeyVerifier(keys []APIKey) (*APIKeyVerifier, error) {
+	v := &APIKeyVerifier{}
+	seen := make(map[[sha256.Size]byte]struct{}, len(keys))
+	for _, k := range keys {
+		if k.Subject == "" {
+			return nil, errors.New("api key subject is required")
+		}
+		var hash [sha256.Size]byte
+		switch {
+		case k.Key != "" && k.KeySHA256 != "":
+			return nil, fmt.Errorf("api key for %q: set only one of key and keySha256", k.Subject)
+		case k.Key != "":
+			hash = sha256.Sum256([]byte(k.Key))
+		case k.KeySHA256 != "":
+			raw, err := hex.DecodeString(k.KeySHA256)
+			if err != nil || len(raw) != sha256.Size {
+				return nil, fmt.Errorf("api key for %q: keySha256 must be 64 hex characters", k.Subject)
+			}
+			copy(hash[:], raw)
+		default:
+			return nil, fmt.Errorf("api key for %q: key or keySha256 is required", k.Subject)
+		}
+		if _, dup := seen[hash]; dup {
+			return nil, fmt.Errorf("api key for %q duplicates another entry", k.Subject)
+		}
+		seen[hash] = struct{}{}
+		v.entries = append(v.entries, apiKeyEntry{hash: hash, principal: reqctx.Principal{
+			Subject:  k.Subject,
+			Roles:    append([]string(nil), k.Roles...),
+			TenantID: k.TenantID,
+		}})
+	}
+	return v, nil
+}
+
+// Verify 实现 TokenVerifier；遍历全部条目以避免按位置泄露时序信息。
+func (v *APIKeyVerifier) Verify(_ context.Context, token string) (reqctx.Principal, error) {
+	hash := sha256.Sum256([]byte(token))
+	var (
+		found reqctx.Principal
+		ok    bool
+	)
+	for _, e := range v.entries {
+		if subtle.ConstantTimeCompare(hash[:], e.hash[:]) == 1 {
+			found, ok = e.principal, true
+		}
+	}
+	if !ok {
+		return reqctx.Principal{}, ErrUnauthenticated
+	}
+	return found, nil
+}
+
+// JWTConfig 配置 JWTVerifier；HMACSecret 与 RSAPublicKey 至少设置一个，分别对应 HS256 与 RS256。
+type JWTConfig struct {
+	Issuer   string
+	Audience string
+	// HMACSecret 启用 HS256。
+	HMACSecret []byte
+	// RSAPublicKey 启用 RS256。
+	RSAPublicKey *rsa.PublicKey
+	// TenantClaim 为租户 claim 名，默认 tenant_id。
+	TenantClaim string
+	// Leeway 为 exp/nbf 的时钟偏差容忍，默认 30s。
+	Leeway time.Duration
+	// Now 默认 time.Now。
+	Now func() time.Time
+}
+
+// JWTVerifier 校验紧凑格式的 JWT：签名、exp（必需）、nbf、iss、aud 与 sub（必需）。
+type JWTVerifier struct {
+	cfg JWTConfig
+}
+
+// NewJWTVerifier 构造 JWTVerifier。
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	if len(cfg.HMACSecret) == 0 && cfg.RSAPublicKey == nil {
+		return nil, errors.New("jwt verifier requires an HMAC secret or RSA public key")
+	}
+	if cfg.TenantClaim == "" {
+		cfg.TenantClaim = defaultTenantClaim
+	}
+	if cfg.Leeway <= 0 {
+		cfg.Leeway = defaultJWTLeeway
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &JWTVerifier{cfg: cfg}, nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+// Verify 实现 TokenVerifier。
+func (v *JWTVerifier) Verify(_ context.Context, token string) (reqctx.Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return reqctx.Principal{}, fmt.Errorf("%w: malformed jwt", ErrUnauthenticated)
+	}
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return reqctx.Principal{}, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return reqctx.Principal{}, fmt.Errorf("%w: malformed jwt signature", ErrUnauthenticated)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	// 只接受已配置密钥对应的算法，避免 alg 混淆攻击。
+	switch {
+	case header.Alg == "HS256" && len(v.cfg.HMACSecret) > 0:
+		mac := hmac.New(sha256.New, v.cfg.HMACSecret)
+		mac.Write(signed)
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return reqctx.Principal{}, fmt.Errorf("%w: bad jwt signature", ErrUnauthenticated)
+		}
+	case header.Alg == "RS256" && v.cfg.RSAPublicKey != nil:
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(v.cfg.RSAPublicKey, crypto.SHA256, digest[:], sig); err != nil {
+			return reqctx.Principal{}, fmt.Errorf("%w: bad jwt signature", ErrUnauthenticated)
+		}
+	default:
+		return reqctx.Principal{}, fmt.Errorf("%w: unsupported jwt alg %q", ErrUnauthenticated, header.Alg)
+	}
+	var claims map[string]any
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return reqctx.Principal{}, err
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return reqctx.Principal{}, err
+	}
+	sub, _ := claims["sub"].(string)
+	tenant, _ := claims[v.cfg.TenantClaim].(string)
+	return reqctx.Principal{Subject: sub, Roles: stringList(claims["roles"]), TenantID: tenant}, nil
+}
+
+func (v *JWTVerifier) checkClaims(claims map[string]any) error {
+	now := v.cfg.Now()
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return fmt.Errorf("%w: jwt exp is required", ErrUnauthenticated)
+	}
+	if now.After(time.Unix(exp, 0).Add(v.cfg.Leeway)) {
+		return fmt.Errorf("%w: jwt expired", ErrUnauthenticated)
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(v.cfg.Leeway).Before(time.Unix(nbf, 0)) {
+		return fmt.Errorf("%w: jwt not yet valid", ErrUnauthenticated)
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return fmt.Errorf("%w: jwt sub is required", ErrUnauthenticated)
+	}
+	if v.cfg.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
+			return fmt.Errorf("%w: jwt issuer mismatch", ErrUnauthenticated)
+		}
+	}
+	if v.cfg.Audience != "" && !containsString(stringList(claims["aud"]), v.cfg.Audience) {
+		return fmt.Errorf("%w: jwt audience mismatch", ErrUnauthenticated)
+	}
+	return nil
+}
+
+func decodeJWTSegment(segment string, out any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: malformed jwt segment", ErrUnauthenticated)
+	}
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.UseNumber()
+	if err := dec.Decode(out); err != nil {
+		return fmt.Errorf("%w: malformed jwt json", ErrUnauthenticated)
+	}
+	return nil
+}
+
+func numericClaim(claims map[string]any, name string) (int64, bool) {
+	n, ok := claims[name].(json.Number)
+	if !ok {
+		return 0, false
+	}
+	if v, err := n.Int64(); err == nil {
+		return v, true
+	}
+	f, err := n.Float64()
+	return int64(f), err == nil
+}
+
+// stringList 接受字符串或字符串数组形式的 claim（如 aud、roles）。
+func stringList(v any) []string {
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case []any:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func containsString(list []string, want string) bool {
+	for _, s := range list {
+		if s == want {
+			return true
+		}
+	}
+	return false
+}
+
+// CredentialsFile 是 SIGNER_AUTH_CREDENTIALS_FILE 的 JSON 结构。
+type CredentialsFile struct {
+	APIKeys []APIKey `json:"apiKeys"`
+	JWT     *struct {
+		Issuer   string `json:"issuer"`
+		Audience string `json:"audience"`
+		// HS256Secret 为 HS256 共享密钥原文。
+		HS256Secret string `json:"hs256Secret"`
+		// RS256PublicKeyFile 为 PEM 格式（PKIX）的 RSA 公钥路径。
+		RS256PublicKeyFile string `json:"rs256PublicKeyFile"`
+		TenantClaim        string `json:"tenantClaim"`
+		LeewaySeconds      int    `json:"leewaySeconds"`
+	} `json:"jwt"`
+}
+
+// LoadCredentials 从 JSON 文件构造 TokenVerifier，文件中未声明任何凭证时返回错误。
+func LoadCredentials(path string) (TokenVerifier, error) {
+	verifiers, err := loadCredentials(path)
+	if err != nil {
+		return nil, err
+	}
+	return verifiers, nil
+}
+
+func loadCredentials(path string) (Verifiers, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read credentials: %w", err)
+	}
+	var file CredentialsFile
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("parse credentials: %w", err)
+	}
+	var verifiers Verifiers
+	if len(file.APIKeys) > 0 {
+		keys, err := NewAPIKeyVerifier(file.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		verifiers = append(verifiers, keys)
+	}
+	if file.JWT != nil {
+		cfg := JWTConfig{
+			Issuer:      file.JWT.Issuer,
+			Audience:    file.JWT.Audience,
+			HMACSecret:  []byte(file.JWT.HS256Secret),
+			TenantClaim: file.JWT.TenantClaim,
+			Leeway:      time.Duration(file.JWT.LeewaySeconds) * time.Second,
+		}
+		if file.JWT.RS256PublicKeyFile != "" {
+			if cfg.RSAPublicKey, err = loadRSAPublicKey(file.JWT.RS256PublicKeyFile); err != nil {
+				return nil, err
+			}
+		}
+		jwt, err := NewJWTVerifier(cfg)
+		if err != nil {
+			return nil, err
+		}
+		verifiers = append(verifiers, jwt)
+	}
+	if len(verifiers) == 0 {
+		return nil, errors.New("credentials file declares no apiKeys or jwt")
+	}
+	return verifiers, nil
+}
+
+// CredentialsReloader 是可在运行时重新加载凭证文件的 TokenVerifier；重新加载失败时继续使用原凭证。
+type CredentialsReloader struct {
+	path    string
+	current atomic.Pointer[Verifiers]
+}
+
+// NewCredentialsReloader 加载 path 并构造 CredentialsReloader。
+func NewCredentialsReloader(path string) (*CredentialsReloader, error) {
+	r := &CredentialsReloader{path: path}
+	if err := r.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload 重新读取凭证文件，校验通过后原子替换；在途请求不受影响。
+func (r *CredentialsReloader) Reload(context.Context) error {
+	verifiers, err := loadCredentials(r.path)
+	if err != nil {
+		return err
+	}
+	r.current.Store(&verifiers)
+	return nil
+}
+
+// Verify 实现 TokenVerifier。
+func (r *CredentialsReloader) Verify(ctx context.Context, token string) (reqctx.Principal, error) {
+	return r.current.Load().Verify(ctx, token)
+}
+
+func loadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read jwt public key: %w", err)
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("jwt public key is not PEM encoded")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse jwt public key: %w", err)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("jwt public key is not RSA")
+	}
+	return rsaPub, nil
+}
+
+// withAuthenticated 将认证结果写入上下文，凭证绑定的租户同时作为请求租户。
+func withAuthenticated(ctx context.Context, p reqctx.Principal) context.Context {
+	ctx = reqctx.WithPrincipal(ctx, p)
+	return reqctx.WithTenantID(ctx, p.TenantID)
+}
+
+// bearerToken 从 Authorization: Bearer 或 X-API-Key 取出凭证。
+func bearerToken(authorization, apiKey string) string {
+	if token, ok := strings.CutPrefix(authorization, "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(apiKey)
+}
+
+// RequireAuth 包装 Router，使其上注册的每个 handler 都先经 verifier 认证，失败返回 401 UNAUTHENTICATED。
+// verifier 为 nil 时原样返回 router。
+func RequireAuth(router Router, verifier TokenVerifier) Router {
+	if verifier == nil {
+		return router
+	}
+	return &authRouter{next: router, verifier: verifier}
+}
+
+type authRouter struct {
+	next     Router
+	verifier TokenVerifier
+}
+
+func (r *authRouter) Handle(pattern string, handler http.Handler) {
+	r.next.Handle(pattern, r.wrap(handler))
+}
+
+func (r *authRouter) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	r.Handle(pattern, http.HandlerFunc(handler))
+}
+
+func (r *authRouter) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token := bearerToken(req.Header.Get("Authorization"), req.Header.Get(APIKeyHeader))
+		id, err := r.verifier.Verify(req.Context(), token)
+		if token == "" || err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="signer"`)
+			apiErr := apierrors.New(apierrors.CodeUnauthenticated, "missing or invalid credentials")
+			writeJSONResponse(w, apierrors.HTTPStatus(apiErr.Code), errorResponse{Code: string(apiErr.Code), Message: apiErr.Message})
+			return
+		}
+		next.ServeHTTP(w, req.WithContext(withAuthenticated(req.Context(), id)))
+	})
+}
@@ -0,0 +1,238 @@
+package signerapi
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func signHS256(t *testing.T, secret []byte, claims map[string]any) string {
+	t.Helper()
+	signed := jwtSigningInput(t, "HS256", claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, claims map[string]any) string {
+	t.Helper()
+	signed := jwtSigningInput(t, "RS256", claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func jwtSigningInput(t *testing.T, alg string, claims map[string]any) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+}
+
+func TestAPIKeyVerifier(t *testing.T) {
+	sum := sha256.Sum256([]byte("hashed-key"))
+	v, err := NewAPIKeyVerifier([]APIKey{
+		{Subject: "svc-a", Key: "plain-key", TenantID: "t1", Roles: []string{"signer"}},
+		{Subject: "svc-b", KeySHA256: hex.EncodeToString(sum[:])},
+	})
+	require.NoError(t, err)
+
+	id, err := v.Verify(context.Background(), "plain-key")
+	require.NoError(t, err)
+	require.Equal(t, reqctx.Principal{Subject: "svc-a", Roles: []string{"signer"}, TenantID: "t1"}, id)
+	id, err = v.Verify(context.Background(), "hashed-key")
+	require.NoError(t, err)
+	require.Equal(t, "svc-b", id.Subject)
+	_, err = v.Verify(context.Background(), "unknown")
+	require.ErrorIs(t, err, ErrUnauthenticated)
+
+	for _, keys := range [][]APIKey{
+		{{Key: "k"}},
+		{{Subject: "a"}},
+		{{Subject: "a", Key: "k", KeySHA256: hex.EncodeToString(sum[:])}},
+		{{Subject: "a", KeySHA256: "zz"}},
+		{{Subject: "a", Key: "k"}, {Subject: "b", Key: "k"}},
+	} {
+		_, err := NewAPIKeyVerifier(keys)
+		require.Error(t, err, keys)
+	}
+}
+
+func TestJWTVerifier(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	secret := []byte("hs-secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	v, err := NewJWTVerifier(JWTConfig{
+		Issuer:       "idp",
+		Audience:     "signer",
+		HMACSecret:   secret,
+		RSAPublicKey: &rsaKey.PublicKey,
+		Now:          func() time.Time { return now },
+	})
+	require.NoError(t, err)
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{"sub": "user-1", "iss": "idp", "aud": []string{"other", "signer"}, "exp": now.Add(time.Minute).Unix(), "tenant_id": "t1", "roles": []string{"admin"}}
+		for k, val := range overrides {
+			if val == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = val
+		}
+		return c
+	}
+
+	id, err := v.Verify(context.Background(), signHS256(t, secret, claims(nil)))
+	require.NoError(t, err)
+	require.Equal(t, reqctx.Principal{Subject: "user-1", Roles: []string{"admin"}, TenantID: "t1"}, id)
+	_, err = v.Verify(context.Background(), signRS256(t, rsaKey, claims(nil)))
+	require.NoError(t, err)
+	// exp 在 leeway 内仍然有效。
+	_, err = v.Verify(context.Background(), signHS256(t, secret, claims(map[string]any{"exp": now.Add(-10 * time.Second).Unix()})))
+	require.NoError(t, err)
+
+	rejected := map[string]string{
+		"expired":      signHS256(t, secret, claims(map[string]any{"exp": now.Add(-time.Minute).Unix()})),
+		"no exp":       signHS256(t, secret, claims(map[string]any{"exp": nil})),
+		"no sub":       signHS256(t, secret, claims(map[string]any{"sub": nil})),
+		"not before":   signHS256(t, secret, claims(map[string]any{"nbf": now.Add(time.Minute).Unix()})),
+		"issuer":       signHS256(t, secret, claims(map[string]any{"iss": "evil"})),
+		"audience":     signHS256(t, secret, claims(map[string]any{"aud": "other"})),
+		"wrong secret": signHS256(t, []byte("other"), claims(nil)),
+		"alg none":     jwtSigningInput(t, "none", claims(nil)) + ".",
+		"malformed":    "a.b",
+	}
+	for name, token := range rejected {
+		_, err := v.Verify(context.Background(), token)
+		require.ErrorIs(t, err, ErrUnauthenticated, name)
+	}
+
+	// 仅配置 RS256 时不接受以公钥作为 HMAC 密钥伪造的 HS256 token。
+	rsOnly, err := NewJWTVerifier(JWTConfig{RSAPublicKey: &rsaKey.PublicKey, Now: func() time.Time { return now }})
+	require.NoError(t, err)
+	_, err = rsOnly.Verify(context.Background(), signHS256(t, secret, claims(nil)))
+	require.ErrorIs(t, err, ErrUnauthenticated)
+
+	_, err = NewJWTVerifier(JWTConfig{})
+	require.Error(t, err)
+}
+
+func TestRequireAuth(t *testing.T) {
+	verifier, err := NewAPIKeyVerifier([]APIKey{{Subject: "svc-a", Key: "s3cret", TenantID: "t1"}})
+	require.NoError(t, err)
+	mux := http.NewServeMux()
+	var (
+		seen       reqctx.Principal
+		seenTenant string
+	)
+	RequireAuth(mux, verifier).HandleFunc("/sign", func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = reqctx.PrincipalFrom(r.Context())
+		seenTenant, _ = reqctx.TenantIDFrom(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for _, header := range []http.Header{{}, {"Authorization": {"Bearer wrong"}}, {"Authorization": {"Basic s3cret"}}} {
+		req := httptest.NewRequest(http.MethodPost, "/sign", nil)
+		req.Header = header
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+		require.Contains(t, rr.Body.String(), `"UNAUTHENTICATED"`)
+		require.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+	}
+
+	for _, header := range [][2]string{{"Authorization", "Bearer s3cret"}, {APIKeyHeader, "s3cret"}} {
+		req := httptest.NewRequest(http.MethodPost, "/sign", nil)
+		req.Header.Set(header[0], header[1])
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		require.Equal(t, reqctx.Principal{Subject: "svc-a", TenantID: "t1"}, seen)
+		require.Equal(t, "t1", seenTenant)
+	}
+
+	require.Same(t, mux, RequireAuth(mux, nil))
+}
+
+func TestMetadataAuthenticator(t *testing.T) {
+	verifier, err := NewStaticTokenVerifier(map[string]string{"s3cret": "svc-a"})
+	require.NoError(t, err)
+	authn := MetadataAuthenticator(verifier)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(apiKeyMetadataKey, "s3cret"))
+	id, err := authn(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "svc-a", id.Subject)
+
+	_, err = authn(context.Background())
+	require.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestLoadCredentials(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	pubPath := filepath.Join(dir, "jwt.pub")
+	require.NoError(t, os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	path := filepath.Join(dir, "credentials.json")
+	raw := `{"apiKeys":[{"subject":"svc-a","key":"s3cret"}],"jwt":{"rs256PublicKeyFile":"` + pubPath + `"}}`
+	require.NoError(t, os.WriteFile(path, []byte(raw), 0o600))
+	verifier, err := LoadCredentials(path)
+	require.NoError(t, err)
+
+	_, err = verifier.Verify(context.Background(), "s3cret")
+	require.NoError(t, err)
+	id, err := verifier.Verify(context.Background(), signRS256(t, rsaKey, map[string]any{"sub": "user-1", "exp": time.Now().Add(time.Minute).Unix()}))
+	require.NoError(t, err)
+	require.Equal(t, "user-1", id.Subject)
+
+	for _, bad := range []string{`{}`, `{"apiKeys":[],"unknown":1}`, `{"jwt":{}}`, `not json`} {
+		require.NoError(t, os.WriteFile(path, []byte(bad), 0o600))
+		_, err := LoadCredentials(path)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestCredentialsReloaderKeepsPreviousOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"apiKeys":[{"subject":"svc-a","key":"old"}]}`), 0o600))
+	reloader, err := NewCredentialsReloader(path)
+	require.NoError(t, err)
+	_, err = reloader.Verify(context.Background(), "old")
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"apiKeys":[{"subject":"svc-a","key":"new"}]}`), 0o600))
+	require.NoError(t, reloader.Reload(context.Background()))
+	_, err = reloader.Verify(context.Background(), "old")
+	require.ErrorIs(t, err, ErrUnauthenticated)
+
+	// 文件损坏时保留上一次成功加载的凭证。
+	require.NoError(t, os.WriteFile(path, []byte(`not json`), 0o600))
+	require.Error(t, reloader.Reload(context.Background()))
+	_, err = reloader.Verify(context.Background(), "new")
+	require.NoError(t, err)
+}
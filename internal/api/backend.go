@@ -6,8 +6,36 @@ import (
 	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
 )
 
-// Backend 定义业务层接口，HTTP/gRPC handler 通过它与实际 signer 交互。
-type Backend interface {
+// Creator 负责在 Enclave 端创建 key。
+type Creator interface {
 	Create(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error)
+}
+
+// Signer 负责使用已有 key 对摘要签名。
+type Signer interface {
 	Sign(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error)
 }
+
+// Importer 负责将外部生成的私钥导入 Enclave。
+type Importer interface {
+	ImportKey(ctx context.Context, req *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error)
+}
+
+// PublicKeyGetter 负责按 keyId 查询公钥与地址。
+type PublicKeyGetter interface {
+	GetPublicKey(ctx context.Context, req *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error)
+}
+
+// Disabler 负责停用或删除 key，并转发至所属 Enclave。
+type Disabler interface {
+	DisableKey(ctx context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error)
+}
+
+// Backend 定义业务层接口，HTTP/gRPC handler 通过它与实际 signer 交互。
+type Backend interface {
+	Creator
+	Importer
+	Signer
+	PublicKeyGetter
+	Disabler
+}
@@ -0,0 +1,167 @@
+package signerapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/internal/audit"
+	"github.com/aegis-sign/wallet/internal/policy"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultBackupAdminRole 是导出 key 备份所需的默认角色。
+const DefaultBackupAdminRole = "admin"
+
+// KeyBackupExporter 从 key 所属 Enclave 取回加密备份，EnclaveBackend 实现该接口。
+type KeyBackupExporter interface {
+	ExportKeyBackup(ctx context.Context, req *signerv1.ExportKeyBackupRequest) (*signerv1.ExportKeyBackupResponse, error)
+}
+
+// KeyBackupConfig 配置 ExportKeyBackup 的门禁与审计。
+type KeyBackupConfig struct {
+	Exporter KeyBackupExporter
+	// AdminRole 为调用方凭证必须携带的角色，默认 admin；未认证的调用一律拒绝。
+	AdminRole string
+	// Policy 可选，设置时 key 还须授予调用方租户。
+	Policy *policy.Policy
+	// Auditor 必填，每次尝试（含被拒绝的）都写入审计；写入失败时不返回备份。
+	Auditor audit.Auditor
+	Logger  *slog.Logger
+}
+
+func (c KeyBackupConfig) withDefaults() KeyBackupConfig {
+	if c.AdminRole == "" {
+		c.AdminRole = DefaultBackupAdminRole
+	}
+	if c.Logger == nil {
+		c.Logger = slog.Default()
+	}
+	return c
+}
+
+// KeyBackup 对 ExportKeyBackup 做管理员门禁、租户策略校验与强制审计。
+type KeyBackup struct {
+	cfg KeyBackupConfig
+}
+
+// NewKeyBackup 构造 KeyBackup；Exporter 或 Auditor 为空时返回 nil（不开放导出）。
+func NewKeyBackup(cfg KeyBackupConfig) *KeyBackup {
+	if cfg.Exporter == nil || cfg.Auditor == nil {
+		return nil
+	}
+	return &KeyBackup{cfg: cfg.withDefaults()}
+}
+
+// Export 校验请求与调用方后转发到 Enclave，并在返回前写入审计。
+func (b *KeyBackup) Export(ctx context.Context, req *signerv1.ExportKeyBackupRequest) (*signerv1.ExportKeyBackupResponse, error) {
+	ctx = withAuditContext(audit.TrackTarget(ctx), req.GetAuditContext())
+	start := time.Now()
+	resp, err := b.export(ctx, req)
+	ev := audit.Event{
+		Time:      start.UTC(),
+		Operation: audit.OpExportBackup,
+		KeyID:     req.GetKeyId(),
+		Enclave:   audit.TargetFrom(ctx),
+		Reason:    req.GetReason(),
+		Result:    errorCodeLabel(err),
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err == nil {
+		ev.BlobSHA256 = audit.DigestHash(resp.GetEncryptedKey())
+	}
+	// 租户以凭证为准，与策略校验使用同一来源。
+	ev.TenantID, _ = requestTenant(ctx, req.GetAuditContext())
+	ev.RequestID, _ = reqctx.RequestIDFrom(ctx)
+	if p, ok := reqctx.PrincipalFrom(ctx); ok {
+		ev.Principal = p.Subject
+	}
+	b.cfg.Logger.WarnContext(ctx, "key backup export",
+		"principal", ev.Principal, "key", ev.KeyID, "reason", ev.Reason, "enclave", ev.Enclave, "code", ev.Result)
+	// 审计不受调用方取消影响，且始终 fail-closed：没有审计记录的备份不得离开服务。
+	if recErr := b.cfg.Auditor.Record(context.WithoutCancel(ctx), ev); recErr != nil {
+		b.cfg.Logger.ErrorContext(ctx, "audit record failed", "operation", audit.OpExportBackup, "key", ev.KeyID, "error", recErr)
+		if err == nil {
+			return nil, fmt.Errorf("audit record failed: %w", recErr)
+		}
+	}
+	return resp, err
+}
+
+func (b *KeyBackup) export(ctx context.Context, req *signerv1.ExportKeyBackupRequest) (*signerv1.ExportKeyBackupResponse, error) {
+	if req.GetKeyId() == "" {
+		return nil, apierrors.New(apierrors.CodeInvalidArgument, "key_id is required")
+	}
+	if req.GetReason() == "" {
+		return nil, apierrors.New(apierrors.CodeInvalidArgument, "reason is required")
+	}
+	p, ok := reqctx.PrincipalFrom(ctx)
+	if !ok {
+		return nil, apierrors.New(apierrors.CodeUnauthenticated, "key backup export requires authentication")
+	}
+	if !slices.Contains(p.Roles, b.cfg.AdminRole) {
+		return nil, apierrors.New(apierrors.CodePermissionDenied, "key backup export requires role "+b.cfg.AdminRole)
+	}
+	if b.cfg.Policy != nil {
+		tenant, err := requestTenant(ctx, req.GetAuditContext())
+		if err != nil {
+			return nil, err
+		}
+		if !b.cfg.Policy.Allowed(tenant, req.GetKeyId()) {
+			return nil, apierrors.New(apierrors.CodePermissionDenied, "key is not granted to tenant")
+		}
+	}
+	resp, err := b.cfg.Exporter.ExportKeyBackup(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.GetEncryptedKey()) == 0 || len(resp.GetEncryptedDataKey()) == 0 {
+		return nil, apierrors.New(apierrors.CodeEnclaveUnavailable, "enclave returned an incomplete key backup")
+	}
+	return resp, nil
+}
+
+// SetKeyBackup 启用 ExportKeyBackup，nil 时返回 UNIMPLEMENTED。
+func (s *GRPCServer) SetKeyBackup(b *KeyBackup) {
+	s.backup = b
+}
+
+// ExportKeyBackup 仅限管理员导出加密 key 备份，见 KeyBackup.Export。
+func (s *GRPCServer) ExportKeyBackup(ctx context.Context, req *signerv1.ExportKeyBackupRequest) (*signerv1.ExportKeyBackupResponse, error) {
+	if s.backup == nil {
+		return nil, status.Error(codes.Unimplemented, "key backup export is not enabled")
+	}
+	resp, err := s.backup.Export(ctx, req)
+	if err != nil {
+		return nil, s.grpcError(ctx, err)
+	}
+	return resp, nil
+}
+
+// ExportKeyBackup 沿签名的粘性路由从 key 所属 Enclave 取回加密备份。
+func (b *EnclaveBackend) ExportKeyBackup(ctx context.Context, req *signerv1.ExportKeyBackupRequest) (_ *signerv1.ExportKeyBackupResponse, err error) {
+	target, pinned := PinnedTarget(ctx)
+	if !pinned {
+		target, err = b.selector.SelectForSign(ctx, &signerv1.SignRequest{KeyId: req.GetKeyId()})
+	}
+	if err != nil {
+		return nil, err
+	}
+	audit.RecordTarget(ctx, target)
+	lease, err := b.pool.Acquire(ctx, target)
+	if err != nil {
+		return nil, translateAcquireError(err)
+	}
+	defer func() { lease.Release(err) }()
+	callCtx, cancel := b.callContext(ctx)
+	defer cancel()
+	req.AuditContext = mergeAuditContext(ctx, req.GetAuditContext())
+	resp, err := lease.Client().ExportKeyBackup(callCtx, req)
+	return resp, callerError(ctx, err)
+}
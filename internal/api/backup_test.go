@@ -0,0 +1,111 @@
+package signerapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/internal/audit"
+	"github.com/aegis-sign/wallet/internal/policy"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type exporterFunc func(ctx context.Context, req *signerv1.ExportKeyBackupRequest) (*signerv1.ExportKeyBackupResponse, error)
+
+func (f exporterFunc) ExportKeyBackup(ctx context.Context, req *signerv1.ExportKeyBackupRequest) (*signerv1.ExportKeyBackupResponse, error) {
+	return f(ctx, req)
+}
+
+func testBackupExporter(calls *int) exporterFunc {
+	return func(_ context.Context, req *signerv1.ExportKeyBackupRequest) (*signerv1.ExportKeyBackupResponse, error) {
+		*calls++
+		return &signerv1.ExportKeyBackupResponse{
+			KeyId:            req.GetKeyId(),
+			Curve:            "secp256k1",
+			EncryptedKey:     []byte{0x01, 0x02},
+			EncryptedDataKey: []byte{0x03},
+			KmsKeyId:         "arn:aws:kms:kms-1",
+			Algorithm:        "AES-256-GCM",
+		}, nil
+	}
+}
+
+func TestExportKeyBackupGating(t *testing.T) {
+	p, err := policy.New([]policy.Rule{{TenantID: "t1", KeyPrefixes: []string{"t1/"}}})
+	require.NoError(t, err)
+	auditor := &memoryAuditor{}
+	calls := 0
+	srv := NewGRPCServer(&stubBackend{}, nil)
+	srv.SetKeyBackup(NewKeyBackup(KeyBackupConfig{Exporter: testBackupExporter(&calls), Policy: p, Auditor: auditor}))
+
+	admin := reqctx.WithPrincipal(context.Background(), reqctx.Principal{Subject: "ops-1", Roles: []string{"admin"}, TenantID: "t1"})
+	signer := reqctx.WithPrincipal(context.Background(), reqctx.Principal{Subject: "svc-a", Roles: []string{"signer"}, TenantID: "t1"})
+	cases := []struct {
+		name string
+		ctx  context.Context
+		req  *signerv1.ExportKeyBackupRequest
+		code codes.Code
+	}{
+		{"unauthenticated", context.Background(), &signerv1.ExportKeyBackupRequest{KeyId: "t1/k", Reason: "dr"}, codes.Unauthenticated},
+		{"missing role", signer, &signerv1.ExportKeyBackupRequest{KeyId: "t1/k", Reason: "dr"}, codes.PermissionDenied},
+		{"missing reason", admin, &signerv1.ExportKeyBackupRequest{KeyId: "t1/k"}, codes.InvalidArgument},
+		{"other tenant key", admin, &signerv1.ExportKeyBackupRequest{KeyId: "t2/k", Reason: "dr"}, codes.PermissionDenied},
+	}
+	for _, tc := range cases {
+		_, err := srv.ExportKeyBackup(tc.ctx, tc.req)
+		require.Equal(t, tc.code, status.Code(err), tc.name)
+	}
+	require.Zero(t, calls)
+	// 被拒绝的尝试同样留痕。
+	require.Len(t, auditor.events, len(cases))
+	require.Equal(t, "PERMISSION_DENIED", auditor.events[1].Result)
+	require.Equal(t, "svc-a", auditor.events[1].Principal)
+
+	resp, err := srv.ExportKeyBackup(admin, &signerv1.ExportKeyBackupRequest{KeyId: "t1/k", Reason: "DR drill 2026-10"})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x01, 0x02}, resp.GetEncryptedKey())
+	require.Equal(t, 1, calls)
+	ev := auditor.events[len(auditor.events)-1]
+	require.Equal(t, audit.OpExportBackup, ev.Operation)
+	require.Equal(t, "OK", ev.Result)
+	require.Equal(t, "ops-1", ev.Principal)
+	require.Equal(t, "t1", ev.TenantID)
+	require.Equal(t, "DR drill 2026-10", ev.Reason)
+	require.Equal(t, audit.DigestHash([]byte{0x01, 0x02}), ev.BlobSHA256)
+}
+
+func TestExportKeyBackupWithholdsBlobWhenAuditFails(t *testing.T) {
+	calls := 0
+	srv := NewGRPCServer(&stubBackend{}, nil)
+	srv.SetKeyBackup(NewKeyBackup(KeyBackupConfig{
+		Exporter: testBackupExporter(&calls),
+		Auditor:  &memoryAuditor{err: errors.New("sink down")},
+	}))
+	ctx := reqctx.WithPrincipal(context.Background(), reqctx.Principal{Subject: "ops-1", Roles: []string{"admin"}})
+	resp, err := srv.ExportKeyBackup(ctx, &signerv1.ExportKeyBackupRequest{KeyId: "k1", Reason: "dr"})
+	require.Error(t, err)
+	require.Nil(t, resp)
+	require.Equal(t, 1, calls)
+}
+
+func TestExportKeyBackupDisabled(t *testing.T) {
+	require.Nil(t, NewKeyBackup(KeyBackupConfig{Exporter: testBackupExporter(new(int))}))
+	srv := NewGRPCServer(&stubBackend{}, nil)
+	_, err := srv.ExportKeyBackup(context.Background(), &signerv1.ExportKeyBackupRequest{KeyId: "k1", Reason: "dr"})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	// 即使 gRPC 已启用导出，/v2 网关也不暴露该方法。
+	srv.SetKeyBackup(NewKeyBackup(KeyBackupConfig{Exporter: testBackupExporter(new(int)), Auditor: &memoryAuditor{}}))
+	mux := http.NewServeMux()
+	NewHTTPHandler(&stubBackend{}, WithGateway(srv, nil)).Register(mux)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v2/ExportKeyBackup", strings.NewReader(`{"keyId":"k1","reason":"dr"}`)))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,191 @@
+package signerapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+)
+
+const (
+	defaultBatchMaxItems       = 64
+	defaultBatchConcurrency    = 16
+	defaultBatchStreamMaxItems = 100000
+)
+
+// BatchConfig 控制 /sign/batch 与 BatchSign 的规模。
+type BatchConfig struct {
+	// MaxItems 为单批最多条数，默认 64；超出时整批返回 INVALID_ARGUMENT。
+	MaxItems int
+	// Concurrency 为单批同时处理的 key 分组数，默认 16。
+	Concurrency int
+	// StreamMaxItems 为流式响应模式（NDJSON/SSE）下单批最多条数，默认 100000。
+	StreamMaxItems int
+}
+
+func (c BatchConfig) withDefaults() BatchConfig {
+	if c.MaxItems <= 0 {
+		c.MaxItems = defaultBatchMaxItems
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = defaultBatchConcurrency
+	}
+	if c.StreamMaxItems <= 0 {
+		c.StreamMaxItems = defaultBatchStreamMaxItems
+	}
+	return c
+}
+
+type batchSignRequestBody struct {
+	Items        []signRequestBody `json:"items"`
+	AuditHeaders *auditHeaders     `json:"auditHeaders"`
+}
+
+// batchSignResult 与请求 items 一一对应；成功时含 signature/recId，失败时仅含 error。
+type batchSignResult struct {
+	KeyID     string         `json:"keyId"`
+	Signature string         `json:"signature,omitempty"`
+	RecID     *uint32        `json:"recId,omitempty"`
+	DryRun    bool           `json:"dryRun,omitempty"`
+	Error     *errorResponse `json:"error,omitempty"`
+}
+
+type batchSignResponseBody struct {
+	Results []batchSignResult `json:"results"`
+}
+
+// batchResult 是单条签名的结果，resp 与 err 二选一。
+type batchResult struct {
+	resp *signerv1.SignResponse
+	err  error
+}
+
+// signBatch 按 keyId 分组：同一 key 的条目在一个 goroutine 内顺序执行，保持粘性路由且不重复触发刷新；
+// 不同 key 的分组经连接池并发，最多 concurrency 组同时进行。reqs 中为 nil 的条目被跳过（调用方已判定失败）。
+func signBatch(ctx context.Context, backend Backend, reqs []*signerv1.SignRequest, concurrency int) []batchResult {
+	results := make([]batchResult, len(reqs))
+	signBatchEach(ctx, backend, reqs, concurrency, func(i int, res batchResult) {
+		results[i] = res
+	})
+	return results
+}
+
+// signBatchEach 与 signBatch 的调度相同，但每完成一条即调用 emit；emit 会在多个 goroutine 中并发调用。
+func signBatchEach(ctx context.Context, backend Backend, reqs []*signerv1.SignRequest, concurrency int, emit func(int, batchResult)) {
+	groups := make(map[string][]int)
+	var order []string
+	for i, req := range reqs {
+		if req == nil {
+			continue
+		}
+		key := req.GetKeyId()
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+	sem := make(chan struct{}, max(1, concurrency))
+	var wg sync.WaitGroup
+	for _, key := range order {
+		idx := groups[key]
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			for _, i := range idx {
+				resp, err := backend.Sign(ctx, reqs[i])
+				emit(i, batchResult{resp: resp, err: err})
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// handleSignBatch 处理 POST /sign/batch：仅请求体非法、items 为空或超限时整体返回 400，其余失败逐条返回。
+func (h *HTTPHandler) handleSignBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "POST required"))
+		return
+	}
+	cfg := h.batch.withDefaults()
+	mode := batchStreamMode(r.Header.Get("Accept"))
+	maxBytes, maxItems := h.body.withDefaults().BatchMaxBytes, cfg.MaxItems
+	if mode != "" {
+		maxBytes, maxItems = h.body.withDefaults().StreamMaxBytes, cfg.StreamMaxItems
+	}
+	var body batchSignRequestBody
+	if apiErr := h.decodeJSONLimit(w, r, maxBytes, &body, false); apiErr != nil {
+		h.writeAPIError(w, apiErr)
+		return
+	}
+	if len(body.Items) == 0 {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "items is required"))
+		return
+	}
+	if len(body.Items) > maxItems {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, fmt.Sprintf("batch exceeds %d items", maxItems)))
+		return
+	}
+	ctx := withAuditHeaders(r.Context(), body.AuditHeaders)
+	results := make([]batchSignResult, len(body.Items))
+	reqs := make([]*signerv1.SignRequest, len(body.Items))
+	for i := range body.Items {
+		item := &body.Items[i]
+		results[i].KeyID = item.KeyID
+		req, apiErr := decodeSignBody(item)
+		if apiErr != nil {
+			results[i].Error = &errorResponse{Code: string(apiErr.Code), Message: apiErr.Error()}
+			continue
+		}
+		req.AuditContext = auditContextFrom(withAuditHeaders(ctx, item.AuditHeaders))
+		reqs[i] = req
+	}
+	if mode != "" {
+		h.streamSignBatch(ctx, w, mode, reqs, results, cfg.Concurrency)
+		return
+	}
+	for i, res := range signBatch(ctx, h.backend, reqs, cfg.Concurrency) {
+		if reqs[i] != nil {
+			h.fillBatchResult(ctx, &results[i], res)
+		}
+	}
+	h.writeJSON(w, http.StatusOK, batchSignResponseBody{Results: results})
+}
+
+// fillBatchResult 将单条签名结果写入 out。
+func (h *HTTPHandler) fillBatchResult(ctx context.Context, out *batchSignResult, res batchResult) {
+	if res.err != nil {
+		out.Error = h.itemError(ctx, out.KeyID, res.err)
+		return
+	}
+	payload := newSignResponseBody(res.resp)
+	out.Signature, out.RecID, out.DryRun = payload.Signature, payload.RecID, payload.DryRun
+}
+
+// itemError 将单条失败转换为 error 字段；UNLOCK_REQUIRED 同样触发解锁入队并给出退避提示。
+func (h *HTTPHandler) itemError(ctx context.Context, keyID string, err error) *errorResponse {
+	apiErr, ok := apierrors.FromError(err)
+	if !ok {
+		h.logger.Error("http batch item internal error", "keyId", keyID, "err", err)
+		return &errorResponse{Code: "INTERNAL_ERROR", Message: "internal error"}
+	}
+	resp := &errorResponse{Code: string(apiErr.Code), Message: apiErr.Error(), RetryAfterHint: apiErr.RetryAfterHint()}
+	switch {
+	case apiErr.Code == apierrors.CodeUnlockRequired:
+		retry := 100 * time.Millisecond
+		if h.unlock != nil {
+			if meta := h.unlock.Handle(ctx, keyID, err); meta.RetryAfter > 0 {
+				retry = meta.RetryAfter
+			}
+		}
+		resp.RetryAfterHint = formatRetryAfterHint(retry)
+	case apiErr.Code == apierrors.CodeRetryLater && !apiErr.HasRetryAfter() && h.hints != nil:
+		resp.RetryAfterHint = formatRetryAfterHint(h.hints.HintForError(apiErr))
+	}
+	return resp
+}
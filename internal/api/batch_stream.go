@@ -0,0 +1,131 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+)
+
+const (
+	// NDJSONContentType 选择 /sign/batch 的 NDJSON 流式响应：每行一个结果，最后一行为汇总。
+	NDJSONContentType = "application/x-ndjson"
+	// EventStreamContentType 选择 /sign/batch 的 SSE 流式响应：result 事件逐条返回，done 事件为汇总。
+	EventStreamContentType = "text/event-stream"
+)
+
+// batchStreamItem 是流式模式下的单条结果，index 对应请求 items 的下标。
+type batchStreamItem struct {
+	Index int `json:"index"`
+	batchSignResult
+}
+
+// batchStreamSummary 在全部结果之后输出。
+type batchStreamSummary struct {
+	Done   bool `json:"done"`
+	Total  int  `json:"total"`
+	Failed int  `json:"failed"`
+}
+
+// batchStreamMode 从 Accept 头选出流式响应类型，未请求流式时返回空串。
+func batchStreamMode(accept string) string {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case NDJSONContentType, EventStreamContentType:
+			return mediaType
+		}
+	}
+	return ""
+}
+
+// batchStreamWriter 按所选格式逐条写出结果。
+type batchStreamWriter struct {
+	w    http.ResponseWriter
+	rc   *http.ResponseController
+	mode string
+	err  error
+}
+
+func (s *batchStreamWriter) write(event string, payload any) {
+	if s.err != nil {
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		s.err = err
+		return
+	}
+	if s.mode == EventStreamContentType {
+		_, s.err = io.WriteString(s.w, "event: "+event+"\ndata: "+string(data)+"\n\n")
+		return
+	}
+	_, s.err = s.w.Write(append(data, '\n'))
+}
+
+func (s *batchStreamWriter) flush() {
+	if s.err != nil {
+		return
+	}
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		s.err = err
+	}
+}
+
+// streamSignBatch 在每条签名完成时立即写出结果（顺序为完成顺序，以 index 对应请求），
+// 避免十万级批量在内存中攒齐后才响应。已判定失败的条目（reqs[i] 为 nil）最先写出。
+// 客户端断开后请求上下文被取消，剩余条目快速失败，不再写出。
+func (h *HTTPHandler) streamSignBatch(ctx context.Context, w http.ResponseWriter, mode string, reqs []*signerv1.SignRequest, results []batchSignResult, concurrency int) {
+	w.Header().Set("Content-Type", mode)
+	w.Header().Set("Cache-Control", "no-cache")
+	// 关闭反向代理（如 nginx）的响应缓冲。
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	out := &batchStreamWriter{w: w, rc: http.NewResponseController(w), mode: mode}
+	summary := batchStreamSummary{Done: true, Total: len(reqs)}
+
+	for i, req := range reqs {
+		if req == nil {
+			summary.Failed++
+			out.write("result", batchStreamItem{Index: i, batchSignResult: results[i]})
+		}
+	}
+	out.flush()
+
+	type completed struct {
+		index int
+		res   batchResult
+	}
+	done := make(chan completed, max(1, concurrency))
+	go func() {
+		defer close(done)
+		signBatchEach(ctx, h.backend, reqs, concurrency, func(i int, res batchResult) {
+			done <- completed{index: i, res: res}
+		})
+	}()
+	for c := range done {
+		item := batchStreamItem{Index: c.index, batchSignResult: results[c.index]}
+		h.fillBatchResult(ctx, &item.batchSignResult, c.res)
+		if item.Error != nil {
+			summary.Failed++
+		}
+		out.write("result", item)
+		// 队列中没有待写结果时再刷新，高吞吐下合并多条写出。
+		if len(done) == 0 {
+			out.flush()
+		}
+	}
+	out.write("done", summary)
+	out.flush()
+	if out.err != nil {
+		h.logger.WarnContext(ctx, "batch stream write failed", "error", out.err)
+	}
+}
@@ -0,0 +1,168 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// batchBackend 按 keyId 决定结果，并检测同一 key 是否被并发调用。
+type batchBackend struct {
+	stubBackend
+	mu         sync.Mutex
+	active     map[string]int
+	overlapped bool
+}
+
+func newBatchBackend() *batchBackend {
+	b := &batchBackend{active: make(map[string]int)}
+	b.signFn = func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		key := req.GetKeyId()
+		b.mu.Lock()
+		b.active[key]++
+		if b.active[key] > 1 {
+			b.overlapped = true
+		}
+		b.mu.Unlock()
+		time.Sleep(time.Millisecond)
+		b.mu.Lock()
+		b.active[key]--
+		b.mu.Unlock()
+		switch key {
+		case "locked":
+			return nil, keycache.NewUnlockRequiredError("dek expired", 0)
+		case "unknown":
+			return nil, apierrors.New(apierrors.CodeInvalidKey, "unknown key")
+		}
+		return &signerv1.SignResponse{Signature: append([]byte(key+":"), req.GetDigest()[0]), RecId: proto.Uint32(1)}, nil
+	}
+	return b
+}
+
+func TestHandleSignBatchPerItemResults(t *testing.T) {
+	queue := &httpUnlockQueue{}
+	backend := newBatchBackend()
+	handler := NewHTTPHandler(backend,
+		WithUnlockResponder(NewUnlockResponder(UnlockResponderConfig{Queue: queue, Keyspace: "prod"})),
+		WithBatchConfig(BatchConfig{MaxItems: 8, Concurrency: 4}))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	item := func(key string, b byte) string {
+		return fmt.Sprintf(`{"keyId":%q,"digest":"%s"}`, key, strings.Repeat(fmt.Sprintf("%02x", b), 32))
+	}
+	items := []string{item("k1", 1), item("k2", 2), item("k1", 3), `{"keyId":"k3","digest":"zz"}`, item("locked", 4), item("unknown", 5), item("k1", 6)}
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sign/batch", strings.NewReader(`{"items":[`+strings.Join(items, ",")+`]}`)))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var body batchSignResponseBody
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	require.Len(t, body.Results, len(items))
+
+	for i, want := range map[int]string{0: "k1:\x01", 1: "k2:\x02", 2: "k1:\x03", 6: "k1:\x06"} {
+		res := body.Results[i]
+		require.Nil(t, res.Error, i)
+		require.Equal(t, encodeSignature([]byte(want)), res.Signature, i)
+		require.Equal(t, uint32(1), *res.RecID)
+	}
+	require.Equal(t, "k3", body.Results[3].KeyID)
+	require.Equal(t, string(apierrors.CodeInvalidArgument), body.Results[3].Error.Code)
+	require.Equal(t, string(apierrors.CodeUnlockRequired), body.Results[4].Error.Code)
+	require.NotEmpty(t, body.Results[4].Error.RetryAfterHint)
+	require.Equal(t, "locked", queue.lastEvent.KeyID)
+	require.Equal(t, string(apierrors.CodeInvalidKey), body.Results[5].Error.Code)
+	require.False(t, backend.overlapped, "items of the same key must be signed sequentially")
+
+	for _, payload := range []string{`{"items":[]}`, `{"items":[` + strings.Repeat(item("k1", 1)+",", 8) + item("k1", 1) + `]}`} {
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sign/batch", strings.NewReader(payload)))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestHandleSignBatchStreamsResults(t *testing.T) {
+	handler := NewHTTPHandler(newBatchBackend(), WithBatchConfig(BatchConfig{MaxItems: 2, Concurrency: 2}))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+	digest := strings.Repeat("01", 32)
+	items := []string{
+		`{"keyId":"k1","digest":"` + digest + `"}`,
+		`{"keyId":"k2","digest":"zz"}`,
+		`{"keyId":"unknown","digest":"` + digest + `"}`,
+		`{"keyId":"k1","digest":"` + digest + `"}`,
+	}
+	post := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/sign/batch", strings.NewReader(`{"items":[`+strings.Join(items, ",")+`]}`))
+		req.Header.Set("Accept", accept)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// 流式模式不受 MaxItems 约束，结果按完成顺序返回并以 index 对应请求。
+	rr := post("application/x-ndjson")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, NDJSONContentType, rr.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	require.Len(t, lines, len(items)+1)
+	byIndex := make(map[int]batchStreamItem)
+	for _, line := range lines[:len(items)] {
+		var item batchStreamItem
+		require.NoError(t, json.Unmarshal([]byte(line), &item))
+		byIndex[item.Index] = item
+	}
+	require.Len(t, byIndex, len(items))
+	require.Equal(t, encodeSignature([]byte("k1:\x01")), byIndex[3].Signature)
+	require.Equal(t, string(apierrors.CodeInvalidArgument), byIndex[1].Error.Code)
+	require.Equal(t, "unknown", byIndex[2].KeyID)
+	require.Equal(t, string(apierrors.CodeInvalidKey), byIndex[2].Error.Code)
+	var summary batchStreamSummary
+	require.NoError(t, json.Unmarshal([]byte(lines[len(items)]), &summary))
+	require.Equal(t, batchStreamSummary{Done: true, Total: 4, Failed: 2}, summary)
+
+	rr = post("text/event-stream; q=0.9, application/json")
+	require.Equal(t, EventStreamContentType, rr.Header().Get("Content-Type"))
+	require.Equal(t, len(items), strings.Count(rr.Body.String(), "event: result\ndata: {"))
+	require.True(t, strings.HasSuffix(rr.Body.String(), "event: done\ndata: {\"done\":true,\"total\":4,\"failed\":2}\n\n"))
+
+	// 非流式请求仍受 MaxItems 约束。
+	require.Equal(t, http.StatusBadRequest, post("application/json").Code)
+}
+
+func TestGRPCBatchSign(t *testing.T) {
+	server := NewGRPCServer(newBatchBackend(), nil)
+	server.SetBatchConfig(BatchConfig{MaxItems: 4})
+	resp, err := server.BatchSign(context.Background(), &signerv1.BatchSignRequest{Items: []*signerv1.SignRequest{
+		{KeyId: "k1", Digest: repeatBytes(0x01, 32)},
+		{KeyId: "k2", Digest: repeatBytes(0x02, 8)},
+		{KeyId: "unknown", Digest: repeatBytes(0x03, 32)},
+		{KeyId: "k1", Digest: repeatBytes(0x04, 32)},
+	}})
+	require.NoError(t, err)
+	results := resp.GetResults()
+	require.Len(t, results, 4)
+	require.Equal(t, "k1:\x01", string(results[0].GetSignature()))
+	require.Equal(t, signerv1.ApiErrorCode_API_ERROR_CODE_INVALID_ARGUMENT, results[1].GetError().GetCode())
+	require.Equal(t, signerv1.ApiErrorCode_API_ERROR_CODE_INVALID_KEY, results[2].GetError().GetCode())
+	require.Equal(t, "k1:\x04", string(results[3].GetSignature()))
+	for i, key := range []string{"k1", "k2", "unknown", "k1"} {
+		require.Equal(t, key, results[i].GetKeyId())
+	}
+
+	_, err = server.BatchSign(context.Background(), &signerv1.BatchSignRequest{Items: make([]*signerv1.SignRequest, 5)})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
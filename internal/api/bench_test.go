@@ -0,0 +1,57 @@
+package signerapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+)
+
+// 签名热路径基准，用 -benchmem 观察 allocs/op。目标预算（超出需在评审中说明原因）：
+//
+//	BenchmarkHandleSign:         ≤ 45 allocs/op（含 httptest 请求/响应与 JSON 编解码）
+//	BenchmarkEnclaveBackendSign: ≤ 240 allocs/op（bufconn 上的一次完整 gRPC unary 调用）
+//
+// keycache 的 Entry.Checkout 热命中预算见 keycache/checkout_bench_test.go，由 AllocsPerRun 测试强制。
+
+func BenchmarkHandleSign(b *testing.B) {
+	sig := bytesRepeat(0xab, 65)
+	handler := NewHTTPHandler(&stubBackend{
+		signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			return &signerv1.SignResponse{Signature: sig}, nil
+		},
+	})
+	body := `{"keyId":"k1","digest":"` + strings.Repeat("a", 64) + `","auditHeaders":{"requestId":"r1","tenantId":"t1"}}`
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rr := httptest.NewRecorder()
+		handler.handleSign(rr, httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			b.Fatalf("status=%d", rr.Code)
+		}
+	}
+}
+
+func BenchmarkEnclaveBackendSign(b *testing.B) {
+	pool, _, _ := newTestPool(b)
+	backend, err := NewEnclaveBackend(pool, StaticTargetSelector{TargetID: "enclave-1"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.Background()
+	req := &signerv1.SignRequest{KeyId: "k1", Digest: bytesRepeat(0x01, 32)}
+	if _, err := backend.Sign(ctx, req); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := backend.Sign(ctx, req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
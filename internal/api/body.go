@@ -0,0 +1,91 @@
+package signerapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+)
+
+const (
+	defaultMaxBodyBytes       = 256 << 10
+	defaultMaxBatchBodyBytes  = 1 << 20
+	defaultMaxStreamBodyBytes = 32 << 20
+)
+
+// BodyLimits 控制 HTTP 请求体的大小上限与 JSON 解析的严格程度。
+type BodyLimits struct {
+	// MaxBytes 为单个请求体上限，默认 256KiB（容纳合约部署交易与 EIP-712 数据）。
+	MaxBytes int64
+	// BatchMaxBytes 为 /sign/batch 请求体上限，默认 1MiB。
+	BatchMaxBytes int64
+	// StreamMaxBytes 为流式响应模式下 /sign/batch 请求体上限，默认 32MiB。
+	StreamMaxBytes int64
+	// AllowUnknownFields 为 true 时忽略未知字段，默认拒绝，避免拼错的字段被静默忽略。
+	AllowUnknownFields bool
+}
+
+func (l BodyLimits) withDefaults() BodyLimits {
+	if l.MaxBytes <= 0 {
+		l.MaxBytes = defaultMaxBodyBytes
+	}
+	if l.BatchMaxBytes <= 0 {
+		l.BatchMaxBytes = defaultMaxBatchBodyBytes
+	}
+	if l.StreamMaxBytes <= 0 {
+		l.StreamMaxBytes = defaultMaxStreamBodyBytes
+	}
+	return l
+}
+
+// WithBodyLimits 设置请求体大小上限与未知字段策略。
+func WithBodyLimits(limits BodyLimits) HTTPOption {
+	return func(h *HTTPHandler) {
+		h.body = limits
+	}
+}
+
+// decodeJSON 以 MaxBytes 为上限解析请求体，见 decodeJSONLimit。
+func (h *HTTPHandler) decodeJSON(w http.ResponseWriter, r *http.Request, out any, allowEmpty bool) *apierrors.Error {
+	return h.decodeJSONLimit(w, r, h.body.withDefaults().MaxBytes, out, allowEmpty)
+}
+
+// decodeJSONLimit 经 http.MaxBytesReader 读取请求体并解析为单个 JSON 值，超限、未知字段、
+// 尾随数据均返回 INVALID_ARGUMENT；allowEmpty 为 true 时空请求体保留 out 的零值。
+func (h *HTTPHandler) decodeJSONLimit(w http.ResponseWriter, r *http.Request, limit int64, out any, allowEmpty bool) *apierrors.Error {
+	if r.Body == nil || r.Body == http.NoBody {
+		if allowEmpty {
+			return nil
+		}
+		return apierrors.New(apierrors.CodeInvalidArgument, "invalid JSON body: empty body")
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit))
+	if !h.body.AllowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	err := dec.Decode(out)
+	if err == nil {
+		// 拒绝 {...}{...} 之类的尾随数据，与严格解析保持一致。
+		if _, err = dec.Token(); errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err == nil {
+			err = errors.New("unexpected data after JSON value")
+		}
+	}
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return apierrors.New(apierrors.CodeInvalidArgument, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+	case errors.Is(err, io.EOF) && allowEmpty:
+		return nil
+	case errors.Is(err, io.EOF):
+		return apierrors.New(apierrors.CodeInvalidArgument, "invalid JSON body: empty body")
+	default:
+		return apierrors.New(apierrors.CodeInvalidArgument, "invalid JSON body: "+strings.TrimPrefix(err.Error(), "json: "))
+	}
+}
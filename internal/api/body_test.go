@@ -0,0 +1,57 @@
+package signerapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPHandlerRejectsOversizedAndMalformedBodies(t *testing.T) {
+	handler := NewHTTPHandler(&stubBackend{}, WithBodyLimits(BodyLimits{MaxBytes: 128, BatchMaxBytes: 256}))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+	digest := strings.Repeat("a", 64)
+	post := func(path, body string) (int, errorResponse) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		var resp errorResponse
+		if rr.Code != http.StatusOK {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		}
+		return rr.Code, resp
+	}
+
+	code, _ := post("/sign", `{"keyId":"k1","digest":"`+digest+`"}`)
+	require.Equal(t, http.StatusOK, code)
+	// /create 允许空请求体。
+	code, _ = post("/create", "")
+	require.Equal(t, http.StatusOK, code)
+
+	for name, tc := range map[string]struct{ path, body, message string }{
+		"oversized":      {"/sign", `{"keyId":"` + strings.Repeat("k", 200) + `"}`, "request body exceeds 128 bytes"},
+		"unknown field":  {"/sign", `{"keyId":"k1","digest":"` + digest + `","digst":"x"}`, `invalid JSON body: unknown field "digst"`},
+		"trailing data":  {"/sign", `{"keyId":"k1","digest":"` + digest + `"}{}`, "invalid JSON body: unexpected data after JSON value"},
+		"empty sign":     {"/sign", "", "invalid JSON body: empty body"},
+		"create unknown": {"/create", `{"curv":"ed25519"}`, `invalid JSON body: unknown field "curv"`},
+		"batch oversize": {"/sign/batch", `{"items":[` + strings.Repeat(`{"keyId":"k1"},`, 20) + `{}]}`, "request body exceeds 256 bytes"},
+	} {
+		code, resp := post(tc.path, tc.body)
+		require.Equal(t, http.StatusBadRequest, code, name)
+		require.Equal(t, string(apierrors.CodeInvalidArgument), resp.Code, name)
+		require.Equal(t, tc.message, resp.Message, name)
+	}
+}
+
+func TestHTTPHandlerAllowUnknownFields(t *testing.T) {
+	handler := NewHTTPHandler(&stubBackend{}, WithBodyLimits(BodyLimits{AllowUnknownFields: true}))
+	rr := httptest.NewRecorder()
+	handler.handleSign(rr, httptest.NewRequest(http.MethodPost, "/sign",
+		strings.NewReader(`{"keyId":"k1","digest":"`+strings.Repeat("a", 64)+`","clientVersion":"1.2"}`)))
+	require.Equal(t, http.StatusOK, rr.Code)
+}
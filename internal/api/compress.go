@@ -0,0 +1,222 @@
+package signerapi
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+)
+
+const defaultCompressionMinSize = 1024
+
+// CompressionConfig 配置 HTTP 请求解压与响应压缩（gzip、deflate）。
+type CompressionConfig struct {
+	// MinSize 为压缩响应的最小字节数，更小的响应原样返回，默认 1KiB。
+	MinSize int
+	// Level 为压缩级别，默认 gzip.DefaultCompression。
+	Level int
+}
+
+// WithCompression 启用 Content-Encoding 请求解压与按 Accept-Encoding 协商的响应压缩。
+func WithCompression(cfg CompressionConfig) HTTPOption {
+	return func(h *HTTPHandler) {
+		if cfg.MinSize <= 0 {
+			cfg.MinSize = defaultCompressionMinSize
+		}
+		if cfg.Level == 0 {
+			cfg.Level = gzip.DefaultCompression
+		}
+		h.compression = &cfg
+	}
+}
+
+// compress 为 handler 增加压缩支持，未启用时原样返回。
+// 请求体解压后仍受 BodyLimits 约束，上限按解压后的字节数计算，可防御压缩炸弹。
+func (h *HTTPHandler) compress(next http.HandlerFunc) http.HandlerFunc {
+	cfg := h.compression
+	if cfg == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := decodeRequestBody(r); err != nil {
+			h.writeAPIError(w, err)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			next(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, cfg: cfg, encoding: encoding, status: http.StatusOK}
+		defer cw.Close()
+		next(cw, r)
+	}
+}
+
+// decodeRequestBody 按 Content-Encoding 替换请求体为解压流。
+func decodeRequestBody(r *http.Request) *apierrors.Error {
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	var (
+		body io.ReadCloser
+		err  error
+	)
+	switch encoding {
+	case "gzip", "x-gzip":
+		body, err = gzip.NewReader(r.Body)
+	case "deflate":
+		body, err = zlib.NewReader(r.Body)
+	default:
+		return apierrors.New(apierrors.CodeInvalidArgument, "unsupported Content-Encoding "+strconv.Quote(encoding))
+	}
+	if err != nil {
+		return apierrors.New(apierrors.CodeInvalidArgument, "invalid "+encoding+" request body")
+	}
+	r.Body = body
+	r.Header.Del("Content-Encoding")
+	r.ContentLength = -1
+	return nil
+}
+
+// negotiateEncoding 按 Accept-Encoding 的 q 值选择 gzip 或 deflate，同权重时优先 gzip；都不可用时返回空串。
+func negotiateEncoding(accept string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if name == "*" {
+			name = "gzip"
+		}
+		if (name != "gzip" && name != "deflate") || q <= 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && name == "gzip") {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressWriter 先缓冲响应，达到 MinSize 或首次 Flush（流式响应）时开始压缩。
+type compressWriter struct {
+	http.ResponseWriter
+	cfg      *CompressionConfig
+	encoding string
+	status   int
+	buf      []byte
+	started  bool
+	enc      interface {
+		io.WriteCloser
+		Flush() error
+	}
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if !w.started {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.started {
+		if w.enc != nil {
+			return w.enc.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.cfg.MinSize {
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// start 写出响应头与已缓冲的内容，此后不再改变是否压缩。
+func (w *compressWriter) start(compress bool) error {
+	w.started = true
+	header := w.Header()
+	if compress && header.Get("Content-Encoding") == "" && bodyAllowed(w.status) {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		var err error
+		if w.encoding == "gzip" {
+			w.enc, err = gzip.NewWriterLevel(w.ResponseWriter, w.cfg.Level)
+		} else {
+			w.enc, err = zlib.NewWriterLevel(w.ResponseWriter, w.cfg.Level)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.enc != nil {
+		_, err := w.enc.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// Flush 实现 http.Flusher，供流式批量签名逐条推送；首次 Flush 时总长未知，按压缩处理。
+func (w *compressWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// FlushError 供 http.ResponseController 调用。
+func (w *compressWriter) FlushError() error {
+	if !w.started {
+		if err := w.start(true); err != nil {
+			return err
+		}
+	}
+	if w.enc != nil {
+		if err := w.enc.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Close 写出剩余缓冲并结束压缩流；未达到 MinSize 的响应原样写出。
+func (w *compressWriter) Close() error {
+	if !w.started {
+		if err := w.start(false); err != nil {
+			return err
+		}
+	}
+	if w.enc != nil {
+		return w.enc.Close()
+	}
+	return nil
+}
+
+// Unwrap 供 http.ResponseController 取得底层 writer。
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
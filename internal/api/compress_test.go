@@ -0,0 +1,117 @@
+package signerapi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBytes(t *testing.T, raw string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(raw))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestHTTPHandlerCompression(t *testing.T) {
+	handler := NewHTTPHandler(&stubBackend{}, WithCompression(CompressionConfig{MinSize: 64}))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+	digest := strings.Repeat("a", 64)
+	serve := func(path string, body io.Reader, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, body)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// gzip 请求体被解压；短响应不压缩。
+	rr := serve("/sign", bytes.NewReader(gzipBytes(t, `{"keyId":"k1","digest":"`+digest+`"}`)),
+		map[string]string{"Content-Encoding": "gzip", "Accept-Encoding": "gzip"})
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Empty(t, rr.Header().Get("Content-Encoding"))
+	require.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+
+	// 较大的响应按 Accept-Encoding 压缩。
+	items := strings.TrimSuffix(strings.Repeat(`{"keyId":"k1","digest":"`+digest+`"},`, 8), ",")
+	batch := `{"items":[` + items + `]}`
+	for encoding, reader := range map[string]func(io.Reader) (io.ReadCloser, error){
+		"gzip":    func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		"deflate": zlib.NewReader,
+	} {
+		rr = serve("/sign/batch", strings.NewReader(batch), map[string]string{"Accept-Encoding": encoding})
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, encoding, rr.Header().Get("Content-Encoding"))
+		zr, err := reader(rr.Body)
+		require.NoError(t, err)
+		var body batchSignResponseBody
+		require.NoError(t, json.NewDecoder(zr).Decode(&body))
+		require.Len(t, body.Results, 8)
+	}
+
+	// 解压后的大小仍受请求体上限约束。
+	limited := NewHTTPHandler(&stubBackend{}, WithCompression(CompressionConfig{}), WithBodyLimits(BodyLimits{MaxBytes: 256}))
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/sign", bytes.NewReader(gzipBytes(t, `{"keyId":"`+strings.Repeat("k", 4096)+`"}`)))
+	req.Header.Set("Content-Encoding", "gzip")
+	limited.compress(limited.handleSign)(rr, req)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Contains(t, rr.Body.String(), "request body exceeds 256 bytes")
+
+	for _, encoding := range []string{"br", "gzip"} {
+		rr = serve("/sign", strings.NewReader(`{}`), map[string]string{"Content-Encoding": encoding})
+		require.Equal(t, http.StatusBadRequest, rr.Code, encoding)
+		var resp errorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, string(apierrors.CodeInvalidArgument), resp.Code)
+	}
+}
+
+func TestCompressionFlushesStreamingBatch(t *testing.T) {
+	handler := NewHTTPHandler(&stubBackend{signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		return &signerv1.SignResponse{Signature: bytes.Repeat([]byte{0x01}, 64)}, nil
+	}}, WithCompression(CompressionConfig{MinSize: 16}))
+	items := strings.TrimSuffix(strings.Repeat(`{"keyId":"k1","digest":"`+strings.Repeat("a", 64)+`"},`, 3), ",")
+	req := httptest.NewRequest(http.MethodPost, "/sign/batch", strings.NewReader(`{"items":[`+items+`]}`))
+	req.Header.Set("Accept", NDJSONContentType)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	handler.compress(handler.handleSignBatch)(rr, req)
+	require.True(t, rr.Flushed)
+	require.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+	zr, err := gzip.NewReader(rr.Body)
+	require.NoError(t, err)
+	raw, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	require.Equal(t, 4, strings.Count(string(raw), "\n"))
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	for accept, want := range map[string]string{
+		"":                        "",
+		"gzip, deflate, br":       "gzip",
+		"deflate;q=1, gzip;q=0.5": "deflate",
+		"gzip;q=0, deflate":       "deflate",
+		"*":                       "gzip",
+		"br, identity":            "",
+	} {
+		require.Equal(t, want, negotiateEncoding(accept), accept)
+	}
+}
@@ -0,0 +1,124 @@
+package signerapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+)
+
+// ReasonKeyDisabled 是未给出原因时写入 key cache 与审计日志的默认停用原因。
+const ReasonKeyDisabled = "key_disabled"
+
+// KeyPurger 清除父机侧缓存的 key 材料，通常由 keycache.Store 实现。
+type KeyPurger interface {
+	PurgeKey(keyID, reason string) bool
+}
+
+// DisabledKeys 记录本进程内已停用的 key：Sign 立即返回 INVALID_KEY，不再进入 Enclave。
+// 重启后以 Enclave 端的停用/删除状态为准。
+type DisabledKeys struct {
+	purger KeyPurger
+
+	mu   sync.RWMutex
+	keys map[string]struct{}
+}
+
+// NewDisabledKeys 构造停用表，purger 为 nil 时只拒绝签名、不清理缓存。
+func NewDisabledKeys(purger KeyPurger) *DisabledKeys {
+	return &DisabledKeys{purger: purger, keys: make(map[string]struct{})}
+}
+
+// Disabled 判断 key 是否已停用，nil 表示不拦截。
+func (d *DisabledKeys) Disabled(keyID string) bool {
+	if d == nil {
+		return false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	_, ok := d.keys[keyID]
+	return ok
+}
+
+// disable 先标记停用再清理缓存，保证清理期间不会有新的签名取到明文。
+func (d *DisabledKeys) disable(keyID, reason string) {
+	d.mu.Lock()
+	d.keys[keyID] = struct{}{}
+	d.mu.Unlock()
+	if d.purger != nil {
+		d.purger.PurgeKey(keyID, reason)
+	}
+}
+
+// KeyDisableMiddleware 拦截已停用 key 的签名与公钥查询；DisableKey 先在本地停用并清理缓存，再转发至 Enclave。
+// 转发失败时本地停用状态保留，调用方可安全重试。
+func KeyDisableMiddleware(d *DisabledKeys) BackendMiddleware {
+	return func(next Backend) Backend {
+		if d == nil {
+			return next
+		}
+		return BackendFuncs{
+			Next: next,
+			SignFunc: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+				if d.Disabled(req.GetKeyId()) {
+					return nil, apierrors.New(apierrors.CodeInvalidKey, "key is disabled")
+				}
+				return next.Sign(ctx, req)
+			},
+			PublicKeyFunc: func(ctx context.Context, req *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error) {
+				if d.Disabled(req.GetKeyId()) {
+					return nil, apierrors.New(apierrors.CodeInvalidKey, "key is disabled")
+				}
+				return next.GetPublicKey(ctx, req)
+			},
+			DisableFunc: func(ctx context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+				reason := req.GetReason()
+				if reason == "" {
+					reason = ReasonKeyDisabled
+				}
+				d.disable(req.GetKeyId(), reason)
+				return next.DisableKey(ctx, req)
+			},
+		}
+	}
+}
+
+type disableKeyResponseBody struct {
+	KeyID   string `json:"keyId"`
+	Deleted bool   `json:"deleted"`
+}
+
+// handleKey 处理 /keys/{id} 下的路由：DELETE /keys/{id}[?reason=...] 停用并要求所属 Enclave 删除 key，
+// GET /keys/{id}/publickey 查询公钥与地址。
+func (h *HTTPHandler) handleKey(w http.ResponseWriter, r *http.Request) {
+	keyID := keyPathID(r.URL.Path)
+	if id, ok := strings.CutSuffix(keyID, "/publickey"); ok && id != "" && !strings.Contains(id, "/") {
+		h.handlePublicKey(w, r, id)
+		return
+	}
+	if keyID == "" || strings.Contains(keyID, "/") {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "path must be /keys/{id} or /keys/{id}/publickey"))
+		return
+	}
+	h.serveGateway(w, r, signerMethods["DisableKey"], v1Codec(http.MethodDelete,
+		func(_ http.ResponseWriter, r *http.Request, in *signerv1.DisableKeyRequest) *apierrors.Error {
+			in.KeyId, in.Delete, in.Reason = keyID, true, r.URL.Query().Get("reason")
+			in.AuditContext = auditContextFrom(r.Context())
+			return nil
+		},
+		func(resp *signerv1.DisableKeyResponse) disableKeyResponseBody {
+			return disableKeyResponseBody{KeyID: keyID, Deleted: resp.GetDeleted()}
+		}))
+}
+
+// handlePublicKey 经 SignerService.GetPublicKey 处理 GET /keys/{id}/publickey，响应与 /create 一致（{keyId, publicKey, address?}）。
+func (h *HTTPHandler) handlePublicKey(w http.ResponseWriter, r *http.Request, keyID string) {
+	h.serveGateway(w, r, signerMethods["GetPublicKey"], v1Codec(http.MethodGet,
+		func(_ http.ResponseWriter, r *http.Request, in *signerv1.GetPublicKeyRequest) *apierrors.Error {
+			in.KeyId, in.AuditContext = keyID, auditContextFrom(r.Context())
+			return nil
+		}, newCreateResponseBody))
+}
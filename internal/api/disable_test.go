@@ -0,0 +1,143 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type recordingPurger struct {
+	purged map[string]string
+}
+
+func (p *recordingPurger) PurgeKey(keyID, reason string) bool {
+	p.purged[keyID] = reason
+	return true
+}
+
+func TestKeyDisableMiddleware(t *testing.T) {
+	purger := &recordingPurger{purged: map[string]string{}}
+	forwardErr := errors.New("enclave unreachable")
+	var forwarded []*signerv1.DisableKeyRequest
+	backend := Chain(&stubBackend{
+		signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			return &signerv1.SignResponse{Signature: []byte("sig")}, nil
+		},
+		disableFn: func(_ context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+			forwarded = append(forwarded, req)
+			return nil, forwardErr
+		},
+	}, KeyDisableMiddleware(NewDisabledKeys(purger)))
+	ctx := context.Background()
+
+	_, err := backend.Sign(ctx, &signerv1.SignRequest{KeyId: "k1"})
+	require.NoError(t, err)
+
+	// Enclave 转发失败时本地停用依然生效，签名立即被拒绝。
+	_, err = backend.DisableKey(ctx, &signerv1.DisableKeyRequest{KeyId: "k1", Reason: "incident"})
+	require.ErrorIs(t, err, forwardErr)
+	require.Len(t, forwarded, 1)
+	require.Equal(t, map[string]string{"k1": "incident"}, purger.purged)
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "k1"})
+	apiErr, ok := apierrors.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, apierrors.CodeInvalidKey, apiErr.Code)
+	_, err = backend.GetPublicKey(ctx, &signerv1.GetPublicKeyRequest{KeyId: "k1"})
+	require.Error(t, err)
+
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "k2"})
+	require.NoError(t, err)
+	_, _ = backend.DisableKey(ctx, &signerv1.DisableKeyRequest{KeyId: "k2"})
+	require.Equal(t, ReasonKeyDisabled, purger.purged["k2"])
+}
+
+func TestHandleKeyDelete(t *testing.T) {
+	var got *signerv1.DisableKeyRequest
+	handler := NewHTTPHandler(&stubBackend{
+		disableFn: func(_ context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+			got = req
+			return &signerv1.DisableKeyResponse{KeyId: req.GetKeyId(), Deleted: true}, nil
+		},
+	})
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/keys/k1?reason=tenant_offboarding", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var body disableKeyResponseBody
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	require.Equal(t, disableKeyResponseBody{KeyID: "k1", Deleted: true}, body)
+	require.True(t, got.GetDelete())
+	require.Equal(t, "tenant_offboarding", got.GetReason())
+
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodGet, "/keys/k1"},
+		{http.MethodDelete, "/keys/"},
+		{http.MethodDelete, "/keys/a/b"},
+	} {
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+		require.Equal(t, http.StatusBadRequest, rr.Code, tc.path)
+	}
+
+	// /keys/import 仍由导入 handler 处理。
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/keys/import", strings.NewReader(`{}`)))
+	require.NotContains(t, rr.Body.String(), "DELETE required")
+}
+
+func TestHandleKeyPublicKey(t *testing.T) {
+	handler := NewHTTPHandler(&stubBackend{
+		publicKeyFn: func(_ context.Context, req *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error) {
+			if req.GetKeyId() != "k1" {
+				return nil, apierrors.New(apierrors.CodeInvalidKey, "unknown key")
+			}
+			return &signerv1.CreateResponse{KeyId: "k1", PublicKey: []byte{0x02, 0xab}, Address: "0xabc"}, nil
+		},
+	})
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/keys/k1/publickey", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var body createResponseBody
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	require.Equal(t, createResponseBody{KeyID: "k1", PublicKey: "02ab", Address: "0xabc"}, body)
+
+	for path, want := range map[string]int{
+		"/keys/k2/publickey":  http.StatusNotFound,
+		"/keys/a/b/publickey": http.StatusBadRequest,
+	} {
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, want, rr.Code, path)
+	}
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/keys/k1/publickey", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	server := NewGRPCServer(&stubBackend{}, nil)
+	_, err := server.GetPublicKey(context.Background(), &signerv1.GetPublicKeyRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCDisableKeyRequiresKeyID(t *testing.T) {
+	server := NewGRPCServer(&stubBackend{}, nil)
+	_, err := server.DisableKey(context.Background(), &signerv1.DisableKeyRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	resp, err := server.DisableKey(context.Background(), &signerv1.DisableKeyRequest{KeyId: "k1"})
+	require.NoError(t, err)
+	require.Equal(t, "k1", resp.GetKeyId())
+}
@@ -0,0 +1,229 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+)
+
+const (
+	defaultDrainTimeout    = 30 * time.Second
+	defaultDrainPoll       = 50 * time.Millisecond
+	defaultDrainRetryAfter = time.Second
+)
+
+// UnlockQueueDepth 提供解锁队列深度，*unlock.Dispatcher 满足。
+type UnlockQueueDepth interface {
+	QueueDepth() int
+}
+
+// DrainConfig 配置 Drainer。
+type DrainConfig struct {
+	// Queue 为空时不等待解锁队列。
+	Queue UnlockQueueDepth
+	// Timeout 为 POST 未指定 timeoutMs 时等待排空的上限，默认 30s。
+	Timeout time.Duration
+	// PollInterval 为检查在途调用与队列的周期，默认 50ms。
+	PollInterval time.Duration
+	// RetryAfter 为排空期间拒绝请求时给出的退避提示，默认 1s。
+	RetryAfter time.Duration
+}
+
+// Drainer 支撑滚动发布的优雅摘流：开始排空后拒绝新的 Create/ImportKey/Sign（RETRY_LATER），
+// 并等待在途调用与解锁队列清空。nil 表示关闭。
+type Drainer struct {
+	cfg      DrainConfig
+	draining atomic.Bool
+	inFlight atomic.Int64
+
+	mu        sync.Mutex
+	startedAt time.Time
+	drainedAt time.Time
+}
+
+type drainState struct {
+	Draining    bool      `json:"draining"`
+	Drained     bool      `json:"drained"`
+	InFlight    int64     `json:"inFlight"`
+	UnlockQueue int       `json:"unlockQueue"`
+	StartedAt   time.Time `json:"startedAt,omitempty"`
+	DrainedAt   time.Time `json:"drainedAt,omitempty"`
+}
+
+type drainRequest struct {
+	TimeoutMs int64 `json:"timeoutMs"`
+}
+
+// NewDrainer 构造 Drainer。
+func NewDrainer(cfg DrainConfig) *Drainer {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultDrainTimeout
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultDrainPoll
+	}
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = defaultDrainRetryAfter
+	}
+	return &Drainer{cfg: cfg}
+}
+
+// Draining 表示是否处于排空状态，nil 视为否。
+func (d *Drainer) Draining() bool {
+	return d != nil && d.draining.Load()
+}
+
+// Start 进入排空状态，重复调用不会重置开始时间。
+func (d *Drainer) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining.Swap(true) {
+		return
+	}
+	d.startedAt = time.Now().UTC()
+	d.drainedAt = time.Time{}
+}
+
+// Resume 退出排空状态，用于中止的发布。
+func (d *Drainer) Resume() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.draining.Store(false)
+	d.startedAt, d.drainedAt = time.Time{}, time.Time{}
+}
+
+// Wait 阻塞到在途调用与解锁队列清空或 ctx 结束，返回是否已排空。
+func (d *Drainer) Wait(ctx context.Context) bool {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		if st := d.state(); st.Drained {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Drainer) state() drainState {
+	st := drainState{Draining: d.draining.Load(), InFlight: d.inFlight.Load()}
+	if d.cfg.Queue != nil {
+		st.UnlockQueue = d.cfg.Queue.QueueDepth()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	st.StartedAt = d.startedAt
+	if st.Draining && st.InFlight == 0 && st.UnlockQueue == 0 {
+		if d.drainedAt.IsZero() {
+			d.drainedAt = time.Now().UTC()
+		}
+		st.Drained = true
+	}
+	if st.Drained {
+		st.DrainedAt = d.drainedAt
+	}
+	return st
+}
+
+// ServeHTTP 处理 /admin/drain：GET 查询状态；POST 开始排空并等待至多 timeoutMs（默认 DrainConfig.Timeout），
+// 排空完成返回 200，超时返回 503（排空状态保持）；DELETE 恢复接收请求。
+func (d *Drainer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var body drainRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			writeJSONResponse(w, http.StatusBadRequest, errorResponse{
+				Code:    string(apierrors.CodeInvalidArgument),
+				Message: `body must be empty or {"timeoutMs": n}`,
+			})
+			return
+		}
+		timeout := d.cfg.Timeout
+		if body.TimeoutMs > 0 {
+			timeout = time.Duration(body.TimeoutMs) * time.Millisecond
+		}
+		d.Start()
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		if !d.Wait(ctx) {
+			writeJSONResponse(w, http.StatusServiceUnavailable, d.state())
+			return
+		}
+	case http.MethodDelete:
+		d.Resume()
+	default:
+		writeJSONResponse(w, http.StatusBadRequest, errorResponse{
+			Code:    string(apierrors.CodeInvalidArgument),
+			Message: "GET, POST or DELETE required",
+		})
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, d.state())
+}
+
+// DrainMiddleware 统计全部在途调用，并在排空期间拒绝新的 Create/ImportKey/Sign；d 为 nil 时返回 nil，由 Chain 跳过。
+func DrainMiddleware(d *Drainer) BackendMiddleware {
+	if d == nil {
+		return nil
+	}
+	// admit 先计数再检查状态，保证 Start 之后 Wait 观察到的在途数不会漏掉刚放行的调用。
+	admit := func(reject bool) (func(), error) {
+		d.inFlight.Add(1)
+		if reject && d.draining.Load() {
+			d.inFlight.Add(-1)
+			return nil, apierrors.New(apierrors.CodeRetryLater, "signer is draining").WithRetryAfter(d.cfg.RetryAfter)
+		}
+		return func() { d.inFlight.Add(-1) }, nil
+	}
+	return func(next Backend) Backend {
+		return BackendFuncs{
+			Next: next,
+			CreateFunc: func(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+				done, err := admit(true)
+				if err != nil {
+					return nil, err
+				}
+				defer done()
+				return next.Create(ctx, req)
+			},
+			ImportFunc: func(ctx context.Context, req *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+				done, err := admit(true)
+				if err != nil {
+					return nil, err
+				}
+				defer done()
+				return next.ImportKey(ctx, req)
+			},
+			SignFunc: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+				done, err := admit(true)
+				if err != nil {
+					return nil, err
+				}
+				defer done()
+				return next.Sign(ctx, req)
+			},
+			PublicKeyFunc: func(ctx context.Context, req *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error) {
+				done, _ := admit(false)
+				defer done()
+				return next.GetPublicKey(ctx, req)
+			},
+			DisableFunc: func(ctx context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+				done, _ := admit(false)
+				defer done()
+				return next.DisableKey(ctx, req)
+			},
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainMiddlewareRejectsNewCallsAndWaitsForInFlight(t *testing.T) {
+	drainer := NewDrainer(DrainConfig{PollInterval: time.Millisecond})
+	entered, release := make(chan struct{}), make(chan struct{})
+	backend := Chain(&stubBackend{signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		close(entered)
+		<-release
+		return &signerv1.SignResponse{}, nil
+	}}, DrainMiddleware(drainer))
+
+	signed := make(chan error, 1)
+	go func() {
+		_, err := backend.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k1"})
+		signed <- err
+	}()
+	<-entered
+	drainer.Start()
+
+	_, err := backend.Create(context.Background(), &signerv1.CreateRequest{})
+	requireRetryLater(t, err, time.Second)
+	// 查询类调用在排空期间仍然放行。
+	_, err = backend.GetPublicKey(context.Background(), &signerv1.GetPublicKeyRequest{KeyId: "k1"})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.False(t, drainer.Wait(ctx))
+
+	close(release)
+	require.NoError(t, <-signed)
+	require.True(t, drainer.Wait(context.Background()))
+
+	drainer.Resume()
+	_, err = backend.Create(context.Background(), &signerv1.CreateRequest{})
+	require.NoError(t, err)
+	require.Nil(t, DrainMiddleware(nil))
+}
+
+func TestDrainerHandlerWaitsForUnlockQueue(t *testing.T) {
+	queue := &fakeQueue{depth: 2}
+	drainer := NewDrainer(DrainConfig{Queue: queue, PollInterval: time.Millisecond})
+	serve := func(method, body string) (int, drainState) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		drainer.ServeHTTP(rr, httptest.NewRequest(method, "/admin/drain", strings.NewReader(body)))
+		var st drainState
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &st))
+		return rr.Code, st
+	}
+
+	code, st := serve(http.MethodPost, `{"timeoutMs":10}`)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.True(t, st.Draining)
+	require.False(t, st.Drained)
+	require.Equal(t, 2, st.UnlockQueue)
+
+	queue.depth = 0
+	code, st = serve(http.MethodPost, "")
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, st.Drained)
+	require.False(t, st.DrainedAt.IsZero())
+
+	code, st = serve(http.MethodDelete, "")
+	require.Equal(t, http.StatusOK, code)
+	require.False(t, st.Draining)
+
+	rr := httptest.NewRecorder()
+	drainer.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/drain", strings.NewReader("{")))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestStatusHandlerNotReadyWhileDraining(t *testing.T) {
+	drainer := NewDrainer(DrainConfig{})
+	h := NewStatusHandler(BuildInfo{}, nil)
+	h.SetReadiness(ReadinessConfig{Drain: drainer})
+	mux := http.NewServeMux()
+	h.Register(mux)
+
+	ready := func() int {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rr.Code
+	}
+	require.Equal(t, http.StatusOK, ready())
+	drainer.Start()
+	require.Equal(t, http.StatusServiceUnavailable, ready())
+	drainer.Resume()
+	require.Equal(t, http.StatusOK, ready())
+}
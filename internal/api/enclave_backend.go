@@ -3,12 +3,19 @@ package signerapi
 import (
 	"context"
 	"errors"
-	"hash/fnv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
 	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/internal/audit"
 	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"google.golang.org/grpc/metadata"
 )
 
 // TargetSelector 决定 key/create 请求映射到哪个 Enclave。
@@ -44,15 +51,15 @@ type EnclaveBackend struct {
 	pool        *enclaveclient.Pool
 	selector    TargetSelector
 	callTimeout time.Duration
+	budget      *LatencyBudget
+	hedge       *Hedger
+	retry       *Retrier
 }
 
-// 默认 RPC 超时时间，覆盖 handler 级别 deadline。
-const defaultCallTimeout = 2 * time.Second
-
 // EnclaveBackendOption 定义可选参数。
 type EnclaveBackendOption func(*EnclaveBackend)
 
-// WithCallTimeout 自定义单次 RPC 超时时间。
+// WithCallTimeout 为单次 Enclave RPC 额外设置超时时间；整体调用时限建议使用 TimeoutMiddleware。
 func WithCallTimeout(d time.Duration) EnclaveBackendOption {
 	return func(b *EnclaveBackend) {
 		if d > 0 {
@@ -61,6 +68,11 @@ func WithCallTimeout(d time.Duration) EnclaveBackendOption {
 	}
 }
 
+// WithLatencyBudget 让 Sign 按 Enclave 跟踪延迟，并在剩余时限不足时快速失败；nil 表示关闭。
+func WithLatencyBudget(budget *LatencyBudget) EnclaveBackendOption {
+	return func(b *EnclaveBackend) { b.budget = budget }
+}
+
 // NewEnclaveBackend 构造依赖连接池的 Backend 实现。
 func NewEnclaveBackend(pool *enclaveclient.Pool, selector TargetSelector, opts ...EnclaveBackendOption) (*EnclaveBackend, error) {
 	if pool == nil {
@@ -70,9 +82,8 @@ func NewEnclaveBackend(pool *enclaveclient.Pool, selector TargetSelector, opts .
 		return nil, errors.New("target selector is required")
 	}
 	backend := &EnclaveBackend{
-		pool:        pool,
-		selector:    selector,
-		callTimeout: defaultCallTimeout,
+		pool:     pool,
+		selector: selector,
 	}
 	for _, opt := range opts {
 		opt(backend)
@@ -80,84 +91,443 @@ func NewEnclaveBackend(pool *enclaveclient.Pool, selector TargetSelector, opts .
 	return backend, nil
 }
 
-// Create 通过长连接在 Enclave 端创建 key。
+// pinnedTargetKey 用于在 context 中固定目标 Enclave。
+type pinnedTargetKey struct{}
+
+// WithPinnedTarget 让后续 Backend 调用绕过 selector，固定路由到指定 Enclave（自检等场景）。
+func WithPinnedTarget(ctx context.Context, targetID string) context.Context {
+	return context.WithValue(ctx, pinnedTargetKey{}, targetID)
+}
+
+// PinnedTarget 返回 context 中固定的目标 Enclave。
+func PinnedTarget(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(pinnedTargetKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Create 通过长连接在 Enclave 端创建 key；启用重试时失败的尝试重新按轮询选择目标。
 func (b *EnclaveBackend) Create(ctx context.Context, req *signerv1.CreateRequest) (_ *signerv1.CreateResponse, err error) {
-	target, err := b.selector.SelectForCreate(ctx, req)
+	target, pinned := PinnedTarget(ctx)
+	if !pinned {
+		target, err = b.selector.SelectForCreate(ctx, req)
+	}
 	if err != nil {
 		return nil, err
 	}
+	req.AuditContext = mergeAuditContext(ctx, req.GetAuditContext())
+	b.retry.begin()
+	for attempt := 1; ; attempt++ {
+		audit.RecordTarget(ctx, target)
+		resp, err := b.createOnce(ctx, target, req)
+		if !b.retry.next(ctx, "create", attempt, err) {
+			if err == nil {
+				b.recordPlacement(ctx, resp.GetKeyId(), target)
+			}
+			return resp, err
+		}
+		if !pinned {
+			if next, selErr := b.selector.SelectForCreate(ctx, req); selErr == nil {
+				target = next
+			}
+		}
+	}
+}
+
+// createOnce 在 target 上执行一次 Create，req.AuditContext 须已补齐。
+func (b *EnclaveBackend) createOnce(ctx context.Context, target string, req *signerv1.CreateRequest) (_ *signerv1.CreateResponse, err error) {
 	lease, err := b.pool.Acquire(ctx, target)
 	if err != nil {
-		return nil, err
+		return nil, translateAcquireError(err)
 	}
 	defer func() { lease.Release(err) }()
-	callCtx, cancel := context.WithTimeout(ctx, b.callTimeout)
+	callCtx, cancel := b.callContext(ctx)
 	defer cancel()
 	resp, err := lease.Client().Create(callCtx, req)
-	return resp, err
+	return resp, callerError(ctx, err)
 }
 
-// Sign 通过复用的长连接执行签名。
-func (b *EnclaveBackend) Sign(ctx context.Context, req *signerv1.SignRequest) (_ *signerv1.SignResponse, err error) {
-	target, err := b.selector.SelectForSign(ctx, req)
+// ImportKey 先在本地校验信封，再沿 Create 的路由选择目标 Enclave 导入 key。
+func (b *EnclaveBackend) ImportKey(ctx context.Context, req *signerv1.ImportKeyRequest) (_ *signerv1.CreateResponse, err error) {
+	if err := ValidateWrappedKey(req.GetWrappedKey()); err != nil {
+		return nil, err
+	}
+	target, pinned := PinnedTarget(ctx)
+	if !pinned {
+		target, err = b.selector.SelectForCreate(ctx, &signerv1.CreateRequest{
+			Curve:        req.GetCurve(),
+			AuditContext: req.GetAuditContext(),
+		})
+	}
 	if err != nil {
 		return nil, err
 	}
+	audit.RecordTarget(ctx, target)
 	lease, err := b.pool.Acquire(ctx, target)
+	if err != nil {
+		return nil, translateAcquireError(err)
+	}
+	defer func() { lease.Release(err) }()
+	callCtx, cancel := b.callContext(ctx)
+	defer cancel()
+	req.AuditContext = mergeAuditContext(ctx, req.GetAuditContext())
+	resp, err := lease.Client().ImportKey(callCtx, req)
+	if err != nil {
+		return nil, callerError(ctx, err)
+	}
+	b.recordPlacement(ctx, resp.GetKeyId(), target)
+	return resp, nil
+}
+
+// recordPlacement 在 selector 支持时登记新 key 所在的 Enclave。
+func (b *EnclaveBackend) recordPlacement(ctx context.Context, keyID, target string) {
+	if rec, ok := b.selector.(PlacementRecorder); ok {
+		rec.RecordPlacement(ctx, keyID, target)
+	}
+}
+
+// Sign 通过复用的长连接执行签名；启用对冲时交由 hedgedSign 处理，否则按重试策略执行。
+func (b *EnclaveBackend) Sign(ctx context.Context, req *signerv1.SignRequest) (_ *signerv1.SignResponse, err error) {
+	target, pinned := PinnedTarget(ctx)
+	if !pinned {
+		target, err = b.selector.SelectForSign(ctx, req)
+	}
 	if err != nil {
 		return nil, err
 	}
+	audit.RecordTarget(ctx, target)
+	// 剩余时限不足以覆盖该 Enclave 的典型延迟时，不占用连接与 Enclave 算力。
+	if err := b.budget.Check(ctx, target); err != nil {
+		return nil, err
+	}
+	req.AuditContext = mergeAuditContext(ctx, req.GetAuditContext())
+	if !pinned && !req.GetDryRun() {
+		if alternate, ok := b.hedge.alternate(ctx, b.selector, req, target); ok {
+			return b.hedgedSign(ctx, target, alternate, req)
+		}
+	}
+	return b.retriedSign(ctx, target, pinned, req)
+}
+
+// signOnce 在 target 上执行一次签名；req.AuditContext 须已补齐，可被多个并发尝试共享只读。
+func (b *EnclaveBackend) signOnce(ctx context.Context, target string, req *signerv1.SignRequest) (_ *signerv1.SignResponse, err error) {
+	start := time.Now()
+	lease, err := b.pool.Acquire(ctx, target)
+	if err != nil {
+		return nil, translateAcquireError(err)
+	}
 	defer func() { lease.Release(err) }()
-	callCtx, cancel := context.WithTimeout(ctx, b.callTimeout)
+	// callCtx 派生自请求上下文：客户端断开或超时时等待立即返回，连接上的常驻流随之关闭，
+	// Enclave 端感知取消；下一次借用该连接时重建流。
+	callCtx, cancel := b.callContext(ctx)
 	defer cancel()
-	client := lease.Client()
-	stream, err := client.SignStream(callCtx)
+	resp, err := lease.Sign(callCtx, req)
+	if err != nil {
+		return nil, callerError(ctx, err)
+	}
+	// dry-run 由 Enclave 完成 key cache checkout 后跳过签名；其耗时不代表签名延迟，不计入时延预算。
+	// RPC 已成功，先以 nil 归还连接，Enclave 不支持 dry-run 时不把连接判为故障。
+	if req.GetDryRun() {
+		lease.Release(nil)
+		return dryRunResult(target, resp)
+	}
+	b.budget.Observe(target, time.Since(start))
+	return resp, nil
+}
+
+// dryRunResult 校验 Enclave 对 dry-run 的回显：未回显 DryRun 说明 Enclave 不支持 dry-run 且可能已真实签名，
+// 丢弃其响应并报错；否则统一替换为合成签名，不把 Enclave 返回的任何签名字节交给调用方。
+func dryRunResult(target string, resp *signerv1.SignResponse) (*signerv1.SignResponse, error) {
+	if !resp.GetDryRun() {
+		return nil, apierrors.New(apierrors.CodeEnclaveUnavailable, fmt.Sprintf("enclave %s does not support dry-run", target))
+	}
+	return dryRunSignResponse(), nil
+}
+
+// dryRunSignatureSize 是 dry-run 合成签名的长度，与 64B raw 签名一致。
+const dryRunSignatureSize = 64
+
+// dryRunSignResponse 返回全零的合成签名，不带 recId，调用方据 DryRun 区分。
+func dryRunSignResponse() *signerv1.SignResponse {
+	return &signerv1.SignResponse{Signature: make([]byte, dryRunSignatureSize), DryRun: true}
+}
+
+// GetPublicKey 沿 Sign 的粘性路由向 key 所属 Enclave 查询公钥，不占用签名算力预算。
+func (b *EnclaveBackend) GetPublicKey(ctx context.Context, req *signerv1.GetPublicKeyRequest) (_ *signerv1.CreateResponse, err error) {
+	target, pinned := PinnedTarget(ctx)
+	if !pinned {
+		target, err = b.selector.SelectForSign(ctx, &signerv1.SignRequest{KeyId: req.GetKeyId()})
+	}
 	if err != nil {
 		return nil, err
 	}
-	if err := stream.Send(req); err != nil {
+	audit.RecordTarget(ctx, target)
+	lease, err := b.pool.Acquire(ctx, target)
+	if err != nil {
+		return nil, translateAcquireError(err)
+	}
+	defer func() { lease.Release(err) }()
+	callCtx, cancel := b.callContext(ctx)
+	defer cancel()
+	req.AuditContext = mergeAuditContext(ctx, req.GetAuditContext())
+	resp, err := lease.Client().GetPublicKey(callCtx, req)
+	return resp, callerError(ctx, err)
+}
+
+// DisableKey 沿 Sign 的粘性路由将停用/删除请求转发至 key 所属 Enclave。
+func (b *EnclaveBackend) DisableKey(ctx context.Context, req *signerv1.DisableKeyRequest) (_ *signerv1.DisableKeyResponse, err error) {
+	target, pinned := PinnedTarget(ctx)
+	if !pinned {
+		target, err = b.selector.SelectForSign(ctx, &signerv1.SignRequest{KeyId: req.GetKeyId()})
+	}
+	if err != nil {
 		return nil, err
 	}
-	resp, err := stream.Recv()
-	if closeErr := stream.CloseSend(); closeErr != nil && err == nil {
-		err = closeErr
+	audit.RecordTarget(ctx, target)
+	lease, err := b.pool.Acquire(ctx, target)
+	if err != nil {
+		return nil, translateAcquireError(err)
+	}
+	defer func() { lease.Release(err) }()
+	callCtx, cancel := b.callContext(ctx)
+	defer cancel()
+	req.AuditContext = mergeAuditContext(ctx, req.GetAuditContext())
+	resp, err := lease.Client().DisableKey(callCtx, req)
+	return resp, callerError(ctx, err)
+}
+
+// callerError 在调用方已取消时返回 ctx 错误（包裹原始 RPC 错误），
+// 使上层按 CANCELED 统计且连接不被误判为故障。
+func callerError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); errors.Is(ctxErr, context.Canceled) {
+		return fmt.Errorf("%w: %v", ctxErr, err)
+	}
+	return err
+}
+
+// translateAcquireError 按失败原因映射业务错误码：饱和可重试，排空需等待恢复，未知目标属于调用方错误。
+func translateAcquireError(err error) error {
+	switch {
+	case errors.Is(err, enclaveclient.ErrAcquireTimeout):
+		return apierrors.Wrap(apierrors.CodeRetryLater, "enclave pool saturated", err)
+	case errors.Is(err, enclaveclient.ErrPoolDraining):
+		return apierrors.Wrap(apierrors.CodeEnclaveUnavailable, "enclave is draining", err)
+	case errors.Is(err, enclaveclient.ErrTargetNotFound):
+		return apierrors.Wrap(apierrors.CodeInvalidArgument, "enclave target not registered", err)
+	}
+	return err
+}
+
+// callContext 派生单次 Enclave 调用的上下文，并将请求 ID 经 x-request-id metadata 透传。
+func (b *EnclaveBackend) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if requestID, ok := reqctx.RequestIDFrom(ctx); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx, RequestIDMetadataKey, requestID)
+	}
+	if b.callTimeout > 0 {
+		return context.WithTimeout(ctx, b.callTimeout)
 	}
-	return resp, err
+	return context.WithCancel(ctx)
+}
+
+// TargetHealth 报告 Enclave 是否适合接收新请求，*enclaveclient.Pool 实现了该接口。
+type TargetHealth interface {
+	Routable(enclaveID string) bool
 }
 
-// StickySelector 根据 keyId 做一致性路由，Create 请求使用轮询方式均衡分发。
+// StickySelector 根据 keyId 在一致性 hash 环上路由，Create 请求使用轮询方式均衡分发。
+// 目标集合可经 SetTargets 原子替换（动态发现）。
 type StickySelector struct {
+	vnodes  int
+	weights map[string]int
+	state   atomic.Pointer[stickyState]
+	rr      atomic.Uint64
+	health  TargetHealth
+}
+
+// stickyState 是某一时刻目标集合对应的只读路由表。
+type stickyState struct {
 	targetIDs []string
-	rr        atomic.Uint64
+	ring      *hashRing
+	// createOrder 是按权重平滑交错的 Create 轮询序列（元素为 targetIDs 下标）。
+	createOrder []int
+}
+
+// StickySelectorOption 定义 StickySelector 的可选参数。
+type StickySelectorOption func(*StickySelector)
+
+// WithTargetHealth 让 selector 跳过摘除或熔断降级中的 Enclave，沿 hash 环顺延到下一个目标；nil 表示不检查。
+func WithTargetHealth(health TargetHealth) StickySelectorOption {
+	return func(s *StickySelector) { s.health = health }
+}
+
+// WithVirtualNodes 设置每个 Enclave 在 hash 环上的虚拟节点数，<=0 时使用 DefaultVirtualNodes。
+// 调大可让 key 分布更均匀，代价是环占用更多内存。
+func WithVirtualNodes(n int) StickySelectorOption {
+	return func(s *StickySelector) { s.vnodes = n }
+}
+
+// MaxTargetWeight 为单个 Enclave 权重上限。
+const MaxTargetWeight = 100
+
+// WithTargetWeights 按 Enclave 容量设置权重（未列出的目标为 1），
+// 权重同时作用于 Sign 的虚拟节点数与 Create 的轮询比例；当前不在目标集合中的 ID 被忽略。
+func WithTargetWeights(weights map[string]int) StickySelectorOption {
+	return func(s *StickySelector) { s.weights = weights }
+}
+
+// ParseTargetWeights 解析 "id=weight,id2=weight" 形式的 Enclave 权重，取值范围由 NewStickySelector 校验。
+func ParseTargetWeights(raw string) (map[string]int, error) {
+	weights := make(map[string]int)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, value, ok := strings.Cut(part, "=")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid enclave weight %q, want id=weight", part)
+		}
+		w, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight in enclave weight %q", part)
+		}
+		if _, dup := weights[id]; dup {
+			return nil, fmt.Errorf("enclave weight for %q is declared twice", id)
+		}
+		weights[id] = w
+	}
+	return weights, nil
 }
 
 // NewStickySelector 构造一致性路由选择器。
-func NewStickySelector(targetIDs []string) (TargetSelector, error) {
+func NewStickySelector(targetIDs []string, opts ...StickySelectorOption) (*StickySelector, error) {
 	if len(targetIDs) == 0 {
 		return nil, errors.New("at least one enclave target is required")
 	}
-	ids := make([]string, len(targetIDs))
-	copy(ids, targetIDs)
-	return &StickySelector{targetIDs: ids}, nil
+	s := &StickySelector{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	for id, w := range s.weights {
+		if w < 1 || w > MaxTargetWeight {
+			return nil, fmt.Errorf("weight for enclave target %q must be in [1, %d], got %d", id, MaxTargetWeight, w)
+		}
+	}
+	s.state.Store(s.buildState(targetIDs))
+	return s, nil
+}
+
+// SetTargets 原子替换参与路由的目标集合。
+// hash 环按 ID 放置节点，保留下来的目标之间不迁移 key。
+func (s *StickySelector) SetTargets(targetIDs []string) error {
+	if len(targetIDs) == 0 {
+		return errors.New("at least one enclave target is required")
+	}
+	s.state.Store(s.buildState(targetIDs))
+	return nil
+}
+
+// Targets 返回当前参与路由的目标 ID。
+func (s *StickySelector) Targets() []string {
+	return slices.Clone(s.state.Load().targetIDs)
+}
+
+func (s *StickySelector) buildState(targetIDs []string) *stickyState {
+	ids := slices.Clone(targetIDs)
+	weights := make([]int, len(ids))
+	for i, id := range ids {
+		weights[i] = 1
+		if w, ok := s.weights[id]; ok {
+			weights[i] = w
+		}
+	}
+	return &stickyState{
+		targetIDs:   ids,
+		ring:        newHashRing(ids, s.vnodes, weights),
+		createOrder: smoothWeightedOrder(weights),
+	}
 }
 
-// SelectForCreate 使用轮询，避免 create 请求扎堆。
+// smoothWeightedOrder 用平滑加权轮询生成一个周期的目标序列，避免同一目标连续被选中。
+func smoothWeightedOrder(weights []int) []int {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	current := make([]int, len(weights))
+	order := make([]int, 0, total)
+	for len(order) < total {
+		best := 0
+		for i, w := range weights {
+			current[i] += w
+			if current[i] > current[best] {
+				best = i
+			}
+		}
+		current[best] -= total
+		order = append(order, best)
+	}
+	return order
+}
+
+// SelectForCreate 按权重轮询，避免 create 请求扎堆；不可用目标按列表顺序顺延。
 func (s *StickySelector) SelectForCreate(context.Context, *signerv1.CreateRequest) (string, error) {
-	idx := int(s.rr.Add(1)-1) % len(s.targetIDs)
-	return s.targetIDs[idx], nil
+	st := s.state.Load()
+	idx := st.createOrder[int(s.rr.Add(1)-1)%len(st.createOrder)]
+	if s.health == nil {
+		return st.targetIDs[idx], nil
+	}
+	for i := range st.targetIDs {
+		id := st.targetIDs[(idx+i)%len(st.targetIDs)]
+		if s.health.Routable(id) {
+			return id, nil
+		}
+	}
+	return st.targetIDs[idx], nil
 }
 
-// SelectForSign 根据 keyId 做一致性 hash，保障缓存粘性路由。
+// SelectForSign 根据 keyId 做一致性 hash，保障缓存粘性路由；derivation_path 不参与 hash，
+// 派生子 key 与主 key 落在同一 Enclave，复用其密文与解锁状态。
+// 首选目标不可用时顺延到环上下一个可用目标；全部不可用时仍返回首选目标，由 Acquire 给出 ENCLAVE_UNAVAILABLE。
 func (s *StickySelector) SelectForSign(_ context.Context, req *signerv1.SignRequest) (string, error) {
-	if len(s.targetIDs) == 0 {
-		return "", errors.New("no enclave targets configured")
-	}
+	st := s.state.Load()
 	key := req.GetKeyId()
-	if key == "" {
-		return s.targetIDs[0], nil
+	preferred := st.ring.lookup(key)
+	if s.health == nil {
+		return st.targetIDs[preferred], nil
+	}
+	target := preferred
+	st.ring.walk(key, func(t int) bool {
+		if s.health.Routable(st.targetIDs[t]) {
+			target = t
+			return true
+		}
+		return false
+	})
+	return st.targetIDs[target], nil
+}
+
+// SelectAlternate 返回 hash 环上主目标之后的下一个 Enclave，作为 key 密文副本所在的备选目标；
+// primary 已是顺延后的目标或备选目标不可用时不对冲。
+func (s *StickySelector) SelectAlternate(_ context.Context, req *signerv1.SignRequest, primary string) (string, bool) {
+	st := s.state.Load()
+	if len(st.targetIDs) < 2 {
+		return "", false
+	}
+	var order []int
+	st.ring.walk(req.GetKeyId(), func(t int) bool {
+		order = append(order, t)
+		return len(order) == 2
+	})
+	if st.targetIDs[order[0]] != primary {
+		return "", false
+	}
+	alt := st.targetIDs[order[1]]
+	if s.health != nil && !s.health.Routable(alt) {
+		return "", false
 	}
-	h := fnv.New32a()
-	_, _ = h.Write([]byte(key))
-	idx := int(h.Sum32()) % len(s.targetIDs)
-	return s.targetIDs[idx], nil
+	return alt, true
 }
@@ -4,15 +4,20 @@ import (
 	"context"
 	"io"
 	"net"
+	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
 	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 )
 
@@ -31,7 +36,7 @@ func (streamingServer) SignStream(stream signerv1.SignerService_SignStreamServer
 		if err != nil {
 			return err
 		}
-		if err := stream.Send(&signerv1.SignResponse{Signature: append([]byte{}, req.GetDigest()...)}); err != nil {
+		if err := stream.Send(&signerv1.SignResponse{Signature: append([]byte{}, req.GetDigest()...), Sequence: req.GetSequence()}); err != nil {
 			return err
 		}
 	}
@@ -41,11 +46,25 @@ func (streamingServer) Create(context.Context, *signerv1.CreateRequest) (*signer
 	return &signerv1.CreateResponse{KeyId: "generated"}, nil
 }
 
-func newTestPool(t *testing.T) (*enclaveclient.Pool, *grpc.Server, *bufconn.Listener) {
+func (streamingServer) GetPublicKey(_ context.Context, req *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error) {
+	return &signerv1.CreateResponse{KeyId: req.GetKeyId(), PublicKey: []byte{0x02, 0x01}}, nil
+}
+
+func (streamingServer) DisableKey(_ context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+	return &signerv1.DisableKeyResponse{KeyId: req.GetKeyId(), Deleted: req.GetDelete()}, nil
+}
+
+func newTestPool(t testing.TB) (*enclaveclient.Pool, *grpc.Server, *bufconn.Listener) {
+	t.Helper()
+	return newTestPoolWith(t, streamingServer{})
+}
+
+// newTestPoolWith 以 enclave 作为唯一 Enclave（enclave-1）的服务端构造连接池。
+func newTestPoolWith(t testing.TB, enclave signerv1.SignerServiceServer) (*enclaveclient.Pool, *grpc.Server, *bufconn.Listener) {
 	t.Helper()
 	lis := bufconn.Listen(testBufSize)
 	srv := grpc.NewServer()
-	signerv1.RegisterSignerServiceServer(srv, streamingServer{})
+	signerv1.RegisterSignerServiceServer(srv, enclave)
 	go func() { _ = srv.Serve(lis) }()
 	cfg := enclaveclient.DefaultConfig()
 	cfg.MinConns = 1
@@ -80,6 +99,92 @@ func TestEnclaveBackendSign(t *testing.T) {
 	require.Equal(t, []byte("payload"), resp.GetSignature())
 }
 
+// dryRunServer 模拟支持 dry-run 的 Enclave：先按 key cache 校验 key，dry-run 时跳过签名并回显 DryRun。
+type dryRunServer struct {
+	signerv1.UnimplementedSignerServiceServer
+	signed atomic.Int64
+}
+
+func (s *dryRunServer) SignStream(stream signerv1.SignerService_SignStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if req.GetKeyId() != "k1" {
+			return status.Error(codes.NotFound, "key not found")
+		}
+		resp := &signerv1.SignResponse{Sequence: req.GetSequence(), DryRun: req.GetDryRun()}
+		if !req.GetDryRun() {
+			s.signed.Add(1)
+			resp.Signature = append([]byte{}, req.GetDigest()...)
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func TestEnclaveBackendSignDryRun(t *testing.T) {
+	enclave := &dryRunServer{}
+	pool, _, _ := newTestPoolWith(t, enclave)
+	backend, err := NewEnclaveBackend(pool, StaticTargetSelector{TargetID: "enclave-1"})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := backend.Sign(ctx, &signerv1.SignRequest{KeyId: "k1", Digest: []byte("payload"), DryRun: true})
+	require.NoError(t, err)
+	require.True(t, resp.GetDryRun())
+	require.Equal(t, make([]byte, dryRunSignatureSize), resp.GetSignature())
+	require.Nil(t, resp.RecId)
+	require.Zero(t, enclave.signed.Load())
+
+	// dry-run 经 Enclave 完成 key checkout，未知 key 与真实签名一样报错。
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "unknown", Digest: []byte("payload"), DryRun: true})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestEnclaveBackendSignDryRunUnsupported(t *testing.T) {
+	// streamingServer 忽略 dry_run 照常签名，不回显 DryRun：丢弃其签名并报错。
+	pool, _, _ := newTestPool(t)
+	backend, err := NewEnclaveBackend(pool, StaticTargetSelector{TargetID: "enclave-1"})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := backend.Sign(ctx, &signerv1.SignRequest{KeyId: "k1", Digest: []byte("payload"), DryRun: true})
+	require.Nil(t, resp)
+	apiErr, ok := apierrors.FromError(err)
+	require.True(t, ok, err)
+	require.Equal(t, apierrors.CodeEnclaveUnavailable, apiErr.Code)
+}
+
+func TestEnclaveBackendDisableKey(t *testing.T) {
+	pool, _, _ := newTestPool(t)
+	backend, err := NewEnclaveBackend(pool, StaticTargetSelector{TargetID: "enclave-1"})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := backend.DisableKey(ctx, &signerv1.DisableKeyRequest{KeyId: "k1", Delete: true})
+	require.NoError(t, err)
+	require.Equal(t, "k1", resp.GetKeyId())
+	require.True(t, resp.GetDeleted())
+}
+
+func TestEnclaveBackendGetPublicKey(t *testing.T) {
+	pool, _, _ := newTestPool(t)
+	backend, err := NewEnclaveBackend(pool, StaticTargetSelector{TargetID: "enclave-1"})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := backend.GetPublicKey(ctx, &signerv1.GetPublicKeyRequest{KeyId: "k1"})
+	require.NoError(t, err)
+	require.Equal(t, "k1", resp.GetKeyId())
+	require.Equal(t, []byte{0x02, 0x01}, resp.GetPublicKey())
+}
+
 func TestEnclaveBackendCreate(t *testing.T) {
 	pool, _, _ := newTestPool(t)
 	backend, err := NewEnclaveBackend(pool, StaticTargetSelector{TargetID: "enclave-1"}, WithCallTimeout(500*time.Millisecond))
@@ -91,6 +196,29 @@ func TestEnclaveBackendCreate(t *testing.T) {
 	require.Equal(t, "generated", resp.GetKeyId())
 }
 
+func TestEnclaveBackendAcquireErrorCodes(t *testing.T) {
+	pool, _, _ := newTestPool(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	backend, err := NewEnclaveBackend(pool, StaticTargetSelector{TargetID: "enclave-typo"})
+	require.NoError(t, err)
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "k1", Digest: []byte("payload")})
+	apiErr, ok := apierrors.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, apierrors.CodeInvalidArgument, apiErr.Code)
+	require.ErrorIs(t, err, enclaveclient.ErrTargetNotFound)
+
+	require.NoError(t, pool.Drain("enclave-1"))
+	backend, err = NewEnclaveBackend(pool, StaticTargetSelector{TargetID: "enclave-1"})
+	require.NoError(t, err)
+	_, err = backend.Create(ctx, &signerv1.CreateRequest{})
+	apiErr, ok = apierrors.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, apierrors.CodeEnclaveUnavailable, apiErr.Code)
+	require.ErrorIs(t, err, enclaveclient.ErrPoolDraining)
+}
+
 func TestStickySelector(t *testing.T) {
 	selector, err := NewStickySelector([]string{"a", "b"})
 	require.NoError(t, err)
@@ -110,3 +238,104 @@ func TestStickySelector(t *testing.T) {
 		}
 	})
 }
+
+type unroutable map[string]bool
+
+func (u unroutable) Routable(id string) bool { return !u[id] }
+
+func TestStickySelectorFailover(t *testing.T) {
+	down := unroutable{}
+	selector, err := NewStickySelector([]string{"a", "b", "c"}, WithTargetHealth(down))
+	require.NoError(t, err)
+	req := &signerv1.SignRequest{KeyId: "hot-key"}
+	preferred, err := selector.SelectForSign(context.Background(), req)
+	require.NoError(t, err)
+
+	down[preferred] = true
+	fallback, err := selector.SelectForSign(context.Background(), req)
+	require.NoError(t, err)
+	require.NotEqual(t, preferred, fallback)
+	// 顺延后的目标不再对冲。
+	_, ok := selector.SelectAlternate(context.Background(), req, fallback)
+	require.False(t, ok)
+	for i := 0; i < 6; i++ {
+		target, _ := selector.SelectForCreate(context.Background(), &signerv1.CreateRequest{})
+		require.NotEqual(t, preferred, target)
+	}
+
+	// 全部不可用时返回首选目标，由 Acquire 报错。
+	down["a"], down["b"], down["c"] = true, true, true
+	target, err := selector.SelectForSign(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, preferred, target)
+}
+
+func TestEnclaveBackendSignFailsOverDrainedTarget(t *testing.T) {
+	pool := newMultiTargetPool(t, map[string]signerv1.SignerServiceServer{"e1": streamingServer{}, "e2": streamingServer{}})
+	selector, err := NewStickySelector([]string{"e1", "e2"}, WithTargetHealth(pool))
+	require.NoError(t, err)
+	backend, err := NewEnclaveBackend(pool, selector)
+	require.NoError(t, err)
+	req := &signerv1.SignRequest{KeyId: "hot-key", Digest: []byte("payload")}
+	preferred, err := selector.SelectForSign(context.Background(), req)
+	require.NoError(t, err)
+	require.NoError(t, pool.Drain(preferred))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := backend.Sign(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []byte("payload"), resp.GetSignature())
+}
+
+func TestStickySelectorWeights(t *testing.T) {
+	selector, err := NewStickySelector([]string{"big", "small"}, WithTargetWeights(map[string]int{"big": 3}))
+	require.NoError(t, err)
+	counts := map[string]int{}
+	for i := 0; i < 8; i++ {
+		target, _ := selector.SelectForCreate(context.Background(), &signerv1.CreateRequest{})
+		counts[target]++
+	}
+	require.Equal(t, map[string]int{"big": 6, "small": 2}, counts)
+	require.Equal(t, []int{0, 0, 1, 0}, smoothWeightedOrder([]int{3, 1}))
+
+	// 尚未加入的目标可预先配置权重。
+	_, err = NewStickySelector([]string{"a"}, WithTargetWeights(map[string]int{"b": 2}))
+	require.NoError(t, err)
+	_, err = NewStickySelector([]string{"a"}, WithTargetWeights(map[string]int{"a": 0}))
+	require.Error(t, err)
+	_, err = NewStickySelector([]string{"a"}, WithTargetWeights(map[string]int{"a": MaxTargetWeight + 1}))
+	require.Error(t, err)
+}
+
+func TestParseTargetWeights(t *testing.T) {
+	weights, err := ParseTargetWeights("big=16, small = 4,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"big": 16, "small": 4}, weights)
+
+	for _, raw := range []string{"big", "=2", "big=x", "big=1,big=2"} {
+		_, err := ParseTargetWeights(raw)
+		require.Error(t, err, raw)
+	}
+}
+
+func TestStickySelectorSetTargets(t *testing.T) {
+	selector, err := NewStickySelector([]string{"a", "b", "c"})
+	require.NoError(t, err)
+	before := map[string]string{}
+	for i := 0; i < 200; i++ {
+		key := "key-" + strconv.Itoa(i)
+		before[key], _ = selector.SelectForSign(context.Background(), &signerv1.SignRequest{KeyId: key})
+	}
+
+	require.NoError(t, selector.SetTargets([]string{"a", "b"}))
+	require.Equal(t, []string{"a", "b"}, selector.Targets())
+	for key, old := range before {
+		target, _ := selector.SelectForSign(context.Background(), &signerv1.SignRequest{KeyId: key})
+		if old != "c" {
+			require.Equal(t, old, target, key)
+		}
+		require.NotEqual(t, "c", target)
+	}
+	require.Error(t, selector.SetTargets(nil))
+}
@@ -0,0 +1,264 @@
+package signerapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// APIVersionV2 是由 signer.v1 proto 派生的 HTTP/JSON 接口前缀。
+const APIVersionV2 = "v2"
+
+// errorInfoDomain 标识 gRPC 错误详情中的 ErrorInfo 来源，Reason 为 apierrors 错误码。
+const errorInfoDomain = "aegis-sign"
+
+// WithGateway 在 /v2/{Method} 下挂载由 SignerService 服务描述派生的 HTTP/JSON 接口，
+// 请求与响应为 proto 的 JSON 映射，经与 gRPC 相同的实现处理，/v2 与 gRPC 两套契约不会分叉。
+// interceptor 通常为 InterceptorRegistry.UnaryChain 组装的 gRPC 拦截器链（request_id、tracing、日志、指标、recovery），
+// 使 /v2 调用同样出现在 gRPC 日志与指标中；nil 表示不经拦截器。
+// /v1 中与 SignerService 方法对应的路由同样经 srv 与 interceptor 处理，只由适配器保留 v1 的字段名与编码。
+func WithGateway(srv signerv1.SignerServiceServer, interceptor grpc.UnaryServerInterceptor) HTTPOption {
+	return func(h *HTTPHandler) {
+		h.gateway = srv
+		h.gatewayInterceptor = interceptor
+	}
+}
+
+// gatewayExcludedMethods 为仅限 gRPC 的管理方法与仅由 Enclave 实现的方法，不经 /v2 暴露在业务监听器上。
+var gatewayExcludedMethods = map[string]bool{
+	"ExportKeyBackup": true,
+	"GetAttestation":  true,
+}
+
+// signerMethods 按方法名索引 SignerService 的 unary 方法，供 /v1 路由取得处理器。
+var signerMethods = func() map[string]grpc.MethodDesc {
+	out := make(map[string]grpc.MethodDesc, len(signerv1.SignerService_ServiceDesc.Methods))
+	for _, method := range signerv1.SignerService_ServiceDesc.Methods {
+		out[method.MethodName] = method
+	}
+	return out
+}()
+
+// gatewayCodec 描述网关路由的 HTTP 契约：/v2 为 proto 的 JSON 映射，/v1 由适配器转换手写契约的字段名与编码。
+type gatewayCodec struct {
+	// httpMethod 为路由接受的 HTTP 方法。
+	httpMethod string
+	// decode 从请求填充 in（对应方法的 proto 请求），失败时返回 INVALID_ARGUMENT。
+	decode func(w http.ResponseWriter, r *http.Request, in proto.Message) *apierrors.Error
+	// encode 将 proto 响应编码为 JSON 响应体。
+	encode func(resp proto.Message) ([]byte, error)
+}
+
+// registerV2 为 SignerService 的每个 unary 方法注册 POST /{Method}；SignStream 为双向流，不经网关暴露。
+func (h *HTTPHandler) registerV2(mux Router) {
+	for _, method := range signerv1.SignerService_ServiceDesc.Methods {
+		if gatewayExcludedMethods[method.MethodName] {
+			continue
+		}
+		mux.HandleFunc("/"+method.MethodName, h.metrics.instrument("v2_"+method.MethodName, h.compress(h.gatewayMethod(method))))
+	}
+}
+
+func (h *HTTPHandler) gatewayMethod(method grpc.MethodDesc) http.HandlerFunc {
+	limit := h.body.withDefaults().MaxBytes
+	if method.MethodName == "BatchSign" {
+		limit = h.body.withDefaults().BatchMaxBytes
+	}
+	unmarshal := protojson.UnmarshalOptions{DiscardUnknown: h.body.AllowUnknownFields}
+	codec := gatewayCodec{
+		httpMethod: http.MethodPost,
+		decode: func(w http.ResponseWriter, r *http.Request, in proto.Message) *apierrors.Error {
+			raw, apiErr := readGatewayBody(w, r, limit)
+			if apiErr != nil || len(raw) == 0 {
+				return apiErr
+			}
+			if err := unmarshal.Unmarshal(raw, in); err != nil {
+				return apierrors.New(apierrors.CodeInvalidArgument, "invalid JSON body: "+err.Error())
+			}
+			return nil
+		},
+		encode: func(resp proto.Message) ([]byte, error) { return protojson.Marshal(resp) },
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.serveGateway(w, r, method, codec)
+	}
+}
+
+// serveGateway 经 SignerService 的 method 处理一次 HTTP 请求，与 gRPC 调用共用校验、解锁入队与错误映射。
+func (h *HTTPHandler) serveGateway(w http.ResponseWriter, r *http.Request, method grpc.MethodDesc, codec gatewayCodec) {
+	if r.Method != codec.httpMethod {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, codec.httpMethod+" required"))
+		return
+	}
+	dec := func(in any) error {
+		if apiErr := codec.decode(w, r, in.(proto.Message)); apiErr != nil {
+			return apiStatusError(apiErr)
+		}
+		return nil
+	}
+	stream := &gatewayStream{method: "/" + signerv1.SignerService_ServiceDesc.ServiceName + "/" + method.MethodName}
+	ctx := grpc.NewContextWithServerTransportStream(r.Context(), stream)
+	ctx = metadata.NewIncomingContext(ctx, gatewayMetadata(r))
+	resp, err := method.Handler(h.signerServer(), ctx, dec, h.gatewayInterceptor)
+	if err != nil {
+		h.writeGatewayError(w, stream.header, err)
+		return
+	}
+	data, err := codec.encode(resp.(proto.Message))
+	if err != nil {
+		h.writeUnknownError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// v1Codec 构造 /v1 路由的网关契约：decode 将 v1 请求转换为 proto 请求，encode 将 proto 响应转换为 v1 响应体，
+// 两者只做字段名与编码的转换，校验、调用与错误映射均由 SignerService 实现完成。
+func v1Codec[Req, Resp proto.Message, Body any](httpMethod string, decode func(w http.ResponseWriter, r *http.Request, in Req) *apierrors.Error, encode func(resp Resp) Body) gatewayCodec {
+	return gatewayCodec{
+		httpMethod: httpMethod,
+		decode: func(w http.ResponseWriter, r *http.Request, in proto.Message) *apierrors.Error {
+			return decode(w, r, in.(Req))
+		},
+		encode: func(resp proto.Message) ([]byte, error) {
+			// 与 writeJSON 的 json.Encoder 输出一致，末尾带换行。
+			data, err := json.Marshal(encode(resp.(Resp)))
+			return append(data, '\n'), err
+		},
+	}
+}
+
+// signerServer 返回网关调用的 SignerService 实现；未设置 WithGateway 时以同一 backend 构造 GRPCServer，
+// /v1 因此始终与 gRPC 共用同一套实现。
+func (h *HTTPHandler) signerServer() signerv1.SignerServiceServer {
+	if h.gateway != nil {
+		return h.gateway
+	}
+	srv := NewGRPCServer(h.backend, h.unlock)
+	srv.SetRetryHints(h.hints)
+	srv.SetBatchConfig(h.batch)
+	return srv
+}
+
+// readGatewayBody 读取请求体，超过 limit 时返回 INVALID_ARGUMENT，与 v1 的提示一致。
+func readGatewayBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, *apierrors.Error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	raw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return nil, apierrors.New(apierrors.CodeInvalidArgument, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+	case err != nil:
+		return nil, apierrors.New(apierrors.CodeInvalidArgument, "failed to read request body")
+	}
+	return raw, nil
+}
+
+// gatewayMetadata 将请求头转换为 gRPC incoming metadata（键名小写），
+// 使 Idempotency-Key 等头部与 gRPC 调用方式同样生效；x-request-id 取 HTTP 中间件已确定的请求 ID，
+// 网关上的 request_id 拦截器不会另行生成，响应头、日志与审计使用同一 ID。
+func gatewayMetadata(r *http.Request) metadata.MD {
+	md := make(metadata.MD, len(r.Header)+1)
+	for key, values := range r.Header {
+		key = strings.ToLower(key)
+		if key == "connection" || key == "content-length" || key == "te" {
+			continue
+		}
+		md[key] = append(md[key], values...)
+	}
+	if id, ok := reqctx.RequestIDFrom(r.Context()); ok && id != "" {
+		md.Set(RequestIDMetadataKey, id)
+	}
+	return md
+}
+
+// writeGatewayError 从 gRPC 状态的 ErrorInfo 中还原 apierrors 错误码，
+// 并将 retry-after-ms、x-unlock-request-id 响应头转换为与 v1 相同的 Retry-After 语义。
+func (h *HTTPHandler) writeGatewayError(w http.ResponseWriter, header metadata.MD, err error) {
+	st := status.Convert(err)
+	code := gatewayCode(st.Code())
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == errorInfoDomain {
+			code = apierrors.Code(info.GetReason())
+		}
+	}
+	resp := errorResponse{Code: string(code), Message: st.Message()}
+	if values := header.Get("retry-after-ms"); len(values) > 0 {
+		if ms, err := strconv.ParseInt(values[0], 10, 64); err == nil {
+			retry := time.Duration(ms) * time.Millisecond
+			w.Header().Set("Retry-After", formatRetryAfterHeader(retry))
+			resp.RetryAfterHint = formatRetryAfterHint(retry)
+		}
+	}
+	if values := header.Get("x-unlock-request-id"); len(values) > 0 {
+		w.Header().Set("X-Unlock-Request-Id", values[0])
+	}
+	h.writeJSON(w, apierrors.HTTPStatus(code), resp)
+}
+
+// gatewayCode 为未携带 ErrorInfo 的 gRPC 错误（如参数校验）选择 apierrors 错误码。
+func gatewayCode(code codes.Code) apierrors.Code {
+	switch code {
+	case codes.InvalidArgument:
+		return apierrors.CodeInvalidArgument
+	case codes.NotFound:
+		return apierrors.CodeInvalidKey
+	case codes.ResourceExhausted:
+		return apierrors.CodeRetryLater
+	case codes.Unavailable:
+		return apierrors.CodeEnclaveUnavailable
+	case codes.Unauthenticated:
+		return apierrors.CodeUnauthenticated
+	case codes.PermissionDenied:
+		return apierrors.CodePermissionDenied
+	default:
+		return apierrors.Code("INTERNAL_ERROR")
+	}
+}
+
+// gatewayStream 实现 grpc.ServerTransportStream，收集处理器通过 grpc.SetHeader 设置的响应头。
+type gatewayStream struct {
+	method string
+	header metadata.MD
+}
+
+func (s *gatewayStream) Method() string { return s.method }
+
+func (s *gatewayStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *gatewayStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *gatewayStream) SetTrailer(metadata.MD) error { return nil }
+
+// apiStatusError 构造携带 ErrorInfo 的 gRPC 错误，调用方可据此取得 apierrors 错误码。
+func apiStatusError(apiErr *apierrors.Error) error {
+	st := status.New(apierrors.GRPCStatus(apiErr.Code), apiErr.Error())
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: string(apiErr.Code), Domain: errorInfoDomain})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
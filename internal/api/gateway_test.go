@@ -0,0 +1,169 @@
+package signerapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGatewayRoutes(t *testing.T) {
+	var sawIdempotency string
+	backend := &stubBackend{
+		createFn: func(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			if values := md.Get("idempotency-key"); len(values) > 0 {
+				sawIdempotency = values[0]
+			}
+			return &signerv1.CreateResponse{KeyId: "k1", Address: req.GetCurve()}, nil
+		},
+		signFn: func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			switch req.GetKeyId() {
+			case "busy":
+				return nil, apierrors.New(apierrors.CodeRetryLater, "busy").WithRetryAfter(250 * time.Millisecond)
+			case "locked":
+				return nil, apierrors.New(apierrors.CodeUnlockRequired, "key locked")
+			}
+			return &signerv1.SignResponse{Signature: bytes.Repeat([]byte{0x01}, 64)}, nil
+		},
+	}
+	handler := NewHTTPHandler(backend, WithGateway(NewGRPCServer(backend, nil), nil))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+	post := func(path, body string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+	digest := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0xaa}, 32))
+
+	rr := post("/v2/Create", `{"curve":"secp256k1"}`, map[string]string{IdempotencyKeyHeader: "req-1"})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.JSONEq(t, `{"keyId":"k1","address":"secp256k1"}`, rr.Body.String())
+	require.Equal(t, "req-1", sawIdempotency)
+
+	rr = post("/v2/Sign", `{"keyId":"k1","digest":"`+digest+`"}`, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Contains(t, rr.Body.String(), `"signature"`)
+
+	// RETRY_LATER 与 UNLOCK_REQUIRED 保留 v1 的状态码与 Retry-After 语义。
+	rr = post("/v2/Sign", `{"keyId":"busy","digest":"`+digest+`"}`, nil)
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	require.Equal(t, "0.250", rr.Header().Get("Retry-After"))
+	var resp errorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, string(apierrors.CodeRetryLater), resp.Code)
+	require.Equal(t, "250", resp.RetryAfterHint)
+
+	rr = post("/v2/Sign", `{"keyId":"locked","digest":"`+digest+`"}`, nil)
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	require.Equal(t, "0.100", rr.Header().Get("Retry-After"))
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, string(apierrors.CodeUnlockRequired), resp.Code)
+
+	// 参数校验与 JSON 解析错误映射为 INVALID_ARGUMENT。
+	for _, body := range []string{`{"keyId":"k1","digest":"AA=="}`, `{"keyId":"k1","unknown":1}`, `{`} {
+		rr = post("/v2/Sign", body, nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code, body)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, string(apierrors.CodeInvalidArgument), resp.Code, body)
+	}
+
+	// SignStream 为双向流，不经网关暴露；未设置 WithGateway 时不注册 /v2。
+	rr = post("/v2/SignStream", `{}`, nil)
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	plain := http.NewServeMux()
+	NewHTTPHandler(backend).Register(plain)
+	rr = httptest.NewRecorder()
+	plain.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v2/Sign", strings.NewReader(`{}`)))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestGatewayRunsUnaryInterceptorChain(t *testing.T) {
+	var calls []string
+	recording := func(name string) GRPCInterceptor {
+		return GRPCInterceptor{Unary: func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			calls = append(calls, name+":"+info.FullMethod)
+			return handler(ctx, req)
+		}}
+	}
+	registry := NewInterceptorRegistry()
+	registry.Register("outer", recording("outer"))
+	registry.Register("inner", recording("inner"))
+	chain, err := registry.UnaryChain([]string{"outer", "inner"})
+	require.NoError(t, err)
+
+	backend := &stubBackend{}
+	mux := http.NewServeMux()
+	NewHTTPHandler(backend, WithGateway(NewGRPCServer(backend, nil), chain)).Register(mux)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v2/Create", strings.NewReader(`{"curve":"secp256k1"}`)))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	method := "/" + signerv1.SignerService_ServiceDesc.ServiceName + "/Create"
+	require.Equal(t, []string{"outer:" + method, "inner:" + method}, calls)
+
+	chain, err = registry.UnaryChain(nil)
+	require.NoError(t, err)
+	require.Nil(t, chain)
+}
+
+func TestV1RoutesRunThroughGateway(t *testing.T) {
+	var methods []string
+	registry := NewInterceptorRegistry()
+	registry.Register("request_id", RequestIDInterceptor())
+	registry.Register("recording", GRPCInterceptor{Unary: func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		methods = append(methods, info.FullMethod)
+		return handler(ctx, req)
+	}})
+	chain, err := registry.UnaryChain([]string{"request_id", "recording"})
+	require.NoError(t, err)
+
+	var sawRequestID string
+	backend := &stubBackend{signFn: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		sawRequestID, _ = reqctx.RequestIDFrom(ctx)
+		recID := uint32(1)
+		return &signerv1.SignResponse{Signature: bytes.Repeat([]byte{0xab}, 64), RecId: &recID}, nil
+	}}
+	mux := http.NewServeMux()
+	NewHTTPHandler(backend, WithGateway(NewGRPCServer(backend, nil), chain)).Register(mux)
+	handler := RequestIDMiddleware(mux)
+
+	// v1 保留 hex 编码与字段名，但经 SignerService 与同一拦截器链处理，请求 ID 沿用 HTTP 中间件确定的值。
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/sign", strings.NewReader(`{"keyId":"k1","digest":"`+strings.Repeat("a", 64)+`"}`)))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.JSONEq(t, `{"signature":"`+strings.Repeat("ab", 64)+`","recId":1}`, rr.Body.String())
+	require.Equal(t, rr.Header().Get(RequestIDHeader), sawRequestID)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/keys/k1/publickey", nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Contains(t, rr.Body.String(), `"keyId":"k1"`)
+
+	// 校验失败同样来自 gRPC 实现，错误体保持 v1 格式。
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/create", strings.NewReader(`{"curve":"ed448"}`)))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	var resp errorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, string(apierrors.CodeInvalidArgument), resp.Code)
+
+	prefix := "/" + signerv1.SignerService_ServiceDesc.ServiceName + "/"
+	require.Equal(t, []string{prefix + "Sign", prefix + "GetPublicKey", prefix + "Create"}, methods)
+}
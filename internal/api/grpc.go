@@ -2,12 +2,16 @@ package signerapi
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"strconv"
+	"sync"
 	"time"
 
 	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
 	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/aegis-sign/wallet/pkg/curves"
+	"github.com/aegis-sign/wallet/pkg/validator"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -19,6 +23,11 @@ type GRPCServer struct {
 	signerv1.UnimplementedSignerServiceServer
 	backend Backend
 	unlock  *UnlockResponder
+	hints   *RetryHintProvider
+	streams *StreamLimiter
+	batch   BatchConfig
+	watcher *UnlockWatcher
+	backup  *KeyBackup
 }
 
 // NewGRPCServer 构造 gRPC server。
@@ -29,14 +38,61 @@ func NewGRPCServer(backend Backend, unlock *UnlockResponder) *GRPCServer {
 	return &GRPCServer{backend: backend, unlock: unlock}
 }
 
+// SetRetryHints 设置 RETRY_LATER 的动态退避提示来源，通过 retry-after-ms header 下发。
+func (s *GRPCServer) SetRetryHints(p *RetryHintProvider) {
+	s.hints = p
+}
+
 // Create 直接透传到 backend。
 func (s *GRPCServer) Create(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
 	if req == nil {
 		return nil, status.Error(codes.InvalidArgument, "request is required")
 	}
+	curve, err := curves.Lookup(req.GetCurve())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	req.Curve = curve.Name
+	formats, apiErr := parseAddressFormats(curve.Name, req.GetAddressFormats())
+	if apiErr != nil {
+		return nil, apiStatusError(apiErr)
+	}
+	req.AddressFormats = formatStrings(formats)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(IdempotencyKeyMetadataKey); len(values) > 0 {
+			if ctx, err = withIdempotencyKey(ctx, values[0]); err != nil {
+				return nil, s.grpcError(ctx, err)
+			}
+		}
+	}
+	ctx = withAuditContext(ctx, req.GetAuditContext())
 	resp, err := s.backend.Create(ctx, req)
 	if err != nil {
-		return nil, s.grpcError(err)
+		return nil, s.grpcError(ctx, err)
+	}
+	if err := deriveAddresses(resp, formats); err != nil {
+		return nil, s.grpcError(ctx, err)
+	}
+	return resp, nil
+}
+
+// ImportKey 校验曲线与导入信封后调用 backend。
+func (s *GRPCServer) ImportKey(ctx context.Context, req *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "request is required")
+	}
+	curve, err := curves.Lookup(req.GetCurve())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	req.Curve = curve.Name
+	ctx = withAuditContext(ctx, req.GetAuditContext())
+	if err := validateImportRequest(req); err != nil {
+		return nil, s.grpcError(ctx, err)
+	}
+	resp, err := s.backend.ImportKey(ctx, req)
+	if err != nil {
+		return nil, s.grpcError(ctx, err)
 	}
 	return resp, nil
 }
@@ -46,44 +102,270 @@ func (s *GRPCServer) Sign(ctx context.Context, req *signerv1.SignRequest) (*sign
 	if req == nil {
 		return nil, status.Error(codes.InvalidArgument, "request is required")
 	}
-	if len(req.GetDigest()) != 32 {
-		return nil, status.Error(codes.InvalidArgument, "digest must be 32 bytes")
+	if apiErr := checkSignDigest(req); apiErr != nil {
+		return nil, status.Error(codes.InvalidArgument, apiErr.Error())
 	}
+	ctx = withAuditContext(ctx, req.GetAuditContext())
 	resp, err := s.backend.Sign(ctx, req)
 	if err != nil {
 		s.tryHandleUnlock(ctx, req.GetKeyId(), err)
-		return nil, s.grpcError(err)
+		return nil, s.grpcError(ctx, err)
 	}
 	return resp, nil
 }
 
-// SignStream 支持双向流模式，用于压测和粘性路由。
+// SignStream 支持双向流模式，用于压测和粘性路由。流内请求流水线并发处理，响应回显 key_id、request_id 与 sequence；
+// 单个流最多 StreamMaxInFlight 个（调用方可经 x-sign-stream-window 调小），达到窗口时暂停 Recv；
+// x-sign-stream-ordered 为 true 时响应按请求到达顺序返回。共享许可耗尽时立即回 in-band RETRY_LATER，不阻塞 Recv。
 func (s *GRPCServer) SignStream(stream signerv1.SignerService_SignStreamServer) error {
+	quota := s.streams.open()
+	defer quota.close()
+	mode := signStreamModeFrom(stream.Context(), s.streams.streamMaxInFlight())
+	out := newSignStreamSender(stream, mode)
+	var wg sync.WaitGroup
+	defer wg.Wait()
 	for {
 		req, err := stream.Recv()
 		if err == io.EOF {
-			return nil
+			wg.Wait()
+			return out.failed()
 		}
 		if err != nil {
 			return err
 		}
-		if len(req.GetDigest()) != 32 {
-			return status.Error(codes.InvalidArgument, "digest must be 32 bytes")
+		if err := out.failed(); err != nil {
+			return err
 		}
-		resp, signErr := s.backend.Sign(stream.Context(), req)
-		if signErr != nil {
-			s.tryHandleUnlock(stream.Context(), req.GetKeyId(), signErr)
-			return s.grpcError(signErr)
+		if apiErr := checkSignDigest(req); apiErr != nil {
+			return status.Error(codes.InvalidArgument, apiErr.Error())
 		}
-		if err := stream.Send(resp); err != nil {
-			return err
+		ctx := withAuditContext(stream.Context(), req.GetAuditContext())
+		slot := out.reserve()
+		if !quota.tryAcquire() {
+			out.emit(slot, req, s.streamError(ctx, req.GetKeyId(), apierrors.New(apierrors.CodeRetryLater, "stream permits exhausted").
+				WithRetryAfter(s.hints.Hint(RetryReasonPoolSaturated))))
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, signErr := s.backend.Sign(ctx, req)
+			quota.release()
+			if signErr != nil {
+				resp = s.streamError(ctx, req.GetKeyId(), signErr)
+			}
+			out.emit(slot, req, resp)
+		}()
+	}
+}
+
+// BatchSign 并发处理一批签名请求，结果与 items 顺序一致；单项失败以 in-band error 返回。
+func (s *GRPCServer) BatchSign(ctx context.Context, req *signerv1.BatchSignRequest) (*signerv1.BatchSignResponse, error) {
+	cfg := s.batch.withDefaults()
+	items := req.GetItems()
+	if len(items) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "items is required")
+	}
+	if len(items) > cfg.MaxItems {
+		return nil, status.Errorf(codes.InvalidArgument, "batch exceeds %d items", cfg.MaxItems)
+	}
+	ctx = withAuditContext(ctx, req.GetAuditContext())
+	results := make([]*signerv1.SignResponse, len(items))
+	reqs := make([]*signerv1.SignRequest, len(items))
+	for i, item := range items {
+		if apiErr := checkSignDigest(item); apiErr != nil {
+			results[i] = s.streamError(ctx, item.GetKeyId(), apiErr)
+			continue
+		}
+		if item.AuditContext == nil {
+			item.AuditContext = req.GetAuditContext()
+		}
+		reqs[i] = item
+	}
+	for i, res := range signBatch(ctx, s.backend, reqs, cfg.Concurrency) {
+		if reqs[i] == nil {
+			continue
+		}
+		resp := res.resp
+		if res.err != nil {
+			resp = s.streamError(ctx, reqs[i].GetKeyId(), res.err)
+		}
+		resp.KeyId = reqs[i].GetKeyId()
+		results[i] = resp
+	}
+	return &signerv1.BatchSignResponse{Results: results}, nil
+}
+
+// GetPublicKey 校验 key_id 后查询公钥与地址。
+func (s *GRPCServer) GetPublicKey(ctx context.Context, req *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error) {
+	if req.GetKeyId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "key_id is required")
+	}
+	ctx = withAuditContext(ctx, req.GetAuditContext())
+	resp, err := s.backend.GetPublicKey(ctx, req)
+	if err != nil {
+		return nil, s.grpcError(ctx, err)
+	}
+	return resp, nil
+}
+
+// DisableKey 停用 key 并转发至所属 Enclave，delete=true 时同时删除密钥材料。
+func (s *GRPCServer) DisableKey(ctx context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+	if req.GetKeyId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "key_id is required")
+	}
+	ctx = withAuditContext(ctx, req.GetAuditContext())
+	resp, err := s.backend.DisableKey(ctx, req)
+	if err != nil {
+		return nil, s.grpcError(ctx, err)
+	}
+	return resp, nil
+}
+
+// SignTransaction 在服务端计算以太坊交易的签名哈希并返回已签名交易。
+func (s *GRPCServer) SignTransaction(ctx context.Context, req *signerv1.SignTransactionRequest) (*signerv1.SignTransactionResponse, error) {
+	if len(req.GetUnsignedTx()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "unsigned_tx is required")
+	}
+	ctx = withAuditContext(ctx, req.GetAuditContext())
+	resp, err := signTransaction(ctx, s.backend, req)
+	if err != nil {
+		s.tryHandleUnlock(ctx, req.GetKeyId(), err)
+		return nil, s.grpcError(ctx, err)
+	}
+	return resp, nil
+}
+
+// SetBatchConfig 设置 BatchSign 的条数上限与并发度。
+func (s *GRPCServer) SetBatchConfig(cfg BatchConfig) {
+	s.batch = cfg
+}
+
+// checkSignDigest 校验 digest 长度：请求带 curve 时按该曲线校验并规范化曲线名，
+// 否则按登记处所有曲线摘要长度的并集校验；带 derivation_path 时一并校验并规范化路径。
+// rawMessage 请求先由服务端哈希得到 digest，再走同一套校验。
+func checkSignDigest(req *signerv1.SignRequest) *apierrors.Error {
+	if apiErr := hashSignMessage(req); apiErr != nil {
+		return apiErr
+	}
+	if req.GetCurve() == "" {
+		if !curves.ValidDigestSize(len(req.GetDigest())) {
+			return apierrors.New(apierrors.CodeInvalidArgument, "digest must be 32 bytes")
+		}
+		return checkDerivationPath(req, curves.Default())
+	}
+	curve, err := curves.Lookup(req.GetCurve())
+	if err != nil {
+		return apierrors.New(apierrors.CodeInvalidArgument, err.Error())
+	}
+	if len(req.GetDigest()) != curve.DigestSize {
+		return apierrors.New(apierrors.CodeInvalidArgument, fmt.Sprintf("digest must be %d bytes for %s", curve.DigestSize, curve.Name))
+	}
+	req.Curve = curve.Name
+	return checkDerivationPath(req, curve)
+}
+
+// hashSignMessage 处理 payload_type：digest 模式不得携带 message 与 hash_algorithm；rawMessage 模式按 hash_algorithm
+// 哈希 message 写入 digest 并清空 message，之后的中间件、审计与 Enclave 只看到摘要，规范化的 payload_type 与算法保留供审计。
+func hashSignMessage(req *signerv1.SignRequest) *apierrors.Error {
+	payload, err := validator.NormalizePayloadType(req.GetPayloadType())
+	if err != nil {
+		return apierrors.New(apierrors.CodeInvalidArgument, err.Error())
+	}
+	if payload == validator.PayloadTypeDigest {
+		if len(req.GetMessage()) > 0 || req.GetHashAlgorithm() != "" {
+			return apierrors.New(apierrors.CodeInvalidArgument, "message and hash_algorithm require payload_type rawMessage")
 		}
+		req.PayloadType = ""
+		return nil
 	}
+	if len(req.GetDigest()) > 0 {
+		return apierrors.New(apierrors.CodeInvalidArgument, "digest must be empty for rawMessage payload")
+	}
+	alg, err := validator.NormalizeHashAlgorithm(req.GetHashAlgorithm())
+	if err != nil {
+		return apierrors.New(apierrors.CodeInvalidArgument, err.Error())
+	}
+	digest, err := validator.HashMessage(req.GetMessage(), alg)
+	if err != nil {
+		return apierrors.New(apierrors.CodeInvalidArgument, err.Error())
+	}
+	req.Digest, req.Message = digest, nil
+	req.PayloadType, req.HashAlgorithm = string(payload), string(alg)
+	return nil
+}
+
+// checkDerivationPath 按曲线校验 derivation_path 并改写为规范写法，使同一子 key 在路由、缓存与审计中只有一个标识。
+func checkDerivationPath(req *signerv1.SignRequest, curve curves.Curve) *apierrors.Error {
+	if req.GetDerivationPath() == "" {
+		return nil
+	}
+	path, err := validator.NormalizeDerivationPath(req.GetDerivationPath(), curve)
+	if err != nil {
+		return apierrors.New(apierrors.CodeInvalidArgument, err.Error())
+	}
+	req.DerivationPath = path
+	return nil
+}
+
+// SetStreamLimiter 设置 SignStream 共享许可池，nil 表示不限制。
+func (s *GRPCServer) SetStreamLimiter(l *StreamLimiter) {
+	s.streams = l
 }
 
-func (s *GRPCServer) grpcError(err error) error {
+// streamError 将 SignStream / BatchSign 中单个请求的失败转换为 in-band 响应；UNLOCK_REQUIRED 同样触发解锁入队。
+func (s *GRPCServer) streamError(ctx context.Context, keyID string, err error) *signerv1.SignResponse {
+	errStatus := &signerv1.ErrorStatus{Message: "internal error"}
 	if apiErr, ok := apierrors.FromError(err); ok {
-		return status.Error(apierrors.GRPCStatus(apiErr.Code), apiErr.Error())
+		errStatus.Code = apiErrorCode(apiErr.Code)
+		errStatus.Message = apiErr.Error()
+		retry, hasRetry := apiErr.RetryAfter(), apiErr.HasRetryAfter()
+		switch {
+		case apiErr.Code == apierrors.CodeUnlockRequired:
+			retry, hasRetry = 100*time.Millisecond, true
+			if s.unlock != nil {
+				if meta := s.unlock.Handle(ctx, keyID, err); meta.RetryAfter > 0 {
+					retry = meta.RetryAfter
+				}
+			}
+		case apiErr.Code == apierrors.CodeRetryLater && !hasRetry:
+			retry, hasRetry = s.hints.HintForError(apiErr), true
+		}
+		if hasRetry {
+			errStatus.RetryAfter = formatRetryAfterHeader(retry)
+		}
+	}
+	return &signerv1.SignResponse{KeyId: keyID, Error: errStatus}
+}
+
+// apiErrorCode 将业务错误码映射到 proto 枚举，无对应枚举的错误码为 UNSPECIFIED（详见 message）。
+func apiErrorCode(code apierrors.Code) signerv1.ApiErrorCode {
+	switch code {
+	case apierrors.CodeInvalidArgument:
+		return signerv1.ApiErrorCode_API_ERROR_CODE_INVALID_ARGUMENT
+	case apierrors.CodeRetryLater:
+		return signerv1.ApiErrorCode_API_ERROR_CODE_RETRY_LATER
+	case apierrors.CodeUnlockRequired:
+		return signerv1.ApiErrorCode_API_ERROR_CODE_UNLOCK_REQUIRED
+	case apierrors.CodeInvalidKey:
+		return signerv1.ApiErrorCode_API_ERROR_CODE_INVALID_KEY
+	default:
+		return signerv1.ApiErrorCode_API_ERROR_CODE_UNSPECIFIED
+	}
+}
+
+func (s *GRPCServer) grpcError(ctx context.Context, err error) error {
+	if apiErr, ok := apierrors.FromError(err); ok {
+		if apiErr.Code == apierrors.CodeRetryLater {
+			retry, ok := apiErr.RetryAfter(), apiErr.HasRetryAfter()
+			if !ok && s.hints != nil {
+				retry, ok = s.hints.HintForError(apiErr), true
+			}
+			if ok {
+				_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after-ms", strconv.FormatInt(retry.Milliseconds(), 10)))
+			}
+		}
+		return apiStatusError(apiErr)
 	}
 	return status.Error(codes.Internal, "internal error")
 }
@@ -0,0 +1,131 @@
+package signerapi
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// EnclaveHealthPrefix 是单个 Enclave 连接池在 grpc.health.v1 中的服务名前缀，如 enclave/enc-1。
+	EnclaveHealthPrefix = "enclave/"
+
+	healthMethodPrefix    = "/grpc.health.v1.Health/"
+	defaultHealthInterval = 5 * time.Second
+)
+
+// PoolHealthSource 提供各 Enclave 连接池状态，*enclaveclient.Pool 满足。
+type PoolHealthSource interface {
+	Stats() []enclaveclient.TargetStats
+}
+
+// HealthConfig 配置 gRPC 健康检查状态的刷新。
+type HealthConfig struct {
+	Pool PoolHealthSource
+	// Interval 为刷新周期，默认 5s。
+	Interval time.Duration
+}
+
+// HealthReporter 依据连接池状态维护 grpc.health.v1 的服务状态：
+// 整体服务（"" 与 signer.v1.SignerService）在至少一个 Enclave 可用时为 SERVING，
+// 每个 Enclave 另以 enclave/<id> 暴露自身状态。
+type HealthReporter struct {
+	cfg    HealthConfig
+	server *health.Server
+
+	mu       sync.Mutex
+	known    map[string]struct{}
+	shutdown bool
+}
+
+// NewHealthReporter 构造 HealthReporter，初始状态为 NOT_SERVING，直到首次 Refresh。
+func NewHealthReporter(cfg HealthConfig) *HealthReporter {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultHealthInterval
+	}
+	h := &HealthReporter{cfg: cfg, server: health.NewServer(), known: make(map[string]struct{})}
+	h.setOverall(healthpb.HealthCheckResponse_NOT_SERVING)
+	return h
+}
+
+// Register 将健康检查服务注册到 gRPC server。
+func (h *HealthReporter) Register(srv *grpc.Server) {
+	healthpb.RegisterHealthServer(srv, h.server)
+}
+
+// Run 立即刷新一次并按 Interval 周期刷新，直到 ctx 结束。
+func (h *HealthReporter) Run(ctx context.Context) {
+	h.Refresh()
+	ticker := time.NewTicker(h.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.Refresh()
+		}
+	}
+}
+
+// Refresh 按连接池当前状态更新各服务状态。draining 的 Enclave 视为不可用；
+// degraded 仍会接收流量以便熔断器恢复，因此视为可用。
+func (h *HealthReporter) Refresh() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.shutdown {
+		return
+	}
+	var stats []enclaveclient.TargetStats
+	if h.cfg.Pool != nil {
+		stats = h.cfg.Pool.Stats()
+	}
+	seen := make(map[string]struct{}, len(stats))
+	serving := false
+	for _, st := range stats {
+		name := EnclaveHealthPrefix + st.ID
+		seen[name] = struct{}{}
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if st.Available() {
+			status = healthpb.HealthCheckResponse_SERVING
+			serving = true
+		}
+		h.server.SetServingStatus(name, status)
+	}
+	// 已移除的 Enclave 保留为 NOT_SERVING，避免 Watch 方悬空。
+	for name := range h.known {
+		if _, ok := seen[name]; !ok {
+			h.server.SetServingStatus(name, healthpb.HealthCheckResponse_NOT_SERVING)
+		}
+	}
+	h.known = seen
+	if serving {
+		h.setOverall(healthpb.HealthCheckResponse_SERVING)
+	} else {
+		h.setOverall(healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+}
+
+// Shutdown 将全部服务置为 NOT_SERVING 且不再刷新，应在 GracefulStop 之前调用以便探针提前摘流。
+func (h *HealthReporter) Shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.shutdown = true
+	h.server.Shutdown()
+}
+
+func (h *HealthReporter) setOverall(status healthpb.HealthCheckResponse_ServingStatus) {
+	h.server.SetServingStatus("", status)
+	h.server.SetServingStatus(signerv1.SignerService_ServiceDesc.ServiceName, status)
+}
+
+func isHealthMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, healthMethodPrefix)
+}
@@ -0,0 +1,84 @@
+package signerapi
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeHealthPool struct {
+	mu    sync.Mutex
+	stats []enclaveclient.TargetStats
+}
+
+func (p *fakeHealthPool) Stats() []enclaveclient.TargetStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]enclaveclient.TargetStats(nil), p.stats...)
+}
+
+func (p *fakeHealthPool) set(stats ...enclaveclient.TargetStats) {
+	p.mu.Lock()
+	p.stats = stats
+	p.mu.Unlock()
+}
+
+func TestHealthReporterTracksPool(t *testing.T) {
+	pool := &fakeHealthPool{}
+	reporter := NewHealthReporter(HealthConfig{Pool: pool})
+
+	// 认证拦截器不应拦截健康探针。
+	auth := AuthInterceptor(StaticTokenAuthenticator(map[string]string{"s3cret": "svc-a"}))
+	lis := bufconn.Listen(testBufSize)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(auth.Unary), grpc.ChainStreamInterceptor(auth.Stream))
+	reporter.Register(srv)
+	go func() { _ = srv.Serve(lis) }()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+		srv.Stop()
+	})
+	client := healthpb.NewHealthClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.GetStatus()
+	}
+
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+
+	pool.set(enclaveclient.TargetStats{ID: "a", Breaker: "degraded"}, enclaveclient.TargetStats{ID: "b", Breaker: "draining"})
+	reporter.Refresh()
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, check(""))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, check("signer.v1.SignerService"))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, check("enclave/a"))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check("enclave/b"))
+
+	pool.set(enclaveclient.TargetStats{ID: "b", Breaker: "draining"})
+	reporter.Refresh()
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check("enclave/a"))
+
+	pool.set(enclaveclient.TargetStats{ID: "b", Breaker: "healthy"})
+	reporter.Refresh()
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, check(""))
+	reporter.Shutdown()
+	reporter.Refresh()
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check("enclave/b"))
+}
@@ -0,0 +1,350 @@
+package signerapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// 内置拦截器名称，可在 SIGNER_GRPC_INTERCEPTORS 中引用。
+const (
+	InterceptorRequestID = "request_id"
+	InterceptorRecovery  = "recovery"
+	InterceptorLogging   = "logging"
+	InterceptorMetrics   = "metrics"
+	InterceptorAuth      = "auth"
+)
+
+// DefaultGRPCInterceptors 是未配置 SIGNER_GRPC_INTERCEPTORS 时启用的拦截器；
+// request_id 位于最外层使其后的日志与 span 均带请求 ID，recovery 位于最内层，panic 转换后的 Internal 仍会被日志与指标记录。
+var DefaultGRPCInterceptors = []string{InterceptorRequestID, InterceptorTracing, InterceptorLogging, InterceptorMetrics, InterceptorRecovery}
+
+// AuthenticatedGRPCInterceptors 是启用认证且未配置 SIGNER_GRPC_INTERCEPTORS 时的默认顺序；
+// metrics 位于 auth 之外以统计被拒绝的调用，logging 位于 auth 之内以记录调用方。
+var AuthenticatedGRPCInterceptors = []string{InterceptorRequestID, InterceptorTracing, InterceptorMetrics, InterceptorAuth, InterceptorLogging, InterceptorRecovery}
+
+// GRPCInterceptor 是一组 unary/stream 拦截器，任一为 nil 时该类调用不经过它。
+type GRPCInterceptor struct {
+	Unary  grpc.UnaryServerInterceptor
+	Stream grpc.StreamServerInterceptor
+}
+
+// InterceptorRegistry 按名称登记 gRPC 拦截器，再按配置顺序组装为 ServerOption，
+// 使横切逻辑无需改动 main 即可插拔。
+type InterceptorRegistry struct {
+	entries map[string]GRPCInterceptor
+}
+
+// NewInterceptorRegistry 构造空的拦截器登记表。
+func NewInterceptorRegistry() *InterceptorRegistry {
+	return &InterceptorRegistry{entries: make(map[string]GRPCInterceptor)}
+}
+
+// Register 登记或覆盖名为 name 的拦截器。
+func (r *InterceptorRegistry) Register(name string, ic GRPCInterceptor) {
+	r.entries[name] = ic
+}
+
+// ServerOptions 按 names 顺序组装 ChainUnaryInterceptor/ChainStreamInterceptor，names[0] 位于最外层；
+// 名称未登记或重复时返回错误。
+func (r *InterceptorRegistry) ServerOptions(names []string) ([]grpc.ServerOption, error) {
+	unary, stream, err := r.resolve(names)
+	if err != nil {
+		return nil, err
+	}
+	var opts []grpc.ServerOption
+	if len(unary) > 0 {
+		opts = append(opts, grpc.ChainUnaryInterceptor(unary...))
+	}
+	if len(stream) > 0 {
+		opts = append(opts, grpc.ChainStreamInterceptor(stream...))
+	}
+	return opts, nil
+}
+
+// UnaryChain 按 names 顺序把 unary 拦截器串成一个（names[0] 位于最外层），供不经 grpc.Server 的入口
+// （如 /v2 网关）复用与 gRPC 相同的链路；没有 unary 拦截器时返回 nil。
+func (r *InterceptorRegistry) UnaryChain(names []string) (grpc.UnaryServerInterceptor, error) {
+	unary, _, err := r.resolve(names)
+	if err != nil || len(unary) == 0 {
+		return nil, err
+	}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		next := handler
+		for i := len(unary) - 1; i >= 0; i-- {
+			ic, inner := unary[i], next
+			next = func(ctx context.Context, req any) (any, error) {
+				return ic(ctx, req, info, inner)
+			}
+		}
+		return next(ctx, req)
+	}, nil
+}
+
+// resolve 按 names 取出已登记的拦截器；名称未登记或重复时返回错误。
+func (r *InterceptorRegistry) resolve(names []string) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor, error) {
+	var (
+		unary  []grpc.UnaryServerInterceptor
+		stream []grpc.StreamServerInterceptor
+		seen   = make(map[string]struct{}, len(names))
+	)
+	for _, name := range names {
+		ic, ok := r.entries[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown gRPC interceptor %q", name)
+		}
+		if _, dup := seen[name]; dup {
+			return nil, nil, fmt.Errorf("gRPC interceptor %q listed twice", name)
+		}
+		seen[name] = struct{}{}
+		if ic.Unary != nil {
+			unary = append(unary, ic.Unary)
+		}
+		if ic.Stream != nil {
+			stream = append(stream, ic.Stream)
+		}
+	}
+	return unary, stream, nil
+}
+
+// ParseInterceptorNames 解析逗号分隔的拦截器列表，空串返回 DefaultGRPCInterceptors，"none" 表示不启用。
+func ParseInterceptorNames(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	switch raw {
+	case "":
+		return append([]string(nil), DefaultGRPCInterceptors...)
+	case "none":
+		return nil
+	}
+	var names []string
+	for _, part := range strings.Split(raw, ",") {
+		if name := strings.TrimSpace(part); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// RecoveryInterceptor 将 handler 中的 panic 转换为 codes.Internal 并记录堆栈，避免单个请求拖垮进程。
+func RecoveryInterceptor(logger *slog.Logger) GRPCInterceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	recovered := func(ctx context.Context, method string, err *error) {
+		if p := recover(); p != nil {
+			logger.ErrorContext(ctx, "grpc handler panic", "method", method, "panic", p, "stack", string(debug.Stack()))
+			*err = status.Error(codes.Internal, "internal error")
+		}
+	}
+	return GRPCInterceptor{
+		Unary: func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+			defer recovered(ctx, info.FullMethod, &err)
+			return handler(ctx, req)
+		},
+		Stream: func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+			defer recovered(ss.Context(), info.FullMethod, &err)
+			return handler(srv, ss)
+		},
+	}
+}
+
+// LoggingInterceptor 记录每次 RPC 的方法、状态码与耗时，成功为 Debug，失败为 Warn。
+func LoggingInterceptor(logger *slog.Logger) GRPCInterceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	log := func(ctx context.Context, method string, start time.Time, err error) {
+		level := slog.LevelDebug
+		if err != nil {
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", method),
+			slog.String("code", status.Code(err).String()),
+			slog.Duration("latency", time.Since(start)),
+		}
+		if p, ok := reqctx.PrincipalFrom(ctx); ok {
+			attrs = append(attrs, slog.String("principal", p.Subject))
+		}
+		logger.LogAttrs(ctx, level, "grpc call", attrs...)
+	}
+	return GRPCInterceptor{
+		Unary: func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			start := time.Now()
+			resp, err := handler(ctx, req)
+			log(ctx, info.FullMethod, start, err)
+			return resp, err
+		},
+		Stream: func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			start := time.Now()
+			err := handler(srv, ss)
+			log(ss.Context(), info.FullMethod, start, err)
+			return err
+		},
+	}
+}
+
+// GRPCMetrics 记录 gRPC 调用次数与延迟（stream 按整条流计）。
+type GRPCMetrics struct {
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// NewGRPCMetricsWithOptions 按 opts 覆盖默认的 signer / grpc 前缀与常量标签。
+func NewGRPCMetricsWithOptions(reg prometheus.Registerer, opts metricsopts.Options) (*GRPCMetrics, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	opts = opts.WithDefaults("signer", "grpc")
+	m := &GRPCMetrics{
+		requests: prometheus.NewCounterVec(opts.Counter("requests_total",
+			"Number of gRPC calls by method and status code"), []string{"method", "code"}),
+		latency: prometheus.NewHistogramVec(opts.Histogram("latency_ms",
+			"Latency of gRPC calls in milliseconds",
+			[]float64{0.5, 1, 2, 3, 5, 7.5, 10, 20, 50, 100, 250, 1000}), []string{"method"}),
+	}
+	if err := metricsopts.Register(reg, m.requests, m.latency); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *GRPCMetrics) observe(method string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.requests.WithLabelValues(method, status.Code(err).String()).Inc()
+	m.latency.WithLabelValues(method).Observe(float64(time.Since(start).Microseconds()) / 1000)
+}
+
+// MetricsInterceptor 以 GRPCMetrics 记录调用，m 为 nil 时不记录。
+func MetricsInterceptor(m *GRPCMetrics) GRPCInterceptor {
+	return GRPCInterceptor{
+		Unary: func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			start := time.Now()
+			resp, err := handler(ctx, req)
+			m.observe(info.FullMethod, start, err)
+			return resp, err
+		},
+		Stream: func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			start := time.Now()
+			err := handler(srv, ss)
+			m.observe(info.FullMethod, start, err)
+			return err
+		},
+	}
+}
+
+// Authenticator 从 RPC 上下文（含 incoming metadata）识别调用方，失败时返回的错误不会透出给客户端。
+type Authenticator func(ctx context.Context) (reqctx.Principal, error)
+
+// AuthInterceptor 要求每次调用通过 authn，成功后将 Principal 与租户写入上下文，失败返回 codes.Unauthenticated；
+// grpc.health.v1 探针不经过认证。
+func AuthInterceptor(authn Authenticator) GRPCInterceptor {
+	authenticate := func(ctx context.Context) (context.Context, error) {
+		id, err := authn(ctx)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+		}
+		return withAuthenticated(ctx, id), nil
+	}
+	return GRPCInterceptor{
+		Unary: func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if isHealthMethod(info.FullMethod) {
+				return handler(ctx, req)
+			}
+			ctx, err := authenticate(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		},
+		Stream: func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if isHealthMethod(info.FullMethod) {
+				return handler(srv, ss)
+			}
+			ctx, err := authenticate(ss.Context())
+			if err != nil {
+				return err
+			}
+			return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		},
+	}
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
+
+// MetadataAuthenticator 以 verifier 校验 authorization: Bearer <token> 或 x-api-key 元数据。
+func MetadataAuthenticator(verifier TokenVerifier) Authenticator {
+	return func(ctx context.Context) (reqctx.Principal, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		token := bearerToken(firstMetadata(md, "authorization"), firstMetadata(md, apiKeyMetadataKey))
+		if token == "" {
+			return reqctx.Principal{}, ErrUnauthenticated
+		}
+		return verifier.Verify(ctx, token)
+	}
+}
+
+const apiKeyMetadataKey = "x-api-key"
+
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// StaticTokenAuthenticator 校验 authorization: Bearer <token> 元数据，tokens 为 token 到调用方 subject 的映射。
+func StaticTokenAuthenticator(tokens map[string]string) Authenticator {
+	verifier, err := NewStaticTokenVerifier(tokens)
+	if err != nil {
+		return func(context.Context) (reqctx.Principal, error) { return reqctx.Principal{}, err }
+	}
+	return MetadataAuthenticator(verifier)
+}
+
+// NewStaticTokenVerifier 将 ParseAuthTokens 的结果转换为 APIKeyVerifier。
+func NewStaticTokenVerifier(tokens map[string]string) (*APIKeyVerifier, error) {
+	keys := make([]APIKey, 0, len(tokens))
+	for token, subject := range tokens {
+		keys = append(keys, APIKey{Subject: subject, Key: token})
+	}
+	return NewAPIKeyVerifier(keys)
+}
+
+// ParseAuthTokens 解析 subject:token[,subject:token] 形式的静态凭证。
+func ParseAuthTokens(raw string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		subject, token, ok := strings.Cut(part, ":")
+		if !ok || subject == "" || token == "" {
+			return nil, fmt.Errorf("invalid auth token entry %q, want subject:token", part)
+		}
+		if _, dup := tokens[token]; dup {
+			return nil, fmt.Errorf("auth token for %q duplicates another subject", subject)
+		}
+		tokens[token] = subject
+	}
+	return tokens, nil
+}
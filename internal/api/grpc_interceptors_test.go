@@ -0,0 +1,115 @@
+package signerapi
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dialIntercepted 以 names 指定的拦截器启动 signer gRPC 服务并返回客户端。
+func dialIntercepted(t *testing.T, registry *InterceptorRegistry, names []string, backend Backend) signerv1.SignerServiceClient {
+	t.Helper()
+	opts, err := registry.ServerOptions(names)
+	require.NoError(t, err)
+	lis := bufconn.Listen(testBufSize)
+	srv := grpc.NewServer(opts...)
+	signerv1.RegisterSignerServiceServer(srv, NewGRPCServer(backend, nil))
+	go func() { _ = srv.Serve(lis) }()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+		srv.Stop()
+	})
+	return signerv1.NewSignerServiceClient(conn)
+}
+
+func TestInterceptorChainRecoveryAuthMetrics(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	reg := prometheus.NewRegistry()
+	metrics, err := NewGRPCMetricsWithOptions(reg, metricsopts.Options{})
+	require.NoError(t, err)
+	registry := NewInterceptorRegistry()
+	registry.Register(InterceptorRecovery, RecoveryInterceptor(logger))
+	registry.Register(InterceptorLogging, LoggingInterceptor(logger))
+	registry.Register(InterceptorMetrics, MetricsInterceptor(metrics))
+	registry.Register(InterceptorAuth, AuthInterceptor(StaticTokenAuthenticator(map[string]string{"s3cret": "svc-a"})))
+
+	var principal string
+	client := dialIntercepted(t, registry, ParseInterceptorNames("metrics,auth,logging,recovery"), &stubBackend{
+		signFn: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			if req.GetKeyId() == "boom" {
+				panic("backend bug")
+			}
+			p, _ := reqctx.PrincipalFrom(ctx)
+			principal = p.Subject
+			return &signerv1.SignResponse{}, nil
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	digest := repeatBytes(0x01, 32)
+
+	_, err = client.Sign(ctx, &signerv1.SignRequest{KeyId: "k1", Digest: digest})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	authed := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer s3cret")
+	_, err = client.Sign(authed, &signerv1.SignRequest{KeyId: "k1", Digest: digest})
+	require.NoError(t, err)
+	require.Equal(t, "svc-a", principal)
+
+	_, err = client.Sign(authed, &signerv1.SignRequest{KeyId: "boom", Digest: digest})
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.Contains(t, logs.String(), `"msg":"grpc handler panic"`)
+
+	stream, err := client.SignStream(ctx)
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	const method = "/signer.v1.SignerService/Sign"
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues(method, "Unauthenticated")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues(method, "OK")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues(method, "Internal")))
+	require.Contains(t, logs.String(), `"principal":"svc-a"`)
+}
+
+func TestInterceptorRegistryRejectsUnknownNames(t *testing.T) {
+	registry := NewInterceptorRegistry()
+	registry.Register(InterceptorRecovery, RecoveryInterceptor(nil))
+	_, err := registry.ServerOptions([]string{InterceptorRecovery, InterceptorAuth})
+	require.ErrorContains(t, err, `"auth"`)
+	_, err = registry.ServerOptions([]string{InterceptorRecovery, InterceptorRecovery})
+	require.Error(t, err)
+	opts, err := registry.ServerOptions(ParseInterceptorNames("none"))
+	require.NoError(t, err)
+	require.Empty(t, opts)
+	require.Equal(t, DefaultGRPCInterceptors, ParseInterceptorNames(" "))
+
+	tokens, err := ParseAuthTokens("svc-a:t1, svc-b:t2")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"t1": "svc-a", "t2": "svc-b"}, tokens)
+	for _, raw := range []string{"svc-a", "svc-a:", "svc-a:t1,svc-b:t1"} {
+		_, err = ParseAuthTokens(raw)
+		require.Error(t, err, raw)
+	}
+}
@@ -2,6 +2,7 @@ package signerapi
 
 import (
 	"context"
+	"encoding/hex"
 	"io"
 	"testing"
 	"time"
@@ -21,6 +22,52 @@ func TestGRPCSignValidatesDigest(t *testing.T) {
 	}
 }
 
+func TestGRPCSignNormalizesDerivationPath(t *testing.T) {
+	var got string
+	server := NewGRPCServer(&stubBackend{
+		signFn: func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			got = req.GetDerivationPath()
+			return &signerv1.SignResponse{Signature: []byte{0x01}}, nil
+		},
+	}, nil)
+	if _, err := server.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k1", Digest: repeatBytes(0x01, 32), DerivationPath: "m/44H/0h/1"}); err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+	if got != "m/44'/0'/1" {
+		t.Fatalf("path=%q", got)
+	}
+	_, err := server.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k1", Digest: repeatBytes(0x01, 32), Curve: "ed25519", DerivationPath: "m/44'/1"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected invalid argument, got %v", status.Code(err))
+	}
+}
+
+func TestGRPCSignRawMessage(t *testing.T) {
+	var got []byte
+	server := NewGRPCServer(&stubBackend{
+		signFn: func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			got = req.GetDigest()
+			return &signerv1.SignResponse{Signature: []byte{0x01}}, nil
+		},
+	}, nil)
+	req := &signerv1.SignRequest{KeyId: "k1", PayloadType: "rawMessage", Message: []byte("hello"), HashAlgorithm: "sha256"}
+	if _, err := server.Sign(context.Background(), req); err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+	if hex.EncodeToString(got) != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Fatalf("digest=%x", got)
+	}
+	for _, bad := range []*signerv1.SignRequest{
+		{KeyId: "k1", PayloadType: "rawMessage", Message: []byte("hello")},
+		{KeyId: "k1", Digest: repeatBytes(0x01, 32), Message: []byte("hello")},
+		{KeyId: "k1", PayloadType: "rawMessage", Message: make([]byte, 64<<10+1), HashAlgorithm: "sha256"},
+	} {
+		if _, err := server.Sign(context.Background(), bad); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected invalid argument, got %v", status.Code(err))
+		}
+	}
+}
+
 func TestGRPCSignInvalidKey(t *testing.T) {
 	server := NewGRPCServer(&stubBackend{
 		signFn: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
@@ -53,8 +100,15 @@ func TestGRPCSignStream(t *testing.T) {
 	if len(stream.sent) != 2 {
 		t.Fatalf("expected 2 responses, got %d", len(stream.sent))
 	}
-	if !equalBytes(stream.sent[0].GetSignature(), repeatBytes(0x01, 32)) {
-		t.Fatalf("unexpected response payload")
+	// 流内请求并发处理，响应按回显的 key_id 关联。
+	for _, resp := range stream.sent {
+		want := repeatBytes(0x01, 32)
+		if resp.GetKeyId() == "k2" {
+			want = repeatBytes(0x02, 32)
+		}
+		if !equalBytes(resp.GetSignature(), want) {
+			t.Fatalf("unexpected response payload for %s", resp.GetKeyId())
+		}
 	}
 }
 
@@ -132,3 +186,59 @@ func repeatBytes(b byte, n int) []byte {
 	}
 	return buf
 }
+
+func TestGRPCCreateRejectsUnknownCurve(t *testing.T) {
+	server := NewGRPCServer(&stubBackend{}, nil)
+	_, err := server.Create(context.Background(), &signerv1.CreateRequest{Curve: "ed448"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected invalid argument, got %v", status.Code(err))
+	}
+	if _, err := server.Create(context.Background(), &signerv1.CreateRequest{Curve: "SECP256K1"}); err != nil {
+		t.Fatalf("known curve rejected: %v", err)
+	}
+}
+
+func TestGRPCCreateDerivesAddresses(t *testing.T) {
+	pub, _ := hex.DecodeString("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	server := NewGRPCServer(&stubBackend{
+		createFn: func(context.Context, *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+			return &signerv1.CreateResponse{KeyId: "k1", PublicKey: pub}, nil
+		},
+	}, nil)
+	resp, err := server.Create(context.Background(), &signerv1.CreateRequest{AddressFormats: []string{"tron", "eth"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	addrs := resp.GetAddresses()
+	if len(addrs) != 2 || addrs[0].GetFormat() != "tron" || addrs[1].GetAddress() != "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf" {
+		t.Fatalf("unexpected addresses %v", addrs)
+	}
+	_, err = server.Create(context.Background(), &signerv1.CreateRequest{AddressFormats: []string{"solana"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected invalid argument, got %v", status.Code(err))
+	}
+}
+
+func TestGRPCSignNormalizesCurve(t *testing.T) {
+	var got string
+	server := NewGRPCServer(&stubBackend{
+		signFn: func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			got = req.GetCurve()
+			return &signerv1.SignResponse{}, nil
+		},
+	}, nil)
+	if _, err := server.Sign(context.Background(), &signerv1.SignRequest{Curve: "ED25519", Digest: repeatBytes(0x01, 32)}); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if got != "ed25519" {
+		t.Fatalf("expected normalized curve, got %q", got)
+	}
+	for _, req := range []*signerv1.SignRequest{
+		{Curve: "ed448", Digest: repeatBytes(0x01, 32)},
+		{Curve: "ed25519", Digest: repeatBytes(0x01, 20)},
+	} {
+		if _, err := server.Sign(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("curve %q: expected invalid argument, got %v", req.GetCurve(), status.Code(err))
+		}
+	}
+}
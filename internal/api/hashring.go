@@ -0,0 +1,92 @@
+package signerapi
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// DefaultVirtualNodes 为每个 Enclave 在 hash 环上放置的默认虚拟节点数。
+const DefaultVirtualNodes = 160
+
+type ringPoint struct {
+	hash   uint64
+	target int
+}
+
+// hashRing 是带虚拟节点的一致性 hash 环；增删一个 Enclave 只会迁移约 1/N 的 key。
+// 构造后只读，可并发使用。
+type hashRing struct {
+	points  []ringPoint
+	targets int
+}
+
+// newHashRing 为每个目标放置 vnodes×weight 个虚拟节点；weights 为 nil 或元素 <=0 时按 1 计。
+// 节点数只取决于自身权重，调整某个目标的权重不会迁移其他目标之间的 key。
+func newHashRing(targetIDs []string, vnodes int, weights []int) *hashRing {
+	if vnodes <= 0 {
+		vnodes = DefaultVirtualNodes
+	}
+	var points []ringPoint
+	for i, id := range targetIDs {
+		n := vnodes
+		if i < len(weights) && weights[i] > 1 {
+			n *= weights[i]
+		}
+		for v := 0; v < n; v++ {
+			points = append(points, ringPoint{hash: ringHash(id + "#" + strconv.Itoa(v)), target: i})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].hash != points[j].hash {
+			return points[i].hash < points[j].hash
+		}
+		return points[i].target < points[j].target
+	})
+	return &hashRing{points: points, targets: len(targetIDs)}
+}
+
+// walk 从 key 所在位置顺时针遍历，按首次出现的顺序对每个不同的目标调用 fn，fn 返回 true 时停止。
+func (r *hashRing) walk(key string, fn func(target int) bool) {
+	if len(r.points) == 0 {
+		return
+	}
+	h := ringHash(key)
+	start := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	seen := make([]bool, r.targets)
+	visited := 0
+	for i := 0; i < len(r.points) && visited < r.targets; i++ {
+		p := r.points[(start+i)%len(r.points)]
+		if seen[p.target] {
+			continue
+		}
+		seen[p.target] = true
+		visited++
+		if fn(p.target) {
+			return
+		}
+	}
+}
+
+// lookup 返回 key 的首选目标。
+func (r *hashRing) lookup(key string) int {
+	target := 0
+	r.walk(key, func(t int) bool {
+		target = t
+		return true
+	})
+	return target
+}
+
+// ringHash 对 FNV-64a 结果再做一次 fmix64 混淆，使 "id#0"、"id#1" 这类相近输入在环上均匀分布。
+func ringHash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,53 @@
+package signerapi
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashRingBalanceAndRemap(t *testing.T) {
+	const keys = 20000
+	before := newHashRing([]string{"e1", "e2", "e3"}, DefaultVirtualNodes, nil)
+	after := newHashRing([]string{"e1", "e2", "e3", "e4"}, DefaultVirtualNodes, nil)
+
+	counts := make([]int, 4)
+	moved := 0
+	for i := 0; i < keys; i++ {
+		key := "key-" + strconv.Itoa(i)
+		old, cur := before.lookup(key), after.lookup(key)
+		counts[cur]++
+		if old != cur {
+			moved++
+			// 只会迁往新增的 Enclave，其余 key 保持原路由。
+			require.Equal(t, 3, cur, key)
+		}
+	}
+	// 新增第 4 个 Enclave 约迁移 1/4 的 key。
+	require.InDelta(t, 0.25, float64(moved)/keys, 0.05)
+	for i, c := range counts {
+		require.InDelta(t, keys/4, c, keys/4*0.2, "target %d", i)
+	}
+}
+
+func TestHashRingWalkVisitsEachTargetOnce(t *testing.T) {
+	ring := newHashRing([]string{"a", "b", "c"}, 8, nil)
+	var order []int
+	ring.walk("hot-key", func(target int) bool {
+		order = append(order, target)
+		return false
+	})
+	require.ElementsMatch(t, []int{0, 1, 2}, order)
+	require.Equal(t, ring.lookup("hot-key"), order[0])
+}
+
+func TestHashRingWeights(t *testing.T) {
+	const keys = 20000
+	ring := newHashRing([]string{"big", "small"}, DefaultVirtualNodes, []int{4, 1})
+	counts := make([]int, 2)
+	for i := 0; i < keys; i++ {
+		counts[ring.lookup("key-"+strconv.Itoa(i))]++
+	}
+	require.InDelta(t, 0.8, float64(counts[0])/keys, 0.05)
+}
@@ -0,0 +1,251 @@
+package signerapi
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/audit"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultHedgeQuantile    = 0.95
+	defaultHedgeMinSamples  = 20
+	defaultHedgeMaxInFlight = 64
+	// hedgeWindowSize 控制估算对冲延迟的最近成功样本数。
+	hedgeWindowSize = 256
+)
+
+// AlternateSelector 由能给出 Sign 备选 Enclave 的 selector 实现；备选目标须持有同一 key 的密文副本。
+type AlternateSelector interface {
+	SelectAlternate(ctx context.Context, req *signerv1.SignRequest, primary string) (string, bool)
+}
+
+// HedgeConfig 配置 Sign 对冲：主目标超过 Delay 仍未返回时向备选 Enclave 发出第二次尝试。
+type HedgeConfig struct {
+	// Enabled 为 false 时不对冲（默认）；仅在 key 密文已复制到备选 Enclave 时开启。
+	Enabled bool
+	// Delay 为固定对冲延迟；<=0 时按最近成功 Sign 延迟的 Quantile 分位自适应。
+	Delay time.Duration
+	// Quantile 为自适应延迟使用的分位，默认 0.95。
+	Quantile float64
+	// MinDelay 为自适应延迟的下限。
+	MinDelay time.Duration
+	// MinSamples 为自适应模式下开始对冲所需的最少样本数，默认 20。
+	MinSamples int
+	// MaxInFlight 限制同时进行的对冲尝试数，避免 Enclave 整体变慢时负载翻倍，默认 64。
+	MaxInFlight int
+	Registerer  prometheus.Registerer
+	// MetricsOptions 覆盖 hedged_total / hedge_wins_total 的默认 signer / backend 前缀与常量标签。
+	MetricsOptions metricsopts.Options
+}
+
+// Hedger 决定 Sign 何时以及是否对冲。nil 表示关闭，所有方法均可安全调用。
+type Hedger struct {
+	delay       time.Duration
+	quantile    float64
+	minDelay    time.Duration
+	minSamples  int
+	maxInFlight int64
+	inFlight    atomic.Int64
+
+	hedged prometheus.Counter
+	wins   prometheus.Counter
+
+	mu      sync.Mutex
+	samples [hedgeWindowSize]time.Duration
+	next    int
+	count   int
+}
+
+// NewHedger 构造 Hedger，未启用时返回 nil。
+func NewHedger(cfg HedgeConfig) *Hedger {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Quantile <= 0 || cfg.Quantile > 1 {
+		cfg.Quantile = defaultHedgeQuantile
+	}
+	if cfg.MinSamples <= 0 {
+		cfg.MinSamples = defaultHedgeMinSamples
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = defaultHedgeMaxInFlight
+	}
+	reg := cfg.Registerer
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	opts := cfg.MetricsOptions.WithDefaults("signer", "backend")
+	h := &Hedger{
+		delay:       cfg.Delay,
+		quantile:    cfg.Quantile,
+		minDelay:    cfg.MinDelay,
+		minSamples:  cfg.MinSamples,
+		maxInFlight: int64(cfg.MaxInFlight),
+		hedged: prometheus.NewCounter(opts.Counter("hedged_total",
+			"Number of sign requests that issued a hedged attempt to an alternate enclave")),
+		wins: prometheus.NewCounter(opts.Counter("hedge_wins_total",
+			"Number of hedged sign requests answered first by the alternate enclave")),
+	}
+	metricsopts.MustRegister(reg, h.hedged, h.wins)
+	return h
+}
+
+// Delay 返回当前对冲延迟；自适应模式样本不足时 ok 为 false。
+func (h *Hedger) Delay() (time.Duration, bool) {
+	if h == nil {
+		return 0, false
+	}
+	if h.delay > 0 {
+		return h.delay, true
+	}
+	h.mu.Lock()
+	n := h.count
+	buf := make([]time.Duration, n)
+	copy(buf, h.samples[:n])
+	h.mu.Unlock()
+	if n < h.minSamples {
+		return 0, false
+	}
+	sort.Slice(buf, func(i, j int) bool { return buf[i] < buf[j] })
+	idx := int(h.quantile*float64(n)+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= n {
+		idx = n - 1
+	}
+	d := buf[idx]
+	if d < h.minDelay {
+		d = h.minDelay
+	}
+	return d, true
+}
+
+// observe 记录一次成功 Sign 的耗时。
+func (h *Hedger) observe(d time.Duration) {
+	if h == nil || d <= 0 {
+		return
+	}
+	h.mu.Lock()
+	h.samples[h.next] = d
+	h.next = (h.next + 1) % len(h.samples)
+	if h.count < len(h.samples) {
+		h.count++
+	}
+	h.mu.Unlock()
+}
+
+// alternate 返回可对冲的备选目标；关闭、selector 不支持或没有备选时 ok 为 false。
+func (h *Hedger) alternate(ctx context.Context, selector TargetSelector, req *signerv1.SignRequest, primary string) (string, bool) {
+	if h == nil {
+		return "", false
+	}
+	alt, ok := selector.(AlternateSelector)
+	if !ok {
+		return "", false
+	}
+	target, ok := alt.SelectAlternate(ctx, req, primary)
+	return target, ok && target != "" && target != primary
+}
+
+func (h *Hedger) acquire() bool {
+	if h.inFlight.Add(1) > h.maxInFlight {
+		h.inFlight.Add(-1)
+		return false
+	}
+	return true
+}
+
+func (h *Hedger) release() { h.inFlight.Add(-1) }
+
+type hedgeResult struct {
+	target string
+	resp   *signerv1.SignResponse
+	err    error
+}
+
+// hedgedSign 先向 primary 发起签名，超过对冲延迟仍未返回时再向 alternate 发起一次，
+// 取先成功者并取消另一方。primary 在对冲前失败时直接返回其错误，不做故障转移。
+func (b *EnclaveBackend) hedgedSign(ctx context.Context, primary, alternate string, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+	delay, ok := b.hedge.Delay()
+	if !ok {
+		return b.timedSign(ctx, primary, req)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// 容量为 2：败者在取消后写入也不会阻塞。
+	results := make(chan hedgeResult, 2)
+	attempt := func(target string) {
+		resp, err := b.timedSign(ctx, target, req)
+		results <- hedgeResult{target: target, resp: resp, err: err}
+	}
+	go attempt(primary)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	hedgeC := timer.C
+	pending := 1
+	var primaryErr error
+	for {
+		select {
+		case <-hedgeC:
+			hedgeC = nil
+			if !b.hedge.acquire() {
+				continue
+			}
+			if b.budget.Check(ctx, alternate) != nil {
+				b.hedge.release()
+				continue
+			}
+			b.hedge.hedged.Inc()
+			pending++
+			go func() {
+				defer b.hedge.release()
+				attempt(alternate)
+			}()
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				if r.target != primary {
+					b.hedge.wins.Inc()
+					audit.RecordTarget(ctx, r.target)
+				}
+				return r.resp, nil
+			}
+			if r.target == primary {
+				if hedgeC != nil {
+					return nil, r.err
+				}
+				primaryErr = r.err
+			}
+			if pending == 0 {
+				if primaryErr != nil {
+					return nil, primaryErr
+				}
+				return nil, r.err
+			}
+		}
+	}
+}
+
+// timedSign 执行 signOnce 并把成功耗时计入对冲延迟样本。
+func (b *EnclaveBackend) timedSign(ctx context.Context, target string, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+	start := time.Now()
+	resp, err := b.signOnce(ctx, target, req)
+	if err == nil {
+		b.hedge.observe(time.Since(start))
+	}
+	return resp, err
+}
+
+// WithHedging 为 Sign 启用对冲；nil 表示关闭。selector 须实现 AlternateSelector 才会生效。
+func WithHedging(h *Hedger) EnclaveBackendOption {
+	return func(b *EnclaveBackend) { b.hedge = h }
+}
@@ -0,0 +1,128 @@
+package signerapi
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newMultiTargetPool 为每个 Enclave ID 起一个独立的 bufconn 服务端，endpoint 即 ID。
+func newMultiTargetPool(t testing.TB, enclaves map[string]signerv1.SignerServiceServer) *enclaveclient.Pool {
+	t.Helper()
+	listeners := make(map[string]*bufconn.Listener, len(enclaves))
+	for id, enclave := range enclaves {
+		lis := bufconn.Listen(testBufSize)
+		srv := grpc.NewServer()
+		signerv1.RegisterSignerServiceServer(srv, enclave)
+		go func() { _ = srv.Serve(lis) }()
+		t.Cleanup(srv.Stop)
+		listeners[id] = lis
+	}
+	cfg := enclaveclient.DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 2
+	cfg.HealthCheckInterval = 200 * time.Millisecond
+	pool, err := enclaveclient.NewPool(cfg,
+		enclaveclient.WithRegisterer(prometheus.NewRegistry()),
+		enclaveclient.WithDialer(func(ctx context.Context, target enclaveclient.Target, _ enclaveclient.Config) (*grpc.ClientConn, error) {
+			lis := listeners[target.Endpoint]
+			return grpc.DialContext(ctx, target.Endpoint,
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			)
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	for id := range enclaves {
+		pool.RegisterTarget(enclaveclient.Target{ID: id, Endpoint: id})
+	}
+	return pool
+}
+
+// stallingServer 的 SignStream 一直阻塞到调用方取消。
+type stallingServer struct {
+	streamingServer
+	cancelled chan struct{}
+	once      sync.Once
+}
+
+func (s *stallingServer) SignStream(stream signerv1.SignerService_SignStreamServer) error {
+	<-stream.Context().Done()
+	s.once.Do(func() { close(s.cancelled) })
+	return stream.Context().Err()
+}
+
+func TestEnclaveBackendHedgesSlowPrimary(t *testing.T) {
+	selector, err := NewStickySelector([]string{"e1", "e2"})
+	require.NoError(t, err)
+	req := &signerv1.SignRequest{KeyId: "hot-key", Digest: []byte("payload")}
+	primary, err := selector.SelectForSign(context.Background(), req)
+	require.NoError(t, err)
+	alternate, ok := selector.SelectAlternate(context.Background(), req, primary)
+	require.True(t, ok)
+	require.NotEqual(t, primary, alternate)
+
+	slow := &stallingServer{cancelled: make(chan struct{})}
+	pool := newMultiTargetPool(t, map[string]signerv1.SignerServiceServer{primary: slow, alternate: streamingServer{}})
+	hedger := NewHedger(HedgeConfig{Enabled: true, Delay: 20 * time.Millisecond, Registerer: prometheus.NewRegistry()})
+	backend, err := NewEnclaveBackend(pool, selector, WithHedging(hedger))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	resp, err := backend.Sign(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []byte("payload"), resp.GetSignature())
+	require.Equal(t, 1.0, testutil.ToFloat64(hedger.hedged))
+	require.Equal(t, 1.0, testutil.ToFloat64(hedger.wins))
+	// 败者被取消，不会一直占用 Enclave。
+	select {
+	case <-slow.cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("primary attempt was not cancelled")
+	}
+
+	// 固定路由与 dry-run 不对冲。
+	_, err = backend.Sign(WithPinnedTarget(ctx, alternate), req)
+	require.NoError(t, err)
+	// dry-run 停在慢主节点上直到超时，不向备选节点对冲。
+	dryCtx, dryCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer dryCancel()
+	_, err = backend.Sign(dryCtx, &signerv1.SignRequest{KeyId: "hot-key", DryRun: true})
+	require.Error(t, err)
+	require.Equal(t, 1.0, testutil.ToFloat64(hedger.hedged))
+}
+
+func TestHedgerAdaptiveDelay(t *testing.T) {
+	var disabled *Hedger
+	_, ok := disabled.Delay()
+	require.False(t, ok)
+	require.Nil(t, NewHedger(HedgeConfig{Delay: time.Second}))
+
+	hedger := NewHedger(HedgeConfig{Enabled: true, MinSamples: 20, MinDelay: 5 * time.Millisecond, Registerer: prometheus.NewRegistry()})
+	for i := 1; i <= 19; i++ {
+		hedger.observe(time.Duration(i) * time.Millisecond)
+	}
+	_, ok = hedger.Delay()
+	require.False(t, ok)
+	hedger.observe(20 * time.Millisecond)
+	delay, ok := hedger.Delay()
+	require.True(t, ok)
+	require.Equal(t, 19*time.Millisecond, delay)
+
+	fast := NewHedger(HedgeConfig{Enabled: true, MinSamples: 1, MinDelay: 5 * time.Millisecond, Registerer: prometheus.NewRegistry()})
+	fast.observe(time.Millisecond)
+	delay, _ = fast.Delay()
+	require.Equal(t, 5*time.Millisecond, delay)
+}
@@ -4,34 +4,200 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
-	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
 	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/aegis-sign/wallet/pkg/curves"
 	"github.com/aegis-sign/wallet/pkg/validator"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
 )
 
-// HTTPHandler 实现 `/create` `/sign` HTTP/JSON 接口。
+// HTTPHandler 实现 `/create` `/keys/import` `/sign` `/verify` HTTP/JSON 接口。
 type HTTPHandler struct {
 	backend Backend
 	unlock  *UnlockResponder
+	hints   *RetryHintProvider
+	logger  *slog.Logger
+	metrics *HTTPMetrics
+	lookup  KeyLookup
+	batch   BatchConfig
+	body    BodyLimits
+
+	compression *CompressionConfig
+	legacy      LegacyRoutesConfig
+	gateway     signerv1.SignerServiceServer
+	// gatewayInterceptor 为网关调用（/v2 及 /v1 中对应 SignerService 方法的路由）复用的 gRPC unary 拦截器链。
+	gatewayInterceptor grpc.UnaryServerInterceptor
+
+	websocket    signerv1.SignerServiceServer
+	websocketCfg WebSocketConfig
+
+	watcher      *UnlockWatcher
+	unlockStatus UnlockStatusSource
+}
+
+// HTTPOption 定制 HTTPHandler。
+type HTTPOption func(*HTTPHandler)
+
+// WithUnlockResponder 设置 UNLOCK_REQUIRED 时的异步解锁入口，未设置时只返回默认退避。
+func WithUnlockResponder(unlock *UnlockResponder) HTTPOption {
+	return func(h *HTTPHandler) {
+		h.unlock = unlock
+	}
+}
+
+// WithRetryHints 设置 RETRY_LATER 的动态退避提示来源。
+func WithRetryHints(p *RetryHintProvider) HTTPOption {
+	return func(h *HTTPHandler) {
+		h.hints = p
+	}
+}
+
+// WithLogger 设置记录内部错误的 logger，默认 slog.Default()。
+func WithLogger(logger *slog.Logger) HTTPOption {
+	return func(h *HTTPHandler) {
+		if logger != nil {
+			h.logger = logger
+		}
+	}
+}
+
+// WithMetrics 按路由与状态码统计 HTTP 响应。
+func WithMetrics(m *HTTPMetrics) HTTPOption {
+	return func(h *HTTPHandler) {
+		h.metrics = m
+	}
+}
+
+// WithBatchConfig 设置 /sign/batch 与 /sign/multi 的条数上限与并发度。
+func WithBatchConfig(cfg BatchConfig) HTTPOption {
+	return func(h *HTTPHandler) {
+		h.batch = cfg
+	}
 }
 
 // NewHTTPHandler 构造 HTTP handler。
-func NewHTTPHandler(backend Backend, unlock *UnlockResponder) *HTTPHandler {
+func NewHTTPHandler(backend Backend, opts ...HTTPOption) *HTTPHandler {
 	if backend == nil {
 		panic("signer backend is required")
 	}
-	return &HTTPHandler{backend: backend, unlock: unlock}
+	h := &HTTPHandler{backend: backend, logger: slog.Default()}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(h)
+		}
+	}
+	return h
+}
+
+// NewHTTPHandlerWithUnlock 保留旧的两参数构造方式。
+//
+// Deprecated: 使用 NewHTTPHandler(backend, WithUnlockResponder(unlock))。
+func NewHTTPHandlerWithUnlock(backend Backend, unlock *UnlockResponder) *HTTPHandler {
+	return NewHTTPHandler(backend, WithUnlockResponder(unlock))
+}
+
+// SetRetryHints 设置 RETRY_LATER 的动态退避提示来源，nil 表示沿用错误自带提示。
+func (h *HTTPHandler) SetRetryHints(p *RetryHintProvider) {
+	h.hints = p
+}
+
+// Register 将业务路由挂载到 /v1 下，并按 LegacyRoutesConfig 保留带弃用头的无前缀旧路径；
+// 设置 WithGateway 时同时挂载 /v2，设置 WithWebSocket 时挂载 /ws/sign。
+func (h *HTTPHandler) Register(mux Router) {
+	h.registerV1(VersionedRouter(mux, APIVersionV1))
+	if !h.legacy.Disabled {
+		h.registerV1(DeprecatedRouter(mux, APIVersionV1, h.legacy.Sunset))
+	}
+	if h.gateway != nil {
+		h.registerV2(VersionedRouter(mux, APIVersionV2))
+	}
+	if h.websocket != nil {
+		mux.HandleFunc("/ws/sign", h.handleWebSocketSign)
+	}
+}
+
+// registerV1 注册 v1 业务路由：与 SignerService 方法对应的路由经网关调用同一实现，其余由 HTTP 层直接处理。
+func (h *HTTPHandler) registerV1(mux Router) {
+	mux.HandleFunc("/create", h.metrics.instrument("create", h.compress(h.handleCreate)))
+	mux.HandleFunc("/keys/import", h.metrics.instrument("import", h.compress(h.handleImport)))
+	mux.HandleFunc("/keys/", h.metrics.instrument("key", h.compress(h.handleKey)))
+	mux.HandleFunc("/sign", h.metrics.instrument("sign", h.compress(h.handleSign)))
+	mux.HandleFunc("/sign/batch", h.metrics.instrument("sign_batch", h.compress(h.handleSignBatch)))
+	mux.HandleFunc("/sign/multi", h.metrics.instrument("sign_multi", h.compress(h.handleSignMulti)))
+	mux.HandleFunc("/sign/tx", h.metrics.instrument("sign_tx", h.compress(h.handleSignTx)))
+	mux.HandleFunc("/sign/typed-data", h.metrics.instrument("sign_typed_data", h.compress(h.handleSignTypedData)))
+	mux.HandleFunc("/verify", h.metrics.instrument("verify", h.compress(h.handleVerify)))
+	if h.unlockStatus != nil {
+		mux.HandleFunc("/unlock/", h.metrics.instrument("unlock_status", h.compress(h.handleUnlockStatus)))
+	}
+	if h.watcher != nil {
+		// SSE 长连接逐条 flush，不经压缩中间件。
+		mux.HandleFunc("/unlock/events", h.metrics.instrument("unlock_events", h.handleUnlockEvents))
+	}
+}
+
+// HTTPMetrics 记录 HTTP 接口的响应数。
+type HTTPMetrics struct {
+	responses *prometheus.CounterVec
+}
+
+// NewHTTPMetrics 在注册器中注册 HTTP 指标，reg 为空时使用全局注册器。
+func NewHTTPMetrics(reg prometheus.Registerer) *HTTPMetrics {
+	m, err := NewHTTPMetricsWithOptions(reg, metricsopts.Options{})
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// NewHTTPMetricsWithOptions 按 opts 覆盖默认的 signer / http 前缀与常量标签。
+func NewHTTPMetricsWithOptions(reg prometheus.Registerer, opts metricsopts.Options) (*HTTPMetrics, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	opts = opts.WithDefaults("signer", "http")
+	m := &HTTPMetrics{
+		responses: prometheus.NewCounterVec(opts.Counter("responses_total",
+			"Number of HTTP responses by route and status code"), []string{"route", "status"}),
+	}
+	if err := metricsopts.Register(reg, m.responses); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *HTTPMetrics) instrument(route string, next http.HandlerFunc) http.HandlerFunc {
+	if m == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		m.responses.WithLabelValues(route, strconv.Itoa(rec.status)).Inc()
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
 }
 
-// Register 将 handler 注册到 mux。
-func (h *HTTPHandler) Register(mux *http.ServeMux) {
-	mux.HandleFunc("/create", h.handleCreate)
-	mux.HandleFunc("/sign", h.handleSign)
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap 供 http.ResponseController 取得底层 writer，流式响应依赖其 Flush。
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
 }
 
 type auditHeaders struct {
@@ -40,7 +206,15 @@ type auditHeaders struct {
 }
 
 type createRequestBody struct {
+	Curve          string        `json:"curve"`
+	AddressFormats []string      `json:"addressFormats,omitempty"`
+	AuditHeaders   *auditHeaders `json:"auditHeaders"`
+}
+
+type importRequestBody struct {
+	WrappedKey   string        `json:"wrappedKey"`
 	Curve        string        `json:"curve"`
+	ImportToken  string        `json:"importToken"`
 	AuditHeaders *auditHeaders `json:"auditHeaders"`
 }
 
@@ -48,18 +222,29 @@ type createResponseBody struct {
 	KeyID     string `json:"keyId"`
 	PublicKey string `json:"publicKey"`
 	Address   string `json:"address,omitempty"`
+	// Addresses 以格式名为键，仅在 Create 请求给出 addressFormats 时返回。
+	Addresses map[string]string `json:"addresses,omitempty"`
 }
 
 type signRequestBody struct {
-	KeyID        string        `json:"keyId"`
-	Digest       string        `json:"digest"`
-	Encoding     string        `json:"encoding"`
-	AuditHeaders *auditHeaders `json:"auditHeaders"`
+	KeyID    string `json:"keyId"`
+	Digest   string `json:"digest"`
+	Encoding string `json:"encoding"`
+	Curve    string `json:"curve,omitempty"`
+	DryRun   bool   `json:"dryRun,omitempty"`
+	// DerivationPath 为 BIP-32/SLIP-0010 路径（如 m/44'/60'/0'/0/5），以 keyId 为主 key 派生子 key 签名。
+	DerivationPath string `json:"derivationPath,omitempty"`
+	// PayloadType 为 rawMessage 时 message 按 encoding 解码后由服务端以 hashAlgorithm 哈希，digest 须为空。
+	PayloadType   string        `json:"payloadType,omitempty"`
+	Message       string        `json:"message,omitempty"`
+	HashAlgorithm string        `json:"hashAlgorithm,omitempty"`
+	AuditHeaders  *auditHeaders `json:"auditHeaders"`
 }
 
 type signResponseBody struct {
 	Signature string  `json:"signature"`
 	RecID     *uint32 `json:"recId,omitempty"`
+	DryRun    bool    `json:"dryRun,omitempty"`
 }
 
 type errorResponse struct {
@@ -68,88 +253,148 @@ type errorResponse struct {
 	RetryAfterHint string `json:"retryAfterHint,omitempty"`
 }
 
+// handleCreate 处理 POST /create，经 SignerService.Create 执行；请求体可为空，此时使用默认曲线。
 func (h *HTTPHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "POST required"))
-		return
-	}
-	var body createRequestBody
-	if r.Body != nil && r.Body != http.NoBody {
-		decoder := json.NewDecoder(r.Body)
-		if err := decoder.Decode(&body); err != nil && err != io.EOF {
-			h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "invalid JSON body"))
-			return
-		}
-	}
-	resp, err := h.backend.Create(r.Context(), &signerv1.CreateRequest{
-		Curve:        body.Curve,
-		AuditContext: convertAuditHeaders(body.AuditHeaders),
-	})
-	if err != nil {
-		h.writeUnknownError(w, err)
-		return
-	}
-	publicKey := hex.EncodeToString(resp.GetPublicKey())
-	payload := createResponseBody{
+	h.serveGateway(w, r, signerMethods["Create"], v1Codec(http.MethodPost,
+		func(w http.ResponseWriter, r *http.Request, in *signerv1.CreateRequest) *apierrors.Error {
+			var body createRequestBody
+			if apiErr := h.decodeJSON(w, r, &body, true); apiErr != nil {
+				return apiErr
+			}
+			in.Curve, in.AddressFormats = body.Curve, body.AddressFormats
+			in.AuditContext = auditContextFrom(withAuditHeaders(r.Context(), body.AuditHeaders))
+			return nil
+		}, newCreateResponseBody))
+}
+
+func newCreateResponseBody(resp *signerv1.CreateResponse) createResponseBody {
+	return createResponseBody{
 		KeyID:     resp.GetKeyId(),
-		PublicKey: publicKey,
+		PublicKey: hex.EncodeToString(resp.GetPublicKey()),
 		Address:   resp.GetAddress(),
+		Addresses: derivedAddressMap(resp.GetAddresses()),
 	}
-	h.writeJSON(w, http.StatusOK, payload)
 }
 
+func derivedAddressMap(addrs []*signerv1.DerivedAddress) map[string]string {
+	if len(addrs) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(addrs))
+	for _, a := range addrs {
+		out[a.GetFormat()] = a.GetAddress()
+	}
+	return out
+}
+
+// handleImport 经 SignerService.ImportKey 导入以 import key 包裹的外部私钥，响应与 /create 一致。
+func (h *HTTPHandler) handleImport(w http.ResponseWriter, r *http.Request) {
+	h.serveGateway(w, r, signerMethods["ImportKey"], v1Codec(http.MethodPost,
+		func(w http.ResponseWriter, r *http.Request, in *signerv1.ImportKeyRequest) *apierrors.Error {
+			var body importRequestBody
+			if apiErr := h.decodeJSON(w, r, &body, false); apiErr != nil {
+				return apiErr
+			}
+			wrapped, err := hex.DecodeString(body.WrappedKey)
+			if err != nil {
+				return apierrors.New(apierrors.CodeInvalidArgument, "wrappedKey must be hex encoded")
+			}
+			in.WrappedKey, in.Curve, in.ImportToken = wrapped, body.Curve, body.ImportToken
+			in.AuditContext = auditContextFrom(withAuditHeaders(r.Context(), body.AuditHeaders))
+			return nil
+		}, newCreateResponseBody))
+}
+
+// handleSign 处理 POST /sign，经 SignerService.Sign 执行；UNLOCK_REQUIRED 的解锁入队与退避头由 gRPC 实现给出。
 func (h *HTTPHandler) handleSign(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "POST required"))
-		return
+	h.serveGateway(w, r, signerMethods["Sign"], v1Codec(http.MethodPost,
+		func(w http.ResponseWriter, r *http.Request, in *signerv1.SignRequest) *apierrors.Error {
+			var body signRequestBody
+			if apiErr := h.decodeJSON(w, r, &body, false); apiErr != nil {
+				return apiErr
+			}
+			if apiErr := fillSignRequest(in, &body); apiErr != nil {
+				return apiErr
+			}
+			in.AuditContext = auditContextFrom(withAuditHeaders(r.Context(), body.AuditHeaders))
+			return nil
+		}, newSignResponseBody))
+}
+
+// decodeSignBody 解码 v1 签名请求体并按 checkSignDigest 校验，构造 SignRequest（不含审计字段），
+// 供不经 SignerService 的批量与联签路由使用；失败时返回 INVALID_ARGUMENT。
+func decodeSignBody(body *signRequestBody) (*signerv1.SignRequest, *apierrors.Error) {
+	req := &signerv1.SignRequest{}
+	if apiErr := fillSignRequest(req, body); apiErr != nil {
+		return nil, apiErr
 	}
-	var body signRequestBody
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&body); err != nil {
-		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "invalid JSON body"))
-		return
+	if apiErr := checkSignDigest(req); apiErr != nil {
+		return nil, apiErr
 	}
+	return req, nil
+}
+
+// fillSignRequest 将 v1 签名请求体转换为 req：digest 按 encoding 解码（带 curve 时按该曲线的摘要长度），
+// rawMessage 的 message 只解码不哈希；哈希与 derivationPath 校验留给 checkSignDigest。
+func fillSignRequest(req *signerv1.SignRequest, body *signRequestBody) *apierrors.Error {
 	if body.KeyID == "" {
-		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "keyId is required"))
-		return
-	}
-	if body.Digest == "" {
-		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "digest is required"))
-		return
+		return apierrors.New(apierrors.CodeInvalidArgument, "keyId is required")
 	}
 	encoding, err := validator.NormalizeEncoding(body.Encoding)
 	if err != nil {
-		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, err.Error()))
-		return
+		return apierrors.New(apierrors.CodeInvalidArgument, err.Error())
 	}
-	decoded, err := validator.DecodeDigest(body.Digest, encoding)
+	payload, err := validator.NormalizePayloadType(body.PayloadType)
 	if err != nil {
-		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, err.Error()))
-		return
+		return apierrors.New(apierrors.CodeInvalidArgument, err.Error())
 	}
-	ctx := r.Context()
-	resp, err := h.backend.Sign(ctx, &signerv1.SignRequest{
-		KeyId:        body.KeyID,
-		Digest:       decoded,
-		Encoding:     convertEncoding(encoding),
-		AuditContext: convertAuditHeaders(body.AuditHeaders),
-	})
-	if err != nil {
-		if h.tryHandleUnlock(w, ctx, body.KeyID, err) {
-			return
+	req.KeyId, req.Encoding, req.Curve = body.KeyID, convertEncoding(encoding), body.Curve
+	req.DryRun, req.DerivationPath = body.DryRun, body.DerivationPath
+	if payload == validator.PayloadTypeRawMessage {
+		if body.Digest != "" {
+			return apierrors.New(apierrors.CodeInvalidArgument, "digest must be empty for rawMessage payload")
 		}
-		h.writeUnknownError(w, err)
-		return
+		if req.Message, err = validator.DecodeMessage(body.Message, encoding); err != nil {
+			return apierrors.New(apierrors.CodeInvalidArgument, err.Error())
+		}
+		req.PayloadType, req.HashAlgorithm = string(payload), body.HashAlgorithm
+		return nil
+	}
+	if body.Digest == "" {
+		return apierrors.New(apierrors.CodeInvalidArgument, "digest is required")
 	}
-	payload := signResponseBody{Signature: hex.EncodeToString(resp.GetSignature())}
-	if resp.GetRecId() != 0 {
+	if body.Message != "" || body.HashAlgorithm != "" {
+		return apierrors.New(apierrors.CodeInvalidArgument, "message and hashAlgorithm require payloadType rawMessage")
+	}
+	if body.Curve == "" {
+		req.Digest, err = validator.DecodeDigest(body.Digest, encoding)
+	} else if curve, lookupErr := curves.Lookup(body.Curve); lookupErr != nil {
+		err = lookupErr
+	} else {
+		req.Curve = curve.Name
+		req.Digest, err = validator.DecodeDigestFor(body.Digest, encoding, curve)
+	}
+	if err != nil {
+		return apierrors.New(apierrors.CodeInvalidArgument, err.Error())
+	}
+	return nil
+}
+
+func newSignResponseBody(resp *signerv1.SignResponse) signResponseBody {
+	payload := signResponseBody{Signature: encodeSignature(resp.GetSignature()), DryRun: resp.GetDryRun()}
+	// recId 为 0 同样合法，只以字段是否设置决定输出。
+	if resp.RecId != nil {
 		value := resp.GetRecId()
 		payload.RecID = &value
 	}
-	h.writeJSON(w, http.StatusOK, payload)
+	return payload
 }
 
 func (h *HTTPHandler) writeJSON(w http.ResponseWriter, status int, payload any) {
+	writeJSONResponse(w, status, payload)
+}
+
+func writeJSONResponse(w http.ResponseWriter, status int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(payload)
@@ -160,6 +405,7 @@ func (h *HTTPHandler) writeUnknownError(w http.ResponseWriter, err error) {
 		h.writeAPIError(w, apiErr)
 		return
 	}
+	h.logger.Error("http handler internal error", "err", err)
 	h.writeAPIError(w, apierrors.New(apierrors.Code("INTERNAL_ERROR"), "internal error"))
 }
 
@@ -175,7 +421,11 @@ func (h *HTTPHandler) writeAPIError(w http.ResponseWriter, apiErr *apierrors.Err
 		Code:    string(apiErr.Code),
 		Message: apiErr.Error(),
 	}
-	if hint := apiErr.RetryAfterHint(); hint != "" {
+	if apiErr.Code == apierrors.CodeRetryLater && !apiErr.HasRetryAfter() && h.hints != nil {
+		retry := h.hints.HintForError(apiErr)
+		w.Header().Set("Retry-After", formatRetryAfterHeader(retry))
+		resp.RetryAfterHint = formatRetryAfterHint(retry)
+	} else if hint := apiErr.RetryAfterHint(); hint != "" {
 		resp.RetryAfterHint = hint
 		if apierrors.RequiresRetryAfter(apiErr.Code) && w.Header().Get("Retry-After") == "" {
 			w.Header().Set("Retry-After", hint)
@@ -242,15 +492,14 @@ func convertEncoding(enc validator.DigestEncoding) signerv1.DigestEncoding {
 	}
 }
 
-func convertAuditHeaders(headers *auditHeaders) *signerv1.AuditContext {
-	if headers == nil {
-		return nil
-	}
-	if headers.RequestID == "" && headers.TenantID == "" {
-		return nil
-	}
-	return &signerv1.AuditContext{
-		RequestId: headers.RequestID,
-		TenantId:  headers.TenantID,
+// encodeSignature 一次性分配目标长度的 hex 字符串，省去 hex.EncodeToString 的中间切片。
+func encodeSignature(sig []byte) string {
+	const digits = "0123456789abcdef"
+	var b strings.Builder
+	b.Grow(hex.EncodedLen(len(sig)))
+	for _, c := range sig {
+		b.WriteByte(digits[c>>4])
+		b.WriteByte(digits[c&0x0f])
 	}
+	return b.String()
 }
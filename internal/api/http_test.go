@@ -11,8 +11,13 @@ import (
 	"time"
 
 	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
 	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
 	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/aegis-sign/wallet/pkg/validator"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestHandleSignSuccess(t *testing.T) {
@@ -22,9 +27,9 @@ func TestHandleSignSuccess(t *testing.T) {
 			if len(req.GetDigest()) != 32 {
 				t.Fatalf("digest len=%d", len(req.GetDigest()))
 			}
-			return &signerv1.SignResponse{Signature: []byte{0x01, 0x02}, RecId: 7}, nil
+			return &signerv1.SignResponse{Signature: []byte{0x01, 0x02}, RecId: proto.Uint32(7)}, nil
 		},
-	}, nil)
+	})
 	req := httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(`{"keyId":"k1","digest":"`+digest+`","encoding":"hex"}`))
 	rr := httptest.NewRecorder()
 	handler.handleSign(rr, req)
@@ -43,8 +48,56 @@ func TestHandleSignSuccess(t *testing.T) {
 	}
 }
 
+func TestHandleSignRecIDPresence(t *testing.T) {
+	var recID *uint32
+	handler := NewHTTPHandler(&stubBackend{
+		signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			return &signerv1.SignResponse{Signature: []byte{0x01}, RecId: recID}, nil
+		},
+	})
+	sign := func() string {
+		req := httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(`{"keyId":"k1","digest":"`+strings.Repeat("a", 64)+`"}`))
+		rr := httptest.NewRecorder()
+		handler.handleSign(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status=%d", rr.Code)
+		}
+		return rr.Body.String()
+	}
+	// recId 为 0 是合法的恢复 id，必须输出；未设置时省略。
+	recID = proto.Uint32(0)
+	if body := sign(); !strings.Contains(body, `"recId":0`) {
+		t.Fatalf("expected recId=0, got %s", body)
+	}
+	recID = nil
+	if body := sign(); strings.Contains(body, "recId") {
+		t.Fatalf("expected no recId, got %s", body)
+	}
+}
+
+func TestHandleSignDryRun(t *testing.T) {
+	handler := NewHTTPHandler(&stubBackend{
+		signFn: func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			if !req.GetDryRun() {
+				t.Fatalf("dryRun not forwarded to backend")
+			}
+			return dryRunSignResponse(), nil
+		},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(`{"keyId":"k1","digest":"`+strings.Repeat("a", 64)+`","dryRun":true}`))
+	rr := httptest.NewRecorder()
+	handler.handleSign(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d", rr.Code)
+	}
+	want := `{"signature":"` + strings.Repeat("0", 128) + `","dryRun":true}`
+	if body := strings.TrimSpace(rr.Body.String()); body != want {
+		t.Fatalf("body=%s want %s", body, want)
+	}
+}
+
 func TestHandleSignInvalidDigest(t *testing.T) {
-	handler := NewHTTPHandler(&stubBackend{}, nil)
+	handler := NewHTTPHandler(&stubBackend{})
 	req := httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(`{"keyId":"k1","digest":"zzz"}`))
 	rr := httptest.NewRecorder()
 	handler.handleSign(rr, req)
@@ -60,12 +113,103 @@ func TestHandleSignInvalidDigest(t *testing.T) {
 	}
 }
 
+func TestHandleSignCurve(t *testing.T) {
+	var got string
+	handler := NewHTTPHandler(&stubBackend{
+		signFn: func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			got = req.GetCurve()
+			return &signerv1.SignResponse{Signature: []byte{0x01}}, nil
+		},
+	})
+	digest := strings.Repeat("ab", 32)
+	rr := httptest.NewRecorder()
+	handler.handleSign(rr, httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(`{"keyId":"k1","digest":"`+digest+`","curve":"Ed25519"}`)))
+	if rr.Code != http.StatusOK || got != "ed25519" {
+		t.Fatalf("status=%d curve=%q", rr.Code, got)
+	}
+	for _, payload := range []string{
+		`{"keyId":"k1","digest":"` + digest + `","curve":"ed448"}`,
+		`{"keyId":"k1","digest":"` + digest[:40] + `","curve":"ed25519"}`,
+	} {
+		rr = httptest.NewRecorder()
+		handler.handleSign(rr, httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(payload)))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("payload %s: status=%d", payload, rr.Code)
+		}
+	}
+}
+
+func TestHandleSignDerivationPath(t *testing.T) {
+	var got string
+	handler := NewHTTPHandler(&stubBackend{
+		signFn: func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			got = req.GetDerivationPath()
+			return &signerv1.SignResponse{Signature: []byte{0x01}}, nil
+		},
+	})
+	digest := strings.Repeat("ab", 32)
+	rr := httptest.NewRecorder()
+	handler.handleSign(rr, httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(`{"keyId":"k1","digest":"`+digest+`","derivationPath":"m/44h/60h/0h/0/5"}`)))
+	if rr.Code != http.StatusOK || got != "m/44'/60'/0'/0/5" {
+		t.Fatalf("status=%d path=%q", rr.Code, got)
+	}
+	for _, payload := range []string{
+		`{"keyId":"k1","digest":"` + digest + `","derivationPath":"44'/60'"}`,
+		`{"keyId":"k1","digest":"` + digest + `","derivationPath":"m/2147483648"}`,
+		// SLIP-0010 ed25519 只支持硬化派生。
+		`{"keyId":"k1","digest":"` + digest + `","curve":"ed25519","derivationPath":"m/44'/501'/0'/0"}`,
+	} {
+		rr = httptest.NewRecorder()
+		handler.handleSign(rr, httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(payload)))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("payload %s: status=%d", payload, rr.Code)
+		}
+	}
+}
+
+func TestHandleSignRawMessage(t *testing.T) {
+	var got *signerv1.SignRequest
+	handler := NewHTTPHandler(&stubBackend{
+		signFn: func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			got = req
+			return &signerv1.SignResponse{Signature: []byte{0x01}}, nil
+		},
+	})
+	// message 为 "hello" 的 hex 编码。
+	rr := httptest.NewRecorder()
+	handler.handleSign(rr, httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(`{"keyId":"k1","payloadType":"rawMessage","message":"68656c6c6f","hashAlgorithm":"keccak256"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if hex.EncodeToString(got.GetDigest()) != "1c8aff950685c2ed4bc3174f3472287b56d9517b9c948127319a09a7a36deac8" {
+		t.Fatalf("digest=%x", got.GetDigest())
+	}
+	if len(got.GetMessage()) != 0 || got.GetHashAlgorithm() != "keccak256" {
+		t.Fatalf("message=%x hash=%q", got.GetMessage(), got.GetHashAlgorithm())
+	}
+	digest := strings.Repeat("ab", 32)
+	for _, payload := range []string{
+		`{"keyId":"k1","payloadType":"rawMessage","message":"68656c6c6f"}`,
+		`{"keyId":"k1","payloadType":"rawMessage","message":"68656c6c6f","hashAlgorithm":"sha256","digest":"` + digest + `"}`,
+		`{"keyId":"k1","payloadType":"rawMessage","hashAlgorithm":"sha256"}`,
+		`{"keyId":"k1","payloadType":"rawMessage","message":"` + strings.Repeat("00", validator.MaxMessageSize+1) + `","hashAlgorithm":"sha256"}`,
+		`{"keyId":"k1","digest":"` + digest + `","hashAlgorithm":"sha256"}`,
+		`{"keyId":"k1","digest":"` + digest + `","payloadType":"typedData"}`,
+	} {
+		rr = httptest.NewRecorder()
+		handler.handleSign(rr, httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(payload)))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("payload %.120s: status=%d", payload, rr.Code)
+		}
+	}
+}
+
 func TestHandleSignInvalidKey(t *testing.T) {
 	handler := NewHTTPHandler(&stubBackend{
 		signFn: func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
 			return nil, apierrors.New(apierrors.CodeInvalidKey, "unknown key")
 		},
-	}, nil)
+	})
 	req := httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(`{"keyId":"k1","digest":"`+strings.Repeat("a", 64)+`"}`))
 	rr := httptest.NewRecorder()
 	handler.handleSign(rr, req)
@@ -90,7 +234,7 @@ func TestCreateFastPathBudget(t *testing.T) {
 				Address:   "0x1234",
 			}, nil
 		},
-	}, nil)
+	})
 	warmReq := httptest.NewRequest(http.MethodPost, "/create", strings.NewReader(`{}`))
 	handler.handleCreate(httptest.NewRecorder(), warmReq)
 	req := httptest.NewRequest(http.MethodPost, "/create", strings.NewReader(`{}`))
@@ -118,7 +262,7 @@ func TestHandleSignUnlockRequired(t *testing.T) {
 		signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
 			return nil, keycache.NewUnlockRequiredError("dek expired", 0)
 		},
-	}, responder)
+	}, WithUnlockResponder(responder))
 	req := httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(`{"keyId":"k-unlock","digest":"`+strings.Repeat("a", 64)+`"}`))
 	rr := httptest.NewRecorder()
 	handler.handleSign(rr, req)
@@ -143,6 +287,32 @@ func TestHandleSignUnlockRequired(t *testing.T) {
 	}
 }
 
+func TestNewHTTPHandlerWithUnlockCompat(t *testing.T) {
+	queue := &httpUnlockQueue{}
+	responder := NewUnlockResponder(UnlockResponderConfig{Queue: queue, Keyspace: "prod"})
+	reg := prometheus.NewRegistry()
+	handler := NewHTTPHandlerWithUnlock(&stubBackend{
+		signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			return nil, keycache.NewUnlockRequiredError("dek expired", 0)
+		},
+	}, responder)
+	WithMetrics(NewHTTPMetrics(reg))(handler)
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(`{"keyId":"k-compat","digest":"`+strings.Repeat("a", 64)+`"}`)))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status=%d", rr.Code)
+	}
+	if queue.lastEvent.KeyID != "k-compat" || rr.Header().Get("X-Unlock-Request-Id") == "" {
+		t.Fatalf("responder not consulted: event=%+v headers=%v", queue.lastEvent, rr.Header())
+	}
+	if got := testutil.ToFloat64(handler.metrics.responses.WithLabelValues("sign", "503")); got != 1 {
+		t.Fatalf("expected one 503 response counted, got %v", got)
+	}
+}
+
 type httpUnlockQueue struct {
 	lastEvent keycache.UnlockEvent
 }
@@ -161,8 +331,11 @@ func bytesRepeat(b byte, n int) []byte {
 }
 
 type stubBackend struct {
-	createFn func(context.Context, *signerv1.CreateRequest) (*signerv1.CreateResponse, error)
-	signFn   func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error)
+	createFn    func(context.Context, *signerv1.CreateRequest) (*signerv1.CreateResponse, error)
+	importFn    func(context.Context, *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error)
+	signFn      func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error)
+	publicKeyFn func(context.Context, *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error)
+	disableFn   func(context.Context, *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error)
 }
 
 func (s *stubBackend) Create(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
@@ -172,9 +345,127 @@ func (s *stubBackend) Create(ctx context.Context, req *signerv1.CreateRequest) (
 	return s.createFn(ctx, req)
 }
 
+func (s *stubBackend) ImportKey(ctx context.Context, req *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+	if s.importFn == nil {
+		return &signerv1.CreateResponse{}, nil
+	}
+	return s.importFn(ctx, req)
+}
+
 func (s *stubBackend) Sign(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
 	if s.signFn == nil {
 		return &signerv1.SignResponse{}, nil
 	}
 	return s.signFn(ctx, req)
 }
+
+func (s *stubBackend) GetPublicKey(ctx context.Context, req *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error) {
+	if s.publicKeyFn == nil {
+		return &signerv1.CreateResponse{KeyId: req.GetKeyId()}, nil
+	}
+	return s.publicKeyFn(ctx, req)
+}
+
+func (s *stubBackend) DisableKey(ctx context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+	if s.disableFn == nil {
+		return &signerv1.DisableKeyResponse{KeyId: req.GetKeyId()}, nil
+	}
+	return s.disableFn(ctx, req)
+}
+
+func TestHandleCreateRejectsUnknownCurve(t *testing.T) {
+	var seen string
+	handler := NewHTTPHandler(&stubBackend{
+		createFn: func(_ context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+			seen = req.GetCurve()
+			return &signerv1.CreateResponse{KeyId: "k1"}, nil
+		},
+	})
+	rr := httptest.NewRecorder()
+	handler.handleCreate(rr, httptest.NewRequest(http.MethodPost, "/create", strings.NewReader(`{"curve":"ed448"}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status=%d", rr.Code)
+	}
+	if seen != "" {
+		t.Fatal("backend must not be called for unsupported curve")
+	}
+
+	rr = httptest.NewRecorder()
+	handler.handleCreate(rr, httptest.NewRequest(http.MethodPost, "/create", strings.NewReader(`{}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d", rr.Code)
+	}
+	if seen != "secp256k1" {
+		t.Fatalf("default curve not applied, got %q", seen)
+	}
+}
+
+func TestHandleCreateDerivesAddresses(t *testing.T) {
+	// 私钥 1 对应的压缩公钥，地址为公开测试向量。
+	pub, _ := hex.DecodeString("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	var seen []string
+	handler := NewHTTPHandler(&stubBackend{
+		createFn: func(_ context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+			seen = req.GetAddressFormats()
+			return &signerv1.CreateResponse{KeyId: "k1", PublicKey: pub}, nil
+		},
+	})
+	rr := httptest.NewRecorder()
+	handler.handleCreate(rr, httptest.NewRequest(http.MethodPost, "/create", strings.NewReader(`{"addressFormats":["ETH","tron","eth","bech32"]}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if strings.Join(seen, ",") != "eth,tron,bech32" {
+		t.Fatalf("formats not normalized: %v", seen)
+	}
+	var body createResponseBody
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"eth":    "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf",
+		"tron":   "TMVQGm1qAQYVdetCeGRRkTWYYrLXuHK2HC",
+		"bech32": "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+	}
+	for format, addr := range want {
+		if body.Addresses[format] != addr {
+			t.Fatalf("%s address=%q want %q", format, body.Addresses[format], addr)
+		}
+	}
+
+	// 未知格式与不支持地址派生的曲线在创建前拒绝。
+	seen = nil
+	for _, payload := range []string{`{"addressFormats":["solana"]}`, `{"curve":"ed25519","addressFormats":["eth"]}`} {
+		rr = httptest.NewRecorder()
+		handler.handleCreate(rr, httptest.NewRequest(http.MethodPost, "/create", strings.NewReader(payload)))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status=%d", payload, rr.Code)
+		}
+	}
+	if seen != nil {
+		t.Fatal("backend must not be called for invalid address formats")
+	}
+}
+
+func TestHandleSignPropagatesAuditContext(t *testing.T) {
+	handler := NewHTTPHandler(&stubBackend{
+		signFn: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			if tenant, _ := reqctx.TenantIDFrom(ctx); tenant != "tenant-a" {
+				t.Fatalf("tenant not in context: %q", tenant)
+			}
+			if requestID, _ := reqctx.RequestIDFrom(ctx); requestID != "req-1" {
+				t.Fatalf("request id not in context: %q", requestID)
+			}
+			if req.GetAuditContext().GetTenantId() != "tenant-a" {
+				t.Fatalf("audit context not forwarded: %v", req.GetAuditContext())
+			}
+			return &signerv1.SignResponse{Signature: []byte{0x01}}, nil
+		},
+	})
+	body := `{"keyId":"k1","digest":"` + strings.Repeat("a", 64) + `","auditHeaders":{"requestId":"req-1","tenantId":"tenant-a"}}`
+	rr := httptest.NewRecorder()
+	handler.handleSign(rr, httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d", rr.Code)
+	}
+}
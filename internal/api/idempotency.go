@@ -0,0 +1,273 @@
+package signerapi
+
+import (
+	"container/list"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// IdempotencyKeyHeader 是 /create 携带幂等键的 HTTP 头。
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotencyKeyMetadataKey 是 gRPC Create 携带幂等键的 metadata 键。
+	IdempotencyKeyMetadataKey = "idempotency-key"
+
+	maxIdempotencyKeyLen         = 255
+	defaultIdempotencyTTL        = 24 * time.Hour
+	defaultIdempotencyMaxEntries = 100000
+	idempotencyInProgressRetry   = 100 * time.Millisecond
+)
+
+// IdempotencyRecord 是一次成功 Create 的结果。
+type IdempotencyRecord struct {
+	// Fingerprint 概括请求参数，同一幂等键携带不同参数时拒绝重放。
+	Fingerprint string
+	Response    *signerv1.CreateResponse
+	CreatedAt   time.Time
+}
+
+// IdempotencyStore 保存幂等 Create 的结果；多实例部署时应替换为共享存储（如 Redis），
+// 内置的 MemoryIdempotencyStore 只覆盖重试落在同一实例的情况。
+type IdempotencyStore interface {
+	// Get 返回未过期的记录，不存在时 ok 为 false。
+	Get(ctx context.Context, key string) (IdempotencyRecord, bool, error)
+	Put(ctx context.Context, key string, rec IdempotencyRecord) error
+}
+
+// ValidateIdempotencyKey 要求幂等键为 1-255 个可打印 ASCII 字符。
+func ValidateIdempotencyKey(key string) error {
+	if key == "" || len(key) > maxIdempotencyKeyLen {
+		return apierrors.New(apierrors.CodeInvalidArgument, "Idempotency-Key must be 1-255 characters")
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return apierrors.New(apierrors.CodeInvalidArgument, "Idempotency-Key must be printable ASCII")
+		}
+	}
+	return nil
+}
+
+// withIdempotencyKey 校验并写入幂等键，key 为空时原样返回。
+func withIdempotencyKey(ctx context.Context, key string) (context.Context, error) {
+	if key == "" {
+		return ctx, nil
+	}
+	if err := ValidateIdempotencyKey(key); err != nil {
+		return ctx, err
+	}
+	return reqctx.WithIdempotencyKey(ctx, key), nil
+}
+
+// IdempotencyConfig 配置 Create 幂等。
+type IdempotencyConfig struct {
+	// Store 为空时关闭幂等，Idempotency-Key 仅做格式校验。
+	Store  IdempotencyStore
+	Logger *slog.Logger
+
+	Registerer     prometheus.Registerer
+	MetricsOptions metricsopts.Options
+}
+
+// Idempotency 让携带幂等键的 Create 在重试时返回首次生成的 keyId，而不是在 Enclave 中再建一把 key。nil 表示关闭。
+type Idempotency struct {
+	store  IdempotencyStore
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	inFlight map[string]struct{}
+
+	requests *prometheus.CounterVec
+}
+
+// NewIdempotency 构造 Idempotency，cfg.Store 为 nil 时返回 nil。
+func NewIdempotency(cfg IdempotencyConfig) (*Idempotency, error) {
+	if cfg.Store == nil {
+		return nil, nil
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	reg := cfg.Registerer
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	opts := cfg.MetricsOptions.WithDefaults("signer", "idempotency")
+	i := &Idempotency{
+		store:    cfg.Store,
+		logger:   logger,
+		inFlight: make(map[string]struct{}),
+		requests: prometheus.NewCounterVec(opts.Counter("requests_total",
+			"Number of create requests carrying an idempotency key by result"), []string{"result"}),
+	}
+	if err := metricsopts.Register(reg, i.requests); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// acquire 保证同一幂等键同时只有一个 Create 在执行。
+func (i *Idempotency) acquire(storeKey string) (func(), bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if _, busy := i.inFlight[storeKey]; busy {
+		return nil, false
+	}
+	i.inFlight[storeKey] = struct{}{}
+	return func() {
+		i.mu.Lock()
+		delete(i.inFlight, storeKey)
+		i.mu.Unlock()
+	}, true
+}
+
+func (i *Idempotency) create(ctx context.Context, next Backend, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+	key, ok := reqctx.IdempotencyKeyFrom(ctx)
+	if !ok {
+		return next.Create(ctx, req)
+	}
+	tenant, _ := requestTenant(ctx, req.GetAuditContext())
+	storeKey := tenant + "\x00" + key
+	fingerprint := req.GetCurve()
+
+	release, ok := i.acquire(storeKey)
+	if !ok {
+		i.requests.WithLabelValues("in_progress").Inc()
+		return nil, apierrors.New(apierrors.CodeRetryLater, "create with this Idempotency-Key is in progress").
+			WithRetryAfter(idempotencyInProgressRetry)
+	}
+	defer release()
+
+	rec, found, err := i.store.Get(ctx, storeKey)
+	switch {
+	case err != nil:
+		i.logger.WarnContext(ctx, "idempotency store lookup failed, creating a new key", "error", err)
+	case found && rec.Fingerprint != fingerprint:
+		i.requests.WithLabelValues("conflict").Inc()
+		return nil, apierrors.New(apierrors.CodeInvalidArgument, "Idempotency-Key was already used with different parameters")
+	case found:
+		i.requests.WithLabelValues("replayed").Inc()
+		return proto.Clone(rec.Response).(*signerv1.CreateResponse), nil
+	}
+
+	resp, err := next.Create(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	i.requests.WithLabelValues("created").Inc()
+	rec = IdempotencyRecord{Fingerprint: fingerprint, Response: proto.Clone(resp).(*signerv1.CreateResponse), CreatedAt: time.Now().UTC()}
+	if err := i.store.Put(ctx, storeKey, rec); err != nil {
+		i.logger.WarnContext(ctx, "idempotency store write failed", "key_id", resp.GetKeyId(), "error", err)
+	}
+	return resp, nil
+}
+
+// IdempotencyMiddleware 对携带幂等键的 Create 去重：幂等键按租户隔离，命中时返回首次的响应；
+// 同一键的并发请求只放行一个，其余返回 RETRY_LATER；失败的 Create 不记录，可用同一键重试。
+// 存储读写失败时照常创建并告警，避免存储故障阻断建 key。i 为 nil 时返回 nil，由 Chain 跳过。
+func IdempotencyMiddleware(i *Idempotency) BackendMiddleware {
+	if i == nil {
+		return nil
+	}
+	return func(next Backend) Backend {
+		return BackendFuncs{
+			Next: next,
+			CreateFunc: func(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+				return i.create(ctx, next, req)
+			},
+		}
+	}
+}
+
+// MemoryIdempotencyStore 是进程内的 IdempotencyStore，按 TTL 过期并在超过容量时淘汰最早的记录。
+type MemoryIdempotencyStore struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryIdempotencyEntry struct {
+	key       string
+	rec       IdempotencyRecord
+	expiresAt time.Time
+}
+
+// NewMemoryIdempotencyStore 构造进程内存储，ttl 默认 24h，maxEntries 默认 100000。
+func NewMemoryIdempotencyStore(ttl time.Duration, maxEntries int) *MemoryIdempotencyStore {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	if maxEntries <= 0 {
+		maxEntries = defaultIdempotencyMaxEntries
+	}
+	return &MemoryIdempotencyStore{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Get 实现 IdempotencyStore。
+func (s *MemoryIdempotencyStore) Get(_ context.Context, key string) (IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireLocked()
+	el, ok := s.entries[key]
+	if !ok {
+		return IdempotencyRecord{}, false, nil
+	}
+	return el.Value.(*memoryIdempotencyEntry).rec, true, nil
+}
+
+// Put 实现 IdempotencyStore，覆盖已有记录并重新计算其过期时间。
+func (s *MemoryIdempotencyStore) Put(_ context.Context, key string, rec IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok {
+		s.order.Remove(el)
+	}
+	s.entries[key] = s.order.PushBack(&memoryIdempotencyEntry{key: key, rec: rec, expiresAt: s.now().Add(s.ttl)})
+	s.expireLocked()
+	for s.order.Len() > s.maxEntries {
+		s.removeLocked(s.order.Front())
+	}
+	return nil
+}
+
+// Len 返回当前记录数。
+func (s *MemoryIdempotencyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// expireLocked 从最早写入的记录开始移除过期项；记录按写入顺序排列，遇到未过期的即停止。
+func (s *MemoryIdempotencyStore) expireLocked() {
+	now := s.now()
+	for el := s.order.Front(); el != nil; el = s.order.Front() {
+		if el.Value.(*memoryIdempotencyEntry).expiresAt.After(now) {
+			return
+		}
+		s.removeLocked(el)
+	}
+}
+
+func (s *MemoryIdempotencyStore) removeLocked(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*memoryIdempotencyEntry).key)
+}
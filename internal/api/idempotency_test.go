@@ -0,0 +1,166 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newIdempotentBackend(t *testing.T, createFn func(context.Context, *signerv1.CreateRequest) (*signerv1.CreateResponse, error)) (Backend, *Idempotency) {
+	t.Helper()
+	idem, err := NewIdempotency(IdempotencyConfig{
+		Store:      NewMemoryIdempotencyStore(0, 0),
+		Registerer: prometheus.NewRegistry(),
+	})
+	require.NoError(t, err)
+	return Chain(&stubBackend{createFn: createFn}, IdempotencyMiddleware(idem)), idem
+}
+
+// countingCreate 每次调用生成新的 keyId。
+func countingCreate(calls *atomic.Int32) func(context.Context, *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+	return func(context.Context, *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+		n := calls.Add(1)
+		return &signerv1.CreateResponse{KeyId: fmt.Sprintf("key-%d", n)}, nil
+	}
+}
+
+func TestIdempotencyMiddlewareReplaysCreate(t *testing.T) {
+	var calls atomic.Int32
+	backend, idem := newIdempotentBackend(t, countingCreate(&calls))
+	ctx := reqctx.WithIdempotencyKey(reqctx.WithTenantID(context.Background(), "a"), "retry-1")
+	req := &signerv1.CreateRequest{Curve: "secp256k1"}
+
+	first, err := backend.Create(ctx, req)
+	require.NoError(t, err)
+	again, err := backend.Create(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, first.GetKeyId(), again.GetKeyId())
+	require.EqualValues(t, 1, calls.Load())
+
+	// 幂等键按租户隔离；不带幂等键的请求每次新建。
+	other, err := backend.Create(reqctx.WithIdempotencyKey(reqctx.WithTenantID(context.Background(), "b"), "retry-1"), req)
+	require.NoError(t, err)
+	require.NotEqual(t, first.GetKeyId(), other.GetKeyId())
+	_, err = backend.Create(context.Background(), req)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, calls.Load())
+
+	_, err = backend.Create(ctx, &signerv1.CreateRequest{Curve: "ed25519"})
+	apiErr, ok := apierrors.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, apierrors.CodeInvalidArgument, apiErr.Code)
+	require.Equal(t, 1.0, testutil.ToFloat64(idem.requests.WithLabelValues("replayed")))
+	require.Equal(t, 1.0, testutil.ToFloat64(idem.requests.WithLabelValues("conflict")))
+}
+
+func TestIdempotencyMiddlewareFailedCreateIsRetryable(t *testing.T) {
+	var calls atomic.Int32
+	backend, _ := newIdempotentBackend(t, func(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("enclave timeout")
+		}
+		return &signerv1.CreateResponse{KeyId: "key-ok"}, nil
+	})
+	ctx := reqctx.WithIdempotencyKey(context.Background(), "retry-1")
+	_, err := backend.Create(ctx, &signerv1.CreateRequest{})
+	require.Error(t, err)
+	resp, err := backend.Create(ctx, &signerv1.CreateRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "key-ok", resp.GetKeyId())
+}
+
+func TestIdempotencyMiddlewareRejectsConcurrentDuplicate(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	backend, _ := newIdempotentBackend(t, func(context.Context, *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+		close(entered)
+		<-release
+		return &signerv1.CreateResponse{KeyId: "key-1"}, nil
+	})
+	ctx := reqctx.WithIdempotencyKey(context.Background(), "retry-1")
+	done := make(chan *signerv1.CreateResponse, 1)
+	go func() {
+		resp, _ := backend.Create(ctx, &signerv1.CreateRequest{})
+		done <- resp
+	}()
+	<-entered
+	_, err := backend.Create(ctx, &signerv1.CreateRequest{})
+	requireRetryLater(t, err, idempotencyInProgressRetry)
+	close(release)
+	require.Equal(t, "key-1", (<-done).GetKeyId())
+
+	resp, err := backend.Create(ctx, &signerv1.CreateRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "key-1", resp.GetKeyId())
+}
+
+func TestIdempotencyKeyOnHTTPAndGRPC(t *testing.T) {
+	var calls atomic.Int32
+	backend, _ := newIdempotentBackend(t, countingCreate(&calls))
+	handler := NewHTTPHandler(backend)
+	create := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/create", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, key)
+		rr := httptest.NewRecorder()
+		handler.handleCreate(rr, req)
+		return rr
+	}
+	var first, second createResponseBody
+	rr := create("order-42")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &first))
+	require.NoError(t, json.Unmarshal(create("order-42").Body.Bytes(), &second))
+	require.Equal(t, first.KeyID, second.KeyID)
+	require.Equal(t, http.StatusBadRequest, create("has space").Code)
+	require.Equal(t, http.StatusBadRequest, create(strings.Repeat("k", 256)).Code)
+
+	grpcServer := NewGRPCServer(backend, nil)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(IdempotencyKeyMetadataKey, "order-42"))
+	resp, err := grpcServer.Create(ctx, &signerv1.CreateRequest{})
+	require.NoError(t, err)
+	require.Equal(t, first.KeyID, resp.GetKeyId())
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(IdempotencyKeyMetadataKey, "bad\tkey"))
+	_, err = grpcServer.Create(ctx, &signerv1.CreateRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.EqualValues(t, 1, calls.Load())
+}
+
+func TestMemoryIdempotencyStoreExpiresAndEvicts(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	store := NewMemoryIdempotencyStore(time.Hour, 2)
+	store.now = clock.Now
+	ctx := context.Background()
+	put := func(key string) {
+		require.NoError(t, store.Put(ctx, key, IdempotencyRecord{Response: &signerv1.CreateResponse{KeyId: key}}))
+	}
+
+	put("a")
+	clock.Advance(30 * time.Minute)
+	put("b")
+	put("c")
+	_, ok, _ := store.Get(ctx, "a")
+	require.False(t, ok, "oldest record is evicted beyond capacity")
+	require.Equal(t, 2, store.Len())
+
+	clock.Advance(time.Hour)
+	_, ok, err := store.Get(ctx, "c")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Zero(t, store.Len())
+}
@@ -0,0 +1,157 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// WrappedKeyVersion 是 import key 信封版本，保留旧名兼容。
+	WrappedKeyVersion = WrappedKeyVersionImportKey
+	// WrappedKeyVersionImportKey：version(1) | RFC 3394 AES-KW 以 import key 包裹的 32B 私钥(40)。
+	WrappedKeyVersionImportKey = 0x01
+	// WrappedKeyVersionKMSDataKey：version(1) | blobLen(2, 大端) | KMS 加密的数据密钥 CiphertextBlob | AES-KW 以该数据密钥包裹的私钥(40)。
+	// Enclave 经 attestation 向 KMS 解密数据密钥后解包。
+	WrappedKeyVersionKMSDataKey = 0x02
+	// WrappedKeyVersionEnclavePublicKey：version(1) | 临时 X25519 公钥(32) | nonce(12) | AES-256-GCM 密文与标签(48)，
+	// 加密密钥为 HKDF-SHA256(ECDH(临时私钥, Enclave 导入公钥))。
+	WrappedKeyVersionEnclavePublicKey = 0x03
+
+	// WrappedKeySize 为 import key 信封长度。
+	WrappedKeySize = 1 + 40
+	// EnclaveWrappedKeySize 为 Enclave 公钥信封长度。
+	EnclaveWrappedKeySize = 1 + 32 + 12 + 48
+	// MaxKMSCiphertextBlobSize 为 KMS 信封中数据密钥密文的长度上限，与 KMS CiphertextBlob 上限一致。
+	MaxKMSCiphertextBlobSize = 6144
+
+	aesKWWrappedKeySize = 40
+)
+
+// ValidateWrappedKey 在转发到 Enclave 前按版本字节检查信封结构与长度，不做解包。
+func ValidateWrappedKey(blob []byte) error {
+	if len(blob) == 0 {
+		return apierrors.New(apierrors.CodeInvalidArgument, "wrappedKey is required")
+	}
+	switch blob[0] {
+	case WrappedKeyVersionImportKey:
+		if len(blob) != WrappedKeySize {
+			return apierrors.New(apierrors.CodeInvalidArgument, "wrappedKey must be 41 bytes")
+		}
+	case WrappedKeyVersionKMSDataKey:
+		if len(blob) < 3 {
+			return apierrors.New(apierrors.CodeInvalidArgument, "wrappedKey KMS envelope is truncated")
+		}
+		blobLen := int(binary.BigEndian.Uint16(blob[1:3]))
+		if blobLen == 0 || blobLen > MaxKMSCiphertextBlobSize {
+			return apierrors.New(apierrors.CodeInvalidArgument, fmt.Sprintf("wrappedKey KMS ciphertext blob must be 1-%d bytes", MaxKMSCiphertextBlobSize))
+		}
+		if len(blob) != 3+blobLen+aesKWWrappedKeySize {
+			return apierrors.New(apierrors.CodeInvalidArgument, "wrappedKey KMS envelope length does not match blob length")
+		}
+	case WrappedKeyVersionEnclavePublicKey:
+		if len(blob) != EnclaveWrappedKeySize {
+			return apierrors.New(apierrors.CodeInvalidArgument, fmt.Sprintf("wrappedKey enclave envelope must be %d bytes", EnclaveWrappedKeySize))
+		}
+	default:
+		return apierrors.New(apierrors.CodeInvalidArgument, "unsupported wrappedKey version")
+	}
+	return nil
+}
+
+// wrappedKeyFormat 返回信封类型的审计标签，未知版本返回 unknown。
+func wrappedKeyFormat(blob []byte) string {
+	if len(blob) == 0 {
+		return "unknown"
+	}
+	switch blob[0] {
+	case WrappedKeyVersionImportKey:
+		return "import_key"
+	case WrappedKeyVersionKMSDataKey:
+		return "kms_data_key"
+	case WrappedKeyVersionEnclavePublicKey:
+		return "enclave_public_key"
+	}
+	return "unknown"
+}
+
+// validateImportRequest 校验导入请求中与 Enclave 无关的字段。
+func validateImportRequest(req *signerv1.ImportKeyRequest) error {
+	if req.GetImportToken() == "" {
+		return apierrors.New(apierrors.CodeInvalidArgument, "importToken is required")
+	}
+	return ValidateWrappedKey(req.GetWrappedKey())
+}
+
+// ImportConfig 配置导入路径的限流与审计。
+type ImportConfig struct {
+	// RateLimit 为每秒允许的导入次数，与 create 独立计数；<=0 表示不限流。
+	RateLimit float64
+	// RateBurst 为突发容量，默认取 RateLimit 向上取整。
+	RateBurst int
+	Logger    *slog.Logger
+}
+
+// ImportMiddleware 对 ImportKey 单独限流，并为每次导入（含被拒绝的）输出带调用方的审计日志。
+func ImportMiddleware(cfg ImportConfig) BackendMiddleware {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	var limiter *rate.Limiter
+	if cfg.RateLimit > 0 {
+		burst := cfg.RateBurst
+		if burst <= 0 {
+			burst = int(math.Ceil(cfg.RateLimit))
+		}
+		limiter = rate.NewLimiter(rate.Limit(cfg.RateLimit), burst)
+	}
+	return func(next Backend) Backend {
+		return BackendFuncs{
+			Next: next,
+			ImportFunc: func(ctx context.Context, req *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+				var (
+					resp *signerv1.CreateResponse
+					err  error
+				)
+				if limiter != nil && !limiter.Allow() {
+					retry := time.Duration(float64(time.Second) / cfg.RateLimit)
+					err = apierrors.New(apierrors.CodeRetryLater, "key import rate limited").WithRetryAfter(retry)
+				} else {
+					resp, err = next.ImportKey(ctx, req)
+				}
+				auditImport(ctx, logger, req, resp, err)
+				return resp, err
+			},
+		}
+	}
+}
+
+func auditImport(ctx context.Context, logger *slog.Logger, req *signerv1.ImportKeyRequest, resp *signerv1.CreateResponse, err error) {
+	principal := "anonymous"
+	if p, ok := reqctx.PrincipalFrom(ctx); ok {
+		principal = p.Subject
+	}
+	attrs := []slog.Attr{
+		slog.String("principal", principal),
+		slog.String("curve", req.GetCurve()),
+		slog.String("envelope", wrappedKeyFormat(req.GetWrappedKey())),
+		slog.String("key", resp.GetKeyId()),
+		slog.String("code", errorCodeLabel(err)),
+	}
+	if requestID, ok := reqctx.RequestIDFrom(ctx); ok {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+	if tenantID, ok := reqctx.TenantIDFrom(ctx); ok {
+		attrs = append(attrs, slog.String("tenant_id", tenantID))
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "key import audit", attrs...)
+}
@@ -0,0 +1,138 @@
+package signerapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/stretchr/testify/require"
+)
+
+func wrappedKeyHex(version byte) string {
+	blob := bytes.Repeat([]byte{0xaa}, WrappedKeySize)
+	blob[0] = version
+	return hex.EncodeToString(blob)
+}
+
+func doImport(h *HTTPHandler, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	h.handleImport(rr, httptest.NewRequest(http.MethodPost, "/keys/import", strings.NewReader(body)))
+	return rr
+}
+
+func TestHandleImportPassthrough(t *testing.T) {
+	var seen *signerv1.ImportKeyRequest
+	h := NewHTTPHandler(&stubBackend{importFn: func(ctx context.Context, req *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+		seen = req
+		return &signerv1.CreateResponse{KeyId: "k-imported", PublicKey: []byte{0x02, 0x01}, Address: "0xabc"}, nil
+	}})
+	body := `{"wrappedKey":"` + wrappedKeyHex(WrappedKeyVersion) + `","importToken":"tok-1","auditHeaders":{"tenantId":"tenant-a"}}`
+	rr := doImport(h, body)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var resp createResponseBody
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, createResponseBody{KeyID: "k-imported", PublicKey: "0201", Address: "0xabc"}, resp)
+	require.Equal(t, "secp256k1", seen.GetCurve())
+	require.Equal(t, "tok-1", seen.GetImportToken())
+	require.Equal(t, wrappedKeyHex(WrappedKeyVersion), hex.EncodeToString(seen.GetWrappedKey()))
+	require.Equal(t, "tenant-a", seen.GetAuditContext().GetTenantId())
+}
+
+func TestHandleImportValidation(t *testing.T) {
+	called := false
+	h := NewHTTPHandler(&stubBackend{importFn: func(context.Context, *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+		called = true
+		return &signerv1.CreateResponse{}, nil
+	}})
+	for name, body := range map[string]string{
+		"not hex":       `{"wrappedKey":"zz","importToken":"tok"}`,
+		"short":         `{"wrappedKey":"01aa","importToken":"tok"}`,
+		"bad version":   `{"wrappedKey":"` + wrappedKeyHex(0x7f) + `","importToken":"tok"}`,
+		"missing token": `{"wrappedKey":"` + wrappedKeyHex(WrappedKeyVersion) + `"}`,
+		"unknown curve": `{"wrappedKey":"` + wrappedKeyHex(WrappedKeyVersion) + `","importToken":"tok","curve":"ed448"}`,
+	} {
+		rr := doImport(h, body)
+		require.Equal(t, http.StatusBadRequest, rr.Code, name)
+	}
+	require.False(t, called, "backend must not be called for invalid imports")
+}
+
+func TestValidateWrappedKeyEnvelopes(t *testing.T) {
+	kmsEnvelope := func(blobLen, total int) []byte {
+		blob := make([]byte, total)
+		blob[0] = WrappedKeyVersionKMSDataKey
+		binary.BigEndian.PutUint16(blob[1:3], uint16(blobLen))
+		return blob
+	}
+	enclave := make([]byte, EnclaveWrappedKeySize)
+	enclave[0] = WrappedKeyVersionEnclavePublicKey
+	for name, blob := range map[string][]byte{
+		"kms":     kmsEnvelope(184, 3+184+40),
+		"enclave": enclave,
+	} {
+		require.NoError(t, ValidateWrappedKey(blob), name)
+	}
+	for name, blob := range map[string][]byte{
+		"empty":             nil,
+		"kms truncated":     {WrappedKeyVersionKMSDataKey, 0x00},
+		"kms empty blob":    kmsEnvelope(0, 3+40),
+		"kms blob too long": kmsEnvelope(MaxKMSCiphertextBlobSize+1, 3+MaxKMSCiphertextBlobSize+1+40),
+		"kms length":        kmsEnvelope(184, 3+184+39),
+		"enclave length":    enclave[:EnclaveWrappedKeySize-1],
+	} {
+		err := ValidateWrappedKey(blob)
+		apiErr, ok := apierrors.FromError(err)
+		require.True(t, ok, name)
+		require.Equal(t, apierrors.CodeInvalidArgument, apiErr.Code, name)
+	}
+	require.Equal(t, "kms_data_key", wrappedKeyFormat(kmsEnvelope(184, 3+184+40)))
+	require.Equal(t, "enclave_public_key", wrappedKeyFormat(enclave))
+}
+
+func TestImportMiddlewareRateLimitsAndAudits(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	backend := Chain(&stubBackend{importFn: func(context.Context, *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+		return &signerv1.CreateResponse{KeyId: "k-imported"}, nil
+	}}, ImportMiddleware(ImportConfig{RateLimit: 0.001, RateBurst: 1, Logger: logger}))
+
+	ctx := reqctx.WithPrincipal(context.Background(), reqctx.Principal{Subject: "svc-migrator"})
+	wrapped, _ := hex.DecodeString(wrappedKeyHex(WrappedKeyVersion))
+	req := &signerv1.ImportKeyRequest{Curve: "secp256k1", ImportToken: "secret-import-token", WrappedKey: wrapped}
+	_, err := backend.ImportKey(ctx, req)
+	require.NoError(t, err)
+	_, err = backend.ImportKey(ctx, req)
+	apiErr, ok := apierrors.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, apierrors.CodeRetryLater, apiErr.Code)
+	require.True(t, apiErr.HasRetryAfter())
+
+	// create 不受导入限流影响。
+	for i := 0; i < 3; i++ {
+		_, err = backend.Create(ctx, &signerv1.CreateRequest{})
+		require.NoError(t, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.Len(t, lines, 2)
+	var first, second map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	require.Equal(t, "svc-migrator", first["principal"])
+	require.Equal(t, "k-imported", first["key"])
+	require.Equal(t, "OK", first["code"])
+	require.Equal(t, "import_key", first["envelope"])
+	require.Equal(t, string(apierrors.CodeRetryLater), second["code"])
+	require.NotContains(t, logs.String(), "secret-import-token")
+}
@@ -0,0 +1,69 @@
+package signerapi
+
+import (
+	"context"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+)
+
+// DefaultKeyspace 是无法确定租户时使用的 keyspace。
+const DefaultKeyspace = "default"
+
+// KeyspaceConfig 配置请求所属 keyspace 的派生方式。
+type KeyspaceConfig struct {
+	// Default 为无法确定租户时的 keyspace，默认 DefaultKeyspace（cmd/signer-api 取 UNLOCK_KEYSPACE）。
+	Default string
+	// PerTenant 为 true 时以凭证中的租户作为 keyspace；只信任认证结果，
+	// 调用方自报的 tenantId 不参与，避免任意取值撑大指标标签。
+	PerTenant bool
+}
+
+func (c KeyspaceConfig) withDefaults() KeyspaceConfig {
+	if c.Default == "" {
+		c.Default = DefaultKeyspace
+	}
+	return c
+}
+
+// Resolve 返回请求的 keyspace：上下文中已有时沿用，其次为凭证租户（PerTenant 时），最后回退 Default。
+func (c KeyspaceConfig) Resolve(ctx context.Context) string {
+	if keyspace, ok := reqctx.KeyspaceFrom(ctx); ok {
+		return keyspace
+	}
+	if c.PerTenant {
+		if p, ok := reqctx.PrincipalFrom(ctx); ok && p.TenantID != "" {
+			return p.TenantID
+		}
+	}
+	return c.withDefaults().Default
+}
+
+// KeyspaceMiddleware 将派生的 keyspace 写入上下文，经 AuditContext.keyspace 下发 Enclave，
+// 使 key cache entry、解锁事件与按 keyspace 打标的指标按租户分区。
+func KeyspaceMiddleware(cfg KeyspaceConfig) BackendMiddleware {
+	cfg = cfg.withDefaults()
+	with := func(ctx context.Context) context.Context {
+		return reqctx.WithKeyspace(ctx, cfg.Resolve(ctx))
+	}
+	return func(next Backend) Backend {
+		return BackendFuncs{
+			Next: next,
+			CreateFunc: func(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+				return next.Create(with(ctx), req)
+			},
+			ImportFunc: func(ctx context.Context, req *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+				return next.ImportKey(with(ctx), req)
+			},
+			SignFunc: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+				return next.Sign(with(ctx), req)
+			},
+			PublicKeyFunc: func(ctx context.Context, req *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error) {
+				return next.GetPublicKey(with(ctx), req)
+			},
+			DisableFunc: func(ctx context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+				return next.DisableKey(with(ctx), req)
+			},
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package signerapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyspaceResolve(t *testing.T) {
+	tenantCtx := reqctx.WithPrincipal(context.Background(), reqctx.Principal{Subject: "svc-a", TenantID: "tenant-a"})
+	claimedCtx := reqctx.WithTenantID(context.Background(), "tenant-b")
+
+	perTenant := KeyspaceConfig{Default: "prod", PerTenant: true}
+	require.Equal(t, "tenant-a", perTenant.Resolve(tenantCtx))
+	// 调用方自报的租户不参与派生。
+	require.Equal(t, "prod", perTenant.Resolve(claimedCtx))
+	require.Equal(t, "pinned", perTenant.Resolve(reqctx.WithKeyspace(tenantCtx, "pinned")))
+
+	require.Equal(t, "prod", KeyspaceConfig{Default: "prod"}.Resolve(tenantCtx))
+	require.Equal(t, DefaultKeyspace, KeyspaceConfig{}.Resolve(context.Background()))
+}
+
+func TestKeyspaceMiddlewareForwardsToEnclave(t *testing.T) {
+	var forwarded *signerv1.AuditContext
+	backend := Chain(&stubBackend{
+		signFn: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			forwarded = mergeAuditContext(ctx, req.GetAuditContext())
+			return &signerv1.SignResponse{}, nil
+		},
+	}, KeyspaceMiddleware(KeyspaceConfig{Default: "prod", PerTenant: true}))
+
+	ctx := reqctx.WithPrincipal(context.Background(), reqctx.Principal{Subject: "svc-a", TenantID: "tenant-a"})
+	_, err := backend.Sign(ctx, &signerv1.SignRequest{KeyId: "k1", AuditContext: &signerv1.AuditContext{Keyspace: "spoofed"}})
+	require.NoError(t, err)
+	require.Equal(t, "tenant-a", forwarded.GetKeyspace())
+
+	_, err = backend.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k1"})
+	require.NoError(t, err)
+	require.Equal(t, "prod", forwarded.GetKeyspace())
+}
+
+func TestUnlockResponderPerTenantKeyspace(t *testing.T) {
+	queue := &stubUnlockQueue{}
+	responder := NewUnlockResponder(UnlockResponderConfig{Queue: queue, Keyspace: "prod", PerTenantKeyspace: true})
+	unlockErr := keycache.NewUnlockRequiredError("dek", 100*time.Millisecond)
+
+	ctx := reqctx.WithPrincipal(context.Background(), reqctx.Principal{Subject: "svc-a", TenantID: "tenant-a"})
+	responder.Handle(ctx, "key-1", unlockErr)
+	require.Equal(t, "tenant-a", queue.lastEvent.Keyspace)
+
+	responder.Handle(context.Background(), "key-1", unlockErr)
+	require.Equal(t, "prod", queue.lastEvent.Keyspace)
+}
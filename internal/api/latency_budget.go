@@ -0,0 +1,129 @@
+package signerapi
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultLatencyAlpha      = 0.2
+	defaultLatencyMinSamples = 10
+)
+
+// LatencyBudgetConfig 配置按 Enclave 跟踪 Sign 延迟并在调用前预检剩余时限。
+type LatencyBudgetConfig struct {
+	// Enabled 为 false 时既不跟踪也不预检（默认）。
+	Enabled bool
+	// Alpha 是 EWMA 中新样本的权重，默认 0.2。
+	Alpha float64
+	// SafetyMargin 从延迟估计中扣除的余量：剩余时限 < 估计值 - SafetyMargin 时快速失败。
+	SafetyMargin time.Duration
+	// MinSamples 为某个 Enclave 累计样本不足时不做预检，默认 10。
+	MinSamples int
+	Registerer prometheus.Registerer
+	// MetricsOptions 覆盖 infeasible_deadline_total 的默认 signer / backend 前缀与常量标签。
+	MetricsOptions metricsopts.Options
+}
+
+type latencyEWMA struct {
+	value   float64
+	samples int
+}
+
+// LatencyBudget 维护每个 Enclave 的 Sign 延迟 EWMA（近似 p50），并拒绝注定超时的请求。
+// nil 表示关闭，所有方法均可安全调用。
+type LatencyBudget struct {
+	alpha      float64
+	margin     time.Duration
+	minSamples int
+	infeasible *prometheus.CounterVec
+
+	mu      sync.RWMutex
+	targets map[string]*latencyEWMA
+}
+
+// NewLatencyBudget 构造 LatencyBudget，未启用时返回 nil。
+func NewLatencyBudget(cfg LatencyBudgetConfig) *LatencyBudget {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Alpha <= 0 || cfg.Alpha > 1 {
+		cfg.Alpha = defaultLatencyAlpha
+	}
+	if cfg.MinSamples <= 0 {
+		cfg.MinSamples = defaultLatencyMinSamples
+	}
+	if cfg.SafetyMargin < 0 {
+		cfg.SafetyMargin = 0
+	}
+	reg := cfg.Registerer
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	opts := cfg.MetricsOptions.WithDefaults("signer", "backend")
+	b := &LatencyBudget{
+		alpha:      cfg.Alpha,
+		margin:     cfg.SafetyMargin,
+		minSamples: cfg.MinSamples,
+		infeasible: prometheus.NewCounterVec(opts.Counter("infeasible_deadline_total",
+			"Number of sign requests rejected because the remaining deadline was below the expected enclave latency"), []string{"enclave_id"}),
+		targets: make(map[string]*latencyEWMA),
+	}
+	metricsopts.MustRegister(reg, b.infeasible)
+	return b
+}
+
+// Observe 记录一次成功调用的耗时。
+func (b *LatencyBudget) Observe(target string, d time.Duration) {
+	if b == nil || d <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.targets[target]
+	if !ok {
+		b.targets[target] = &latencyEWMA{value: float64(d), samples: 1}
+		return
+	}
+	e.value = b.alpha*float64(d) + (1-b.alpha)*e.value
+	e.samples++
+}
+
+// Estimate 返回 target 当前的延迟估计，样本不足时 ok 为 false。
+func (b *LatencyBudget) Estimate(target string) (time.Duration, bool) {
+	if b == nil {
+		return 0, false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	e, ok := b.targets[target]
+	if !ok || e.samples < b.minSamples {
+		return 0, false
+	}
+	return time.Duration(e.value), true
+}
+
+// Check 在调用 Enclave 前比较剩余时限与延迟估计，不可能按时完成时返回 Retry-After 为 0 的 RETRY_LATER。
+func (b *LatencyBudget) Check(ctx context.Context, target string) error {
+	if b == nil {
+		return nil
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	estimate, ok := b.Estimate(target)
+	if !ok {
+		return nil
+	}
+	if time.Until(deadline) >= estimate-b.margin {
+		return nil
+	}
+	b.infeasible.WithLabelValues(target).Inc()
+	return apierrors.New(apierrors.CodeRetryLater, "deadline too short for expected enclave latency").WithRetryAfter(0)
+}
@@ -0,0 +1,80 @@
+package signerapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyBudgetCheck(t *testing.T) {
+	require.Nil(t, NewLatencyBudget(LatencyBudgetConfig{}))
+	var disabled *LatencyBudget
+	require.NoError(t, disabled.Check(context.Background(), "enclave-1"))
+
+	budget := NewLatencyBudget(LatencyBudgetConfig{
+		Enabled:      true,
+		MinSamples:   5,
+		SafetyMargin: 5 * time.Millisecond,
+		Registerer:   prometheus.NewRegistry(),
+	})
+	tight, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// 样本不足时不预检。
+	for i := 0; i < 4; i++ {
+		budget.Observe("enclave-1", 50*time.Millisecond)
+	}
+	require.NoError(t, budget.Check(tight, "enclave-1"))
+	budget.Observe("enclave-1", 50*time.Millisecond)
+	estimate, ok := budget.Estimate("enclave-1")
+	require.True(t, ok)
+	require.InDelta(t, float64(50*time.Millisecond), float64(estimate), float64(time.Millisecond))
+
+	err := budget.Check(tight, "enclave-1")
+	apiErr, ok := apierrors.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, apierrors.CodeRetryLater, apiErr.Code)
+	require.True(t, apiErr.HasRetryAfter())
+	require.Zero(t, apiErr.RetryAfter())
+	require.Equal(t, 1.0, testutil.ToFloat64(budget.infeasible.WithLabelValues("enclave-1")))
+
+	loose, cancelLoose := context.WithTimeout(context.Background(), time.Second)
+	defer cancelLoose()
+	require.NoError(t, budget.Check(loose, "enclave-1"))
+	require.NoError(t, budget.Check(context.Background(), "enclave-1"), "no deadline means no pre-check")
+	require.NoError(t, budget.Check(tight, "enclave-2"), "unknown targets are not pre-checked")
+}
+
+func TestEnclaveBackendFailsFastOnInfeasibleDeadline(t *testing.T) {
+	pool, _, _ := newTestPool(t)
+	budget := NewLatencyBudget(LatencyBudgetConfig{Enabled: true, MinSamples: 1, Registerer: prometheus.NewRegistry()})
+	backend, err := NewEnclaveBackend(pool, StaticTargetSelector{TargetID: "enclave-1"}, WithLatencyBudget(budget))
+	require.NoError(t, err)
+
+	req := &signerv1.SignRequest{KeyId: "k1", Digest: []byte("payload")}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = backend.Sign(ctx, req)
+	require.NoError(t, err)
+	_, ok := budget.Estimate("enclave-1")
+	require.True(t, ok, "successful calls feed the estimate")
+
+	budget.Observe("enclave-1", time.Hour)
+	handler := NewHTTPHandler(backend)
+	body := `{"keyId":"k1","digest":"` + strings.Repeat("a", 64) + `"}`
+	httpReq := httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(body)).WithContext(ctx)
+	rr := httptest.NewRecorder()
+	handler.handleSign(rr, httpReq)
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	// v1 经网关处理，Retry-After 与 gRPC 的 retry-after-ms 一致，为秒级小数。
+	require.Equal(t, "0.000", rr.Header().Get("Retry-After"))
+}
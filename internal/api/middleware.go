@@ -0,0 +1,306 @@
+package signerapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// BackendMiddleware 包装 Backend 以叠加横切逻辑（日志、指标、超时等）。
+type BackendMiddleware func(Backend) Backend
+
+// Chain 依次套用中间件，mws[0] 位于最外层，最先看到请求、最后看到响应。
+func Chain(backend Backend, mws ...BackendMiddleware) Backend {
+	for i := len(mws) - 1; i >= 0; i-- {
+		if mws[i] != nil {
+			backend = mws[i](backend)
+		}
+	}
+	return backend
+}
+
+// BackendFuncs 将函数适配为 Backend，未设置的方法透传给 Next。
+type BackendFuncs struct {
+	Next          Backend
+	CreateFunc    func(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error)
+	ImportFunc    func(ctx context.Context, req *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error)
+	SignFunc      func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error)
+	PublicKeyFunc func(ctx context.Context, req *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error)
+	DisableFunc   func(ctx context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error)
+}
+
+// Create 实现 Creator。
+func (f BackendFuncs) Create(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+	if f.CreateFunc != nil {
+		return f.CreateFunc(ctx, req)
+	}
+	return f.Next.Create(ctx, req)
+}
+
+// ImportKey 实现 Importer。
+func (f BackendFuncs) ImportKey(ctx context.Context, req *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+	if f.ImportFunc != nil {
+		return f.ImportFunc(ctx, req)
+	}
+	return f.Next.ImportKey(ctx, req)
+}
+
+// Sign 实现 Signer。
+func (f BackendFuncs) Sign(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+	if f.SignFunc != nil {
+		return f.SignFunc(ctx, req)
+	}
+	return f.Next.Sign(ctx, req)
+}
+
+// GetPublicKey 实现 PublicKeyGetter。
+func (f BackendFuncs) GetPublicKey(ctx context.Context, req *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error) {
+	if f.PublicKeyFunc != nil {
+		return f.PublicKeyFunc(ctx, req)
+	}
+	return f.Next.GetPublicKey(ctx, req)
+}
+
+// DisableKey 实现 Disabler。
+func (f BackendFuncs) DisableKey(ctx context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+	if f.DisableFunc != nil {
+		return f.DisableFunc(ctx, req)
+	}
+	return f.Next.DisableKey(ctx, req)
+}
+
+// TimeoutMiddleware 为每次 Backend 调用设置上限时间，d<=0 时不生效。
+func TimeoutMiddleware(d time.Duration) BackendMiddleware {
+	return func(next Backend) Backend {
+		if d <= 0 {
+			return next
+		}
+		return BackendFuncs{
+			Next: next,
+			CreateFunc: func(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+				ctx, cancel := context.WithTimeout(ctx, d)
+				defer cancel()
+				return next.Create(ctx, req)
+			},
+			ImportFunc: func(ctx context.Context, req *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+				ctx, cancel := context.WithTimeout(ctx, d)
+				defer cancel()
+				return next.ImportKey(ctx, req)
+			},
+			SignFunc: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+				ctx, cancel := context.WithTimeout(ctx, d)
+				defer cancel()
+				return next.Sign(ctx, req)
+			},
+			PublicKeyFunc: func(ctx context.Context, req *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error) {
+				ctx, cancel := context.WithTimeout(ctx, d)
+				defer cancel()
+				return next.GetPublicKey(ctx, req)
+			},
+			DisableFunc: func(ctx context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+				ctx, cancel := context.WithTimeout(ctx, d)
+				defer cancel()
+				return next.DisableKey(ctx, req)
+			},
+		}
+	}
+}
+
+// LoggingMiddleware 记录每次调用的耗时与错误码，成功为 Debug，失败为 Warn。
+func LoggingMiddleware(logger *slog.Logger) BackendMiddleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	log := func(ctx context.Context, method, keyID string, start time.Time, err error) {
+		level := slog.LevelDebug
+		if err != nil {
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", method),
+			slog.String("key", keyID),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("code", errorCodeLabel(err)),
+		}
+		if requestID, ok := reqctx.RequestIDFrom(ctx); ok {
+			attrs = append(attrs, slog.String("request_id", requestID))
+		}
+		if tenantID, ok := reqctx.TenantIDFrom(ctx); ok {
+			attrs = append(attrs, slog.String("tenant_id", tenantID))
+		}
+		logger.LogAttrs(ctx, level, "backend call", attrs...)
+	}
+	return func(next Backend) Backend {
+		return BackendFuncs{
+			Next: next,
+			CreateFunc: func(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+				start := time.Now()
+				resp, err := next.Create(ctx, req)
+				log(ctx, "create", resp.GetKeyId(), start, err)
+				return resp, err
+			},
+			ImportFunc: func(ctx context.Context, req *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+				start := time.Now()
+				resp, err := next.ImportKey(ctx, req)
+				log(ctx, "import", resp.GetKeyId(), start, err)
+				return resp, err
+			},
+			SignFunc: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+				start := time.Now()
+				resp, err := next.Sign(ctx, req)
+				log(ctx, "sign", req.GetKeyId(), start, err)
+				return resp, err
+			},
+			PublicKeyFunc: func(ctx context.Context, req *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error) {
+				start := time.Now()
+				resp, err := next.GetPublicKey(ctx, req)
+				log(ctx, "publickey", req.GetKeyId(), start, err)
+				return resp, err
+			},
+			DisableFunc: func(ctx context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+				start := time.Now()
+				resp, err := next.DisableKey(ctx, req)
+				log(ctx, "disable", req.GetKeyId(), start, err)
+				return resp, err
+			},
+		}
+	}
+}
+
+// BackendMetrics 记录 Backend 调用次数与延迟。
+type BackendMetrics struct {
+	requests  *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+	abandoned *prometheus.CounterVec
+}
+
+// NewBackendMetrics 在注册器中注册 backend 指标，reg 为空时使用全局注册器。
+func NewBackendMetrics(reg prometheus.Registerer) *BackendMetrics {
+	m, err := NewBackendMetricsWithOptions(reg, metricsopts.Options{})
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// NewBackendMetricsWithOptions 按 opts 覆盖默认的 signer / backend 前缀与常量标签。
+func NewBackendMetricsWithOptions(reg prometheus.Registerer, opts metricsopts.Options) (*BackendMetrics, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	opts = opts.WithDefaults("signer", "backend")
+	m := &BackendMetrics{
+		requests: prometheus.NewCounterVec(opts.Counter("requests_total",
+			"Number of backend calls by method and result code"), []string{"method", "code"}),
+		latency: prometheus.NewHistogramVec(opts.Histogram("latency_ms",
+			"Latency of backend calls in milliseconds",
+			[]float64{0.5, 1, 2, 3, 5, 7.5, 10, 20, 50, 100, 250, 1000}), []string{"method"}),
+		abandoned: prometheus.NewCounterVec(opts.Counter("abandoned_total",
+			"Number of backend calls abandoned because the client went away before completion"), []string{"method"}),
+	}
+	if err := metricsopts.Register(reg, m.requests, m.latency, m.abandoned); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *BackendMetrics) observe(ctx context.Context, method string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		m.abandoned.WithLabelValues(method).Inc()
+	}
+	m.requests.WithLabelValues(method, errorCodeLabel(err)).Inc()
+	m.latency.WithLabelValues(method).Observe(float64(time.Since(start).Microseconds()) / 1000)
+}
+
+// MetricsMiddleware 为 Create/ImportKey/Sign/GetPublicKey/DisableKey 记录请求数与延迟。
+func MetricsMiddleware(m *BackendMetrics) BackendMiddleware {
+	return func(next Backend) Backend {
+		if m == nil {
+			return next
+		}
+		return BackendFuncs{
+			Next: next,
+			CreateFunc: func(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+				start := time.Now()
+				resp, err := next.Create(ctx, req)
+				m.observe(ctx, "create", start, err)
+				return resp, err
+			},
+			ImportFunc: func(ctx context.Context, req *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+				start := time.Now()
+				resp, err := next.ImportKey(ctx, req)
+				m.observe(ctx, "import", start, err)
+				return resp, err
+			},
+			SignFunc: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+				start := time.Now()
+				resp, err := next.Sign(ctx, req)
+				m.observe(ctx, "sign", start, err)
+				return resp, err
+			},
+			PublicKeyFunc: func(ctx context.Context, req *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error) {
+				start := time.Now()
+				resp, err := next.GetPublicKey(ctx, req)
+				m.observe(ctx, "publickey", start, err)
+				return resp, err
+			},
+			DisableFunc: func(ctx context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+				start := time.Now()
+				resp, err := next.DisableKey(ctx, req)
+				m.observe(ctx, "disable", start, err)
+				return resp, err
+			},
+		}
+	}
+}
+
+// errorCodeLabel 将错误归类为指标/日志可用的低基数标签。
+func errorCodeLabel(err error) string {
+	switch {
+	case err == nil:
+		return "OK"
+	case errors.Is(err, context.Canceled):
+		return "CANCELED"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "DEADLINE_EXCEEDED"
+	}
+	if apiErr, ok := apierrors.FromError(err); ok {
+		return string(apiErr.Code)
+	}
+	return "INTERNAL_ERROR"
+}
+
+// UsageRecorder 记录 key 的使用情况，例如最近一次签名时间。
+type UsageRecorder interface {
+	Touch(keyID string)
+}
+
+// UsageMiddleware 在 Sign 成功后记录 key 使用时间，失败的签名不计入。
+func UsageMiddleware(recorder UsageRecorder) BackendMiddleware {
+	return func(next Backend) Backend {
+		if recorder == nil {
+			return next
+		}
+		return BackendFuncs{
+			Next: next,
+			SignFunc: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+				resp, err := next.Sign(ctx, req)
+				// dry-run 不产生签名，不计入 key 的最近使用时间。
+				if err == nil && !req.GetDryRun() {
+					recorder.Touch(req.GetKeyId())
+				}
+				return resp, err
+			},
+		}
+	}
+}
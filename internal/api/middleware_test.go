@@ -0,0 +1,139 @@
+package signerapi
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func recordingMiddleware(name string, trace *[]string) BackendMiddleware {
+	return func(next Backend) Backend {
+		return BackendFuncs{
+			Next: next,
+			SignFunc: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+				*trace = append(*trace, name+":before")
+				resp, err := next.Sign(ctx, req)
+				*trace = append(*trace, name+":after")
+				return resp, err
+			},
+		}
+	}
+}
+
+func TestChainOrdering(t *testing.T) {
+	var trace []string
+	backend := &stubBackend{signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		trace = append(trace, "backend")
+		return &signerv1.SignResponse{}, nil
+	}}
+	chained := Chain(backend, recordingMiddleware("outer", &trace), nil, recordingMiddleware("inner", &trace))
+	_, err := chained.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k1"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"outer:before", "inner:before", "backend", "inner:after", "outer:after"}, trace)
+
+	// 未覆盖的方法透传到下一层。
+	resp, err := chained.Create(context.Background(), &signerv1.CreateRequest{})
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+}
+
+func TestTimeoutMiddlewareSetsDeadline(t *testing.T) {
+	backend := &stubBackend{signFn: func(ctx context.Context, _ *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		require.WithinDuration(t, time.Now().Add(50*time.Millisecond), deadline, 20*time.Millisecond)
+		return &signerv1.SignResponse{}, nil
+	}}
+	_, err := Chain(backend, TimeoutMiddleware(50*time.Millisecond)).Sign(context.Background(), &signerv1.SignRequest{})
+	require.NoError(t, err)
+}
+
+func TestLoggingAndMetricsMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	metrics := NewBackendMetrics(prometheus.NewRegistry())
+	backend := &stubBackend{signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		return nil, apierrors.New(apierrors.CodeInvalidKey, "unknown key")
+	}}
+	chained := Chain(backend, LoggingMiddleware(logger), MetricsMiddleware(metrics))
+
+	_, err := chained.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k-missing"})
+	require.Error(t, err)
+	_, err = chained.Create(context.Background(), &signerv1.CreateRequest{})
+	require.NoError(t, err)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("sign", "INVALID_KEY")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("create", "OK")))
+	require.Contains(t, buf.String(), "key=k-missing")
+	require.Contains(t, buf.String(), "code=INVALID_KEY")
+}
+
+type touchRecorder struct{ keys []string }
+
+func (r *touchRecorder) Touch(keyID string) { r.keys = append(r.keys, keyID) }
+
+func TestUsageMiddlewareTouchesOnSuccessfulSign(t *testing.T) {
+	recorder := &touchRecorder{}
+	backend := &stubBackend{signFn: func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		if req.GetKeyId() == "bad" {
+			return nil, apierrors.New(apierrors.CodeInvalidKey, "unknown key")
+		}
+		return &signerv1.SignResponse{}, nil
+	}}
+	chained := Chain(backend, UsageMiddleware(recorder))
+	_, err := chained.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k1"})
+	require.NoError(t, err)
+	_, err = chained.Sign(context.Background(), &signerv1.SignRequest{KeyId: "bad"})
+	require.Error(t, err)
+	// dry-run 不产生签名，不刷新最近使用时间。
+	_, err = chained.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k2", DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"k1"}, recorder.keys)
+}
+
+func TestClientDisconnectCancelsBackendCall(t *testing.T) {
+	metrics := NewBackendMetrics(prometheus.NewRegistry())
+	cancelled := make(chan struct{})
+	backend := &stubBackend{signFn: func(ctx context.Context, _ *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		select {
+		case <-ctx.Done():
+			close(cancelled)
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return &signerv1.SignResponse{}, nil
+		}
+	}}
+	mux := http.NewServeMux()
+	NewHTTPHandler(Chain(backend, MetricsMiddleware(metrics))).Register(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/sign",
+		strings.NewReader(`{"keyId":"k1","digest":"`+strings.Repeat("a", 64)+`"}`))
+	require.NoError(t, err)
+	_, err = srv.Client().Do(req)
+	require.Error(t, err)
+
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("backend call was not cancelled after client disconnect")
+	}
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.abandoned.WithLabelValues("sign")) == 1
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("sign", "CANCELED")))
+}
@@ -0,0 +1,276 @@
+package signerapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ShadowHeader 标记镜像请求，影子部署可据此跳过审计或限流。
+const ShadowHeader = "X-Shadow"
+
+// 影子请求被丢弃的原因，用作 shadow_dropped_total 的 reason 标签。
+const (
+	ShadowDropBreakerOpen = "breaker_open"
+	ShadowDropSaturated   = "saturated"
+)
+
+const (
+	defaultShadowTimeout          = 2 * time.Second
+	defaultShadowMaxInFlight      = 64
+	defaultShadowBreakerThreshold = 5
+	defaultShadowBreakerCooldown  = 30 * time.Second
+)
+
+// MirrorConfig 配置 /sign 流量镜像。
+type MirrorConfig struct {
+	// ShadowURL 为影子部署的 HTTP 基址，请求发往 ShadowURL + "/sign"；为空时关闭镜像。
+	ShadowURL string
+	// SampleRate 为镜像比例（0-1）。
+	SampleRate float64
+	// Timeout 为单个影子请求的超时，默认 2s。
+	Timeout time.Duration
+	// MaxInFlight 限制并发影子请求数，超出直接丢弃，默认 64。
+	MaxInFlight int
+	// BreakerThreshold 为连续失败多少次后熔断，默认 5。
+	BreakerThreshold int
+	// BreakerCooldown 为熔断持续时间，到期后放行请求探测，默认 30s。
+	BreakerCooldown time.Duration
+
+	Client         *http.Client
+	Logger         *slog.Logger
+	Registerer     prometheus.Registerer
+	MetricsOptions metricsopts.Options
+}
+
+type shadowSignBody struct {
+	KeyID    string `json:"keyId"`
+	Digest   string `json:"digest"`
+	Encoding string `json:"encoding"`
+	Curve    string `json:"curve,omitempty"`
+}
+
+// Mirror 异步将抽样的 Sign 请求（仅 keyId 与 digest）转发到影子部署，
+// 只记录影子的状态码与延迟，不读取其响应内容，也不影响主路径。nil 表示关闭。
+type Mirror struct {
+	cfg    MirrorConfig
+	url    string
+	slots  chan struct{}
+	wg     sync.WaitGroup
+	closed chan struct{}
+
+	requests    *prometheus.CounterVec
+	latency     prometheus.Histogram
+	dropped     *prometheus.CounterVec
+	breakerOpen prometheus.Gauge
+
+	mu        sync.Mutex
+	rnd       *rand.Rand
+	failures  int
+	openUntil time.Time
+	closeOnce sync.Once
+}
+
+// NewMirror 构造 Mirror，ShadowURL 为空或 SampleRate<=0 时返回 nil。
+func NewMirror(cfg MirrorConfig) (*Mirror, error) {
+	base := strings.TrimRight(strings.TrimSpace(cfg.ShadowURL), "/")
+	if base == "" || cfg.SampleRate <= 0 {
+		return nil, nil
+	}
+	if cfg.SampleRate > 1 {
+		cfg.SampleRate = 1
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultShadowTimeout
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = defaultShadowMaxInFlight
+	}
+	if cfg.BreakerThreshold <= 0 {
+		cfg.BreakerThreshold = defaultShadowBreakerThreshold
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = defaultShadowBreakerCooldown
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	reg := cfg.Registerer
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	opts := cfg.MetricsOptions.WithDefaults("signer", "shadow")
+	m := &Mirror{
+		cfg:    cfg,
+		url:    base + "/sign",
+		slots:  make(chan struct{}, cfg.MaxInFlight),
+		closed: make(chan struct{}),
+		rnd:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		requests: prometheus.NewCounterVec(opts.Counter("requests_total",
+			"Number of mirrored sign requests by shadow status and primary result code"), []string{"status", "primary"}),
+		latency: prometheus.NewHistogram(opts.Histogram("latency_ms",
+			"Latency of mirrored sign requests in milliseconds",
+			[]float64{1, 2, 5, 10, 20, 50, 100, 250, 500, 1000, 2000})),
+		dropped: prometheus.NewCounterVec(opts.Counter("dropped_total",
+			"Number of sampled sign requests not mirrored by reason"), []string{"reason"}),
+		breakerOpen: prometheus.NewGauge(opts.Gauge("breaker_open",
+			"Whether shadow mirroring is currently disabled by the circuit breaker")),
+	}
+	if err := metricsopts.Register(reg, m.requests, m.latency, m.dropped, m.breakerOpen); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MirrorMiddleware 在 Sign 完成后按采样率镜像请求，m 为 nil 时不生效。
+func MirrorMiddleware(m *Mirror) BackendMiddleware {
+	return func(next Backend) Backend {
+		if m == nil {
+			return next
+		}
+		return BackendFuncs{
+			Next: next,
+			SignFunc: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+				resp, err := next.Sign(ctx, req)
+				// 影子请求不携带 dryRun，dry-run 不镜像以免在影子环境产生真实签名。
+				if !req.GetDryRun() {
+					m.maybeMirror(req, err)
+				}
+				return resp, err
+			},
+		}
+	}
+}
+
+// Close 停止接收新的镜像请求并等待在途请求结束。
+func (m *Mirror) Close() {
+	if m == nil {
+		return
+	}
+	m.closeOnce.Do(func() { close(m.closed) })
+	m.wg.Wait()
+}
+
+func (m *Mirror) maybeMirror(req *signerv1.SignRequest, primaryErr error) {
+	select {
+	case <-m.closed:
+		return
+	default:
+	}
+	if !m.sampled() {
+		return
+	}
+	if !m.allow(time.Now()) {
+		m.dropped.WithLabelValues(ShadowDropBreakerOpen).Inc()
+		return
+	}
+	select {
+	case m.slots <- struct{}{}:
+	default:
+		m.dropped.WithLabelValues(ShadowDropSaturated).Inc()
+		return
+	}
+	body, err := json.Marshal(shadowSignBody{
+		KeyID:    req.GetKeyId(),
+		Digest:   hex.EncodeToString(req.GetDigest()),
+		Encoding: "hex",
+		Curve:    req.GetCurve(),
+	})
+	if err != nil {
+		<-m.slots
+		return
+	}
+	primary := errorCodeLabel(primaryErr)
+	m.wg.Add(1)
+	go func() {
+		defer func() {
+			<-m.slots
+			m.wg.Done()
+		}()
+		m.send(body, primary)
+	}()
+}
+
+func (m *Mirror) send(body []byte, primary string) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.Timeout)
+	defer cancel()
+	start := time.Now()
+	status, err := m.post(ctx, body)
+	m.latency.Observe(float64(time.Since(start)) / float64(time.Millisecond))
+	label := "error"
+	if err == nil {
+		label = strconv.Itoa(status)
+	}
+	m.requests.WithLabelValues(label, primary).Inc()
+	m.record(err == nil && status < http.StatusInternalServerError, time.Now())
+	if err != nil {
+		m.cfg.Logger.Debug("shadow sign request failed", slog.Any("error", err))
+	}
+}
+
+func (m *Mirror) post(ctx context.Context, body []byte) (int, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(ShadowHeader, "true")
+	resp, err := m.cfg.Client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	// 不读取影子的签名结果。
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func (m *Mirror) sampled() bool {
+	if m.cfg.SampleRate >= 1 {
+		return true
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rnd.Float64() < m.cfg.SampleRate
+}
+
+// allow 在熔断期间拒绝镜像；冷却期满后放行，若探测再次失败会立即重新熔断。
+func (m *Mirror) allow(now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !now.Before(m.openUntil)
+}
+
+func (m *Mirror) record(success bool, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if success {
+		m.failures = 0
+		if !m.openUntil.IsZero() {
+			m.openUntil = time.Time{}
+			m.breakerOpen.Set(0)
+			m.cfg.Logger.Info("shadow mirroring resumed")
+		}
+		return
+	}
+	m.failures++
+	if m.failures >= m.cfg.BreakerThreshold && !now.Before(m.openUntil) {
+		m.openUntil = now.Add(m.cfg.BreakerCooldown)
+		m.breakerOpen.Set(1)
+		m.cfg.Logger.Warn("shadow mirroring paused", slog.Int("consecutive_failures", m.failures), slog.Duration("cooldown", m.cfg.BreakerCooldown))
+	}
+}
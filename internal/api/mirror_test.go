@@ -0,0 +1,147 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type shadowRecorder struct {
+	mu     sync.Mutex
+	bodies []shadowSignBody
+	status atomic.Int32
+	block  chan struct{}
+}
+
+func newShadowServer(t *testing.T, rec *shadowRecorder) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/sign", r.URL.Path)
+		require.Equal(t, "true", r.Header.Get(ShadowHeader))
+		var body shadowSignBody
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		rec.mu.Lock()
+		rec.bodies = append(rec.bodies, body)
+		rec.mu.Unlock()
+		if rec.block != nil {
+			<-rec.block
+		}
+		status := int(rec.status.Load())
+		if status == 0 {
+			status = http.StatusOK
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (r *shadowRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.bodies)
+}
+
+func mirroredBackend(t *testing.T, cfg MirrorConfig) (Backend, *Mirror) {
+	t.Helper()
+	cfg.Registerer = prometheus.NewRegistry()
+	m, err := NewMirror(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	t.Cleanup(m.Close)
+	backend := &stubBackend{signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		return &signerv1.SignResponse{Signature: []byte{0x01}}, nil
+	}}
+	return Chain(backend, MirrorMiddleware(m)), m
+}
+
+func TestNewMirrorDisabled(t *testing.T) {
+	m, err := NewMirror(MirrorConfig{SampleRate: 1})
+	require.NoError(t, err)
+	require.Nil(t, m)
+	m, err = NewMirror(MirrorConfig{ShadowURL: "http://shadow"})
+	require.NoError(t, err)
+	require.Nil(t, m)
+	backend := &stubBackend{}
+	require.Equal(t, Backend(backend), MirrorMiddleware(nil)(backend))
+}
+
+func TestMirrorForwardsSampledRequests(t *testing.T) {
+	rec := &shadowRecorder{}
+	srv := newShadowServer(t, rec)
+	backend, m := mirroredBackend(t, MirrorConfig{ShadowURL: srv.URL, SampleRate: 0.5})
+	m.rnd = rand.New(rand.NewSource(1))
+
+	const calls = 200
+	for i := 0; i < calls; i++ {
+		_, err := backend.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k1", Digest: []byte{0xab, 0xcd}})
+		require.NoError(t, err)
+	}
+	m.Close()
+	got := rec.count()
+	require.Greater(t, got, calls/4)
+	require.Less(t, got, calls*3/4)
+	require.Equal(t, shadowSignBody{KeyID: "k1", Digest: "abcd", Encoding: "hex"}, rec.bodies[0])
+	require.Equal(t, float64(got), testutil.ToFloat64(m.requests.WithLabelValues("200", "OK")))
+}
+
+func TestMirrorDoesNotBlockPrimary(t *testing.T) {
+	rec := &shadowRecorder{block: make(chan struct{})}
+	srv := newShadowServer(t, rec)
+	backend, m := mirroredBackend(t, MirrorConfig{ShadowURL: srv.URL, SampleRate: 1, MaxInFlight: 1})
+	defer close(rec.block)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := backend.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k1"})
+		require.NoError(t, err)
+		require.Equal(t, []byte{0x01}, resp.GetSignature())
+	}
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+	require.Equal(t, 2.0, testutil.ToFloat64(m.dropped.WithLabelValues(ShadowDropSaturated)))
+}
+
+func TestMirrorBreakerOpensOnShadowFailures(t *testing.T) {
+	rec := &shadowRecorder{}
+	rec.status.Store(http.StatusInternalServerError)
+	srv := newShadowServer(t, rec)
+	backend, m := mirroredBackend(t, MirrorConfig{
+		ShadowURL:        srv.URL,
+		SampleRate:       1,
+		MaxInFlight:      1,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Hour,
+	})
+	sign := func() {
+		_, err := backend.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k1"})
+		require.NoError(t, err)
+		// 等待在途影子请求结束，保证下一次请求能拿到并发名额。
+		require.Eventually(t, func() bool { return len(m.slots) == 0 }, time.Second, time.Millisecond)
+	}
+	sign()
+	sign()
+	require.Equal(t, 1.0, testutil.ToFloat64(m.breakerOpen))
+	sign()
+	require.Equal(t, 2, rec.count())
+	require.Equal(t, 1.0, testutil.ToFloat64(m.dropped.WithLabelValues(ShadowDropBreakerOpen)))
+
+	// 冷却到期后放行探测，成功即恢复。
+	rec.status.Store(http.StatusOK)
+	m.mu.Lock()
+	m.openUntil = time.Now().Add(-time.Millisecond)
+	m.mu.Unlock()
+	sign()
+	require.Equal(t, 3, rec.count())
+	require.Equal(t, 0.0, testutil.ToFloat64(m.breakerOpen))
+}
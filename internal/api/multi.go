@@ -0,0 +1,93 @@
+package signerapi
+
+import (
+	"fmt"
+	"net/http"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"google.golang.org/protobuf/proto"
+)
+
+type multiSignRequestBody struct {
+	KeyIDs       []string      `json:"keyIds"`
+	Digest       string        `json:"digest"`
+	Encoding     string        `json:"encoding"`
+	Curve        string        `json:"curve,omitempty"`
+	DryRun       bool          `json:"dryRun,omitempty"`
+	AuditHeaders *auditHeaders `json:"auditHeaders"`
+}
+
+// multiSignResponseBody 的 results 与 keyIds 一一对应，succeeded/failed 便于调用方判断是否达到门限。
+type multiSignResponseBody struct {
+	Results   []batchSignResult `json:"results"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+}
+
+// handleSignMulti 处理 POST /sign/multi：用多个 key 对同一摘要并发签名（托管场景的多方联签）。
+// 摘要只校验一次；keyIds 为空、重复或超过 BatchConfig.MaxItems 时整体返回 400，单个 key 的失败逐条返回。
+func (h *HTTPHandler) handleSignMulti(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "POST required"))
+		return
+	}
+	cfg := h.batch.withDefaults()
+	var body multiSignRequestBody
+	if apiErr := h.decodeJSON(w, r, &body, false); apiErr != nil {
+		h.writeAPIError(w, apiErr)
+		return
+	}
+	if len(body.KeyIDs) == 0 {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "keyIds is required"))
+		return
+	}
+	if len(body.KeyIDs) > cfg.MaxItems {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, fmt.Sprintf("keyIds exceeds %d entries", cfg.MaxItems)))
+		return
+	}
+	seen := make(map[string]struct{}, len(body.KeyIDs))
+	for _, keyID := range body.KeyIDs {
+		if keyID == "" {
+			h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "keyIds must not contain empty entries"))
+			return
+		}
+		if _, ok := seen[keyID]; ok {
+			h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "duplicate keyId "+keyID))
+			return
+		}
+		seen[keyID] = struct{}{}
+	}
+	template, apiErr := decodeSignBody(&signRequestBody{
+		KeyID:    body.KeyIDs[0],
+		Digest:   body.Digest,
+		Encoding: body.Encoding,
+		Curve:    body.Curve,
+		DryRun:   body.DryRun,
+	})
+	if apiErr != nil {
+		h.writeAPIError(w, apiErr)
+		return
+	}
+	ctx := withAuditHeaders(r.Context(), body.AuditHeaders)
+	template.AuditContext = auditContextFrom(ctx)
+	reqs := make([]*signerv1.SignRequest, len(body.KeyIDs))
+	for i, keyID := range body.KeyIDs {
+		// 各 key 独立路由且可能落在不同 Enclave，请求需各自一份，避免中间件改写共享对象。
+		req := proto.Clone(template).(*signerv1.SignRequest)
+		req.KeyId = keyID
+		reqs[i] = req
+	}
+	resp := multiSignResponseBody{Results: make([]batchSignResult, len(reqs))}
+	for i, res := range signBatch(ctx, h.backend, reqs, cfg.Concurrency) {
+		out := &resp.Results[i]
+		out.KeyID = body.KeyIDs[i]
+		h.fillBatchResult(ctx, out, res)
+		if out.Error != nil {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+	}
+	h.writeJSON(w, http.StatusOK, resp)
+}
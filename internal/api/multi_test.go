@@ -0,0 +1,52 @@
+package signerapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleSignMultiPartialFailure(t *testing.T) {
+	handler := NewHTTPHandler(newBatchBackend(), WithBatchConfig(BatchConfig{MaxItems: 4, Concurrency: 4}))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+	digest := strings.Repeat("07", 32)
+	post := func(payload string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/sign/multi", strings.NewReader(payload)))
+		return rr
+	}
+
+	rr := post(`{"keyIds":["escrow-a","unknown","escrow-b","locked"],"digest":"` + digest + `"}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var body multiSignResponseBody
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	require.Equal(t, 2, body.Succeeded)
+	require.Equal(t, 2, body.Failed)
+	require.Len(t, body.Results, 4)
+	for i, key := range []string{"escrow-a", "unknown", "escrow-b", "locked"} {
+		require.Equal(t, key, body.Results[i].KeyID)
+	}
+	require.Equal(t, encodeSignature([]byte("escrow-a:\x07")), body.Results[0].Signature)
+	require.Equal(t, encodeSignature([]byte("escrow-b:\x07")), body.Results[2].Signature)
+	require.Equal(t, string(apierrors.CodeInvalidKey), body.Results[1].Error.Code)
+	require.Equal(t, string(apierrors.CodeUnlockRequired), body.Results[3].Error.Code)
+	require.NotEmpty(t, body.Results[3].Error.RetryAfterHint)
+
+	// 摘要非法、keyIds 为空/重复/超限时整体拒绝。
+	for _, payload := range []string{
+		`{"keyIds":["a"],"digest":"zz"}`,
+		`{"keyIds":[],"digest":"` + digest + `"}`,
+		`{"keyIds":["a","a"],"digest":"` + digest + `"}`,
+		`{"keyIds":["a",""],"digest":"` + digest + `"}`,
+		`{"keyIds":["a","b","c","d","e"],"digest":"` + digest + `"}`,
+	} {
+		rr = post(payload)
+		require.Equal(t, http.StatusBadRequest, rr.Code, payload)
+	}
+}
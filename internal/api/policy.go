@@ -0,0 +1,97 @@
+package signerapi
+
+import (
+	"context"
+	"log/slog"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/internal/policy"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+)
+
+// PolicyConfig 配置 PolicyMiddleware。
+type PolicyConfig struct {
+	Policy *policy.Policy
+	Logger *slog.Logger
+}
+
+// PolicyMiddleware 按租户策略校验每次调用：Sign/GetPublicKey/DisableKey 要求 keyId 属于该租户，
+// Create/ImportKey 要求租户已声明策略；拒绝时返回 PERMISSION_DENIED。Policy 为空时返回 nil，由 Chain 跳过。
+func PolicyMiddleware(cfg PolicyConfig) BackendMiddleware {
+	if cfg.Policy == nil {
+		return nil
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	authorize := func(ctx context.Context, op string, audit *signerv1.AuditContext, keyID string) error {
+		tenant, err := requestTenant(ctx, audit)
+		if err == nil {
+			switch {
+			case tenant == "":
+				err = apierrors.New(apierrors.CodePermissionDenied, "tenant is required")
+			case keyID == "" && !cfg.Policy.HasTenant(tenant):
+				err = apierrors.New(apierrors.CodePermissionDenied, "tenant has no key policy")
+			case keyID != "" && !cfg.Policy.Allowed(tenant, keyID):
+				err = apierrors.New(apierrors.CodePermissionDenied, "key is not granted to tenant")
+			}
+		}
+		if err != nil {
+			cfg.Logger.WarnContext(ctx, "policy denied", "operation", op, "tenant", tenant, "key", keyID, "error", err)
+		}
+		return err
+	}
+	return func(next Backend) Backend {
+		return BackendFuncs{
+			Next: next,
+			CreateFunc: func(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+				if err := authorize(ctx, "create", req.GetAuditContext(), ""); err != nil {
+					return nil, err
+				}
+				return next.Create(ctx, req)
+			},
+			ImportFunc: func(ctx context.Context, req *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+				if err := authorize(ctx, "import", req.GetAuditContext(), ""); err != nil {
+					return nil, err
+				}
+				return next.ImportKey(ctx, req)
+			},
+			SignFunc: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+				if err := authorize(ctx, "sign", req.GetAuditContext(), req.GetKeyId()); err != nil {
+					return nil, err
+				}
+				return next.Sign(ctx, req)
+			},
+			PublicKeyFunc: func(ctx context.Context, req *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error) {
+				if err := authorize(ctx, "public_key", req.GetAuditContext(), req.GetKeyId()); err != nil {
+					return nil, err
+				}
+				return next.GetPublicKey(ctx, req)
+			},
+			DisableFunc: func(ctx context.Context, req *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+				if err := authorize(ctx, "disable", req.GetAuditContext(), req.GetKeyId()); err != nil {
+					return nil, err
+				}
+				return next.DisableKey(ctx, req)
+			},
+		}
+	}
+}
+
+// requestTenant 解析本次调用的租户：请求体 audit_context 优先于上下文；
+// 凭证绑定了租户时以凭证为准，请求声明的租户与之不一致视为越权。
+func requestTenant(ctx context.Context, audit *signerv1.AuditContext) (string, error) {
+	claimed := audit.GetTenantId()
+	if claimed == "" {
+		claimed, _ = reqctx.TenantIDFrom(ctx)
+	}
+	p, ok := reqctx.PrincipalFrom(ctx)
+	if !ok || p.TenantID == "" {
+		return claimed, nil
+	}
+	if claimed != "" && claimed != p.TenantID {
+		return p.TenantID, apierrors.New(apierrors.CodePermissionDenied, "tenant does not match credentials")
+	}
+	return p.TenantID, nil
+}
@@ -0,0 +1,79 @@
+package signerapi
+
+import (
+	"context"
+	"testing"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/internal/policy"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/stretchr/testify/require"
+)
+
+func requirePermissionDenied(t *testing.T, err error) {
+	t.Helper()
+	apiErr, ok := apierrors.FromError(err)
+	require.True(t, ok, err)
+	require.Equal(t, apierrors.CodePermissionDenied, apiErr.Code)
+}
+
+func TestPolicyMiddleware(t *testing.T) {
+	require.Nil(t, PolicyMiddleware(PolicyConfig{}))
+
+	p, err := policy.New([]policy.Rule{{TenantID: "a", KeyPrefixes: []string{"a/"}}})
+	require.NoError(t, err)
+	var calls int
+	stub := &stubBackend{
+		signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			calls++
+			return &signerv1.SignResponse{}, nil
+		},
+		createFn: func(context.Context, *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+			calls++
+			return &signerv1.CreateResponse{}, nil
+		},
+	}
+	backend := Chain(stub, PolicyMiddleware(PolicyConfig{Policy: p}))
+	tenantA := reqctx.WithTenantID(context.Background(), "a")
+
+	_, err = backend.Sign(tenantA, &signerv1.SignRequest{KeyId: "a/1"})
+	require.NoError(t, err)
+	_, err = backend.Create(tenantA, &signerv1.CreateRequest{})
+	require.NoError(t, err)
+
+	// 请求体中的 audit_context 同样受校验。
+	_, err = backend.Sign(context.Background(), &signerv1.SignRequest{KeyId: "a/1", AuditContext: &signerv1.AuditContext{TenantId: "a"}})
+	require.NoError(t, err)
+
+	_, err = backend.Sign(tenantA, &signerv1.SignRequest{KeyId: "b/1"})
+	requirePermissionDenied(t, err)
+	_, err = backend.Sign(context.Background(), &signerv1.SignRequest{KeyId: "a/1"})
+	requirePermissionDenied(t, err)
+	_, err = backend.Create(reqctx.WithTenantID(context.Background(), "b"), &signerv1.CreateRequest{})
+	requirePermissionDenied(t, err)
+	_, err = backend.DisableKey(tenantA, &signerv1.DisableKeyRequest{KeyId: "b/1"})
+	requirePermissionDenied(t, err)
+	require.Equal(t, 3, calls)
+}
+
+func TestPolicyMiddlewarePrefersCredentialTenant(t *testing.T) {
+	p, err := policy.New([]policy.Rule{
+		{TenantID: "a", KeyPrefixes: []string{"a/"}},
+		{TenantID: "b", KeyPrefixes: []string{"b/"}},
+	})
+	require.NoError(t, err)
+	backend := Chain(&stubBackend{signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		return &signerv1.SignResponse{}, nil
+	}}, PolicyMiddleware(PolicyConfig{Policy: p}))
+	ctx := withAuthenticated(context.Background(), reqctx.Principal{Subject: "svc-a", TenantID: "a"})
+
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "a/1"})
+	require.NoError(t, err)
+
+	// 凭证绑定租户 a 时，请求声明租户 b 不能借此使用 b 的 key。
+	_, err = backend.Sign(reqctx.WithTenantID(ctx, "b"), &signerv1.SignRequest{KeyId: "b/1"})
+	requirePermissionDenied(t, err)
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "b/1", AuditContext: &signerv1.AuditContext{TenantId: "b"}})
+	requirePermissionDenied(t, err)
+}
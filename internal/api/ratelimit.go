@@ -0,0 +1,222 @@
+package signerapi
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+const defaultRateLimitIdleTTL = 10 * time.Minute
+
+// RateLimit 是单个令牌桶的速率与突发容量；Rate<=0 表示不限流。
+type RateLimit struct {
+	// Rate 为每秒补充的令牌数。
+	Rate float64
+	// Burst 为桶容量，默认取 Rate 向上取整。
+	Burst int
+}
+
+func (l RateLimit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return int(math.Ceil(l.Rate))
+}
+
+// SignRateLimitConfig 配置签名路径按租户与按 key 的限流。
+type SignRateLimitConfig struct {
+	// Tenant 为每个租户的默认限额，未携带租户的请求共享一个桶。
+	Tenant RateLimit
+	// TenantOverrides 覆盖指定租户的限额。
+	TenantOverrides map[string]RateLimit
+	// Key 为每个 keyId 的限额。
+	Key RateLimit
+	// IdleTTL 为桶闲置多久后回收，默认 10m；回收后的桶以满容量重建。
+	IdleTTL time.Duration
+
+	Registerer     prometheus.Registerer
+	MetricsOptions metricsopts.Options
+	// Now 默认 time.Now。
+	Now func() time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// SignRateLimiter 为每个租户与 keyId 维护令牌桶，防止单个调用方占满 Enclave。nil 表示关闭。
+type SignRateLimiter struct {
+	cfg SignRateLimitConfig
+
+	mu        sync.Mutex
+	tenants   map[string]*bucket
+	keys      map[string]*bucket
+	lastSweep time.Time
+
+	rejected *prometheus.CounterVec
+	buckets  *prometheus.GaugeVec
+}
+
+// NewSignRateLimiter 构造 SignRateLimiter，租户与 key 均未配置限额时返回 nil。
+func NewSignRateLimiter(cfg SignRateLimitConfig) (*SignRateLimiter, error) {
+	if cfg.Tenant.Rate <= 0 && cfg.Key.Rate <= 0 && len(cfg.TenantOverrides) == 0 {
+		return nil, nil
+	}
+	if cfg.IdleTTL <= 0 {
+		cfg.IdleTTL = defaultRateLimitIdleTTL
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	reg := cfg.Registerer
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	opts := cfg.MetricsOptions.WithDefaults("signer", "ratelimit")
+	l := &SignRateLimiter{
+		cfg:       cfg,
+		tenants:   make(map[string]*bucket),
+		keys:      make(map[string]*bucket),
+		lastSweep: cfg.Now(),
+		rejected: prometheus.NewCounterVec(opts.Counter("rejected_total",
+			"Number of sign requests rejected by rate limiting by scope"), []string{"scope"}),
+		buckets: prometheus.NewGaugeVec(opts.Gauge("buckets",
+			"Number of live rate limit buckets by scope"), []string{"scope"}),
+	}
+	if err := metricsopts.Register(reg, l.rejected, l.buckets); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// reserve 从租户与 key 的桶中各取一个令牌；任一不足时全部退还并返回需等待的时长与受限范围。
+func (l *SignRateLimiter) reserve(tenant, keyID string) (time.Duration, string) {
+	now := l.cfg.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweepLocked(now)
+
+	var (
+		reservations []*rate.Reservation
+		wait         time.Duration
+		scope        string
+	)
+	if limit, ok := l.tenantLimit(tenant); ok {
+		r := l.bucketLocked("tenant", l.tenants, tenant, limit, now).ReserveN(now, 1)
+		reservations = append(reservations, r)
+		if d := r.DelayFrom(now); d > 0 {
+			wait, scope = d, "tenant"
+		}
+	}
+	if l.cfg.Key.Rate > 0 && keyID != "" {
+		r := l.bucketLocked("key", l.keys, keyID, l.cfg.Key, now).ReserveN(now, 1)
+		reservations = append(reservations, r)
+		if d := r.DelayFrom(now); d > wait {
+			wait, scope = d, "key"
+		}
+	}
+	if wait > 0 {
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+	}
+	return wait, scope
+}
+
+func (l *SignRateLimiter) tenantLimit(tenant string) (RateLimit, bool) {
+	if limit, ok := l.cfg.TenantOverrides[tenant]; ok {
+		return limit, limit.Rate > 0
+	}
+	return l.cfg.Tenant, l.cfg.Tenant.Rate > 0
+}
+
+func (l *SignRateLimiter) bucketLocked(scope string, buckets map[string]*bucket, id string, limit RateLimit, now time.Time) *rate.Limiter {
+	b, ok := buckets[id]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.burst())}
+		buckets[id] = b
+		l.buckets.WithLabelValues(scope).Set(float64(len(buckets)))
+	}
+	b.lastSeen = now
+	return b.limiter
+}
+
+// sweepLocked 每隔 IdleTTL 回收闲置的桶，避免 keyId 数量无界增长。
+func (l *SignRateLimiter) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < l.cfg.IdleTTL {
+		return
+	}
+	l.lastSweep = now
+	for scope, buckets := range map[string]map[string]*bucket{"tenant": l.tenants, "key": l.keys} {
+		for id, b := range buckets {
+			if now.Sub(b.lastSeen) >= l.cfg.IdleTTL {
+				delete(buckets, id)
+			}
+		}
+		l.buckets.WithLabelValues(scope).Set(float64(len(buckets)))
+	}
+}
+
+// RateLimitMiddleware 对 Sign 按租户与 keyId 限流，超限返回带 Retry-After 的 RETRY_LATER；l 为 nil 时返回 nil，由 Chain 跳过。
+func RateLimitMiddleware(l *SignRateLimiter) BackendMiddleware {
+	if l == nil {
+		return nil
+	}
+	return func(next Backend) Backend {
+		return BackendFuncs{
+			Next: next,
+			SignFunc: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+				// 租户与凭证不一致由 PolicyMiddleware 拒绝，这里只取用于计数的租户。
+				tenant, _ := requestTenant(ctx, req.GetAuditContext())
+				if wait, scope := l.reserve(tenant, req.GetKeyId()); wait > 0 {
+					l.rejected.WithLabelValues(scope).Inc()
+					return nil, apierrors.New(apierrors.CodeRetryLater, scope+" sign rate limited").WithRetryAfter(wait)
+				}
+				return next.Sign(ctx, req)
+			},
+		}
+	}
+}
+
+// ParseTenantRateOverrides 解析 tenant:rate[:burst][,tenant:rate[:burst]] 形式的租户限额，rate 为 0 表示该租户不限流。
+func ParseTenantRateOverrides(raw string) (map[string]RateLimit, error) {
+	overrides := make(map[string]RateLimit)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, ":")
+		if len(fields) < 2 || len(fields) > 3 || fields[0] == "" {
+			return nil, fmt.Errorf("invalid tenant rate override %q, want tenant:rate[:burst]", part)
+		}
+		var (
+			limit RateLimit
+			err   error
+		)
+		if limit.Rate, err = strconv.ParseFloat(fields[1], 64); err != nil || limit.Rate < 0 {
+			return nil, fmt.Errorf("invalid rate in tenant rate override %q", part)
+		}
+		if len(fields) == 3 {
+			if limit.Burst, err = strconv.Atoi(fields[2]); err != nil || limit.Burst <= 0 {
+				return nil, fmt.Errorf("invalid burst in tenant rate override %q", part)
+			}
+		}
+		if _, dup := overrides[fields[0]]; dup {
+			return nil, fmt.Errorf("tenant rate override for %q is declared twice", fields[0])
+		}
+		overrides[fields[0]] = limit
+	}
+	return overrides, nil
+}
@@ -0,0 +1,135 @@
+package signerapi
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func newRateLimitedBackend(t *testing.T, cfg SignRateLimitConfig) (Backend, *SignRateLimiter, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	cfg.Now = clock.Now
+	cfg.Registerer = prometheus.NewRegistry()
+	limiter, err := NewSignRateLimiter(cfg)
+	require.NoError(t, err)
+	backend := Chain(&stubBackend{signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		return &signerv1.SignResponse{}, nil
+	}}, RateLimitMiddleware(limiter))
+	return backend, limiter, clock
+}
+
+func requireRetryLater(t *testing.T, err error, wantRetry time.Duration) {
+	t.Helper()
+	apiErr, ok := apierrors.FromError(err)
+	require.True(t, ok, err)
+	require.Equal(t, apierrors.CodeRetryLater, apiErr.Code)
+	require.Equal(t, wantRetry, apiErr.RetryAfter())
+}
+
+func TestRateLimitMiddlewarePerTenant(t *testing.T) {
+	backend, limiter, clock := newRateLimitedBackend(t, SignRateLimitConfig{
+		Tenant:          RateLimit{Rate: 2},
+		TenantOverrides: map[string]RateLimit{"vip": {Rate: 0}},
+	})
+	tenantA := reqctx.WithTenantID(context.Background(), "a")
+	tenantB := reqctx.WithTenantID(context.Background(), "b")
+	vip := reqctx.WithTenantID(context.Background(), "vip")
+
+	for i := 0; i < 2; i++ {
+		_, err := backend.Sign(tenantA, &signerv1.SignRequest{KeyId: "k1"})
+		require.NoError(t, err)
+	}
+	_, err := backend.Sign(tenantA, &signerv1.SignRequest{KeyId: "k2"})
+	requireRetryLater(t, err, 500*time.Millisecond)
+
+	// 其他租户与不限流的租户不受影响。
+	_, err = backend.Sign(tenantB, &signerv1.SignRequest{KeyId: "k1"})
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, err = backend.Sign(vip, &signerv1.SignRequest{KeyId: "k1"})
+		require.NoError(t, err)
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	_, err = backend.Sign(tenantA, &signerv1.SignRequest{KeyId: "k1"})
+	require.NoError(t, err)
+	require.Equal(t, 1.0, testutil.ToFloat64(limiter.rejected.WithLabelValues("tenant")))
+}
+
+func TestRateLimitMiddlewarePerKeyRefundsTenant(t *testing.T) {
+	backend, limiter, _ := newRateLimitedBackend(t, SignRateLimitConfig{
+		Tenant: RateLimit{Rate: 1, Burst: 2},
+		Key:    RateLimit{Rate: 1},
+	})
+	ctx := reqctx.WithTenantID(context.Background(), "a")
+
+	_, err := backend.Sign(ctx, &signerv1.SignRequest{KeyId: "hot"})
+	require.NoError(t, err)
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "hot"})
+	requireRetryLater(t, err, time.Second)
+	// key 超限的请求不消耗租户令牌。
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "cold"})
+	require.NoError(t, err)
+	require.Equal(t, 1.0, testutil.ToFloat64(limiter.rejected.WithLabelValues("key")))
+}
+
+func TestSignRateLimiterEvictsIdleBuckets(t *testing.T) {
+	backend, limiter, clock := newRateLimitedBackend(t, SignRateLimitConfig{
+		Key:     RateLimit{Rate: 1},
+		IdleTTL: time.Minute,
+	})
+	for _, key := range []string{"k1", "k2", "k3"} {
+		_, err := backend.Sign(context.Background(), &signerv1.SignRequest{KeyId: key})
+		require.NoError(t, err)
+	}
+	require.Equal(t, 3.0, testutil.ToFloat64(limiter.buckets.WithLabelValues("key")))
+
+	clock.Advance(time.Minute)
+	_, err := backend.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k1"})
+	require.NoError(t, err)
+	require.Len(t, limiter.keys, 1)
+}
+
+func TestNewSignRateLimiterDisabled(t *testing.T) {
+	limiter, err := NewSignRateLimiter(SignRateLimitConfig{})
+	require.NoError(t, err)
+	require.Nil(t, limiter)
+	require.Nil(t, RateLimitMiddleware(nil))
+}
+
+func TestParseTenantRateOverrides(t *testing.T) {
+	overrides, err := ParseTenantRateOverrides("a:100, b:0.5:3,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]RateLimit{"a": {Rate: 100}, "b": {Rate: 0.5, Burst: 3}}, overrides)
+
+	for _, raw := range []string{"a", ":1", "a:x", "a:-1", "a:1:0", "a:1:2:3", "a:1,a:2"} {
+		_, err := ParseTenantRateOverrides(raw)
+		require.Error(t, err, raw)
+	}
+}
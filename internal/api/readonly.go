@@ -0,0 +1,106 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+)
+
+// ReadOnlyMode 是运行时只读开关：开启后拒绝新建 key，签名路径不受影响。
+type ReadOnlyMode struct {
+	enabled   atomic.Bool
+	changedAt atomic.Int64
+}
+
+type readOnlyState struct {
+	ReadOnly  bool      `json:"readOnly"`
+	ChangedAt time.Time `json:"changedAt,omitempty"`
+}
+
+type readOnlyUpdate struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// NewReadOnlyMode 以初始状态构造开关。
+func NewReadOnlyMode(enabled bool) *ReadOnlyMode {
+	m := &ReadOnlyMode{}
+	m.enabled.Store(enabled)
+	return m
+}
+
+// Enabled 返回当前是否处于只读模式，nil 视为关闭。
+func (m *ReadOnlyMode) Enabled() bool {
+	return m != nil && m.enabled.Load()
+}
+
+// Set 切换只读模式，返回状态是否发生变化。
+func (m *ReadOnlyMode) Set(enabled bool) bool {
+	if m.enabled.Swap(enabled) == enabled {
+		return false
+	}
+	m.changedAt.Store(time.Now().UnixNano())
+	return true
+}
+
+func (m *ReadOnlyMode) state() readOnlyState {
+	st := readOnlyState{ReadOnly: m.Enabled()}
+	if m != nil {
+		if ts := m.changedAt.Load(); ts > 0 {
+			st.ChangedAt = time.Unix(0, ts).UTC()
+		}
+	}
+	return st
+}
+
+// ServeHTTP 处理管理端点：GET 查询当前状态，POST `{"enabled":bool}` 即时切换。
+func (m *ReadOnlyMode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var body readOnlyUpdate
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Enabled == nil {
+			writeJSONResponse(w, http.StatusBadRequest, errorResponse{
+				Code:    string(apierrors.CodeInvalidArgument),
+				Message: `body must be {"enabled": true|false}`,
+			})
+			return
+		}
+		m.Set(*body.Enabled)
+	default:
+		writeJSONResponse(w, http.StatusBadRequest, errorResponse{
+			Code:    string(apierrors.CodeInvalidArgument),
+			Message: "GET or POST required",
+		})
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, m.state())
+}
+
+// ReadOnlyMiddleware 在只读模式下拒绝 Create 与 ImportKey，返回 READ_ONLY；Sign 直接透传。
+func ReadOnlyMiddleware(m *ReadOnlyMode) BackendMiddleware {
+	return func(next Backend) Backend {
+		if m == nil {
+			return next
+		}
+		return BackendFuncs{
+			Next: next,
+			CreateFunc: func(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+				if m.Enabled() {
+					return nil, apierrors.New(apierrors.CodeReadOnly, "signer is in read-only mode: key creation is disabled, signing remains available")
+				}
+				return next.Create(ctx, req)
+			},
+			ImportFunc: func(ctx context.Context, req *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+				if m.Enabled() {
+					return nil, apierrors.New(apierrors.CodeReadOnly, "signer is in read-only mode: key import is disabled, signing remains available")
+				}
+				return next.ImportKey(ctx, req)
+			},
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestReadOnlyModeRejectsCreateOnBothTransports(t *testing.T) {
+	mode := NewReadOnlyMode(false)
+	backend := Chain(&stubBackend{}, ReadOnlyMiddleware(mode))
+	httpHandler := NewHTTPHandler(backend)
+	grpcServer := NewGRPCServer(backend, nil)
+
+	admin := httptest.NewRecorder()
+	mode.ServeHTTP(admin, httptest.NewRequest(http.MethodPost, "/admin/readonly", strings.NewReader(`{"enabled":true}`)))
+	require.Equal(t, http.StatusOK, admin.Code)
+	require.Contains(t, admin.Body.String(), `"readOnly":true`)
+
+	rr := httptest.NewRecorder()
+	httpHandler.handleCreate(rr, httptest.NewRequest(http.MethodPost, "/create", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	var body errorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	require.Equal(t, string(apierrors.CodeReadOnly), body.Code)
+
+	_, err := grpcServer.Create(context.Background(), &signerv1.CreateRequest{})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	// 签名路径不受影响。
+	_, err = grpcServer.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k1", Digest: repeatBytes(0x01, 32)})
+	require.NoError(t, err)
+	rr = httptest.NewRecorder()
+	httpHandler.handleSign(rr, httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(`{"keyId":"k1","digest":"`+strings.Repeat("a", 64)+`"}`)))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	mode.Set(false)
+	_, err = grpcServer.Create(context.Background(), &signerv1.CreateRequest{})
+	require.NoError(t, err)
+	rr = httptest.NewRecorder()
+	httpHandler.handleCreate(rr, httptest.NewRequest(http.MethodPost, "/create", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestStatusHandlerReportsReadOnly(t *testing.T) {
+	mode := NewReadOnlyMode(true)
+	mux := http.NewServeMux()
+	NewStatusHandler(BuildInfo{Version: "v1.2.3"}, mode).Register(mux)
+
+	for _, path := range []string{"/version", "/readyz"} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Contains(t, rr.Body.String(), `"readOnly":true`, path)
+	}
+
+	mode.Set(false)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/version", nil))
+	require.Contains(t, rr.Body.String(), `"version":"v1.2.3"`)
+	require.Contains(t, rr.Body.String(), `"readOnly":false`)
+}
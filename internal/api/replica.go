@@ -0,0 +1,84 @@
+package signerapi
+
+import (
+	"context"
+	"errors"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+)
+
+// PlacementRecorder 由需要知道 key 落在哪个 Enclave 的 selector 实现；EnclaveBackend 在 Create/ImportKey 成功后调用。
+type PlacementRecorder interface {
+	RecordPlacement(ctx context.Context, keyID, target string)
+}
+
+// ReplicaSelector 让 Sign 优先路由到 key 的创建者（read-your-key），创建者不可用时改走副本；
+// 未登记归属的 key（如重启前创建的）沿用 StickySelector 的 hash 环路由。
+// 归属保存在与连接池共享健康状态的 enclaveclient.ReplicaGroup 中。
+type ReplicaSelector struct {
+	sticky   *StickySelector
+	group    *enclaveclient.ReplicaGroup
+	replicas int
+}
+
+// NewReplicaSelector 构造副本感知的 selector，replicas 为每个新 key 登记的副本数（取 hash 环上的后继目标）。
+func NewReplicaSelector(sticky *StickySelector, group *enclaveclient.ReplicaGroup, replicas int) (*ReplicaSelector, error) {
+	if sticky == nil || group == nil {
+		return nil, errors.New("replica selector requires sticky selector and replica group")
+	}
+	if replicas < 0 {
+		return nil, errors.New("replica count must not be negative")
+	}
+	return &ReplicaSelector{sticky: sticky, group: group, replicas: replicas}, nil
+}
+
+// SelectForCreate 沿用 StickySelector 的加权轮询。
+func (s *ReplicaSelector) SelectForCreate(ctx context.Context, req *signerv1.CreateRequest) (string, error) {
+	return s.sticky.SelectForCreate(ctx, req)
+}
+
+// SelectForSign 对已登记的 key 返回其主 Enclave 或首个可用副本，否则按 hash 环路由。
+func (s *ReplicaSelector) SelectForSign(ctx context.Context, req *signerv1.SignRequest) (string, error) {
+	if target, ok := s.group.Route(req.GetKeyId()); ok {
+		return target, nil
+	}
+	return s.sticky.SelectForSign(ctx, req)
+}
+
+// SelectAlternate 对已登记的 key 返回 primary 之外首个可用副本，供对冲与重试故障转移使用。
+func (s *ReplicaSelector) SelectAlternate(ctx context.Context, req *signerv1.SignRequest, primary string) (string, bool) {
+	if _, ok := s.group.Placement(req.GetKeyId()); ok {
+		return s.group.Replica(req.GetKeyId(), primary)
+	}
+	return s.sticky.SelectAlternate(ctx, req, primary)
+}
+
+// RecordPlacement 将 target 登记为 key 的主 Enclave，并取 hash 环上跳过 target 的后继目标作为副本。
+func (s *ReplicaSelector) RecordPlacement(_ context.Context, keyID, target string) {
+	if keyID == "" || target == "" {
+		return
+	}
+	s.group.Assign(keyID, enclaveclient.Placement{Primary: target, Replicas: s.sticky.successors(keyID, target, s.replicas)})
+}
+
+// Placement 返回 key 的归属，供管理与排障使用。
+func (s *ReplicaSelector) Placement(keyID string) (enclaveclient.Placement, bool) {
+	return s.group.Placement(keyID)
+}
+
+// successors 按 hash 环顺序返回 key 的前 n 个目标，跳过 exclude。
+func (s *StickySelector) successors(key, exclude string, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	st := s.state.Load()
+	var out []string
+	st.ring.walk(key, func(t int) bool {
+		if id := st.targetIDs[t]; id != exclude {
+			out = append(out, id)
+		}
+		return len(out) == n
+	})
+	return out
+}
@@ -0,0 +1,63 @@
+package signerapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaSelectorRoutesSignToCreator(t *testing.T) {
+	ids := []string{"e1", "e2", "e3"}
+	pool := newMultiTargetPool(t, map[string]signerv1.SignerServiceServer{"e1": streamingServer{}, "e2": streamingServer{}, "e3": streamingServer{}})
+	sticky, err := NewStickySelector(ids, WithTargetHealth(pool))
+	require.NoError(t, err)
+	selector, err := NewReplicaSelector(sticky, enclaveclient.NewReplicaGroup(pool), 1)
+	require.NoError(t, err)
+	backend, err := NewEnclaveBackend(pool, selector)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// 让 Create 落在 hash 环首选目标之外，验证 Sign 跟随创建者而不是环。
+	ringTarget, err := sticky.SelectForSign(ctx, &signerv1.SignRequest{KeyId: "generated"})
+	require.NoError(t, err)
+	var creator string
+	for creator == "" || creator == ringTarget {
+		_, err = backend.Create(ctx, &signerv1.CreateRequest{})
+		require.NoError(t, err)
+		placement, ok := selector.Placement("generated")
+		require.True(t, ok)
+		creator = placement.Primary
+	}
+	placement, _ := selector.Placement("generated")
+	require.Len(t, placement.Replicas, 1)
+	require.NotEqual(t, creator, placement.Replicas[0])
+
+	req := &signerv1.SignRequest{KeyId: "generated", Digest: []byte("payload")}
+	target, err := selector.SelectForSign(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, creator, target)
+	alt, ok := selector.SelectAlternate(ctx, req, creator)
+	require.True(t, ok)
+	require.Equal(t, placement.Replicas[0], alt)
+
+	// 创建者不可用时改走副本。
+	require.NoError(t, pool.Drain(creator))
+	target, err = selector.SelectForSign(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, placement.Replicas[0], target)
+	resp, err := backend.Sign(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []byte("payload"), resp.GetSignature())
+
+	// 未登记的 key 沿用 hash 环。
+	unknown := &signerv1.SignRequest{KeyId: "legacy"}
+	want, _ := sticky.SelectForSign(ctx, unknown)
+	got, err := selector.SelectForSign(ctx, unknown)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
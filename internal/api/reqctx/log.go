@@ -0,0 +1,48 @@
+package reqctx
+
+import (
+	"context"
+	"log/slog"
+)
+
+// RequestIDLogKey 是日志中请求 ID 的属性名。
+const RequestIDLogKey = "request_id"
+
+// LogHandler 包装 slog.Handler，为带请求 ID 的上下文日志自动附加 request_id；
+// 记录已显式携带 request_id 时不重复添加。
+type LogHandler struct {
+	slog.Handler
+}
+
+// NewLogHandler 构造 LogHandler。
+func NewLogHandler(h slog.Handler) *LogHandler {
+	return &LogHandler{Handler: h}
+}
+
+// Handle 实现 slog.Handler。
+func (h *LogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if requestID, ok := RequestIDFrom(ctx); ok && !hasAttr(r, RequestIDLogKey) {
+		r = r.Clone()
+		r.AddAttrs(slog.String(RequestIDLogKey, requestID))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs 实现 slog.Handler。
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup 实现 slog.Handler。
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+func hasAttr(r slog.Record, key string) bool {
+	found := false
+	r.Attrs(func(a slog.Attr) bool {
+		found = a.Key == key
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,166 @@
+// Package reqctx 集中定义请求上下文中的租户、调用方、keyspace 与请求 ID，
+// 避免各处自定义 context key。
+package reqctx
+
+import (
+	"context"
+	"fmt"
+)
+
+type ctxKey int
+
+const (
+	principalKey ctxKey = iota
+	tenantIDKey
+	keyspaceKey
+	requestIDKey
+	unlockRequestIDKey
+	idempotencyKeyKey
+)
+
+// Principal 描述已认证的调用方。
+type Principal struct {
+	Subject string
+	Roles   []string
+	// TenantID 是凭证绑定的租户，为空表示凭证不限定租户。
+	TenantID string
+}
+
+// clone 深拷贝 Roles，保证写入与读出的值互不影响。
+func (p Principal) clone() Principal {
+	if p.Roles != nil {
+		p.Roles = append([]string(nil), p.Roles...)
+	}
+	return p
+}
+
+// WithPrincipal 写入调用方，Roles 会被复制。
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p.clone())
+}
+
+// PrincipalFrom 读取调用方，返回值可安全修改。
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey).(Principal)
+	if !ok || p.Subject == "" {
+		return Principal{}, false
+	}
+	return p.clone(), true
+}
+
+// WithTenantID 写入租户 ID，空值不写入。
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return withString(ctx, tenantIDKey, tenantID)
+}
+
+// TenantIDFrom 读取租户 ID。
+func TenantIDFrom(ctx context.Context) (string, bool) {
+	return stringFrom(ctx, tenantIDKey)
+}
+
+// WithKeyspace 写入 keyspace，空值不写入。
+func WithKeyspace(ctx context.Context, keyspace string) context.Context {
+	return withString(ctx, keyspaceKey, keyspace)
+}
+
+// KeyspaceFrom 读取 keyspace。
+func KeyspaceFrom(ctx context.Context) (string, bool) {
+	return stringFrom(ctx, keyspaceKey)
+}
+
+// WithRequestID 写入调用方提供的请求 ID（审计用），空值不写入。
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return withString(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFrom 读取请求 ID。
+func RequestIDFrom(ctx context.Context) (string, bool) {
+	return stringFrom(ctx, requestIDKey)
+}
+
+// WithUnlockRequestID 写入异步解锁任务 ID，空值不写入。
+func WithUnlockRequestID(ctx context.Context, requestID string) context.Context {
+	return withString(ctx, unlockRequestIDKey, requestID)
+}
+
+// UnlockRequestIDFrom 读取异步解锁任务 ID。
+func UnlockRequestIDFrom(ctx context.Context) (string, bool) {
+	return stringFrom(ctx, unlockRequestIDKey)
+}
+
+// WithIdempotencyKey 写入调用方提供的幂等键，空值不写入。
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return withString(ctx, idempotencyKeyKey, key)
+}
+
+// IdempotencyKeyFrom 读取幂等键。
+func IdempotencyKeyFrom(ctx context.Context) (string, bool) {
+	return stringFrom(ctx, idempotencyKeyKey)
+}
+
+func withString(ctx context.Context, key ctxKey, value string) context.Context {
+	if value == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, key, value)
+}
+
+func stringFrom(ctx context.Context, key ctxKey) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	v, ok := ctx.Value(key).(string)
+	return v, ok && v != ""
+}
+
+// MissingError 表示 Strict 模式下必需的上下文值缺失。
+type MissingError struct {
+	Field string
+}
+
+func (e *MissingError) Error() string {
+	return fmt.Sprintf("reqctx: %s is required", e.Field)
+}
+
+// Reader 按统一策略读取上下文：Strict 时缺值返回 *MissingError，否则回退到默认值。
+type Reader struct {
+	Strict          bool
+	DefaultTenantID string
+	DefaultKeyspace string
+}
+
+// TenantID 读取租户 ID。
+func (r Reader) TenantID(ctx context.Context) (string, error) {
+	return r.read(ctx, tenantIDKey, "tenantId", r.DefaultTenantID)
+}
+
+// Keyspace 读取 keyspace。
+func (r Reader) Keyspace(ctx context.Context) (string, error) {
+	return r.read(ctx, keyspaceKey, "keyspace", r.DefaultKeyspace)
+}
+
+// RequestID 读取请求 ID，非 Strict 模式缺值时返回空串。
+func (r Reader) RequestID(ctx context.Context) (string, error) {
+	return r.read(ctx, requestIDKey, "requestId", "")
+}
+
+// Principal 读取调用方，非 Strict 模式缺值时返回零值。
+func (r Reader) Principal(ctx context.Context) (Principal, error) {
+	if p, ok := PrincipalFrom(ctx); ok {
+		return p, nil
+	}
+	if r.Strict {
+		return Principal{}, &MissingError{Field: "principal"}
+	}
+	return Principal{}, nil
+}
+
+func (r Reader) read(ctx context.Context, key ctxKey, field, def string) (string, error) {
+	if v, ok := stringFrom(ctx, key); ok {
+		return v, nil
+	}
+	if r.Strict {
+		return "", &MissingError{Field: field}
+	}
+	return def, nil
+}
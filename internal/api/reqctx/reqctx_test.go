@@ -0,0 +1,96 @@
+package reqctx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringValuesPresenceAndAbsence(t *testing.T) {
+	ctx := context.Background()
+	_, ok := TenantIDFrom(ctx)
+	require.False(t, ok)
+
+	ctx = WithTenantID(ctx, "tenant-a")
+	ctx = WithKeyspace(ctx, "prod")
+	ctx = WithRequestID(ctx, "req-1")
+	ctx = WithUnlockRequestID(ctx, "unlock-1")
+	ctx = WithIdempotencyKey(ctx, "idem-1")
+
+	tenant, ok := TenantIDFrom(ctx)
+	require.True(t, ok)
+	require.Equal(t, "tenant-a", tenant)
+	keyspace, _ := KeyspaceFrom(ctx)
+	require.Equal(t, "prod", keyspace)
+	requestID, _ := RequestIDFrom(ctx)
+	require.Equal(t, "req-1", requestID)
+	unlockID, _ := UnlockRequestIDFrom(ctx)
+	require.Equal(t, "unlock-1", unlockID)
+	idemKey, _ := IdempotencyKeyFrom(ctx)
+	require.Equal(t, "idem-1", idemKey)
+
+	// 空值不会覆盖已有值。
+	require.Equal(t, ctx, WithTenantID(ctx, ""))
+}
+
+func TestPrincipalIsCopied(t *testing.T) {
+	roles := []string{"signer"}
+	ctx := WithPrincipal(context.Background(), Principal{Subject: "svc-a", Roles: roles})
+	roles[0] = "admin"
+
+	p, ok := PrincipalFrom(ctx)
+	require.True(t, ok)
+	require.Equal(t, []string{"signer"}, p.Roles)
+
+	p.Roles[0] = "admin"
+	again, _ := PrincipalFrom(ctx)
+	require.Equal(t, []string{"signer"}, again.Roles)
+
+	_, ok = PrincipalFrom(context.Background())
+	require.False(t, ok)
+}
+
+func TestReaderStrictMode(t *testing.T) {
+	ctx := context.Background()
+	lenient := Reader{DefaultTenantID: "default", DefaultKeyspace: "default"}
+	tenant, err := lenient.TenantID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "default", tenant)
+	p, err := lenient.Principal(ctx)
+	require.NoError(t, err)
+	require.Empty(t, p.Subject)
+
+	strict := Reader{Strict: true, DefaultTenantID: "default"}
+	_, err = strict.TenantID(ctx)
+	var missing *MissingError
+	require.True(t, errors.As(err, &missing))
+	require.Equal(t, "tenantId", missing.Field)
+	_, err = strict.Principal(ctx)
+	require.Error(t, err)
+
+	keyspace, err := strict.Keyspace(WithKeyspace(ctx, "prod"))
+	require.NoError(t, err)
+	require.Equal(t, "prod", keyspace)
+}
+
+func TestLogHandlerAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewLogHandler(slog.NewTextHandler(&buf, nil))).With("component", "test")
+	ctx := WithRequestID(context.Background(), "req-1")
+
+	logger.InfoContext(ctx, "with id")
+	logger.InfoContext(ctx, "explicit", RequestIDLogKey, "req-1")
+	logger.Info("no context")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[0], "request_id=req-1")
+	require.Contains(t, lines[0], "component=test")
+	require.Equal(t, 1, strings.Count(lines[1], "request_id="))
+	require.NotContains(t, lines[2], "request_id")
+}
@@ -0,0 +1,95 @@
+package signerapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// RequestIDHeader 是 HTTP 请求/响应中携带请求 ID 的头。
+	RequestIDHeader = "X-Request-Id"
+	// RequestIDMetadataKey 是 gRPC metadata 中携带请求 ID 的键。
+	RequestIDMetadataKey = "x-request-id"
+
+	maxRequestIDLen = 128
+)
+
+// RequestIDMiddleware 从 X-Request-Id 读取请求 ID，缺失或非法时生成新 ID，
+// 写入上下文并回显到响应头。body 中的 auditHeaders.requestId 仍优先。
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := requestIDOrNew(r.Header.Get(RequestIDHeader))
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(reqctx.WithRequestID(r.Context(), id)))
+	})
+}
+
+// RequestIDInterceptor 从 x-request-id metadata 读取或生成请求 ID，写入上下文并通过响应 header 回传。
+func RequestIDInterceptor() GRPCInterceptor {
+	extract := func(ctx context.Context) (context.Context, string) {
+		var raw string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(RequestIDMetadataKey); len(values) > 0 {
+				raw = values[0]
+			}
+		}
+		id := requestIDOrNew(raw)
+		return reqctx.WithRequestID(ctx, id), id
+	}
+	return GRPCInterceptor{
+		Unary: func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			ctx, id := extract(ctx)
+			_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadataKey, id))
+			return handler(ctx, req)
+		},
+		Stream: func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, id := extract(ss.Context())
+			_ = ss.SetHeader(metadata.Pairs(RequestIDMetadataKey, id))
+			return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		},
+	}
+}
+
+// requestIDOrNew 接受长度不超过 128 的可打印 ASCII（不含空白），否则生成 32 位十六进制 ID。
+func requestIDOrNew(raw string) string {
+	if raw != "" && len(raw) <= maxRequestIDLen && isPrintableToken(raw) {
+		return raw
+	}
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
+
+func isPrintableToken(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] <= ' ' || s[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeAuditContext 以上下文中的请求 ID 与租户补齐 audit 中缺失的字段，keyspace 以上下文为准，
+// 不修改传入的 audit；无需改动时原样返回。
+func mergeAuditContext(ctx context.Context, audit *signerv1.AuditContext) *signerv1.AuditContext {
+	requestID, tenantID := audit.GetRequestId(), audit.GetTenantId()
+	if requestID == "" {
+		requestID, _ = reqctx.RequestIDFrom(ctx)
+	}
+	if tenantID == "" {
+		tenantID, _ = reqctx.TenantIDFrom(ctx)
+	}
+	// keyspace 只取父机派生值，调用方自带的一律丢弃。
+	keyspace, _ := reqctx.KeyspaceFrom(ctx)
+	if requestID == audit.GetRequestId() && tenantID == audit.GetTenantId() && keyspace == audit.GetKeyspace() {
+		return audit
+	}
+	return &signerv1.AuditContext{RequestId: requestID, TenantId: tenantID, Keyspace: keyspace}
+}
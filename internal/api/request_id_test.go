@@ -0,0 +1,96 @@
+package signerapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := RequestIDMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		seen, _ = reqctx.RequestIDFrom(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "client-123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, "client-123", seen)
+	require.Equal(t, "client-123", rr.Header().Get(RequestIDHeader))
+
+	for _, raw := range []string{"", "has space", strings.Repeat("a", maxRequestIDLen+1)} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, raw)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		require.Len(t, seen, 32, raw)
+		require.Equal(t, seen, rr.Header().Get(RequestIDHeader))
+	}
+}
+
+func TestRequestIDInterceptorPropagates(t *testing.T) {
+	registry := NewInterceptorRegistry()
+	registry.Register(InterceptorRequestID, RequestIDInterceptor())
+	var seen string
+	client := dialIntercepted(t, registry, []string{InterceptorRequestID}, &stubBackend{
+		signFn: func(ctx context.Context, _ *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			seen, _ = reqctx.RequestIDFrom(ctx)
+			return &signerv1.SignResponse{}, nil
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req := &signerv1.SignRequest{KeyId: "k1", Digest: repeatBytes(0x01, 32)}
+
+	var header metadata.MD
+	_, err := client.Sign(metadata.AppendToOutgoingContext(ctx, RequestIDMetadataKey, "rpc-1"), req, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Equal(t, "rpc-1", seen)
+	require.Equal(t, []string{"rpc-1"}, header.Get(RequestIDMetadataKey))
+
+	// 请求体中的 audit_context 优先于传输层 ID。
+	req.AuditContext = &signerv1.AuditContext{RequestId: "body-1"}
+	_, err = client.Sign(ctx, req, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Equal(t, "body-1", seen)
+	require.Len(t, header.Get(RequestIDMetadataKey)[0], 32)
+}
+
+// auditRecordingServer 在 GetPublicKey 中回显收到的审计字段与 metadata 请求 ID。
+type auditRecordingServer struct {
+	streamingServer
+}
+
+func (auditRecordingServer) GetPublicKey(ctx context.Context, req *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return &signerv1.CreateResponse{
+		KeyId:   req.GetAuditContext().GetRequestId() + "/" + req.GetAuditContext().GetTenantId(),
+		Address: strings.Join(md.Get(RequestIDMetadataKey), ","),
+	}, nil
+}
+
+func TestEnclaveBackendForwardsRequestID(t *testing.T) {
+	pool, _, _ := newTestPoolWith(t, auditRecordingServer{})
+	backend, err := NewEnclaveBackend(pool, StaticTargetSelector{TargetID: "enclave-1"})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx = reqctx.WithRequestID(ctx, "req-9")
+
+	audit := &signerv1.AuditContext{TenantId: "t1"}
+	resp, err := backend.GetPublicKey(ctx, &signerv1.GetPublicKeyRequest{KeyId: "k1", AuditContext: audit})
+	require.NoError(t, err)
+	require.Equal(t, "req-9/t1", resp.GetKeyId())
+	require.Equal(t, "req-9", resp.GetAddress())
+	require.Empty(t, audit.GetRequestId(), "caller's AuditContext must not be mutated")
+}
@@ -0,0 +1,207 @@
+package signerapi
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/audit"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBudgetRatio = 0.1
+	defaultRetryBudgetBurst = 10
+	defaultRetryBackoff     = 10 * time.Millisecond
+)
+
+// RetryConfig 配置 Sign/Create 的请求级重试：Enclave RPC 以可重试状态码失败时换一条连接（能换目标时换目标）再试。
+type RetryConfig struct {
+	// Enabled 为 false 时不重试（默认）。
+	Enabled bool
+	// MaxAttempts 为含首次在内的最大尝试次数，默认 3。
+	MaxAttempts int
+	// Codes 为可重试的 gRPC 状态码，默认只有 Unavailable（连接重置、Enclave 重启等传输错误）。
+	Codes []codes.Code
+	// BudgetRatio 与 BudgetBurst 构成重试预算：每个请求存入 BudgetRatio 个令牌（上限 BudgetBurst），
+	// 每次重试消耗 1 个，Enclave 整体故障时重试量不超过请求量的 BudgetRatio 倍。默认 0.1 / 10。
+	BudgetRatio float64
+	BudgetBurst int
+	// Backoff 为两次尝试之间的等待时长（±50% 抖动），默认 10ms。
+	Backoff time.Duration
+	// SignFailover 为 true 时 Sign 重试改发到 hash 环上的备选 Enclave，仅在 key 密文已复制到备选目标时开启；
+	// 默认在同一目标上换连接重试。Create 重试总是重新轮询选择目标。
+	SignFailover bool
+	Registerer   prometheus.Registerer
+	// MetricsOptions 覆盖 retries_total / retry_budget_exhausted_total 的默认 signer / backend 前缀与常量标签。
+	MetricsOptions metricsopts.Options
+}
+
+// Retrier 决定失败的 Enclave 调用是否重试。nil 表示关闭，所有方法均可安全调用。
+type Retrier struct {
+	maxAttempts int
+	codes       []codes.Code
+	ratio       float64
+	burst       float64
+	backoff     time.Duration
+	failover    bool
+
+	retries   *prometheus.CounterVec
+	exhausted *prometheus.CounterVec
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+// NewRetrier 构造 Retrier，未启用时返回 nil。
+func NewRetrier(cfg RetryConfig) *Retrier {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if len(cfg.Codes) == 0 {
+		cfg.Codes = []codes.Code{codes.Unavailable}
+	}
+	if cfg.BudgetRatio <= 0 {
+		cfg.BudgetRatio = defaultRetryBudgetRatio
+	}
+	if cfg.BudgetBurst <= 0 {
+		cfg.BudgetBurst = defaultRetryBudgetBurst
+	}
+	if cfg.Backoff < 0 {
+		cfg.Backoff = 0
+	} else if cfg.Backoff == 0 {
+		cfg.Backoff = defaultRetryBackoff
+	}
+	reg := cfg.Registerer
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	opts := cfg.MetricsOptions.WithDefaults("signer", "backend")
+	r := &Retrier{
+		maxAttempts: cfg.MaxAttempts,
+		codes:       slices.Clone(cfg.Codes),
+		ratio:       cfg.BudgetRatio,
+		burst:       float64(cfg.BudgetBurst),
+		backoff:     cfg.Backoff,
+		failover:    cfg.SignFailover,
+		tokens:      float64(cfg.BudgetBurst),
+		retries: prometheus.NewCounterVec(opts.Counter("retries_total",
+			"Number of enclave calls retried after a retryable error"), []string{"method"}),
+		exhausted: prometheus.NewCounterVec(opts.Counter("retry_budget_exhausted_total",
+			"Number of retryable enclave errors returned because the retry budget was exhausted"), []string{"method"}),
+	}
+	metricsopts.MustRegister(reg, r.retries, r.exhausted)
+	return r
+}
+
+// ParseRetryCodes 解析逗号分隔的 gRPC 状态码名称（如 "UNAVAILABLE,RESOURCE_EXHAUSTED"）。
+func ParseRetryCodes(raw string) ([]codes.Code, error) {
+	var out []codes.Code
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.ToUpper(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		var c codes.Code
+		if err := c.UnmarshalJSON([]byte(strconv.Quote(part))); err != nil {
+			return nil, fmt.Errorf("invalid retry status code %q", part)
+		}
+		if c == codes.OK {
+			return nil, fmt.Errorf("status code %q is not retryable", part)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// begin 为一次新请求向预算存入令牌。
+func (r *Retrier) begin() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.tokens = min(r.tokens+r.ratio, r.burst)
+	r.mu.Unlock()
+}
+
+// retryable 判断 err 是否属于可重试的 RPC 错误；调用方取消（callerError 包裹）与借用失败不重试。
+func (r *Retrier) retryable(err error) bool {
+	st, ok := status.FromError(err)
+	return ok && slices.Contains(r.codes, st.Code())
+}
+
+// next 判断第 attempt 次尝试失败后是否重试；重试时消耗预算并完成退避，ctx 结束时返回 false。
+func (r *Retrier) next(ctx context.Context, method string, attempt int, err error) bool {
+	if r == nil || err == nil || attempt >= r.maxAttempts || ctx.Err() != nil || !r.retryable(err) {
+		return false
+	}
+	r.mu.Lock()
+	ok := r.tokens >= 1
+	if ok {
+		r.tokens--
+	}
+	r.mu.Unlock()
+	if !ok {
+		r.exhausted.WithLabelValues(method).Inc()
+		return false
+	}
+	if r.backoff > 0 {
+		timer := time.NewTimer(r.backoff/2 + time.Duration(rand.Int63n(int64(r.backoff))))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	r.retries.WithLabelValues(method).Inc()
+	return true
+}
+
+// retriedSign 执行 signOnce，失败可重试时在同一目标换连接重试；开启 SignFailover 时换到备选 Enclave。
+func (b *EnclaveBackend) retriedSign(ctx context.Context, target string, pinned bool, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+	b.retry.begin()
+	for attempt := 1; ; attempt++ {
+		resp, err := b.signOnce(ctx, target, req)
+		if !b.retry.next(ctx, "sign", attempt, err) {
+			return resp, err
+		}
+		if !pinned && b.retry.failover {
+			if alt, ok := b.retryAlternate(ctx, req, target); ok {
+				target = alt
+				audit.RecordTarget(ctx, target)
+			}
+		}
+		if b.budget.Check(ctx, target) != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryAlternate 返回 Sign 重试可用的备选目标；selector 不支持时为 false。
+func (b *EnclaveBackend) retryAlternate(ctx context.Context, req *signerv1.SignRequest, primary string) (string, bool) {
+	alt, ok := b.selector.(AlternateSelector)
+	if !ok {
+		return "", false
+	}
+	target, ok := alt.SelectAlternate(ctx, req, primary)
+	return target, ok && target != "" && target != primary
+}
+
+// WithRetries 为 Sign/Create 启用请求级重试；nil 表示关闭。
+func WithRetries(r *Retrier) EnclaveBackendOption {
+	return func(b *EnclaveBackend) { b.retry = r }
+}
@@ -0,0 +1,158 @@
+package signerapi
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/gateway/unlock"
+	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+)
+
+// RetryReason 表示 RETRY_LATER 的来源，决定退避提示的计算方式。
+type RetryReason string
+
+const (
+	RetryReasonUnknown       RetryReason = "unknown"
+	RetryReasonPoolSaturated RetryReason = "pool_saturated"
+	RetryReasonQueueFull     RetryReason = "unlock_queue_full"
+	RetryReasonRateLimited   RetryReason = "rate_limited"
+)
+
+const (
+	defaultRetryHintMin    = 50 * time.Millisecond
+	defaultRetryHintMax    = 5 * time.Second
+	defaultRetryHintJitter = 0.2
+)
+
+// PoolWaitStats 提供连接池 Acquire 等待时间的 p95。
+type PoolWaitStats interface {
+	AcquireWaitP95() time.Duration
+}
+
+// UnlockQueueStats 提供解锁队列深度与单任务耗时估计。
+type UnlockQueueStats interface {
+	QueueDepth() int
+	Workers() int
+	ItemLatency() time.Duration
+}
+
+// RateLimitStats 提供限流器下一次放行所需等待时间。
+type RateLimitStats interface {
+	RateLimitDelay() time.Duration
+}
+
+// RetryHintConfig 配置 RetryHintProvider，未配置的数据源按 Min 处理。
+type RetryHintConfig struct {
+	Pool    PoolWaitStats
+	Queue   UnlockQueueStats
+	Limiter RateLimitStats
+	Min     time.Duration
+	Max     time.Duration
+	Jitter  float64
+	// Rand 返回 [0,1) 随机数，便于测试固定抖动。
+	Rand func() float64
+}
+
+// RetryHintProvider 根据系统实时状态计算 Retry-After，避免客户端同一时刻重试。
+type RetryHintProvider struct {
+	cfg RetryHintConfig
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewRetryHintProvider 构造 RetryHintProvider。
+func NewRetryHintProvider(cfg RetryHintConfig) *RetryHintProvider {
+	if cfg.Min <= 0 {
+		cfg.Min = defaultRetryHintMin
+	}
+	if cfg.Max <= 0 {
+		cfg.Max = defaultRetryHintMax
+	}
+	if cfg.Max < cfg.Min {
+		cfg.Max = cfg.Min
+	}
+	if cfg.Jitter < 0 {
+		cfg.Jitter = 0
+	} else if cfg.Jitter == 0 {
+		cfg.Jitter = defaultRetryHintJitter
+	}
+	return &RetryHintProvider{
+		cfg: cfg,
+		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// ClassifyRetryReason 根据错误链判断 RETRY_LATER 的来源。
+func ClassifyRetryReason(err error) RetryReason {
+	switch {
+	case errors.Is(err, enclaveclient.ErrAcquireTimeout):
+		return RetryReasonPoolSaturated
+	case errors.Is(err, unlock.ErrQueueFull):
+		return RetryReasonQueueFull
+	case errors.Is(err, unlock.ErrRateLimited):
+		return RetryReasonRateLimited
+	default:
+		return RetryReasonUnknown
+	}
+}
+
+// HintForError 先分类错误再计算提示。
+func (p *RetryHintProvider) HintForError(err error) time.Duration {
+	return p.Hint(ClassifyRetryReason(err))
+}
+
+// Hint 计算指定来源的退避提示：基础值来自实时状态，叠加抖动后限制在 [Min, Max]。
+func (p *RetryHintProvider) Hint(reason RetryReason) time.Duration {
+	if p == nil {
+		return defaultRetryHintMin
+	}
+	base := p.base(reason)
+	if base < p.cfg.Min {
+		base = p.cfg.Min
+	}
+	if p.cfg.Jitter > 0 {
+		factor := 1 + p.cfg.Jitter*(2*p.random()-1)
+		base = time.Duration(float64(base) * factor)
+	}
+	if base < p.cfg.Min {
+		base = p.cfg.Min
+	}
+	if base > p.cfg.Max {
+		base = p.cfg.Max
+	}
+	return base
+}
+
+func (p *RetryHintProvider) base(reason RetryReason) time.Duration {
+	switch reason {
+	case RetryReasonPoolSaturated:
+		if p.cfg.Pool != nil {
+			return p.cfg.Pool.AcquireWaitP95()
+		}
+	case RetryReasonQueueFull:
+		if q := p.cfg.Queue; q != nil {
+			workers := q.Workers()
+			if workers <= 0 {
+				workers = 1
+			}
+			return time.Duration(q.QueueDepth()) * q.ItemLatency() / time.Duration(workers)
+		}
+	case RetryReasonRateLimited:
+		if p.cfg.Limiter != nil {
+			return p.cfg.Limiter.RateLimitDelay()
+		}
+	}
+	return p.cfg.Min
+}
+
+func (p *RetryHintProvider) random() float64 {
+	if p.cfg.Rand != nil {
+		return p.cfg.Rand()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rng.Float64()
+}
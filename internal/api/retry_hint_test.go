@@ -0,0 +1,86 @@
+package signerapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/gateway/unlock"
+	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePoolStats struct{ p95 time.Duration }
+
+func (f *fakePoolStats) AcquireWaitP95() time.Duration { return f.p95 }
+
+type fakeQueueStats struct {
+	depth   int
+	workers int
+	latency time.Duration
+}
+
+func (f *fakeQueueStats) QueueDepth() int            { return f.depth }
+func (f *fakeQueueStats) Workers() int               { return f.workers }
+func (f *fakeQueueStats) ItemLatency() time.Duration { return f.latency }
+
+func TestRetryHintGrowsWithLoad(t *testing.T) {
+	pool := &fakePoolStats{p95: 10 * time.Millisecond}
+	queue := &fakeQueueStats{depth: 10, workers: 4, latency: 40 * time.Millisecond}
+	hints := NewRetryHintProvider(RetryHintConfig{
+		Pool:   pool,
+		Queue:  queue,
+		Min:    20 * time.Millisecond,
+		Max:    time.Second,
+		Jitter: -1, // 关闭抖动
+	})
+
+	require.Equal(t, 20*time.Millisecond, hints.Hint(RetryReasonPoolSaturated), "clamped to Min")
+	pool.p95 = 300 * time.Millisecond
+	require.Equal(t, 300*time.Millisecond, hints.HintForError(apierrors.Wrap(apierrors.CodeRetryLater, "saturated", enclaveclient.ErrAcquireTimeout)))
+	pool.p95 = 10 * time.Second
+	require.Equal(t, time.Second, hints.Hint(RetryReasonPoolSaturated), "clamped to Max")
+
+	require.Equal(t, 100*time.Millisecond, hints.HintForError(unlock.ErrQueueFull))
+	queue.depth = 20
+	require.Equal(t, 200*time.Millisecond, hints.HintForError(unlock.ErrQueueFull))
+
+	require.Equal(t, 20*time.Millisecond, hints.HintForError(unlock.ErrRateLimited), "missing limiter falls back to Min")
+}
+
+func TestRetryHintJitterBounds(t *testing.T) {
+	for _, r := range []float64{0, 0.5, 0.999} {
+		hints := NewRetryHintProvider(RetryHintConfig{
+			Pool:   &fakePoolStats{p95: 100 * time.Millisecond},
+			Min:    10 * time.Millisecond,
+			Max:    time.Second,
+			Jitter: 0.2,
+			Rand:   func() float64 { return r },
+		})
+		hint := hints.Hint(RetryReasonPoolSaturated)
+		require.GreaterOrEqual(t, hint, 80*time.Millisecond)
+		require.LessOrEqual(t, hint, 120*time.Millisecond)
+	}
+}
+
+func TestHandleSignRetryLaterUsesHint(t *testing.T) {
+	handler := NewHTTPHandler(&stubBackend{
+		signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			return nil, apierrors.Wrap(apierrors.CodeRetryLater, "enclave pool saturated", enclaveclient.ErrAcquireTimeout)
+		},
+	}, WithRetryHints(NewRetryHintProvider(RetryHintConfig{
+		Pool:   &fakePoolStats{p95: 1500 * time.Millisecond},
+		Jitter: -1,
+	})))
+	req := httptest.NewRequest(http.MethodPost, "/sign", strings.NewReader(`{"keyId":"k1","digest":"`+strings.Repeat("a", 64)+`"}`))
+	rr := httptest.NewRecorder()
+	handler.handleSign(rr, req)
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	require.Equal(t, "1.500", rr.Header().Get("Retry-After"))
+	require.Contains(t, rr.Body.String(), `"retryAfterHint":"1500"`)
+}
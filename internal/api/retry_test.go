@@ -0,0 +1,98 @@
+package signerapi
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// flakyServer 的前 failures 次 SignStream/Create 以 code 失败，之后正常处理。
+type flakyServer struct {
+	streamingServer
+	code     codes.Code
+	failures int64
+	calls    atomic.Int64
+}
+
+func (s *flakyServer) SignStream(stream signerv1.SignerService_SignStreamServer) error {
+	if s.calls.Add(1) <= s.failures {
+		return status.Error(s.code, "connection reset")
+	}
+	return s.streamingServer.SignStream(stream)
+}
+
+func (s *flakyServer) Create(ctx context.Context, req *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+	if s.calls.Add(1) <= s.failures {
+		return nil, status.Error(s.code, "connection reset")
+	}
+	return s.streamingServer.Create(ctx, req)
+}
+
+func TestEnclaveBackendRetriesTransientErrors(t *testing.T) {
+	flaky := &flakyServer{code: codes.Unavailable, failures: 2}
+	pool, _, _ := newTestPoolWith(t, flaky)
+	retrier := NewRetrier(RetryConfig{Enabled: true, Backoff: time.Millisecond, BudgetBurst: 3, Registerer: prometheus.NewRegistry()})
+	backend, err := NewEnclaveBackend(pool, StaticTargetSelector{TargetID: "enclave-1"}, WithRetries(retrier))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	resp, err := backend.Sign(ctx, &signerv1.SignRequest{KeyId: "k1", Digest: []byte("payload")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("payload"), resp.GetSignature())
+	require.EqualValues(t, 3, flaky.calls.Load())
+	require.Equal(t, 2.0, testutil.ToFloat64(retrier.retries.WithLabelValues("sign")))
+
+	// 预算只剩约 1 个令牌：第二次失败后不再重试。
+	flaky.calls.Store(0)
+	_, err = backend.Create(ctx, &signerv1.CreateRequest{})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.EqualValues(t, 2, flaky.calls.Load())
+	require.Equal(t, 1.0, testutil.ToFloat64(retrier.exhausted.WithLabelValues("create")))
+
+	// 不在可重试列表中的状态码直接返回。
+	flaky.calls.Store(0)
+	flaky.code = codes.InvalidArgument
+	_, err = backend.Sign(ctx, &signerv1.SignRequest{KeyId: "k1", Digest: []byte("payload")})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.EqualValues(t, 1, flaky.calls.Load())
+}
+
+func TestEnclaveBackendRetriesCreateOnAnotherTarget(t *testing.T) {
+	down := &flakyServer{code: codes.Unavailable, failures: 1 << 30}
+	pool := newMultiTargetPool(t, map[string]signerv1.SignerServiceServer{"e1": down, "e2": streamingServer{}})
+	selector, err := NewStickySelector([]string{"e1", "e2"})
+	require.NoError(t, err)
+	retrier := NewRetrier(RetryConfig{Enabled: true, MaxAttempts: 2, Backoff: -1, Registerer: prometheus.NewRegistry()})
+	backend, err := NewEnclaveBackend(pool, selector, WithRetries(retrier))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// 首次尝试与重试各推进一次轮询：每个请求先落到 e1，失败后转到 e2。
+	for i := 0; i < 4; i++ {
+		resp, err := backend.Create(ctx, &signerv1.CreateRequest{})
+		require.NoError(t, err)
+		require.Equal(t, "generated", resp.GetKeyId())
+	}
+	require.EqualValues(t, 4, down.calls.Load())
+}
+
+func TestParseRetryCodes(t *testing.T) {
+	got, err := ParseRetryCodes(" unavailable, RESOURCE_EXHAUSTED ,")
+	require.NoError(t, err)
+	require.Equal(t, []codes.Code{codes.Unavailable, codes.ResourceExhausted}, got)
+	for _, raw := range []string{"OK", "NOT_A_CODE"} {
+		_, err := ParseRetryCodes(raw)
+		require.Error(t, err, raw)
+	}
+	require.Nil(t, NewRetrier(RetryConfig{MaxAttempts: 5}))
+}
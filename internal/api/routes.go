@@ -0,0 +1,112 @@
+package signerapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RouteSet 标识一组 HTTP 路由，监听器按 RouteSet 选择暴露面。
+type RouteSet string
+
+const (
+	// RoutePublic 为业务入口：/v1/create、/v1/keys/*、/v1/sign*、/v1/verify、/v1/unlock/* 及其无前缀旧路径、/v2/{Method}、/ws/sign，以及 /version、/healthz、/readyz。
+	RoutePublic RouteSet = "public"
+	// RouteInternal 为运维入口：/admin/*、/selfcheck、/metrics。
+	RouteInternal RouteSet = "internal"
+	// RouteDebug 为排障入口：/debug/*。
+	RouteDebug RouteSet = "debug"
+	// RouteAdmin 为独立认证的管理 API：/admin/v1/*，只在显式声明的监听器上暴露。
+	RouteAdmin RouteSet = "admin"
+)
+
+// AllRouteSets 返回未配置 SIGNER_HTTP_LISTENERS 时默认暴露的路由组，不含 RouteAdmin，顺序即注册顺序。
+func AllRouteSets() []RouteSet {
+	return []RouteSet{RoutePublic, RouteInternal, RouteDebug}
+}
+
+// ParseRouteSets 解析逗号分隔的路由组列表，未知名称返回错误。
+func ParseRouteSets(raw string) ([]RouteSet, error) {
+	var sets []RouteSet
+	seen := make(map[RouteSet]bool)
+	for _, part := range strings.Split(raw, ",") {
+		set := RouteSet(strings.ToLower(strings.TrimSpace(part)))
+		if set == "" || seen[set] {
+			continue
+		}
+		switch set {
+		case RoutePublic, RouteInternal, RouteDebug, RouteAdmin:
+		default:
+			return nil, fmt.Errorf("unknown route set %q", part)
+		}
+		seen[set] = true
+		sets = append(sets, set)
+	}
+	if len(sets) == 0 {
+		return nil, fmt.Errorf("no route set provided")
+	}
+	return sets, nil
+}
+
+// Router 是 Register 系列方法需要的最小路由接口，*http.ServeMux 与 *RouteGroup 均满足。
+type Router interface {
+	Handle(pattern string, handler http.Handler)
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+type route struct {
+	pattern string
+	handler http.Handler
+}
+
+// RouteGroup 记录某个 RouteSet 下注册的路由。
+type RouteGroup struct {
+	routes []route
+}
+
+// Handle 注册一个 handler。
+func (g *RouteGroup) Handle(pattern string, handler http.Handler) {
+	g.routes = append(g.routes, route{pattern: pattern, handler: handler})
+}
+
+// HandleFunc 注册一个 handler 函数。
+func (g *RouteGroup) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	g.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// Routes 按 RouteSet 收集路由，同一批 handler 实例可被多个监听器按需组合。
+type Routes struct {
+	groups map[RouteSet]*RouteGroup
+}
+
+// NewRoutes 构造空的路由表。
+func NewRoutes() *Routes {
+	return &Routes{groups: make(map[RouteSet]*RouteGroup)}
+}
+
+// Group 返回 set 对应的路由组，不存在时创建。
+func (r *Routes) Group(set RouteSet) *RouteGroup {
+	g, ok := r.groups[set]
+	if !ok {
+		g = &RouteGroup{}
+		r.groups[set] = g
+	}
+	return g
+}
+
+// Mux 用选中的路由组构造 ServeMux，未选中的路由返回 404。
+func (r *Routes) Mux(sets ...RouteSet) *http.ServeMux {
+	mux := http.NewServeMux()
+	seen := make(map[RouteSet]bool)
+	for _, set := range sets {
+		g, ok := r.groups[set]
+		if !ok || seen[set] {
+			continue
+		}
+		seen[set] = true
+		for _, rt := range g.routes {
+			mux.Handle(rt.pattern, rt.handler)
+		}
+	}
+	return mux
+}
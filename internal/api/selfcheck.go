@@ -0,0 +1,228 @@
+package signerapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/aegis-sign/wallet/pkg/curves"
+	"github.com/aegis-sign/wallet/pkg/sigverify"
+)
+
+// SelfCheckDigest 是金丝雀自检签名使用的固定摘要。
+var SelfCheckDigest = sha256.Sum256([]byte("aegis-sign/selfcheck/v1"))
+
+const (
+	defaultSelfCheckInterval = 30 * time.Second
+	defaultSelfCheckTimeout  = time.Second
+)
+
+// SignatureVerifier 在本地校验签名，便于测试替换。
+type SignatureVerifier func(publicKey, digest, signature []byte) (bool, error)
+
+// defaultVerifiers 按曲线名登记本地验签实现。
+var defaultVerifiers = map[string]SignatureVerifier{
+	"secp256k1": sigverify.VerifySecp256k1,
+	"ed25519":   sigverify.VerifyEd25519,
+}
+
+// SelfCheckConfig 配置 /selfcheck 行为。
+type SelfCheckConfig struct {
+	Targets  []string
+	Interval time.Duration
+	Timeout  time.Duration
+	Curve    string
+	Verifier SignatureVerifier
+	Now      func() time.Time
+}
+
+// SelfCheckResult 描述单个 Enclave 的自检结果。
+type SelfCheckResult struct {
+	Target    string    `json:"target"`
+	OK        bool      `json:"ok"`
+	LatencyMs float64   `json:"latencyMs"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+	Cached    bool      `json:"cached"`
+}
+
+type selfCheckReport struct {
+	OK      bool              `json:"ok"`
+	Results []SelfCheckResult `json:"results"`
+}
+
+// SelfChecker 使用每个 Enclave 专属的金丝雀 key 对固定摘要签名并本地验签，
+// 同一 Enclave 在 Interval 内至多发起一次往返，其余请求返回缓存结果。
+type SelfChecker struct {
+	backend Backend
+	cfg     SelfCheckConfig
+
+	mu      sync.RWMutex
+	targets []string
+	probes  map[string]*selfCheckProbe
+}
+
+type selfCheckProbe struct {
+	mu        sync.Mutex
+	keyID     string
+	publicKey []byte
+	last      SelfCheckResult
+}
+
+// NewSelfChecker 构造自检器，targets 为空时返回错误。
+func NewSelfChecker(backend Backend, cfg SelfCheckConfig) (*SelfChecker, error) {
+	if backend == nil {
+		return nil, errors.New("signer backend is required")
+	}
+	if len(cfg.Targets) == 0 {
+		return nil, errors.New("at least one selfcheck target is required")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultSelfCheckInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultSelfCheckTimeout
+	}
+	curve, err := curves.Lookup(cfg.Curve)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Curve = curve.Name
+	if cfg.Verifier == nil {
+		verifier, ok := defaultVerifiers[curve.Name]
+		if !ok {
+			return nil, fmt.Errorf("no local signature verifier for curve %s", curve.Name)
+		}
+		cfg.Verifier = verifier
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	c := &SelfChecker{backend: backend, cfg: cfg}
+	c.SetTargets(cfg.Targets)
+	return c, nil
+}
+
+// SetTargets 替换自检目标（动态发现），保留仍存在目标的金丝雀 key 与缓存结果。
+func (c *SelfChecker) SetTargets(targets []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	probes := make(map[string]*selfCheckProbe, len(targets))
+	for _, target := range targets {
+		if probe, ok := c.probes[target]; ok {
+			probes[target] = probe
+			continue
+		}
+		probes[target] = &selfCheckProbe{}
+	}
+	c.targets = slices.Clone(targets)
+	c.probes = probes
+}
+
+// ServeHTTP 处理 POST /selfcheck，全部通过返回 200，否则 503。
+func (c *SelfChecker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONResponse(w, http.StatusBadRequest, errorResponse{
+			Code:    string(apierrors.CodeInvalidArgument),
+			Message: "POST required",
+		})
+		return
+	}
+	results := c.Check(r.Context())
+	report := selfCheckReport{OK: true, Results: results}
+	for _, res := range results {
+		if !res.OK {
+			report.OK = false
+		}
+	}
+	status := http.StatusOK
+	if !report.OK {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSONResponse(w, status, report)
+}
+
+// Check 并发检查所有 Enclave，结果顺序与 Targets 一致。
+func (c *SelfChecker) Check(ctx context.Context) []SelfCheckResult {
+	c.mu.RLock()
+	targets, probes := c.targets, c.probes
+	c.mu.RUnlock()
+	results := make([]SelfCheckResult, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			results[i] = c.checkTarget(ctx, target, probes[target])
+		}(i, target)
+	}
+	wg.Wait()
+	return results
+}
+
+func (c *SelfChecker) checkTarget(ctx context.Context, target string, probe *selfCheckProbe) SelfCheckResult {
+	probe.mu.Lock()
+	defer probe.mu.Unlock()
+	now := c.cfg.Now()
+	if !probe.last.CheckedAt.IsZero() && now.Sub(probe.last.CheckedAt) < c.cfg.Interval {
+		cached := probe.last
+		cached.Cached = true
+		return cached
+	}
+	start := time.Now()
+	err := c.roundTrip(ctx, target, probe)
+	result := SelfCheckResult{
+		Target:    target,
+		OK:        err == nil,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt: now,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	probe.last = result
+	return result
+}
+
+func (c *SelfChecker) roundTrip(ctx context.Context, target string, probe *selfCheckProbe) error {
+	callCtx, cancel := context.WithTimeout(WithPinnedTarget(ctx, target), c.cfg.Timeout)
+	defer cancel()
+	if probe.keyID == "" {
+		created, err := c.backend.Create(callCtx, &signerv1.CreateRequest{Curve: c.cfg.Curve})
+		if err != nil {
+			return err
+		}
+		if created.GetKeyId() == "" || len(created.GetPublicKey()) == 0 {
+			return errors.New("canary key creation returned empty key")
+		}
+		probe.keyID = created.GetKeyId()
+		probe.publicKey = append([]byte(nil), created.GetPublicKey()...)
+	}
+	resp, err := c.backend.Sign(callCtx, &signerv1.SignRequest{
+		KeyId:  probe.keyID,
+		Digest: SelfCheckDigest[:],
+	})
+	if err != nil {
+		if apiErr, ok := apierrors.FromError(err); ok && apiErr.Code == apierrors.CodeInvalidKey {
+			// 金丝雀 key 丢失（如 Enclave 重启），下一轮重新创建。
+			probe.keyID = ""
+			probe.publicKey = nil
+		}
+		return err
+	}
+	valid, err := c.cfg.Verifier(probe.publicKey, SelfCheckDigest[:], resp.GetSignature())
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errors.New("canary signature verification failed")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/stretchr/testify/require"
+)
+
+type selfCheckBackend struct {
+	mu      sync.Mutex
+	creates map[string]int
+	signs   map[string]int
+	failOn  string
+}
+
+func newSelfCheckBackend() *selfCheckBackend {
+	return &selfCheckBackend{creates: map[string]int{}, signs: map[string]int{}}
+}
+
+func (b *selfCheckBackend) Create(ctx context.Context, _ *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+	target, _ := PinnedTarget(ctx)
+	b.mu.Lock()
+	b.creates[target]++
+	b.mu.Unlock()
+	return &signerv1.CreateResponse{KeyId: "canary-" + target, PublicKey: []byte(target)}, nil
+}
+
+func (b *selfCheckBackend) ImportKey(context.Context, *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+	return nil, errors.New("import not supported")
+}
+
+func (b *selfCheckBackend) GetPublicKey(context.Context, *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error) {
+	return nil, errors.New("public key not supported")
+}
+
+func (b *selfCheckBackend) DisableKey(context.Context, *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+	return nil, errors.New("disable not supported")
+}
+
+func (b *selfCheckBackend) Sign(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+	target, _ := PinnedTarget(ctx)
+	b.mu.Lock()
+	b.signs[target]++
+	b.mu.Unlock()
+	if target == b.failOn {
+		return nil, errors.New("enclave unreachable")
+	}
+	return &signerv1.SignResponse{Signature: append([]byte(target), req.GetDigest()...)}, nil
+}
+
+func (b *selfCheckBackend) signCount(target string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.signs[target]
+}
+
+func stubVerifier(pub, digest, sig []byte) (bool, error) {
+	return string(sig) == string(pub)+string(digest), nil
+}
+
+func TestSelfCheckCachesWithinInterval(t *testing.T) {
+	backend := newSelfCheckBackend()
+	now := time.Unix(0, 0)
+	checker, err := NewSelfChecker(backend, SelfCheckConfig{
+		Targets:  []string{"a", "b"},
+		Interval: 10 * time.Second,
+		Verifier: stubVerifier,
+		Now:      func() time.Time { return now },
+	})
+	require.NoError(t, err)
+
+	first := checker.Check(context.Background())
+	require.True(t, first[0].OK)
+	require.True(t, first[1].OK)
+	require.False(t, first[0].Cached)
+
+	now = now.Add(5 * time.Second)
+	second := checker.Check(context.Background())
+	require.True(t, second[0].Cached)
+	require.Equal(t, 1, backend.signCount("a"))
+	require.Equal(t, 1, backend.signCount("b"))
+
+	now = now.Add(6 * time.Second)
+	third := checker.Check(context.Background())
+	require.False(t, third[0].Cached)
+	require.Equal(t, 2, backend.signCount("a"))
+	require.Equal(t, 1, backend.creates["a"], "canary key must be reused")
+
+	// 目标变更后保留仍存在目标的缓存与金丝雀 key。
+	checker.SetTargets([]string{"a", "c"})
+	fourth := checker.Check(context.Background())
+	require.Len(t, fourth, 2)
+	require.True(t, fourth[0].Cached)
+	require.Equal(t, "c", fourth[1].Target)
+	require.False(t, fourth[1].Cached)
+	require.Equal(t, 1, backend.creates["a"])
+}
+
+func TestSelfCheckReportsFailingEnclave(t *testing.T) {
+	backend := newSelfCheckBackend()
+	backend.failOn = "b"
+	checker, err := NewSelfChecker(backend, SelfCheckConfig{
+		Targets:  []string{"a", "b"},
+		Verifier: stubVerifier,
+	})
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	checker.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/selfcheck", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	var report selfCheckReport
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	require.False(t, report.OK)
+	require.Len(t, report.Results, 2)
+	require.True(t, report.Results[0].OK)
+	require.False(t, report.Results[1].OK)
+	require.Equal(t, "b", report.Results[1].Target)
+	require.Contains(t, report.Results[1].Error, "enclave unreachable")
+}
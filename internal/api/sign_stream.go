@@ -0,0 +1,107 @@
+package signerapi
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// SignStreamOrderedMetadataKey 为 true 时 SignStream 按请求到达顺序返回响应（以 sequence 标注），
+	// 慢请求会阻塞其后已完成的响应，缓冲上限即流内窗口。
+	SignStreamOrderedMetadataKey = "x-sign-stream-ordered"
+	// SignStreamWindowMetadataKey 为调用方期望的流内并发窗口，只能调小服务端的 StreamMaxInFlight。
+	SignStreamWindowMetadataKey = "x-sign-stream-window"
+)
+
+// signStreamMode 为单个 SignStream 协商出的流控参数。
+type signStreamMode struct {
+	window  int
+	ordered bool
+}
+
+// signStreamModeFrom 从 incoming metadata 读取流控参数，非法值沿用服务端默认。
+func signStreamModeFrom(ctx context.Context, maxInFlight int) signStreamMode {
+	mode := signStreamMode{window: maxInFlight}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(SignStreamWindowMetadataKey); len(values) > 0 {
+		if n, err := strconv.Atoi(strings.TrimSpace(values[0])); err == nil && n > 0 && n < mode.window {
+			mode.window = n
+		}
+	}
+	if values := md.Get(SignStreamOrderedMetadataKey); len(values) > 0 {
+		mode.ordered, _ = strconv.ParseBool(strings.TrimSpace(values[0]))
+	}
+	return mode
+}
+
+// signStreamSender 串行化 SignStream 的发送并维护流内窗口：reserve 占用一个窗口位，
+// 对应响应发出后释放。有序模式下先完成的响应暂存，直到此前的响应全部发出。
+type signStreamSender struct {
+	stream  signerv1.SignerService_SignStreamServer
+	window  chan struct{}
+	ordered bool
+	// reserved 只由 Recv 循环读写，无需加锁。
+	reserved uint64
+
+	mu      sync.Mutex
+	err     error
+	next    uint64
+	pending map[uint64]*signerv1.SignResponse
+}
+
+func newSignStreamSender(stream signerv1.SignerService_SignStreamServer, mode signStreamMode) *signStreamSender {
+	o := &signStreamSender{stream: stream, window: make(chan struct{}, mode.window), ordered: mode.ordered}
+	if mode.ordered {
+		o.pending = make(map[uint64]*signerv1.SignResponse, mode.window)
+	}
+	return o
+}
+
+// reserve 阻塞至窗口有空位，返回该请求的到达序号；仅由 Recv 循环调用。
+func (o *signStreamSender) reserve() uint64 {
+	o.window <- struct{}{}
+	slot := o.reserved
+	o.reserved++
+	return slot
+}
+
+// emit 回填请求标识后发送响应；有序模式下按到达序号依次发出。
+func (o *signStreamSender) emit(slot uint64, req *signerv1.SignRequest, resp *signerv1.SignResponse) {
+	resp.KeyId = req.GetKeyId()
+	resp.RequestId = req.GetRequestId()
+	resp.Sequence = req.GetSequence()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.ordered {
+		o.send(resp)
+		return
+	}
+	o.pending[slot] = resp
+	for {
+		ready, ok := o.pending[o.next]
+		if !ok {
+			return
+		}
+		delete(o.pending, o.next)
+		o.next++
+		o.send(ready)
+	}
+}
+
+func (o *signStreamSender) send(resp *signerv1.SignResponse) {
+	if o.err == nil {
+		o.err = o.stream.Send(resp)
+	}
+	<-o.window
+}
+
+func (o *signStreamSender) failed() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.err
+}
@@ -0,0 +1,69 @@
+package signerapi
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestSignStreamOrderedResponses(t *testing.T) {
+	var fast sync.WaitGroup
+	fast.Add(2)
+	backend := &stubBackend{signFn: func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		if req.GetKeyId() == "slow" {
+			// 等后两个请求完成后再返回，验证有序模式会暂存先完成的响应。
+			fast.Wait()
+		} else {
+			defer fast.Done()
+		}
+		return &signerv1.SignResponse{Signature: []byte(req.GetKeyId())}, nil
+	}}
+	digest := make([]byte, 32)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(SignStreamOrderedMetadataKey, "true"))
+	stream := &fakeSignStream{ctx: ctx, reqs: []*signerv1.SignRequest{
+		{KeyId: "slow", Digest: digest, Sequence: 10},
+		{KeyId: "k2", Digest: digest, Sequence: 11},
+		{KeyId: "k3", Digest: digest, Sequence: 12},
+	}}
+	require.NoError(t, NewGRPCServer(backend, nil).SignStream(stream))
+	require.Len(t, stream.sent, 3)
+	for i, resp := range stream.sent {
+		require.Equal(t, uint64(10+i), resp.GetSequence())
+		require.Equal(t, stream.reqs[i].GetKeyId(), string(resp.GetSignature()))
+	}
+}
+
+func TestSignStreamClientWindow(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	backend := &stubBackend{signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return &signerv1.SignResponse{}, nil
+	}}
+	reqs := make([]*signerv1.SignRequest, 8)
+	for i := range reqs {
+		reqs[i] = &signerv1.SignRequest{KeyId: "k", Digest: make([]byte, 32), Sequence: uint64(i)}
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(SignStreamWindowMetadataKey, "2"))
+	stream := &fakeSignStream{ctx: ctx, reqs: reqs}
+	require.NoError(t, NewGRPCServer(backend, nil).SignStream(stream))
+	require.Len(t, stream.sent, len(reqs))
+	require.LessOrEqual(t, peak.Load(), int32(2))
+
+	// 调用方窗口只能调小服务端上限。
+	require.Equal(t, signStreamMode{window: 4}, signStreamModeFrom(metadata.NewIncomingContext(context.Background(), metadata.Pairs(SignStreamWindowMetadataKey, "64")), 4))
+	require.Equal(t, signStreamMode{window: 4}, signStreamModeFrom(context.Background(), 4))
+}
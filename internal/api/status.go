@@ -0,0 +1,170 @@
+package signerapi
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+
+	"github.com/aegis-sign/wallet/internal/infra/kms"
+)
+
+const defaultQueueSaturation = 0.9
+
+// BuildInfo 描述构建版本信息，通常由 main 通过 -ldflags 注入。
+type BuildInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+}
+
+type versionResponse struct {
+	BuildInfo
+	GoVersion string `json:"goVersion"`
+	ReadOnly  bool   `json:"readOnly"`
+}
+
+type readyResponse struct {
+	Ready    bool          `json:"ready"`
+	ReadOnly bool          `json:"readOnly"`
+	Checks   []healthCheck `json:"checks,omitempty"`
+}
+
+type liveResponse struct {
+	Status string `json:"status"`
+}
+
+// healthCheck 是单项依赖检查结果；Critical 为 false 的检查失败只告警，不影响就绪。
+type healthCheck struct {
+	Name     string `json:"name"`
+	OK       bool   `json:"ok"`
+	Critical bool   `json:"critical"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// UnlockQueueCapacity 提供解锁队列深度与容量，*unlock.Dispatcher 满足。
+type UnlockQueueCapacity interface {
+	QueueDepth() int
+	QueueCapacity() int
+}
+
+// KMSHealthSource 提供 KMS 最近调用结果，*kms.Client 满足。
+type KMSHealthSource interface {
+	Health() kms.Health
+}
+
+// ReadinessConfig 配置 /readyz 聚合的依赖，未设置的来源不参与检查。
+type ReadinessConfig struct {
+	Pool  PoolHealthSource
+	Queue UnlockQueueCapacity
+	KMS   KMSHealthSource
+	// Drain 处于排空状态时就绪检查失败，使负载均衡在发布前摘流。
+	Drain *Drainer
+	// QueueSaturation 为队列占用比例阈值，达到后视为饱和，默认 0.9。
+	QueueSaturation float64
+}
+
+// StatusHandler 提供 `/version`、`/healthz` 与 `/readyz`，并回显运行时开关状态。
+type StatusHandler struct {
+	build     BuildInfo
+	readOnly  *ReadOnlyMode
+	readiness ReadinessConfig
+}
+
+// NewStatusHandler 构造状态 handler，readOnly 可为空。
+func NewStatusHandler(build BuildInfo, readOnly *ReadOnlyMode) *StatusHandler {
+	if build.Version == "" {
+		build.Version = "dev"
+	}
+	return &StatusHandler{build: build, readOnly: readOnly}
+}
+
+// SetReadiness 配置 /readyz 检查的依赖，须在 Register 之前调用。
+func (h *StatusHandler) SetReadiness(cfg ReadinessConfig) {
+	if cfg.QueueSaturation <= 0 || cfg.QueueSaturation > 1 {
+		cfg.QueueSaturation = defaultQueueSaturation
+	}
+	h.readiness = cfg
+}
+
+// Register 将状态路由注册到 mux。
+func (h *StatusHandler) Register(mux Router) {
+	mux.HandleFunc("/version", h.handleVersion)
+	mux.HandleFunc("/healthz", h.handleLive)
+	mux.HandleFunc("/readyz", h.handleReady)
+}
+
+func (h *StatusHandler) handleVersion(w http.ResponseWriter, _ *http.Request) {
+	writeJSONResponse(w, http.StatusOK, versionResponse{
+		BuildInfo: h.build,
+		GoVersion: runtime.Version(),
+		ReadOnly:  h.readOnly.Enabled(),
+	})
+}
+
+// handleLive 只反映进程能否响应，不检查依赖，避免依赖故障引发重启风暴。
+func (h *StatusHandler) handleLive(w http.ResponseWriter, _ *http.Request) {
+	writeJSONResponse(w, http.StatusOK, liveResponse{Status: "ok"})
+}
+
+// handleReady 在任一关键检查失败时返回 503，使负载均衡摘流；
+// 只读模式下签名路径仍可用，因此不影响就绪。
+func (h *StatusHandler) handleReady(w http.ResponseWriter, _ *http.Request) {
+	checks := h.checks()
+	resp := readyResponse{Ready: true, ReadOnly: h.readOnly.Enabled(), Checks: checks}
+	for _, c := range checks {
+		if c.Critical && !c.OK {
+			resp.Ready = false
+		}
+	}
+	status := http.StatusOK
+	if !resp.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSONResponse(w, status, resp)
+}
+
+func (h *StatusHandler) checks() []healthCheck {
+	cfg := h.readiness
+	var checks []healthCheck
+	if cfg.Pool != nil {
+		stats := cfg.Pool.Stats()
+		available := 0
+		for _, st := range stats {
+			if st.Available() {
+				available++
+			}
+		}
+		checks = append(checks, healthCheck{
+			Name:     "enclaves",
+			OK:       available > 0,
+			Critical: true,
+			Detail:   fmt.Sprintf("%d/%d enclaves available", available, len(stats)),
+		})
+	}
+	if cfg.Queue != nil {
+		depth, capacity := cfg.Queue.QueueDepth(), cfg.Queue.QueueCapacity()
+		checks = append(checks, healthCheck{
+			Name:     "unlock_queue",
+			OK:       capacity <= 0 || float64(depth) < cfg.QueueSaturation*float64(capacity),
+			Critical: true,
+			Detail:   fmt.Sprintf("%d/%d queued", depth, capacity),
+		})
+	}
+	if cfg.Drain != nil {
+		check := healthCheck{Name: "drain", OK: !cfg.Drain.Draining(), Critical: true}
+		if !check.OK {
+			check.Detail = "draining for shutdown"
+		}
+		checks = append(checks, check)
+	}
+	if cfg.KMS != nil {
+		// KMS 故障时已解锁的 key 仍可签名，且所有实例会同时受影响，因此只告警不摘流。
+		health := cfg.KMS.Health()
+		check := healthCheck{Name: "kms", OK: true}
+		if health.LastErrorAt.After(health.LastSuccessAt) {
+			check.OK = false
+			check.Detail = health.LastError
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
@@ -0,0 +1,69 @@
+package signerapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+	"github.com/aegis-sign/wallet/internal/infra/kms"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeQueue struct{ depth, capacity int }
+
+func (q fakeQueue) QueueDepth() int    { return q.depth }
+func (q fakeQueue) QueueCapacity() int { return q.capacity }
+
+type fakeKMSHealth struct{ health kms.Health }
+
+func (k *fakeKMSHealth) Health() kms.Health { return k.health }
+
+func TestStatusHandlerReadinessChecks(t *testing.T) {
+	pool := &fakeHealthPool{}
+	pool.set(enclaveclient.TargetStats{ID: "a", Breaker: "healthy"}, enclaveclient.TargetStats{ID: "b", Breaker: "draining"})
+	queue := &fakeQueue{depth: 1, capacity: 10}
+	now := time.Now()
+	kmsHealth := &fakeKMSHealth{health: kms.Health{LastError: "dial timeout", LastErrorAt: now, LastSuccessAt: now.Add(-time.Minute)}}
+	h := NewStatusHandler(BuildInfo{}, nil)
+	h.SetReadiness(ReadinessConfig{Pool: pool, Queue: queue, KMS: kmsHealth})
+	mux := http.NewServeMux()
+	h.Register(mux)
+
+	ready := func() (int, readyResponse) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var resp readyResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return rr.Code, resp
+	}
+
+	// KMS 故障只告警，不摘流。
+	code, resp := ready()
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, resp.Ready)
+	require.Equal(t, []healthCheck{
+		{Name: "enclaves", OK: true, Critical: true, Detail: "1/2 enclaves available"},
+		{Name: "unlock_queue", OK: true, Critical: true, Detail: "1/10 queued"},
+		{Name: "kms", OK: false, Detail: "dial timeout"},
+	}, resp.Checks)
+
+	queue.depth = 9
+	code, resp = ready()
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.False(t, resp.Ready)
+
+	queue.depth = 0
+	pool.set(enclaveclient.TargetStats{ID: "b", Breaker: "draining"})
+	code, _ = ready()
+	require.Equal(t, http.StatusServiceUnavailable, code)
+
+	// 存活探针不检查依赖。
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.JSONEq(t, `{"status":"ok"}`, rr.Body.String())
+}
@@ -0,0 +1,113 @@
+package signerapi
+
+import (
+	"sync"
+
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defaultStreamMaxInFlight = 32
+
+// StreamLimiterConfig 配置 SignStream 的背压。
+type StreamLimiterConfig struct {
+	// Permits 为所有 SignStream 共享的 in-flight 许可数，通常取连接池容量 × 倍数；<=0 表示不限制。
+	Permits int
+	// StreamMaxInFlight 为单个流的并发上限，默认 32；达到上限时该流暂停 Recv。
+	StreamMaxInFlight int
+	Registerer        prometheus.Registerer
+	// MetricsOptions 覆盖 stream_permits_held 的默认 signer / stream 前缀与常量标签。
+	MetricsOptions metricsopts.Options
+}
+
+// StreamLimiter 是所有 SignStream 共享的许可池，防止单个流占满连接池而饿死 unary 调用。
+// 每个流最多持有 Permits / 活跃流数（至少 1）个许可；nil 表示不限制，所有方法均可安全调用。
+type StreamLimiter struct {
+	permits     int
+	maxInFlight int
+	held        prometheus.Gauge
+
+	mu      sync.Mutex
+	inUse   int
+	streams int
+}
+
+// NewStreamLimiter 构造 StreamLimiter。
+func NewStreamLimiter(cfg StreamLimiterConfig) *StreamLimiter {
+	if cfg.StreamMaxInFlight <= 0 {
+		cfg.StreamMaxInFlight = defaultStreamMaxInFlight
+	}
+	reg := cfg.Registerer
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	opts := cfg.MetricsOptions.WithDefaults("signer", "stream")
+	l := &StreamLimiter{
+		permits:     cfg.Permits,
+		maxInFlight: cfg.StreamMaxInFlight,
+		held:        prometheus.NewGauge(opts.Gauge("permits_held", "Number of shared permits currently held by SignStream requests")),
+	}
+	metricsopts.MustRegister(reg, l.held)
+	return l
+}
+
+// streamQuota 记录单个流持有的许可数。
+type streamQuota struct {
+	limiter *StreamLimiter
+	held    int
+}
+
+func (l *StreamLimiter) streamMaxInFlight() int {
+	if l == nil {
+		return defaultStreamMaxInFlight
+	}
+	return l.maxInFlight
+}
+
+// open 为新流登记配额，流结束时须调用 close。
+func (l *StreamLimiter) open() *streamQuota {
+	if l != nil {
+		l.mu.Lock()
+		l.streams++
+		l.mu.Unlock()
+	}
+	return &streamQuota{limiter: l}
+}
+
+// tryAcquire 非阻塞地获取一个许可：共享池耗尽或本流已达公平份额时返回 false。
+func (q *streamQuota) tryAcquire() bool {
+	l := q.limiter
+	if l == nil || l.permits <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	share := max(1, l.permits/max(1, l.streams))
+	if l.inUse >= l.permits || q.held >= share {
+		return false
+	}
+	l.inUse++
+	q.held++
+	l.held.Inc()
+	return true
+}
+
+func (q *streamQuota) release() {
+	l := q.limiter
+	if l == nil || l.permits <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inUse--
+	q.held--
+	l.held.Dec()
+}
+
+func (q *streamQuota) close() {
+	if l := q.limiter; l != nil {
+		l.mu.Lock()
+		l.streams--
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,122 @@
+package signerapi
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type chanSignStream struct {
+	signerv1.SignerService_SignStreamServer
+	reqs chan *signerv1.SignRequest
+	sent chan *signerv1.SignResponse
+}
+
+func newChanSignStream() *chanSignStream {
+	return &chanSignStream{reqs: make(chan *signerv1.SignRequest), sent: make(chan *signerv1.SignResponse, 8)}
+}
+
+func (c *chanSignStream) Context() context.Context { return context.Background() }
+
+func (c *chanSignStream) Recv() (*signerv1.SignRequest, error) {
+	req, ok := <-c.reqs
+	if !ok {
+		return nil, io.EOF
+	}
+	return req, nil
+}
+
+func (c *chanSignStream) Send(resp *signerv1.SignResponse) error {
+	c.sent <- resp
+	return nil
+}
+
+func (c *chanSignStream) next(t *testing.T) *signerv1.SignResponse {
+	t.Helper()
+	select {
+	case resp := <-c.sent:
+		return resp
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for stream response")
+		return nil
+	}
+}
+
+func TestSignStreamSharesPermitsFairly(t *testing.T) {
+	started := make(chan string, 4)
+	release := map[string]chan struct{}{"a1": make(chan struct{}), "b2": make(chan struct{})}
+	server := NewGRPCServer(&stubBackend{signFn: func(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		started <- req.GetKeyId()
+		<-release[req.GetKeyId()]
+		return &signerv1.SignResponse{Signature: []byte("sig")}, nil
+	}}, nil)
+	limiter := NewStreamLimiter(StreamLimiterConfig{Permits: 1, Registerer: prometheus.NewRegistry()})
+	server.SetStreamLimiter(limiter)
+
+	a, b := newChanSignStream(), newChanSignStream()
+	errs := make(chan error, 2)
+	go func() { errs <- server.SignStream(a) }()
+	go func() { errs <- server.SignStream(b) }()
+	sign := func(s *chanSignStream, key string) {
+		s.reqs <- &signerv1.SignRequest{KeyId: key, Digest: repeatBytes(0x01, 32)}
+	}
+	requireExhausted := func(resp *signerv1.SignResponse, key string) {
+		t.Helper()
+		require.Equal(t, key, resp.GetKeyId())
+		require.Equal(t, signerv1.ApiErrorCode_API_ERROR_CODE_RETRY_LATER, resp.GetError().GetCode())
+		require.NotEmpty(t, resp.GetError().GetRetryAfter())
+		require.Empty(t, resp.GetSignature())
+	}
+
+	// a 占用唯一许可时，b 的请求立即收到 in-band RETRY_LATER，而不是阻塞在 Recv 上。
+	sign(a, "a1")
+	require.Equal(t, "a1", <-started)
+	sign(b, "b1")
+	requireExhausted(b.next(t), "b1")
+	require.Equal(t, 1.0, testutil.ToFloat64(limiter.held))
+
+	close(release["a1"])
+	resp := a.next(t)
+	require.Equal(t, "a1", resp.GetKeyId())
+	require.Equal(t, "sig", string(resp.GetSignature()))
+
+	// 许可归还后 b 可以获取，此时轮到 a 被拒绝。
+	sign(b, "b2")
+	require.Equal(t, "b2", <-started)
+	sign(a, "a2")
+	requireExhausted(a.next(t), "a2")
+	close(release["b2"])
+	require.Equal(t, "sig", string(b.next(t).GetSignature()))
+
+	close(a.reqs)
+	close(b.reqs)
+	require.NoError(t, <-errs)
+	require.NoError(t, <-errs)
+	require.Equal(t, 0.0, testutil.ToFloat64(limiter.held))
+}
+
+func TestStreamLimiterCapsPerStreamShare(t *testing.T) {
+	limiter := NewStreamLimiter(StreamLimiterConfig{Permits: 2, Registerer: prometheus.NewRegistry()})
+	q1, q2 := limiter.open(), limiter.open()
+	require.True(t, q1.tryAcquire())
+	require.False(t, q1.tryAcquire(), "a stream may not exceed its fair share while others are active")
+	require.True(t, q2.tryAcquire())
+	require.False(t, q2.tryAcquire())
+
+	q2.release()
+	q2.close()
+	require.True(t, q1.tryAcquire(), "a lone stream may use the whole pool")
+	require.False(t, q1.tryAcquire())
+
+	var unlimited *StreamLimiter
+	q := unlimited.open()
+	require.True(t, q.tryAcquire())
+	q.release()
+	q.close()
+}
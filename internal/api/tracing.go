@@ -0,0 +1,140 @@
+package signerapi
+
+import (
+	"context"
+	"net/http"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/internal/infra/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// InterceptorTracing 为每次 gRPC 调用创建 server span 的拦截器名称。
+const InterceptorTracing = "tracing"
+
+// tracer 取自全局 TracerProvider，未安装时为空操作。
+var tracer = otel.Tracer("github.com/aegis-sign/wallet/internal/api")
+
+// HTTPTracingMiddleware 从 traceparent 头延续上游 trace 并创建 server span，span 覆盖排队、
+// 后端中间件、连接池借用与 Enclave RPC。应位于 RequestIDMiddleware 之内，使 span 带上请求 ID。
+func HTTPTracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, "HTTP "+r.Method, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			))
+		defer span.End()
+		if id, ok := reqctx.RequestIDFrom(ctx); ok {
+			span.SetAttributes(attribute.String("request_id", id))
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+// TracingInterceptor 从 gRPC metadata 延续上游 trace 并为每次调用创建 server span，stream 按整条流计。
+func TracingInterceptor() GRPCInterceptor {
+	start := func(ctx context.Context, method string) (context.Context, trace.Span) {
+		ctx, span := tracer.Start(tracing.Extract(ctx), method, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("rpc.system", "grpc")))
+		if id, ok := reqctx.RequestIDFrom(ctx); ok {
+			span.SetAttributes(attribute.String("request_id", id))
+		}
+		return ctx, span
+	}
+	end := func(span trace.Span, err error) {
+		span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(status.Code(err))))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+	return GRPCInterceptor{
+		Unary: func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			ctx, span := start(ctx, info.FullMethod)
+			resp, err := handler(ctx, req)
+			end(span, err)
+			return resp, err
+		},
+		Stream: func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, span := start(ss.Context(), info.FullMethod)
+			err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+			end(span, err)
+			return err
+		},
+	}
+}
+
+// TracingMiddleware 为每次 Backend 调用创建 span，包住重试、对冲与全部 Enclave 尝试；
+// 上游没有 trace 时不创建，避免自检等后台调用产生孤立的根 span。
+func TracingMiddleware() BackendMiddleware {
+	run := func(ctx context.Context, name, keyID string, call func(context.Context) error) {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			_ = call(ctx)
+			return
+		}
+		ctx, span := tracer.Start(ctx, "backend."+name)
+		if keyID != "" {
+			span.SetAttributes(attribute.String("key.id", keyID))
+		}
+		if err := call(ctx); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, errorCodeLabel(err))
+		}
+		span.End()
+	}
+	return func(next Backend) Backend {
+		return BackendFuncs{
+			Next: next,
+			CreateFunc: func(ctx context.Context, req *signerv1.CreateRequest) (resp *signerv1.CreateResponse, err error) {
+				run(ctx, "create", "", func(ctx context.Context) error {
+					resp, err = next.Create(ctx, req)
+					return err
+				})
+				return resp, err
+			},
+			ImportFunc: func(ctx context.Context, req *signerv1.ImportKeyRequest) (resp *signerv1.CreateResponse, err error) {
+				run(ctx, "import", "", func(ctx context.Context) error {
+					resp, err = next.ImportKey(ctx, req)
+					return err
+				})
+				return resp, err
+			},
+			SignFunc: func(ctx context.Context, req *signerv1.SignRequest) (resp *signerv1.SignResponse, err error) {
+				run(ctx, "sign", req.GetKeyId(), func(ctx context.Context) error {
+					resp, err = next.Sign(ctx, req)
+					return err
+				})
+				return resp, err
+			},
+			PublicKeyFunc: func(ctx context.Context, req *signerv1.GetPublicKeyRequest) (resp *signerv1.CreateResponse, err error) {
+				run(ctx, "publickey", req.GetKeyId(), func(ctx context.Context) error {
+					resp, err = next.GetPublicKey(ctx, req)
+					return err
+				})
+				return resp, err
+			},
+			DisableFunc: func(ctx context.Context, req *signerv1.DisableKeyRequest) (resp *signerv1.DisableKeyResponse, err error) {
+				run(ctx, "disable", req.GetKeyId(), func(ctx context.Context) error {
+					resp, err = next.DisableKey(ctx, req)
+					return err
+				})
+				return resp, err
+			},
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package signerapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	recorderOnce sync.Once
+	recorder     *tracetest.SpanRecorder
+)
+
+// spanRecorder 安装进程级的记录器；全局 tracer 只会绑定首个 TracerProvider，因此各测试共用并按 trace ID 过滤。
+func spanRecorder() *tracetest.SpanRecorder {
+	recorderOnce.Do(func() {
+		recorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	return recorder
+}
+
+func spansInTrace(rec *tracetest.SpanRecorder, traceID string) map[string]sdktrace.ReadOnlySpan {
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range rec.Ended() {
+		if s.SpanContext().TraceID().String() == traceID {
+			spans[s.Name()] = s
+		}
+	}
+	return spans
+}
+
+func TestHTTPTracingContinuesUpstreamTrace(t *testing.T) {
+	rec := spanRecorder()
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	backend := Chain(&stubBackend{signFn: func(ctx context.Context, _ *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		require.True(t, trace.SpanContextFromContext(ctx).IsValid())
+		return &signerv1.SignResponse{}, nil
+	}}, TracingMiddleware())
+	handler := RequestIDMiddleware(HTTPTracingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := backend.Sign(r.Context(), &signerv1.SignRequest{KeyId: "k1"})
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusAccepted)
+	})))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/sign", strings.NewReader("{}"))
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	req.Header.Set(RequestIDHeader, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := spansInTrace(rec, traceID)
+	server, backendSpan := spans["HTTP POST"], spans["backend.sign"]
+	require.NotNil(t, server)
+	require.NotNil(t, backendSpan)
+	require.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	require.Equal(t, server.SpanContext().SpanID(), backendSpan.Parent().SpanID())
+	attrs := map[string]any{}
+	for _, kv := range server.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	require.Equal(t, "req-1", attrs["request_id"])
+	require.EqualValues(t, http.StatusAccepted, attrs["http.response.status_code"])
+}
+
+func TestTracingMiddlewareSkipsUntracedCalls(t *testing.T) {
+	rec := spanRecorder()
+	before := len(rec.Ended())
+	_, err := Chain(&stubBackend{}, TracingMiddleware()).Sign(context.Background(), &signerv1.SignRequest{KeyId: "k1"})
+	require.NoError(t, err)
+	require.Len(t, rec.Ended(), before)
+}
@@ -0,0 +1,97 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/aegis-sign/wallet/pkg/curves"
+	"github.com/aegis-sign/wallet/pkg/ethtx"
+)
+
+type signTxRequestBody struct {
+	KeyID        string        `json:"keyId"`
+	UnsignedTx   string        `json:"unsignedTx"`
+	ChainID      uint64        `json:"chainId,omitempty"`
+	AuditHeaders *auditHeaders `json:"auditHeaders"`
+}
+
+type signTxResponseBody struct {
+	SignedTx string `json:"signedTx"`
+	TxHash   string `json:"txHash"`
+	Type     uint32 `json:"type"`
+	ChainID  uint64 `json:"chainId"`
+}
+
+// signTransaction 解析未签名交易，以其签名哈希经 backend.Sign 签名（沿用停用、用量等中间件），再组装已签名交易。
+// 交易非法返回 INVALID_ARGUMENT；Enclave 返回的签名无法编码时视为内部错误。
+func signTransaction(ctx context.Context, backend Backend, req *signerv1.SignTransactionRequest) (*signerv1.SignTransactionResponse, error) {
+	if req.GetKeyId() == "" {
+		return nil, apierrors.New(apierrors.CodeInvalidArgument, "keyId is required")
+	}
+	tx, err := ethtx.Decode(req.GetUnsignedTx(), req.GetChainId())
+	if err != nil {
+		return nil, apierrors.New(apierrors.CodeInvalidArgument, err.Error())
+	}
+	hash := tx.SigningHash()
+	resp, err := backend.Sign(ctx, &signerv1.SignRequest{
+		KeyId:        req.GetKeyId(),
+		Digest:       hash[:],
+		Curve:        curves.DefaultName,
+		AuditContext: req.GetAuditContext(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 未设置 recId 时只能取 65B 签名的末字节，不能按 0 处理，否则会组装出恢复到其他地址的交易。
+	recID := resp.GetRecId()
+	if resp.RecId == nil {
+		sig := resp.GetSignature()
+		if len(sig) != 65 {
+			return nil, errors.New("assemble signed transaction: signature has no recId")
+		}
+		recID = uint32(sig[64])
+	}
+	signed, err := tx.WithSignature(resp.GetSignature(), recID)
+	if err != nil {
+		return nil, fmt.Errorf("assemble signed transaction: %w", err)
+	}
+	txHash := ethtx.Hash(signed)
+	return &signerv1.SignTransactionResponse{
+		SignedTx: signed,
+		TxHash:   txHash[:],
+		TxType:   uint32(tx.Type),
+		ChainId:  tx.ChainID,
+	}, nil
+}
+
+// handleSignTx 经 SignerService.SignTransaction 处理 POST /sign/tx：unsignedTx 为 hex（可带 0x），
+// 响应中的 signedTx/txHash 为 0x 前缀 hex。
+func (h *HTTPHandler) handleSignTx(w http.ResponseWriter, r *http.Request) {
+	h.serveGateway(w, r, signerMethods["SignTransaction"], v1Codec(http.MethodPost,
+		func(w http.ResponseWriter, r *http.Request, in *signerv1.SignTransactionRequest) *apierrors.Error {
+			var body signTxRequestBody
+			if apiErr := h.decodeJSON(w, r, &body, false); apiErr != nil {
+				return apiErr
+			}
+			raw, err := decodeHexField(body.UnsignedTx)
+			if err != nil || len(raw) == 0 {
+				return apierrors.New(apierrors.CodeInvalidArgument, "unsignedTx must be non-empty hex")
+			}
+			in.KeyId, in.UnsignedTx, in.ChainId = body.KeyID, raw, body.ChainID
+			in.AuditContext = auditContextFrom(withAuditHeaders(r.Context(), body.AuditHeaders))
+			return nil
+		},
+		func(resp *signerv1.SignTransactionResponse) signTxResponseBody {
+			return signTxResponseBody{
+				SignedTx: "0x" + hex.EncodeToString(resp.GetSignedTx()),
+				TxHash:   "0x" + hex.EncodeToString(resp.GetTxHash()),
+				Type:     resp.GetTxType(),
+				ChainID:  resp.GetChainId(),
+			}
+		}))
+}
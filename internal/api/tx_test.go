@@ -0,0 +1,97 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// EIP-155 规范示例：未签名交易、签名哈希与 chainId=1 下的签名结果。
+const (
+	txUnsigned = "e9098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080"
+	txHashSign = "daf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53"
+	txSig      = "28ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa63627667cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+	txSigned   = "f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+)
+
+func txBackend(t *testing.T) *stubBackend {
+	return &stubBackend{
+		signFn: func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			require.Equal(t, txHashSign, hex.EncodeToString(req.GetDigest()))
+			require.Equal(t, "secp256k1", req.GetCurve())
+			sig, _ := hex.DecodeString(txSig)
+			return &signerv1.SignResponse{Signature: sig, RecId: proto.Uint32(0)}, nil
+		},
+	}
+}
+
+func TestHandleSignTxLegacy(t *testing.T) {
+	handler := NewHTTPHandler(txBackend(t))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sign/tx", strings.NewReader(`{"keyId":"k1","unsignedTx":"0x`+txUnsigned+`","chainId":1}`)))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var body signTxResponseBody
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	require.Equal(t, "0x"+txSigned, body.SignedTx)
+	require.Equal(t, uint32(0), body.Type)
+	require.Equal(t, uint64(1), body.ChainID)
+	require.Len(t, body.TxHash, 66)
+
+	for _, payload := range []string{
+		`{"keyId":"k1","unsignedTx":"0x` + txUnsigned + `"}`,
+		`{"keyId":"k1","unsignedTx":"zz","chainId":1}`,
+		`{"unsignedTx":"0x` + txUnsigned + `","chainId":1}`,
+	} {
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sign/tx", strings.NewReader(payload)))
+		require.Equal(t, http.StatusBadRequest, rr.Code, payload)
+	}
+}
+
+func TestGRPCSignTransaction(t *testing.T) {
+	server := NewGRPCServer(txBackend(t), nil)
+	raw, _ := hex.DecodeString(txUnsigned)
+	resp, err := server.SignTransaction(context.Background(), &signerv1.SignTransactionRequest{KeyId: "k1", UnsignedTx: raw, ChainId: 1})
+	require.NoError(t, err)
+	require.Equal(t, txSigned, hex.EncodeToString(resp.GetSignedTx()))
+
+	// 9 字段的 EIP-155 载荷内嵌 chainId=1，与请求的 chainId 冲突。
+	payload, _ := hex.DecodeString("ec098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080018080")
+	_, err = server.SignTransaction(context.Background(), &signerv1.SignTransactionRequest{KeyId: "k1", UnsignedTx: payload, ChainId: 5})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.SignTransaction(context.Background(), &signerv1.SignTransactionRequest{KeyId: "k1"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestSignTransactionRecIDSource(t *testing.T) {
+	sig, _ := hex.DecodeString(txSig)
+	raw, _ := hex.DecodeString(txUnsigned)
+	req := &signerv1.SignTransactionRequest{KeyId: "k1", UnsignedTx: raw, ChainId: 1}
+	sign := func(resp *signerv1.SignResponse) (*signerv1.SignTransactionResponse, error) {
+		return signTransaction(context.Background(), &stubBackend{signFn: func(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			return resp, nil
+		}}, req)
+	}
+
+	// 未设置 recId 时取 65B 签名的末字节。
+	resp, err := sign(&signerv1.SignResponse{Signature: append(sig[:64:64], 0x00)})
+	require.NoError(t, err)
+	require.Equal(t, txSigned, hex.EncodeToString(resp.GetSignedTx()))
+
+	// 64B 签名且未设置 recId 时拒绝组装，而不是按 0 处理。
+	_, err = sign(&signerv1.SignResponse{Signature: sig})
+	require.ErrorContains(t, err, "signature has no recId")
+}
@@ -0,0 +1,111 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/aegis-sign/wallet/pkg/curves"
+	"github.com/aegis-sign/wallet/pkg/eip712"
+)
+
+type typedDataRequestBody struct {
+	KeyID        string          `json:"keyId"`
+	TypedData    json.RawMessage `json:"typedData"`
+	AuditHeaders *auditHeaders   `json:"auditHeaders"`
+}
+
+// typedDataResponseBody 在签名结果之外回显服务端计算的摘要，便于调用方核对。
+type typedDataResponseBody struct {
+	signResponseBody
+	Digest string `json:"digest"`
+}
+
+// handleSignTypedData 处理 POST /sign/typed-data：服务端按 EIP-712 计算摘要后经 backend.Sign 签名，
+// 每次请求（含失败）输出 typed data sign audit 日志，记录实际签名的 domain 与摘要。
+func (h *HTTPHandler) handleSignTypedData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "POST required"))
+		return
+	}
+	var body typedDataRequestBody
+	if apiErr := h.decodeJSON(w, r, &body, false); apiErr != nil {
+		h.writeAPIError(w, apiErr)
+		return
+	}
+	if body.KeyID == "" {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "keyId is required"))
+		return
+	}
+	if len(body.TypedData) == 0 {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "typedData is required"))
+		return
+	}
+	td, err := eip712.Parse(body.TypedData)
+	if err != nil {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, err.Error()))
+		return
+	}
+	digest, err := td.Hash()
+	if err != nil {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, err.Error()))
+		return
+	}
+	ctx := withAuditHeaders(r.Context(), body.AuditHeaders)
+	resp, err := h.backend.Sign(ctx, &signerv1.SignRequest{
+		KeyId:        body.KeyID,
+		Digest:       digest[:],
+		Curve:        curves.DefaultName,
+		AuditContext: auditContextFrom(ctx),
+	})
+	auditTypedData(ctx, h.logger, body.KeyID, td, digest, err)
+	if err != nil {
+		if h.tryHandleUnlock(w, ctx, body.KeyID, err) {
+			return
+		}
+		h.writeUnknownError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, typedDataResponseBody{
+		signResponseBody: newSignResponseBody(resp),
+		Digest:           hex.EncodeToString(digest[:]),
+	})
+}
+
+func auditTypedData(ctx context.Context, logger *slog.Logger, keyID string, td *eip712.TypedData, digest [32]byte, err error) {
+	principal := "anonymous"
+	if p, ok := reqctx.PrincipalFrom(ctx); ok {
+		principal = p.Subject
+	}
+	attrs := []slog.Attr{
+		slog.String("principal", principal),
+		slog.String("key", keyID),
+		slog.String("primary_type", td.PrimaryType),
+		slog.String("domain_name", domainString(td, "name")),
+		slog.String("chain_id", domainString(td, "chainId")),
+		slog.String("verifying_contract", domainString(td, "verifyingContract")),
+		slog.String("digest", hex.EncodeToString(digest[:])),
+		slog.String("code", errorCodeLabel(err)),
+	}
+	if requestID, ok := reqctx.RequestIDFrom(ctx); ok {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+	if tenantID, ok := reqctx.TenantIDFrom(ctx); ok {
+		attrs = append(attrs, slog.String("tenant_id", tenantID))
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "typed data sign audit", attrs...)
+}
+
+func domainString(td *eip712.TypedData, field string) string {
+	v, ok := td.Domain[field]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,57 @@
+package signerapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+const typedDataMail = `{"types":{"EIP712Domain":[{"name":"name","type":"string"},{"name":"version","type":"string"},{"name":"chainId","type":"uint256"},{"name":"verifyingContract","type":"address"}],` +
+	`"Person":[{"name":"name","type":"string"},{"name":"wallet","type":"address"}],` +
+	`"Mail":[{"name":"from","type":"Person"},{"name":"to","type":"Person"},{"name":"contents","type":"string"}]},` +
+	`"primaryType":"Mail","domain":{"name":"Ether Mail","version":"1","chainId":1,"verifyingContract":"0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"},` +
+	`"message":{"from":{"name":"Cow","wallet":"0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},"to":{"name":"Bob","wallet":"0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},"contents":"Hello, Bob!"}}`
+
+func TestHandleSignTypedData(t *testing.T) {
+	const digest = "be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"
+	var logs bytes.Buffer
+	handler := NewHTTPHandler(&stubBackend{
+		signFn: func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+			require.Equal(t, digest, hex.EncodeToString(req.GetDigest()))
+			return &signerv1.SignResponse{Signature: []byte{0xaa}, RecId: proto.Uint32(1)}, nil
+		},
+	}, WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sign/typed-data", strings.NewReader(`{"keyId":"k1","typedData":`+typedDataMail+`}`)))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var body typedDataResponseBody
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	require.Equal(t, digest, body.Digest)
+	require.Equal(t, "aa", body.Signature)
+	require.Contains(t, logs.String(), `"msg":"typed data sign audit"`)
+	require.Contains(t, logs.String(), `"domain_name":"Ether Mail"`)
+	require.Contains(t, logs.String(), `"digest":"`+digest+`"`)
+
+	for _, payload := range []string{
+		`{"typedData":` + typedDataMail + `}`,
+		`{"keyId":"k1"}`,
+		`{"keyId":"k1","typedData":` + strings.Replace(typedDataMail, `"contents":"Hello, Bob!"`, `"contents":1`, 1) + `}`,
+	} {
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sign/typed-data", strings.NewReader(payload)))
+		require.Equal(t, http.StatusBadRequest, rr.Code, payload)
+	}
+}
@@ -8,20 +8,24 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
 	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
 )
 
-// UnlockQueue 抽象后端解锁队列，供 HTTP/gRPC handler 注入。
-type UnlockQueue interface {
-	NotifyUnlock(ctx context.Context, event keycache.UnlockEvent) error
-}
+// UnlockQueue 抽象后端解锁队列，供 HTTP/gRPC handler 注入；handler 只入队，不关心执行结果。
+type UnlockQueue = keycache.Notifier
 
 // UnlockResponderConfig 配置 UnlockResponder 行为。
 type UnlockResponderConfig struct {
-	Queue    UnlockQueue
+	Queue UnlockQueue
+	// Keyspace 为无法确定租户时解锁事件使用的 keyspace，默认 default。
 	Keyspace string
-	MinRetry time.Duration
-	MaxRetry time.Duration
+	// PerTenantKeyspace 与 KeyspaceConfig.PerTenant 一致，为 true 时解锁事件按凭证租户分区。
+	PerTenantKeyspace bool
+	MinRetry          time.Duration
+	MaxRetry          time.Duration
+	// Hints 在入队被拒（队列满/限流）时根据实时状态放大 Retry-After。
+	Hints *RetryHintProvider
 }
 
 // UnlockMetadata 表示一次解锁响应所需的元数据。
@@ -32,12 +36,13 @@ type UnlockMetadata struct {
 
 // UnlockResponder 负责将 UNLOCK_REQUIRED 错误放入后台队列并生成客户端提示。
 type UnlockResponder struct {
-	queue    UnlockQueue
-	keyspace string
-	minRetry time.Duration
-	maxRetry time.Duration
-	rng      *rand.Rand
-	seq      atomic.Uint64
+	queue     UnlockQueue
+	keyspaces KeyspaceConfig
+	minRetry  time.Duration
+	maxRetry  time.Duration
+	hints     *RetryHintProvider
+	rng       *rand.Rand
+	seq       atomic.Uint64
 }
 
 // NewUnlockResponder 构造 UnlockResponder，若 Queue 为空则退化为仅生成 Retry-After。
@@ -53,36 +58,44 @@ func NewUnlockResponder(cfg UnlockResponderConfig) *UnlockResponder {
 	if max < min {
 		max = min
 	}
-	keyspace := cfg.Keyspace
-	if keyspace == "" {
-		keyspace = "default"
-	}
 	return &UnlockResponder{
-		queue:    cfg.Queue,
-		keyspace: keyspace,
-		minRetry: min,
-		maxRetry: max,
-		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		queue:     cfg.Queue,
+		keyspaces: KeyspaceConfig{Default: cfg.Keyspace, PerTenant: cfg.PerTenantKeyspace}.withDefaults(),
+		minRetry:  min,
+		maxRetry:  max,
+		hints:     cfg.Hints,
+		rng:       rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
 // Handle 处理 UNLOCK_REQUIRED 错误，返回客户端需要的 request id 与 Retry-After。
+// 上下文中已有解锁任务 ID 或 keyspace 时优先使用。
 func (r *UnlockResponder) Handle(ctx context.Context, keyID string, unlockErr error) UnlockMetadata {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 	retryAfter := r.randomRetry()
 	reason, refreshBudget := extractUnlockReason(unlockErr)
-	requestID := r.nextRequestID(keyID)
+	requestID, ok := reqctx.UnlockRequestIDFrom(ctx)
+	if !ok {
+		requestID = r.nextRequestID(keyID)
+	}
 	if r.queue != nil && keyID != "" {
 		event := keycache.UnlockEvent{
-			Keyspace:      r.keyspace,
+			Keyspace:      r.keyspaces.Resolve(ctx),
 			KeyID:         keyID,
 			Reason:        reason,
 			RefreshBudget: refreshBudget,
 			RequestID:     requestID,
 		}
-		_ = r.queue.NotifyUnlock(ctx, event)
+		if ue, ok := keycache.AsUnlockRequired(unlockErr); ok {
+			event.Epoch = ue.Epoch()
+		}
+		if err := r.queue.NotifyUnlock(ctx, event); err != nil && r.hints != nil {
+			if hint := r.hints.HintForError(err); hint > retryAfter {
+				retryAfter = hint
+			}
+		}
 	}
 	return UnlockMetadata{RequestID: requestID, RetryAfter: retryAfter}
 }
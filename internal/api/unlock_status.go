@@ -0,0 +1,41 @@
+package signerapi
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/aegis-sign/wallet/internal/gateway/unlock"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+)
+
+// UnlockStatusSource 按 request id 查询后台解锁任务，*unlock.Dispatcher 满足。
+type UnlockStatusSource interface {
+	RequestStatus(requestID string) (unlock.RequestStatus, bool)
+}
+
+// WithUnlockStatus 挂载 GET /unlock/{requestId}，供调用方与值班人员查询 X-Unlock-Request-Id 对应任务的进度。
+func WithUnlockStatus(src UnlockStatusSource) HTTPOption {
+	return func(h *HTTPHandler) {
+		h.unlockStatus = src
+	}
+}
+
+// handleUnlockStatus 返回 queued/in_flight/succeeded/failed 与执行次数；
+// 从未入队或结束超过保留期的 request id 返回 404 NOT_FOUND。
+func (h *HTTPHandler) handleUnlockStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "GET required"))
+		return
+	}
+	_, requestID, _ := strings.Cut(r.URL.Path, "/unlock/")
+	if requestID == "" || strings.Contains(requestID, "/") {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "path must be /unlock/{requestId}"))
+		return
+	}
+	status, ok := h.unlockStatus.RequestStatus(requestID)
+	if !ok {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{Code: "NOT_FOUND", Message: "unknown unlock request id"})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, status)
+}
@@ -0,0 +1,49 @@
+package signerapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aegis-sign/wallet/internal/gateway/unlock"
+	"github.com/stretchr/testify/require"
+)
+
+type staticUnlockStatus map[string]unlock.RequestStatus
+
+func (s staticUnlockStatus) RequestStatus(requestID string) (unlock.RequestStatus, bool) {
+	status, ok := s[requestID]
+	return status, ok
+}
+
+func TestHandleUnlockStatus(t *testing.T) {
+	src := staticUnlockStatus{"unlock-1-k1": {RequestID: "unlock-1-k1", KeyID: "k1", Status: unlock.JobInFlight, Attempts: 2, JobRequestID: "unlock-1-k1"}}
+	handler := NewHTTPHandler(&stubBackend{}, WithUnlockStatus(src), WithUnlockWatcher(NewUnlockWatcher(UnlockWatcherConfig{})))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	for _, path := range []string{"/v1/unlock/unlock-1-k1", "/unlock/unlock-1-k1"} {
+		rr := get(path)
+		require.Equal(t, http.StatusOK, rr.Code, path)
+		var got unlock.RequestStatus
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		require.Equal(t, unlock.JobInFlight, got.Status)
+		require.Equal(t, 2, got.Attempts)
+	}
+
+	rr := get("/v1/unlock/missing")
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	var resp errorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, "NOT_FOUND", resp.Code)
+	require.Equal(t, http.StatusBadRequest, get("/v1/unlock/").Code)
+	// /unlock/events 仍由 SSE 订阅处理。
+	require.Equal(t, http.StatusBadRequest, get("/v1/unlock/events").Code)
+	require.Contains(t, get("/v1/unlock/events").Body.String(), "keyId is required")
+}
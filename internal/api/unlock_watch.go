@@ -0,0 +1,293 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// UnlockWatcherConfig 配置 UnlockWatcher。
+type UnlockWatcherConfig struct {
+	// Next 可选，结果推送给订阅者后继续转发给下游 ResultSink（如 keycache.Store）。
+	Next keycache.ResultSink
+	// Retention 为已完成结果的保留时长，覆盖解锁先于订阅完成的竞态，默认 5s。
+	Retention time.Duration
+	// MaxWait 为单次订阅的最长等待时间，默认 30s。
+	MaxWait time.Duration
+	// MaxWatchers 为同时等待的订阅数上限，超过时返回 RETRY_LATER，默认 4096。
+	MaxWatchers int
+	// KeepAlive 为 SSE 注释心跳间隔，避免代理断开空闲连接，默认 15s。
+	KeepAlive time.Duration
+}
+
+func (c UnlockWatcherConfig) withDefaults() UnlockWatcherConfig {
+	if c.Retention <= 0 {
+		c.Retention = 5 * time.Second
+	}
+	if c.MaxWait <= 0 {
+		c.MaxWait = 30 * time.Second
+	}
+	if c.MaxWatchers <= 0 {
+		c.MaxWatchers = 4096
+	}
+	if c.KeepAlive <= 0 {
+		c.KeepAlive = 15 * time.Second
+	}
+	return c
+}
+
+// UnlockWatcher 实现 keycache.ResultSink，把 Dispatcher 回传的解锁终态按 key 推送给
+// WatchUnlock / SSE 订阅者，使收到 UNLOCK_REQUIRED 的调用方无需盲目按 Retry-After 轮询。
+// 同一 key 的解锁请求在 Dispatcher 中合并，因此按 key 而非 request id 匹配。
+type UnlockWatcher struct {
+	cfg UnlockWatcherConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	waiters  map[string]map[*UnlockSubscription]struct{}
+	watchers int
+	recent   map[string]recentUnlock
+	expiry   []recentExpiry
+}
+
+type recentUnlock struct {
+	result keycache.UnlockResult
+	at     time.Time
+}
+
+type recentExpiry struct {
+	keyID string
+	at    time.Time
+}
+
+// NewUnlockWatcher 构造 UnlockWatcher。
+func NewUnlockWatcher(cfg UnlockWatcherConfig) *UnlockWatcher {
+	return &UnlockWatcher{
+		cfg:     cfg.withDefaults(),
+		now:     time.Now,
+		waiters: make(map[string]map[*UnlockSubscription]struct{}),
+		recent:  make(map[string]recentUnlock),
+	}
+}
+
+// UnlockSubscription 是一次订阅，C 最多收到一个结果。
+type UnlockSubscription struct {
+	C <-chan keycache.UnlockResult
+
+	ch      chan keycache.UnlockResult
+	keyID   string
+	watcher *UnlockWatcher
+	once    sync.Once
+}
+
+// Close 取消订阅；已收到结果后调用无副作用。
+func (s *UnlockSubscription) Close() {
+	s.once.Do(func() {
+		w := s.watcher
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if subs, ok := w.waiters[s.keyID]; ok {
+			if _, ok := subs[s]; ok {
+				delete(subs, s)
+				w.watchers--
+				if len(subs) == 0 {
+					delete(w.waiters, s.keyID)
+				}
+			}
+		}
+	})
+}
+
+// Ack 实现 ResultSink：记录结果、唤醒该 key 的全部订阅者，再转发给 Next。
+func (w *UnlockWatcher) Ack(ctx context.Context, result keycache.UnlockResult) {
+	now := w.now()
+	w.mu.Lock()
+	w.pruneLocked(now)
+	w.recent[result.KeyID] = recentUnlock{result: result, at: now}
+	w.expiry = append(w.expiry, recentExpiry{keyID: result.KeyID, at: now})
+	for sub := range w.waiters[result.KeyID] {
+		sub.ch <- result
+	}
+	w.watchers -= len(w.waiters[result.KeyID])
+	delete(w.waiters, result.KeyID)
+	w.mu.Unlock()
+	if w.cfg.Next != nil {
+		w.cfg.Next.Ack(ctx, result)
+	}
+}
+
+// Subscribe 订阅 keyID 的下一个解锁结果；保留期内已有结果时立即可读。
+func (w *UnlockWatcher) Subscribe(keyID string) (*UnlockSubscription, error) {
+	if keyID == "" {
+		return nil, apierrors.New(apierrors.CodeInvalidArgument, "keyId is required")
+	}
+	ch := make(chan keycache.UnlockResult, 1)
+	sub := &UnlockSubscription{C: ch, ch: ch, keyID: keyID, watcher: w}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pruneLocked(w.now())
+	if recent, ok := w.recent[keyID]; ok {
+		ch <- recent.result
+		sub.once.Do(func() {})
+		return sub, nil
+	}
+	if w.watchers >= w.cfg.MaxWatchers {
+		return nil, apierrors.New(apierrors.CodeRetryLater, "too many unlock watchers")
+	}
+	subs, ok := w.waiters[keyID]
+	if !ok {
+		subs = make(map[*UnlockSubscription]struct{})
+		w.waiters[keyID] = subs
+	}
+	subs[sub] = struct{}{}
+	w.watchers++
+	return sub, nil
+}
+
+// Wait 订阅并阻塞至结果到达、ctx 结束或超过 MaxWait（返回 context.DeadlineExceeded）。
+func (w *UnlockWatcher) Wait(ctx context.Context, keyID string) (keycache.UnlockResult, error) {
+	sub, err := w.Subscribe(keyID)
+	if err != nil {
+		return keycache.UnlockResult{}, err
+	}
+	defer sub.Close()
+	ctx, cancel := context.WithTimeout(ctx, w.cfg.MaxWait)
+	defer cancel()
+	select {
+	case result := <-sub.C:
+		return result, nil
+	case <-ctx.Done():
+		return keycache.UnlockResult{}, ctx.Err()
+	}
+}
+
+// pruneLocked 按完成顺序淘汰超过 Retention 的结果。
+func (w *UnlockWatcher) pruneLocked(now time.Time) {
+	cutoff := now.Add(-w.cfg.Retention)
+	n := 0
+	for ; n < len(w.expiry) && !w.expiry[n].at.After(cutoff); n++ {
+		exp := w.expiry[n]
+		if recent, ok := w.recent[exp.keyID]; ok && recent.at.Equal(exp.at) {
+			delete(w.recent, exp.keyID)
+		}
+	}
+	if n > 0 {
+		w.expiry = append(w.expiry[:0], w.expiry[n:]...)
+	}
+}
+
+// unlockEvent 将解锁结果转换为 proto 事件，失败原因以 UNLOCK_REQUIRED 表示。
+func unlockEvent(result keycache.UnlockResult) *signerv1.UnlockEvent {
+	event := &signerv1.UnlockEvent{
+		KeyId:     result.KeyID,
+		RequestId: result.RequestID,
+		Success:   result.Success,
+		Attempts:  uint32(max(result.Attempts, 0)),
+	}
+	if !result.Success {
+		message := "unlock failed"
+		if result.Err != nil {
+			message = result.Err.Error()
+		}
+		event.Error = &signerv1.ErrorStatus{Code: signerv1.ApiErrorCode_API_ERROR_CODE_UNLOCK_REQUIRED, Message: message}
+	}
+	return event
+}
+
+// SetUnlockWatcher 启用 WatchUnlock，nil 时返回 UNIMPLEMENTED。
+func (s *GRPCServer) SetUnlockWatcher(w *UnlockWatcher) {
+	s.watcher = w
+}
+
+// WatchUnlock 等待 key 的解锁结果，推送一条 UnlockEvent 后结束流。
+func (s *GRPCServer) WatchUnlock(req *signerv1.WatchUnlockRequest, stream signerv1.SignerService_WatchUnlockServer) error {
+	if s.watcher == nil {
+		return status.Error(codes.Unimplemented, "unlock notifications are not enabled")
+	}
+	result, err := s.watcher.Wait(stream.Context(), req.GetKeyId())
+	if err != nil {
+		if apiErr, ok := apierrors.FromError(err); ok {
+			return apiStatusError(apiErr)
+		}
+		return status.FromContextError(err).Err()
+	}
+	return stream.Send(unlockEvent(result))
+}
+
+// WithUnlockWatcher 挂载 GET /unlock/events SSE 订阅接口，nil 时不注册。
+func WithUnlockWatcher(w *UnlockWatcher) HTTPOption {
+	return func(h *HTTPHandler) {
+		h.watcher = w
+	}
+}
+
+// handleUnlockEvents 以 SSE 推送 key 的解锁结果：unlock 事件携带 UnlockEvent 后关闭连接，
+// 超过 MaxWait 时发送 timeout 事件；等待期间按 KeepAlive 发送注释心跳。
+func (h *HTTPHandler) handleUnlockEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "GET required"))
+		return
+	}
+	keyID := r.URL.Query().Get("keyId")
+	sub, err := h.watcher.Subscribe(keyID)
+	if err != nil {
+		apiErr, _ := apierrors.FromError(err)
+		h.writeAPIError(w, apiErr)
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", EventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	flush := func() bool {
+		err := rc.Flush()
+		return err == nil || errors.Is(err, http.ErrNotSupported)
+	}
+	if !flush() {
+		return
+	}
+	cfg := h.watcher.cfg
+	timeout := time.NewTimer(cfg.MaxWait)
+	defer timeout.Stop()
+	keepAlive := time.NewTicker(cfg.KeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case result := <-sub.C:
+			data, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(unlockEvent(result))
+			if err != nil {
+				return
+			}
+			if _, err := io.WriteString(w, "event: unlock\ndata: "+string(data)+"\n\n"); err == nil {
+				flush()
+			}
+			return
+		case <-timeout.C:
+			data, _ := json.Marshal(map[string]string{"keyId": keyID})
+			if _, err := io.WriteString(w, "event: timeout\ndata: "+string(data)+"\n\n"); err == nil {
+				flush()
+			}
+			return
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil || !flush() {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
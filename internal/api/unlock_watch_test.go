@@ -0,0 +1,154 @@
+package signerapi
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type recordingSink struct {
+	mu      sync.Mutex
+	results []keycache.UnlockResult
+}
+
+func (s *recordingSink) Ack(_ context.Context, result keycache.UnlockResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results = append(s.results, result)
+}
+
+func TestUnlockWatcherDeliversResults(t *testing.T) {
+	next := &recordingSink{}
+	now := time.Unix(1000, 0)
+	w := NewUnlockWatcher(UnlockWatcherConfig{Next: next, Retention: time.Second, MaxWatchers: 2})
+	w.now = func() time.Time { return now }
+
+	// 订阅后完成：所有订阅者收到结果并转发给 Next。
+	first, err := w.Subscribe("k1")
+	require.NoError(t, err)
+	second, err := w.Subscribe("k1")
+	require.NoError(t, err)
+	_, err = w.Subscribe("k2")
+	require.Equal(t, apierrors.CodeRetryLater, apiCode(err))
+	w.Ack(context.Background(), keycache.UnlockResult{KeyID: "k1", RequestID: "unlock-1-k1", Success: true, Attempts: 1})
+	require.Equal(t, "unlock-1-k1", (<-first.C).RequestID)
+	require.Equal(t, "unlock-1-k1", (<-second.C).RequestID)
+	require.Len(t, next.results, 1)
+	require.Zero(t, w.watchers)
+
+	// 完成先于订阅：保留期内立即返回，过期后需等待下一次结果。
+	got, err := w.Wait(context.Background(), "k1")
+	require.NoError(t, err)
+	require.True(t, got.Success)
+	now = now.Add(2 * time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = w.Wait(ctx, "k1")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Zero(t, w.watchers)
+	require.Empty(t, w.recent)
+
+	_, err = w.Subscribe("")
+	require.Equal(t, apierrors.CodeInvalidArgument, apiCode(err))
+}
+
+func apiCode(err error) apierrors.Code {
+	apiErr, ok := apierrors.FromError(err)
+	if !ok {
+		return ""
+	}
+	return apiErr.Code
+}
+
+type fakeWatchUnlockStream struct {
+	signerv1.SignerService_WatchUnlockServer
+	ctx  context.Context
+	sent []*signerv1.UnlockEvent
+}
+
+func (f *fakeWatchUnlockStream) Context() context.Context { return f.ctx }
+
+func (f *fakeWatchUnlockStream) Send(event *signerv1.UnlockEvent) error {
+	f.sent = append(f.sent, event)
+	return nil
+}
+
+func TestGRPCWatchUnlock(t *testing.T) {
+	srv := NewGRPCServer(&stubBackend{}, nil)
+	stream := &fakeWatchUnlockStream{ctx: context.Background()}
+	err := srv.WatchUnlock(&signerv1.WatchUnlockRequest{KeyId: "k1"}, stream)
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	w := NewUnlockWatcher(UnlockWatcherConfig{MaxWait: 20 * time.Millisecond})
+	srv.SetUnlockWatcher(w)
+	w.Ack(context.Background(), keycache.UnlockResult{KeyID: "k1", RequestID: "unlock-1-k1", Attempts: 3, Err: errors.New("kms denied")})
+	require.NoError(t, srv.WatchUnlock(&signerv1.WatchUnlockRequest{KeyId: "k1"}, stream))
+	require.Len(t, stream.sent, 1)
+	event := stream.sent[0]
+	require.False(t, event.GetSuccess())
+	require.Equal(t, uint32(3), event.GetAttempts())
+	require.Equal(t, signerv1.ApiErrorCode_API_ERROR_CODE_UNLOCK_REQUIRED, event.GetError().GetCode())
+	require.Equal(t, "kms denied", event.GetError().GetMessage())
+
+	err = srv.WatchUnlock(&signerv1.WatchUnlockRequest{KeyId: "k2"}, stream)
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	err = srv.WatchUnlock(&signerv1.WatchUnlockRequest{}, stream)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestUnlockEventsSSE(t *testing.T) {
+	w := NewUnlockWatcher(UnlockWatcherConfig{MaxWait: 50 * time.Millisecond})
+	handler := NewHTTPHandler(&stubBackend{}, WithUnlockWatcher(w))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v1/unlock/events?keyId=k1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, EventStreamContentType, resp.Header.Get("Content-Type"))
+	w.Ack(context.Background(), keycache.UnlockResult{KeyID: "k1", RequestID: "unlock-1-k1", Success: true, Attempts: 1})
+	br := bufio.NewReader(resp.Body)
+	line, err := br.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "event: unlock\n", line)
+	line, err = br.ReadString('\n')
+	require.NoError(t, err)
+	require.Contains(t, line, `"success":true`)
+	require.Contains(t, line, `"requestId":"unlock-1-k1"`)
+
+	// 超过 MaxWait 时以 timeout 事件结束。
+	resp, err = http.Get(srv.URL + "/v1/unlock/events?keyId=k2")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "event: timeout\n", body)
+
+	resp, err = http.Get(srv.URL + "/v1/unlock/events")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// 未设置 WithUnlockWatcher 时不注册。
+	plain := http.NewServeMux()
+	NewHTTPHandler(&stubBackend{}).Register(plain)
+	rr := httptest.NewRecorder()
+	plain.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/unlock/events?keyId=k1", strings.NewReader("")))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
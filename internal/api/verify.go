@@ -0,0 +1,222 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"github.com/aegis-sign/wallet/pkg/curves"
+	"github.com/aegis-sign/wallet/pkg/sigverify"
+	"github.com/aegis-sign/wallet/pkg/validator"
+)
+
+const defaultKeyLookupCacheSize = 4096
+
+// KeyInfo 是按 keyId 查询到的公钥信息。
+type KeyInfo struct {
+	// Curve 为空时视为默认曲线。
+	Curve     string
+	PublicKey []byte
+}
+
+// KeyLookup 按 keyId 查询公钥，/verify 只给 keyId 时使用。
+type KeyLookup interface {
+	LookupKey(ctx context.Context, keyID string) (KeyInfo, error)
+}
+
+// KeyLookupFunc 将函数适配为 KeyLookup。
+type KeyLookupFunc func(ctx context.Context, keyID string) (KeyInfo, error)
+
+// LookupKey 实现 KeyLookup。
+func (f KeyLookupFunc) LookupKey(ctx context.Context, keyID string) (KeyInfo, error) {
+	return f(ctx, keyID)
+}
+
+// keyLookupCache 缓存查询成功的公钥；公钥不可变，无需过期，只按容量整体淘汰。
+type keyLookupCache struct {
+	next    KeyLookup
+	maxSize int
+
+	mu      sync.RWMutex
+	entries map[string]KeyInfo
+}
+
+// NewCachedKeyLookup 为 next 加一层进程内缓存，maxSize<=0 时使用默认容量。
+func NewCachedKeyLookup(next KeyLookup, maxSize int) KeyLookup {
+	if next == nil {
+		return nil
+	}
+	if maxSize <= 0 {
+		maxSize = defaultKeyLookupCacheSize
+	}
+	return &keyLookupCache{next: next, maxSize: maxSize, entries: make(map[string]KeyInfo)}
+}
+
+func (c *keyLookupCache) LookupKey(ctx context.Context, keyID string) (KeyInfo, error) {
+	c.mu.RLock()
+	info, ok := c.entries[keyID]
+	c.mu.RUnlock()
+	if ok {
+		return info, nil
+	}
+	info, err := c.next.LookupKey(ctx, keyID)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+	info.PublicKey = append([]byte(nil), info.PublicKey...)
+	c.mu.Lock()
+	if len(c.entries) >= c.maxSize {
+		c.entries = make(map[string]KeyInfo)
+	}
+	c.entries[keyID] = info
+	c.mu.Unlock()
+	return info, nil
+}
+
+// WithKeyLookup 设置 /verify 按 keyId 解析公钥的来源，自动带缓存；未设置时必须直接提供 publicKey。
+func WithKeyLookup(lookup KeyLookup) HTTPOption {
+	return func(h *HTTPHandler) {
+		h.lookup = NewCachedKeyLookup(lookup, 0)
+	}
+}
+
+type verifyRequestBody struct {
+	KeyID     string  `json:"keyId"`
+	PublicKey string  `json:"publicKey"`
+	Curve     string  `json:"curve"`
+	Digest    string  `json:"digest"`
+	Encoding  string  `json:"encoding"`
+	Signature string  `json:"signature"`
+	RecID     *uint32 `json:"recId"`
+}
+
+type verifyResponseBody struct {
+	Valid            bool   `json:"valid"`
+	RecoveredAddress string `json:"recoveredAddress,omitempty"`
+}
+
+// handleVerify 在 Enclave 之外本地验签，签名不匹配返回 200 与 valid=false。
+func (h *HTTPHandler) handleVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "POST required"))
+		return
+	}
+	var body verifyRequestBody
+	if apiErr := h.decodeJSON(w, r, &body, false); apiErr != nil {
+		h.writeAPIError(w, apiErr)
+		return
+	}
+	if body.Digest == "" {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "digest is required"))
+		return
+	}
+	if body.Signature == "" {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "signature is required"))
+		return
+	}
+	encoding, err := validator.NormalizeEncoding(body.Encoding)
+	if err != nil {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, err.Error()))
+		return
+	}
+	digest, err := validator.DecodeDigest(body.Digest, encoding)
+	if err != nil {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, err.Error()))
+		return
+	}
+	sig, err := decodeHexField(body.Signature)
+	if err != nil {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "signature must be hex"))
+		return
+	}
+	curve, pub, err := h.resolveVerifyKey(r.Context(), body)
+	if err != nil {
+		h.writeUnknownError(w, err)
+		return
+	}
+	verifier, ok := defaultVerifiers[curve.Name]
+	if !ok {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, "verification not supported for curve "+curve.Name))
+		return
+	}
+	valid, err := verifier(pub, digest, sig)
+	if err != nil {
+		h.writeAPIError(w, apierrors.New(apierrors.CodeInvalidArgument, err.Error()))
+		return
+	}
+	resp := verifyResponseBody{Valid: valid}
+	if valid && curve.HasRecoveryID {
+		resp.RecoveredAddress = recoverAddress(pub, digest, sig, body.RecID)
+	}
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// resolveVerifyKey 优先使用请求中的 publicKey，否则经 KeyLookup 按 keyId 解析。
+func (h *HTTPHandler) resolveVerifyKey(ctx context.Context, body verifyRequestBody) (curves.Curve, []byte, error) {
+	if body.PublicKey != "" {
+		pub, err := decodeHexField(body.PublicKey)
+		if err != nil {
+			return curves.Curve{}, nil, apierrors.New(apierrors.CodeInvalidArgument, "publicKey must be hex")
+		}
+		curve, err := curves.Lookup(body.Curve)
+		if err != nil {
+			return curves.Curve{}, nil, apierrors.New(apierrors.CodeInvalidArgument, err.Error())
+		}
+		return curve, pub, nil
+	}
+	if body.KeyID == "" {
+		return curves.Curve{}, nil, apierrors.New(apierrors.CodeInvalidArgument, "keyId or publicKey is required")
+	}
+	if h.lookup == nil {
+		return curves.Curve{}, nil, apierrors.New(apierrors.CodeInvalidArgument, "publicKey is required: key lookup is not configured")
+	}
+	info, err := h.lookup.LookupKey(ctx, body.KeyID)
+	if err != nil {
+		return curves.Curve{}, nil, err
+	}
+	curve, err := curves.Lookup(info.Curve)
+	if err != nil {
+		return curves.Curve{}, nil, err
+	}
+	if body.Curve != "" && !strings.EqualFold(body.Curve, curve.Name) {
+		return curves.Curve{}, nil, apierrors.New(apierrors.CodeInvalidArgument, "curve does not match key "+body.KeyID)
+	}
+	return curve, info.PublicKey, nil
+}
+
+// recoverAddress 用 recId（请求字段或 65B 签名的末字节）恢复公钥，仅在与验签公钥一致时返回地址。
+func recoverAddress(pub, digest, sig []byte, recID *uint32) string {
+	var id byte
+	switch {
+	case recID != nil && *recID <= 255:
+		id = byte(*recID)
+	case len(sig) == 65:
+		id = sig[64]
+	default:
+		return ""
+	}
+	recovered, err := sigverify.RecoverSecp256k1(digest, sig, id)
+	if err != nil {
+		return ""
+	}
+	want, err := sigverify.EthereumAddress(pub)
+	if err != nil {
+		return ""
+	}
+	got, err := sigverify.EthereumAddress(recovered)
+	if err != nil || got != want {
+		return ""
+	}
+	return got
+}
+
+func decodeHexField(raw string) ([]byte, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) >= 2 && (raw[:2] == "0x" || raw[:2] == "0X") {
+		raw = raw[2:]
+	}
+	return hex.DecodeString(raw)
+}
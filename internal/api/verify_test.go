@@ -0,0 +1,129 @@
+package signerapi
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+)
+
+// 测试向量：secp256k1 私钥 1、ed25519 种子 00..1f，均对 sha256("aegis") 签名。
+const (
+	verifyDigest       = "598f7a741a1e3a05654d346033571fda567af6dc2bf099b34b930171519d995f"
+	secpVectorPub      = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	secpVectorSig      = "12faae608bd6562562b8f85564664cd1fdcd667f6b24b2b221ef86b9231f4d742f653f06ce661840b3cfc071b8cdecc6b367367a7d7831b22772517238a3da6c"
+	secpVectorAddress  = "0x7e5f4552091a69125d5dfcb7b8c2659029395bdf"
+	ed25519VectorPub   = "03a107bff3ce10be1d70dd18e74bc09967e4d6309ba50d5f1ddc8664125531b8"
+	ed25519VectorSig   = "948c0ac1dd757ff98700033c33fb998e6d78b90c233329b5c1b01a61404af673053a83568c1381cb1faee52491d76c53d9b6f11764db2df4c3d60066beabe605"
+	tamperedFirstDigit = "698f7a741a1e3a05654d346033571fda567af6dc2bf099b34b930171519d995f"
+)
+
+func doVerify(t *testing.T, h *HTTPHandler, body string) (*httptest.ResponseRecorder, verifyResponseBody) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	h.handleVerify(rr, httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(body)))
+	var resp verifyResponseBody
+	if rr.Code == http.StatusOK {
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+	}
+	return rr, resp
+}
+
+func TestHandleVerifyVectors(t *testing.T) {
+	h := NewHTTPHandler(&stubBackend{})
+	cases := []struct {
+		name    string
+		body    string
+		valid   bool
+		address string
+	}{
+		{
+			name:    "secp256k1 valid with recId",
+			body:    fmt.Sprintf(`{"publicKey":%q,"digest":%q,"signature":%q,"recId":1}`, secpVectorPub, verifyDigest, secpVectorSig),
+			valid:   true,
+			address: secpVectorAddress,
+		},
+		{
+			name:  "secp256k1 valid without recId",
+			body:  fmt.Sprintf(`{"publicKey":%q,"digest":%q,"signature":%q}`, secpVectorPub, verifyDigest, secpVectorSig),
+			valid: true,
+		},
+		{
+			name: "secp256k1 tampered digest",
+			body: fmt.Sprintf(`{"publicKey":%q,"digest":%q,"signature":%q,"recId":1}`, secpVectorPub, tamperedFirstDigit, secpVectorSig),
+		},
+		{
+			name:  "ed25519 valid",
+			body:  fmt.Sprintf(`{"publicKey":%q,"curve":"ed25519","digest":%q,"signature":%q}`, ed25519VectorPub, verifyDigest, ed25519VectorSig),
+			valid: true,
+		},
+		{
+			name: "ed25519 tampered digest",
+			body: fmt.Sprintf(`{"publicKey":%q,"curve":"ed25519","digest":%q,"signature":%q}`, ed25519VectorPub, tamperedFirstDigit, ed25519VectorSig),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr, resp := doVerify(t, h, tc.body)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status=%d body=%s", rr.Code, rr.Body.String())
+			}
+			if resp.Valid != tc.valid || resp.RecoveredAddress != tc.address {
+				t.Fatalf("unexpected response %+v", resp)
+			}
+		})
+	}
+}
+
+func TestHandleVerifyMalformedInput(t *testing.T) {
+	h := NewHTTPHandler(&stubBackend{})
+	for _, body := range []string{
+		`{"publicKey":"` + secpVectorPub + `","signature":"` + secpVectorSig + `"}`,
+		`{"publicKey":"` + secpVectorPub + `","digest":"abcd","signature":"` + secpVectorSig + `"}`,
+		`{"publicKey":"zz","digest":"` + verifyDigest + `","signature":"` + secpVectorSig + `"}`,
+		`{"publicKey":"0500","digest":"` + verifyDigest + `","signature":"` + secpVectorSig + `"}`,
+		`{"keyId":"k1","digest":"` + verifyDigest + `","signature":"` + secpVectorSig + `"}`,
+	} {
+		rr, _ := doVerify(t, h, body)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("body %s: status=%d", body, rr.Code)
+		}
+	}
+}
+
+func TestHandleVerifyResolvesKeyIDWithCache(t *testing.T) {
+	pub, err := hex.DecodeString(secpVectorPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	h := NewHTTPHandler(&stubBackend{}, WithKeyLookup(KeyLookupFunc(func(_ context.Context, keyID string) (KeyInfo, error) {
+		calls++
+		if keyID != "k-secp" {
+			return KeyInfo{}, apierrors.New(apierrors.CodeInvalidKey, "key not found")
+		}
+		return KeyInfo{Curve: "secp256k1", PublicKey: pub}, nil
+	})))
+	body := fmt.Sprintf(`{"keyId":"k-secp","digest":%q,"signature":%q,"recId":1}`, verifyDigest, secpVectorSig)
+	for i := 0; i < 2; i++ {
+		rr, resp := doVerify(t, h, body)
+		if rr.Code != http.StatusOK || !resp.Valid || resp.RecoveredAddress != secpVectorAddress {
+			t.Fatalf("status=%d resp=%+v", rr.Code, resp)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected cached lookup, got %d calls", calls)
+	}
+	rr, _ := doVerify(t, h, fmt.Sprintf(`{"keyId":"missing","digest":%q,"signature":%q}`, verifyDigest, secpVectorSig))
+	if rr.Code != apierrors.HTTPStatus(apierrors.CodeInvalidKey) {
+		t.Fatalf("lookup error not propagated: status=%d", rr.Code)
+	}
+}
@@ -0,0 +1,78 @@
+package signerapi
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APIVersionV1 是当前业务接口的版本前缀。
+const APIVersionV1 = "v1"
+
+// LegacyRoutesConfig 控制未带版本前缀的旧路径（/create、/sign 等）。
+type LegacyRoutesConfig struct {
+	// Disabled 为 true 时只注册 /v1 路径，旧路径返回 404。
+	Disabled bool
+	// Sunset 非零时在旧路径的响应中附带 Sunset 头（RFC 8594），告知下线时间。
+	Sunset time.Time
+}
+
+// WithLegacyRoutes 设置旧路径的兼容策略，默认保留并附带弃用头。
+func WithLegacyRoutes(cfg LegacyRoutesConfig) HTTPOption {
+	return func(h *HTTPHandler) {
+		h.legacy = cfg
+	}
+}
+
+// VersionedRouter 将 pattern 挂载到 /{version} 前缀下，例如 /sign → /v1/sign；
+// 同一 mux 可同时挂载多个版本，新版本只需实现各自的注册函数。
+func VersionedRouter(mux Router, version string) Router {
+	return prefixRouter{Router: mux, prefix: "/" + version}
+}
+
+type prefixRouter struct {
+	Router
+	prefix string
+}
+
+func (p prefixRouter) Handle(pattern string, handler http.Handler) {
+	p.Router.Handle(p.prefix+pattern, handler)
+}
+
+func (p prefixRouter) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	p.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// DeprecatedRouter 注册原路径作为 successor 版本的别名，响应附带 Deprecation 头（RFC 9745）
+// 与指向新路径的 Link 头；sunset 非零时附带 Sunset 头。
+func DeprecatedRouter(mux Router, successor string, sunset time.Time) Router {
+	return deprecatedRouter{Router: mux, successor: "/" + successor, sunset: sunset}
+}
+
+type deprecatedRouter struct {
+	Router
+	successor string
+	sunset    time.Time
+}
+
+func (d deprecatedRouter) Handle(pattern string, handler http.Handler) {
+	d.Router.Handle(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		header.Set("Deprecation", "true")
+		header.Add("Link", "<"+d.successor+r.URL.Path+`>; rel="successor-version"`)
+		if !d.sunset.IsZero() {
+			header.Set("Sunset", d.sunset.UTC().Format(http.TimeFormat))
+		}
+		handler.ServeHTTP(w, r)
+	}))
+}
+
+func (d deprecatedRouter) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	d.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// keyPathID 从 /keys/{rest} 或 /v1/keys/{rest} 中取出 {rest}。
+func keyPathID(path string) string {
+	_, rest, _ := strings.Cut(path, "/keys/")
+	return rest
+}
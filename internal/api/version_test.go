@@ -0,0 +1,47 @@
+package signerapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPHandlerVersionedRoutes(t *testing.T) {
+	sunset := time.Date(2027, 6, 30, 0, 0, 0, 0, time.UTC)
+	handler := NewHTTPHandler(&stubBackend{}, WithLegacyRoutes(LegacyRoutesConfig{Sunset: sunset}))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+	sign := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"keyId":"k1","digest":"`+strings.Repeat("a", 64)+`"}`)))
+		return rr
+	}
+
+	rr := sign("/v1/sign")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Empty(t, rr.Header().Get("Deprecation"))
+
+	rr = sign("/sign")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "true", rr.Header().Get("Deprecation"))
+	require.Equal(t, `</v1/sign>; rel="successor-version"`, rr.Header().Get("Link"))
+	require.Equal(t, "Wed, 30 Jun 2027 00:00:00 GMT", rr.Header().Get("Sunset"))
+
+	// /keys/{id} 在两种前缀下解析出相同的 keyId。
+	for _, path := range []string{"/v1/keys/k1", "/keys/k1"} {
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, path, nil))
+		require.Equal(t, http.StatusOK, rr.Code, path)
+		require.Contains(t, rr.Body.String(), `"keyId":"k1"`, path)
+	}
+
+	strict := http.NewServeMux()
+	NewHTTPHandler(&stubBackend{}, WithLegacyRoutes(LegacyRoutesConfig{Disabled: true})).Register(strict)
+	rr = httptest.NewRecorder()
+	strict.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sign", nil))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
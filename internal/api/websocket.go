@@ -0,0 +1,453 @@
+package signerapi
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/pkg/apierrors"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultWebSocketMaxMessageBytes = 64 << 10
+	defaultWebSocketPingInterval    = 30 * time.Second
+	defaultWebSocketWriteTimeout    = 10 * time.Second
+
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xa
+
+	wsCloseNormal        = 1000
+	wsCloseProtocolError = 1002
+	wsCloseUnsupported   = 1003
+	wsCloseTooBig        = 1009
+	wsCloseInternalError = 1011
+)
+
+// WebSocketConfig 配置 /ws/sign 签名通道。
+type WebSocketConfig struct {
+	// MaxMessageBytes 为单条消息（含分片）的上限，超限时以 1009 关闭连接，默认 64KiB。
+	MaxMessageBytes int64
+	// PingInterval 为服务端心跳间隔，连续两个间隔未收到任何帧时断开，默认 30s。
+	PingInterval time.Duration
+	// WriteTimeout 为单帧写出时限，对端停止读取导致超时时断开连接，释放写锁与流许可，默认 10s。
+	WriteTimeout time.Duration
+	// AllowedOrigins 为允许升级的浏览器 Origin（如 https://wallet.example.com），"*" 允许任意来源。
+	// 未携带 Origin 的非浏览器客户端不受限制；携带 Origin 但不在列表中的请求返回 403，默认拒绝全部浏览器来源，
+	// 防止持有凭证的页面被第三方站点跨站发起连接。
+	AllowedOrigins []string
+}
+
+func (c WebSocketConfig) withDefaults() WebSocketConfig {
+	if c.MaxMessageBytes <= 0 {
+		c.MaxMessageBytes = defaultWebSocketMaxMessageBytes
+	}
+	if c.PingInterval <= 0 {
+		c.PingInterval = defaultWebSocketPingInterval
+	}
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = defaultWebSocketWriteTimeout
+	}
+	origins := make([]string, 0, len(c.AllowedOrigins))
+	for _, origin := range c.AllowedOrigins {
+		if origin = normalizeOrigin(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	c.AllowedOrigins = origins
+	return c
+}
+
+// allowOrigin 判断升级请求的 Origin 是否被允许，未携带 Origin 的请求视为非浏览器客户端。
+func (c WebSocketConfig) allowOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	origin = normalizeOrigin(origin)
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeOrigin(origin string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), "/")
+}
+
+// WithWebSocket 注册 /ws/sign：连接升级为 WebSocket 后映射到 srv 的 SignStream，
+// 每条文本消息为一个 SignRequest 的 proto JSON，响应为 SignResponse 并回显 requestId，
+// 流内并发、许可与 in-band 错误与 gRPC SignStream 完全一致。
+func WithWebSocket(srv signerv1.SignerServiceServer, cfg WebSocketConfig) HTTPOption {
+	return func(h *HTTPHandler) {
+		h.websocket = srv
+		h.websocketCfg = cfg.withDefaults()
+	}
+}
+
+func (h *HTTPHandler) handleWebSocketSign(w http.ResponseWriter, r *http.Request) {
+	accept, apiErr := websocketAccept(r)
+	if apiErr != nil {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		h.writeAPIError(w, apiErr)
+		return
+	}
+	cfg := h.websocketCfg
+	if !cfg.allowOrigin(r.Header.Get("Origin")) {
+		h.writeAPIError(w, apierrors.New(apierrors.CodePermissionDenied, "websocket origin not allowed"))
+		return
+	}
+	netConn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		h.writeUnknownError(w, err)
+		return
+	}
+	defer netConn.Close()
+	// 清除 http.Server 设置的读写超时，长连接改由心跳判定读端存活，写端按帧设置超时。
+	_ = netConn.SetDeadline(time.Time{})
+	_, err = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " + accept + "\r\n\r\n")
+	if err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		return
+	}
+	// 升级后请求上下文不再感知断连，由读循环在连接结束时取消。
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	ctx = metadata.NewIncomingContext(ctx, gatewayMetadata(r))
+	conn := &wsConn{conn: netConn, br: rw.Reader, maxMessage: cfg.MaxMessageBytes, idle: 2 * cfg.PingInterval, writeTimeout: cfg.WriteTimeout}
+	go conn.keepalive(ctx, cfg.PingInterval)
+	stream := &wsSignStream{ctx: ctx, conn: conn}
+	err = h.websocket.SignStream(stream)
+	cancel()
+	switch {
+	case err == nil:
+		conn.close(wsCloseNormal, "")
+	case errors.Is(err, errWebSocketClosed):
+	default:
+		var closeErr *wsCloseError
+		if errors.As(err, &closeErr) {
+			conn.close(closeErr.code, closeErr.reason)
+			return
+		}
+		h.logger.WarnContext(ctx, "websocket sign stream ended", "error", err)
+		conn.close(wsCloseInternalError, "internal error")
+	}
+}
+
+// websocketAccept 校验升级请求并计算 Sec-WebSocket-Accept。
+func websocketAccept(r *http.Request) (string, *apierrors.Error) {
+	if r.Method != http.MethodGet {
+		return "", apierrors.New(apierrors.CodeInvalidArgument, "GET required")
+	}
+	if !headerContainsToken(r.Header, "Connection", "upgrade") || !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return "", apierrors.New(apierrors.CodeInvalidArgument, "websocket upgrade required")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return "", apierrors.New(apierrors.CodeInvalidArgument, "unsupported Sec-WebSocket-Version")
+	}
+	key := strings.TrimSpace(r.Header.Get("Sec-WebSocket-Key"))
+	if raw, err := base64.StdEncoding.DecodeString(key); err != nil || len(raw) != 16 {
+		return "", apierrors.New(apierrors.CodeInvalidArgument, "invalid Sec-WebSocket-Key")
+	}
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsSignStream 以 WebSocket 连接实现 SignerService_SignStreamServer。
+// 无法解析或校验失败的消息在此直接回 in-band INVALID_ARGUMENT，不会中断整个连接。
+type wsSignStream struct {
+	ctx  context.Context
+	conn *wsConn
+}
+
+func (s *wsSignStream) Context() context.Context { return s.ctx }
+
+func (s *wsSignStream) Send(resp *signerv1.SignResponse) error {
+	data, err := protojson.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return s.conn.writeFrame(wsOpText, data)
+}
+
+func (s *wsSignStream) Recv() (*signerv1.SignRequest, error) {
+	for {
+		data, err := s.conn.readMessage()
+		if err != nil {
+			return nil, err
+		}
+		req := &signerv1.SignRequest{}
+		if err := protojson.Unmarshal(data, req); err != nil {
+			if err := s.reject(req, "invalid JSON message: "+err.Error()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if apiErr := checkSignDigest(req); apiErr != nil {
+			if err := s.reject(req, apiErr.Error()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return req, nil
+	}
+}
+
+func (s *wsSignStream) reject(req *signerv1.SignRequest, message string) error {
+	return s.Send(&signerv1.SignResponse{
+		KeyId:     req.GetKeyId(),
+		RequestId: req.GetRequestId(),
+		Error: &signerv1.ErrorStatus{
+			Code:    apiErrorCode(apierrors.CodeInvalidArgument),
+			Message: message,
+		},
+	})
+}
+
+func (s *wsSignStream) SetHeader(metadata.MD) error  { return nil }
+func (s *wsSignStream) SendHeader(metadata.MD) error { return nil }
+func (s *wsSignStream) SetTrailer(metadata.MD)       {}
+
+func (s *wsSignStream) SendMsg(m any) error {
+	resp, ok := m.(*signerv1.SignResponse)
+	if !ok {
+		return errors.New("websocket stream only sends SignResponse")
+	}
+	return s.Send(resp)
+}
+
+func (s *wsSignStream) RecvMsg(m any) error {
+	req, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	proto.Merge(m.(proto.Message), req)
+	return nil
+}
+
+// errWebSocketClosed 表示连接已关闭（对端关闭帧已回应或底层连接断开），无需再发送关闭帧。
+var errWebSocketClosed = errors.New("websocket closed")
+
+// wsCloseError 表示需要以指定关闭码结束连接的协议错误。
+type wsCloseError struct {
+	code   uint16
+	reason string
+}
+
+func (e *wsCloseError) Error() string { return "websocket: " + e.reason }
+
+// wsConn 是 RFC 6455 的最小服务端实现：不协商扩展，客户端帧必须掩码，支持分片与 ping/pong。
+type wsConn struct {
+	conn         net.Conn
+	br           *bufio.Reader
+	maxMessage   int64
+	idle         time.Duration
+	writeTimeout time.Duration
+
+	writeMu sync.Mutex
+	closed  bool
+}
+
+// readMessage 返回下一条完整的文本消息，期间处理控制帧；对端关闭时回应关闭帧并返回 io.EOF。
+func (c *wsConn) readMessage() ([]byte, error) {
+	var (
+		message []byte
+		started bool
+	)
+	for {
+		if c.idle > 0 {
+			_ = c.conn.SetReadDeadline(time.Now().Add(c.idle))
+		}
+		fin, opcode, payload, err := c.readFrame(int64(len(message)))
+		if err != nil {
+			return nil, err
+		}
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			code := uint16(wsCloseNormal)
+			if len(payload) >= 2 {
+				code = binary.BigEndian.Uint16(payload)
+			}
+			c.close(code, "")
+			return nil, io.EOF
+		case wsOpBinary:
+			return nil, &wsCloseError{code: wsCloseUnsupported, reason: "binary messages are not supported"}
+		case wsOpText:
+			if started {
+				return nil, &wsCloseError{code: wsCloseProtocolError, reason: "unexpected text frame"}
+			}
+			started = true
+		case wsOpContinuation:
+			if !started {
+				return nil, &wsCloseError{code: wsCloseProtocolError, reason: "unexpected continuation frame"}
+			}
+		default:
+			return nil, &wsCloseError{code: wsCloseProtocolError, reason: "unknown opcode"}
+		}
+		message = append(message, payload...)
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// readFrame 读取并去掩码一个帧；buffered 为当前消息已累计的字节数，用于提前拒绝超限消息。
+func (c *wsConn) readFrame(buffered int64) (bool, byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return false, 0, nil, c.connErr(err)
+	}
+	fin, opcode := head[0]&0x80 != 0, head[0]&0x0f
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, &wsCloseError{code: wsCloseProtocolError, reason: "reserved bits set"}
+	}
+	if head[1]&0x80 == 0 {
+		return false, 0, nil, &wsCloseError{code: wsCloseProtocolError, reason: "client frames must be masked"}
+	}
+	length := int64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, c.connErr(err)
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, c.connErr(err)
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]) & (1<<63 - 1))
+	}
+	control := opcode&0x8 != 0
+	if control && (length > 125 || !fin) {
+		return false, 0, nil, &wsCloseError{code: wsCloseProtocolError, reason: "invalid control frame"}
+	}
+	if !control && buffered+length > c.maxMessage {
+		return false, 0, nil, &wsCloseError{code: wsCloseTooBig, reason: "message too big"}
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, c.connErr(err)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, c.connErr(err)
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// connErr 将底层连接的读写错误（断开、心跳或写超时）统一为 errWebSocketClosed。
+func (c *wsConn) connErr(err error) error {
+	var netErr net.Error
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return errWebSocketClosed
+	}
+	return err
+}
+
+// writeFrame 写出一个不分片、不掩码的服务端帧，可并发调用。
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return errWebSocketClosed
+	}
+	return c.writeFrameLocked(opcode, payload)
+}
+
+func (c *wsConn) writeFrameLocked(opcode byte, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|opcode)
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	frame = append(frame, payload...)
+	if c.writeTimeout > 0 {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		// 帧可能只写出一部分，连接已不可用：关闭底层连接使读循环退出、SignStream 结束并归还许可。
+		c.closed = true
+		_ = c.conn.Close()
+		return c.connErr(err)
+	}
+	return nil
+}
+
+// close 发送关闭帧，之后不再写出任何帧。
+func (c *wsConn) close(code uint16, reason string) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return
+	}
+	payload := binary.BigEndian.AppendUint16(nil, code)
+	_ = c.writeFrameLocked(wsOpClose, append(payload, reason...))
+	c.closed = true
+}
+
+// keepalive 定期发送 ping，读循环在收到任意帧时刷新读超时。
+func (c *wsConn) keepalive(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.writeFrame(wsOpPing, nil); err != nil {
+				return
+			}
+		}
+	}
+}
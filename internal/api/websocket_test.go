@@ -0,0 +1,195 @@
+package signerapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// wsTestClient 是测试用的最小 WebSocket 客户端。
+type wsTestClient struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dialWebSocket(t *testing.T, url string) *wsTestClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x01}, 16))
+	_, err = io.WriteString(conn, "GET /ws/sign HTTP/1.1\r\nHost: signer\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: "+key+"\r\n\r\n")
+	require.NoError(t, err)
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	want, _ := websocketAccept(&http.Request{Method: http.MethodGet, Header: http.Header{
+		"Connection": {"Upgrade"}, "Upgrade": {"websocket"}, "Sec-Websocket-Version": {"13"}, "Sec-Websocket-Key": {key},
+	}})
+	require.Equal(t, want, resp.Header.Get("Sec-WebSocket-Accept"))
+	return &wsTestClient{t: t, conn: conn, br: br}
+}
+
+func (c *wsTestClient) write(opcode byte, fin bool, payload []byte) {
+	head := opcode
+	if fin {
+		head |= 0x80
+	}
+	frame := []byte{head, 0x80 | byte(len(payload))}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := c.conn.Write(frame)
+	require.NoError(c.t, err)
+}
+
+func (c *wsTestClient) read() (byte, []byte) {
+	var head [2]byte
+	_, err := io.ReadFull(c.br, head[:])
+	require.NoError(c.t, err)
+	length := int(head[1] & 0x7f)
+	if length == 126 {
+		var ext [2]byte
+		_, err = io.ReadFull(c.br, ext[:])
+		require.NoError(c.t, err)
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(c.br, payload)
+	require.NoError(c.t, err)
+	return head[0] & 0x0f, payload
+}
+
+func (c *wsTestClient) readResponse() *signerv1.SignResponse {
+	opcode, payload := c.read()
+	require.Equal(c.t, byte(wsOpText), opcode)
+	resp := &signerv1.SignResponse{}
+	require.NoError(c.t, protojson.Unmarshal(payload, resp))
+	return resp
+}
+
+func TestWebSocketSign(t *testing.T) {
+	backend := &stubBackend{signFn: func(_ context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+		return &signerv1.SignResponse{Signature: []byte(req.GetKeyId())}, nil
+	}}
+	handler := NewHTTPHandler(backend, WithWebSocket(NewGRPCServer(backend, nil), WebSocketConfig{MaxMessageBytes: 512}))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := dialWebSocket(t, srv.URL)
+	digest := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0xaa}, 32))
+	client.write(wsOpText, true, []byte(`{"requestId":"r1","keyId":"k1","digest":"`+digest+`"}`))
+	// 分片消息按完整消息处理。
+	second := []byte(`{"requestId":"r2","keyId":"k2","digest":"` + digest + `"}`)
+	client.write(wsOpText, false, second[:10])
+	client.write(wsOpContinuation, true, second[10:])
+
+	got := map[string]string{}
+	for range 2 {
+		resp := client.readResponse()
+		require.Nil(t, resp.GetError())
+		got[resp.GetRequestId()] = string(resp.GetSignature())
+	}
+	require.Equal(t, map[string]string{"r1": "k1", "r2": "k2"}, got)
+
+	// 非法消息以 in-band INVALID_ARGUMENT 返回，连接保持可用。
+	client.write(wsOpText, true, []byte(`{"requestId":"r3","keyId":"k1","digest":"AA=="}`))
+	resp := client.readResponse()
+	require.Equal(t, "r3", resp.GetRequestId())
+	require.Equal(t, signerv1.ApiErrorCode_API_ERROR_CODE_INVALID_ARGUMENT, resp.GetError().GetCode())
+	client.write(wsOpText, true, []byte(`not json`))
+	require.Equal(t, signerv1.ApiErrorCode_API_ERROR_CODE_INVALID_ARGUMENT, client.readResponse().GetError().GetCode())
+
+	client.write(wsOpPing, true, []byte("hi"))
+	opcode, payload := client.read()
+	require.Equal(t, byte(wsOpPong), opcode)
+	require.Equal(t, "hi", string(payload))
+
+	client.write(wsOpClose, true, binary.BigEndian.AppendUint16(nil, wsCloseNormal))
+	opcode, payload = client.read()
+	require.Equal(t, byte(wsOpClose), opcode)
+	require.Equal(t, uint16(wsCloseNormal), binary.BigEndian.Uint16(payload))
+
+	// 超过 MaxMessageBytes 的消息以 1009 关闭连接。
+	big := dialWebSocket(t, srv.URL)
+	big.write(wsOpText, false, bytes.Repeat([]byte{'a'}, 125))
+	for range 4 {
+		big.write(wsOpContinuation, false, bytes.Repeat([]byte{'a'}, 125))
+	}
+	opcode, payload = big.read()
+	require.Equal(t, byte(wsOpClose), opcode)
+	require.Equal(t, uint16(wsCloseTooBig), binary.BigEndian.Uint16(payload))
+}
+
+func TestWebSocketSignRequiresUpgrade(t *testing.T) {
+	handler := NewHTTPHandler(&stubBackend{}, WithWebSocket(NewGRPCServer(&stubBackend{}, nil), WebSocketConfig{}))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ws/sign", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Equal(t, "13", rr.Header().Get("Sec-WebSocket-Version"))
+}
+
+func TestWebSocketSignChecksOrigin(t *testing.T) {
+	backend := &stubBackend{}
+	upgrade := func(cfg WebSocketConfig, origin string) int {
+		handler := NewHTTPHandler(backend, WithWebSocket(NewGRPCServer(backend, nil), cfg))
+		mux := http.NewServeMux()
+		handler.Register(mux)
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/ws/sign", nil)
+		require.NoError(t, err)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x01}, 16)))
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	// 浏览器来源默认拒绝，非浏览器客户端不受影响。
+	require.Equal(t, http.StatusForbidden, upgrade(WebSocketConfig{}, "https://evil.example"))
+	require.Equal(t, http.StatusSwitchingProtocols, upgrade(WebSocketConfig{}, ""))
+	allowed := WebSocketConfig{AllowedOrigins: []string{" https://Wallet.example.com/ ", ""}}
+	require.Equal(t, http.StatusSwitchingProtocols, upgrade(allowed, "https://wallet.example.com"))
+	require.Equal(t, http.StatusForbidden, upgrade(allowed, "https://evil.example"))
+}
+
+func TestWebSocketWriteTimeoutClosesConnection(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	conn := &wsConn{conn: server, writeTimeout: 20 * time.Millisecond}
+	// 对端不读取时写出在超时后失败，连接随之关闭，后续读写立即返回。
+	start := time.Now()
+	require.ErrorIs(t, conn.writeFrame(wsOpText, []byte("resp")), errWebSocketClosed)
+	require.Less(t, time.Since(start), time.Second)
+	require.ErrorIs(t, conn.writeFrame(wsOpText, []byte("resp")), errWebSocketClosed)
+	_, err := client.Read(make([]byte, 1))
+	require.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,93 @@
+package keycache
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/aegis-sign/wallet/internal/api/reqctx"
+)
+
+// 运维端点触发的状态变化原因，出现在 entry 日志中。
+const (
+	ReasonOperatorFlush      = "operator_flush"
+	ReasonOperatorInvalidate = "operator_invalidate"
+)
+
+// adminRequest 是 invalidate/refresh 的请求体。
+type adminRequest struct {
+	Keyspace string   `json:"keyspace,omitempty"`
+	KeyIDs   []string `json:"keyIds,omitempty"`
+}
+
+type adminResponse struct {
+	Action   string `json:"action"`
+	Affected int    `json:"affected"`
+}
+
+// FlushHandler 处理 POST /admin/keycache/flush：全部 entry 降为 COOL 并清零明文。
+func (s *Store) FlushHandler() http.Handler {
+	return s.adminHandler("flush", false, func(adminRequest) (int, string) {
+		return s.Flush(ReasonOperatorFlush), ""
+	})
+}
+
+// InvalidateHandler 处理 POST /admin/keycache/invalidate，body 为 {"keyspace":...} 或 {"keyIds":[...]}（二选一），
+// 命中的 entry 置为 INVALID，下次 Checkout 经解锁路径换取新密文。
+func (s *Store) InvalidateHandler() http.Handler {
+	return s.adminHandler("invalidate", true, func(req adminRequest) (int, string) {
+		switch {
+		case (req.Keyspace == "") == (len(req.KeyIDs) == 0):
+			return 0, "exactly one of keyspace or keyIds is required"
+		case req.Keyspace != "":
+			return s.InvalidateKeyspace(req.Keyspace, ReasonOperatorInvalidate), ""
+		default:
+			return s.InvalidateKeys(req.KeyIDs, ReasonOperatorInvalidate), ""
+		}
+	})
+}
+
+// RefreshHandler 处理 POST /admin/keycache/refresh {"keyIds":[...]}，通过 RefreshGroup 异步立即重新水合。
+func (s *Store) RefreshHandler() http.Handler {
+	return s.adminHandler("refresh", true, func(req adminRequest) (int, string) {
+		if len(req.KeyIDs) == 0 {
+			return 0, "keyIds is required"
+		}
+		return s.RefreshKeys(req.KeyIDs), ""
+	})
+}
+
+// adminHandler 统一处理方法校验、请求解析与审计日志；apply 返回受影响数或参数错误信息。
+func (s *Store) adminHandler(action string, needsBody bool, apply func(adminRequest) (int, string)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST required", http.StatusMethodNotAllowed)
+			return
+		}
+		var req adminRequest
+		if needsBody {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "invalid JSON body", http.StatusBadRequest)
+				return
+			}
+		}
+		affected, problem := apply(req)
+		if problem != "" {
+			http.Error(w, problem, http.StatusBadRequest)
+			return
+		}
+		principal := "anonymous"
+		if p, ok := reqctx.PrincipalFrom(r.Context()); ok {
+			principal = p.Subject
+		}
+		s.logger.LogAttrs(r.Context(), slog.LevelInfo, "key cache admin audit",
+			slog.String("action", action),
+			slog.String("principal", principal),
+			slog.String("keyspace", req.Keyspace),
+			slog.Int("keys", len(req.KeyIDs)),
+			slog.Int("affected", affected),
+		)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(adminResponse{Action: action, Affected: affected})
+	})
+}
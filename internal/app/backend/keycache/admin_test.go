@@ -0,0 +1,122 @@
+package keycache
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+// newAdminStore 构造包含 prod(k1,k2,k3) 与 staging(s1) 四个 WARM entry 的 Store。
+func newAdminStore(t *testing.T, logs *bytes.Buffer) (*Store, *stubRehydrator) {
+	t.Helper()
+	metrics := NewMetrics(prometheus.NewRegistry())
+	rehydrator := &stubRehydrator{plain: fixedPlain(0x33)}
+	store := NewStore(StoreConfig{Logger: slog.New(slog.NewJSONHandler(logs, nil))})
+	for _, key := range []string{"k1", "k2", "k3", "s1"} {
+		keyspace := "prod"
+		if strings.HasPrefix(key, "s") {
+			keyspace = "staging"
+		}
+		store.Put(mustEntry(t, EntryConfig{
+			KeyID:       key,
+			Keyspace:    keyspace,
+			HasPlainKey: true,
+			PlainKey:    fixedPlain(0x11),
+			CipherBlob:  []byte("cipher"),
+			Metrics:     metrics,
+			Rehydrator:  rehydrator,
+			Refresher:   NewRefreshGroup(metrics, nil),
+		}))
+	}
+	return store, rehydrator
+}
+
+func callAdmin(t *testing.T, h http.Handler, body string) (int, adminResponse) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/keycache", strings.NewReader(body)))
+	var resp adminResponse
+	if rr.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	}
+	return rr.Code, resp
+}
+
+func states(store *Store) map[string]State {
+	out := make(map[string]State)
+	store.Range(func(e *Entry) bool {
+		out[e.KeyID()] = e.State()
+		return true
+	})
+	return out
+}
+
+func TestAdminFlushCoolsAllEntries(t *testing.T) {
+	var logs bytes.Buffer
+	store, _ := newAdminStore(t, &logs)
+
+	code, resp := callAdmin(t, store.FlushHandler(), "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, adminResponse{Action: "flush", Affected: 4}, resp)
+	for key, state := range states(store) {
+		require.Equal(t, StateCool, state, key)
+	}
+	e, _ := store.Get("k1")
+	require.Equal(t, [32]byte{}, e.priv32, "plaintext must be zeroed")
+
+	// 再次 flush 没有 entry 发生变化。
+	_, resp = callAdmin(t, store.FlushHandler(), "")
+	require.Zero(t, resp.Affected)
+	require.Contains(t, logs.String(), `"msg":"key cache admin audit"`)
+	require.Contains(t, logs.String(), `"action":"flush"`)
+}
+
+func TestAdminInvalidateByKeyspaceOrKeys(t *testing.T) {
+	var logs bytes.Buffer
+	store, _ := newAdminStore(t, &logs)
+	h := store.InvalidateHandler()
+
+	code, resp := callAdmin(t, h, `{"keyspace":"staging"}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, 1, resp.Affected)
+
+	code, resp = callAdmin(t, h, `{"keyIds":["k1","k1","missing"]}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, 1, resp.Affected)
+	require.Equal(t, map[string]State{"k1": StateInvalid, "k2": StateWarm, "k3": StateWarm, "s1": StateInvalid}, states(store))
+
+	for _, body := range []string{`{}`, `{"keyspace":"prod","keyIds":["k2"]}`, `not json`} {
+		code, _ = callAdmin(t, h, body)
+		require.Equal(t, http.StatusBadRequest, code, body)
+	}
+	require.Contains(t, logs.String(), `"keyspace":"staging"`)
+}
+
+func TestAdminRefreshRehydratesKeys(t *testing.T) {
+	var logs bytes.Buffer
+	store, rehydrator := newAdminStore(t, &logs)
+	store.InvalidateKeys([]string{"k3"}, "test")
+	h := store.RefreshHandler()
+
+	code, resp := callAdmin(t, h, `{"keyIds":["k1","k2","k3","missing"]}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, adminResponse{Action: "refresh", Affected: 2}, resp)
+	require.Eventually(t, func() bool { return rehydrator.Calls() == 2 }, time.Second, time.Millisecond)
+	e, _ := store.Get("k1")
+	require.Eventually(t, func() bool { return e.State() == StateWarm && e.UsesLeft() > 0 }, time.Second, time.Millisecond)
+
+	code, _ = callAdmin(t, h, `{"keyIds":[]}`)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/keycache/refresh", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
@@ -0,0 +1,456 @@
+package keycache
+
+import (
+	"container/list"
+	"context"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultCacheShards = 32
+	// entryOverheadBytes 近似 Entry 结构体、分片索引与链表节点的固定开销，不含 keyID、path 与密文。
+	entryOverheadBytes = 512
+	// defaultBudgetInterval 为只配置 MaxBytes 时 Start 的 Sweep 周期。
+	defaultBudgetInterval = 10 * time.Second
+)
+
+// 淘汰原因，用作 key_cache_evictions_total 的 reason 标签与 OnEvict 的参数。
+const (
+	// EvictReasonCapacity 表示分片达到容量上限，淘汰最久未访问的 entry。
+	EvictReasonCapacity = "capacity"
+	// EvictReasonIdle 表示 entry 超过 IdleTTL 未被访问。
+	EvictReasonIdle = "idle"
+	// EvictReasonReplaced 表示同一 key 被 Put 了新的 entry。
+	EvictReasonReplaced = "replaced"
+	// EvictReasonMemory 表示缓存占用超过 MaxBytes，淘汰全局最久未访问的 entry。
+	EvictReasonMemory = "memory"
+)
+
+// CacheConfig 配置 Cache。
+type CacheConfig struct {
+	// Shards 为分片数，向上取 2 的幂，默认 32；分片越多锁竞争越小，LRU 越近似。
+	Shards int
+	// MaxEntries 为 entry 总数上限，按分片均分，分片满时淘汰其中最久未访问的 entry；0 表示不限制。
+	MaxEntries int
+	// IdleTTL 为 entry 最长未访问时长，超过后在访问或 Sweep 时淘汰；0 表示不按时间淘汰。
+	IdleTTL time.Duration
+	// MaxBytes 为缓存近似内存占用上限（密文、明文与每个 entry 的固定开销），在 Put 与 Sweep 时超出则
+	// 跨分片淘汰最久未访问的 entry 直至回到上限以内；0 表示不限制。
+	MaxBytes int64
+	Clock    Clock
+	Metrics  *Metrics
+	// OnEvict 在 entry 被淘汰并清零明文后调用（不持锁），可用于同步 Store 或发出解锁事件。
+	OnEvict func(e *Entry, reason string)
+}
+
+// Loader 在缓存未命中时构造 key 的 entry，通常从元数据存储读取密文后调用 NewEntry。
+type Loader func(ctx context.Context, key string) (*Entry, error)
+
+// Cache 是按 key 分片、带容量与空闲时间淘汰的 entry 容器，key 为 EntryKey(keyID, path)，主 key 即 keyID。
+// 被淘汰的 entry 清零明文并降为 COOL（INVALID 保持不变），仍持有它的调用方之后的 Checkout 会重新水合。
+type Cache struct {
+	shards   []*cacheShard
+	mask     uint64
+	idleTTL  time.Duration
+	maxBytes int64
+	clock    Clock
+	metrics  *Metrics
+	onEvict  func(*Entry, string)
+	loads    singleflight.Group
+	budget   *byteBudget
+	// trimMu 串行化超预算淘汰，避免并发 Put 重复淘汰。
+	trimMu sync.Mutex
+}
+
+// byteBudget 汇总 Cache 内 entry 的近似内存占用；entry 在持锁修改明文或密文时直接更新，无需 Cache 重新扫描。
+type byteBudget struct {
+	total   atomic.Int64
+	metrics *Metrics
+}
+
+func (b *byteBudget) add(keyspace string, delta int64) {
+	b.total.Add(delta)
+	b.metrics.addCacheBytes(keyspace, delta)
+}
+
+// cacheShard 以链表维护访问顺序，队首为最近访问。
+type cacheShard struct {
+	mu    sync.Mutex
+	max   int
+	items map[string]*list.Element
+	lru   list.List
+}
+
+type cacheItem struct {
+	key        string
+	entry      *Entry
+	lastAccess time.Time
+}
+
+// NewCache 构造空的 Cache。
+func NewCache(cfg CacheConfig) *Cache {
+	if cfg.Clock == nil {
+		cfg.Clock = NewRealClock()
+	}
+	if cfg.Shards <= 0 {
+		cfg.Shards = defaultCacheShards
+	}
+	n := 1
+	for n < cfg.Shards {
+		n <<= 1
+	}
+	perShard := 0
+	if cfg.MaxEntries > 0 {
+		perShard = max((cfg.MaxEntries+n-1)/n, 1)
+	}
+	c := &Cache{
+		shards:   make([]*cacheShard, n),
+		mask:     uint64(n - 1),
+		idleTTL:  cfg.IdleTTL,
+		maxBytes: cfg.MaxBytes,
+		clock:    cfg.Clock,
+		metrics:  cfg.Metrics,
+		onEvict:  cfg.OnEvict,
+		budget:   &byteBudget{metrics: cfg.Metrics},
+	}
+	for i := range c.shards {
+		c.shards[i] = &cacheShard{max: perShard, items: make(map[string]*list.Element)}
+	}
+	return c
+}
+
+func (c *Cache) shard(key string) *cacheShard {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return c.shards[h.Sum64()&c.mask]
+}
+
+// Get 返回 key 的 entry 并刷新其访问时间；已空闲超时的 entry 被淘汰并视为未命中。
+func (c *Cache) Get(key string) (*Entry, bool) {
+	s := c.shard(key)
+	now := c.clock.Now()
+	s.mu.Lock()
+	el, ok := s.items[key]
+	if !ok {
+		s.mu.Unlock()
+		return nil, false
+	}
+	item := el.Value.(*cacheItem)
+	if c.idleTTL > 0 && now.Sub(item.lastAccess) >= c.idleTTL {
+		s.removeLocked(el)
+		s.mu.Unlock()
+		c.evicted(item.entry, EvictReasonIdle)
+		return nil, false
+	}
+	item.lastAccess = now
+	s.lru.MoveToFront(el)
+	s.mu.Unlock()
+	return item.entry, true
+}
+
+// GetOrLoad 命中时返回缓存的 entry，未命中时以 load 构造并放入缓存；同一 key 的并发未命中只调用一次 load，
+// 共享首个调用方的 ctx 与结果。
+func (c *Cache) GetOrLoad(ctx context.Context, key string, load Loader) (*Entry, error) {
+	if e, ok := c.Get(key); ok {
+		return e, nil
+	}
+	v, err, _ := c.loads.Do(key, func() (any, error) {
+		if e, ok := c.Get(key); ok {
+			return e, nil
+		}
+		e, err := load(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if e == nil {
+			return nil, ErrEntryNotFound
+		}
+		c.put(key, e)
+		return e, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Entry), nil
+}
+
+// Put 以 EntryKey(keyID, path) 写入 entry，替换的旧 entry 被淘汰；分片超出上限时淘汰最久未访问的 entry，
+// 超出 MaxBytes 时跨分片淘汰。
+func (c *Cache) Put(e *Entry) {
+	if e == nil {
+		return
+	}
+	c.put(e.flightKey(), e)
+}
+
+func (c *Cache) put(key string, e *Entry) {
+	s := c.shard(key)
+	now := c.clock.Now()
+	var evicted []*Entry
+	var replaced *Entry
+	s.mu.Lock()
+	if el, ok := s.items[key]; ok {
+		item := el.Value.(*cacheItem)
+		if item.entry != e {
+			replaced = item.entry
+			replaced.attach(nil)
+		}
+		item.entry, item.lastAccess = e, now
+		s.lru.MoveToFront(el)
+		e.attach(c.budget)
+	} else {
+		s.items[key] = s.lru.PushFront(&cacheItem{key: key, entry: e, lastAccess: now})
+		e.attach(c.budget)
+		for s.max > 0 && len(s.items) > s.max {
+			oldest := s.lru.Back()
+			evicted = append(evicted, oldest.Value.(*cacheItem).entry)
+			s.removeLocked(oldest)
+		}
+	}
+	s.mu.Unlock()
+	if replaced != nil {
+		c.evicted(replaced, EvictReasonReplaced)
+	}
+	for _, old := range evicted {
+		c.evicted(old, EvictReasonCapacity)
+	}
+	c.trim()
+}
+
+// Delete 移除 key 的 entry 并清零其明文，不计入淘汰指标，也不调用 OnEvict；返回是否存在。
+func (c *Cache) Delete(key string) bool {
+	s := c.shard(key)
+	s.mu.Lock()
+	el, ok := s.items[key]
+	if ok {
+		s.removeLocked(el)
+	}
+	s.mu.Unlock()
+	if ok {
+		el.Value.(*cacheItem).entry.evict()
+	}
+	return ok
+}
+
+// Sweep 淘汰全部空闲超时的 entry，再按 MaxBytes 淘汰超出预算的部分（明文在 Put 之后装载也会占用预算），返回淘汰数。
+func (c *Cache) Sweep() int {
+	return c.sweepIdle() + c.trim()
+}
+
+func (c *Cache) sweepIdle() int {
+	if c.idleTTL <= 0 {
+		return 0
+	}
+	now := c.clock.Now()
+	total := 0
+	for _, s := range c.shards {
+		var expired []*Entry
+		s.mu.Lock()
+		// 队尾最久未访问，遇到未超时的 entry 即可停止。
+		for el := s.lru.Back(); el != nil; {
+			item := el.Value.(*cacheItem)
+			if now.Sub(item.lastAccess) < c.idleTTL {
+				break
+			}
+			prev := el.Prev()
+			expired = append(expired, item.entry)
+			s.removeLocked(el)
+			el = prev
+		}
+		s.mu.Unlock()
+		for _, e := range expired {
+			c.evicted(e, EvictReasonIdle)
+		}
+		total += len(expired)
+	}
+	return total
+}
+
+// Start 在后台每 IdleTTL/2（至少 1s；只配置 MaxBytes 时为 10s）执行一次 Sweep，直到 ctx 结束；
+// IdleTTL 与 MaxBytes 均为 0 时立即返回。
+func (c *Cache) Start(ctx context.Context) {
+	if c.idleTTL <= 0 && c.maxBytes <= 0 {
+		return
+	}
+	interval := defaultBudgetInterval
+	if c.idleTTL > 0 {
+		interval = max(c.idleTTL/2, time.Second)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.Sweep()
+			}
+		}
+	}()
+}
+
+// Len 返回缓存中的 entry 数。
+func (c *Cache) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		s.mu.Lock()
+		n += len(s.items)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// HotKeys 按最近访问时间降序返回至多 limit 个 key（limit<=0 表示全部），供 SaveHotKeys 持久化。
+func (c *Cache) HotKeys(limit int) []string {
+	var items []cacheItem
+	for _, s := range c.shards {
+		s.mu.Lock()
+		for el := s.lru.Front(); el != nil; el = el.Next() {
+			item := el.Value.(*cacheItem)
+			items = append(items, cacheItem{key: item.key, lastAccess: item.lastAccess})
+		}
+		s.mu.Unlock()
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].lastAccess.After(items[j].lastAccess) })
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = item.key
+	}
+	return keys
+}
+
+// Bytes 返回缓存中 entry 的近似内存占用（字节）。
+func (c *Cache) Bytes() int64 {
+	return c.budget.total.Load()
+}
+
+// Range 实现 EntryIterator，遍历基于逐分片快照，不刷新访问时间，回调中可安全访问 Cache。
+func (c *Cache) Range(fn func(*Entry) bool) {
+	for _, s := range c.shards {
+		s.mu.Lock()
+		snapshot := make([]*Entry, 0, len(s.items))
+		for el := s.lru.Front(); el != nil; el = el.Next() {
+			snapshot = append(snapshot, el.Value.(*cacheItem).entry)
+		}
+		s.mu.Unlock()
+		for _, e := range snapshot {
+			if !fn(e) {
+				return
+			}
+		}
+	}
+}
+
+// removeLocked 移除 el 并让其 entry 退出字节预算；记账与移除都在分片锁内，与并发 Put 的顺序一致。
+func (s *cacheShard) removeLocked(el *list.Element) {
+	item := el.Value.(*cacheItem)
+	delete(s.items, item.key)
+	s.lru.Remove(el)
+	item.entry.attach(nil)
+}
+
+// trim 在占用超过 MaxBytes 时反复淘汰全局最久未访问的 entry（比较各分片队尾），返回淘汰数。
+func (c *Cache) trim() int {
+	if c.maxBytes <= 0 || c.budget.total.Load() <= c.maxBytes {
+		return 0
+	}
+	c.trimMu.Lock()
+	defer c.trimMu.Unlock()
+	n := 0
+	for c.budget.total.Load() > c.maxBytes {
+		e := c.popColdest()
+		if e == nil {
+			break
+		}
+		c.evicted(e, EvictReasonMemory)
+		n++
+	}
+	return n
+}
+
+// popColdest 移除并返回各分片队尾中最久未访问的 entry，缓存为空时返回 nil。
+func (c *Cache) popColdest() *Entry {
+	var coldest *cacheShard
+	var oldest time.Time
+	for _, s := range c.shards {
+		s.mu.Lock()
+		if el := s.lru.Back(); el != nil {
+			if at := el.Value.(*cacheItem).lastAccess; coldest == nil || at.Before(oldest) {
+				coldest, oldest = s, at
+			}
+		}
+		s.mu.Unlock()
+	}
+	if coldest == nil {
+		return nil
+	}
+	coldest.mu.Lock()
+	defer coldest.mu.Unlock()
+	// 比较期间队尾可能已被访问或移除，此时取其当前队尾，近似即可。
+	el := coldest.lru.Back()
+	if el == nil {
+		return nil
+	}
+	coldest.removeLocked(el)
+	return el.Value.(*cacheItem).entry
+}
+
+// evicted 清零被淘汰 entry 的明文、计数并通知 OnEvict。
+func (c *Cache) evicted(e *Entry, reason string) {
+	e.evict()
+	c.metrics.incCacheEviction(e.keyspace, reason)
+	if c.onEvict != nil {
+		c.onEvict(e, reason)
+	}
+}
+
+// evict 清零明文并降为 COOL，INVALID 保持不变；淘汰频繁，不像 coolDown 那样逐条记日志。
+func (e *Entry) evict() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state == StateInvalid {
+		return
+	}
+	e.clearPlainLocked()
+	e.transitionLocked(e.state, StateCool)
+}
+
+// attach 将 entry 的占用从原预算移到 b，b 为 nil 时只退出原预算。
+func (e *Entry) attach(b *byteBudget) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.budget == b {
+		return
+	}
+	if e.budget != nil {
+		e.budget.add(e.keyspace, -e.bytes)
+	}
+	e.budget = b
+	if b != nil {
+		b.add(e.keyspace, e.bytes)
+	}
+}
+
+// resizeLocked 重新估算 entry 的内存占用，并把差值计入所属预算。
+func (e *Entry) resizeLocked() {
+	n := int64(entryOverheadBytes + len(e.keyID) + len(e.path) + len(e.cipherBlob))
+	if e.hasPlainKey {
+		n += int64(len(e.priv32))
+	}
+	if delta := n - e.bytes; delta != 0 {
+		e.bytes = n
+		if e.budget != nil {
+			e.budget.add(e.keyspace, delta)
+		}
+	}
+}
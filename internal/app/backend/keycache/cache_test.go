@@ -0,0 +1,164 @@
+package keycache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	clock := newFakeClock(time.Unix(0, 0))
+	metrics := NewMetrics(prometheus.NewRegistry())
+	var evicted []string
+	cache := NewCache(CacheConfig{
+		Shards:     1,
+		MaxEntries: 2,
+		Clock:      clock,
+		Metrics:    metrics,
+		OnEvict:    func(e *Entry, reason string) { evicted = append(evicted, e.KeyID()+":"+reason) },
+	})
+	warm := func(id string) *Entry {
+		return mustEntry(t, EntryConfig{KeyID: id, PlainKey: fixedPlain(0x22), HasPlainKey: true, CipherBlob: []byte("cipher"), Clock: clock, Metrics: metrics})
+	}
+	a, b, c := warm("a"), warm("b"), warm("c")
+	cache.Put(a)
+	cache.Put(b)
+	// 访问 a 后 b 成为最久未访问。
+	_, ok := cache.Get("a")
+	require.True(t, ok)
+	cache.Put(c)
+
+	require.Equal(t, 2, cache.Len())
+	_, ok = cache.Get("b")
+	require.False(t, ok)
+	require.Equal(t, []string{"b:" + EvictReasonCapacity}, evicted)
+	require.Equal(t, StateCool, b.State())
+	require.Equal(t, [32]byte{}, b.priv32)
+	require.Equal(t, StateWarm, a.State())
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.cacheEvictions.WithLabelValues("prod", EvictReasonCapacity)))
+
+	// 同 key 写入新 entry 时旧 entry 被清零。
+	a2 := warm("a")
+	cache.Put(a2)
+	require.Equal(t, StateCool, a.State())
+	got, _ := cache.Get("a")
+	require.Same(t, a2, got)
+
+	require.True(t, cache.Delete("c"))
+	require.Equal(t, StateCool, c.State())
+	require.False(t, cache.Delete("c"))
+}
+
+func TestCacheEvictsIdleEntries(t *testing.T) {
+	clock := newFakeClock(time.Unix(0, 0))
+	cache := NewCache(CacheConfig{IdleTTL: time.Minute, Clock: clock})
+	for _, id := range []string{"a", "b", "c"} {
+		cache.Put(mustEntry(t, EntryConfig{KeyID: id, PlainKey: fixedPlain(0x33), HasPlainKey: true, Clock: clock}))
+	}
+	clock.Advance(40 * time.Second)
+	_, ok := cache.Get("a")
+	require.True(t, ok)
+	clock.Advance(30 * time.Second)
+
+	require.Equal(t, 2, cache.Sweep())
+	require.Equal(t, 1, cache.Len())
+	clock.Advance(time.Minute)
+	// 访问时发现超时同样淘汰。
+	_, ok = cache.Get("a")
+	require.False(t, ok)
+	require.Zero(t, cache.Len())
+}
+
+func TestCacheGetOrLoadLoadsOnce(t *testing.T) {
+	cache := NewCache(CacheConfig{})
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(_ context.Context, key string) (*Entry, error) {
+		loads.Add(1)
+		<-release
+		return mustEntry(t, EntryConfig{KeyID: key, CipherBlob: []byte("cipher")}), nil
+	}
+	var wg sync.WaitGroup
+	results := make([]*Entry, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			e, err := cache.GetOrLoad(context.Background(), "k1", load)
+			require.NoError(t, err)
+			results[i] = e
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	require.Equal(t, int32(1), loads.Load())
+	for _, e := range results {
+		require.Same(t, results[0], e)
+	}
+
+	_, err := cache.GetOrLoad(context.Background(), "missing", func(context.Context, string) (*Entry, error) { return nil, nil })
+	require.ErrorIs(t, err, ErrEntryNotFound)
+	require.Equal(t, 1, cache.Len())
+}
+
+func TestCacheDrivesPrefetcher(t *testing.T) {
+	clock := newFakeClock(time.Unix(0, 0))
+	cache := NewCache(CacheConfig{Shards: 4})
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		cache.Put(mustEntry(t, EntryConfig{KeyID: id, PlainKey: fixedPlain(0x44), HasPlainKey: true, Clock: clock}))
+	}
+	seen := 0
+	cache.Range(func(*Entry) bool { seen++; return true })
+	require.Equal(t, 5, seen)
+
+	scheduler := &recordingScheduler{}
+	prefetcher := NewPrefetcher(PrefetcherConfig{Iterator: cache, Scheduler: scheduler, Clock: clock, RefreshWindow: 2 * time.Minute})
+	clock.Advance(14 * time.Minute)
+	prefetcher.RunOnce(context.Background())
+	require.Equal(t, 5, scheduler.GoCalls())
+}
+
+func TestCacheEnforcesMemoryBudget(t *testing.T) {
+	clock := newFakeClock(time.Unix(0, 0))
+	metrics := NewMetrics(prometheus.NewRegistry())
+	var evicted []string
+	entrySize := int64(entryOverheadBytes + 1 + 100 + 32)
+	cache := NewCache(CacheConfig{
+		Shards:   4,
+		MaxBytes: 2*entrySize + 10,
+		Clock:    clock,
+		Metrics:  metrics,
+		OnEvict:  func(e *Entry, reason string) { evicted = append(evicted, e.KeyID()+":"+reason) },
+	})
+	warm := func(id string) *Entry {
+		return mustEntry(t, EntryConfig{KeyID: id, PlainKey: fixedPlain(0x55), HasPlainKey: true, CipherBlob: make([]byte, 100), Clock: clock, Metrics: metrics})
+	}
+	a, b, c := warm("a"), warm("b"), warm("c")
+	cache.Put(a)
+	clock.Advance(time.Second)
+	cache.Put(b)
+	clock.Advance(time.Second)
+	require.Equal(t, 2*entrySize, cache.Bytes())
+
+	// 超出预算时跨分片淘汰最久未访问的 a，并清零其明文。
+	cache.Put(c)
+	require.Equal(t, []string{"a:" + EvictReasonMemory}, evicted)
+	require.Equal(t, StateCool, a.State())
+	require.Equal(t, [32]byte{}, a.priv32)
+	require.Equal(t, 2*entrySize, cache.Bytes())
+	require.Equal(t, float64(2*entrySize), testutil.ToFloat64(metrics.cacheBytes.WithLabelValues("prod")))
+
+	// 缓存内 entry 清零明文时占用随之下降，已淘汰的 entry 不再计入。
+	require.NoError(t, b.ApplyUnlockResult(UnlockResult{Success: true}))
+	require.Equal(t, 2*entrySize-32, cache.Bytes())
+	require.True(t, cache.Delete("c"))
+	require.Equal(t, entrySize-32, cache.Bytes())
+	require.Equal(t, float64(entrySize-32), testutil.ToFloat64(metrics.cacheBytes.WithLabelValues("prod")))
+}
@@ -0,0 +1,56 @@
+package keycache
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// 热路径分配预算（allocs/op），超出即视为回归：
+//
+//	BenchmarkEntryCheckoutWarm: 1（plainCopy 跟踪对象）
+const warmCheckoutAllocBudget = 1
+
+func newWarmEntry(tb testing.TB) *Entry {
+	tb.Helper()
+	return mustEntry(tb, EntryConfig{
+		HasPlainKey: true,
+		PlainKey:    fixedPlain(0x11),
+		CipherBlob:  []byte("cipher"),
+		UsesLeft:    math.MaxUint32,
+		MaxUses:     math.MaxUint32,
+		Metrics:     NewMetrics(prometheus.NewRegistry()),
+		Rehydrator:  &stubRehydrator{plain: fixedPlain(0x22)},
+	})
+}
+
+func BenchmarkEntryCheckoutWarm(b *testing.B) {
+	entry := newWarmEntry(b)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		res, err := entry.Checkout(ctx)
+		if err != nil {
+			b.Fatal(err)
+		}
+		res.Zero()
+	}
+}
+
+func TestEntryCheckoutWarmAllocBudget(t *testing.T) {
+	entry := newWarmEntry(t)
+	ctx := context.Background()
+	allocs := testing.AllocsPerRun(200, func() {
+		res, err := entry.Checkout(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Zero()
+	})
+	if allocs > warmCheckoutAllocBudget {
+		t.Fatalf("warm Checkout allocs/op = %.1f, budget %d", allocs, warmCheckoutAllocBudget)
+	}
+}
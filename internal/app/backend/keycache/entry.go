@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aegis-sign/wallet/pkg/apierrors"
@@ -20,6 +22,7 @@ const (
 	defaultHardTTL       = 16 * time.Minute
 	defaultDEKValidFor   = 60 * time.Minute
 	defaultRefreshBudget = 3 * time.Millisecond
+	defaultTTLJitterPct  = 5
 )
 
 // EntryConfig 用于初始化单个 key entry。
@@ -27,10 +30,14 @@ type EntryConfig struct {
 	KeyID    string
 	Enclave  string
 	Keyspace string
+	// DerivationPath 非空时 entry 缓存 KeyID 主 key 按该路径派生的子 key，
+	// 须为 validator.NormalizeDerivationPath 的规范写法。
+	DerivationPath string
 
 	PlainKey     [32]byte
 	HasPlainKey  bool
 	CipherBlob   []byte
+	BlobVersion  uint64
 	UsesLeft     uint32
 	MaxUses      uint32
 	LowWaterMark uint32
@@ -39,6 +46,9 @@ type EntryConfig struct {
 	PlainHardTTL time.Duration
 	DEKValidFor  time.Duration
 	CreatedAt    time.Time
+	// TTLJitterPercent 为软/硬 TTL 窗口施加 ±N% 的随机抖动（默认 5），每次再水合重新抽样，
+	// 避免同批创建的 key 同时到期；负数关闭抖动。
+	TTLJitterPercent float64
 
 	RefreshBudget time.Duration
 	Clock         Clock
@@ -46,36 +56,48 @@ type EntryConfig struct {
 	Logger        *slog.Logger
 	Rehydrator    Rehydrator
 	Refresher     RefreshScheduler
+	// TrackZeroing 为 Checkout 副本挂载 finalizer，统计未调用 Zero 即被回收的副本；
+	// 有额外分配与 GC 开销，仅用于排查。
+	TrackZeroing bool
 }
 
 // Entry 表示单个 key cache 元素。
 type Entry struct {
 	keyID    string
+	path     string
 	enclave  string
 	keyspace string
 
-	cipherBlob []byte
-
 	softWindow    time.Duration
 	hardWindow    time.Duration
+	ttlJitter     float64
 	maxUses       uint32
 	lowWater      uint32
 	refreshBudget time.Duration
+	dekValidFor   time.Duration
 
 	clock      Clock
 	metrics    *Metrics
+	copies     *copyCounters
 	logger     *slog.Logger
 	rehydrator Rehydrator
 	refresher  RefreshScheduler
+	trackZero  bool
 
 	mu            sync.Mutex
+	cipherBlob    []byte
+	blobVersion   uint64
 	priv32        [32]byte
 	hasPlainKey   bool
+	plainSince    time.Time
 	usesLeft      uint32
 	softTTL       time.Time
 	hardTTL       time.Time
 	dekValidUntil time.Time
 	state         State
+	// bytes 为最近一次记账的内存占用，budget 为所属 Cache 的字节预算，不在 Cache 中时为 nil。
+	bytes  int64
+	budget *byteBudget
 }
 
 // CheckoutResult 返回给调用者的 PlainKey 副本以及状态。
@@ -84,6 +106,14 @@ type CheckoutResult struct {
 	State       State
 	PlainKey    [32]byte
 	HasPlainKey bool
+
+	copy *plainCopy
+}
+
+// plainCopy 跟踪一次 Checkout 产生的明文副本是否被清零，同一结果的值拷贝共享该对象。
+type plainCopy struct {
+	counters *copyCounters
+	zeroed   atomic.Bool
 }
 
 // Zero 清零 PlainKey 副本，避免泄漏。
@@ -93,6 +123,9 @@ func (r *CheckoutResult) Zero() {
 	}
 	secureZero(r.PlainKey[:])
 	r.HasPlainKey = false
+	if c := r.copy; c != nil && c.zeroed.CompareAndSwap(false, true) {
+		c.counters.zeroed.Inc()
+	}
 }
 
 // NewEntry 根据配置创建 Entry。
@@ -121,6 +154,12 @@ func NewEntry(cfg EntryConfig) (*Entry, error) {
 	if cfg.RefreshBudget <= 0 {
 		cfg.RefreshBudget = defaultRefreshBudget
 	}
+	if cfg.TTLJitterPercent == 0 {
+		cfg.TTLJitterPercent = defaultTTLJitterPct
+	}
+	if cfg.TTLJitterPercent < 0 {
+		cfg.TTLJitterPercent = 0
+	}
 	if cfg.MaxUses == 0 {
 		cfg.MaxUses = defaultMaxUses
 	}
@@ -145,28 +184,32 @@ func NewEntry(cfg EntryConfig) (*Entry, error) {
 	}
 	entry := &Entry{
 		keyID:         cfg.KeyID,
+		path:          cfg.DerivationPath,
 		enclave:       cfg.Enclave,
 		keyspace:      cfg.Keyspace,
 		cipherBlob:    append([]byte(nil), cfg.CipherBlob...),
 		softWindow:    cfg.PlainSoftTTL,
 		hardWindow:    cfg.PlainHardTTL,
+		ttlJitter:     cfg.TTLJitterPercent / 100,
 		maxUses:       cfg.MaxUses,
 		lowWater:      cfg.LowWaterMark,
 		refreshBudget: cfg.RefreshBudget,
+		dekValidFor:   cfg.DEKValidFor,
+		blobVersion:   cfg.BlobVersion,
 		clock:         cfg.Clock,
 		metrics:       cfg.Metrics,
+		copies:        cfg.Metrics.copyCounters(cfg.Keyspace),
 		logger:        cfg.Logger,
 		rehydrator:    cfg.Rehydrator,
 		refresher:     cfg.Refresher,
-		hasPlainKey:   cfg.HasPlainKey,
+		trackZero:     cfg.TrackZeroing,
 		usesLeft:      cfg.UsesLeft,
-		softTTL:       createdAt.Add(cfg.PlainSoftTTL),
-		hardTTL:       createdAt.Add(cfg.PlainHardTTL),
 		dekValidUntil: createdAt.Add(cfg.DEKValidFor),
 		state:         StateCool,
 	}
+	entry.resetTTLLocked(createdAt)
 	if cfg.HasPlainKey {
-		entry.priv32 = cfg.PlainKey
+		entry.installPlainLocked(cfg.PlainKey, createdAt)
 		entry.state = StateWarm
 	} else {
 		entry.clearPlainLocked()
@@ -195,7 +238,7 @@ func (e *Entry) Checkout(ctx context.Context) (CheckoutResult, error) {
 		if !e.hasPlainKey || now.After(e.hardTTL) || e.usesLeft == 0 {
 			e.mu.Unlock()
 			callCtx, cancel := e.refreshContext(ctx)
-			err := e.refresher.Do(callCtx, e.keyspace, e.keyID, e.refreshOnce)
+			err := e.refresher.Do(callCtx, e.keyspace, e.flightKey(), e.refreshOnce)
 			cancel()
 			if err != nil {
 				if _, ok := apierrors.FromError(err); ok {
@@ -213,12 +256,13 @@ func (e *Entry) Checkout(ctx context.Context) (CheckoutResult, error) {
 		result.State = StateWarm
 		result.HasPlainKey = true
 		result.PlainKey = e.priv32
+		result.copy = e.newPlainCopy()
 
 		shouldBackground := e.shouldScheduleRefreshLocked(now)
 		e.mu.Unlock()
 
 		if shouldBackground {
-			e.refresher.Go(context.Background(), e.keyspace, e.keyID, e.refreshOnce)
+			e.refresher.Go(context.Background(), e.keyspace, e.flightKey(), e.refreshOnce)
 		}
 		return result, nil
 	}
@@ -231,6 +275,86 @@ func (e *Entry) State() State {
 	return e.state
 }
 
+// KeyID 返回 entry 的 key 标识；派生子 key 返回其主 key。
+func (e *Entry) KeyID() string {
+	return e.keyID
+}
+
+// DerivationPath 返回派生子 key 的路径，主 key 为空。
+func (e *Entry) DerivationPath() string {
+	return e.path
+}
+
+// EntryKey 返回 (keyID, path) 的单一字符串标识，用作刷新单航班的 key 与日志字段；主 key 即 keyID。
+func EntryKey(keyID, path string) string {
+	if path == "" {
+		return keyID
+	}
+	return keyID + "#" + path
+}
+
+func (e *Entry) flightKey() string {
+	return EntryKey(e.keyID, e.path)
+}
+
+// EntryInfo 是 entry 的只读摘要，不含任何密钥材料。
+type EntryInfo struct {
+	KeyID          string `json:"keyId"`
+	DerivationPath string `json:"derivationPath,omitempty"`
+	Keyspace       string `json:"keyspace"`
+	Enclave        string `json:"enclave"`
+	State          State  `json:"state"`
+	UsesLeft       uint32 `json:"usesLeft"`
+	BlobVersion    uint64 `json:"blobVersion"`
+}
+
+// Info 返回 entry 的当前摘要。
+func (e *Entry) Info() EntryInfo {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return EntryInfo{
+		KeyID:          e.keyID,
+		DerivationPath: e.path,
+		Keyspace:       e.keyspace,
+		Enclave:        e.enclave,
+		State:          e.state,
+		UsesLeft:       e.usesLeft,
+		BlobVersion:    e.blobVersion,
+	}
+}
+
+// BlobVersion 返回当前密文 DEK 的版本号，每次成功应用解锁结果后递增。
+func (e *Entry) BlobVersion() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.blobVersion
+}
+
+// ApplyUnlockResult 安装后台解锁得到的新密文：Epoch 早于当前 BlobVersion 的结果
+// 来自更早的解锁（如另一副本的慢任务），直接拒绝，避免覆盖更新的 DEK。
+// 应用后 entry 回到 COOL，下次 Checkout 从新密文再水合。
+func (e *Entry) ApplyUnlockResult(result UnlockResult) error {
+	if !result.Success {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if result.Epoch < e.blobVersion {
+		e.metrics.incStaleApply(e.keyspace)
+		e.logger.Warn("key cache stale unlock result rejected", slog.String("key", e.keyID), slog.Uint64("epoch", result.Epoch), slog.Uint64("blob_version", e.blobVersion))
+		return ErrStaleUnlockResult
+	}
+	if len(result.CipherBlob) > 0 {
+		e.cipherBlob = append([]byte(nil), result.CipherBlob...)
+	}
+	e.blobVersion = result.Epoch + 1
+	e.dekValidUntil = e.clock.Now().Add(e.dekValidFor)
+	e.clearPlainLocked()
+	e.resizeLocked()
+	e.transitionLocked(e.state, StateCool)
+	return nil
+}
+
 // UsesLeft 返回剩余可用次数。
 func (e *Entry) UsesLeft() uint32 {
 	e.mu.Lock()
@@ -323,7 +447,7 @@ func (e *Entry) rehydrateLocked(ctx context.Context, now time.Time) error {
 	callCtx, cancel := context.WithTimeout(ctx, budget)
 	defer cancel()
 	start := e.clock.Now()
-	plain, err := e.rehydrator.Rehydrate(callCtx, e.keyID, e.cipherBlob)
+	plain, err := e.rehydrate(callCtx)
 	duration := e.clock.Now().Sub(start)
 	e.metrics.observeRehydrate(e.keyspace, duration.Seconds()*1000, err == nil)
 	if err != nil {
@@ -331,15 +455,41 @@ func (e *Entry) rehydrateLocked(ctx context.Context, now time.Time) error {
 		e.toInvalidLocked(fmt.Sprintf("rehydrate failed: %v", err))
 		return e.newUnlockError("rehydrate failed")
 	}
-	e.priv32 = plain
-	e.hasPlainKey = true
+	e.installPlainLocked(plain, now)
 	e.usesLeft = e.maxUses
-	e.softTTL = now.Add(e.softWindow)
-	e.hardTTL = now.Add(e.hardWindow)
+	e.resetTTLLocked(now)
 	e.transitionLocked(e.state, StateWarm)
 	return nil
 }
 
+// rehydrate 解密主 key 密文；派生 entry 要求 Rehydrator 实现 DerivedRehydrator，在同一次解密中完成派生。
+func (e *Entry) rehydrate(ctx context.Context) ([32]byte, error) {
+	if e.path == "" {
+		return e.rehydrator.Rehydrate(ctx, e.keyID, e.cipherBlob)
+	}
+	derived, ok := e.rehydrator.(DerivedRehydrator)
+	if !ok {
+		return [32]byte{}, ErrRehydrateUnsupported
+	}
+	return derived.RehydrateDerived(ctx, e.keyID, e.path, e.cipherBlob)
+}
+
+// resetTTLLocked 以同一个抖动系数缩放软/硬窗口，保持 soft <= hard，且 hard 不超过 DEK 有效期。
+func (e *Entry) resetTTLLocked(now time.Time) {
+	factor := 1.0
+	if e.ttlJitter > 0 {
+		factor += (rand.Float64()*2 - 1) * e.ttlJitter
+	}
+	e.softTTL = now.Add(time.Duration(float64(e.softWindow) * factor))
+	e.hardTTL = now.Add(time.Duration(float64(e.hardWindow) * factor))
+	if e.hardTTL.After(e.dekValidUntil) {
+		e.hardTTL = e.dekValidUntil
+	}
+	if e.softTTL.After(e.hardTTL) {
+		e.softTTL = e.hardTTL
+	}
+}
+
 func (e *Entry) toCoolLocked(reason string) {
 	if e.state == StateCool {
 		return
@@ -349,6 +499,41 @@ func (e *Entry) toCoolLocked(reason string) {
 	e.transitionLocked(e.state, StateCool)
 }
 
+// coolDown 供 Store 在 Enclave 排空或运维清空时清零明文；INVALID 状态保持不变，仍需走冷路径解锁。
+// 返回 entry 是否由其他状态降为 COOL。
+func (e *Entry) coolDown(reason string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state == StateInvalid || e.state == StateCool {
+		return false
+	}
+	e.toCoolLocked(reason)
+	return true
+}
+
+// invalidate 清零明文并置为 INVALID，之后的 Checkout 走解锁路径换取新密文。返回状态是否变化。
+func (e *Entry) invalidate(reason string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state == StateInvalid {
+		return false
+	}
+	e.toInvalidLocked(reason)
+	return true
+}
+
+// forceRefresh 无视 TTL 立即清零并从密文重新水合，供运维触发的刷新使用。
+func (e *Entry) forceRefresh(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.clock.Now()
+	if err := e.ensureValidLocked(now); err != nil {
+		return err
+	}
+	e.toCoolLocked("operator refresh")
+	return e.rehydrateLocked(ctx, now)
+}
+
 func (e *Entry) toInvalidLocked(reason string) {
 	if e.state == StateInvalid {
 		return
@@ -368,10 +553,46 @@ func (e *Entry) transitionLocked(from, to State) {
 	e.state = to
 }
 
+// installPlainLocked 安装明文并开始计量驻留时长；替换旧明文时先结算旧明文的驻留。
+func (e *Entry) installPlainLocked(plain [32]byte, now time.Time) {
+	if e.hasPlainKey {
+		e.metrics.observeResidency(e.keyspace, now.Sub(e.plainSince))
+	} else {
+		e.metrics.addPlainHolder(e.enclave, 1)
+	}
+	e.priv32 = plain
+	e.hasPlainKey = true
+	e.plainSince = now
+	e.resizeLocked()
+}
+
 func (e *Entry) clearPlainLocked() {
+	if e.hasPlainKey {
+		e.metrics.observeResidency(e.keyspace, e.clock.Now().Sub(e.plainSince))
+		e.metrics.addPlainHolder(e.enclave, -1)
+	}
 	secureZero(e.priv32[:])
 	e.hasPlainKey = false
+	e.plainSince = time.Time{}
 	e.usesLeft = 0
+	e.resizeLocked()
+}
+
+// newPlainCopy 记录一次明文副本的借出；开启 TrackZeroing 时以 finalizer 检测未清零的副本。
+func (e *Entry) newPlainCopy() *plainCopy {
+	if e.copies == nil {
+		return nil
+	}
+	e.copies.checkouts.Inc()
+	c := &plainCopy{counters: e.copies}
+	if e.trackZero {
+		runtime.SetFinalizer(c, func(c *plainCopy) {
+			if !c.zeroed.Load() {
+				c.counters.leaked.Inc()
+			}
+		})
+	}
+	return c
 }
 
 func secureZero(buf []byte) {
@@ -388,5 +609,7 @@ func (e *Entry) newUnlockError(reason string) error {
 	if budget <= 0 {
 		budget = defaultRefreshBudget
 	}
-	return NewUnlockRequiredError(reason, budget)
+	err := NewUnlockRequiredError(reason, budget)
+	err.epoch = e.blobVersion
+	return err
 }
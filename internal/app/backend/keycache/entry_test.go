@@ -3,6 +3,7 @@ package keycache
 import (
 	"context"
 	"errors"
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/require"
 
 	"github.com/aegis-sign/wallet/pkg/apierrors"
@@ -344,7 +346,7 @@ func (s *recordingScheduler) Go(ctx context.Context, _ string, _ string, fn Refr
 	}
 }
 
-func (recordingScheduler) Do(ctx context.Context, _ string, _ string, fn RefreshFunc) error {
+func (*recordingScheduler) Do(ctx context.Context, _ string, _ string, fn RefreshFunc) error {
 	if fn == nil {
 		return nil
 	}
@@ -365,7 +367,7 @@ func fixedPlain(val byte) [32]byte {
 	return out
 }
 
-func mustEntry(t *testing.T, cfg EntryConfig) *Entry {
+func mustEntry(t testing.TB, cfg EntryConfig) *Entry {
 	t.Helper()
 	if cfg.Enclave == "" {
 		cfg.Enclave = "enc"
@@ -392,3 +394,132 @@ func mustEntry(t *testing.T, cfg EntryConfig) *Entry {
 	require.NoError(t, err)
 	return entry
 }
+
+func TestEntryPlainResidencyMetrics(t *testing.T) {
+	clock := newFakeClock(time.Unix(0, 0))
+	metrics := NewMetrics(prometheus.NewRegistry())
+	entry := mustEntry(t, EntryConfig{
+		KeyID:        "key-resident",
+		Enclave:      "enc-a",
+		PlainKey:     fixedPlain(0x05),
+		HasPlainKey:  true,
+		CipherBlob:   []byte("cipher"),
+		MaxUses:      10,
+		PlainSoftTTL: time.Minute,
+		PlainHardTTL: 2 * time.Minute,
+		DEKValidFor:  3 * time.Minute,
+		Clock:        clock,
+		Metrics:      metrics,
+		Rehydrator:   &stubRehydrator{plain: fixedPlain(0x06)},
+	})
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.plainHolders.WithLabelValues("enc-a")))
+
+	// 硬过期触发 COOL + 重新解封：旧明文驻留 150s 后被清零。
+	clock.Advance(150 * time.Second)
+	_, err := entry.Checkout(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.plainResidency))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.plainHolders.WithLabelValues("enc-a")))
+
+	// DEK 过期使 entry 失效，新明文驻留 60s 后清零。
+	clock.Advance(time.Minute)
+	_, err = entry.Checkout(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 0.0, testutil.ToFloat64(metrics.plainHolders.WithLabelValues("enc-a")))
+
+	hist := &dto.Metric{}
+	require.NoError(t, metrics.plainResidency.WithLabelValues("prod").(prometheus.Histogram).Write(hist))
+	require.Equal(t, uint64(2), hist.GetHistogram().GetSampleCount())
+	require.InDelta(t, 210, hist.GetHistogram().GetSampleSum(), 0.001)
+}
+
+func TestCheckoutZeroAccounting(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	entry := mustEntry(t, EntryConfig{
+		KeyID:        "key-zero",
+		PlainKey:     fixedPlain(0x07),
+		HasPlainKey:  true,
+		CipherBlob:   []byte("cipher"),
+		MaxUses:      10,
+		Metrics:      metrics,
+		TrackZeroing: true,
+	})
+
+	result, err := entry.Checkout(context.Background())
+	require.NoError(t, err)
+	copied := result
+	result.Zero()
+	copied.Zero() // 同一次 Checkout 的值拷贝只计一次。
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.plainCheckouts.WithLabelValues("prod")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.plainCopiesZeroed.WithLabelValues("prod")))
+
+	func() {
+		leaked, err := entry.Checkout(context.Background())
+		require.NoError(t, err)
+		_ = leaked.PlainKey
+	}()
+	require.Eventually(t, func() bool {
+		runtime.GC()
+		return testutil.ToFloat64(metrics.plainCopiesLeaked.WithLabelValues("prod")) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.plainCheckouts.WithLabelValues("prod")))
+}
+
+func TestEntryTTLJitterSpread(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := newFakeClock(start)
+	const n = 1000
+	soft, hard := 15*time.Minute, 16*time.Minute
+	dekValid := 16*time.Minute + 30*time.Second
+	entries := make([]*Entry, n)
+	for i := range entries {
+		entries[i] = mustEntry(t, EntryConfig{
+			KeyID:        "key-jitter",
+			PlainKey:     fixedPlain(0x01),
+			HasPlainKey:  true,
+			PlainSoftTTL: soft,
+			PlainHardTTL: hard,
+			DEKValidFor:  dekValid,
+			Clock:        clock,
+			Rehydrator:   &stubRehydrator{plain: fixedPlain(0x02)},
+		})
+	}
+	assertSpread := func(base time.Time) {
+		t.Helper()
+		var minSoft, maxSoft time.Duration
+		var sum float64
+		for i, e := range entries {
+			offset := e.softTTL.Sub(base)
+			require.GreaterOrEqual(t, offset, time.Duration(float64(soft)*0.95))
+			require.LessOrEqual(t, offset, time.Duration(float64(soft)*1.05))
+			require.False(t, e.hardTTL.After(e.dekValidUntil), "hard TTL beyond DEK validity")
+			require.False(t, e.softTTL.After(e.hardTTL))
+			if i == 0 || offset < minSoft {
+				minSoft = offset
+			}
+			if offset > maxSoft {
+				maxSoft = offset
+			}
+			sum += offset.Seconds()
+		}
+		// 均匀分布于 ±45s：极差应接近 90s，均值接近 900s。
+		require.Greater(t, maxSoft-minSoft, 80*time.Second)
+		require.InDelta(t, soft.Seconds(), sum/n, 5)
+	}
+	assertSpread(start)
+
+	// 再水合重新抽样抖动，而不是沿用构造时的系数。
+	clock.Advance(time.Second)
+	same := 0
+	for _, e := range entries {
+		e.mu.Lock()
+		before := e.softTTL.Sub(start)
+		require.NoError(t, e.rehydrateLocked(context.Background(), clock.Now()))
+		if e.softTTL.Sub(clock.Now()) == before {
+			same++
+		}
+		e.mu.Unlock()
+	}
+	require.Less(t, same, n/10)
+	assertSpread(clock.Now())
+}
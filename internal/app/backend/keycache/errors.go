@@ -5,4 +5,8 @@ import "errors"
 var (
 	// ErrRehydrateUnsupported 表示未配置本地再水合器。
 	ErrRehydrateUnsupported = errors.New("rehydrator not configured")
+	// ErrStaleUnlockResult 表示解锁结果的 Epoch 早于 entry 当前的 BlobVersion。
+	ErrStaleUnlockResult = errors.New("stale unlock result")
+	// ErrEntryNotFound 表示 Store 中没有对应 keyID 的 entry。
+	ErrEntryNotFound = errors.New("key cache entry not found")
 )
@@ -1,6 +1,9 @@
 package keycache
 
 import (
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -16,57 +19,84 @@ type Metrics struct {
 	prefetchScans          prometheus.Counter
 	prefetchSkipped        prometheus.Counter
 	prefetchTriggers       *prometheus.CounterVec
+	plainResidency         *prometheus.HistogramVec
+	plainHolders           *prometheus.GaugeVec
+	plainCheckouts         *prometheus.CounterVec
+	plainCopiesZeroed      *prometheus.CounterVec
+	plainCopiesLeaked      *prometheus.CounterVec
+	staleApplies           *prometheus.CounterVec
+	snapshotFailures       *prometheus.CounterVec
+	joinedBackground       *prometheus.CounterVec
+	cacheEvictions         *prometheus.CounterVec
+	cacheBytes             *prometheus.GaugeVec
 }
 
+// 前台刷新加入后台刷新的结果，用作 refresh_joined_background_total 的 outcome 标签。
+const (
+	JoinOutcomeOK      = "ok"
+	JoinOutcomeError   = "error"
+	JoinOutcomeTimeout = "timeout"
+)
+
 // NewMetrics 构造指标集合，reg 为空时默认使用全局注册器。
 func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m, err := NewMetricsWithOptions(reg, metricsopts.Options{})
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// NewMetricsWithOptions 按 opts 为指标加前缀与常量标签，零值保持原指标名。
+func NewMetricsWithOptions(reg prometheus.Registerer, opts metricsopts.Options) (*Metrics, error) {
 	if reg == nil {
 		reg = prometheus.DefaultRegisterer
 	}
 	m := &Metrics{
-		stateGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Name: "key_cache_state",
-			Help: "Number of key cache entries in each state",
-		}, []string{"enclave", "state"}),
-		hardExpiredRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
-			Name: "hard_expired_rejections_total",
-			Help: "Number of requests rejected due to hard expiration",
-		}, []string{"keyspace"}),
-		rehydrateLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
-			Name:    "rehydrate_latency_ms",
-			Help:    "Latency of local rehydrate operations (milliseconds)",
-			Buckets: []float64{0.05, 0.1, 0.2, 0.5, 1, 2, 3, 5, 7.5, 10},
-		}, []string{"keyspace"}),
-		rehydrateFailuresTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
-			Name: "rehydrate_fail_total",
-			Help: "Number of failed local rehydrate attempts",
-		}, []string{"keyspace"}),
-		rehydrateTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
-			Name: "rehydrate_total",
-			Help: "Number of local rehydrate attempts",
-		}, []string{"keyspace"}),
-		singleflightWaiters: prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Name: "singleflight_waiters",
-			Help: "Number of goroutines waiting on key refresh singleflight",
-		}, []string{"keyspace"}),
-		singleflightTimeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
-			Name: "singleflight_wait_timeout_total",
-			Help: "Number of refresh wait budget expirations",
-		}, []string{"keyspace"}),
-		prefetchScans: prometheus.NewCounter(prometheus.CounterOpts{
-			Name: "prefetch_scan_total",
-			Help: "Number of key cache prefetch scans",
-		}),
-		prefetchSkipped: prometheus.NewCounter(prometheus.CounterOpts{
-			Name: "prefetch_skipped_total",
-			Help: "Number of keys skipped due to max in-flight",
-		}),
-		prefetchTriggers: prometheus.NewCounterVec(prometheus.CounterOpts{
-			Name: "prefetch_trigger_total",
-			Help: "Number of keys scheduled by the background prefetcher",
-		}, []string{"keyspace"}),
-	}
-	reg.MustRegister(
+		stateGauge: prometheus.NewGaugeVec(opts.Gauge("key_cache_state",
+			"Number of key cache entries in each state"), []string{"enclave", "state"}),
+		hardExpiredRejections: prometheus.NewCounterVec(opts.Counter("hard_expired_rejections_total",
+			"Number of requests rejected due to hard expiration"), []string{"keyspace"}),
+		rehydrateLatency: prometheus.NewHistogramVec(opts.Histogram("rehydrate_latency_ms",
+			"Latency of local rehydrate operations (milliseconds)",
+			[]float64{0.05, 0.1, 0.2, 0.5, 1, 2, 3, 5, 7.5, 10}), []string{"keyspace"}),
+		rehydrateFailuresTotal: prometheus.NewCounterVec(opts.Counter("rehydrate_fail_total",
+			"Number of failed local rehydrate attempts"), []string{"keyspace"}),
+		rehydrateTotal: prometheus.NewCounterVec(opts.Counter("rehydrate_total",
+			"Number of local rehydrate attempts"), []string{"keyspace"}),
+		singleflightWaiters: prometheus.NewGaugeVec(opts.Gauge("singleflight_waiters",
+			"Number of goroutines waiting on key refresh singleflight"), []string{"keyspace"}),
+		singleflightTimeouts: prometheus.NewCounterVec(opts.Counter("singleflight_wait_timeout_total",
+			"Number of refresh wait budget expirations"), []string{"keyspace"}),
+		prefetchScans: prometheus.NewCounter(opts.Counter("prefetch_scan_total",
+			"Number of key cache prefetch scans")),
+		prefetchSkipped: prometheus.NewCounter(opts.Counter("prefetch_skipped_total",
+			"Number of keys skipped due to max in-flight")),
+		prefetchTriggers: prometheus.NewCounterVec(opts.Counter("prefetch_trigger_total",
+			"Number of keys scheduled by the background prefetcher"), []string{"keyspace"}),
+		plainResidency: prometheus.NewHistogramVec(opts.Histogram("plain_key_residency_seconds",
+			"How long plaintext key material stayed resident in an entry before being zeroed or replaced",
+			[]float64{1, 10, 60, 300, 600, 900, 960, 1800, 3600}), []string{"keyspace"}),
+		plainHolders: prometheus.NewGaugeVec(opts.Gauge("plain_key_entries",
+			"Number of key cache entries currently holding plaintext"), []string{"enclave"}),
+		plainCheckouts: prometheus.NewCounterVec(opts.Counter("plain_key_checkouts_total",
+			"Number of plaintext copies handed out by Checkout"), []string{"keyspace"}),
+		plainCopiesZeroed: prometheus.NewCounterVec(opts.Counter("plain_key_copies_zeroed_total",
+			"Number of checked out plaintext copies zeroed by callers"), []string{"keyspace"}),
+		plainCopiesLeaked: prometheus.NewCounterVec(opts.Counter("plain_key_copies_leaked_total",
+			"Number of checked out plaintext copies garbage collected without Zero (debug tracking only)"), []string{"keyspace"}),
+		staleApplies: prometheus.NewCounterVec(opts.Counter("stale_unlock_apply_total",
+			"Number of unlock results rejected because their epoch was older than the entry blob version"), []string{"keyspace"}),
+		snapshotFailures: prometheus.NewCounterVec(opts.Counter("snapshot_restore_failures_total",
+			"Number of key cache snapshot restores aborted by reason"), []string{"reason"}),
+		joinedBackground: prometheus.NewCounterVec(opts.Counter("refresh_joined_background_total",
+			"Number of foreground refreshes that joined an in-flight background refresh, by outcome"), []string{"keyspace", "outcome"}),
+		cacheEvictions: prometheus.NewCounterVec(opts.Counter("key_cache_evictions_total",
+			"Number of entries evicted from the key cache, by reason"), []string{"keyspace", "reason"}),
+		cacheBytes: prometheus.NewGaugeVec(opts.Gauge("key_cache_bytes",
+			"Approximate memory held by key cache entries (cipher blobs, plaintext keys and per-entry overhead)"), []string{"keyspace"}),
+	}
+	if err := metricsopts.Register(reg,
 		m.stateGauge,
 		m.hardExpiredRejections,
 		m.rehydrateLatency,
@@ -77,8 +107,20 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 		m.prefetchScans,
 		m.prefetchSkipped,
 		m.prefetchTriggers,
-	)
-	return m
+		m.plainResidency,
+		m.plainHolders,
+		m.plainCheckouts,
+		m.plainCopiesZeroed,
+		m.plainCopiesLeaked,
+		m.staleApplies,
+		m.snapshotFailures,
+		m.joinedBackground,
+		m.cacheEvictions,
+		m.cacheBytes,
+	); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
 func (m *Metrics) updateState(enclave string, from, to State) {
@@ -127,6 +169,13 @@ func (m *Metrics) incWaitTimeout(keyspace string) {
 	m.singleflightTimeouts.WithLabelValues(keyspace).Inc()
 }
 
+func (m *Metrics) incJoinedBackground(keyspace, outcome string) {
+	if m == nil || keyspace == "" {
+		return
+	}
+	m.joinedBackground.WithLabelValues(keyspace, outcome).Inc()
+}
+
 func (m *Metrics) incPrefetchScan() {
 	if m == nil {
 		return
@@ -148,6 +197,68 @@ func (m *Metrics) incPrefetchTrigger(keyspace string) {
 	m.prefetchTriggers.WithLabelValues(keyspace).Inc()
 }
 
+func (m *Metrics) observeResidency(keyspace string, d time.Duration) {
+	if m == nil || keyspace == "" {
+		return
+	}
+	m.plainResidency.WithLabelValues(keyspace).Observe(d.Seconds())
+}
+
+func (m *Metrics) addPlainHolder(enclave string, delta float64) {
+	if m == nil || enclave == "" {
+		return
+	}
+	m.plainHolders.WithLabelValues(enclave).Add(delta)
+}
+
+// copyCounters 是某个 keyspace 下明文副本相关的计数器，在 NewEntry 时解析一次，
+// 避免 Checkout 热路径上每次 WithLabelValues 的查找与分配。
+type copyCounters struct {
+	checkouts prometheus.Counter
+	zeroed    prometheus.Counter
+	leaked    prometheus.Counter
+}
+
+// copyCounters 返回 keyspace 的副本计数器；Metrics 为 nil 或 keyspace 为空时返回 nil。
+func (m *Metrics) copyCounters(keyspace string) *copyCounters {
+	if m == nil || keyspace == "" {
+		return nil
+	}
+	return &copyCounters{
+		checkouts: m.plainCheckouts.WithLabelValues(keyspace),
+		zeroed:    m.plainCopiesZeroed.WithLabelValues(keyspace),
+		leaked:    m.plainCopiesLeaked.WithLabelValues(keyspace),
+	}
+}
+
+func (m *Metrics) incStaleApply(keyspace string) {
+	if m == nil || keyspace == "" {
+		return
+	}
+	m.staleApplies.WithLabelValues(keyspace).Inc()
+}
+
+func (m *Metrics) incSnapshotRestoreFailure(reason string) {
+	if m == nil {
+		return
+	}
+	m.snapshotFailures.WithLabelValues(reason).Inc()
+}
+
+func (m *Metrics) incCacheEviction(keyspace, reason string) {
+	if m == nil || keyspace == "" {
+		return
+	}
+	m.cacheEvictions.WithLabelValues(keyspace, reason).Inc()
+}
+
+func (m *Metrics) addCacheBytes(keyspace string, delta int64) {
+	if m == nil || keyspace == "" {
+		return
+	}
+	m.cacheBytes.WithLabelValues(keyspace).Add(float64(delta))
+}
+
 func labelForState(s State) string {
 	switch s {
 	case StateWarm, StateCool, StateInvalid:
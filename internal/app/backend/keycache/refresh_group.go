@@ -2,39 +2,65 @@ package keycache
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/aegis-sign/wallet/pkg/apierrors"
 	"golang.org/x/sync/singleflight"
 )
 
+const defaultJoinCeiling = 10 * time.Millisecond
+
 // RefreshGroup 基于 singleflight 保证每个 key 仅有一个在飞刷新。
 type RefreshGroup struct {
 	metrics *Metrics
 	logger  *slog.Logger
 	group   singleflight.Group
-	notify  UnlockNotifier
+	notify  Notifier
+	// joinCeiling 为前台调用加入后台刷新时可额外等待的上限。
+	joinCeiling time.Duration
+
+	mu      sync.Mutex
+	flights map[string]flightInfo
+}
+
+// flightInfo 记录在飞刷新的来源与时限。
+type flightInfo struct {
+	background bool
+	deadline   time.Time
 }
 
 // RefreshGroupOption 自定义刷新器行为。
 type RefreshGroupOption func(*RefreshGroup)
 
-// WithUnlockNotifier 注入 UnlockNotifier。
-func WithUnlockNotifier(n UnlockNotifier) RefreshGroupOption {
+// WithUnlockNotifier 注入 Notifier。
+func WithUnlockNotifier(n Notifier) RefreshGroupOption {
 	return func(g *RefreshGroup) {
 		g.notify = n
 	}
 }
 
+// WithJoinCeiling 设置前台 Do 加入后台 Go 刷新时的额外等待上限，默认 10ms，<=0 表示不延长。
+// 后台刷新通常早几毫秒发起且预算更长，前台仅凭自身 3ms 预算等待会在新材料到达前超时。
+func WithJoinCeiling(d time.Duration) RefreshGroupOption {
+	return func(g *RefreshGroup) {
+		g.joinCeiling = d
+	}
+}
+
 // NewRefreshGroup 创建刷新协调器。
 func NewRefreshGroup(metrics *Metrics, logger *slog.Logger, opts ...RefreshGroupOption) *RefreshGroup {
 	if logger == nil {
 		logger = slog.Default()
 	}
 	g := &RefreshGroup{
-		metrics: metrics,
-		logger:  logger,
-		notify:  defaultUnlockNotifier(),
+		metrics:     metrics,
+		logger:      logger,
+		notify:      defaultUnlockNotifier(),
+		joinCeiling: defaultJoinCeiling,
+		flights:     make(map[string]flightInfo),
 	}
 	for _, opt := range opts {
 		if opt != nil {
@@ -57,18 +83,23 @@ func (g *RefreshGroup) Go(ctx context.Context, keyspace, keyID string, fn Refres
 	ctx, cancel := context.WithCancel(ctx)
 	go func() {
 		defer cancel()
-		if err := g.Do(ctx, keyspace, keyID, fn); err != nil && g.logger != nil {
+		if err := g.do(ctx, keyspace, keyID, fn, true); err != nil && g.logger != nil {
 			g.logger.Warn("refresh async failed", slog.String("key", keyID), slog.String("keyspace", keyspace), slog.Any("err", err))
 		}
 	}()
 }
 
-// Do 合并相同 key 的刷新操作。
+// Do 合并相同 key 的刷新操作。加入后台刷新时，等待时间可超出自身时限，
+// 延长到该刷新的剩余时限（不超过 joinCeiling）；显式取消仍立即返回。
 func (g *RefreshGroup) Do(ctx context.Context, keyspace, keyID string, fn RefreshFunc) error {
 	if g == nil {
 		var sched NoopScheduler
 		return sched.Do(ctx, keyspace, keyID, fn)
 	}
+	return g.do(ctx, keyspace, keyID, fn, false)
+}
+
+func (g *RefreshGroup) do(ctx context.Context, keyspace, keyID string, fn RefreshFunc, background bool) error {
 	if fn == nil {
 		return nil
 	}
@@ -80,24 +111,101 @@ func (g *RefreshGroup) Do(ctx context.Context, keyspace, keyID string, fn Refres
 	defer done()
 
 	resultCh := g.group.DoChan(keyID, func() (interface{}, error) {
+		g.trackFlight(keyID, background, ctx)
+		defer g.untrackFlight(keyID)
 		return nil, fn(ctx)
 	})
+	joined := false
+	if !background {
+		joined = g.joinedBackground(keyID)
+	}
 
-	select {
-	case <-ctx.Done():
-		if g.metrics != nil {
-			g.metrics.incWaitTimeout(keyspace)
-		}
-		if g.logger != nil {
-			g.logger.Warn("refresh wait timeout", slog.String("key", keyID), slog.String("keyspace", keyspace), slog.String("reason", ctx.Err().Error()))
+	ctxDone := ctx.Done()
+	var extend <-chan time.Time
+	for {
+		select {
+		case <-ctxDone:
+			if !background && extend == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				// 此时刷新必然已登记，重新判断来源，避免 DoChan 刚返回时的登记竞争。
+				if grace := g.joinGrace(keyID); grace > 0 {
+					joined = true
+					timer := time.NewTimer(grace)
+					defer timer.Stop()
+					ctxDone, extend = nil, timer.C
+					continue
+				}
+			}
+			return g.waitTimeout(ctx.Err(), keyspace, keyID, joined)
+		case <-extend:
+			return g.waitTimeout(context.DeadlineExceeded, keyspace, keyID, joined)
+		case res := <-resultCh:
+			if err := res.Err; err != nil {
+				g.maybeNotifyUnlock(ctx, keyspace, keyID, err, !res.Shared)
+			}
+			if joined {
+				outcome := JoinOutcomeOK
+				if res.Err != nil {
+					outcome = JoinOutcomeError
+				}
+				g.metrics.incJoinedBackground(keyspace, outcome)
+			}
+			return res.Err
 		}
-		return ctx.Err()
-	case res := <-resultCh:
-		if err := res.Err; err != nil {
-			g.maybeNotifyUnlock(ctx, keyspace, keyID, err, !res.Shared)
+	}
+}
+
+func (g *RefreshGroup) waitTimeout(err error, keyspace, keyID string, joined bool) error {
+	if g.metrics != nil {
+		g.metrics.incWaitTimeout(keyspace)
+		if joined {
+			g.metrics.incJoinedBackground(keyspace, JoinOutcomeTimeout)
 		}
-		return res.Err
 	}
+	if g.logger != nil {
+		g.logger.Warn("refresh wait timeout", slog.String("key", keyID), slog.String("keyspace", keyspace), slog.String("reason", err.Error()))
+	}
+	return err
+}
+
+func (g *RefreshGroup) trackFlight(keyID string, background bool, ctx context.Context) {
+	info := flightInfo{background: background}
+	if deadline, ok := ctx.Deadline(); ok {
+		info.deadline = deadline
+	}
+	g.mu.Lock()
+	g.flights[keyID] = info
+	g.mu.Unlock()
+}
+
+func (g *RefreshGroup) untrackFlight(keyID string) {
+	g.mu.Lock()
+	delete(g.flights, keyID)
+	g.mu.Unlock()
+}
+
+// joinedBackground 判断当前在飞的刷新是否由后台 Go 发起；
+// 自己发起的刷新在登记前查询不到，按未加入处理。
+func (g *RefreshGroup) joinedBackground(keyID string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	info, ok := g.flights[keyID]
+	return ok && info.background
+}
+
+// joinGrace 返回前台调用在自身时限到期后还可等待的时间：后台刷新的剩余时限，
+// 无时限时取 joinCeiling，且不超过 joinCeiling。
+func (g *RefreshGroup) joinGrace(keyID string) time.Duration {
+	g.mu.Lock()
+	info, ok := g.flights[keyID]
+	g.mu.Unlock()
+	if !ok || !info.background || g.joinCeiling <= 0 {
+		return 0
+	}
+	grace := g.joinCeiling
+	if !info.deadline.IsZero() {
+		grace = min(grace, time.Until(info.deadline))
+	}
+	return grace
 }
 
 func (g *RefreshGroup) maybeNotifyUnlock(ctx context.Context, keyspace, keyID string, err error, primary bool) {
@@ -128,6 +236,7 @@ func (g *RefreshGroup) maybeNotifyUnlock(ctx context.Context, keyspace, keyID st
 		KeyID:         keyID,
 		Reason:        reason,
 		RefreshBudget: unlockErr.RefreshBudget(),
+		Epoch:         unlockErr.Epoch(),
 	}
 	if err := notifier.NotifyUnlock(ctx, event); err != nil && g.logger != nil {
 		g.logger.Warn("notify unlock failed", slog.String("key", keyID), slog.Any("err", err))
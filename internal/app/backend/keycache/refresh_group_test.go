@@ -0,0 +1,53 @@
+package keycache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// raceForegroundWithBackground 先发起一个较慢的后台刷新，再让 3ms 预算的前台调用加入。
+func raceForegroundWithBackground(t *testing.T, group *RefreshGroup) (*slowRehydrator, error) {
+	t.Helper()
+	slow := &slowRehydrator{delay: 8 * time.Millisecond}
+	started := make(chan struct{})
+	group.Go(context.Background(), "prod", "k-race", func(ctx context.Context) error {
+		close(started)
+		_, err := slow.Rehydrate(ctx, "k-race", nil)
+		return err
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Millisecond)
+	defer cancel()
+	err := group.Do(ctx, "prod", "k-race", func(context.Context) error {
+		t.Error("foreground refresh must join the background flight")
+		return nil
+	})
+	return slow, err
+}
+
+func TestRefreshGroupForegroundWaitsForBackgroundFlight(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	group := NewRefreshGroup(metrics, nil, WithJoinCeiling(200*time.Millisecond))
+
+	slow, err := raceForegroundWithBackground(t, group)
+	require.NoError(t, err)
+	require.Equal(t, 1, slow.Calls())
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.joinedBackground.WithLabelValues("prod", JoinOutcomeOK)))
+	require.Zero(t, testutil.ToFloat64(metrics.singleflightTimeouts.WithLabelValues("prod")))
+}
+
+func TestRefreshGroupJoinCeilingDisabled(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	group := NewRefreshGroup(metrics, nil, WithJoinCeiling(0))
+
+	_, err := raceForegroundWithBackground(t, group)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.singleflightTimeouts.WithLabelValues("prod")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.joinedBackground.WithLabelValues("prod", JoinOutcomeTimeout)))
+}
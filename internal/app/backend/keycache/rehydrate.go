@@ -7,6 +7,12 @@ type Rehydrator interface {
 	Rehydrate(ctx context.Context, keyID string, cipherBlob []byte) ([32]byte, error)
 }
 
+// DerivedRehydrator 由支持 BIP-32/SLIP-0010 的再水合器实现：解密主 key 密文后按 path 派生子 key，
+// 只返回子 key 明文，主 key 明文不进入派生 entry。
+type DerivedRehydrator interface {
+	RehydrateDerived(ctx context.Context, keyID, path string, cipherBlob []byte) ([32]byte, error)
+}
+
 // RefreshFunc 是单次刷新任务。
 type RefreshFunc func(ctx context.Context) error
 
@@ -0,0 +1,256 @@
+package keycache
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/infra/snapshot"
+)
+
+// 快照格式：header | records | HMAC-SHA256(header|records)。
+// header = magic(4) | version(1) | createdAt unix nanos(8) | count(4)，record = len(4) | JSON。
+const (
+	snapshotMagic      = "AKCS"
+	snapshotVersion    = 1
+	snapshotHeaderSize = 4 + 1 + 8 + 4
+	snapshotMACSize    = sha256.Size
+	// snapshotMaxRecord 限制单条记录长度，防止损坏的长度字段触发超大分配。
+	snapshotMaxRecord = 1 << 20
+)
+
+// 快照恢复失败原因，用作 snapshot_restore_failures_total 的 reason 标签。
+const (
+	SnapshotFailTampered = "tampered"
+	SnapshotFailFormat   = "format"
+	SnapshotFailIO       = "io"
+)
+
+var (
+	// ErrSnapshotKeyMissing 表示未配置快照 HMAC 密钥。
+	ErrSnapshotKeyMissing = errors.New("key cache snapshot key not configured")
+	// ErrSnapshotTampered 表示快照被截断或篡改，HMAC 校验失败。
+	ErrSnapshotTampered = errors.New("key cache snapshot integrity check failed")
+	// ErrSnapshotFormat 表示快照通过校验但格式无法识别（如版本不支持）。
+	ErrSnapshotFormat = errors.New("key cache snapshot format invalid")
+)
+
+// snapshotRecord 只保存密文与元数据，明文永不落盘。
+type snapshotRecord struct {
+	KeyID         string `json:"keyId"`
+	Path          string `json:"derivationPath,omitempty"`
+	Enclave       string `json:"enclave"`
+	Keyspace      string `json:"keyspace"`
+	CipherBlob    []byte `json:"cipherBlob"`
+	BlobVersion   uint64 `json:"blobVersion"`
+	DEKValidUntil int64  `json:"dekValidUntil"`
+}
+
+// SnapshotHeader 是快照头部信息。
+type SnapshotHeader struct {
+	Version   uint8
+	CreatedAt time.Time
+	Count     uint32
+}
+
+func (e *Entry) snapshotRecord() snapshotRecord {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return snapshotRecord{
+		KeyID:         e.keyID,
+		Path:          e.path,
+		Enclave:       e.enclave,
+		Keyspace:      e.keyspace,
+		CipherBlob:    append([]byte(nil), e.cipherBlob...),
+		BlobVersion:   e.blobVersion,
+		DEKValidUntil: e.dekValidUntil.UnixNano(),
+	}
+}
+
+// WriteSnapshot 将全部 entry 的密文与元数据写入 w，并在末尾追加 HMAC。
+func (s *Store) WriteSnapshot(w io.Writer) error {
+	if len(s.snapshotKey) == 0 {
+		return ErrSnapshotKeyMissing
+	}
+	var records []snapshotRecord
+	s.Range(func(e *Entry) bool {
+		records = append(records, e.snapshotRecord())
+		return true
+	})
+	mac := hmac.New(sha256.New, s.snapshotKey)
+	out := io.MultiWriter(w, mac)
+	header := make([]byte, snapshotHeaderSize)
+	copy(header, snapshotMagic)
+	header[4] = snapshotVersion
+	binary.BigEndian.PutUint64(header[5:13], uint64(s.clock.Now().UnixNano()))
+	binary.BigEndian.PutUint32(header[13:17], uint32(len(records)))
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+	var lenBuf [4]byte
+	for _, rec := range records {
+		payload, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		binary.BigEndian.PutUint32(lenBuf[:], uint32(len(payload)))
+		if _, err := out.Write(lenBuf[:]); err != nil {
+			return err
+		}
+		if _, err := out.Write(payload); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(mac.Sum(nil))
+	return err
+}
+
+// VerifySnapshot 流式计算 r 的 HMAC 并与末尾 32 字节比较，不缓存整个文件。
+func VerifySnapshot(r io.Reader, key []byte) error {
+	if len(key) == 0 {
+		return ErrSnapshotKeyMissing
+	}
+	tw := &macTailWriter{mac: hmac.New(sha256.New, key)}
+	if _, err := io.Copy(tw, r); err != nil {
+		return err
+	}
+	if tw.total < snapshotHeaderSize+snapshotMACSize || !hmac.Equal(tw.mac.Sum(nil), tw.tail) {
+		return ErrSnapshotTampered
+	}
+	return nil
+}
+
+// macTailWriter 将除最后 32 字节外的内容写入 mac，最后 32 字节留作待比较的签名。
+type macTailWriter struct {
+	mac   hash.Hash
+	tail  []byte
+	total int64
+}
+
+func (t *macTailWriter) Write(p []byte) (int, error) {
+	t.total += int64(len(p))
+	buf := append(t.tail, p...)
+	if len(buf) > snapshotMACSize {
+		cut := len(buf) - snapshotMACSize
+		_, _ = t.mac.Write(buf[:cut])
+		buf = append([]byte(nil), buf[cut:]...)
+	}
+	t.tail = buf
+	return len(p), nil
+}
+
+// RestoreSnapshot 先完整校验 HMAC，再解析记录并以 template 为模板构造 entry；
+// 任一步失败都不会写入 Store。DEK 已过期的记录会被跳过。返回恢复的 entry 数。
+func (s *Store) RestoreSnapshot(r io.ReadSeeker, template EntryConfig) (int, error) {
+	n, reason, err := s.restoreSnapshot(r, template)
+	if err != nil {
+		s.metrics.incSnapshotRestoreFailure(reason)
+		s.logger.Error("key cache snapshot restore aborted", slog.String("reason", reason), slog.Any("error", err))
+		return 0, err
+	}
+	return n, nil
+}
+
+func (s *Store) restoreSnapshot(r io.ReadSeeker, template EntryConfig) (int, string, error) {
+	if err := VerifySnapshot(r, s.snapshotKey); err != nil {
+		if errors.Is(err, ErrSnapshotTampered) {
+			return 0, SnapshotFailTampered, err
+		}
+		return 0, SnapshotFailIO, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, SnapshotFailIO, err
+	}
+	br := bufio.NewReader(r)
+	header, err := readSnapshotHeader(br)
+	if err != nil {
+		return 0, SnapshotFailFormat, err
+	}
+	now := s.clock.Now()
+	entries := make([]*Entry, 0, min(int(header.Count), 4096))
+	var lenBuf [4]byte
+	for i := uint32(0); i < header.Count; i++ {
+		if _, err := io.ReadFull(br, lenBuf[:]); err != nil {
+			return 0, SnapshotFailFormat, fmt.Errorf("%w: record %d: %v", ErrSnapshotFormat, i, err)
+		}
+		size := binary.BigEndian.Uint32(lenBuf[:])
+		if size > snapshotMaxRecord {
+			return 0, SnapshotFailFormat, fmt.Errorf("%w: record %d too large", ErrSnapshotFormat, i)
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return 0, SnapshotFailFormat, fmt.Errorf("%w: record %d: %v", ErrSnapshotFormat, i, err)
+		}
+		var rec snapshotRecord
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			return 0, SnapshotFailFormat, fmt.Errorf("%w: record %d: %v", ErrSnapshotFormat, i, err)
+		}
+		validFor := time.Unix(0, rec.DEKValidUntil).Sub(now)
+		if validFor <= 0 {
+			continue
+		}
+		cfg := template
+		cfg.KeyID, cfg.DerivationPath, cfg.Enclave, cfg.Keyspace = rec.KeyID, rec.Path, rec.Enclave, rec.Keyspace
+		cfg.CipherBlob, cfg.BlobVersion = rec.CipherBlob, rec.BlobVersion
+		cfg.PlainKey, cfg.HasPlainKey = [32]byte{}, false
+		cfg.DEKValidFor, cfg.CreatedAt = validFor, now
+		entry, err := NewEntry(cfg)
+		if err != nil {
+			return 0, SnapshotFailFormat, fmt.Errorf("%w: record %d: %v", ErrSnapshotFormat, i, err)
+		}
+		entries = append(entries, entry)
+	}
+	if rest, _ := io.Copy(io.Discard, br); rest != snapshotMACSize {
+		return 0, SnapshotFailFormat, fmt.Errorf("%w: unexpected trailing data", ErrSnapshotFormat)
+	}
+	for _, e := range entries {
+		s.Put(e)
+	}
+	s.logger.Info("key cache snapshot restored", slog.Int("entries", len(entries)), slog.Time("created_at", header.CreatedAt))
+	return len(entries), "", nil
+}
+
+func readSnapshotHeader(r io.Reader) (SnapshotHeader, error) {
+	buf := make([]byte, snapshotHeaderSize)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return SnapshotHeader{}, fmt.Errorf("%w: header: %v", ErrSnapshotFormat, err)
+	}
+	if string(buf[:4]) != snapshotMagic {
+		return SnapshotHeader{}, fmt.Errorf("%w: bad magic", ErrSnapshotFormat)
+	}
+	if buf[4] != snapshotVersion {
+		return SnapshotHeader{}, fmt.Errorf("%w: unsupported version %d", ErrSnapshotFormat, buf[4])
+	}
+	return SnapshotHeader{
+		Version:   buf[4],
+		CreatedAt: time.Unix(0, int64(binary.BigEndian.Uint64(buf[5:13]))),
+		Count:     binary.BigEndian.Uint32(buf[13:17]),
+	}, nil
+}
+
+// SaveSnapshot 原子写入 path。
+func (s *Store) SaveSnapshot(path string) error {
+	return snapshot.Save(path, s.WriteSnapshot)
+}
+
+// LoadSnapshot 从 path 恢复，文件不存在时返回 snapshot.ErrNoSnapshot。
+func (s *Store) LoadSnapshot(path string, template EntryConfig) (int, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, snapshot.ErrNoSnapshot
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return s.RestoreSnapshot(f, template)
+}
@@ -0,0 +1,103 @@
+package keycache
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/infra/snapshot"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var testSnapshotKey = []byte("0123456789abcdef0123456789abcdef")
+
+func snapshotStore(t *testing.T, clock Clock, metrics *Metrics) *Store {
+	t.Helper()
+	return NewStore(StoreConfig{Clock: clock, Metrics: metrics, SnapshotKey: testSnapshotKey})
+}
+
+func writeTestSnapshot(t *testing.T, clock *fakeClock) []byte {
+	t.Helper()
+	store := snapshotStore(t, clock, nil)
+	for i, id := range []string{"k1", "k2", "k3"} {
+		store.Put(mustEntry(t, EntryConfig{
+			KeyID:       id,
+			Enclave:     "enclave-a",
+			PlainKey:    fixedPlain(0x33),
+			HasPlainKey: true,
+			CipherBlob:  []byte("cipher-" + id),
+			BlobVersion: uint64(i + 1),
+			DEKValidFor: time.Hour,
+			Clock:       clock,
+		}))
+	}
+	var buf bytes.Buffer
+	require.NoError(t, store.WriteSnapshot(&buf))
+	require.NotContains(t, buf.String(), string(bytes.Repeat([]byte{0x33}, 32)), "plaintext must never be persisted")
+	return buf.Bytes()
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	clock := newFakeClock(time.Unix(1000, 0))
+	data := writeTestSnapshot(t, clock)
+
+	clock.Advance(10 * time.Minute)
+	restored := snapshotStore(t, clock, nil)
+	n, err := restored.RestoreSnapshot(bytes.NewReader(data), EntryConfig{Clock: clock})
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	e, ok := restored.Get("k2")
+	require.True(t, ok)
+	require.Equal(t, StateCool, e.State())
+	require.Equal(t, uint64(2), e.BlobVersion())
+	rec := e.snapshotRecord()
+	require.Equal(t, []byte("cipher-k2"), rec.CipherBlob)
+	require.Equal(t, time.Unix(1000, 0).Add(time.Hour).UnixNano(), rec.DEKValidUntil)
+}
+
+func TestSnapshotRejectsTampering(t *testing.T) {
+	clock := newFakeClock(time.Unix(1000, 0))
+	data := writeTestSnapshot(t, clock)
+	metrics := NewMetrics(prometheus.NewRegistry())
+
+	offsets := []int{0, 4, 10, snapshotHeaderSize + 2, len(data) / 2, len(data) - snapshotMACSize - 1, len(data) - 1}
+	for _, off := range offsets {
+		tampered := append([]byte(nil), data...)
+		tampered[off] ^= 0x01
+		store := snapshotStore(t, clock, metrics)
+		_, err := store.RestoreSnapshot(bytes.NewReader(tampered), EntryConfig{Clock: clock})
+		require.ErrorIs(t, err, ErrSnapshotTampered, "offset %d", off)
+		require.Zero(t, store.Len(), "offset %d", off)
+	}
+	for _, cut := range []int{1, snapshotMACSize, len(data) / 2, len(data) - 1} {
+		store := snapshotStore(t, clock, metrics)
+		_, err := store.RestoreSnapshot(bytes.NewReader(data[:len(data)-cut]), EntryConfig{Clock: clock})
+		require.ErrorIs(t, err, ErrSnapshotTampered, "truncated %d", cut)
+		require.Zero(t, store.Len())
+	}
+	require.Equal(t, float64(len(offsets)+4), testutil.ToFloat64(metrics.snapshotFailures.WithLabelValues(SnapshotFailTampered)))
+
+	wrongKey := NewStore(StoreConfig{Clock: clock, SnapshotKey: []byte("another-key")})
+	_, err := wrongKey.RestoreSnapshot(bytes.NewReader(data), EntryConfig{Clock: clock})
+	require.ErrorIs(t, err, ErrSnapshotTampered)
+}
+
+func TestSnapshotFileAndMissingKey(t *testing.T) {
+	clock := newFakeClock(time.Unix(1000, 0))
+	path := filepath.Join(t.TempDir(), "keycache.snap")
+	store := snapshotStore(t, clock, nil)
+	_, err := store.LoadSnapshot(path, EntryConfig{Clock: clock})
+	require.ErrorIs(t, err, snapshot.ErrNoSnapshot)
+
+	store.Put(mustEntry(t, EntryConfig{KeyID: "k1", CipherBlob: []byte("c"), DEKValidFor: time.Hour, Clock: clock}))
+	require.NoError(t, store.SaveSnapshot(path))
+	restored := snapshotStore(t, clock, nil)
+	n, err := restored.LoadSnapshot(path, EntryConfig{Clock: clock})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	require.ErrorIs(t, NewStore(StoreConfig{}).WriteSnapshot(&bytes.Buffer{}), ErrSnapshotKeyMissing)
+}
@@ -0,0 +1,327 @@
+package keycache
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// ReasonEnclaveRelocate 是 InvalidateEnclave 发出的解锁事件原因前缀。
+const ReasonEnclaveRelocate = "enclave_relocate"
+
+// StoreConfig 配置 Store。
+type StoreConfig struct {
+	// Notifier 为空时使用 SetUnlockNotifier 注入的全局通知器。
+	Notifier Notifier
+	Logger   *slog.Logger
+	Metrics  *Metrics
+	Clock    Clock
+	// SnapshotKey 是快照 HMAC-SHA256 密钥，为空时不能保存/恢复快照。
+	SnapshotKey []byte
+}
+
+// Store 按 (keyID, 派生路径) 保存 Entry，主 key 的路径为空；并维护 enclave → entry 的二级索引，
+// 以便 Enclave 排空时只处理该 Enclave 上的 key。派生子 key 与主 key 同属一个 keyID，
+// 按 keyID 停用、失效或应用解锁结果时一并处理。
+type Store struct {
+	notifier    Notifier
+	logger      *slog.Logger
+	metrics     *Metrics
+	clock       Clock
+	snapshotKey []byte
+
+	mu        sync.RWMutex
+	entries   map[string]map[string]*Entry
+	byEnclave map[string]map[*Entry]struct{}
+}
+
+// NewStore 构造空的 Store。
+func NewStore(cfg StoreConfig) *Store {
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = NewRealClock()
+	}
+	return &Store{
+		notifier:    cfg.Notifier,
+		logger:      cfg.Logger,
+		metrics:     cfg.Metrics,
+		clock:       cfg.Clock,
+		snapshotKey: append([]byte(nil), cfg.SnapshotKey...),
+		entries:     make(map[string]map[string]*Entry),
+		byEnclave:   make(map[string]map[*Entry]struct{}),
+	}
+}
+
+// Put 写入 entry，同 (keyID, 路径) 的旧 entry 会被替换并从原 enclave 索引中移除。
+func (s *Store) Put(e *Entry) {
+	if e == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	byPath, ok := s.entries[e.keyID]
+	if !ok {
+		byPath = make(map[string]*Entry)
+		s.entries[e.keyID] = byPath
+	}
+	if old, ok := byPath[e.path]; ok {
+		s.unindexLocked(old)
+	}
+	byPath[e.path] = e
+	idx, ok := s.byEnclave[e.enclave]
+	if !ok {
+		idx = make(map[*Entry]struct{})
+		s.byEnclave[e.enclave] = idx
+	}
+	idx[e] = struct{}{}
+}
+
+// Get 按 keyID 查找主 key 的 entry。
+func (s *Store) Get(keyID string) (*Entry, bool) {
+	return s.GetDerived(keyID, "")
+}
+
+// GetDerived 按 keyID 与规范派生路径查找子 key 的 entry，path 为空时等同 Get。
+func (s *Store) GetDerived(keyID, path string) (*Entry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.entries[keyID][path]
+	return e, ok
+}
+
+// Delete 移除 keyID 的主 key 及其全部派生子 key 的 entry。
+func (s *Store) Delete(keyID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(keyID)
+}
+
+// PurgeKey 移除 keyID 的主 key 及其全部派生子 key 的 entry 并将其置为 INVALID（清零明文），用于停用/删除 key；
+// 仍持有这些 entry 的调用方随之失败。返回是否存在任一 entry。
+func (s *Store) PurgeKey(keyID, reason string) bool {
+	s.mu.Lock()
+	removed := s.removeLocked(keyID)
+	s.mu.Unlock()
+	for _, e := range removed {
+		e.invalidate(reason)
+	}
+	return len(removed) > 0
+}
+
+func (s *Store) removeLocked(keyID string) []*Entry {
+	byPath := s.entries[keyID]
+	removed := make([]*Entry, 0, len(byPath))
+	for _, e := range byPath {
+		s.unindexLocked(e)
+		removed = append(removed, e)
+	}
+	delete(s.entries, keyID)
+	return removed
+}
+
+// Len 返回 entry 总数（含派生子 key）。
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := 0
+	for _, byPath := range s.entries {
+		n += len(byPath)
+	}
+	return n
+}
+
+// Range 实现 EntryIterator，遍历基于快照，回调中可安全访问 Store。
+func (s *Store) Range(fn func(*Entry) bool) {
+	s.mu.RLock()
+	snapshot := make([]*Entry, 0, len(s.entries))
+	for _, byPath := range s.entries {
+		for _, e := range byPath {
+			snapshot = append(snapshot, e)
+		}
+	}
+	s.mu.RUnlock()
+	for _, e := range snapshot {
+		if !fn(e) {
+			return
+		}
+	}
+}
+
+// CountByEnclave 返回每个 Enclave 上的 entry 数，供看板使用。
+func (s *Store) CountByEnclave() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	counts := make(map[string]int, len(s.byEnclave))
+	for enclave, idx := range s.byEnclave {
+		counts[enclave] = len(idx)
+	}
+	return counts
+}
+
+// StoreStats 汇总 Store 中各状态的 entry 数。
+type StoreStats struct {
+	Total      int                      `json:"total"`
+	ByState    map[State]int            `json:"byState"`
+	ByKeyspace map[string]map[State]int `json:"byKeyspace"`
+}
+
+// Stats 基于 Range 快照统计各状态与 keyspace 的 entry 数，每个 entry 只短暂持有自身的锁。
+func (s *Store) Stats() StoreStats {
+	stats := StoreStats{ByState: make(map[State]int), ByKeyspace: make(map[string]map[State]int)}
+	s.Range(func(e *Entry) bool {
+		state := e.State()
+		stats.Total++
+		stats.ByState[state]++
+		byState, ok := stats.ByKeyspace[e.keyspace]
+		if !ok {
+			byState = make(map[State]int)
+			stats.ByKeyspace[e.keyspace] = byState
+		}
+		byState[state]++
+		return true
+	})
+	return stats
+}
+
+// InvalidateEnclave 将 enclaveID 上的 entry 降为 COOL（清零明文但保留 DEK，INVALID 保持不变），
+// 并为每个 key 发出迁移解锁事件，返回受影响的 entry 数。其他 Enclave 的 entry 不受影响。
+func (s *Store) InvalidateEnclave(enclaveID, reason string) int {
+	s.mu.RLock()
+	affected := make([]*Entry, 0, len(s.byEnclave[enclaveID]))
+	for e := range s.byEnclave[enclaveID] {
+		affected = append(affected, e)
+	}
+	s.mu.RUnlock()
+	if len(affected) == 0 {
+		return 0
+	}
+	eventReason := ReasonEnclaveRelocate
+	if reason != "" {
+		eventReason += ":" + reason
+	}
+	notifier := s.notifier
+	if notifier == nil {
+		notifier = defaultUnlockNotifier()
+	}
+	notified := make(map[string]struct{}, len(affected))
+	for _, e := range affected {
+		e.coolDown(eventReason)
+		// 派生子 key 共用主 key 的密文，每个 keyID 只发一次解锁事件。
+		if _, ok := notified[e.keyID]; ok {
+			continue
+		}
+		notified[e.keyID] = struct{}{}
+		event := UnlockEvent{
+			Keyspace:      e.keyspace,
+			KeyID:         e.keyID,
+			Reason:        eventReason,
+			RefreshBudget: e.refreshBudget,
+			Epoch:         e.BlobVersion(),
+		}
+		if err := notifier.NotifyUnlock(context.Background(), event); err != nil {
+			s.logger.Warn("key cache relocation notify failed", slog.String("key", e.keyID), slog.String("enclave", enclaveID), slog.Any("error", err))
+		}
+	}
+	s.logger.Info("key cache enclave invalidated", slog.String("enclave", enclaveID), slog.String("reason", reason), slog.Int("entries", len(affected)))
+	return len(affected)
+}
+
+// Flush 将全部 entry 降为 COOL 并清零明文（INVALID 保持不变），返回状态发生变化的 entry 数。
+// 与其他批量方法一样，先在读锁内取快照，清零时不持有 Store 锁。
+func (s *Store) Flush(reason string) int {
+	affected := 0
+	s.Range(func(e *Entry) bool {
+		if e.coolDown(reason) {
+			affected++
+		}
+		return true
+	})
+	return affected
+}
+
+// InvalidateKeyspace 将 keyspace 下的 entry 置为 INVALID，返回状态发生变化的 entry 数。
+func (s *Store) InvalidateKeyspace(keyspace, reason string) int {
+	affected := 0
+	s.Range(func(e *Entry) bool {
+		if e.keyspace == keyspace && e.invalidate(reason) {
+			affected++
+		}
+		return true
+	})
+	return affected
+}
+
+// InvalidateKeys 将指定 key（含其派生子 key）置为 INVALID，不存在的 key 被忽略，返回状态发生变化的 entry 数。
+func (s *Store) InvalidateKeys(keyIDs []string, reason string) int {
+	affected := 0
+	for _, e := range s.lookup(keyIDs) {
+		if e.invalidate(reason) {
+			affected++
+		}
+	}
+	return affected
+}
+
+// RefreshKeys 通过各 entry（含派生子 key）的 RefreshScheduler 异步安排立即重新水合，
+// 跳过不存在与 INVALID 的 key，返回已安排的 entry 数。
+func (s *Store) RefreshKeys(keyIDs []string) int {
+	scheduled := 0
+	for _, e := range s.lookup(keyIDs) {
+		if e.State() == StateInvalid {
+			continue
+		}
+		e.refresher.Go(context.Background(), e.keyspace, e.flightKey(), e.forceRefresh)
+		scheduled++
+	}
+	return scheduled
+}
+
+// lookup 在读锁内按 keyID 取出去重后的主 key 与派生子 key entry。
+func (s *Store) lookup(keyIDs []string) []*Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := make(map[string]struct{}, len(keyIDs))
+	entries := make([]*Entry, 0, len(keyIDs))
+	for _, id := range keyIDs {
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		for _, e := range s.entries[id] {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// ApplyUnlockResult 将后台解锁结果应用到 keyID 的主 key 与派生子 key（共用同一密文），
+// 任一 entry 拒绝过期结果时返回 ErrStaleUnlockResult。
+func (s *Store) ApplyUnlockResult(result UnlockResult) error {
+	entries := s.lookup([]string{result.KeyID})
+	if len(entries) == 0 {
+		return ErrEntryNotFound
+	}
+	var err error
+	for _, e := range entries {
+		if applyErr := e.ApplyUnlockResult(result); applyErr != nil {
+			err = applyErr
+		}
+	}
+	return err
+}
+
+// Ack 实现 ResultSink：将 Dispatcher 回传的成功结果应用到 entry。
+// 过期结果已由 entry 记录指标与日志，key 已被淘汰时直接忽略。
+func (s *Store) Ack(_ context.Context, result UnlockResult) {
+	_ = s.ApplyUnlockResult(result)
+}
+
+func (s *Store) unindexLocked(e *Entry) {
+	idx := s.byEnclave[e.enclave]
+	delete(idx, e)
+	if len(idx) == 0 {
+		delete(s.byEnclave, e.enclave)
+	}
+}
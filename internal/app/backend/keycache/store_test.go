@@ -0,0 +1,212 @@
+package keycache
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []UnlockEvent
+}
+
+func (n *recordingNotifier) NotifyUnlock(_ context.Context, event UnlockEvent) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, event)
+	return nil
+}
+
+func TestStoreInvalidateEnclaveIsTargeted(t *testing.T) {
+	notifier := &recordingNotifier{}
+	store := NewStore(StoreConfig{Notifier: notifier})
+	clock := newFakeClock(time.Unix(0, 0))
+	newWarm := func(keyID, enclave string) *Entry {
+		return mustEntry(t, EntryConfig{
+			KeyID:       keyID,
+			Enclave:     enclave,
+			Keyspace:    "prod",
+			PlainKey:    fixedPlain(0x11),
+			HasPlainKey: true,
+			CipherBlob:  []byte("cipher"),
+			DEKValidFor: time.Hour,
+			Clock:       clock,
+		})
+	}
+	for _, id := range []string{"a1", "a2", "a3"} {
+		store.Put(newWarm(id, "enclave-a"))
+	}
+	for _, id := range []string{"b1", "b2"} {
+		store.Put(newWarm(id, "enclave-b"))
+	}
+	require.Equal(t, map[string]int{"enclave-a": 3, "enclave-b": 2}, store.CountByEnclave())
+
+	// 同 keyID 重新写入到另一个 Enclave 时索引随之迁移。
+	store.Put(newWarm("a3", "enclave-b"))
+	require.Equal(t, map[string]int{"enclave-a": 2, "enclave-b": 3}, store.CountByEnclave())
+
+	require.Equal(t, 2, store.InvalidateEnclave("enclave-a", "drain"))
+	for _, id := range []string{"a1", "a2"} {
+		e, ok := store.Get(id)
+		require.True(t, ok)
+		require.Equal(t, StateCool, e.State())
+	}
+	for _, id := range []string{"a3", "b1", "b2"} {
+		e, ok := store.Get(id)
+		require.True(t, ok)
+		require.Equal(t, StateWarm, e.State(), "key %s on enclave-b must stay warm", id)
+	}
+
+	require.Len(t, notifier.events, 2)
+	for _, event := range notifier.events {
+		require.True(t, strings.HasPrefix(event.KeyID, "a"))
+		require.Equal(t, ReasonEnclaveRelocate+":drain", event.Reason)
+		require.Equal(t, "prod", event.Keyspace)
+	}
+	require.Zero(t, store.InvalidateEnclave("enclave-missing", "drain"))
+
+	store.Delete("b1")
+	require.Equal(t, 4, store.Len())
+	require.Equal(t, map[string]int{"enclave-a": 2, "enclave-b": 2}, store.CountByEnclave())
+}
+
+func TestStoreApplyUnlockResultRejectsStaleEpoch(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	rehydrator := &stubRehydrator{plain: fixedPlain(0x22)}
+	store := NewStore(StoreConfig{})
+	store.Put(mustEntry(t, EntryConfig{
+		KeyID:       "k1",
+		CipherBlob:  []byte("v3"),
+		BlobVersion: 3,
+		DEKValidFor: time.Hour,
+		Clock:       newFakeClock(time.Unix(0, 0)),
+		Metrics:     metrics,
+		Rehydrator:  rehydrator,
+	}))
+
+	// 两个副本都基于 epoch 3 发起解锁，快的先写回，慢的随后到达。
+	fast := UnlockResult{KeyID: "k1", Success: true, Epoch: 3, CipherBlob: []byte("fast")}
+	slow := UnlockResult{KeyID: "k1", Success: true, Epoch: 3, CipherBlob: []byte("slow")}
+	// Store 作为 Dispatcher 的 ResultSink 接收结果。
+	var sink ResultSink = store
+	sink.Ack(context.Background(), fast)
+	require.ErrorIs(t, store.ApplyUnlockResult(slow), ErrStaleUnlockResult)
+	require.ErrorIs(t, store.ApplyUnlockResult(UnlockResult{KeyID: "k1", Success: true, Epoch: 1}), ErrStaleUnlockResult)
+	require.ErrorIs(t, store.ApplyUnlockResult(UnlockResult{KeyID: "missing", Success: true}), ErrEntryNotFound)
+
+	e, _ := store.Get("k1")
+	require.Equal(t, uint64(4), e.BlobVersion())
+	res, err := e.Checkout(context.Background())
+	require.NoError(t, err)
+	res.Zero()
+	require.Equal(t, []byte("fast"), rehydrator.last)
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.staleApplies.WithLabelValues("prod")))
+}
+
+func TestStorePurgeKeyRemovesAndInvalidates(t *testing.T) {
+	var logs bytes.Buffer
+	store, _ := newAdminStore(t, &logs)
+	e, ok := store.Get("k1")
+	require.True(t, ok)
+
+	require.True(t, store.PurgeKey("k1", "tenant_offboarding"))
+	_, ok = store.Get("k1")
+	require.False(t, ok)
+	require.Equal(t, StateInvalid, e.State())
+	require.Equal(t, [32]byte{}, e.priv32, "plaintext must be zeroed")
+	_, err := e.Checkout(context.Background())
+	require.Error(t, err, "callers still holding the entry must not sign")
+	require.Equal(t, 3, store.Len())
+
+	require.False(t, store.PurgeKey("k1", "tenant_offboarding"))
+}
+
+type derivingRehydrator struct {
+	stubRehydrator
+	paths []string
+}
+
+func (d *derivingRehydrator) RehydrateDerived(ctx context.Context, keyID, path string, blob []byte) ([32]byte, error) {
+	d.mu.Lock()
+	d.paths = append(d.paths, path)
+	d.mu.Unlock()
+	return fixedPlain(0x44), nil
+}
+
+func TestStoreTracksDerivedEntries(t *testing.T) {
+	notifier := &recordingNotifier{}
+	store := NewStore(StoreConfig{Notifier: notifier})
+	rehydrator := &derivingRehydrator{stubRehydrator: stubRehydrator{plain: fixedPlain(0x33)}}
+	newEntry := func(keyID, path string) *Entry {
+		return mustEntry(t, EntryConfig{
+			KeyID:          keyID,
+			DerivationPath: path,
+			Enclave:        "enclave-a",
+			CipherBlob:     []byte("cipher"),
+			DEKValidFor:    time.Hour,
+			Clock:          newFakeClock(time.Unix(0, 0)),
+			Rehydrator:     rehydrator,
+		})
+	}
+	store.Put(newEntry("k1", ""))
+	store.Put(newEntry("k1", "m/44'/60'/0'/0/1"))
+	store.Put(newEntry("k1", "m/44'/60'/0'/0/2"))
+	store.Put(newEntry("k2", ""))
+	require.Equal(t, 4, store.Len())
+	require.Equal(t, map[string]int{"enclave-a": 4}, store.CountByEnclave())
+
+	master, ok := store.Get("k1")
+	require.True(t, ok)
+	require.Empty(t, master.DerivationPath())
+	child, ok := store.GetDerived("k1", "m/44'/60'/0'/0/1")
+	require.True(t, ok)
+	require.Equal(t, "k1", child.KeyID())
+	_, ok = store.GetDerived("k1", "m/44'/60'/0'/0/3")
+	require.False(t, ok)
+
+	// 子 key 经 DerivedRehydrator 派生，主 key 仍走 Rehydrate。
+	res, err := child.Checkout(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, fixedPlain(0x44), res.PlainKey)
+	res.Zero()
+	require.Equal(t, []string{"m/44'/60'/0'/0/1"}, rehydrator.paths)
+	res, err = master.Checkout(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, fixedPlain(0x33), res.PlainKey)
+	res.Zero()
+
+	// 子 key 共用主 key 密文，迁移时每个 keyID 只发一次解锁事件，解锁结果一并应用。
+	require.Equal(t, 4, store.InvalidateEnclave("enclave-a", "drain"))
+	require.Len(t, notifier.events, 2)
+	require.NoError(t, store.ApplyUnlockResult(UnlockResult{KeyID: "k1", Success: true, CipherBlob: []byte("v2")}))
+	require.Equal(t, uint64(1), child.BlobVersion())
+	require.Equal(t, uint64(1), master.BlobVersion())
+
+	require.Equal(t, 3, store.InvalidateKeys([]string{"k1"}, ReasonOperatorInvalidate))
+	require.True(t, store.PurgeKey("k1", "tenant_offboarding"))
+	_, ok = store.GetDerived("k1", "m/44'/60'/0'/0/2")
+	require.False(t, ok)
+	require.Equal(t, 1, store.Len())
+	require.Equal(t, map[string]int{"enclave-a": 1}, store.CountByEnclave())
+}
+
+func TestEntryDerivedRequiresDerivedRehydrator(t *testing.T) {
+	e := mustEntry(t, EntryConfig{
+		KeyID:          "k1",
+		DerivationPath: "m/0'",
+		DEKValidFor:    time.Hour,
+		Clock:          newFakeClock(time.Unix(0, 0)),
+		Rehydrator:     &stubRehydrator{plain: fixedPlain(0x33)},
+	})
+	_, err := e.Checkout(context.Background())
+	require.Error(t, err)
+	require.Equal(t, StateInvalid, e.State())
+}
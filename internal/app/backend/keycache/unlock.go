@@ -9,14 +9,23 @@ import (
 	"github.com/aegis-sign/wallet/pkg/apierrors"
 )
 
-// UnlockNotifier 描述异步解锁通道，Story 2.2/2.3 通过它通知/确认解锁任务。
-type UnlockNotifier interface {
-	// NotifyUnlock 将 key 加入后台解锁队列。
+// Notifier 将 key 加入后台解锁队列，由 Store、RefreshGroup 与 HTTP/gRPC handler 调用。
+type Notifier interface {
 	NotifyUnlock(ctx context.Context, event UnlockEvent) error
-	// Ack 由后台解锁完成后调用，用于回传执行结果以便统计指标。
+}
+
+// ResultSink 接收后台解锁任务的最终结果：Dispatcher 在任务成功或放弃重试时对每个任务调用一次，
+// 关闭时仍未完成的任务不会回传。
+type ResultSink interface {
 	Ack(ctx context.Context, result UnlockResult)
 }
 
+// UnlockNotifier 组合 Notifier 与 ResultSink，便于同时实现两端的组件。
+type UnlockNotifier interface {
+	Notifier
+	ResultSink
+}
+
 // UnlockEvent 记录一次解锁请求的上下文。
 type UnlockEvent struct {
 	Keyspace      string
@@ -24,6 +33,8 @@ type UnlockEvent struct {
 	Reason        string
 	RefreshBudget time.Duration
 	RequestID     string
+	// Epoch 是入队时 entry 的 BlobVersion，作为 fencing token 随结果回传。
+	Epoch uint64
 }
 
 // UnlockResult 由后台解锁完成后回传，用于统计/自愈。
@@ -36,15 +47,20 @@ type UnlockResult struct {
 	Attempts int
 	Success  bool
 	Err      error
+
+	// Epoch 原样回传 UnlockEvent.Epoch，ApplyUnlockResult 据此拒绝过期结果。
+	Epoch uint64
+	// CipherBlob 为解锁得到的新密文 DEK，为空时保留原密文。
+	CipherBlob []byte
 }
 
 var (
 	notifierMu           sync.RWMutex
-	globalUnlockNotifier UnlockNotifier = noopUnlockNotifier{}
+	globalUnlockNotifier Notifier = noopUnlockNotifier{}
 )
 
-// SetUnlockNotifier 设置默认 UnlockNotifier，便于网关注入 Dispatcher。
-func SetUnlockNotifier(n UnlockNotifier) {
+// SetUnlockNotifier 设置默认 Notifier，便于网关注入 Dispatcher。
+func SetUnlockNotifier(n Notifier) {
 	notifierMu.Lock()
 	defer notifierMu.Unlock()
 	if n == nil {
@@ -54,7 +70,7 @@ func SetUnlockNotifier(n UnlockNotifier) {
 	globalUnlockNotifier = n
 }
 
-func defaultUnlockNotifier() UnlockNotifier {
+func defaultUnlockNotifier() Notifier {
 	notifierMu.RLock()
 	defer notifierMu.RUnlock()
 	return globalUnlockNotifier
@@ -64,13 +80,12 @@ type noopUnlockNotifier struct{}
 
 func (noopUnlockNotifier) NotifyUnlock(context.Context, UnlockEvent) error { return nil }
 
-func (noopUnlockNotifier) Ack(context.Context, UnlockResult) {}
-
 // UnlockRequiredError 包装 apierrors.CodeUnlockRequired 以携带额外上下文。
 type UnlockRequiredError struct {
 	apiErr        *apierrors.Error
 	reason        string
 	refreshBudget time.Duration
+	epoch         uint64
 }
 
 // NewUnlockRequiredError 根据原因/预算构造错误。
@@ -117,6 +132,14 @@ func (e *UnlockRequiredError) RefreshBudget() time.Duration {
 	return e.refreshBudget
 }
 
+// Epoch 返回触发解锁时 entry 的 BlobVersion。
+func (e *UnlockRequiredError) Epoch() uint64 {
+	if e == nil {
+		return 0
+	}
+	return e.epoch
+}
+
 // AsUnlockRequired 尝试解析 UnlockRequiredError。
 func AsUnlockRequired(err error) (*UnlockRequiredError, bool) {
 	var target *UnlockRequiredError
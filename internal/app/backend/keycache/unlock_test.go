@@ -14,8 +14,6 @@ func (r *recorderNotifier) NotifyUnlock(ctx context.Context, event UnlockEvent)
 	return nil
 }
 
-func (r *recorderNotifier) Ack(context.Context, UnlockResult) {}
-
 func TestSetUnlockNotifier(t *testing.T) {
 	recorder := &recorderNotifier{}
 	SetUnlockNotifier(recorder)
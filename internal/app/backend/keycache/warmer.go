@@ -0,0 +1,182 @@
+package keycache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/infra/snapshot"
+)
+
+const (
+	defaultWarmLimit       = 1000
+	defaultWarmConcurrency = 16
+	defaultWarmTimeout     = 30 * time.Second
+)
+
+// HotKeySource 提供启动时需要预热的 key（EntryKey 形式），按热度降序，最多 limit 个。
+type HotKeySource interface {
+	HotKeys(ctx context.Context, limit int) ([]string, error)
+}
+
+// HotKeySourceFunc 将函数适配为 HotKeySource，便于直接查询元数据存储。
+type HotKeySourceFunc func(ctx context.Context, limit int) ([]string, error)
+
+// HotKeys 实现 HotKeySource。
+func (f HotKeySourceFunc) HotKeys(ctx context.Context, limit int) ([]string, error) {
+	return f(ctx, limit)
+}
+
+// HotKeyFile 是由 SaveHotKeys 持久化的热 key 列表，每行一个 key，空行与 # 开头的行被忽略；文件不存在时返回空列表。
+type HotKeyFile string
+
+// HotKeys 实现 HotKeySource。
+func (f HotKeyFile) HotKeys(_ context.Context, limit int) ([]string, error) {
+	var keys []string
+	err := snapshot.Load(string(f), func(r io.Reader) error {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() && (limit <= 0 || len(keys) < limit) {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			keys = append(keys, line)
+		}
+		return scanner.Err()
+	})
+	if errors.Is(err, snapshot.ErrNoSnapshot) {
+		return nil, nil
+	}
+	return keys, err
+}
+
+// SaveHotKeys 原子写入 c 中最近访问的至多 limit 个 key，通常在优雅退出时调用，供下次启动的 Warmer 读取。
+func SaveHotKeys(path string, c *Cache, limit int) error {
+	keys := c.HotKeys(limit)
+	return snapshot.Save(path, func(w io.Writer) error {
+		for _, key := range keys {
+			if _, err := fmt.Fprintln(w, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// WarmerConfig 配置 Warmer。
+type WarmerConfig struct {
+	Source HotKeySource
+	Cache  *Cache
+	// Loader 从元数据存储读取密文并构造 entry，与运行时 Cache.GetOrLoad 使用的相同。
+	Loader Loader
+	// Limit 为预热的 key 数上限，默认 1000。
+	Limit int
+	// Concurrency 为并发加载与再水合的上限，默认 16，避免启动时压垮元数据存储与 Enclave。
+	Concurrency int
+	// Timeout 为整体预热时限，默认 30s；超时后未完成的 key 留给运行时按需加载，Warmer 仍视为完成。
+	Timeout time.Duration
+	Logger  *slog.Logger
+}
+
+// WarmResult 汇总一次预热的结果。
+type WarmResult struct {
+	Listed int
+	Warmed int
+	Failed int
+}
+
+// Warmer 在启动时加载热 key 的密文并预先再水合，使发布后最初几分钟的请求命中 WARM entry，
+// 而不是集中触发 UNLOCK_REQUIRED；Run 结束前 Warmed 返回 false，持有 Cache 的进程据此控制自身就绪。
+type Warmer struct {
+	cfg  WarmerConfig
+	done atomic.Bool
+}
+
+// NewWarmer 构造 Warmer。
+func NewWarmer(cfg WarmerConfig) (*Warmer, error) {
+	if cfg.Source == nil || cfg.Cache == nil || cfg.Loader == nil {
+		return nil, errors.New("warmer requires source, cache and loader")
+	}
+	if cfg.Limit <= 0 {
+		cfg.Limit = defaultWarmLimit
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultWarmConcurrency
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultWarmTimeout
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	return &Warmer{cfg: cfg}, nil
+}
+
+// Warmed 报告预热是否已结束（无论成功与否）。
+func (w *Warmer) Warmed() bool {
+	return w.done.Load()
+}
+
+// Run 读取热 key 并以至多 Concurrency 个 goroutine 加载、再水合，返回时 Warmed 变为 true。
+// 单个 key 失败只计数与记录日志；只有读取热 key 列表失败时返回错误。
+func (w *Warmer) Run(ctx context.Context) (WarmResult, error) {
+	defer w.done.Store(true)
+	ctx, cancel := context.WithTimeout(ctx, w.cfg.Timeout)
+	defer cancel()
+	start := time.Now()
+	keys, err := w.cfg.Source.HotKeys(ctx, w.cfg.Limit)
+	if err != nil {
+		w.cfg.Logger.Warn("key cache warmup skipped", slog.Any("error", err))
+		return WarmResult{}, fmt.Errorf("list hot keys: %w", err)
+	}
+	if len(keys) > w.cfg.Limit {
+		keys = keys[:w.cfg.Limit]
+	}
+	var warmed, failed atomic.Int64
+	sem := make(chan struct{}, w.cfg.Concurrency)
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(key string) {
+			defer func() { <-sem; wg.Done() }()
+			if err := w.warm(ctx, key); err != nil {
+				failed.Add(1)
+				w.cfg.Logger.Debug("key cache warmup failed", slog.String("key", key), slog.Any("error", err))
+				return
+			}
+			warmed.Add(1)
+		}(key)
+	}
+	wg.Wait()
+	result := WarmResult{Listed: len(keys), Warmed: int(warmed.Load()), Failed: int(failed.Load())}
+	w.cfg.Logger.Info("key cache warmup finished",
+		slog.Int("listed", result.Listed),
+		slog.Int("warmed", result.Warmed),
+		slog.Int("failed", result.Failed),
+		slog.Duration("elapsed", time.Since(start)),
+		slog.Bool("timed_out", ctx.Err() != nil))
+	return result, nil
+}
+
+// warm 加载 key 的 entry 并在其不持有新鲜明文时再水合。
+func (w *Warmer) warm(ctx context.Context, key string) error {
+	e, err := w.cfg.Cache.GetOrLoad(ctx, key, w.cfg.Loader)
+	if err != nil {
+		return err
+	}
+	return e.refreshOnce(ctx)
+}
@@ -0,0 +1,69 @@
+package keycache
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmerRehydratesHotKeysFromFile(t *testing.T) {
+	clock := newFakeClock(time.Unix(0, 0))
+	previous := NewCache(CacheConfig{Clock: clock})
+	for _, id := range []string{"cold", "warm", "hot"} {
+		previous.Put(mustEntry(t, EntryConfig{KeyID: id, Clock: clock}))
+		clock.Advance(time.Second)
+	}
+	path := filepath.Join(t.TempDir(), "hot-keys")
+	require.NoError(t, SaveHotKeys(path, previous, 2))
+
+	rehydrator := &stubRehydrator{plain: fixedPlain(0x66)}
+	var inFlight, peak atomic.Int32
+	load := func(_ context.Context, key string) (*Entry, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		if key == "warm" {
+			return nil, errors.New("metadata unavailable")
+		}
+		return mustEntry(t, EntryConfig{KeyID: key, CipherBlob: []byte("cipher-" + key), Clock: clock, Rehydrator: rehydrator}), nil
+	}
+	cache := NewCache(CacheConfig{Clock: clock})
+	warmer, err := NewWarmer(WarmerConfig{Source: HotKeyFile(path), Cache: cache, Loader: load, Concurrency: 1})
+	require.NoError(t, err)
+	require.False(t, warmer.Warmed())
+
+	result, err := warmer.Run(context.Background())
+	require.NoError(t, err)
+	require.True(t, warmer.Warmed())
+	require.Equal(t, WarmResult{Listed: 2, Warmed: 1, Failed: 1}, result)
+	require.Equal(t, int32(1), peak.Load())
+	hot, ok := cache.Get("hot")
+	require.True(t, ok)
+	require.Equal(t, StateWarm, hot.State())
+	require.Equal(t, 1, rehydrator.Calls())
+	require.Equal(t, []byte("cipher-hot"), rehydrator.LastBlob())
+}
+
+func TestWarmerMissingHotKeyFile(t *testing.T) {
+	warmer, err := NewWarmer(WarmerConfig{
+		Source: HotKeyFile(filepath.Join(t.TempDir(), "absent")),
+		Cache:  NewCache(CacheConfig{}),
+		Loader: func(context.Context, string) (*Entry, error) { return nil, nil },
+	})
+	require.NoError(t, err)
+	result, err := warmer.Run(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, result)
+	require.True(t, warmer.Warmed())
+}
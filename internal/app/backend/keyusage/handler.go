@@ -0,0 +1,63 @@
+package keyusage
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const defaultIdleLimit = 1000
+
+type idleResponse struct {
+	Threshold string    `json:"threshold"`
+	Tracked   int       `json:"tracked"`
+	Keys      []IdleKey `json:"keys"`
+}
+
+// IdleHandler 返回闲置 key 列表的管理端点。
+// 查询参数：days（整数天）或 threshold（Go duration，如 720h），limit（默认 1000）。
+func (t *Tracker) IdleHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "GET required", http.StatusMethodNotAllowed)
+			return
+		}
+		threshold := t.cfg.IdleThreshold
+		query := r.URL.Query()
+		if v := query.Get("days"); v != "" {
+			days, err := strconv.Atoi(v)
+			if err != nil || days < 0 {
+				http.Error(w, "days must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			threshold = time.Duration(days) * 24 * time.Hour
+		} else if v := query.Get("threshold"); v != "" {
+			parsed, err := time.ParseDuration(v)
+			if err != nil || parsed < 0 {
+				http.Error(w, "threshold must be a non-negative duration", http.StatusBadRequest)
+				return
+			}
+			threshold = parsed
+		}
+		limit := defaultIdleLimit
+		if v := query.Get("limit"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			limit = parsed
+		}
+		resp := idleResponse{
+			Threshold: threshold.String(),
+			Tracked:   t.Len(),
+			Keys:      t.Idle(threshold, limit),
+		}
+		if resp.Keys == nil {
+			resp.Keys = []IdleKey{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
@@ -0,0 +1,49 @@
+package keyusage
+
+import (
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics 记录 key 闲置时长分布。
+type Metrics struct {
+	lastUsedAge prometheus.Summary
+	tracked     prometheus.Gauge
+}
+
+// NewMetrics 构造指标集合，reg 为空时默认使用全局注册器。
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m, err := NewMetricsWithOptions(reg, metricsopts.Options{})
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// NewMetricsWithOptions 按 opts 为指标加前缀与常量标签，零值保持原指标名。
+func NewMetricsWithOptions(reg prometheus.Registerer, opts metricsopts.Options) (*Metrics, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	m := &Metrics{
+		lastUsedAge: prometheus.NewSummary(opts.Summary("key_last_used_age_seconds",
+			"Seconds since each tracked key last signed, sampled periodically",
+			map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001})),
+		tracked: prometheus.NewGauge(opts.Gauge("key_usage_tracked_keys",
+			"Number of keys tracked for last-used reporting")),
+	}
+	if err := metricsopts.Register(reg, m.lastUsedAge, m.tracked); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Metrics) observeAges(ages []float64) {
+	if m == nil {
+		return
+	}
+	for _, age := range ages {
+		m.lastUsedAge.Observe(age)
+	}
+	m.tracked.Set(float64(len(ages)))
+}
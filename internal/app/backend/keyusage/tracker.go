@@ -0,0 +1,256 @@
+// Package keyusage 记录每个 key 最近一次成功签名的时间，用于识别长期闲置的 key。
+package keyusage
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/infra/snapshot"
+)
+
+const (
+	defaultMaxKeys          = 100000
+	defaultSampleInterval   = time.Minute
+	defaultSnapshotInterval = 5 * time.Minute
+	defaultIdleThreshold    = 90 * 24 * time.Hour
+
+	snapshotVersion = 1
+)
+
+// Config 配置 Tracker。
+type Config struct {
+	// MaxKeys 限制跟踪的 key 数量，超出后淘汰最久未使用的 key。
+	MaxKeys int
+	// SnapshotPath 为空时不做持久化。
+	SnapshotPath     string
+	SnapshotInterval time.Duration
+	SampleInterval   time.Duration
+	// IdleThreshold 是闲置查询未指定阈值时的默认值。
+	IdleThreshold time.Duration
+	Metrics       *Metrics
+	Logger        *slog.Logger
+	Now           func() time.Time
+}
+
+func (c *Config) normalize() {
+	if c.MaxKeys <= 0 {
+		c.MaxKeys = defaultMaxKeys
+	}
+	if c.SnapshotInterval <= 0 {
+		c.SnapshotInterval = defaultSnapshotInterval
+	}
+	if c.SampleInterval <= 0 {
+		c.SampleInterval = defaultSampleInterval
+	}
+	if c.IdleThreshold <= 0 {
+		c.IdleThreshold = defaultIdleThreshold
+	}
+	if c.Logger == nil {
+		c.Logger = slog.Default()
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+}
+
+// IdleKey 描述一个闲置 key。
+type IdleKey struct {
+	KeyID    string    `json:"keyId"`
+	LastUsed time.Time `json:"lastUsed"`
+	IdleFor  string    `json:"idleFor"`
+}
+
+type record struct {
+	keyID    string
+	lastUsed time.Time
+}
+
+// Tracker 以有界 LRU 保存 key 的最近使用时间，链表头为最近使用。
+type Tracker struct {
+	cfg Config
+
+	mu    sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+}
+
+// NewTracker 构造 Tracker。
+func NewTracker(cfg Config) *Tracker {
+	cfg.normalize()
+	return &Tracker{
+		cfg:   cfg,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Touch 记录 keyID 在当前时刻被使用。
+func (t *Tracker) Touch(keyID string) {
+	if t == nil || keyID == "" {
+		return
+	}
+	t.touchAt(keyID, t.cfg.Now())
+}
+
+func (t *Tracker) touchAt(keyID string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if elem, ok := t.items[keyID]; ok {
+		rec := elem.Value.(*record)
+		if at.After(rec.lastUsed) {
+			rec.lastUsed = at
+		}
+		t.order.MoveToFront(elem)
+		return
+	}
+	t.items[keyID] = t.order.PushFront(&record{keyID: keyID, lastUsed: at})
+	for t.order.Len() > t.cfg.MaxKeys {
+		oldest := t.order.Back()
+		t.order.Remove(oldest)
+		delete(t.items, oldest.Value.(*record).keyID)
+	}
+}
+
+// LastUsed 返回 keyID 最近一次使用时间。
+func (t *Tracker) LastUsed(keyID string) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if elem, ok := t.items[keyID]; ok {
+		return elem.Value.(*record).lastUsed, true
+	}
+	return time.Time{}, false
+}
+
+// Len 返回当前跟踪的 key 数量。
+func (t *Tracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.order.Len()
+}
+
+// Idle 返回闲置超过 threshold 的 key，按闲置时间从长到短排序，limit<=0 表示不限。
+func (t *Tracker) Idle(threshold time.Duration, limit int) []IdleKey {
+	now := t.cfg.Now()
+	cutoff := now.Add(-threshold)
+	var out []IdleKey
+	t.mu.Lock()
+	for elem := t.order.Back(); elem != nil; elem = elem.Prev() {
+		rec := elem.Value.(*record)
+		if !rec.lastUsed.Before(cutoff) {
+			continue
+		}
+		out = append(out, IdleKey{KeyID: rec.keyID, LastUsed: rec.lastUsed})
+	}
+	t.mu.Unlock()
+	sort.SliceStable(out, func(i, j int) bool { return out[i].LastUsed.Before(out[j].LastUsed) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	for i := range out {
+		out[i].IdleFor = now.Sub(out[i].LastUsed).Truncate(time.Second).String()
+	}
+	return out
+}
+
+type snapshotFile struct {
+	Version int             `json:"version"`
+	SavedAt time.Time       `json:"savedAt"`
+	Keys    []snapshotEntry `json:"keys"`
+}
+
+type snapshotEntry struct {
+	KeyID    string    `json:"keyId"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// WriteSnapshot 按最久未使用到最近使用的顺序导出，保证 Restore 后 LRU 顺序一致。
+func (t *Tracker) WriteSnapshot(w io.Writer) error {
+	file := snapshotFile{Version: snapshotVersion, SavedAt: t.cfg.Now().UTC()}
+	t.mu.Lock()
+	file.Keys = make([]snapshotEntry, 0, t.order.Len())
+	for elem := t.order.Back(); elem != nil; elem = elem.Prev() {
+		rec := elem.Value.(*record)
+		file.Keys = append(file.Keys, snapshotEntry{KeyID: rec.keyID, LastUsed: rec.lastUsed})
+	}
+	t.mu.Unlock()
+	return json.NewEncoder(w).Encode(file)
+}
+
+// ReadSnapshot 合并快照内容，已存在且更新的记录不会被覆盖。
+func (t *Tracker) ReadSnapshot(r io.Reader) error {
+	var file snapshotFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return fmt.Errorf("keyusage: decode snapshot: %w", err)
+	}
+	if file.Version != snapshotVersion {
+		return fmt.Errorf("keyusage: unsupported snapshot version %d", file.Version)
+	}
+	for _, entry := range file.Keys {
+		if entry.KeyID != "" {
+			t.touchAt(entry.KeyID, entry.LastUsed)
+		}
+	}
+	return nil
+}
+
+// Save 将当前状态写入 SnapshotPath，未配置路径时直接返回。
+func (t *Tracker) Save() error {
+	if t.cfg.SnapshotPath == "" {
+		return nil
+	}
+	return snapshot.Save(t.cfg.SnapshotPath, t.WriteSnapshot)
+}
+
+// Load 从 SnapshotPath 恢复状态，文件不存在视为空状态。
+func (t *Tracker) Load() error {
+	if t.cfg.SnapshotPath == "" {
+		return nil
+	}
+	err := snapshot.Load(t.cfg.SnapshotPath, t.ReadSnapshot)
+	if errors.Is(err, snapshot.ErrNoSnapshot) {
+		return nil
+	}
+	return err
+}
+
+// Run 周期性采样闲置时长指标并持久化快照，直到 ctx 结束。
+func (t *Tracker) Run(ctx context.Context) {
+	sample := time.NewTicker(t.cfg.SampleInterval)
+	defer sample.Stop()
+	persist := time.NewTicker(t.cfg.SnapshotInterval)
+	defer persist.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sample.C:
+			t.sampleAges()
+		case <-persist.C:
+			if err := t.Save(); err != nil {
+				t.cfg.Logger.Warn("key usage snapshot failed", "error", err)
+			}
+		}
+	}
+}
+
+func (t *Tracker) sampleAges() {
+	if t.cfg.Metrics == nil {
+		return
+	}
+	now := t.cfg.Now()
+	t.mu.Lock()
+	ages := make([]float64, 0, t.order.Len())
+	for elem := t.order.Front(); elem != nil; elem = elem.Next() {
+		ages = append(ages, now.Sub(elem.Value.(*record).lastUsed).Seconds())
+	}
+	t.mu.Unlock()
+	t.cfg.Metrics.observeAges(ages)
+}
@@ -0,0 +1,92 @@
+package keyusage
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func TestTrackerIdleThreshold(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	tracker := NewTracker(Config{Now: clock.Now})
+	tracker.Touch("old")
+	clock.now = clock.now.Add(60 * 24 * time.Hour)
+	tracker.Touch("mid")
+	clock.now = clock.now.Add(40 * 24 * time.Hour)
+	tracker.Touch("fresh")
+
+	idle := tracker.Idle(90*24*time.Hour, 0)
+	require.Len(t, idle, 1)
+	require.Equal(t, "old", idle[0].KeyID)
+
+	idle = tracker.Idle(30*24*time.Hour, 0)
+	require.Equal(t, []string{"old", "mid"}, []string{idle[0].KeyID, idle[1].KeyID})
+
+	// 再次签名后不再闲置。
+	tracker.Touch("old")
+	require.Empty(t, tracker.Idle(90*24*time.Hour, 0))
+}
+
+func TestTrackerEvictsLeastRecentlyUsed(t *testing.T) {
+	tracker := NewTracker(Config{MaxKeys: 2})
+	tracker.Touch("a")
+	tracker.Touch("b")
+	tracker.Touch("a")
+	tracker.Touch("c")
+	require.Equal(t, 2, tracker.Len())
+	_, ok := tracker.LastUsed("b")
+	require.False(t, ok)
+	_, ok = tracker.LastUsed("a")
+	require.True(t, ok)
+}
+
+func TestTrackerSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	tracker := NewTracker(Config{SnapshotPath: path, Now: clock.Now})
+	tracker.Touch("k1")
+	clock.now = clock.now.Add(time.Hour)
+	tracker.Touch("k2")
+	require.NoError(t, tracker.Save())
+
+	restored := NewTracker(Config{SnapshotPath: path, Now: clock.Now})
+	require.NoError(t, restored.Load())
+	require.Equal(t, 2, restored.Len())
+	last, ok := restored.LastUsed("k1")
+	require.True(t, ok)
+	require.True(t, last.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	// 文件不存在视为空状态。
+	empty := NewTracker(Config{SnapshotPath: filepath.Join(t.TempDir(), "missing.json")})
+	require.NoError(t, empty.Load())
+	require.Zero(t, empty.Len())
+}
+
+func TestIdleHandler(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	tracker := NewTracker(Config{Now: clock.Now})
+	tracker.Touch("k1")
+	tracker.Touch("k2")
+	clock.now = clock.now.Add(10 * 24 * time.Hour)
+
+	rr := httptest.NewRecorder()
+	tracker.IdleHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/keys/idle?days=7&limit=1", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp idleResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, 2, resp.Tracked)
+	require.Len(t, resp.Keys, 1)
+
+	rr = httptest.NewRecorder()
+	tracker.IdleHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/keys/idle?days=x", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
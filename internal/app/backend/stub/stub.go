@@ -18,7 +18,22 @@ func (Backend) Create(context.Context, *signerv1.CreateRequest) (*signerv1.Creat
 	return nil, apierrors.New(apierrors.CodeRetryLater, "stub backend: implement Create")
 }
 
+// ImportKey 当前仅返回占位错误，提醒尚未接入真实实现。
+func (Backend) ImportKey(context.Context, *signerv1.ImportKeyRequest) (*signerv1.CreateResponse, error) {
+	return nil, apierrors.New(apierrors.CodeRetryLater, "stub backend: implement ImportKey")
+}
+
 // Sign 当前仅返回占位错误，提醒尚未接入真实实现。
 func (Backend) Sign(context.Context, *signerv1.SignRequest) (*signerv1.SignResponse, error) {
 	return nil, apierrors.New(apierrors.CodeRetryLater, "stub backend: implement Sign")
 }
+
+// GetPublicKey 当前仅返回占位错误，提醒尚未接入真实实现。
+func (Backend) GetPublicKey(context.Context, *signerv1.GetPublicKeyRequest) (*signerv1.CreateResponse, error) {
+	return nil, apierrors.New(apierrors.CodeRetryLater, "stub backend: implement GetPublicKey")
+}
+
+// DisableKey 当前仅返回占位错误，提醒尚未接入真实实现。
+func (Backend) DisableKey(context.Context, *signerv1.DisableKeyRequest) (*signerv1.DisableKeyResponse, error) {
+	return nil, apierrors.New(apierrors.CodeRetryLater, "stub backend: implement DisableKey")
+}
@@ -0,0 +1,216 @@
+// Package audit 为每次 Create/Import/Sign 输出只追加的结构化审计记录，
+// 支持 stdout、文件与 Kafka 等 sink，满足合规对审计轨迹的要求。
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// 审计操作类型。
+const (
+	OpCreate = "create"
+	OpImport = "import"
+	OpSign   = "sign"
+	// OpSignDryRun 为未实际签名的 dry-run 请求，与真实签名分开统计。
+	OpSignDryRun = "sign_dry_run"
+	// OpExportBackup 为管理员导出加密 key 备份，含被拒绝的尝试。
+	OpExportBackup = "export_key_backup"
+)
+
+// Event 是一条审计记录；只记录摘要的 SHA-256，不落盘原始摘要。
+type Event struct {
+	Time         time.Time `json:"time"`
+	Operation    string    `json:"operation"`
+	KeyID        string    `json:"keyId,omitempty"`
+	TenantID     string    `json:"tenantId,omitempty"`
+	RequestID    string    `json:"requestId,omitempty"`
+	Principal    string    `json:"principal,omitempty"`
+	DigestSHA256 string    `json:"digestSha256,omitempty"`
+	// DerivationPath 为签名所用子 key 的规范路径，主 key 签名时为空。
+	DerivationPath string `json:"derivationPath,omitempty"`
+	// HashAlgorithm 为服务端对 rawMessage 使用的哈希算法，调用方直接提交摘要时为空。
+	HashAlgorithm string `json:"hashAlgorithm,omitempty"`
+	Enclave       string `json:"enclave,omitempty"`
+	// Reason 与 BlobSHA256 仅用于 export_key_backup：调用方给出的导出原因与导出 blob 的 SHA-256。
+	Reason     string `json:"reason,omitempty"`
+	BlobSHA256 string `json:"blobSha256,omitempty"`
+	// Result 为 OK 或错误码。
+	Result    string  `json:"result"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+// Auditor 写入审计记录，实现须并发安全。
+type Auditor interface {
+	Record(ctx context.Context, ev Event) error
+	Close() error
+}
+
+// DigestHash 返回 digest 的 SHA-256 十六进制串，digest 为空时返回空串。
+func DigestHash(digest []byte) string {
+	if len(digest) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(digest)
+	return hex.EncodeToString(sum[:])
+}
+
+// WriterAuditor 以 JSON Lines 写入 io.Writer，每条记录一次 Write。
+type WriterAuditor struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	sync   func() error
+}
+
+// NewWriterAuditor 构造写入 w 的审计器（如 os.Stdout），Close 不会关闭 w。
+func NewWriterAuditor(w io.Writer) *WriterAuditor {
+	return &WriterAuditor{w: w}
+}
+
+// FileConfig 配置文件 sink。
+type FileConfig struct {
+	Path string
+	// Sync 为 true 时每条记录后 fsync，牺牲吞吐换取掉电不丢记录。
+	Sync bool
+}
+
+// NewFileAuditor 以 O_APPEND 打开（不存在时创建，权限 0600）审计文件。
+func NewFileAuditor(cfg FileConfig) (*WriterAuditor, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("audit file path is required")
+	}
+	f, err := os.OpenFile(cfg.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit file: %w", err)
+	}
+	a := &WriterAuditor{w: f, closer: f}
+	if cfg.Sync {
+		a.sync = f.Sync
+	}
+	return a, nil
+}
+
+// Record 实现 Auditor。
+func (a *WriterAuditor) Record(_ context.Context, ev Event) error {
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.w.Write(line); err != nil {
+		return err
+	}
+	if a.sync != nil {
+		return a.sync()
+	}
+	return nil
+}
+
+// Close 关闭由审计器打开的文件。
+func (a *WriterAuditor) Close() error {
+	if a.closer == nil {
+		return nil
+	}
+	return a.closer.Close()
+}
+
+// Producer 是 Kafka 客户端的最小适配接口，key 为 keyId 以保证同一 key 的记录有序。
+type Producer interface {
+	Produce(ctx context.Context, key, value []byte) error
+	Close() error
+}
+
+// KafkaAuditor 将审计记录以 JSON 发送到 Producer 绑定的 topic。
+type KafkaAuditor struct {
+	producer Producer
+}
+
+// NewKafkaAuditor 构造 Kafka sink，producer 由调用方按所用客户端适配。
+func NewKafkaAuditor(producer Producer) (*KafkaAuditor, error) {
+	if producer == nil {
+		return nil, errors.New("kafka producer is required")
+	}
+	return &KafkaAuditor{producer: producer}, nil
+}
+
+// Record 实现 Auditor。
+func (a *KafkaAuditor) Record(ctx context.Context, ev Event) error {
+	value, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return a.producer.Produce(ctx, []byte(ev.KeyID), value)
+}
+
+// Close 关闭 producer。
+func (a *KafkaAuditor) Close() error {
+	return a.producer.Close()
+}
+
+// Multi 将记录写入全部 sink，任一失败时返回合并后的错误，但不会跳过其余 sink。
+type Multi []Auditor
+
+// Record 实现 Auditor。
+func (m Multi) Record(ctx context.Context, ev Event) error {
+	var errs []error
+	for _, a := range m {
+		if err := a.Record(ctx, ev); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close 关闭全部 sink。
+func (m Multi) Close() error {
+	var errs []error
+	for _, a := range m {
+		if err := a.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type targetSlotKey struct{}
+
+type targetSlot struct {
+	mu sync.Mutex
+	id string
+}
+
+// TrackTarget 在上下文中预留记录目标 Enclave 的槽位，供下游 RecordTarget 填写。
+func TrackTarget(ctx context.Context) context.Context {
+	return context.WithValue(ctx, targetSlotKey{}, &targetSlot{})
+}
+
+// RecordTarget 记录本次调用实际路由到的 Enclave，上下文未预留槽位时忽略。
+func RecordTarget(ctx context.Context, id string) {
+	if slot, ok := ctx.Value(targetSlotKey{}).(*targetSlot); ok {
+		slot.mu.Lock()
+		slot.id = id
+		slot.mu.Unlock()
+	}
+}
+
+// TargetFrom 读取 RecordTarget 写入的 Enclave。
+func TargetFrom(ctx context.Context) string {
+	slot, ok := ctx.Value(targetSlotKey{}).(*targetSlot)
+	if !ok {
+		return ""
+	}
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+	return slot.id
+}
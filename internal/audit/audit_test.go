@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileAuditorAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for i, key := range []string{"k1", "k2"} {
+		a, err := NewFileAuditor(FileConfig{Path: path, Sync: i == 0})
+		require.NoError(t, err)
+		require.NoError(t, a.Record(context.Background(), Event{Operation: OpSign, KeyID: key, Result: "OK"}))
+		require.NoError(t, a.Close())
+	}
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var keys []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ev Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &ev))
+		keys = append(keys, ev.KeyID)
+	}
+	require.Equal(t, []string{"k1", "k2"}, keys)
+
+	_, err = NewFileAuditor(FileConfig{})
+	require.Error(t, err)
+}
+
+type fakeProducer struct {
+	keys   []string
+	values [][]byte
+	err    error
+}
+
+func (p *fakeProducer) Produce(_ context.Context, key, value []byte) error {
+	p.keys = append(p.keys, string(key))
+	p.values = append(p.values, value)
+	return p.err
+}
+
+func (p *fakeProducer) Close() error { return nil }
+
+func TestMultiFansOutAndJoinsErrors(t *testing.T) {
+	var buf bytes.Buffer
+	ok := &fakeProducer{}
+	failing := &fakeProducer{err: errors.New("broker down")}
+	kafkaOK, err := NewKafkaAuditor(ok)
+	require.NoError(t, err)
+	kafkaFailing, err := NewKafkaAuditor(failing)
+	require.NoError(t, err)
+	multi := Multi{kafkaFailing, NewWriterAuditor(&buf), kafkaOK}
+
+	err = multi.Record(context.Background(), Event{Operation: OpCreate, KeyID: "k1", Result: "OK"})
+	require.ErrorContains(t, err, "broker down")
+	require.Equal(t, []string{"k1"}, ok.keys)
+	require.Contains(t, buf.String(), `"keyId":"k1"`)
+	require.NoError(t, multi.Close())
+
+	_, err = NewKafkaAuditor(nil)
+	require.Error(t, err)
+}
+
+func TestTargetSlot(t *testing.T) {
+	RecordTarget(context.Background(), "ignored")
+	require.Empty(t, TargetFrom(context.Background()))
+
+	ctx := TrackTarget(context.Background())
+	RecordTarget(ctx, "enclave-1")
+	require.Equal(t, "enclave-1", TargetFrom(ctx))
+	require.Equal(t, "", DigestHash(nil))
+	require.Len(t, DigestHash([]byte{1}), 64)
+}
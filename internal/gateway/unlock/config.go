@@ -3,6 +3,18 @@ package unlock
 import (
 	"log/slog"
 	"time"
+
+	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultRetryHorizonMin = 200 * time.Millisecond
+	defaultRetryHorizonMax = 2 * time.Second
+
+	defaultStatusRetention  = 10 * time.Minute
+	defaultStatusMaxEntries = 10000
 )
 
 // Config 控制 Dispatcher 行为。
@@ -15,6 +27,30 @@ type Config struct {
 	BackoffMax  time.Duration
 	Logger      *slog.Logger
 	Metrics     *Metrics
+	// MetricsOptions 在 Metrics 为空时用于构造默认指标集合。
+	MetricsOptions metricsopts.Options
+	// Registerer 在 Metrics 为空时用于注册默认指标集合，默认 prometheus.DefaultRegisterer。
+	Registerer prometheus.Registerer
+	// ResultSink 可选，在任务成功或最终失败时各回传一次结果（通常为 keycache.Store）。
+	ResultSink keycache.ResultSink
+
+	// RetryHorizonMin/RetryHorizonMax 约束由 event.RefreshBudget 推导的单个任务总重试窗口，
+	// 默认 200ms / 2s；窗口耗尽后不再重试，直接按永久失败处理。
+	RetryHorizonMin time.Duration
+	RetryHorizonMax time.Duration
+
+	// WALPath 非空时启用队列 WAL：入队/完成事件追加写入该文件，重启后重放未完成的事件。
+	WALPath string
+	// WALSync 默认 WALSyncAlways。
+	WALSync         WALSyncPolicy
+	WALSyncInterval time.Duration
+	// WALMaxBytes 为日志压缩阈值，默认 64MiB。
+	WALMaxBytes int64
+
+	// StatusRetention 为已结束任务在 request 状态表中的保留时长，默认 10 分钟。
+	StatusRetention time.Duration
+	// StatusMaxEntries 限制保留的已结束 request 数，超出时淘汰最早结束的，默认 10000。
+	StatusMaxEntries int
 }
 
 func (c *Config) normalize() Config {
@@ -34,6 +70,30 @@ func (c *Config) normalize() Config {
 	if cfg.BackoffMax <= 0 {
 		cfg.BackoffMax = time.Second
 	}
+	if cfg.RetryHorizonMin <= 0 {
+		cfg.RetryHorizonMin = defaultRetryHorizonMin
+	}
+	if cfg.RetryHorizonMax <= 0 {
+		cfg.RetryHorizonMax = defaultRetryHorizonMax
+	}
+	if cfg.RetryHorizonMax < cfg.RetryHorizonMin {
+		cfg.RetryHorizonMax = cfg.RetryHorizonMin
+	}
+	if cfg.WALSync == "" {
+		cfg.WALSync = WALSyncAlways
+	}
+	if cfg.WALSyncInterval <= 0 {
+		cfg.WALSyncInterval = defaultWALSyncInterval
+	}
+	if cfg.WALMaxBytes <= 0 {
+		cfg.WALMaxBytes = defaultWALMaxBytes
+	}
+	if cfg.StatusRetention <= 0 {
+		cfg.StatusRetention = defaultStatusRetention
+	}
+	if cfg.StatusMaxEntries <= 0 {
+		cfg.StatusMaxEntries = defaultStatusMaxEntries
+	}
 	if cfg.Logger == nil {
 		cfg.Logger = slog.Default()
 	}
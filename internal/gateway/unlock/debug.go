@@ -3,36 +3,61 @@ package unlock
 import (
 	"encoding/json"
 	"net/http"
+	"sort"
 	"time"
 )
 
 // DebugHandler 返回 /debug/unlock 所需的 handler。
 func (d *Dispatcher) DebugHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		snapshot := d.snapshot()
+		snapshot := d.Snapshot()
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(snapshot)
 	})
 }
 
-type debugSnapshot struct {
-	QueueDepth int       `json:"queueDepth"`
-	InFlight   int       `json:"inFlight"`
-	Workers    int       `json:"workers"`
-	RateLimit  float64   `json:"rateLimit"`
-	Keys       []string  `json:"keys"`
-	Timestamp  time.Time `json:"timestamp"`
+// Snapshot 是 Dispatcher 的瞬时状态，Keys 与 Jobs 按 key 排序。
+type Snapshot struct {
+	QueueDepth int           `json:"queueDepth"`
+	InFlight   int           `json:"inFlight"`
+	Workers    int           `json:"workers"`
+	RateLimit  float64       `json:"rateLimit"`
+	Keys       []string      `json:"keys"`
+	Jobs       []JobSnapshot `json:"jobs"`
+	Timestamp  time.Time     `json:"timestamp"`
 }
 
-func (d *Dispatcher) snapshot() debugSnapshot {
-	snap := debugSnapshot{Workers: d.cfg.Workers, Timestamp: time.Now()}
+// JobSnapshot 描述单个任务的重试窗口，RemainingMs 为 0 表示窗口已耗尽。
+type JobSnapshot struct {
+	Key         string `json:"key"`
+	Attempts    int    `json:"attempts"`
+	HorizonMs   int64  `json:"horizonMs"`
+	RemainingMs int64  `json:"remainingMs"`
+}
+
+// Snapshot 返回当前队列与任务状态，只在复制 states 时短暂持有锁。
+func (d *Dispatcher) Snapshot() Snapshot {
+	snap := Snapshot{Workers: d.cfg.Workers, Timestamp: time.Now()}
 	d.mu.Lock()
 	snap.InFlight = len(d.states)
 	snap.Keys = make([]string, 0, len(d.states))
-	for key := range d.states {
+	snap.Jobs = make([]JobSnapshot, 0, len(d.states))
+	for key, state := range d.states {
 		snap.Keys = append(snap.Keys, key)
+		remaining := state.deadline().Sub(snap.Timestamp)
+		if remaining < 0 {
+			remaining = 0
+		}
+		snap.Jobs = append(snap.Jobs, JobSnapshot{
+			Key:         key,
+			Attempts:    state.attempts,
+			HorizonMs:   state.horizon.Milliseconds(),
+			RemainingMs: remaining.Milliseconds(),
+		})
 	}
 	d.mu.Unlock()
+	sort.Strings(snap.Keys)
+	sort.Slice(snap.Jobs, func(i, j int) bool { return snap.Jobs[i].Key < snap.Jobs[j].Key })
 	snap.QueueDepth = len(d.queue)
 	if limiter := d.limiter.Load(); limiter != nil {
 		snap.RateLimit = float64(limiter.Limit())
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"log/slog"
 	"math/rand"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/aegis-sign/wallet/internal/infra/logdedup"
 	"golang.org/x/time/rate"
 )
 
@@ -19,10 +21,15 @@ var (
 	ErrQueueFull = errors.New("unlock dispatcher queue full")
 	// ErrRateLimited 表示命中速率限制。
 	ErrRateLimited = errors.New("unlock dispatcher rate limited")
+	// ErrShuttingDown 表示 Dispatcher 正在关闭，不再接收新任务。
+	ErrShuttingDown = errors.New("unlock dispatcher shutting down")
 )
 
 const maxAttempts = 3
 
+// maxReportedKeys 限制 ShutdownReport 中列出的剩余 key 数量。
+const maxReportedKeys = 50
+
 // Executor 执行具体的解锁操作（KMS + Enclave 回写）。
 type Executor interface {
 	Execute(ctx context.Context, payload JobPayload) keycache.UnlockResult
@@ -42,17 +49,32 @@ type Dispatcher struct {
 
 	queue   chan *job
 	stopCh  chan struct{}
+	drainCh chan struct{}
 	metrics *Metrics
 
 	limiter atomic.Pointer[rate.Limiter]
 	logger  *slog.Logger
-
-	seq atomic.Uint64
-
-	mu     sync.Mutex
-	states map[string]*jobState
-
-	wg sync.WaitGroup
+	// logs 按 keyspace+reason 合并批量失效时成片出现的重试/失败日志。
+	logs *logdedup.Logger
+
+	seq         atomic.Uint64
+	latencyEWMA atomic.Int64
+	// executed/failed/retried 为累计成功、最终失败与重试的次数，Shutdown 用差值生成报告。
+	executed atomic.Uint64
+	failed   atomic.Uint64
+	retried  atomic.Uint64
+
+	// wal 的追加与 states 的变更都在 mu 内完成，保证日志顺序与内存状态一致。
+	mu           sync.Mutex
+	states       map[string]*jobState
+	requests     *requestRegistry
+	wal          *unlockWAL
+	timers       map[string]*time.Timer
+	shuttingDown bool
+
+	wg        sync.WaitGroup
+	workerWG  sync.WaitGroup
+	closeOnce sync.Once
 
 	randMu sync.Mutex
 	rnd    *rand.Rand
@@ -68,6 +90,18 @@ type job struct {
 type jobState struct {
 	job      *job
 	attempts int
+	// enqueuedAt 与 horizon 决定任务的重试截止时间。
+	enqueuedAt time.Time
+	horizon    time.Duration
+	// inFlight 为 true 表示正在执行，等待重试时为 false。
+	inFlight bool
+	// requestIDs 为合并到该任务的全部 request id，首个即 job.requestID。
+	requestIDs []string
+}
+
+// deadline 返回重试窗口的截止时间。
+func (s *jobState) deadline() time.Time {
+	return s.enqueuedAt.Add(s.horizon)
 }
 
 // NewDispatcher 创建并启动后台 worker。
@@ -81,24 +115,119 @@ func NewDispatcher(cfg Config, executor Executor) (*Dispatcher, error) {
 		executor: executor,
 		queue:    make(chan *job, normalized.MaxQueue),
 		stopCh:   make(chan struct{}),
+		drainCh:  make(chan struct{}),
 		metrics:  normalized.Metrics,
 		logger:   normalized.Logger,
+		logs:     logdedup.New(normalized.Logger, logdedup.Config{}),
 		states:   make(map[string]*jobState),
+		requests: newRequestRegistry(normalized.StatusRetention, normalized.StatusMaxEntries),
+		timers:   make(map[string]*time.Timer),
 		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 	if d.metrics == nil {
-		d.metrics = NewMetrics(nil)
+		metrics, err := NewMetricsWithOptions(normalized.Registerer, normalized.MetricsOptions)
+		if err != nil {
+			return nil, err
+		}
+		d.metrics = metrics
 	}
 	if normalized.RateLimit > 0 {
 		burst := normalized.RateBurst
 		limiter := rate.NewLimiter(rate.Limit(normalized.RateLimit), burst)
 		d.limiter.Store(limiter)
 	}
+	var replay []keycache.UnlockEvent
+	if normalized.WALPath != "" {
+		events, err := d.openWAL()
+		if err != nil {
+			d.logs.Close()
+			return nil, err
+		}
+		replay = events
+	}
 	d.start()
+	d.replay(replay)
 	return d, nil
 }
 
-// NotifyUnlock 实现 keycache.UnlockNotifier，将 key 放入队列。
+// openWAL 读取已有日志并压缩为只含未完成事件的新文件，再以追加模式打开。
+func (d *Dispatcher) openWAL() ([]keycache.UnlockEvent, error) {
+	events, skipped, err := replayWAL(d.cfg.WALPath)
+	if err != nil {
+		return nil, err
+	}
+	if skipped > 0 {
+		d.logger.Warn("unlock wal skipped corrupt records", slog.Int("records", skipped))
+	}
+	wal, err := openWAL(d.cfg.WALPath, d.cfg.WALSync, d.cfg.WALMaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := wal.compact(events); err != nil {
+		_ = wal.close()
+		return nil, err
+	}
+	d.wal = wal
+	if d.cfg.WALSync == WALSyncInterval {
+		d.wg.Add(1)
+		go d.walSyncLoop()
+	}
+	return events, nil
+}
+
+// replay 在接受新请求前把上次未完成的事件放回队列，绕过速率限制。
+func (d *Dispatcher) replay(events []keycache.UnlockEvent) {
+	if len(events) == 0 {
+		return
+	}
+	dropped := 0
+	for _, event := range events {
+		if err := d.enqueue(event, false); err != nil {
+			dropped++
+		}
+	}
+	d.logger.Info("unlock wal replayed", slog.Int("events", len(events)-dropped), slog.Int("dropped", dropped))
+}
+
+func (d *Dispatcher) walSyncLoop() {
+	defer d.wg.Done()
+	ticker := time.NewTicker(d.cfg.WALSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case <-ticker.C:
+			if err := d.wal.sync(); err != nil {
+				d.logger.Warn("unlock wal sync failed", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// persistLocked 追加一条 WAL 记录，超过阈值时用当前 states 压缩日志；调用方需持有 d.mu。
+// 写入失败只记录日志，不阻断解锁流程。
+func (d *Dispatcher) persistLocked(rec walRecord) {
+	if d.wal == nil {
+		return
+	}
+	if err := d.wal.append(rec); err != nil {
+		d.logger.Warn("unlock wal append failed", slog.String("key", rec.KeyID), slog.Any("error", err))
+		return
+	}
+	if !d.wal.oversized() {
+		return
+	}
+	live := make([]keycache.UnlockEvent, 0, len(d.states))
+	for _, state := range d.states {
+		live = append(live, state.job.event)
+	}
+	if err := d.wal.compact(live); err != nil {
+		d.logger.Warn("unlock wal compaction failed", slog.Any("error", err))
+	}
+}
+
+// NotifyUnlock 实现 keycache.Notifier，将 key 放入队列。
 func (d *Dispatcher) NotifyUnlock(ctx context.Context, event keycache.UnlockEvent) error {
 	if event.KeyID == "" {
 		return errors.New("key id is required for unlock")
@@ -109,15 +238,29 @@ func (d *Dispatcher) NotifyUnlock(ctx context.Context, event keycache.UnlockEven
 	if limiter := d.limiter.Load(); limiter != nil && !limiter.Allow() {
 		return ErrRateLimited
 	}
+	return d.enqueue(event, true)
+}
+
+// enqueue 按 key 去重后放入队列；persist 为 false 时不写 WAL（重放场景）。
+func (d *Dispatcher) enqueue(event keycache.UnlockEvent, persist bool) error {
 	d.mu.Lock()
+	if d.shuttingDown {
+		d.mu.Unlock()
+		return ErrShuttingDown
+	}
 	if state, ok := d.states[event.KeyID]; ok {
 		state.job.event.Reason = event.Reason
+		d.requests.attach(state, event.RequestID)
 		d.mu.Unlock()
 		return nil
 	}
 	job := &job{event: event, requestID: event.RequestID}
-	state := &jobState{job: job}
+	state := &jobState{job: job, enqueuedAt: time.Now(), horizon: d.retryHorizon(event.RefreshBudget)}
 	d.states[event.KeyID] = state
+	d.requests.attach(state, event.RequestID)
+	if persist {
+		d.persistLocked(enqueueRecord(event))
+	}
 	d.mu.Unlock()
 
 	select {
@@ -131,20 +274,94 @@ func (d *Dispatcher) NotifyUnlock(ctx context.Context, event keycache.UnlockEven
 	default:
 		d.mu.Lock()
 		delete(d.states, event.KeyID)
+		d.requests.detach(state)
+		d.persistLocked(walRecord{Op: walOpDone, KeyID: event.KeyID})
 		d.mu.Unlock()
 		return ErrQueueFull
 	}
 }
 
-// Ack 记录执行结果，供指标/Runbook 使用。
-func (d *Dispatcher) Ack(ctx context.Context, result keycache.UnlockResult) {
-	// 留作后续扩展（如回传到 key cache 或记录审计日志）。
+// complete 将终态结果回传给 ResultSink，每个任务只在成功或放弃重试时调用一次。
+func (d *Dispatcher) complete(result keycache.UnlockResult) {
+	if d.cfg.ResultSink != nil {
+		d.cfg.ResultSink.Ack(context.Background(), result)
+	}
 }
 
-// Close 停止 worker 并等待队列清空。
-func (d *Dispatcher) Close() {
+// ShutdownReport 汇总 Shutdown 期间的排空结果。
+type ShutdownReport struct {
+	// Executed 为排空期间成功完成的任务数。
+	Executed int
+	// Failed 为排空期间最终失败的任务数。
+	Failed int
+	// Remaining 为关闭时仍未完成的任务数（排队中或等待重试）。
+	Remaining int
+	// RemainingKeys 为未完成任务的 key（排序后最多 maxReportedKeys 个），供人工重新入队。
+	RemainingKeys []string
+}
+
+// Shutdown 停止接收新任务（NotifyUnlock 返回 ErrShuttingDown），取消重试定时器，
+// 让 worker 继续排空队列直到队列为空或 ctx 到期，然后停止并返回报告。
+// 启用 WAL 时未完成的事件保留在日志中，下次启动重放。只有首次调用会执行关闭并返回报告。
+func (d *Dispatcher) Shutdown(ctx context.Context) ShutdownReport {
+	var report ShutdownReport
+	d.closeOnce.Do(func() {
+		report = d.shutdown(ctx)
+	})
+	return report
+}
+
+func (d *Dispatcher) shutdown(ctx context.Context) ShutdownReport {
+	executed, failed := d.executed.Load(), d.failed.Load()
+	d.mu.Lock()
+	d.shuttingDown = true
+	for key, timer := range d.timers {
+		timer.Stop()
+		delete(d.timers, key)
+	}
+	d.mu.Unlock()
+
+	close(d.drainCh)
+	drained := make(chan struct{})
+	go func() {
+		d.workerWG.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+	}
+	// 到期后通知 worker 在当前任务结束后退出。
 	close(d.stopCh)
+	<-drained
 	d.wg.Wait()
+	d.logs.Close()
+
+	report := ShutdownReport{
+		Executed: int(d.executed.Load() - executed),
+		Failed:   int(d.failed.Load() - failed),
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	report.Remaining = len(d.states)
+	for key := range d.states {
+		report.RemainingKeys = append(report.RemainingKeys, key)
+	}
+	sort.Strings(report.RemainingKeys)
+	if len(report.RemainingKeys) > maxReportedKeys {
+		report.RemainingKeys = report.RemainingKeys[:maxReportedKeys]
+	}
+	if d.wal != nil {
+		if err := d.wal.close(); err != nil {
+			d.logger.Warn("unlock wal close failed", slog.Any("error", err))
+		}
+	}
+	return report
+}
+
+// Close 等价于不设期限的 Shutdown，可重复调用。
+func (d *Dispatcher) Close() {
+	d.Shutdown(context.Background())
 }
 
 // UpdateRateLimit 热更新速率限制。
@@ -159,17 +376,20 @@ func (d *Dispatcher) UpdateRateLimit(rateValue float64) {
 
 func (d *Dispatcher) start() {
 	for i := 0; i < d.cfg.Workers; i++ {
-		d.wg.Add(1)
+		d.workerWG.Add(1)
 		go d.workerLoop(i)
 	}
 }
 
 func (d *Dispatcher) workerLoop(id int) {
-	defer d.wg.Done()
+	defer d.workerWG.Done()
 	for {
 		select {
 		case <-d.stopCh:
 			return
+		case <-d.drainCh:
+			d.drain()
+			return
 		case job := <-d.queue:
 			if job == nil {
 				continue
@@ -179,77 +399,131 @@ func (d *Dispatcher) workerLoop(id int) {
 	}
 }
 
+// drain 在关闭阶段处理队列中剩余的任务，队列为空或 stopCh 关闭时返回。
+func (d *Dispatcher) drain() {
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		default:
+		}
+		select {
+		case job := <-d.queue:
+			if job != nil {
+				d.handleJob(job)
+			}
+		default:
+			return
+		}
+	}
+}
+
 func (d *Dispatcher) handleJob(job *job) {
-	state := d.markInFlight(job.event.KeyID)
+	state, event := d.markInFlight(job.event.KeyID)
 	if state == nil {
 		return
 	}
 	attempt := state.attempts
-	payload := JobPayload{Event: job.event, RequestID: job.requestID, Attempt: attempt}
+	payload := JobPayload{Event: event, RequestID: job.requestID, Attempt: attempt}
 	start := time.Now()
 	result := d.executor.Execute(context.Background(), payload)
 	if result.KeyID == "" {
-		result.KeyID = job.event.KeyID
+		result.KeyID = event.KeyID
 	}
 	if result.Keyspace == "" {
-		result.Keyspace = job.event.Keyspace
+		result.Keyspace = event.Keyspace
 	}
 	if result.Reason == "" {
-		result.Reason = job.event.Reason
+		result.Reason = event.Reason
 	}
 	if result.RequestID == "" {
 		result.RequestID = job.requestID
 	}
+	if result.Epoch == 0 {
+		result.Epoch = event.Epoch
+	}
 	result.Attempts = attempt
-	d.metrics.observeLatency(job.event.Keyspace, float64(time.Since(start).Milliseconds()))
+	elapsed := time.Since(start)
+	d.observeItemLatency(elapsed)
+	d.metrics.observeLatency(event.Keyspace, float64(elapsed.Milliseconds()))
 
 	if result.Success {
-		d.finishJob(job.event.KeyID)
-		d.Ack(context.Background(), result)
+		d.executed.Add(1)
+		d.finishJob(event.KeyID, result)
+		d.complete(result)
 		return
 	}
 
-	if attempt >= maxAttempts {
-		d.metrics.incFail(job.event.Keyspace, job.event.Reason)
-		d.finishJob(job.event.KeyID)
-		d.Ack(context.Background(), result)
-		if d.logger != nil {
-			d.logger.Warn("unlock failed permanently", slog.String("key", job.event.KeyID), slog.String("reason", job.event.Reason), slog.String("unlock_request_id", job.requestID))
+	delay := d.backoffDelay(state.horizon, attempt)
+	exhausted := attempt < maxAttempts && time.Now().Add(delay).After(state.deadline())
+	if attempt >= maxAttempts || exhausted {
+		d.metrics.incFail(event.Keyspace, event.Reason)
+		d.failed.Add(1)
+		if exhausted {
+			d.metrics.incHorizonExhausted(event.Keyspace)
 		}
+		d.finishJob(event.KeyID, result)
+		d.complete(result)
+		d.logs.Warn(event.Keyspace+"/"+event.Reason, "unlock failed permanently", slog.String("key", event.KeyID), slog.String("reason", event.Reason), slog.Int("attempts", attempt), slog.Bool("horizon_exhausted", exhausted), slog.String("unlock_request_id", job.requestID))
+		return
+	}
+
+	if !d.scheduleRetry(job, delay) {
+		// 关闭阶段不再重试，任务保留在 states 中计入剩余。
 		return
 	}
+	d.retried.Add(1)
+	d.metrics.incRetry(event.Keyspace, event.Reason)
+	d.logs.Info(event.Keyspace+"/"+event.Reason, "unlock retry scheduled", slog.String("key", event.KeyID), slog.Int("attempt", attempt+1), slog.Duration("delay", delay), slog.String("unlock_request_id", job.requestID))
+}
 
-	delay := d.backoffDelay(attempt)
-	d.metrics.incRetry(job.event.Keyspace, job.event.Reason)
-	if d.logger != nil {
-		d.logger.Info("unlock retry scheduled", slog.String("key", job.event.KeyID), slog.Int("attempt", attempt+1), slog.Duration("delay", delay), slog.String("unlock_request_id", job.requestID))
+// scheduleRetry 登记重试定时器，Shutdown 开始后返回 false。
+func (d *Dispatcher) scheduleRetry(job *job, delay time.Duration) bool {
+	key := job.event.KeyID
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if state := d.states[key]; state != nil {
+		state.inFlight = false
+	}
+	if d.shuttingDown {
+		return false
 	}
-	time.AfterFunc(delay, func() {
+	d.timers[key] = time.AfterFunc(delay, func() {
+		d.mu.Lock()
+		delete(d.timers, key)
+		d.mu.Unlock()
 		select {
 		case <-d.stopCh:
 			return
 		case d.queue <- job:
 		}
 	})
+	return true
 }
 
-func (d *Dispatcher) markInFlight(key string) *jobState {
+// markInFlight 标记任务开始执行，并在 d.mu 内返回事件副本：合并入队会改写 state.job.event.Reason，
+// worker 除不可变的 KeyID 外只读取该副本。
+func (d *Dispatcher) markInFlight(key string) (*jobState, keycache.UnlockEvent) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	state := d.states[key]
 	if state == nil {
-		return nil
+		return nil, keycache.UnlockEvent{}
 	}
 	state.attempts++
-	return state
+	state.inFlight = true
+	return state, state.job.event
 }
 
-func (d *Dispatcher) finishJob(key string) {
+// finishJob 移除任务并把终态登记到 request 状态表。
+func (d *Dispatcher) finishJob(key string, result keycache.UnlockResult) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	if _, ok := d.states[key]; ok {
+	if state, ok := d.states[key]; ok {
+		d.requests.finish(state, result, time.Now())
 		delete(d.states, key)
 		d.metrics.decQueueDepth()
+		d.persistLocked(walRecord{Op: walOpDone, KeyID: key})
 	}
 }
 
@@ -258,9 +532,27 @@ func (d *Dispatcher) nextRequestID(keyID string) string {
 	return fmt.Sprintf("unlock-%d-%s", seq, keyID)
 }
 
-func (d *Dispatcher) backoffDelay(attempt int) time.Duration {
-	base := d.cfg.BackoffBase
-	delay := base * time.Duration(1<<(attempt-1))
+// retryHorizon 将调用方给出的刷新预算限制在 [RetryHorizonMin, RetryHorizonMax]。
+func (d *Dispatcher) retryHorizon(budget time.Duration) time.Duration {
+	if budget < d.cfg.RetryHorizonMin {
+		return d.cfg.RetryHorizonMin
+	}
+	if budget > d.cfg.RetryHorizonMax {
+		return d.cfg.RetryHorizonMax
+	}
+	return budget
+}
+
+// backoffDelay 按指数权重（1,2,4…）把 horizon 分摊到各次重试，再限制在 [BackoffBase, BackoffMax]。
+func (d *Dispatcher) backoffDelay(horizon time.Duration, attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	weights := time.Duration(1<<(maxAttempts-1)) - 1
+	delay := horizon * time.Duration(1<<(attempt-1)) / weights
+	if delay < d.cfg.BackoffBase {
+		delay = d.cfg.BackoffBase
+	}
 	if delay > d.cfg.BackoffMax {
 		delay = d.cfg.BackoffMax
 	}
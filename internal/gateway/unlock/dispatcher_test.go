@@ -2,12 +2,16 @@ package unlock
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -44,6 +48,76 @@ func TestDispatcherRetriesUpToMaxAttempts(t *testing.T) {
 	require.Equal(t, int64(3), exec.CallCount())
 }
 
+func TestDispatcherTinyRefreshBudgetSkipsRetries(t *testing.T) {
+	exec := &stubExecutor{}
+	exec.failures.Store(10)
+	reg := newPromRegistry()
+	metrics := NewMetrics(reg)
+	d, err := NewDispatcher(Config{MaxQueue: 4, Workers: 1, BackoffBase: 20 * time.Millisecond, RetryHorizonMin: time.Millisecond, Metrics: metrics}, exec)
+	require.NoError(t, err)
+	t.Cleanup(d.Close)
+
+	evt := keycache.UnlockEvent{KeyID: "k-tiny", Keyspace: "prod", Reason: "tiny", RefreshBudget: 5 * time.Millisecond}
+	require.NoError(t, d.NotifyUnlock(context.Background(), evt))
+
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.horizonSpent.WithLabelValues("prod")) == 1
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, int64(1), exec.CallCount())
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.failTotal.WithLabelValues("prod", "tiny")))
+	require.Zero(t, testutil.ToFloat64(metrics.retryTotal.WithLabelValues("prod", "tiny")))
+	require.Empty(t, d.Snapshot().Jobs)
+}
+
+func TestDispatcherLargeRefreshBudgetUsesFullRetries(t *testing.T) {
+	exec := &stubExecutor{}
+	exec.failures.Store(2)
+	metrics := NewMetrics(newPromRegistry())
+	d, err := NewDispatcher(Config{
+		MaxQueue:        4,
+		Workers:         1,
+		BackoffBase:     time.Millisecond,
+		BackoffMax:      40 * time.Millisecond,
+		RetryHorizonMin: 10 * time.Millisecond,
+		RetryHorizonMax: 150 * time.Millisecond,
+		Metrics:         metrics,
+	}, exec)
+	require.NoError(t, err)
+	t.Cleanup(d.Close)
+
+	// 预算远超上限时按 RetryHorizonMax 截断。
+	require.Equal(t, 150*time.Millisecond, d.retryHorizon(time.Minute))
+	evt := keycache.UnlockEvent{KeyID: "k-large", Keyspace: "prod", Reason: "large", RefreshBudget: time.Minute}
+	require.NoError(t, d.NotifyUnlock(context.Background(), evt))
+
+	require.Eventually(t, func() bool {
+		return exec.CallCount() == 3
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics.retryTotal.WithLabelValues("prod", "large")))
+	require.Zero(t, testutil.ToFloat64(metrics.horizonSpent.WithLabelValues("prod")))
+}
+
+func TestDispatcherSnapshotShowsRetryHorizon(t *testing.T) {
+	exec := &blockingExecutor{started: make(chan string, 1), release: make(chan struct{})}
+	d, err := NewDispatcher(Config{MaxQueue: 4, Workers: 1, Metrics: NewMetrics(newPromRegistry())}, exec)
+	require.NoError(t, err)
+	t.Cleanup(d.Close)
+	t.Cleanup(func() { close(exec.release) })
+
+	evt := keycache.UnlockEvent{KeyID: "k-snap", Keyspace: "prod", Reason: "snap", RefreshBudget: 500 * time.Millisecond}
+	require.NoError(t, d.NotifyUnlock(context.Background(), evt))
+	<-exec.started
+
+	snap := d.Snapshot()
+	require.Len(t, snap.Jobs, 1)
+	job := snap.Jobs[0]
+	require.Equal(t, "k-snap", job.Key)
+	require.Equal(t, 1, job.Attempts)
+	require.Equal(t, int64(500), job.HorizonMs)
+	require.Greater(t, job.RemainingMs, int64(0))
+	require.LessOrEqual(t, job.RemainingMs, int64(500))
+}
+
 func TestDispatcherRateLimit(t *testing.T) {
 	exec := &stubExecutor{}
 	metrics := NewMetrics(newPromRegistry())
@@ -57,6 +131,141 @@ func TestDispatcherRateLimit(t *testing.T) {
 	require.ErrorIs(t, err, ErrRateLimited)
 }
 
+// gatedExecutor 每消耗一个 token 成功一次，release 关闭后其余调用均失败。
+type gatedExecutor struct {
+	tokens    chan struct{}
+	release   chan struct{}
+	successes atomic.Int64
+}
+
+func (g *gatedExecutor) Execute(ctx context.Context, payload JobPayload) keycache.UnlockResult {
+	select {
+	case <-g.tokens:
+		g.successes.Add(1)
+		return keycache.UnlockResult{Success: true}
+	case <-g.release:
+		return keycache.UnlockResult{Success: false}
+	}
+}
+
+func TestDispatcherShutdownReportsSpillOnDeadline(t *testing.T) {
+	exec := &gatedExecutor{tokens: make(chan struct{}, 10), release: make(chan struct{})}
+	d, err := NewDispatcher(Config{MaxQueue: 16, Workers: 1, BackoffBase: time.Millisecond, Metrics: NewMetrics(newPromRegistry())}, exec)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, d.NotifyUnlock(context.Background(), keycache.UnlockEvent{KeyID: fmt.Sprintf("k%02d", i), Keyspace: "prod", Reason: "drain"}))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan ShutdownReport, 1)
+	go func() { done <- d.Shutdown(ctx) }()
+	waitShuttingDown(t, d)
+
+	// 排空期间只够完成 3 个任务，第 4 个在期限到达后失败且不再重试。
+	for i := 0; i < 3; i++ {
+		exec.tokens <- struct{}{}
+	}
+	require.Eventually(t, func() bool { return exec.successes.Load() == 3 }, time.Second, time.Millisecond)
+	cancel()
+	close(exec.release)
+
+	report := <-done
+	require.Equal(t, 3, report.Executed)
+	require.Equal(t, 0, report.Failed)
+	require.Equal(t, 7, report.Remaining)
+	require.Equal(t, []string{"k03", "k04", "k05", "k06", "k07", "k08", "k09"}, report.RemainingKeys)
+	require.Equal(t, ShutdownReport{}, d.Shutdown(context.Background()))
+}
+
+func TestDispatcherShutdownDrainsQueue(t *testing.T) {
+	exec := &gatedExecutor{tokens: make(chan struct{}, 8), release: make(chan struct{})}
+	d, err := NewDispatcher(Config{MaxQueue: 16, Workers: 2, Metrics: NewMetrics(newPromRegistry())}, exec)
+	require.NoError(t, err)
+	for i := 0; i < 8; i++ {
+		require.NoError(t, d.NotifyUnlock(context.Background(), keycache.UnlockEvent{KeyID: fmt.Sprintf("k%d", i)}))
+	}
+	done := make(chan ShutdownReport, 1)
+	go func() { done <- d.Shutdown(context.Background()) }()
+	waitShuttingDown(t, d)
+	for i := 0; i < 8; i++ {
+		exec.tokens <- struct{}{}
+	}
+	require.Equal(t, ShutdownReport{Executed: 8}, <-done)
+}
+
+// waitShuttingDown 等待 Shutdown 停止接收新任务。
+func waitShuttingDown(t *testing.T, d *Dispatcher) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return d.shuttingDown
+	}, time.Second, time.Millisecond)
+	require.ErrorIs(t, d.NotifyUnlock(context.Background(), keycache.UnlockEvent{KeyID: "late"}), ErrShuttingDown)
+}
+
+type recordingSink struct {
+	mu      sync.Mutex
+	results map[string][]keycache.UnlockResult
+}
+
+func (r *recordingSink) Ack(_ context.Context, result keycache.UnlockResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.results == nil {
+		r.results = make(map[string][]keycache.UnlockResult)
+	}
+	r.results[result.KeyID] = append(r.results[result.KeyID], result)
+}
+
+func (r *recordingSink) get(key string) []keycache.UnlockResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]keycache.UnlockResult(nil), r.results[key]...)
+}
+
+type funcExecutor func(payload JobPayload) keycache.UnlockResult
+
+func (f funcExecutor) Execute(_ context.Context, payload JobPayload) keycache.UnlockResult {
+	return f(payload)
+}
+
+func TestDispatcherResultSinkCalledOncePerTerminalJob(t *testing.T) {
+	sink := &recordingSink{}
+	// k-ok 首次失败后成功，k-fail 始终失败直到耗尽重试次数。
+	exec := funcExecutor(func(payload JobPayload) keycache.UnlockResult {
+		ok := payload.Event.KeyID == "k-ok" && payload.Attempt > 1
+		return keycache.UnlockResult{Success: ok}
+	})
+	d, err := NewDispatcher(Config{
+		MaxQueue:        4,
+		Workers:         2,
+		BackoffBase:     time.Millisecond,
+		BackoffMax:      2 * time.Millisecond,
+		RetryHorizonMin: time.Second,
+		Metrics:         NewMetrics(newPromRegistry()),
+		ResultSink:      sink,
+	}, exec)
+	require.NoError(t, err)
+	t.Cleanup(d.Close)
+
+	require.NoError(t, d.NotifyUnlock(context.Background(), keycache.UnlockEvent{KeyID: "k-ok", Keyspace: "prod", Reason: "sink", Epoch: 7}))
+	require.NoError(t, d.NotifyUnlock(context.Background(), keycache.UnlockEvent{KeyID: "k-fail", Keyspace: "prod", Reason: "sink"}))
+	require.Eventually(t, func() bool {
+		return len(sink.get("k-ok")) > 0 && len(sink.get("k-fail")) > 0
+	}, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool { return len(d.Snapshot().Jobs) == 0 }, time.Second, time.Millisecond)
+
+	okResults, failResults := sink.get("k-ok"), sink.get("k-fail")
+	require.Len(t, okResults, 1)
+	require.True(t, okResults[0].Success)
+	require.Equal(t, 2, okResults[0].Attempts)
+	require.Equal(t, uint64(7), okResults[0].Epoch)
+	require.Len(t, failResults, 1)
+	require.False(t, failResults[0].Success)
+	require.Equal(t, maxAttempts, failResults[0].Attempts)
+}
+
 type stubExecutor struct {
 	count    atomic.Int64
 	failures atomic.Int64
@@ -75,6 +284,19 @@ func (s *stubExecutor) CallCount() int64 {
 	return s.count.Load()
 }
 
+func TestMetricsWithOptionsPrefixesBareNames(t *testing.T) {
+	reg := newPromRegistry()
+	_, err := NewMetricsWithOptions(reg, metricsopts.Options{})
+	require.NoError(t, err)
+	tenant, err := NewMetricsWithOptions(reg, metricsopts.Options{Namespace: "tenant_a", ConstLabels: prometheus.Labels{"service": "signer"}})
+	require.NoError(t, err)
+	tenant.incQueueDepth()
+	require.Equal(t, 1.0, testutil.ToFloat64(tenant.queueDepth))
+
+	_, err = NewMetricsWithOptions(reg, metricsopts.Options{})
+	require.ErrorContains(t, err, "unlock_queue_depth")
+}
+
 func newPromRegistry() *prometheus.Registry {
 	return prometheus.NewRegistry()
 }
@@ -27,6 +27,7 @@ func (n NoopExecutor) Execute(ctx context.Context, payload JobPayload) keycache.
 		KeyID:    payload.Event.KeyID,
 		Reason:   payload.Event.Reason,
 		Attempts: payload.Attempt,
+		Epoch:    payload.Event.Epoch,
 		Success:  true,
 	}
 }
@@ -31,6 +31,7 @@ func (e KMSEnclaveExecutor) Execute(ctx context.Context, payload JobPayload) key
 		KeyID:    payload.Event.KeyID,
 		Reason:   payload.Event.Reason,
 		Attempts: payload.Attempt,
+		Epoch:    payload.Event.Epoch,
 	}
 	if e.client == nil {
 		result.Err = errors.New("kms client not configured")
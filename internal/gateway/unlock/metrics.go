@@ -1,6 +1,9 @@
 package unlock
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
+	"github.com/prometheus/client_golang/prometheus"
+)
 
 // Metrics 记录异步解锁的关键指标。
 type Metrics struct {
@@ -9,38 +12,42 @@ type Metrics struct {
 	failTotal      *prometheus.CounterVec
 	latency        *prometheus.HistogramVec
 	retryTotal     *prometheus.CounterVec
+	horizonSpent   *prometheus.CounterVec
 }
 
 // NewMetrics 构造 Metrics，reg 为空则注册到默认注册器。
 func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m, err := NewMetricsWithOptions(reg, metricsopts.Options{})
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// NewMetricsWithOptions 按 opts 为指标加命名空间/子系统前缀与常量标签，零值保持 unlock_* 原名。
+func NewMetricsWithOptions(reg prometheus.Registerer, opts metricsopts.Options) (*Metrics, error) {
 	if reg == nil {
 		reg = prometheus.DefaultRegisterer
 	}
 	m := &Metrics{
-		queueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
-			Name: "unlock_queue_depth",
-			Help: "Number of keys pending unlock",
-		}),
-		backgroundRate: prometheus.NewCounterVec(prometheus.CounterOpts{
-			Name: "unlock_bg_rate",
-			Help: "Background unlock attempts started",
-		}, []string{"keyspace", "reason"}),
-		failTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
-			Name: "unlock_fail_total",
-			Help: "Number of unlock attempts failed",
-		}, []string{"keyspace", "reason"}),
-		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
-			Name:    "unlock_latency_ms",
-			Help:    "Latency of unlock attempts in milliseconds",
-			Buckets: []float64{10, 25, 50, 75, 100, 250, 500, 750, 1000, 2000},
-		}, []string{"keyspace"}),
-		retryTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
-			Name: "unlock_retry_total",
-			Help: "Number of unlock retries scheduled",
-		}, []string{"keyspace", "reason"}),
+		queueDepth: prometheus.NewGauge(opts.Gauge("unlock_queue_depth",
+			"Number of keys pending unlock")),
+		backgroundRate: prometheus.NewCounterVec(opts.Counter("unlock_bg_rate",
+			"Background unlock attempts started"), []string{"keyspace", "reason"}),
+		failTotal: prometheus.NewCounterVec(opts.Counter("unlock_fail_total",
+			"Number of unlock attempts failed"), []string{"keyspace", "reason"}),
+		latency: prometheus.NewHistogramVec(opts.Histogram("unlock_latency_ms",
+			"Latency of unlock attempts in milliseconds",
+			[]float64{10, 25, 50, 75, 100, 250, 500, 750, 1000, 2000}), []string{"keyspace"}),
+		retryTotal: prometheus.NewCounterVec(opts.Counter("unlock_retry_total",
+			"Number of unlock retries scheduled"), []string{"keyspace", "reason"}),
+		horizonSpent: prometheus.NewCounterVec(opts.Counter("unlock_retry_horizon_exhausted_total",
+			"Number of unlock jobs given up because their retry horizon was spent"), []string{"keyspace"}),
 	}
-	reg.MustRegister(m.queueDepth, m.backgroundRate, m.failTotal, m.latency, m.retryTotal)
-	return m
+	if err := metricsopts.Register(reg, m.queueDepth, m.backgroundRate, m.failTotal, m.latency, m.retryTotal, m.horizonSpent); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
 func (m *Metrics) incQueueDepth() {
@@ -85,6 +92,13 @@ func (m *Metrics) incRetry(keyspace, reason string) {
 	m.retryTotal.WithLabelValues(labelOrUnknown(keyspace), labelOrUnknown(reason)).Inc()
 }
 
+func (m *Metrics) incHorizonExhausted(keyspace string) {
+	if m == nil {
+		return
+	}
+	m.horizonSpent.WithLabelValues(labelOrUnknown(keyspace)).Inc()
+}
+
 func labelOrUnknown(value string) string {
 	if value == "" {
 		return "unknown"
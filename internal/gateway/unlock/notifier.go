@@ -6,13 +6,13 @@ import (
 	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
 )
 
-// DispatcherNotifier 将 keycache 的 UnlockNotifier 接口映射到 Dispatcher。
+// DispatcherNotifier 将 keycache.Notifier 映射到 Dispatcher，dispatcher 为空时忽略通知。
 type DispatcherNotifier struct {
 	dispatcher *Dispatcher
 }
 
-// NewDispatcherNotifier 构造基于 Dispatcher 的 UnlockNotifier 实现。
-func NewDispatcherNotifier(dispatcher *Dispatcher) keycache.UnlockNotifier {
+// NewDispatcherNotifier 构造基于 Dispatcher 的 Notifier 实现。
+func NewDispatcherNotifier(dispatcher *Dispatcher) keycache.Notifier {
 	return DispatcherNotifier{dispatcher: dispatcher}
 }
 
@@ -23,6 +23,3 @@ func (d DispatcherNotifier) NotifyUnlock(ctx context.Context, event keycache.Unl
 	}
 	return d.dispatcher.NotifyUnlock(ctx, event)
 }
-
-// Ack 当前仅记录结果，dispatcher 内部会在任务完成时更新指标。
-func (d DispatcherNotifier) Ack(context.Context, keycache.UnlockResult) {}
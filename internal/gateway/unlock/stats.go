@@ -0,0 +1,74 @@
+package unlock
+
+import "time"
+
+// latencyEWMAWeight 是执行耗时 EWMA 中新样本的权重。
+const latencyEWMAWeight = 0.2
+
+// QueueDepth 返回当前排队中的任务数。
+func (d *Dispatcher) QueueDepth() int {
+	return len(d.queue)
+}
+
+// QueueCapacity 返回队列容量（MaxQueue）。
+func (d *Dispatcher) QueueCapacity() int {
+	return cap(d.queue)
+}
+
+// Workers 返回 worker 数量。
+func (d *Dispatcher) Workers() int {
+	return d.cfg.Workers
+}
+
+// ItemLatency 返回单个解锁任务执行耗时的 EWMA，尚无样本时回退到 BackoffBase。
+func (d *Dispatcher) ItemLatency() time.Duration {
+	if v := d.latencyEWMA.Load(); v > 0 {
+		return time.Duration(v)
+	}
+	return d.cfg.BackoffBase
+}
+
+// RateLimitDelay 返回按当前速率限制再放入一个任务需要等待的时长，不消耗令牌。
+func (d *Dispatcher) RateLimitDelay() time.Duration {
+	limiter := d.limiter.Load()
+	if limiter == nil {
+		return 0
+	}
+	reservation := limiter.Reserve()
+	defer reservation.Cancel()
+	if !reservation.OK() {
+		return 0
+	}
+	return reservation.Delay()
+}
+
+// History 汇总启动以来的执行结果。
+type History struct {
+	Executed      uint64  `json:"executed"`
+	Failed        uint64  `json:"failed"`
+	Retried       uint64  `json:"retried"`
+	ItemLatencyMs float64 `json:"itemLatencyMs"`
+}
+
+// History 返回累计计数与执行耗时 EWMA，不加锁。
+func (d *Dispatcher) History() History {
+	return History{
+		Executed:      d.executed.Load(),
+		Failed:        d.failed.Load(),
+		Retried:       d.retried.Load(),
+		ItemLatencyMs: float64(time.Duration(d.latencyEWMA.Load())) / float64(time.Millisecond),
+	}
+}
+
+func (d *Dispatcher) observeItemLatency(dur time.Duration) {
+	for {
+		prev := d.latencyEWMA.Load()
+		next := int64(dur)
+		if prev > 0 {
+			next = int64(float64(prev)*(1-latencyEWMAWeight) + float64(dur)*latencyEWMAWeight)
+		}
+		if d.latencyEWMA.CompareAndSwap(prev, next) {
+			return
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package unlock
+
+import (
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+)
+
+// JobStatus 是解锁任务所处的阶段。
+type JobStatus string
+
+const (
+	// JobQueued 表示任务在队列中或等待重试。
+	JobQueued JobStatus = "queued"
+	// JobInFlight 表示任务正在执行。
+	JobInFlight JobStatus = "in_flight"
+	// JobSucceeded 表示任务已成功。
+	JobSucceeded JobStatus = "succeeded"
+	// JobFailed 表示任务重试耗尽后最终失败。
+	JobFailed JobStatus = "failed"
+)
+
+// RequestStatus 描述某个 request id 对应的解锁任务。
+type RequestStatus struct {
+	RequestID string    `json:"requestId"`
+	KeyID     string    `json:"keyId"`
+	Status    JobStatus `json:"status"`
+	Attempts  int       `json:"attempts"`
+	// JobRequestID 为实际执行的任务 ID；同一 key 的请求合并到已有任务时与 RequestID 不同。
+	JobRequestID string    `json:"jobRequestId"`
+	EnqueuedAt   time.Time `json:"enqueuedAt"`
+	// FinishedAt 与 Error 仅在任务结束后给出。
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// RequestStatus 按 request id 查询解锁任务；从未入队或已超过保留期时返回 false。
+func (d *Dispatcher) RequestStatus(requestID string) (RequestStatus, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if key, ok := d.requests.active[requestID]; ok {
+		if state := d.states[key]; state != nil {
+			status := JobQueued
+			if state.inFlight {
+				status = JobInFlight
+			}
+			return RequestStatus{
+				RequestID:    requestID,
+				KeyID:        key,
+				Status:       status,
+				Attempts:     state.attempts,
+				JobRequestID: state.job.requestID,
+				EnqueuedAt:   state.enqueuedAt,
+			}, true
+		}
+	}
+	d.requests.prune(time.Now())
+	status, ok := d.requests.finished[requestID]
+	return status, ok
+}
+
+// requestRegistry 维护 request id 到任务的映射，所有方法在 Dispatcher.mu 内调用。
+// 进行中的任务经 active 指向 states；结束的任务按结束顺序保留 retention，最多 maxEntries 条。
+type requestRegistry struct {
+	retention  time.Duration
+	maxEntries int
+	active     map[string]string
+	finished   map[string]RequestStatus
+	order      []string
+}
+
+func newRequestRegistry(retention time.Duration, maxEntries int) *requestRegistry {
+	return &requestRegistry{
+		retention:  retention,
+		maxEntries: maxEntries,
+		active:     make(map[string]string),
+		finished:   make(map[string]RequestStatus),
+	}
+}
+
+// attach 把 requestID 登记到任务，合并到已有任务的请求同样可查。
+func (r *requestRegistry) attach(state *jobState, requestID string) {
+	if requestID == "" {
+		return
+	}
+	if _, ok := r.active[requestID]; ok {
+		return
+	}
+	r.active[requestID] = state.job.event.KeyID
+	state.requestIDs = append(state.requestIDs, requestID)
+}
+
+// detach 移除未能入队的任务，不留下终态。
+func (r *requestRegistry) detach(state *jobState) {
+	for _, id := range state.requestIDs {
+		delete(r.active, id)
+	}
+}
+
+// finish 将任务的全部 request id 转为终态。
+func (r *requestRegistry) finish(state *jobState, result keycache.UnlockResult, now time.Time) {
+	status := RequestStatus{
+		KeyID:        state.job.event.KeyID,
+		Status:       JobFailed,
+		Attempts:     state.attempts,
+		JobRequestID: state.job.requestID,
+		EnqueuedAt:   state.enqueuedAt,
+		FinishedAt:   &now,
+	}
+	if result.Success {
+		status.Status = JobSucceeded
+	} else if result.Err != nil {
+		status.Error = result.Err.Error()
+	}
+	r.prune(now)
+	for _, id := range state.requestIDs {
+		delete(r.active, id)
+		status.RequestID = id
+		if _, ok := r.finished[id]; !ok {
+			r.order = append(r.order, id)
+		}
+		r.finished[id] = status
+	}
+	for len(r.order) > r.maxEntries {
+		delete(r.finished, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+// prune 按结束顺序淘汰超过保留期的终态。
+func (r *requestRegistry) prune(now time.Time) {
+	cutoff := now.Add(-r.retention)
+	n := 0
+	for ; n < len(r.order); n++ {
+		status, ok := r.finished[r.order[n]]
+		if ok && status.FinishedAt.After(cutoff) {
+			break
+		}
+		delete(r.finished, r.order[n])
+	}
+	if n > 0 {
+		r.order = append(r.order[:0], r.order[n:]...)
+	}
+}
@@ -0,0 +1,85 @@
+package unlock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcherRequestStatusLifecycle(t *testing.T) {
+	release := make(chan struct{})
+	exec := funcExecutor(func(payload JobPayload) keycache.UnlockResult {
+		if payload.Event.KeyID == "k-fail" {
+			return keycache.UnlockResult{Err: errors.New("kms denied")}
+		}
+		<-release
+		return keycache.UnlockResult{Success: true}
+	})
+	d, err := NewDispatcher(Config{
+		MaxQueue:        4,
+		Workers:         2,
+		BackoffBase:     time.Millisecond,
+		BackoffMax:      2 * time.Millisecond,
+		RetryHorizonMin: time.Second,
+		Metrics:         NewMetrics(newPromRegistry()),
+	}, exec)
+	require.NoError(t, err)
+	t.Cleanup(d.Close)
+
+	status := func(id string) RequestStatus {
+		st, ok := d.RequestStatus(id)
+		require.True(t, ok, id)
+		return st
+	}
+	require.NoError(t, d.NotifyUnlock(context.Background(), keycache.UnlockEvent{KeyID: "k1", RequestID: "r1"}))
+	require.Eventually(t, func() bool { return status("r1").Status == JobInFlight }, time.Second, time.Millisecond)
+	require.Equal(t, 1, status("r1").Attempts)
+
+	// 合并到进行中任务的 request id 同样可查，并指向实际执行的任务。
+	require.NoError(t, d.NotifyUnlock(context.Background(), keycache.UnlockEvent{KeyID: "k1", RequestID: "r2"}))
+	merged := status("r2")
+	require.Equal(t, JobInFlight, merged.Status)
+	require.Equal(t, "r1", merged.JobRequestID)
+
+	close(release)
+	require.Eventually(t, func() bool { return status("r2").Status == JobSucceeded }, time.Second, time.Millisecond)
+	done := status("r1")
+	require.Equal(t, JobSucceeded, done.Status)
+	require.NotNil(t, done.FinishedAt)
+	require.Empty(t, done.Error)
+
+	require.NoError(t, d.NotifyUnlock(context.Background(), keycache.UnlockEvent{KeyID: "k-fail", RequestID: "r3"}))
+	require.Eventually(t, func() bool { return status("r3").Status == JobFailed }, time.Second, time.Millisecond)
+	failed := status("r3")
+	require.Equal(t, maxAttempts, failed.Attempts)
+	require.Equal(t, "kms denied", failed.Error)
+
+	_, ok := d.RequestStatus("unknown")
+	require.False(t, ok)
+}
+
+func TestRequestRegistryRetention(t *testing.T) {
+	r := newRequestRegistry(time.Minute, 2)
+	now := time.Unix(1000, 0)
+	finish := func(id string, at time.Time) {
+		state := &jobState{job: &job{event: keycache.UnlockEvent{KeyID: "k-" + id}, requestID: id}}
+		r.attach(state, id)
+		r.finish(state, keycache.UnlockResult{Success: true}, at)
+	}
+	finish("a", now)
+	finish("b", now.Add(time.Second))
+	finish("c", now.Add(2*time.Second))
+	// 超过 maxEntries 时淘汰最早结束的。
+	require.NotContains(t, r.finished, "a")
+	require.Contains(t, r.finished, "b")
+	require.Empty(t, r.active)
+
+	r.prune(now.Add(time.Minute + 1500*time.Millisecond))
+	require.NotContains(t, r.finished, "b")
+	require.Contains(t, r.finished, "c")
+	require.Equal(t, []string{"c"}, r.order)
+}
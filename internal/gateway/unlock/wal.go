@@ -0,0 +1,237 @@
+package unlock
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/aegis-sign/wallet/internal/infra/snapshot"
+)
+
+// WALSyncPolicy 控制 WAL 的 fsync 时机。
+type WALSyncPolicy string
+
+const (
+	// WALSyncAlways 每条记录写入后立即 fsync，进程或主机崩溃都不丢事件。
+	WALSyncAlways WALSyncPolicy = "always"
+	// WALSyncInterval 按 WALSyncInterval 周期 fsync，主机崩溃最多丢失一个周期的事件。
+	WALSyncInterval WALSyncPolicy = "interval"
+	// WALSyncNone 只依赖操作系统回写，仅保证进程崩溃不丢事件。
+	WALSyncNone WALSyncPolicy = "none"
+)
+
+const (
+	walOpEnqueue = "enq"
+	walOpDone    = "done"
+
+	defaultWALMaxBytes     = 64 << 20
+	defaultWALSyncInterval = time.Second
+)
+
+// walRecord 为 WAL 中的一行 JSON；done 记录作为墓碑抵消同 key 之前的 enq。
+type walRecord struct {
+	Op              string `json:"op"`
+	KeyID           string `json:"keyId"`
+	Keyspace        string `json:"keyspace,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+	RequestID       string `json:"requestId,omitempty"`
+	RefreshBudgetMs int64  `json:"refreshBudgetMs,omitempty"`
+	Epoch           uint64 `json:"epoch,omitempty"`
+}
+
+func enqueueRecord(event keycache.UnlockEvent) walRecord {
+	return walRecord{
+		Op:              walOpEnqueue,
+		KeyID:           event.KeyID,
+		Keyspace:        event.Keyspace,
+		Reason:          event.Reason,
+		RequestID:       event.RequestID,
+		RefreshBudgetMs: event.RefreshBudget.Milliseconds(),
+		Epoch:           event.Epoch,
+	}
+}
+
+func (r walRecord) event() keycache.UnlockEvent {
+	return keycache.UnlockEvent{
+		KeyID:         r.KeyID,
+		Keyspace:      r.Keyspace,
+		Reason:        r.Reason,
+		RequestID:     r.RequestID,
+		RefreshBudget: time.Duration(r.RefreshBudgetMs) * time.Millisecond,
+		Epoch:         r.Epoch,
+	}
+}
+
+// unlockWAL 是解锁队列的追加写日志，调用方负责串行化 append 与 compact 的顺序。
+type unlockWAL struct {
+	path     string
+	policy   WALSyncPolicy
+	maxBytes int64
+
+	mu    sync.Mutex
+	file  *os.File
+	buf   *bufio.Writer
+	size  int64
+	dirty bool
+}
+
+func openWAL(path string, policy WALSyncPolicy, maxBytes int64) (*unlockWAL, error) {
+	if maxBytes <= 0 {
+		maxBytes = defaultWALMaxBytes
+	}
+	if policy == "" {
+		policy = WALSyncAlways
+	}
+	w := &unlockWAL{path: path, policy: policy, maxBytes: maxBytes}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("unlock wal: create dir: %w", err)
+	}
+	if err := w.reopen(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// replayWAL 读取 path 中尚未被墓碑抵消的事件，按首次入队顺序返回；
+// 崩溃时写了一半的行会被跳过。
+func replayWAL(path string) ([]keycache.UnlockEvent, int, error) {
+	var (
+		order   []string
+		live    = make(map[string]keycache.UnlockEvent)
+		skipped int
+	)
+	err := snapshot.Load(path, func(r io.Reader) error {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+		for scanner.Scan() {
+			var rec walRecord
+			if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil || rec.KeyID == "" {
+				skipped++
+				continue
+			}
+			switch rec.Op {
+			case walOpEnqueue:
+				if _, ok := live[rec.KeyID]; !ok {
+					order = append(order, rec.KeyID)
+				}
+				live[rec.KeyID] = rec.event()
+			case walOpDone:
+				delete(live, rec.KeyID)
+			default:
+				skipped++
+			}
+		}
+		return scanner.Err()
+	})
+	if errors.Is(err, snapshot.ErrNoSnapshot) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, skipped, fmt.Errorf("unlock wal: replay: %w", err)
+	}
+	events := make([]keycache.UnlockEvent, 0, len(live))
+	for _, key := range order {
+		if event, ok := live[key]; ok {
+			events = append(events, event)
+			delete(live, key)
+		}
+	}
+	return events, skipped, nil
+}
+
+func (w *unlockWAL) append(rec walRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.buf.Write(line); err != nil {
+		return fmt.Errorf("unlock wal: write: %w", err)
+	}
+	w.size += int64(len(line))
+	// 先刷到内核，进程崩溃时不丢记录；是否落盘由策略决定。
+	if err := w.buf.Flush(); err != nil {
+		return fmt.Errorf("unlock wal: flush: %w", err)
+	}
+	if w.policy == WALSyncAlways {
+		return w.file.Sync()
+	}
+	w.dirty = true
+	return nil
+}
+
+// oversized 表示日志超过上限，需要压缩。
+func (w *unlockWAL) oversized() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.size > w.maxBytes
+}
+
+// compact 用仅包含 live 事件的新文件原子替换旧日志。
+func (w *unlockWAL) compact(live []keycache.UnlockEvent) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := snapshot.Save(w.path, func(out io.Writer) error {
+		enc := json.NewEncoder(out)
+		for _, event := range live {
+			if err := enc.Encode(enqueueRecord(event)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("unlock wal: compact: %w", err)
+	}
+	_ = w.file.Close()
+	return w.reopen()
+}
+
+// sync 在 interval 策略下把脏数据落盘。
+func (w *unlockWAL) sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.dirty {
+		return nil
+	}
+	w.dirty = false
+	return w.file.Sync()
+}
+
+func (w *unlockWAL) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	return w.file.Close()
+}
+
+func (w *unlockWAL) reopen() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("unlock wal: open: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("unlock wal: stat: %w", err)
+	}
+	w.file = f
+	w.buf = bufio.NewWriter(f)
+	w.size = info.Size()
+	w.dirty = false
+	return nil
+}
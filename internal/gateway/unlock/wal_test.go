@@ -0,0 +1,138 @@
+package unlock
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingExecutor 在 release 关闭前阻塞所有调用，用于模拟执行中途进程被杀。
+type blockingExecutor struct {
+	started chan string
+	release chan struct{}
+}
+
+func (b *blockingExecutor) Execute(ctx context.Context, payload JobPayload) keycache.UnlockResult {
+	b.started <- payload.Event.KeyID
+	<-b.release
+	return keycache.UnlockResult{KeyID: payload.Event.KeyID, Success: true}
+}
+
+type recordingExecutor struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (r *recordingExecutor) Execute(ctx context.Context, payload JobPayload) keycache.UnlockResult {
+	r.mu.Lock()
+	r.keys = append(r.keys, payload.Event.KeyID)
+	r.mu.Unlock()
+	return keycache.UnlockResult{KeyID: payload.Event.KeyID, Success: true}
+}
+
+func (r *recordingExecutor) Keys() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.keys...)
+}
+
+func TestDispatcherWALReplaysAfterCrash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unlock.wal")
+	blocked := &blockingExecutor{started: make(chan string, 8), release: make(chan struct{})}
+	first, err := NewDispatcher(Config{MaxQueue: 8, Workers: 1, Metrics: NewMetrics(newPromRegistry()), WALPath: path}, blocked)
+	require.NoError(t, err)
+	// 测试结束时再放行旧实例，模拟的"已崩溃"进程不会再参与重放。
+	t.Cleanup(func() {
+		close(blocked.release)
+		first.Close()
+	})
+
+	for _, key := range []string{"k1", "k2", "k3"} {
+		require.NoError(t, first.NotifyUnlock(context.Background(), keycache.UnlockEvent{KeyID: key, Keyspace: "prod", Reason: "crash", RefreshBudget: 3 * time.Millisecond}))
+	}
+	require.Equal(t, "k1", <-blocked.started)
+
+	exec := &recordingExecutor{}
+	second, err := NewDispatcher(Config{MaxQueue: 8, Workers: 1, Metrics: NewMetrics(newPromRegistry()), WALPath: path}, exec)
+	require.NoError(t, err)
+	t.Cleanup(second.Close)
+
+	require.Eventually(t, func() bool { return len(exec.Keys()) == 3 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, []string{"k1", "k2", "k3"}, exec.Keys())
+
+	// 重放的事件完成后写入墓碑，再次重启不会重复执行。
+	second.Close()
+	events, _, err := replayWAL(path)
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func TestDispatcherWALSkipsCompletedAndTornRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unlock.wal")
+	exec := &recordingExecutor{}
+	d, err := NewDispatcher(Config{MaxQueue: 8, Workers: 1, Metrics: NewMetrics(newPromRegistry()), WALPath: path}, exec)
+	require.NoError(t, err)
+	require.NoError(t, d.NotifyUnlock(context.Background(), keycache.UnlockEvent{KeyID: "done", Keyspace: "prod"}))
+	require.Eventually(t, func() bool { return len(exec.Keys()) == 1 }, time.Second, 5*time.Millisecond)
+	d.Close()
+
+	// 追加一条未完成的事件以及崩溃时写了一半的行。
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"op":"enq","keyId":"pending","keyspace":"prod"}` + "\n" + `{"op":"enq","keyI`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	events, skipped, err := replayWAL(path)
+	require.NoError(t, err)
+	require.Equal(t, 1, skipped)
+	require.Len(t, events, 1)
+	require.Equal(t, "pending", events[0].KeyID)
+
+	exec = &recordingExecutor{}
+	d, err = NewDispatcher(Config{MaxQueue: 8, Workers: 1, Metrics: NewMetrics(newPromRegistry()), WALPath: path}, exec)
+	require.NoError(t, err)
+	t.Cleanup(d.Close)
+	require.Eventually(t, func() bool { return len(exec.Keys()) == 1 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, []string{"pending"}, exec.Keys())
+}
+
+func TestDispatcherWALCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unlock.wal")
+	exec := &recordingExecutor{}
+	d, err := NewDispatcher(Config{
+		MaxQueue:    8,
+		Workers:     1,
+		Metrics:     NewMetrics(newPromRegistry()),
+		WALPath:     path,
+		WALSync:     WALSyncNone,
+		WALMaxBytes: 1024,
+	}, exec)
+	require.NoError(t, err)
+	t.Cleanup(d.Close)
+
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		require.NoError(t, d.NotifyUnlock(context.Background(), keycache.UnlockEvent{KeyID: key, Keyspace: "prod", Reason: "compaction"}))
+		require.Eventually(t, func() bool { return len(exec.Keys()) == i+1 }, time.Second, time.Millisecond)
+	}
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.LessOrEqual(t, info.Size(), int64(2048))
+}
+
+func TestWALRecordPreservesEpoch(t *testing.T) {
+	event := keycache.UnlockEvent{KeyID: "k1", Keyspace: "prod", Reason: "ttl", RefreshBudget: 5 * time.Millisecond, Epoch: 7}
+	require.Equal(t, event, enqueueRecord(event).event())
+
+	// 执行器原样回传 fencing token。
+	result := NewNoopExecutor(nil).Execute(context.Background(), JobPayload{Event: event})
+	require.Equal(t, uint64(7), result.Epoch)
+}
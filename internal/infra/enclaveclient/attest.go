@@ -0,0 +1,123 @@
+package enclaveclient
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultAttestationTimeout    = 2 * time.Second
+	defaultAttestationQuarantine = time.Minute
+	attestationNonceSize         = 32
+)
+
+// ErrTargetQuarantined 表示目标因 attestation 校验失败处于隔离期（errors.Is 匹配 ErrPoolDraining）。
+var ErrTargetQuarantined = fmt.Errorf("%w: enclave failed attestation", ErrPoolDraining)
+
+// AttestationVerifier 校验 Enclave 返回的 attestation 文档（签名链与 PCR 白名单），kms.Attestor 满足该接口。
+type AttestationVerifier interface {
+	Verify(document []byte) error
+}
+
+// NonceVerifier 为 AttestationVerifier 的可选扩展：校验文档携带的 nonce 与本次请求一致，拒绝重放的旧文档。
+type NonceVerifier interface {
+	VerifyNonce(document, nonce []byte) error
+}
+
+// AttestationConfig 控制新连接交付前的 attestation 握手（Verifier 为 nil 时关闭）。
+type AttestationConfig struct {
+	Verifier AttestationVerifier
+	// Timeout 为单次 GetAttestation 调用的超时，默认 2s。
+	Timeout time.Duration
+	// Quarantine 为校验失败后目标的隔离时长，期间不建连、Acquire 立即失败，默认 1m。
+	Quarantine time.Duration
+}
+
+// WithAttestation 要求每条新连接先通过 attestation 握手才放入池中；度量值不符的目标会被隔离。
+func WithAttestation(cfg AttestationConfig) Option {
+	return func(p *Pool) {
+		if cfg.Verifier == nil {
+			p.attestation = nil
+			return
+		}
+		if cfg.Timeout <= 0 {
+			cfg.Timeout = defaultAttestationTimeout
+		}
+		if cfg.Quarantine <= 0 {
+			cfg.Quarantine = defaultAttestationQuarantine
+		}
+		p.attestation = &cfg
+	}
+}
+
+// attestationError 表示 Enclave 的身份无法被证明（文档校验失败或未实现 GetAttestation），需隔离目标；
+// 网络抖动等传输错误不属于此类，按普通拨号失败处理。
+type attestationError struct{ err error }
+
+func (e *attestationError) Error() string { return "attestation rejected: " + e.err.Error() }
+func (e *attestationError) Unwrap() error { return e.err }
+
+// attest 以随机 nonce 请求 attestation 文档并校验。
+func (p *Pool) attest(ctx context.Context, conn *grpc.ClientConn) error {
+	cfg := p.attestation
+	nonce := make([]byte, attestationNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("attestation nonce: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	resp, err := signerv1.NewSignerServiceClient(conn).GetAttestation(ctx, &signerv1.AttestationRequest{Nonce: nonce})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return &attestationError{err: errors.New("enclave does not implement GetAttestation")}
+		}
+		return fmt.Errorf("get attestation: %w", err)
+	}
+	doc := resp.GetDocument()
+	if err := cfg.Verifier.Verify(doc); err != nil {
+		return &attestationError{err: err}
+	}
+	if nv, ok := cfg.Verifier.(NonceVerifier); ok {
+		if err := nv.VerifyNonce(doc, nonce); err != nil {
+			return &attestationError{err: err}
+		}
+	}
+	return nil
+}
+
+// quarantine 隔离目标：唤醒排队者使其立即失败，并记录原因供 Stats 展示。已建立的连接保留，隔离期间不会被借出。
+func (ep *enclavePool) quarantine(err error) {
+	until := time.Now().Add(ep.parent.attestation.Quarantine)
+	ep.mu.Lock()
+	ep.quarantinedUntil = until
+	ep.quarantineReason = err.Error()
+	for e := ep.waiters.Front(); e != nil; e = e.Next() {
+		w := e.Value.(*waiter)
+		w.elem = nil
+		w.conn <- nil
+	}
+	ep.waiters.Init()
+	ep.waitersChangedLocked()
+	ep.mu.Unlock()
+	ep.parent.metrics.incAttestationFailure(ep.target.ID)
+	ep.parent.logger.Error("enclave quarantined after attestation failure",
+		"enclave", ep.target.ID, "endpoint", ep.target.Endpoint, "until", until, "err", err)
+}
+
+func (ep *enclavePool) quarantinedLocked(now time.Time) bool {
+	return now.Before(ep.quarantinedUntil)
+}
+
+func (ep *enclavePool) quarantined() bool {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	return ep.quarantinedLocked(time.Now())
+}
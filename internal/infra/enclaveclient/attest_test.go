@@ -0,0 +1,116 @@
+package enclaveclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// attestingServer 返回 "<measurement>:<nonce>" 形式的文档，measurement 可在测试中切换。
+type attestingServer struct {
+	mockSignerServer
+	measurement *atomic.Value
+}
+
+func (s attestingServer) GetAttestation(_ context.Context, req *signerv1.AttestationRequest) (*signerv1.AttestationResponse, error) {
+	doc := append([]byte(s.measurement.Load().(string)+":"), req.GetNonce()...)
+	return &signerv1.AttestationResponse{Document: doc}, nil
+}
+
+// prefixVerifier 只接受以 "good:" 开头的文档，并校验其后的 nonce。
+type prefixVerifier struct{}
+
+func (prefixVerifier) Verify(doc []byte) error {
+	if !bytes.HasPrefix(doc, []byte("good:")) {
+		return errors.New("PCR0 is not in the allowlist")
+	}
+	return nil
+}
+
+func (prefixVerifier) VerifyNonce(doc, nonce []byte) error {
+	if !bytes.Equal(doc[len("good:"):], nonce) {
+		return errors.New("attestation nonce mismatch")
+	}
+	return nil
+}
+
+func newAttestedPool(t *testing.T, srv signerv1.SignerServiceServer) *Pool {
+	t.Helper()
+	lis := bufconn.Listen(bufSize)
+	gs := grpc.NewServer()
+	signerv1.RegisterSignerServiceServer(gs, srv)
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 2
+	cfg.AcquireTimeout = time.Second
+	pool, err := NewPool(cfg,
+		WithRegisterer(prometheus.NewRegistry()),
+		WithAttestation(AttestationConfig{Verifier: prefixVerifier{}, Quarantine: time.Hour}),
+		WithDialer(func(ctx context.Context, target Target, _ Config) (*grpc.ClientConn, error) {
+			return grpc.DialContext(ctx, target.Endpoint,
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			)
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	pool.RegisterTarget(Target{ID: "enc", Endpoint: "buf"})
+	return pool
+}
+
+func TestPoolAttestsNewConnections(t *testing.T) {
+	measurement := &atomic.Value{}
+	measurement.Store("good")
+	pool := newAttestedPool(t, attestingServer{measurement: measurement})
+	ctx := context.Background()
+
+	lease, err := pool.Acquire(ctx, "enc")
+	require.NoError(t, err)
+	resp, err := lease.Client().Sign(ctx, &signerv1.SignRequest{KeyId: "k1", Digest: make([]byte, 32)})
+	lease.Release(err)
+	require.NoError(t, err)
+	require.Equal(t, "sig", string(resp.GetSignature()))
+	require.True(t, pool.Routable("enc"))
+
+	// Enclave 被替换为度量值不符的镜像：新连接被拒绝，目标进入隔离。
+	measurement.Store("evil")
+	first, err := pool.Acquire(ctx, "enc")
+	require.NoError(t, err)
+	_, err = pool.Acquire(ctx, "enc")
+	require.ErrorIs(t, err, ErrTargetQuarantined)
+	require.ErrorIs(t, err, ErrPoolDraining)
+	first.Release(nil)
+	require.False(t, pool.Routable("enc"))
+	_, err = pool.Acquire(ctx, "enc")
+	require.ErrorIs(t, err, ErrTargetQuarantined)
+
+	st := pool.Stats()[0]
+	require.NotNil(t, st.QuarantinedUntil)
+	require.False(t, st.Available())
+	require.Contains(t, st.QuarantineReason, "PCR0")
+	require.Contains(t, st.Dials[len(st.Dials)-1].Error, "attestation rejected")
+	require.Equal(t, 1.0, testutil.ToFloat64(pool.metrics.attestFailures.WithLabelValues("enc")))
+}
+
+func TestPoolQuarantinesEnclaveWithoutAttestation(t *testing.T) {
+	pool := newAttestedPool(t, mockSignerServer{})
+	require.Eventually(t, func() bool { return !pool.Routable("enc") }, 2*time.Second, 5*time.Millisecond)
+	_, err := pool.Acquire(context.Background(), "enc")
+	require.ErrorIs(t, err, ErrTargetQuarantined)
+	require.Contains(t, pool.Stats()[0].QuarantineReason, "does not implement GetAttestation")
+	require.Zero(t, pool.Stats()[0].Open)
+}
@@ -0,0 +1,155 @@
+package enclaveclient
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultAutoscaleInterval = 5 * time.Second
+	defaultAutoscaleLatency  = time.Millisecond
+	defaultAutoscaleQuiet    = 2 * time.Minute
+	defaultAutoscaleStep     = 4
+	defaultAutoscaleWaiters  = 1
+)
+
+// AutoscaleConfig 控制 MaxConns 的自适应伸缩（默认关闭）。
+type AutoscaleConfig struct {
+	Enabled bool
+	// Interval 为评估周期，默认 5s。
+	Interval time.Duration
+	// Floor 与 Ceiling 为 MaxConns 的伸缩范围；Floor 为 0 时取启动时的 MaxConns，Ceiling 为 0 时取 4×Floor。
+	Floor   int
+	Ceiling int
+	// Step 为每次扩缩的连接数，默认 4。
+	Step int
+	// LatencyThreshold：最近 Acquire 等待 p95 达到该值时扩容，默认 1ms。
+	LatencyThreshold time.Duration
+	// WaiterThreshold：任一目标排队数达到该值时扩容，默认 1。
+	WaiterThreshold int
+	// QuietPeriod：持续无压力且借出连接数低于缩容后上限达到该时长后缩容一步，默认 2m。
+	QuietPeriod time.Duration
+	Logger      *slog.Logger
+}
+
+// Autoscaler 按 Acquire 等待延迟与排队数周期性调整连接池的 MaxConns。
+// 扩容立即生效，缩容须经过 QuietPeriod，避免在突发流量间隙反复抖动。
+type Autoscaler struct {
+	pool   *Pool
+	cfg    AutoscaleConfig
+	logger *slog.Logger
+
+	lastSamples uint64
+	quietSince  time.Time
+}
+
+// NewAutoscaler 构造伸缩控制器，未启用时返回 nil。
+func NewAutoscaler(pool *Pool, cfg AutoscaleConfig) *Autoscaler {
+	if !cfg.Enabled || pool == nil {
+		return nil
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultAutoscaleInterval
+	}
+	if cfg.Floor <= 0 {
+		cfg.Floor = pool.Config().MaxConns
+	}
+	if cfg.Ceiling <= 0 {
+		cfg.Ceiling = cfg.Floor * 4
+	}
+	if cfg.Ceiling < cfg.Floor {
+		cfg.Ceiling = cfg.Floor
+	}
+	if cfg.Step <= 0 {
+		cfg.Step = defaultAutoscaleStep
+	}
+	if cfg.LatencyThreshold <= 0 {
+		cfg.LatencyThreshold = defaultAutoscaleLatency
+	}
+	if cfg.WaiterThreshold <= 0 {
+		cfg.WaiterThreshold = defaultAutoscaleWaiters
+	}
+	if cfg.QuietPeriod <= 0 {
+		cfg.QuietPeriod = defaultAutoscaleQuiet
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	return &Autoscaler{pool: pool, cfg: cfg, logger: cfg.Logger, lastSamples: pool.acquireWaits.count()}
+}
+
+// Run 按 Interval 评估直到 ctx 结束。
+func (a *Autoscaler) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.evaluate(now)
+		}
+	}
+}
+
+// evaluate 执行一次伸缩判断。只有本周期内出现新的 Acquire 样本时才参考延迟，
+// 避免流量停止后残留在窗口中的旧样本阻止缩容。
+func (a *Autoscaler) evaluate(now time.Time) {
+	cfg := a.pool.Config()
+	var waiters, inUse int
+	for _, st := range a.pool.Stats() {
+		waiters = max(waiters, st.Waiters)
+		inUse = max(inUse, st.InUse)
+	}
+	samples := a.pool.acquireWaits.count()
+	fresh := samples != a.lastSamples
+	a.lastSamples = samples
+	var p95 time.Duration
+	if fresh {
+		p95 = a.pool.acquireWaits.quantile(0.95)
+	}
+
+	reason := ""
+	switch {
+	case waiters >= a.cfg.WaiterThreshold:
+		reason = "waiters"
+	case p95 >= a.cfg.LatencyThreshold:
+		reason = "latency"
+	}
+	if reason != "" {
+		a.quietSince = time.Time{}
+		if cfg.MaxConns < a.cfg.Ceiling {
+			a.resize(cfg, min(cfg.MaxConns+a.cfg.Step, a.cfg.Ceiling), reason, p95, waiters)
+		}
+		return
+	}
+	target := max(cfg.MaxConns-a.cfg.Step, a.cfg.Floor)
+	// 延迟降到阈值一半以下才算平静，留出回差。
+	if target >= cfg.MaxConns || inUse >= target || p95 >= a.cfg.LatencyThreshold/2 {
+		a.quietSince = time.Time{}
+		return
+	}
+	if a.quietSince.IsZero() {
+		a.quietSince = now
+		return
+	}
+	if now.Sub(a.quietSince) >= a.cfg.QuietPeriod {
+		a.resize(cfg, target, "quiet", p95, waiters)
+		// 每缩一步都重新计时。
+		a.quietSince = now
+	}
+}
+
+func (a *Autoscaler) resize(cfg Config, maxConns int, reason string, p95 time.Duration, waiters int) {
+	direction := "up"
+	if maxConns < cfg.MaxConns {
+		direction = "down"
+	}
+	a.logger.Info("enclave pool autoscaled",
+		"from", cfg.MaxConns, "to", maxConns, "reason", reason,
+		"acquire_p95_ms", float64(p95.Microseconds())/1000, "waiters", waiters)
+	a.pool.metrics.incAutoscale(direction)
+	cfg.MaxConns = maxConns
+	a.pool.UpdateConfig(cfg)
+}
@@ -0,0 +1,73 @@
+package enclaveclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoscalerGrowsOnWaitersAndShrinksWhenQuiet(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	cfg.AcquireTimeout = 2 * time.Second
+	pool := newReapPool(t, cfg)
+	require.Nil(t, NewAutoscaler(pool, AutoscaleConfig{}))
+	scaler := NewAutoscaler(pool, AutoscaleConfig{Enabled: true, Ceiling: 3, Step: 2, LatencyThreshold: time.Hour, QuietPeriod: time.Minute})
+
+	ctx := context.Background()
+	held, err := pool.Acquire(ctx, "reap")
+	require.NoError(t, err)
+	queued := make(chan *Lease, 1)
+	go func() {
+		lease, err := pool.Acquire(ctx, "reap")
+		if err != nil {
+			t.Error(err)
+		}
+		queued <- lease
+	}()
+	require.Eventually(t, func() bool { return pool.Stats()[0].Waiters == 1 }, time.Second, time.Millisecond)
+
+	// 扩容后排队者立即拿到新建的连接，不必等待归还。
+	now := time.Now()
+	scaler.evaluate(now)
+	require.Equal(t, 3, pool.Config().MaxConns)
+	second := <-queued
+	require.Equal(t, 1.0, testutil.ToFloat64(pool.metrics.autoscale.WithLabelValues("up")))
+	require.Equal(t, 3.0, testutil.ToFloat64(pool.metrics.maxConns))
+
+	// 已到上限时不再扩容；借出连接仍占满缩容后的上限时不算平静。
+	scaler.evaluate(now.Add(time.Second))
+	scaler.evaluate(now.Add(2 * time.Minute))
+	require.Equal(t, 3, pool.Config().MaxConns)
+
+	held.Release(nil)
+	second.Release(nil)
+	scaler.evaluate(now.Add(3 * time.Minute))
+	require.Equal(t, 3, pool.Config().MaxConns)
+	scaler.evaluate(now.Add(4 * time.Minute))
+	require.Equal(t, 1, pool.Config().MaxConns)
+	require.Equal(t, 1.0, testutil.ToFloat64(pool.metrics.autoscale.WithLabelValues("down")))
+}
+
+func TestAutoscalerGrowsOnFreshLatencyOnly(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 2
+	pool := newReapPool(t, cfg)
+	scaler := NewAutoscaler(pool, AutoscaleConfig{Enabled: true, Step: 2, LatencyThreshold: 5 * time.Millisecond})
+
+	pool.acquireWaits.add(20 * time.Millisecond)
+	now := time.Now()
+	scaler.evaluate(now)
+	require.Equal(t, 4, pool.Config().MaxConns)
+	// 没有新样本时窗口里的旧延迟不再触发扩容。
+	scaler.evaluate(now.Add(time.Second))
+	require.Equal(t, 4, pool.Config().MaxConns)
+	pool.acquireWaits.add(20 * time.Millisecond)
+	scaler.evaluate(now.Add(2 * time.Second))
+	require.Equal(t, 6, pool.Config().MaxConns)
+}
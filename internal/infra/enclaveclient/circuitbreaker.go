@@ -1,83 +1,185 @@
 package enclaveclient
 
 import (
+	"context"
 	"sync"
 	"time"
 )
 
-// breakerState 表示连接池当前健康状况。
-type breakerState string
+// BreakerState 表示单个目标的熔断状态，经 TargetStats.Breaker 与 breaker_state 指标对外暴露。
+type BreakerState string
 
 const (
-	stateHealthy  breakerState = "healthy"
-	stateDegraded breakerState = "degraded"
-	stateDraining breakerState = "draining"
+	// BreakerHealthy 为正常状态。
+	BreakerHealthy BreakerState = "healthy"
+	// BreakerDegraded 为熔断打开：连续失败达到阈值，不再接收新路由，冷却期满后进入 half_open。
+	BreakerDegraded BreakerState = "degraded"
+	// BreakerHalfOpen 为探测中：逐个发送探测 RPC，连续成功 Probes 次后恢复 healthy，任一失败重新打开。
+	BreakerHalfOpen BreakerState = "half_open"
+	// BreakerDraining 为已摘除，不再放行任何 Acquire。
+	BreakerDraining BreakerState = "draining"
 )
 
-// circuitBreaker 用于异常分级与摘除/恢复策略。
+const (
+	defaultBreakerThreshold = 3
+	defaultBreakerCooldown  = time.Second
+	defaultBreakerProbes    = 3
+	// breakerProbeInterval 为 half_open 阶段相邻两次探测的间隔上限（不超过 Cooldown）。
+	breakerProbeInterval = 100 * time.Millisecond
+)
+
+// BreakerConfig 控制目标级熔断，零值字段取默认值；修改后对新注册的目标生效。
+type BreakerConfig struct {
+	// Threshold 为触发熔断的连续失败次数（连接断开、健康检查失败），默认 3。
+	Threshold int
+	// Cooldown 为熔断打开到开始探测的等待时长，默认 1s。
+	Cooldown time.Duration
+	// Probes 为 half_open 阶段恢复所需的连续探测成功次数，默认 3。
+	Probes int
+}
+
+func (c BreakerConfig) withDefaults() BreakerConfig {
+	if c.Threshold <= 0 {
+		c.Threshold = defaultBreakerThreshold
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = defaultBreakerCooldown
+	}
+	if c.Probes <= 0 {
+		c.Probes = defaultBreakerProbes
+	}
+	return c
+}
+
+// circuitBreaker 用于异常分级与摘除/恢复策略。状态只由失败计数、探测结果与 Drain 驱动，
+// 读取状态（Allow/Routable/State）不会改变它。
 type circuitBreaker struct {
-	threshold int
-	cooldown  time.Duration
+	cfg BreakerConfig
+	// onChange 在状态变化后调用（不持锁），用于更新指标与日志。
+	onChange func(from, to BreakerState)
 
 	mu         sync.Mutex
-	state      breakerState
+	state      BreakerState
 	failures   int
+	probeOK    int
 	lastChange time.Time
 }
 
-func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+func newCircuitBreaker(cfg BreakerConfig, onChange func(from, to BreakerState)) *circuitBreaker {
 	return &circuitBreaker{
-		threshold:  threshold,
-		cooldown:   cooldown,
-		state:      stateHealthy,
+		cfg:        cfg.withDefaults(),
+		onChange:   onChange,
+		state:      BreakerHealthy,
 		lastChange: time.Now(),
 	}
 }
 
+// Allow 判断是否放行 Acquire：只有摘除的目标拒绝。熔断打开时仍放行粘在该目标上的请求，
+// 新请求由 Routable 引导到其他目标。
 func (cb *circuitBreaker) Allow() bool {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
-	if cb.state == stateDraining {
-		return false
-	}
-	if cb.state == stateDegraded && time.Since(cb.lastChange) > cb.cooldown {
-		cb.state = stateHealthy
-		cb.failures = 0
-		cb.lastChange = time.Now()
-	}
-	return true
+	return cb.state != BreakerDraining
 }
 
+// Routable 判断是否应主动把新请求路由到该目标：仅 healthy 时返回 true。
+func (cb *circuitBreaker) Routable() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state == BreakerHealthy
+}
+
+// Success 清零 healthy 状态下的连续失败计数；熔断打开后只能经探测恢复。
 func (cb *circuitBreaker) Success() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
-	cb.failures = 0
-	if cb.state != stateHealthy {
-		cb.state = stateHealthy
-		cb.lastChange = time.Now()
+	if cb.state == BreakerHealthy {
+		cb.failures = 0
 	}
 }
 
+// Failure 记录一次失败，tripped 表示本次由 healthy 进入熔断，调用方应启动探测。
+// half_open 期间的失败直接重新打开熔断。
 func (cb *circuitBreaker) Failure() (tripped bool) {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	from := cb.state
 	cb.failures++
-	if cb.failures >= cb.threshold && cb.state == stateHealthy {
-		cb.state = stateDegraded
-		cb.lastChange = time.Now()
-		return true
+	switch {
+	case cb.state == BreakerHealthy && cb.failures >= cb.cfg.Threshold:
+		cb.transitionLocked(BreakerDegraded)
+		tripped = true
+	case cb.state == BreakerHalfOpen:
+		cb.transitionLocked(BreakerDegraded)
 	}
-	return false
+	to := cb.state
+	cb.mu.Unlock()
+	cb.notify(from, to)
+	return tripped
 }
 
-func (cb *circuitBreaker) Drain() {
+// cooldownLeft 返回熔断打开后距可以探测的剩余时长。
+func (cb *circuitBreaker) cooldownLeft() time.Duration {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
-	cb.state = stateDraining
+	return max(cb.cfg.Cooldown-time.Since(cb.lastChange), 0)
+}
+
+// startProbing 在冷却期满后由 degraded 进入 half_open；状态已变化（恢复或摘除）或冷却未满时返回 false。
+func (cb *circuitBreaker) startProbing() bool {
+	cb.mu.Lock()
+	if cb.state != BreakerDegraded || time.Since(cb.lastChange) < cb.cfg.Cooldown {
+		cb.mu.Unlock()
+		return false
+	}
+	cb.transitionLocked(BreakerHalfOpen)
+	cb.mu.Unlock()
+	cb.notify(BreakerDegraded, BreakerHalfOpen)
+	return true
+}
+
+// probeResult 记录一次探测结果并返回之后的状态；不处于 half_open 时忽略结果。
+func (cb *circuitBreaker) probeResult(ok bool) BreakerState {
+	cb.mu.Lock()
+	from := cb.state
+	if from == BreakerHalfOpen {
+		switch {
+		case !ok:
+			cb.transitionLocked(BreakerDegraded)
+		case cb.probeOK+1 >= cb.cfg.Probes:
+			cb.transitionLocked(BreakerHealthy)
+		default:
+			cb.probeOK++
+		}
+	}
+	to := cb.state
+	cb.mu.Unlock()
+	cb.notify(from, to)
+	return to
+}
+
+func (cb *circuitBreaker) Drain() {
+	cb.mu.Lock()
+	from := cb.state
+	cb.transitionLocked(BreakerDraining)
+	cb.mu.Unlock()
+	cb.notify(from, BreakerDraining)
+}
+
+// transitionLocked 切换状态并重置计数，持锁调用。
+func (cb *circuitBreaker) transitionLocked(to BreakerState) {
+	cb.state = to
+	cb.failures = 0
+	cb.probeOK = 0
 	cb.lastChange = time.Now()
 }
 
-func (cb *circuitBreaker) State() breakerState {
+func (cb *circuitBreaker) notify(from, to BreakerState) {
+	if from != to && cb.onChange != nil {
+		cb.onChange(from, to)
+	}
+}
+
+func (cb *circuitBreaker) State() BreakerState {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 	return cb.state
@@ -88,3 +190,86 @@ func (cb *circuitBreaker) Timestamp() time.Time {
 	defer cb.mu.Unlock()
 	return cb.lastChange
 }
+
+// snapshot 返回状态、进入该状态的时间、healthy 下的连续失败数与 half_open 下已成功的探测数。
+func (cb *circuitBreaker) snapshot() (BreakerState, time.Time, int, int) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state, cb.lastChange, cb.failures, cb.probeOK
+}
+
+// breakerFailure 记录一次失败，熔断刚打开时启动探测协程；熔断打开到恢复期间只有一个探测协程。
+func (ep *enclavePool) breakerFailure() {
+	if ep.breaker.Failure() {
+		go ep.probeLoop()
+	}
+}
+
+func (ep *enclavePool) breakerChanged(from, to BreakerState) {
+	ep.parent.metrics.setBreakerState(ep.target.ID, to)
+	ep.parent.metrics.incBreakerTransition(ep.target.ID, to)
+	ep.parent.logger.Info("enclave circuit breaker changed", "enclave", ep.target.ID, "from", from, "to", to)
+	if to == BreakerDegraded {
+		ep.parent.emit(func(l EventListener) { l.OnBreakerTrip(BreakerEvent{EnclaveID: ep.target.ID, From: from}) })
+	}
+}
+
+// probeLoop 等待冷却期满后进入 half_open 并发送探测 RPC，探测失败则重新冷却；
+// 恢复 healthy、目标被摘除/移除或连接池关闭时退出。
+func (ep *enclavePool) probeLoop() {
+	ctx := ep.parent.ctx
+	for {
+		select {
+		case <-time.After(ep.breaker.cooldownLeft()):
+		case <-ctx.Done():
+			return
+		}
+		ep.mu.Lock()
+		closed := ep.closed
+		ep.mu.Unlock()
+		if closed {
+			return
+		}
+		if !ep.breaker.startProbing() {
+			if ep.breaker.State() == BreakerDegraded {
+				continue
+			}
+			return
+		}
+		if ep.runProbes(ctx) != BreakerDegraded {
+			return
+		}
+	}
+}
+
+// runProbes 新建一条不入池、不占名额的探测连接，按间隔发送健康检查 RPC 直到离开 half_open，返回最终状态。
+func (ep *enclavePool) runProbes(ctx context.Context) BreakerState {
+	cfg := ep.parent.Config()
+	ep.mu.Lock()
+	target := ep.target
+	ep.mu.Unlock()
+	dialCtx, cancel := context.WithTimeout(ctx, cfg.DialTimeout)
+	conn, err := ep.parent.dialer(dialCtx, target, cfg)
+	cancel()
+	if err != nil {
+		ep.parent.logs.Warn(target.ID, "enclave probe failed", "enclave", target.ID, "err", err)
+		return ep.breaker.probeResult(false)
+	}
+	defer conn.Close()
+	interval := min(breakerProbeInterval, ep.breaker.cfg.Cooldown)
+	for {
+		err := ep.checkHealth(ctx, conn, target, cfg)
+		if err != nil {
+			ep.parent.logs.Warn(target.ID, "enclave probe failed", "enclave", target.ID, "err", err)
+		}
+		state := ep.breaker.probeResult(err == nil)
+		if state != BreakerHalfOpen {
+			return state
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return state
+		}
+	}
+}
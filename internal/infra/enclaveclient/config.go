@@ -4,6 +4,8 @@ import (
 	"os"
 	"strconv"
 	"time"
+
+	"google.golang.org/grpc/credentials"
 )
 
 // Config 控制连接池的全局行为。
@@ -15,8 +17,34 @@ type Config struct {
 	KeepaliveTime       time.Duration
 	KeepaliveTimeout    time.Duration
 	HealthCheckInterval time.Duration
-	ServiceName         string
-	Backoff             BackoffConfig
+	// MaxIdleTime 为空闲连接的最长保留时间，超过后关闭 MinConns 以上的部分；0 表示不回收。
+	MaxIdleTime time.Duration
+	// MaxConnAge 为单条连接的最长寿命（带最多 10% 的提前抖动），到期后主动重建，
+	// 以便切换到重启后的 Enclave 服务端；0 表示不限制。
+	MaxConnAge time.Duration
+	// MaxLeaseHold 为租约的最长持有时间，超过仍未 Release 的租约被强制收回并关闭连接；0 表示不限制。
+	// LeaseDebug 为 true 时在借出时记录调用栈，收回时写入日志以定位遗漏 Release 的代码。
+	MaxLeaseHold time.Duration
+	LeaseDebug   bool
+	// MaxWaiters 为单个目标排队等待连接的 Acquire 上限，超出时立即拒绝；0 表示不限制。
+	MaxWaiters  int
+	ServiceName string
+	// HealthMethod 非空时健康检查改为以空请求调用该 unary 方法（完整路径，如 "/signer.v1.EnclaveService/Ping"），
+	// 返回 OK 即健康；为空时使用 grpc.health.v1 查询 ServiceName。WithHealthChecker 优先于此项。
+	HealthMethod string
+	Backoff      BackoffConfig
+	// Breaker 控制目标级熔断与 half_open 探测。
+	Breaker BreakerConfig
+	// TLS 为各目标默认的客户端 TLS，未启用时明文；Target.TLS 可逐目标覆盖。
+	TLS TLSConfig
+	// Credentials 非 nil 时优先于 TLS 决定传输凭证，用于接入 ALTS 等自定义凭证。
+	Credentials func(Target) (credentials.TransportCredentials, error)
+	// WarmStreams 为 true 时 Lease.Sign 复用每条连接上常驻的 SignStream，不再逐请求建流；
+	// 要求 Enclave 在响应中回显 sequence。
+	WarmStreams bool
+	// Prewarm 为 true 时新连接在入池前先发送一次健康检查 RPC，失败按拨号失败处理；
+	// 同时启用 WarmStreams 时还会预先建立常驻 SignStream，使首个签名不承担建连与 Enclave 冷启动的开销。
+	Prewarm bool
 }
 
 // BackoffConfig 决定断线重连指数退避参数。
@@ -42,6 +70,12 @@ func DefaultConfig() Config {
 			Max:     200 * time.Millisecond,
 			Jitter:  0.2,
 		},
+		Breaker: BreakerConfig{
+			Threshold: defaultBreakerThreshold,
+			Cooldown:  defaultBreakerCooldown,
+			Probes:    defaultBreakerProbes,
+		},
+		WarmStreams: true,
 	}
 }
 
@@ -78,15 +112,85 @@ func LoadConfigFromEnv() Config {
 	if j := readFloat("SIGN_CONN_POOL_RETRY_JITTER"); j >= 0 {
 		cfg.Backoff.Jitter = j
 	}
+	if d := readDuration("SIGN_CONN_POOL_MAX_IDLE_TIME"); d > 0 {
+		cfg.MaxIdleTime = d
+	}
+	if d := readDuration("SIGN_CONN_POOL_MAX_CONN_AGE"); d > 0 {
+		cfg.MaxConnAge = d
+	}
+	if d := readDuration("SIGN_CONN_POOL_MAX_LEASE_HOLD"); d > 0 {
+		cfg.MaxLeaseHold = d
+	}
+	cfg.LeaseDebug = readBool("SIGN_CONN_POOL_LEASE_DEBUG")
+	if v := readInt("SIGN_CONN_POOL_MAX_WAITERS"); v > 0 {
+		cfg.MaxWaiters = v
+	}
+	if v := readInt("SIGN_CONN_POOL_BREAKER_THRESHOLD"); v > 0 {
+		cfg.Breaker.Threshold = v
+	}
+	if d := readDuration("SIGN_CONN_POOL_BREAKER_COOLDOWN"); d > 0 {
+		cfg.Breaker.Cooldown = d
+	}
+	if v := readInt("SIGN_CONN_POOL_BREAKER_PROBES"); v > 0 {
+		cfg.Breaker.Probes = v
+	}
+	cfg.TLS = TLSConfig{
+		CAFile:     os.Getenv("SIGN_CONN_POOL_TLS_CA_FILE"),
+		CertFile:   os.Getenv("SIGN_CONN_POOL_TLS_CERT_FILE"),
+		KeyFile:    os.Getenv("SIGN_CONN_POOL_TLS_KEY_FILE"),
+		ServerName: os.Getenv("SIGN_CONN_POOL_TLS_SERVER_NAME"),
+		SPIFFEID:   os.Getenv("SIGN_CONN_POOL_TLS_SPIFFE_ID"),
+	}
+	if v, err := strconv.ParseBool(os.Getenv("SIGN_CONN_POOL_WARM_STREAMS")); err == nil {
+		cfg.WarmStreams = v
+	}
+	cfg.Prewarm = readBool("SIGN_CONN_POOL_PREWARM")
 	if service := os.Getenv("SIGN_CONN_POOL_SERVICE"); service != "" {
 		cfg.ServiceName = service
 	}
+	if method := os.Getenv("SIGN_CONN_POOL_HEALTH_METHOD"); method != "" {
+		cfg.HealthMethod = method
+	}
 	if cfg.MaxConns < cfg.MinConns {
 		cfg.MaxConns = cfg.MinConns
 	}
 	return cfg
 }
 
+// LoadAutoscaleConfigFromEnv 解析 SIGN_CONN_POOL_AUTOSCALE*，未设置的字段由 NewAutoscaler 取默认值。
+func LoadAutoscaleConfigFromEnv() AutoscaleConfig {
+	return AutoscaleConfig{
+		Enabled:          readBool("SIGN_CONN_POOL_AUTOSCALE"),
+		Interval:         readDuration("SIGN_CONN_POOL_AUTOSCALE_INTERVAL"),
+		Floor:            readInt("SIGN_CONN_POOL_AUTOSCALE_FLOOR"),
+		Ceiling:          readInt("SIGN_CONN_POOL_AUTOSCALE_CEILING"),
+		Step:             readInt("SIGN_CONN_POOL_AUTOSCALE_STEP"),
+		LatencyThreshold: readDuration("SIGN_CONN_POOL_AUTOSCALE_LATENCY"),
+		WaiterThreshold:  readInt("SIGN_CONN_POOL_AUTOSCALE_WAITERS"),
+		QuietPeriod:      readDuration("SIGN_CONN_POOL_AUTOSCALE_QUIET"),
+	}
+}
+
+// LoadOutlierConfigFromEnv 解析 SIGN_CONN_POOL_OUTLIER*，未设置的字段由 WithOutlierDetection 取默认值。
+func LoadOutlierConfigFromEnv() OutlierConfig {
+	return OutlierConfig{
+		Enabled:            readBool("SIGN_CONN_POOL_OUTLIER"),
+		Alpha:              readFloat("SIGN_CONN_POOL_OUTLIER_ALPHA"),
+		ErrorRate:          readFloat("SIGN_CONN_POOL_OUTLIER_ERROR_RATE"),
+		Latency:            readDuration("SIGN_CONN_POOL_OUTLIER_LATENCY"),
+		MinRequests:        readInt("SIGN_CONN_POOL_OUTLIER_MIN_REQUESTS"),
+		EjectionTime:       readDuration("SIGN_CONN_POOL_OUTLIER_EJECTION_TIME"),
+		MaxEjectionTime:    readDuration("SIGN_CONN_POOL_OUTLIER_MAX_EJECTION_TIME"),
+		MaxEjectionPercent: readInt("SIGN_CONN_POOL_OUTLIER_MAX_EJECTION_PERCENT"),
+		RampUp:             readDuration("SIGN_CONN_POOL_OUTLIER_RAMP_UP"),
+	}
+}
+
+func readBool(key string) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	return err == nil && v
+}
+
 func readInt(key string) int {
 	value := os.Getenv(key)
 	if value == "" {
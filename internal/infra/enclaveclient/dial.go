@@ -0,0 +1,130 @@
+package enclaveclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DialErrorKind 区分拨号失败的原因。
+type DialErrorKind string
+
+const (
+	// DialErrInvalidEndpoint 表示 endpoint 格式错误，重试无意义。
+	DialErrInvalidEndpoint DialErrorKind = "invalid_endpoint"
+	// DialErrNotReady 表示 Enclave 尚未启动或尚未监听端口（ENODEV/EAGAIN/ECONNRESET/ECONNREFUSED），稍后可恢复。
+	DialErrNotReady DialErrorKind = "not_ready"
+	// DialErrBadCID 表示 CID 不可达（EHOSTUNREACH/ENETUNREACH/EADDRNOTAVAIL），通常是配置错误。
+	DialErrBadCID DialErrorKind = "bad_cid"
+	// DialErrTimeout 表示拨号在截止时间内未完成。
+	DialErrTimeout DialErrorKind = "timeout"
+	// DialErrOther 为其余无法归类的错误。
+	DialErrOther DialErrorKind = "other"
+)
+
+const (
+	vsockRetryInitial = 10 * time.Millisecond
+	vsockRetryMax     = 100 * time.Millisecond
+)
+
+// DialError 为 vsock 拨号失败的结构化错误，可用 errors.As 取出 Kind 判断原因；
+// Attempts 为截止前的连接尝试次数，Err 为最后一次失败的原始错误。
+type DialError struct {
+	Kind     DialErrorKind
+	Endpoint string
+	Attempts int
+	Err      error
+}
+
+func (e *DialError) Error() string {
+	return fmt.Sprintf("dial vsock %s (%s, %d attempts): %v", e.Endpoint, e.Kind, e.Attempts, e.Err)
+}
+
+func (e *DialError) Unwrap() error { return e.Err }
+
+// DialErrorKindOf 返回 err 链中 DialError 的 Kind，没有时返回空串。
+func DialErrorKindOf(err error) DialErrorKind {
+	var de *DialError
+	if errors.As(err, &de) {
+		return de.Kind
+	}
+	return ""
+}
+
+// classifyDialError 按 errno 归类单次 vsock 连接失败。
+func classifyDialError(err error) DialErrorKind {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, syscall.ETIMEDOUT), os.IsTimeout(err):
+		return DialErrTimeout
+	case errors.Is(err, syscall.ENODEV), errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.EINTR),
+		errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED):
+		return DialErrNotReady
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH), errors.Is(err, syscall.EADDRNOTAVAIL):
+		return DialErrBadCID
+	default:
+		return DialErrOther
+	}
+}
+
+func parseVsockEndpoint(target string) (cid, port uint32, err error) {
+	parts := strings.Split(target, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid vsock endpoint: %s", target)
+	}
+	c, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid vsock cid: %w", err)
+	}
+	p, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid vsock port: %w", err)
+	}
+	return uint32(c), uint32(p), nil
+}
+
+// dialVsock 在 ctx 截止前连接 vsock 端点，Enclave 未就绪时按指数退避重试，其余错误立即返回。
+func dialVsock(ctx context.Context, target string) (net.Conn, error) {
+	cid, port, err := parseVsockEndpoint(target)
+	if err != nil {
+		return nil, &DialError{Kind: DialErrInvalidEndpoint, Endpoint: target, Err: err}
+	}
+	return retryVsockDial(ctx, target, func(ctx context.Context) (net.Conn, error) {
+		return dialVsockOnce(ctx, cid, port)
+	})
+}
+
+func retryVsockDial(ctx context.Context, target string, dial func(context.Context) (net.Conn, error)) (net.Conn, error) {
+	wait := vsockRetryInitial
+	var lastKind DialErrorKind
+	for attempts := 1; ; attempts++ {
+		conn, err := dial(ctx)
+		if err == nil {
+			return conn, nil
+		}
+		kind := classifyDialError(err)
+		// 截止时间到达前一直未就绪时仍报告 not_ready，便于区分"Enclave 未启动"与普通超时。
+		if kind == DialErrTimeout && lastKind == DialErrNotReady {
+			kind = DialErrNotReady
+		}
+		if kind != DialErrNotReady || ctx.Err() != nil {
+			return nil, &DialError{Kind: kind, Endpoint: target, Attempts: attempts, Err: err}
+		}
+		lastKind = kind
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &DialError{Kind: kind, Endpoint: target, Attempts: attempts, Err: err}
+		case <-timer.C:
+		}
+		if wait *= 2; wait > vsockRetryMax {
+			wait = vsockRetryMax
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package enclaveclient
+
+import (
+	"context"
+	"net"
+
+	"github.com/mdlayher/socket"
+	"github.com/mdlayher/vsock"
+	"golang.org/x/sys/unix"
+)
+
+// vsockConn 将 socket.Conn 适配为 net.Conn。
+type vsockConn struct {
+	*socket.Conn
+	local, remote *vsock.Addr
+}
+
+func (c *vsockConn) LocalAddr() net.Addr  { return c.local }
+func (c *vsockConn) RemoteAddr() net.Addr { return c.remote }
+
+// dialVsockOnce 发起一次非阻塞 connect(2)，ctx 截止或取消时中断并关闭 socket，不残留拨号协程。
+// vsock.Dial 不接受 context，因此直接使用其底层的 socket 包。
+func dialVsockOnce(ctx context.Context, cid, port uint32) (net.Conn, error) {
+	c, err := socket.Socket(unix.AF_VSOCK, unix.SOCK_STREAM, 0, "vsock", nil)
+	if err != nil {
+		return nil, err
+	}
+	sa := &unix.SockaddrVM{CID: cid, Port: port}
+	rsa, err := c.Connect(ctx, sa)
+	if err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	// getpeername(2) 在部分环境返回空地址，此时以目标地址代替。
+	remote, ok := rsa.(*unix.SockaddrVM)
+	if !ok {
+		remote = sa
+	}
+	lsa, err := c.Getsockname()
+	if err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	local, ok := lsa.(*unix.SockaddrVM)
+	if !ok {
+		local = &unix.SockaddrVM{}
+	}
+	return &vsockConn{
+		Conn:   c,
+		local:  &vsock.Addr{ContextID: local.CID, Port: local.Port},
+		remote: &vsock.Addr{ContextID: remote.CID, Port: remote.Port},
+	}, nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package enclaveclient
+
+import (
+	"context"
+	"net"
+
+	"github.com/mdlayher/vsock"
+)
+
+// dialVsockOnce 在非 Linux 平台上退回 vsock.Dial，后者只返回不支持错误。
+func dialVsockOnce(_ context.Context, cid, port uint32) (net.Conn, error) {
+	return vsock.Dial(cid, port, nil)
+}
@@ -0,0 +1,81 @@
+package enclaveclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialVsockRejectsInvalidEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"3", "x:8001", "3:port", "3:8001:1"} {
+		_, err := dialVsock(context.Background(), endpoint)
+		require.Equal(t, DialErrInvalidEndpoint, DialErrorKindOf(err), endpoint)
+	}
+}
+
+func TestClassifyDialError(t *testing.T) {
+	cases := map[error]DialErrorKind{
+		syscall.ENODEV:       DialErrNotReady,
+		syscall.EAGAIN:       DialErrNotReady,
+		syscall.ECONNRESET:   DialErrNotReady,
+		syscall.EHOSTUNREACH: DialErrBadCID,
+		syscall.ETIMEDOUT:    DialErrTimeout,
+		syscall.EPERM:        DialErrOther,
+	}
+	for errno, want := range cases {
+		err := fmt.Errorf("connect: %w", errno)
+		require.Equal(t, want, classifyDialError(err), errno.Error())
+	}
+	require.Equal(t, DialErrTimeout, classifyDialError(context.DeadlineExceeded))
+}
+
+func TestRetryVsockDialRetriesNotReady(t *testing.T) {
+	attempts := 0
+	server, client := net.Pipe()
+	defer server.Close()
+	conn, err := retryVsockDial(context.Background(), "3:8001", func(context.Context) (net.Conn, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, syscall.ENODEV
+		}
+		return client, nil
+	})
+	require.NoError(t, err)
+	require.Same(t, client, conn)
+	require.Equal(t, 3, attempts)
+}
+
+func TestRetryVsockDialStopsOnPermanentError(t *testing.T) {
+	attempts := 0
+	_, err := retryVsockDial(context.Background(), "9:8001", func(context.Context) (net.Conn, error) {
+		attempts++
+		return nil, syscall.EHOSTUNREACH
+	})
+	var de *DialError
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, DialErrBadCID, de.Kind)
+	require.Equal(t, 1, attempts)
+	require.ErrorIs(t, err, syscall.EHOSTUNREACH)
+}
+
+func TestRetryVsockDialReportsNotReadyAtDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := retryVsockDial(ctx, "3:8001", func(ctx context.Context) (net.Conn, error) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, syscall.ECONNRESET
+	})
+	var de *DialError
+	require.True(t, errors.As(err, &de))
+	// 一直未就绪直到截止时报告 not_ready 而不是 timeout。
+	require.Equal(t, DialErrNotReady, de.Kind)
+	require.Greater(t, de.Attempts, 1)
+}
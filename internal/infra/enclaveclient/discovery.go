@@ -0,0 +1,363 @@
+package enclaveclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultDiscoveryInterval = 30 * time.Second
+
+// ErrNoTargetsDiscovered 表示来源返回空集合；TargetWatcher 此时保留现有目标，避免误摘全部 Enclave。
+var ErrNoTargetsDiscovered = errors.New("no enclave targets discovered")
+
+// TargetSource 返回当前应注册的完整目标集合。
+type TargetSource interface {
+	Resolve(ctx context.Context) ([]Target, error)
+}
+
+// SRVResolver 抽象 SRV 查询，*net.Resolver 实现了该接口。
+type SRVResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// DNSSRVSource 通过 DNS SRV 记录发现目标，每条记录对应一个 Enclave，ID 与 Endpoint 均为 host:port。
+type DNSSRVSource struct {
+	// Name 为完整 SRV 名，如 _signer._tcp.enclaves.example.internal。
+	Name string
+	// Resolver 为空时使用 net.DefaultResolver。
+	Resolver SRVResolver
+}
+
+// Resolve 查询 SRV 记录。
+func (s DNSSRVSource) Resolve(ctx context.Context) ([]Target, error) {
+	resolver := s.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	_, records, err := resolver.LookupSRV(ctx, "", "", s.Name)
+	if err != nil {
+		return nil, fmt.Errorf("lookup srv %s: %w", s.Name, err)
+	}
+	targets := make([]Target, 0, len(records))
+	for _, rec := range records {
+		addr := net.JoinHostPort(strings.TrimSuffix(rec.Target, "."), strconv.Itoa(int(rec.Port)))
+		targets = append(targets, Target{ID: addr, Endpoint: addr})
+	}
+	return targets, nil
+}
+
+// FileSource 从 JSON 文件读取目标，每次 Resolve 重新读取，便于配置管理工具原地更新。
+// 文件格式：[{"id":"enclave-a","endpoint":"vsock://3:8001","metadata":{"zone":"a"}}]，
+// 可选的 "tls":{"caFile":...,"serverName":...,"spiffeId":...} 覆盖该目标的客户端 TLS。
+type FileSource struct {
+	Path string
+}
+
+type fileTarget struct {
+	ID       string            `json:"id"`
+	Endpoint string            `json:"endpoint"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	TLS      *TLSConfig        `json:"tls,omitempty"`
+}
+
+// Resolve 读取并解析目标文件。
+func (s FileSource) Resolve(context.Context) ([]Target, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []fileTarget
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", s.Path, err)
+	}
+	targets := make([]Target, 0, len(entries))
+	for _, e := range entries {
+		if strings.TrimSpace(e.ID) == "" || strings.TrimSpace(e.Endpoint) == "" {
+			return nil, fmt.Errorf("parse %s: target requires id and endpoint", s.Path)
+		}
+		targets = append(targets, Target{ID: strings.TrimSpace(e.ID), Endpoint: strings.TrimSpace(e.Endpoint), Metadata: e.Metadata, TLS: e.TLS})
+	}
+	return targets, nil
+}
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// KubernetesSource 读取 Kubernetes Endpoints 对象中就绪的地址。ID 为 Pod 名（缺失时为 IP），Endpoint 为 ip:port。
+type KubernetesSource struct {
+	// APIServer 为 API Server 基址，如 https://10.96.0.1:443。
+	APIServer string
+	Namespace string
+	Service   string
+	// PortName 选择 Endpoints 中的命名端口；为空时要求只有一个端口。
+	PortName string
+	// TokenPath 为 ServiceAccount token 文件，每次请求重新读取以适配 token 轮换；为空时不带认证头。
+	TokenPath string
+	Client    *http.Client
+}
+
+// NewInClusterKubernetesSource 使用 Pod 内 ServiceAccount 凭证构造 KubernetesSource，namespace 为空时读取 Pod 所在命名空间。
+func NewInClusterKubernetesSource(namespace, service, portName string) (*KubernetesSource, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("not running in a kubernetes cluster: KUBERNETES_SERVICE_HOST/PORT unset")
+	}
+	if namespace == "" {
+		data, err := os.ReadFile(serviceAccountDir + "/namespace")
+		if err != nil {
+			return nil, fmt.Errorf("read pod namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+	caPEM, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("read cluster ca: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, errors.New("cluster ca contains no certificates")
+	}
+	return &KubernetesSource{
+		APIServer: "https://" + net.JoinHostPort(host, port),
+		Namespace: namespace,
+		Service:   service,
+		PortName:  portName,
+		TokenPath: serviceAccountDir + "/token",
+		Client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}},
+		},
+	}, nil
+}
+
+type k8sEndpoints struct {
+	Subsets []struct {
+		Addresses []struct {
+			IP        string `json:"ip"`
+			TargetRef *struct {
+				Name string `json:"name"`
+			} `json:"targetRef"`
+		} `json:"addresses"`
+		Ports []struct {
+			Name string `json:"name"`
+			Port int    `json:"port"`
+		} `json:"ports"`
+	} `json:"subsets"`
+}
+
+// Resolve 读取 Endpoints 对象，未就绪的地址（notReadyAddresses）不参与路由。
+func (s *KubernetesSource) Resolve(ctx context.Context) ([]Target, error) {
+	u := strings.TrimSuffix(s.APIServer, "/") + "/api/v1/namespaces/" + url.PathEscape(s.Namespace) + "/endpoints/" + url.PathEscape(s.Service)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.TokenPath != "" {
+		token, err := os.ReadFile(s.TokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("read service account token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("get endpoints %s/%s: %s: %s", s.Namespace, s.Service, resp.Status, strings.TrimSpace(string(body)))
+	}
+	var eps k8sEndpoints
+	if err := json.NewDecoder(resp.Body).Decode(&eps); err != nil {
+		return nil, fmt.Errorf("decode endpoints %s/%s: %w", s.Namespace, s.Service, err)
+	}
+	var targets []Target
+	for _, subset := range eps.Subsets {
+		port := 0
+		for _, p := range subset.Ports {
+			if p.Name == s.PortName || (s.PortName == "" && len(subset.Ports) == 1) {
+				port = p.Port
+				break
+			}
+		}
+		if port == 0 {
+			return nil, fmt.Errorf("endpoints %s/%s: port %q not found", s.Namespace, s.Service, s.PortName)
+		}
+		for _, addr := range subset.Addresses {
+			id := addr.IP
+			if addr.TargetRef != nil && addr.TargetRef.Name != "" {
+				id = addr.TargetRef.Name
+			}
+			targets = append(targets, Target{ID: id, Endpoint: net.JoinHostPort(addr.IP, strconv.Itoa(port))})
+		}
+	}
+	return targets, nil
+}
+
+// WatcherConfig 配置 TargetWatcher。
+type WatcherConfig struct {
+	Source TargetSource
+	// Interval 为轮询间隔，默认 30s。
+	Interval time.Duration
+	Logger   *slog.Logger
+}
+
+// TargetWatcher 周期性地从 TargetSource 解析目标，并对连接池执行 RegisterTarget/RemoveTarget。
+// 只管理自己注册过的目标；运维手动摘除（Drain）的目标在 endpoint 不变时保持摘除状态。
+type TargetWatcher struct {
+	pool     *Pool
+	source   TargetSource
+	interval time.Duration
+	logger   *slog.Logger
+
+	// syncMu 串行化 Sync，保证订阅方按顺序收到变化。
+	syncMu      sync.Mutex
+	mu          sync.Mutex
+	current     map[string]Target
+	subscribers []func([]Target)
+}
+
+// NewTargetWatcher 构造 TargetWatcher。
+func NewTargetWatcher(pool *Pool, cfg WatcherConfig) (*TargetWatcher, error) {
+	if pool == nil {
+		return nil, errors.New("enclave pool is required")
+	}
+	if cfg.Source == nil {
+		return nil, errors.New("target source is required")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultDiscoveryInterval
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	return &TargetWatcher{
+		pool:     pool,
+		source:   cfg.Source,
+		interval: cfg.Interval,
+		logger:   cfg.Logger,
+		current:  make(map[string]Target),
+	}, nil
+}
+
+// Subscribe 注册目标集合变化后的回调，参数为按 ID 排序的当前目标；回调在连接池更新之后同步执行。
+func (w *TargetWatcher) Subscribe(fn func([]Target)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Targets 返回当前由 watcher 管理的目标（按 ID 排序）。
+func (w *TargetWatcher) Targets() []Target {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sortedLocked()
+}
+
+// Sync 解析一次目标并与连接池对齐。解析失败或结果为空时保留现有目标并返回错误。
+func (w *TargetWatcher) Sync(ctx context.Context) error {
+	w.syncMu.Lock()
+	defer w.syncMu.Unlock()
+	resolved, err := w.source.Resolve(ctx)
+	if err != nil {
+		return err
+	}
+	if len(resolved) == 0 {
+		return ErrNoTargetsDiscovered
+	}
+	next := make(map[string]Target, len(resolved))
+	for _, t := range resolved {
+		if _, dup := next[t.ID]; dup {
+			return fmt.Errorf("duplicate enclave target id %q", t.ID)
+		}
+		next[t.ID] = t
+	}
+
+	w.mu.Lock()
+	changed := false
+	for id, old := range w.current {
+		if _, ok := next[id]; !ok {
+			w.pool.RemoveTarget(id)
+			w.logger.Info("enclave target removed", "enclave_id", id, "endpoint", old.Endpoint)
+			changed = true
+		}
+	}
+	for id, t := range next {
+		old, ok := w.current[id]
+		if ok && sameTransport(old, t) {
+			continue
+		}
+		if ok {
+			// 已注册目标只更新 endpoint，不会重建已摘除或 TLS 已变化的连接；先移除再注册。
+			w.pool.RemoveTarget(id)
+		}
+		w.pool.RegisterTarget(t)
+		w.logger.Info("enclave target registered", "enclave_id", id, "endpoint", t.Endpoint)
+		changed = true
+	}
+	w.current = next
+	targets := w.sortedLocked()
+	subscribers := slices.Clone(w.subscribers)
+	w.mu.Unlock()
+
+	if changed {
+		for _, fn := range subscribers {
+			fn(targets)
+		}
+	}
+	return nil
+}
+
+// Run 按 Interval 轮询直至 ctx 结束，失败只记录日志。
+func (w *TargetWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.Sync(ctx); err != nil && ctx.Err() == nil {
+				w.logger.Warn("enclave target discovery failed", "error", err)
+			}
+		}
+	}
+}
+
+// sameTransport 报告两个目标的 endpoint 与 TLS 配置是否一致。
+func sameTransport(a, b Target) bool {
+	if a.Endpoint != b.Endpoint || (a.TLS == nil) != (b.TLS == nil) {
+		return false
+	}
+	return a.TLS == nil || *a.TLS == *b.TLS
+}
+
+func (w *TargetWatcher) sortedLocked() []Target {
+	out := make([]Target, 0, len(w.current))
+	for _, t := range w.current {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
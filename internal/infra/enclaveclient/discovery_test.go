@@ -0,0 +1,146 @@
+package enclaveclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type stubSource struct {
+	targets []Target
+	err     error
+}
+
+func (s *stubSource) Resolve(context.Context) ([]Target, error) { return s.targets, s.err }
+
+func newDiscoveryPool(t *testing.T) *Pool {
+	t.Helper()
+	srv, lis := setupBufConn(t)
+	t.Cleanup(srv.Stop)
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	pool, err := NewPool(cfg,
+		WithRegisterer(prometheus.NewRegistry()),
+		WithDialer(func(ctx context.Context, target Target, _ Config) (*grpc.ClientConn, error) {
+			return grpc.DialContext(ctx, target.Endpoint,
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			)
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	return pool
+}
+
+func poolTargetIDs(p *Pool) []string {
+	var ids []string
+	for _, st := range p.Stats() {
+		ids = append(ids, st.ID)
+	}
+	return ids
+}
+
+func TestTargetWatcherSync(t *testing.T) {
+	pool := newDiscoveryPool(t)
+	source := &stubSource{targets: []Target{{ID: "a", Endpoint: "buf-a"}, {ID: "b", Endpoint: "buf-b"}}}
+	watcher, err := NewTargetWatcher(pool, WatcherConfig{Source: source})
+	require.NoError(t, err)
+	var notified [][]Target
+	watcher.Subscribe(func(ts []Target) { notified = append(notified, ts) })
+
+	require.NoError(t, watcher.Sync(context.Background()))
+	require.Equal(t, []string{"a", "b"}, poolTargetIDs(pool))
+	require.Len(t, notified, 1)
+
+	// 运维摘除的目标在 endpoint 不变时保持摘除。
+	require.NoError(t, pool.Drain("a"))
+	require.NoError(t, watcher.Sync(context.Background()))
+	require.Len(t, notified, 1)
+	require.False(t, pool.Routable("a"))
+
+	source.targets = []Target{{ID: "a", Endpoint: "buf-a2"}, {ID: "c", Endpoint: "buf-c"}}
+	require.NoError(t, watcher.Sync(context.Background()))
+	require.Equal(t, []string{"a", "c"}, poolTargetIDs(pool))
+	require.True(t, pool.Routable("a"))
+	require.Equal(t, []Target{{ID: "a", Endpoint: "buf-a2"}, {ID: "c", Endpoint: "buf-c"}}, notified[1])
+
+	// 空结果与解析失败都保留现有目标。
+	source.targets = nil
+	require.ErrorIs(t, watcher.Sync(context.Background()), ErrNoTargetsDiscovered)
+	source.err = errors.New("dns timeout")
+	require.Error(t, watcher.Sync(context.Background()))
+	require.Equal(t, []string{"a", "c"}, poolTargetIDs(pool))
+	require.Len(t, notified, 2)
+}
+
+type stubSRVResolver []*net.SRV
+
+func (r stubSRVResolver) LookupSRV(context.Context, string, string, string) (string, []*net.SRV, error) {
+	return "", r, nil
+}
+
+func TestDNSSRVSource(t *testing.T) {
+	source := DNSSRVSource{Name: "_signer._tcp.enclaves", Resolver: stubSRVResolver{
+		{Target: "enclave-0.enclaves.", Port: 9443},
+		{Target: "enclave-1.enclaves.", Port: 9443},
+	}}
+	targets, err := source.Resolve(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Target{
+		{ID: "enclave-0.enclaves:9443", Endpoint: "enclave-0.enclaves:9443"},
+		{ID: "enclave-1.enclaves:9443", Endpoint: "enclave-1.enclaves:9443"},
+	}, targets)
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"id":"a","endpoint":"vsock://3:8001","metadata":{"zone":"z1"}},
+		{"id":"b","endpoint":"10.0.0.12:9443","tls":{"caFile":"/tls/ca.pem","spiffeId":"spiffe://prod/enclave"}}]`), 0o600))
+	targets, err := FileSource{Path: path}.Resolve(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Target{
+		{ID: "a", Endpoint: "vsock://3:8001", Metadata: map[string]string{"zone": "z1"}},
+		{ID: "b", Endpoint: "10.0.0.12:9443", TLS: &TLSConfig{CAFile: "/tls/ca.pem", SPIFFEID: "spiffe://prod/enclave"}},
+	}, targets)
+
+	require.NoError(t, os.WriteFile(path, []byte(`[{"id":"a"}]`), 0o600))
+	_, err = FileSource{Path: path}.Resolve(context.Background())
+	require.Error(t, err)
+}
+
+func TestKubernetesSource(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("sa-token\n"), 0o600))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/namespaces/signer/endpoints/enclaves", r.URL.Path)
+		require.Equal(t, "Bearer sa-token", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"subsets":[{
+			"addresses":[{"ip":"10.0.0.5","targetRef":{"name":"enclave-0"}},{"ip":"10.0.0.6"}],
+			"notReadyAddresses":[{"ip":"10.0.0.7"}],
+			"ports":[{"name":"metrics","port":9100},{"name":"grpc","port":9443}]}]}`))
+	}))
+	defer srv.Close()
+
+	source := &KubernetesSource{APIServer: srv.URL, Namespace: "signer", Service: "enclaves", PortName: "grpc", TokenPath: tokenPath}
+	targets, err := source.Resolve(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Target{
+		{ID: "enclave-0", Endpoint: "10.0.0.5:9443"},
+		{ID: "10.0.0.6", Endpoint: "10.0.0.6:9443"},
+	}, targets)
+
+	source.PortName = "missing"
+	_, err = source.Resolve(context.Background())
+	require.Error(t, err)
+}
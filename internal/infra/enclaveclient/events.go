@@ -0,0 +1,94 @@
+package enclaveclient
+
+import "time"
+
+// EventListener 接收连接池生命周期事件，供嵌入方发出告警或结构化日志而无需解析 slog 输出。
+// 回调在触发事件的协程中同步执行（可能并发），应快速返回，且不得调用 Drain/RemoveTarget 等会再次触发事件的方法。
+type EventListener interface {
+	// OnDial 在新连接拨号成功（含 attestation 与预热）后调用。
+	OnDial(DialEvent)
+	// OnDialError 在拨号、attestation 或预热失败后调用。
+	OnDialError(DialEvent)
+	// OnEject 在目标被离群检测摘除后调用。
+	OnEject(EjectEvent)
+	// OnDrain 在目标被 Drain 或 RemoveTarget 后调用，晚于 WithDrainHook 注册的回调。
+	OnDrain(enclaveID string)
+	// OnBreakerTrip 在目标熔断打开（进入 degraded）时调用，包括 half_open 探测失败后重新打开。
+	OnBreakerTrip(BreakerEvent)
+}
+
+// DialEvent 描述一次拨号结果。
+type DialEvent struct {
+	EnclaveID string
+	Endpoint  string
+	Duration  time.Duration
+	// Err 与 Kind 仅在 OnDialError 中非零。
+	Err  error
+	Kind DialErrorKind
+}
+
+// EjectEvent 描述一次离群摘除，ErrorRate 与 LatencyMs 为摘除时的 EWMA。
+type EjectEvent struct {
+	EnclaveID string
+	Reason    string
+	ErrorRate float64
+	LatencyMs float64
+	Until     time.Time
+}
+
+// BreakerEvent 描述一次熔断打开，From 为打开前的状态（healthy 或 half_open）。
+type BreakerEvent struct {
+	EnclaveID string
+	From      BreakerState
+}
+
+// EventListenerFuncs 以函数字段实现 EventListener，未设置的回调忽略对应事件。
+type EventListenerFuncs struct {
+	Dial        func(DialEvent)
+	DialError   func(DialEvent)
+	Eject       func(EjectEvent)
+	Drain       func(enclaveID string)
+	BreakerTrip func(BreakerEvent)
+}
+
+func (f EventListenerFuncs) OnDial(e DialEvent) {
+	if f.Dial != nil {
+		f.Dial(e)
+	}
+}
+
+func (f EventListenerFuncs) OnDialError(e DialEvent) {
+	if f.DialError != nil {
+		f.DialError(e)
+	}
+}
+
+func (f EventListenerFuncs) OnEject(e EjectEvent) {
+	if f.Eject != nil {
+		f.Eject(e)
+	}
+}
+
+func (f EventListenerFuncs) OnDrain(enclaveID string) {
+	if f.Drain != nil {
+		f.Drain(enclaveID)
+	}
+}
+
+func (f EventListenerFuncs) OnBreakerTrip(e BreakerEvent) {
+	if f.BreakerTrip != nil {
+		f.BreakerTrip(e)
+	}
+}
+
+// WithEventListener 注册连接池事件监听器，可多次调用，按注册顺序通知。
+func WithEventListener(l EventListener) Option {
+	return func(p *Pool) { p.listeners = append(p.listeners, l) }
+}
+
+// emit 依次通知全部监听器。
+func (p *Pool) emit(fn func(EventListener)) {
+	for _, l := range p.listeners {
+		fn(l)
+	}
+}
@@ -0,0 +1,69 @@
+package enclaveclient
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// HealthChecker 对一条连接执行一次健康检查，返回供 Stats 展示的状态文本；err 非 nil 即视为不健康。
+// 周期探测、熔断 half_open 探测与 Prewarm 共用同一检查器，ctx 已带 AcquireTimeout。
+type HealthChecker func(ctx context.Context, conn grpc.ClientConnInterface, target Target, cfg Config) (status string, err error)
+
+// WithHealthChecker 替换默认的健康检查，用于探测 Enclave 能否真正签名（attestation、密钥库就绪等），
+// 而不只是 gRPC 服务处于 SERVING。
+func WithHealthChecker(fn HealthChecker) Option {
+	return func(p *Pool) { p.healthCheck = fn }
+}
+
+// GRPCHealthChecker 调用 grpc.health.v1 Check 查询 cfg.ServiceName，SERVING 以外的状态视为不健康。
+func GRPCHealthChecker(ctx context.Context, conn grpc.ClientConnInterface, _ Target, cfg Config) (string, error) {
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: cfg.ServiceName})
+	if err != nil {
+		return "", err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return resp.GetStatus().String(), fmt.Errorf("health status %s", resp.GetStatus())
+	}
+	return resp.GetStatus().String(), nil
+}
+
+// RPCHealthChecker 以 req 调用领域健康 RPC method（完整路径，如 "/signer.v1.EnclaveService/Ping"），
+// 返回 OK 且 verify（可为 nil）接受响应即视为健康。newResp 每次返回新的响应消息，使并发探测互不干扰。
+func RPCHealthChecker(method string, req proto.Message, newResp func() proto.Message, verify func(proto.Message) error) HealthChecker {
+	return func(ctx context.Context, conn grpc.ClientConnInterface, _ Target, _ Config) (string, error) {
+		resp := newResp()
+		if err := conn.Invoke(ctx, method, req, resp); err != nil {
+			return "", err
+		}
+		if verify != nil {
+			if err := verify(resp); err != nil {
+				return codes.OK.String(), err
+			}
+		}
+		return codes.OK.String(), nil
+	}
+}
+
+// defaultHealthCheck 在 Config.HealthMethod 非空时以空请求调用该方法，否则使用 grpc.health.v1；
+// 逐次读取配置以支持热更新。
+func defaultHealthCheck(ctx context.Context, conn grpc.ClientConnInterface, target Target, cfg Config) (string, error) {
+	if cfg.HealthMethod == "" {
+		return GRPCHealthChecker(ctx, conn, target, cfg)
+	}
+	return RPCHealthChecker(cfg.HealthMethod, &emptypb.Empty{}, func() proto.Message { return &emptypb.Empty{} }, nil)(ctx, conn, target, cfg)
+}
+
+// checkHealth 以连接池的检查器探测 conn 一次，并记录结果供 Stats 展示。
+func (ep *enclavePool) checkHealth(ctx context.Context, conn grpc.ClientConnInterface, target Target, cfg Config) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.AcquireTimeout)
+	defer cancel()
+	status, err := ep.parent.healthCheck(ctx, conn, target, cfg)
+	ep.recordHealth(status, err)
+	return err
+}
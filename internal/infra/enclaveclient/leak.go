@@ -0,0 +1,68 @@
+package enclaveclient
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// newLease 借出 conn。启用 MaxLeaseHold 时登记租约供 reclaimLeaked 检查，LeaseDebug 时额外记录借用方调用栈。
+func (ep *enclavePool) newLease(conn *connWrapper) *Lease {
+	lease := &Lease{conn: conn, acquired: time.Now()}
+	cfg := ep.parent.Config()
+	if cfg.MaxLeaseHold <= 0 {
+		return lease
+	}
+	if cfg.LeaseDebug {
+		lease.stack = debug.Stack()
+	}
+	lease.tracked = true
+	ep.mu.Lock()
+	if ep.leases == nil {
+		ep.leases = make(map[*Lease]struct{})
+	}
+	ep.leases[lease] = struct{}{}
+	ep.mu.Unlock()
+	return lease
+}
+
+func (ep *enclavePool) untrack(lease *Lease) {
+	ep.mu.Lock()
+	delete(ep.leases, lease)
+	ep.mu.Unlock()
+}
+
+// reclaimLeaked 强制收回持有超过 MaxLeaseHold 仍未 Release 的租约：关闭其连接并补齐 MinConns，
+// 之后借用方的 Release 为空操作，仍在进行的 RPC 随连接关闭而失败。
+func (ep *enclavePool) reclaimLeaked(cfg Config, now time.Time) {
+	if cfg.MaxLeaseHold <= 0 {
+		return
+	}
+	var leaked []*Lease
+	ep.mu.Lock()
+	for lease := range ep.leases {
+		if now.Sub(lease.acquired) >= cfg.MaxLeaseHold {
+			leaked = append(leaked, lease)
+			delete(ep.leases, lease)
+		}
+	}
+	ep.mu.Unlock()
+	reclaimed := 0
+	for _, lease := range leaked {
+		// 与 Release 竞争：先置位者处理连接。
+		if lease.released.Swap(true) {
+			continue
+		}
+		reclaimed++
+		lease.conn.close()
+		ep.decrement()
+		ep.parent.metrics.incLeaseReclaimed(ep.target.ID)
+		attrs := []any{"enclave", ep.target.ID, "held", now.Sub(lease.acquired)}
+		if lease.stack != nil {
+			attrs = append(attrs, "stack", string(lease.stack))
+		}
+		ep.parent.logs.Warn(ep.target.ID, "leaked enclave lease reclaimed", attrs...)
+	}
+	if reclaimed > 0 {
+		go ep.ensureMin(cfg.MinConns)
+	}
+}
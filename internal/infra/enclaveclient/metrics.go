@@ -1,46 +1,120 @@
 package enclaveclient
 
 import (
+	"context"
+	"path"
 	"time"
 
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
 	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
 )
 
-// Metrics 暴露 active_conns / grpc_stream_resets / pool_acquire_latency_ms。
+// Acquire 失败原因，用作 acquire_failures_total 的 reason 标签。
+const (
+	AcquireFailTargetNotFound = "target_not_found"
+	AcquireFailDraining       = "draining"
+	AcquireFailTimeout        = "timeout"
+	AcquireFailCanceled       = "canceled"
+	AcquireFailQueueFull      = "queue_full"
+	AcquireFailQuarantined    = "quarantined"
+)
+
+// 连接回收原因，用作 conns_recycled_total 的 reason 标签。
+const (
+	RecycleIdle   = "idle"
+	RecycleMaxAge = "max_age"
+)
+
+// Metrics 暴露 active_conns / grpc_stream_resets / pool_acquire_latency_ms / acquire_failures_total /
+// conns_recycled_total / pool_acquire_waiters / pool_acquire_rejected_total / max_conns / autoscale_resizes_total /
+// attestation_failures_total / breaker_state / breaker_transitions_total / outlier_ejections_total /
+// sign_streams_opened_total / leases_reclaimed_total / enclave_rpc_duration_ms / enclave_rpc_errors_total。
 type Metrics struct {
-	activeConns    *prometheus.GaugeVec
-	streamResets   *prometheus.CounterVec
-	acquireLatency *prometheus.HistogramVec
+	activeConns     *prometheus.GaugeVec
+	streamResets    *prometheus.CounterVec
+	acquireLatency  *prometheus.HistogramVec
+	acquireFailures *prometheus.CounterVec
+	connsRecycled   *prometheus.CounterVec
+	acquireWaiters  *prometheus.GaugeVec
+	acquireRejected *prometheus.CounterVec
+	maxConns        prometheus.Gauge
+	autoscale       *prometheus.CounterVec
+	attestFailures  *prometheus.CounterVec
+	breakerState    *prometheus.GaugeVec
+	breakerChanges  *prometheus.CounterVec
+	outlierEjected  *prometheus.CounterVec
+	outlierSkipped  *prometheus.CounterVec
+	streamsOpened   *prometheus.CounterVec
+	leasesReclaimed *prometheus.CounterVec
+	rpcDuration     *prometheus.HistogramVec
+	rpcErrors       *prometheus.CounterVec
 }
 
-// NewMetrics 在注册器中注册三类指标。
+// NewMetrics 在注册器中注册连接池指标，指标名为 signer_enclave_pool_*。
 func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m, err := NewMetricsWithOptions(reg, metricsopts.Options{})
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// NewMetricsWithOptions 按 opts 覆盖命名空间/子系统/常量标签后注册连接池指标，
+// 未设置的字段沿用 signer / enclave_pool。
+func NewMetricsWithOptions(reg prometheus.Registerer, opts metricsopts.Options) (*Metrics, error) {
 	if reg == nil {
 		reg = prometheus.DefaultRegisterer
 	}
+	opts = opts.WithDefaults("signer", "enclave_pool")
 	m := &Metrics{
-		activeConns: prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: "signer",
-			Subsystem: "enclave_pool",
-			Name:      "active_conns",
-			Help:      "Number of established gRPC connections per enclave",
-		}, []string{"enclave_id"}),
-		streamResets: prometheus.NewCounterVec(prometheus.CounterOpts{
-			Namespace: "signer",
-			Subsystem: "enclave_pool",
-			Name:      "grpc_stream_resets_total",
-			Help:      "Total number of gRPC stream reset events",
-		}, []string{"enclave_id"}),
-		acquireLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
-			Namespace: "signer",
-			Subsystem: "enclave_pool",
-			Name:      "pool_acquire_latency_ms",
-			Help:      "Time spent waiting for a pooled connection in milliseconds",
-			Buckets:   []float64{0.05, 0.1, 0.2, 0.5, 1, 2, 5, 10, 20, 50, 100, 200, 500},
-		}, []string{"enclave_id"}),
+		activeConns: prometheus.NewGaugeVec(opts.Gauge("active_conns",
+			"Number of established gRPC connections per enclave"), []string{"enclave_id"}),
+		streamResets: prometheus.NewCounterVec(opts.Counter("grpc_stream_resets_total",
+			"Total number of gRPC stream reset events"), []string{"enclave_id"}),
+		acquireLatency: prometheus.NewHistogramVec(opts.Histogram("pool_acquire_latency_ms",
+			"Time spent waiting for a pooled connection in milliseconds",
+			[]float64{0.05, 0.1, 0.2, 0.5, 1, 2, 5, 10, 20, 50, 100, 200, 500}), []string{"enclave_id"}),
+		acquireFailures: prometheus.NewCounterVec(opts.Counter("acquire_failures_total",
+			"Total number of failed connection acquisitions by reason"), []string{"enclave_id", "reason"}),
+		connsRecycled: prometheus.NewCounterVec(opts.Counter("conns_recycled_total",
+			"Total number of healthy connections closed for idleness or age"), []string{"enclave_id", "reason"}),
+		acquireWaiters: prometheus.NewGaugeVec(opts.Gauge("pool_acquire_waiters",
+			"Number of Acquire calls queued for a connection per enclave"), []string{"enclave_id"}),
+		acquireRejected: prometheus.NewCounterVec(opts.Counter("pool_acquire_rejected_total",
+			"Total number of Acquire calls rejected because the waiter queue was full"), []string{"enclave_id"}),
+		maxConns: prometheus.NewGauge(opts.Gauge("max_conns",
+			"Current per-enclave connection limit")),
+		autoscale: prometheus.NewCounterVec(opts.Counter("autoscale_resizes_total",
+			"Total number of MaxConns changes made by the autoscaler by direction"), []string{"direction"}),
+		attestFailures: prometheus.NewCounterVec(opts.Counter("attestation_failures_total",
+			"Total number of new connections rejected by attestation verification"), []string{"enclave_id"}),
+		breakerState: prometheus.NewGaugeVec(opts.Gauge("breaker_state",
+			"Circuit breaker state per enclave, 1 for the current state and 0 otherwise"), []string{"enclave_id", "state"}),
+		breakerChanges: prometheus.NewCounterVec(opts.Counter("breaker_transitions_total",
+			"Total number of circuit breaker transitions by the state entered"), []string{"enclave_id", "state"}),
+		outlierEjected: prometheus.NewCounterVec(opts.Counter("outlier_ejections_total",
+			"Total number of enclaves ejected by outlier detection"), []string{"enclave_id", "reason"}),
+		outlierSkipped: prometheus.NewCounterVec(opts.Counter("outlier_ejections_skipped_total",
+			"Total number of outlier ejections skipped because max ejection percent was reached"), []string{"enclave_id"}),
+		streamsOpened: prometheus.NewCounterVec(opts.Counter("sign_streams_opened_total",
+			"Total number of long-lived SignStreams opened on pooled connections"), []string{"enclave_id"}),
+		leasesReclaimed: prometheus.NewCounterVec(opts.Counter("leases_reclaimed_total",
+			"Total number of leases reclaimed after exceeding the max hold time without Release"), []string{"enclave_id"}),
+		rpcDuration: prometheus.NewHistogramVec(opts.Histogram("enclave_rpc_duration_ms",
+			"Duration of enclave RPCs in milliseconds, excluding pool acquire time",
+			[]float64{0.5, 1, 2, 3, 5, 7.5, 10, 20, 50, 100, 250, 1000}), []string{"method", "enclave_id", "code"}),
+		rpcErrors: prometheus.NewCounterVec(opts.Counter("enclave_rpc_errors_total",
+			"Total number of enclave RPCs that returned a non-OK status"), []string{"method", "enclave_id", "code"}),
 	}
-	reg.MustRegister(m.activeConns, m.streamResets, m.acquireLatency)
-	return m
+	if err := metricsopts.Register(reg, m.activeConns, m.streamResets, m.acquireLatency, m.acquireFailures,
+		m.connsRecycled, m.acquireWaiters, m.acquireRejected, m.maxConns, m.autoscale, m.attestFailures, m.breakerState, m.breakerChanges,
+		m.outlierEjected, m.outlierSkipped, m.streamsOpened, m.leasesReclaimed,
+		m.rpcDuration, m.rpcErrors); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
 func (m *Metrics) setActive(enclaveID string, value float64) {
@@ -54,3 +128,84 @@ func (m *Metrics) incStreamReset(enclaveID string) {
 func (m *Metrics) observeAcquire(enclaveID string, duration time.Duration) {
 	m.acquireLatency.WithLabelValues(enclaveID).Observe(duration.Seconds() * 1000)
 }
+
+func (m *Metrics) incAcquireFailure(enclaveID, reason string) {
+	m.acquireFailures.WithLabelValues(enclaveID, reason).Inc()
+}
+
+func (m *Metrics) incRecycled(enclaveID, reason string) {
+	m.connsRecycled.WithLabelValues(enclaveID, reason).Inc()
+}
+
+func (m *Metrics) setWaiters(enclaveID string, value float64) {
+	m.acquireWaiters.WithLabelValues(enclaveID).Set(value)
+}
+
+func (m *Metrics) incAcquireRejected(enclaveID string) {
+	m.acquireRejected.WithLabelValues(enclaveID).Inc()
+}
+
+func (m *Metrics) setMaxConns(value float64) {
+	m.maxConns.Set(value)
+}
+
+func (m *Metrics) incAutoscale(direction string) {
+	m.autoscale.WithLabelValues(direction).Inc()
+}
+
+func (m *Metrics) incAttestationFailure(enclaveID string) {
+	m.attestFailures.WithLabelValues(enclaveID).Inc()
+}
+
+// breakerStates 为 breaker_state 指标导出的全部状态。
+var breakerStates = []BreakerState{BreakerHealthy, BreakerDegraded, BreakerHalfOpen, BreakerDraining}
+
+func (m *Metrics) setBreakerState(enclaveID string, state BreakerState) {
+	for _, s := range breakerStates {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		m.breakerState.WithLabelValues(enclaveID, string(s)).Set(value)
+	}
+}
+
+func (m *Metrics) incBreakerTransition(enclaveID string, state BreakerState) {
+	m.breakerChanges.WithLabelValues(enclaveID, string(state)).Inc()
+}
+
+func (m *Metrics) incOutlierEjection(enclaveID, reason string) {
+	m.outlierEjected.WithLabelValues(enclaveID, reason).Inc()
+}
+
+func (m *Metrics) incOutlierSkipped(enclaveID string) {
+	m.outlierSkipped.WithLabelValues(enclaveID).Inc()
+}
+
+func (m *Metrics) incSignStreamOpened(enclaveID string) {
+	m.streamsOpened.WithLabelValues(enclaveID).Inc()
+}
+
+func (m *Metrics) incLeaseReclaimed(enclaveID string) {
+	m.leasesReclaimed.WithLabelValues(enclaveID).Inc()
+}
+
+// observeRPC 记录一次 Enclave RPC 的耗时，非 OK 状态同时计入错误数。
+func (m *Metrics) observeRPC(method, enclaveID string, duration time.Duration, err error) {
+	code := status.Code(err).String()
+	m.rpcDuration.WithLabelValues(method, enclaveID, code).Observe(float64(duration.Microseconds()) / 1000)
+	if err != nil {
+		m.rpcErrors.WithLabelValues(method, enclaveID, code).Inc()
+	}
+}
+
+// rpcDialOptions 返回按方法记录 unary RPC 耗时与错误的拦截器；SignStream 上的签名由 Lease.Sign 逐次记录。
+func (m *Metrics) rpcDialOptions(enclaveID string) []grpc.DialOption {
+	return []grpc.DialOption{grpc.WithChainUnaryInterceptor(
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			start := time.Now()
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			m.observeRPC(path.Base(method), enclaveID, time.Since(start), err)
+			return err
+		})}
+}
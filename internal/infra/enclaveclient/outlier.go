@@ -0,0 +1,236 @@
+package enclaveclient
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultOutlierAlpha            = 0.1
+	defaultOutlierErrorRate        = 0.5
+	defaultOutlierMinRequests      = 20
+	defaultOutlierEjectionTime     = 30 * time.Second
+	defaultOutlierMaxEjectionTime  = 5 * time.Minute
+	defaultOutlierMaxEjectionRatio = 50
+	defaultOutlierRampUp           = 30 * time.Second
+)
+
+// 摘除原因，用作 outlier_ejections_total 的 reason 标签。
+const (
+	OutlierErrorRate = "error_rate"
+	OutlierLatency   = "latency"
+)
+
+// OutlierConfig 控制按目标的离群检测（默认关闭）：借出连接上的 RPC 结果与耗时在归还时计入 EWMA，
+// 超过阈值的目标在一段时间内不接收新路由，之后按比例逐步放回，类似 Envoy outlier detection。
+type OutlierConfig struct {
+	Enabled bool
+	// Alpha 为 EWMA 平滑系数（0,1]，越大越敏感，默认 0.1。
+	Alpha float64
+	// ErrorRate：错误率 EWMA 超过该值时摘除，默认 0.5。
+	ErrorRate float64
+	// Latency：延迟 EWMA 超过该值时摘除，0 表示不按延迟摘除。
+	Latency time.Duration
+	// MinRequests 为判定前所需的最少样本数（每次摘除后重新累计），默认 20。
+	MinRequests int
+	// EjectionTime 为首次摘除时长，连续摘除按次数线性增长，不超过 MaxEjectionTime；默认 30s / 5m。
+	EjectionTime    time.Duration
+	MaxEjectionTime time.Duration
+	// MaxEjectionPercent 为同时被摘除的目标比例上限（至少允许 1 个），默认 50。
+	MaxEjectionPercent int
+	// RampUp 为摘除期满后逐步放回的时长：期间按已过时间的比例把新请求路由回该目标，默认 30s。
+	RampUp time.Duration
+}
+
+// WithOutlierDetection 启用离群检测，cfg.Enabled 为 false 时不生效。
+func WithOutlierDetection(cfg OutlierConfig) Option {
+	return func(p *Pool) {
+		if !cfg.Enabled {
+			p.outlier = nil
+			return
+		}
+		if cfg.Alpha <= 0 || cfg.Alpha > 1 {
+			cfg.Alpha = defaultOutlierAlpha
+		}
+		if cfg.ErrorRate <= 0 {
+			cfg.ErrorRate = defaultOutlierErrorRate
+		}
+		if cfg.MinRequests <= 0 {
+			cfg.MinRequests = defaultOutlierMinRequests
+		}
+		if cfg.EjectionTime <= 0 {
+			cfg.EjectionTime = defaultOutlierEjectionTime
+		}
+		if cfg.MaxEjectionTime < cfg.EjectionTime {
+			cfg.MaxEjectionTime = max(defaultOutlierMaxEjectionTime, cfg.EjectionTime)
+		}
+		if cfg.MaxEjectionPercent <= 0 {
+			cfg.MaxEjectionPercent = defaultOutlierMaxEjectionRatio
+		}
+		if cfg.RampUp < 0 {
+			cfg.RampUp = 0
+		} else if cfg.RampUp == 0 {
+			cfg.RampUp = defaultOutlierRampUp
+		}
+		p.outlier = &cfg
+	}
+}
+
+// OutlierStats 是目标离群检测的瞬时状态。
+type OutlierStats struct {
+	ErrorRate float64 `json:"errorRate"`
+	LatencyMs float64 `json:"latencyMs"`
+	Samples   int     `json:"samples"`
+	// Ejections 为连续摘除次数，决定下一次摘除时长；目标持续健康时逐步递减。
+	Ejections int `json:"ejections"`
+	// EjectedUntil 非 nil 表示目标被摘除至该时间（之后进入逐步放回），Reason 为摘除原因。
+	EjectedUntil *time.Time `json:"ejectedUntil,omitempty"`
+	Reason       string     `json:"reason,omitempty"`
+}
+
+// outlierState 保存单个目标的 EWMA 与摘除状态。ejectedUntil 以 UnixNano 原子存放，
+// 路由判断无需加锁。
+type outlierState struct {
+	mu        sync.Mutex
+	errorRate float64
+	latencyMs float64
+	samples   int
+	ejections int
+	reason    string
+
+	ejectedUntil atomic.Int64
+}
+
+// isOutlierError 判断 RPC 失败是否说明目标异常：传输错误、超时与服务端故障计入，
+// 参数错误等业务错误以及调用方取消不计入。
+func isOutlierError(err error) bool {
+	if !isConnectionError(err) {
+		return false
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return true
+	}
+	switch st.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown, codes.ResourceExhausted, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+// observe 记录一次租约上的调用结果，调用方取消的样本不计入。
+func (ep *enclavePool) observe(latency time.Duration, err error) {
+	cfg := ep.parent.outlier
+	if cfg == nil || (err != nil && !isConnectionError(err)) {
+		return
+	}
+	failed := 0.0
+	if isOutlierError(err) {
+		failed = 1
+	}
+	o := &ep.outlier
+	o.mu.Lock()
+	if o.samples == 0 {
+		o.errorRate, o.latencyMs = failed, float64(latency.Microseconds())/1000
+	} else {
+		o.errorRate += cfg.Alpha * (failed - o.errorRate)
+		o.latencyMs += cfg.Alpha * (float64(latency.Microseconds())/1000 - o.latencyMs)
+	}
+	o.samples++
+	reason := ""
+	if o.samples >= cfg.MinRequests {
+		switch {
+		case o.errorRate > cfg.ErrorRate:
+			reason = OutlierErrorRate
+		case cfg.Latency > 0 && o.latencyMs > float64(cfg.Latency.Microseconds())/1000:
+			reason = OutlierLatency
+		default:
+			// 一个完整窗口内保持健康，连续摘除次数递减。
+			o.samples = 0
+			o.ejections = max(o.ejections-1, 0)
+		}
+	}
+	o.mu.Unlock()
+	if reason != "" && time.Now().UnixNano() >= o.ejectedUntil.Load() {
+		ep.parent.eject(ep, reason)
+	}
+}
+
+// eject 在未超过 MaxEjectionPercent 时摘除目标；摘除决定由 outlierMu 串行化，避免并发摘除突破上限。
+func (p *Pool) eject(ep *enclavePool, reason string) {
+	cfg := p.outlier
+	p.outlierMu.Lock()
+	defer p.outlierMu.Unlock()
+	now := time.Now()
+	p.mu.RLock()
+	total, ejected := len(p.targets), 0
+	for _, other := range p.targets {
+		if now.UnixNano() < other.outlier.ejectedUntil.Load() {
+			ejected++
+		}
+	}
+	p.mu.RUnlock()
+	if now.UnixNano() < ep.outlier.ejectedUntil.Load() {
+		return
+	}
+	if ejected >= max(total*cfg.MaxEjectionPercent/100, 1) {
+		p.metrics.incOutlierSkipped(ep.target.ID)
+		return
+	}
+	o := &ep.outlier
+	o.mu.Lock()
+	o.ejections++
+	d := min(cfg.EjectionTime*time.Duration(o.ejections), cfg.MaxEjectionTime)
+	until := now.Add(d)
+	o.ejectedUntil.Store(until.UnixNano())
+	o.reason = reason
+	errorRate, latencyMs := o.errorRate, o.latencyMs
+	// 放回后重新累计样本，不因摘除前的历史立即再次摘除。
+	o.samples, o.errorRate, o.latencyMs = 0, 0, 0
+	o.mu.Unlock()
+	p.metrics.incOutlierEjection(ep.target.ID, reason)
+	p.logger.Warn("enclave ejected as outlier",
+		"enclave", ep.target.ID, "reason", reason, "error_rate", errorRate, "latency_ms", latencyMs, "until", until)
+	p.emit(func(l EventListener) {
+		l.OnEject(EjectEvent{EnclaveID: ep.target.ID, Reason: reason, ErrorRate: errorRate, LatencyMs: latencyMs, Until: until})
+	})
+}
+
+// outlierRoutable 报告离群检测是否允许把新请求路由到该目标：摘除期间为 false，
+// 放回期间按已过时间占 RampUp 的比例随机放行。
+func (ep *enclavePool) outlierRoutable(now time.Time) bool {
+	cfg := ep.parent.outlier
+	until := ep.outlier.ejectedUntil.Load()
+	if cfg == nil || until == 0 {
+		return true
+	}
+	elapsed := now.UnixNano() - until
+	switch {
+	case elapsed < 0:
+		return false
+	case elapsed >= int64(cfg.RampUp):
+		return true
+	}
+	return rand.Float64() < float64(elapsed)/float64(cfg.RampUp)
+}
+
+func (ep *enclavePool) outlierStats(now time.Time) *OutlierStats {
+	if ep.parent.outlier == nil {
+		return nil
+	}
+	o := &ep.outlier
+	o.mu.Lock()
+	st := &OutlierStats{ErrorRate: o.errorRate, LatencyMs: o.latencyMs, Samples: o.samples, Ejections: o.ejections}
+	reason := o.reason
+	o.mu.Unlock()
+	if until := o.ejectedUntil.Load(); now.UnixNano() < until {
+		t := time.Unix(0, until)
+		st.EjectedUntil, st.Reason = &t, reason
+	}
+	return st
+}
@@ -0,0 +1,140 @@
+package enclaveclient
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func newOutlierPool(t *testing.T, cfg OutlierConfig, ids ...string) *Pool {
+	t.Helper()
+	srv, lis := setupBufConn(t)
+	t.Cleanup(srv.Stop)
+	poolCfg := DefaultConfig()
+	poolCfg.MinConns = 1
+	poolCfg.MaxConns = 4
+	pool, err := NewPool(poolCfg,
+		WithRegisterer(prometheus.NewRegistry()),
+		WithOutlierDetection(cfg),
+		WithDialer(func(ctx context.Context, target Target, _ Config) (*grpc.ClientConn, error) {
+			return grpc.DialContext(ctx, target.Endpoint,
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			)
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	for _, id := range ids {
+		pool.RegisterTarget(Target{ID: id, Endpoint: "buf"})
+	}
+	return pool
+}
+
+func releaseN(t *testing.T, pool *Pool, id string, n int, hold time.Duration, err error) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		lease, acqErr := pool.Acquire(context.Background(), id)
+		require.NoError(t, acqErr)
+		time.Sleep(hold)
+		lease.Release(err)
+	}
+}
+
+func TestPoolEjectsOutliersAndRampsBack(t *testing.T) {
+	pool := newOutlierPool(t, OutlierConfig{
+		Enabled:      true,
+		Alpha:        0.5,
+		MinRequests:  5,
+		EjectionTime: 300 * time.Millisecond,
+		RampUp:       50 * time.Millisecond,
+	}, "a", "b")
+	unavailable := status.Error(codes.Unavailable, "enclave overloaded")
+
+	// 业务错误按成功计入，调用方取消不计入样本。
+	releaseN(t, pool, "a", 3, 0, status.Error(codes.InvalidArgument, "bad digest"))
+	releaseN(t, pool, "a", 3, 0, context.Canceled)
+	require.Equal(t, 3, pool.Stats()[0].Outlier.Samples)
+	require.Zero(t, pool.Stats()[0].Outlier.ErrorRate)
+
+	releaseN(t, pool, "a", 5, 0, unavailable)
+	require.False(t, pool.Routable("a"))
+	st := pool.Stats()[0]
+	require.NotNil(t, st.Outlier.EjectedUntil)
+	require.Equal(t, OutlierErrorRate, st.Outlier.Reason)
+	require.Equal(t, 1, st.Outlier.Ejections)
+	require.True(t, st.Available())
+	require.Equal(t, 1.0, testutil.ToFloat64(pool.metrics.outlierEjected.WithLabelValues("a", OutlierErrorRate)))
+
+	// 摘除期间仍可借用，粘在该目标上的请求不受影响。
+	lease, err := pool.Acquire(context.Background(), "a")
+	require.NoError(t, err)
+	lease.Release(nil)
+
+	// 两个目标最多摘除 1 个。
+	releaseN(t, pool, "b", 5, 0, unavailable)
+	require.True(t, pool.Routable("b"))
+	require.Nil(t, pool.Stats()[1].Outlier.EjectedUntil)
+	require.Equal(t, 1.0, testutil.ToFloat64(pool.metrics.outlierSkipped.WithLabelValues("b")))
+
+	// 摘除期满并完成逐步放回后恢复路由。
+	require.Eventually(t, func() bool {
+		return pool.Routable("a") && pool.Stats()[0].Outlier.EjectedUntil == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	ep := pool.targets["a"]
+	require.True(t, ep.outlierRoutable(time.Now().Add(time.Second)))
+}
+
+func TestPoolEjectsSlowTargetAndBacksOff(t *testing.T) {
+	pool := newOutlierPool(t, OutlierConfig{
+		Enabled:            true,
+		Alpha:              1,
+		Latency:            2 * time.Millisecond,
+		MinRequests:        3,
+		EjectionTime:       time.Minute,
+		MaxEjectionTime:    90 * time.Second,
+		MaxEjectionPercent: 100,
+	}, "slow")
+
+	releaseN(t, pool, "slow", 3, 10*time.Millisecond, nil)
+	st := pool.Stats()[0].Outlier
+	require.Equal(t, OutlierLatency, st.Reason)
+	require.WithinDuration(t, time.Now().Add(time.Minute), *st.EjectedUntil, 5*time.Second)
+	require.False(t, pool.Routable("slow"))
+
+	// 连续摘除的时长按次数增长，不超过 MaxEjectionTime。
+	ep := pool.targets["slow"]
+	ep.outlier.ejectedUntil.Store(time.Now().Add(-time.Hour).UnixNano())
+	releaseN(t, pool, "slow", 3, 10*time.Millisecond, nil)
+	st = pool.Stats()[0].Outlier
+	require.Equal(t, 2, st.Ejections)
+	require.WithinDuration(t, time.Now().Add(90*time.Second), *st.EjectedUntil, 5*time.Second)
+}
+
+func TestLoadOutlierConfigFromEnv(t *testing.T) {
+	t.Setenv("SIGN_CONN_POOL_OUTLIER", "true")
+	t.Setenv("SIGN_CONN_POOL_OUTLIER_ERROR_RATE", "0.3")
+	t.Setenv("SIGN_CONN_POOL_OUTLIER_LATENCY", "250ms")
+	t.Setenv("SIGN_CONN_POOL_OUTLIER_EJECTION_TIME", "10s")
+	cfg := LoadOutlierConfigFromEnv()
+	require.True(t, cfg.Enabled)
+	require.Equal(t, 0.3, cfg.ErrorRate)
+	require.Equal(t, 250*time.Millisecond, cfg.Latency)
+	require.Equal(t, 10*time.Second, cfg.EjectionTime)
+
+	pool := &Pool{}
+	WithOutlierDetection(cfg)(pool)
+	require.Equal(t, defaultOutlierAlpha, pool.outlier.Alpha)
+	require.Equal(t, defaultOutlierMaxEjectionTime, pool.outlier.MaxEjectionTime)
+	require.Equal(t, defaultOutlierRampUp, pool.outlier.RampUp)
+	WithOutlierDetection(OutlierConfig{})(pool)
+	require.Nil(t, pool.outlier)
+}
@@ -1,10 +1,12 @@
 package enclaveclient
 
 import (
+	"container/list"
 	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand"
 	"net"
 	"strconv"
 	"strings"
@@ -13,13 +15,17 @@ import (
 	"time"
 
 	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
-	"github.com/mdlayher/vsock"
+	"github.com/aegis-sign/wallet/internal/infra/logdedup"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/connectivity"
-	"google.golang.org/grpc/credentials/insecure"
-	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 )
 
 // ErrTargetNotFound 表示请求的 Enclave 目标不存在。
@@ -31,6 +37,9 @@ var ErrPoolDraining = errors.New("enclave pool is draining")
 // ErrAcquireTimeout 表示在指定时间内未获取到连接。
 var ErrAcquireTimeout = errors.New("acquire enclave connection timeout")
 
+// ErrAcquireQueueFull 表示排队等待连接的请求数已达 MaxWaiters，按超时处理（errors.Is 匹配 ErrAcquireTimeout）。
+var ErrAcquireQueueFull = fmt.Errorf("%w: acquire queue is full", ErrAcquireTimeout)
+
 // Dialer 允许自定义 vsock/unix socket 拨号逻辑。
 type Dialer func(ctx context.Context, target Target, cfg Config) (*grpc.ClientConn, error)
 
@@ -39,6 +48,8 @@ type Target struct {
 	ID       string
 	Endpoint string
 	Metadata map[string]string
+	// TLS 非 nil 时覆盖 Config.TLS；指向零值表示该目标使用明文。
+	TLS *TLSConfig
 }
 
 // Pool 管理父机→Enclave 的长连接池。
@@ -48,10 +59,28 @@ type Pool struct {
 
 	dialer  Dialer
 	metrics *Metrics
-	logger  *slog.Logger
+	// registerer 与 metricsOpts 在 NewPool 中用于构造 metrics。
+	registerer  prometheus.Registerer
+	metricsOpts metricsopts.Options
+	logger      *slog.Logger
+	// logs 合并 Enclave 故障期间按连接反复出现的相同告警。
+	logs *logdedup.Logger
 
 	cfg atomic.Value // Config
 
+	acquireWaits latencyWindow
+	// healthCheck 为周期探测、熔断探测与 Prewarm 共用的健康检查，见 WithHealthChecker。
+	healthCheck HealthChecker
+	// onDrain 在目标被排空或移除后调用，用于让上层缓存失效该 Enclave 上的 key。
+	onDrain func(enclaveID string)
+	// listeners 接收连接池生命周期事件，见 WithEventListener。
+	listeners []EventListener
+	// attestation 非 nil 时新连接须先通过 attestation 握手，见 WithAttestation。
+	attestation *AttestationConfig
+	// outlier 非 nil 时按租约结果做离群检测，见 WithOutlierDetection；outlierMu 串行化摘除决定。
+	outlier   *OutlierConfig
+	outlierMu sync.Mutex
+
 	mu      sync.RWMutex
 	targets map[string]*enclavePool
 }
@@ -71,7 +100,17 @@ func WithLogger(l *slog.Logger) Option {
 
 // WithRegisterer 指定 Prometheus 注册器。
 func WithRegisterer(reg prometheus.Registerer) Option {
-	return func(p *Pool) { p.metrics = NewMetrics(reg) }
+	return func(p *Pool) { p.registerer = reg }
+}
+
+// WithMetricsOptions 覆盖连接池指标的命名空间、子系统与常量标签。
+func WithMetricsOptions(opts metricsopts.Options) Option {
+	return func(p *Pool) { p.metricsOpts = opts }
+}
+
+// WithDrainHook 注册目标排空/移除后的回调，如 keycache.Store.InvalidateEnclave。
+func WithDrainHook(fn func(enclaveID string)) Option {
+	return func(p *Pool) { p.onDrain = fn }
 }
 
 // NewPool 根据配置创建连接池并预热最小连接数。
@@ -87,16 +126,24 @@ func NewPool(cfg Config, opts ...Option) (*Pool, error) {
 		logger:  slog.Default(),
 	}
 	p.cfg.Store(cfg)
-	p.dialer = defaultDialer
 	for _, opt := range opts {
 		opt(p)
 	}
 	if p.dialer == nil {
-		p.dialer = defaultDialer
+		p.dialer = p.defaultDial
 	}
-	if p.metrics == nil {
-		p.metrics = NewMetrics(nil)
+	if p.healthCheck == nil {
+		p.healthCheck = defaultHealthCheck
 	}
+	metrics, err := NewMetricsWithOptions(p.registerer, p.metricsOpts)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	p.metrics = metrics
+	p.metrics.setMaxConns(float64(cfg.MaxConns))
+	p.logs = logdedup.New(p.logger, logdedup.Config{})
+	go p.reapLoop()
 	return p, nil
 }
 
@@ -109,6 +156,7 @@ func (p *Pool) Close() error {
 		_ = ep.close()
 	}
 	p.targets = map[string]*enclavePool{}
+	p.logs.Close()
 	return nil
 }
 
@@ -123,6 +171,7 @@ func (p *Pool) UpdateConfig(cfg Config) {
 		cfg.MaxConns = cfg.MinConns
 	}
 	p.cfg.Store(cfg)
+	p.metrics.setMaxConns(float64(cfg.MaxConns))
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 	for _, ep := range p.targets {
@@ -150,24 +199,54 @@ func (p *Pool) RegisterTarget(target Target) {
 // RemoveTarget 移除 Enclave，关闭所有连接。
 func (p *Pool) RemoveTarget(id string) {
 	p.mu.Lock()
-	defer p.mu.Unlock()
-	if ep, ok := p.targets[id]; ok {
+	ep, ok := p.targets[id]
+	if ok {
 		_ = ep.close()
 		delete(p.targets, id)
 	}
+	p.mu.Unlock()
+	if ok {
+		p.notifyDrained(id)
+	}
 }
 
-// Acquire 借用一条长连接。
-func (p *Pool) Acquire(ctx context.Context, enclaveID string) (*Lease, error) {
+// Acquire 借用一条长连接；上游已有 trace 时以 span 记录借用等待时间。
+func (p *Pool) Acquire(ctx context.Context, enclaveID string) (_ *Lease, err error) {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		var span trace.Span
+		ctx, span = tracer.Start(ctx, "enclaveclient.Acquire", trace.WithAttributes(attrEnclaveID.String(enclaveID)))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(otelcodes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
 	p.mu.RLock()
 	ep := p.targets[enclaveID]
 	p.mu.RUnlock()
 	if ep == nil {
-		return nil, ErrTargetNotFound
+		return nil, p.acquireFailed(enclaveID, AcquireFailTargetNotFound, ErrTargetNotFound)
 	}
 	return ep.acquire(ctx)
 }
 
+// Routable 报告 enclaveID 是否适合接收新请求：目标未注册、已摘除、熔断降级冷却中、attestation 隔离中
+// 或被离群检测摘除时返回 false；逐步放回期间按比例返回 true。
+func (p *Pool) Routable(enclaveID string) bool {
+	p.mu.RLock()
+	ep := p.targets[enclaveID]
+	p.mu.RUnlock()
+	return ep != nil && ep.breaker.Routable() && !ep.quarantined() && ep.outlierRoutable(time.Now())
+}
+
+// acquireFailed 记录失败原因并以统一格式包装错误，errors.Is 仍可匹配哨兵错误。
+func (p *Pool) acquireFailed(enclaveID, reason string, err error) error {
+	p.metrics.incAcquireFailure(enclaveID, reason)
+	return fmt.Errorf("acquire enclave %s (%s): %w", enclaveID, reason, err)
+}
+
 // Drain 触发目标摘除，释放所有连接。
 func (p *Pool) Drain(enclaveID string) error {
 	p.mu.RLock()
@@ -176,7 +255,60 @@ func (p *Pool) Drain(enclaveID string) error {
 	if ep == nil {
 		return ErrTargetNotFound
 	}
-	return ep.drain()
+	if err := ep.drain(); err != nil {
+		return err
+	}
+	p.notifyDrained(enclaveID)
+	return nil
+}
+
+func (p *Pool) notifyDrained(enclaveID string) {
+	if p.onDrain != nil {
+		p.onDrain(enclaveID)
+	}
+	p.emit(func(l EventListener) { l.OnDrain(enclaveID) })
+}
+
+// reapLoop 周期性回收空闲超时与超龄的连接以及泄漏的租约；间隔随配置热更新。
+func (p *Pool) reapLoop() {
+	timer := time.NewTimer(reapInterval(p.Config()))
+	defer timer.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-timer.C:
+		}
+		cfg := p.Config()
+		if cfg.MaxIdleTime > 0 || cfg.MaxConnAge > 0 || cfg.MaxLeaseHold > 0 {
+			p.mu.RLock()
+			eps := make([]*enclavePool, 0, len(p.targets))
+			for _, ep := range p.targets {
+				eps = append(eps, ep)
+			}
+			p.mu.RUnlock()
+			now := time.Now()
+			for _, ep := range eps {
+				ep.reap(cfg, now)
+				ep.reclaimLeaked(cfg, now)
+			}
+		}
+		timer.Reset(reapInterval(cfg))
+	}
+}
+
+// reapInterval 取 MaxIdleTime、MaxConnAge 与 MaxLeaseHold 中最小非零值的一半，限制在 [10ms, 30s]。
+func reapInterval(cfg Config) time.Duration {
+	interval := 30 * time.Second
+	for _, d := range []time.Duration{cfg.MaxIdleTime, cfg.MaxConnAge, cfg.MaxLeaseHold} {
+		if d > 0 && d/2 < interval {
+			interval = d / 2
+		}
+	}
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	return interval
 }
 
 // Resize 全局更新最小/最大连接数。
@@ -191,6 +323,11 @@ func (p *Pool) Resize(min, max int) {
 type Lease struct {
 	conn     *connWrapper
 	released atomic.Bool
+	// acquired 为借出时间，归还时以持有时长作为该次 RPC 的延迟样本。
+	acquired time.Time
+	// tracked 表示租约已登记，可被 reclaimLeaked 收回；stack 为 LeaseDebug 下借用方的调用栈。
+	tracked bool
+	stack   []byte
 }
 
 // Conn 返回底层 *grpc.ClientConn。
@@ -206,7 +343,8 @@ func (l *Lease) Client() signerv1.SignerServiceClient {
 	return signerv1.NewSignerServiceClient(l.Conn())
 }
 
-// Release 将连接归还池中；若 err!=nil 则标记为需重建。
+// Release 将连接归还池中；若 err 属于连接级错误则标记为需重建，
+// 调用方取消（客户端断开）不视为连接故障。启用离群检测时 err 与持有时长计入目标的 EWMA。
 func (l *Lease) Release(err error) {
 	if l == nil || l.conn == nil {
 		return
@@ -214,6 +352,10 @@ func (l *Lease) Release(err error) {
 	if l.released.Swap(true) {
 		return
 	}
+	if l.tracked {
+		l.conn.pool.untrack(l)
+	}
+	l.conn.pool.observe(time.Since(l.acquired), err)
 	l.conn.pool.release(l.conn, err)
 	l.conn = nil
 }
@@ -225,6 +367,23 @@ type connWrapper struct {
 	cancel    context.CancelFunc
 	target    Target
 	unhealthy atomic.Bool
+	created   time.Time
+	// ageJitter ∈ [0,1)，使同一批预热的连接不会在同一时刻到期重建。
+	ageJitter float64
+	// idleSince 为最近一次归还池中的时间（UnixNano）。
+	idleSince atomic.Int64
+	// stream 为连接上常驻的 SignStream（WarmStreams），首次 Sign 时建立，结束后按需重建。
+	streamMu sync.Mutex
+	stream   *warmStream
+}
+
+// expired 报告连接是否超过 MaxConnAge（提前至多 10% 抖动）。
+func (cw *connWrapper) expired(cfg Config, now time.Time) bool {
+	if cfg.MaxConnAge <= 0 {
+		return false
+	}
+	lifetime := cfg.MaxConnAge - time.Duration(float64(cfg.MaxConnAge/10)*cw.ageJitter)
+	return now.Sub(cw.created) >= lifetime
 }
 
 func (cw *connWrapper) close() {
@@ -254,7 +413,7 @@ func (cw *connWrapper) watchConnectivity(ctx context.Context) {
 		newState := cw.conn.GetState()
 		if newState == connectivity.TransientFailure {
 			cw.pool.parent.metrics.incStreamReset(cw.target.ID)
-			cw.pool.breaker.Failure()
+			cw.pool.breakerFailure()
 			delay := backoff.Next()
 			select {
 			case <-time.After(delay):
@@ -277,7 +436,6 @@ func (cw *connWrapper) healthProbe(ctx context.Context) {
 	}
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
-	client := healthpb.NewHealthClient(cw.conn)
 	for {
 		select {
 		case <-ctx.Done():
@@ -291,13 +449,10 @@ func (cw *connWrapper) healthProbe(ctx context.Context) {
 				interval = newInterval
 				ticker.Reset(interval)
 			}
-			probeCtx, cancel := context.WithTimeout(ctx, cfg.AcquireTimeout)
-			resp, err := client.Check(probeCtx, &healthpb.HealthCheckRequest{Service: cfg.ServiceName})
-			cancel()
-			if err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			if err := cw.pool.checkHealth(ctx, cw.conn, cw.target, cfg); err != nil {
 				cw.unhealthy.Store(true)
-				cw.pool.breaker.Failure()
-				cw.pool.parent.logger.Warn("enclave health degraded", "enclave", cw.target.ID, "err", err)
+				cw.pool.breakerFailure()
+				cw.pool.parent.logs.Warn(cw.target.ID, "enclave health degraded", "enclave", cw.target.ID, "err", err)
 			} else {
 				cw.pool.breaker.Success()
 			}
@@ -310,21 +465,45 @@ type enclavePool struct {
 	parent *Pool
 	target Target
 
-	mu      sync.Mutex
-	conns   chan *connWrapper
+	mu sync.Mutex
+	// idle 按归还顺序保存空闲连接，队首空闲最久。
+	idle     []*connWrapper
+	maxConns int
+	// waiters 为排队中的 Acquire（*waiter），按到达顺序交付连接。
+	waiters list.List
 	total   int
 	breaker *circuitBreaker
 	closed  bool
+	// dials 记录最近的拨号结果，独立加锁，不与连接借还竞争。
+	dials dialRing
+	// quarantinedUntil 之前目标因 attestation 失败被隔离，quarantineReason 为最近一次失败原因。
+	quarantinedUntil time.Time
+	quarantineReason string
+	// lastHealth 为最近一次健康检查结果（含熔断探测）。
+	lastHealth *HealthCheckResult
+	// outlier 为离群检测状态，独立加锁。
+	outlier outlierState
+	// leases 为启用 MaxLeaseHold 时借出中的租约。
+	leases map[*Lease]struct{}
+}
+
+// waiter 是排队中的 Acquire。conn 带 1 个缓冲，交付方持锁写入时不会阻塞；
+// 目标关闭时写入 nil。elem 为 nil 表示已出队。
+type waiter struct {
+	conn chan *connWrapper
+	elem *list.Element
 }
 
 func newEnclavePool(parent *Pool, target Target) *enclavePool {
 	cfg := parent.Config()
-	return &enclavePool{
-		parent:  parent,
-		target:  target,
-		conns:   make(chan *connWrapper, cfg.MaxConns),
-		breaker: newCircuitBreaker(3, time.Second),
+	ep := &enclavePool{
+		parent:   parent,
+		target:   target,
+		maxConns: cfg.MaxConns,
 	}
+	ep.breaker = newCircuitBreaker(cfg.Breaker, ep.breakerChanged)
+	parent.metrics.setBreakerState(target.ID, BreakerHealthy)
+	return ep
 }
 
 func (ep *enclavePool) updateTarget(t Target) {
@@ -336,39 +515,47 @@ func (ep *enclavePool) updateTarget(t Target) {
 	ep.target = t
 }
 
+// updateCapacity 调整连接上限；缩容时先关闭空闲最久的多余连接，借出中的连接在归还时关闭；
+// 扩容时立即为排队者新建连接。
 func (ep *enclavePool) updateCapacity(max int) {
 	ep.mu.Lock()
-	defer ep.mu.Unlock()
 	if ep.closed {
+		ep.mu.Unlock()
 		return
 	}
-	if cap(ep.conns) == max {
-		return
+	ep.maxConns = max
+	excess := min(ep.total-max, len(ep.idle))
+	var extra []*connWrapper
+	if excess > 0 {
+		extra = append(extra, ep.idle[:excess]...)
+		ep.idle = append([]*connWrapper(nil), ep.idle[excess:]...)
 	}
-	newCh := make(chan *connWrapper, max)
-	for {
-		select {
-		case conn := <-ep.conns:
-			newCh <- conn
-		default:
-			goto done
-		}
+	dials := 0
+	for dials < ep.waiters.Len() && ep.reserveLocked() {
+		dials++
+	}
+	ep.mu.Unlock()
+	for _, conn := range extra {
+		conn.close()
+		ep.decrement()
+	}
+	for ; dials > 0; dials-- {
+		go ep.openForWaiters()
 	}
-done:
-	ep.conns = newCh
 }
 
 func (ep *enclavePool) ensureMin(min int) {
 	ctx := ep.parent.ctx
 	for {
 		ep.mu.Lock()
-		total := ep.total
+		total, closed, quarantined := ep.total, ep.closed, ep.quarantinedLocked(time.Now())
 		ep.mu.Unlock()
-		if total >= min {
+		// 隔离期间不预热，期满后由下一次 Acquire 重新建连并校验。
+		if closed || quarantined || total >= min {
 			return
 		}
 		if err := ep.maybeOpen(ctx); err != nil {
-			ep.parent.logger.Warn("prewarm connection failed", "enclave", ep.target.ID, "err", err)
+			ep.parent.logs.Warn(ep.target.ID, "prewarm connection failed", "enclave", ep.target.ID, "err", err)
 			select {
 			case <-time.After(200 * time.Millisecond):
 			case <-ctx.Done():
@@ -379,100 +566,254 @@ func (ep *enclavePool) ensureMin(min int) {
 	}
 }
 
+// acquire 在没有排队者时直接取空闲连接，否则排到队尾，由归还或新建的连接按到达顺序交付；
+// 排队时若未达上限，会为队列后台新建一条连接。
 func (ep *enclavePool) acquire(ctx context.Context) (*Lease, error) {
 	if !ep.breaker.Allow() {
-		return nil, ErrPoolDraining
+		return nil, ep.parent.acquireFailed(ep.target.ID, AcquireFailDraining, ErrPoolDraining)
+	}
+	if ep.quarantined() {
+		return nil, ep.parent.acquireFailed(ep.target.ID, AcquireFailQuarantined, ErrTargetQuarantined)
 	}
 	cfg := ep.parent.Config()
 	start := time.Now()
+	var w *waiter
+	var dial bool
+	for w == nil {
+		ep.mu.Lock()
+		if ep.closed {
+			ep.mu.Unlock()
+			return nil, ep.parent.acquireFailed(ep.target.ID, AcquireFailDraining, ErrPoolDraining)
+		}
+		// 有人排队时不插队，空闲连接此时必然已交给队首。
+		if ep.waiters.Len() == 0 && len(ep.idle) > 0 {
+			conn := ep.idle[0]
+			ep.idle[0] = nil
+			ep.idle = ep.idle[1:]
+			ep.mu.Unlock()
+			if ep.usable(conn, cfg) {
+				ep.observeAcquire(time.Since(start))
+				return ep.newLease(conn), nil
+			}
+			continue
+		}
+		if cfg.MaxWaiters > 0 && ep.waiters.Len() >= cfg.MaxWaiters {
+			ep.mu.Unlock()
+			ep.parent.metrics.incAcquireRejected(ep.target.ID)
+			return nil, ep.parent.acquireFailed(ep.target.ID, AcquireFailQueueFull, ErrAcquireQueueFull)
+		}
+		w = &waiter{conn: make(chan *connWrapper, 1)}
+		w.elem = ep.waiters.PushBack(w)
+		ep.waitersChangedLocked()
+		dial = ep.reserveLocked()
+		ep.mu.Unlock()
+	}
+	trace.SpanFromContext(ctx).AddEvent("enclave.acquire.queued", trace.WithAttributes(attribute.Bool("dial", dial)))
+	if dial {
+		go ep.openForWaiters()
+	}
+
 	acquireCtx := ctx
 	var cancel context.CancelFunc
 	if cfg.AcquireTimeout > 0 {
 		acquireCtx, cancel = context.WithTimeout(ctx, cfg.AcquireTimeout)
 		defer cancel()
 	}
-	for {
-		select {
-		case conn := <-ep.conns:
-			if conn == nil {
-				continue
-			}
-			if conn.unhealthy.Load() {
-				conn.close()
-				ep.decrement()
-				go ep.maybeOpen(ep.parent.ctx)
-				continue
-			}
-			ep.parent.metrics.observeAcquire(ep.target.ID, time.Since(start))
-			return &Lease{conn: conn}, nil
-		default:
-			if err := ep.maybeOpen(ctx); err != nil {
-				if ctx.Err() != nil {
-					return nil, ctx.Err()
-				}
-				ep.parent.logger.Warn("open connection failed", "enclave", ep.target.ID, "err", err)
-			}
-		}
-		select {
-		case conn := <-ep.conns:
-			if conn == nil {
-				continue
-			}
-			if conn.unhealthy.Load() {
-				conn.close()
-				ep.decrement()
-				go ep.maybeOpen(ep.parent.ctx)
-				continue
-			}
-			ep.parent.metrics.observeAcquire(ep.target.ID, time.Since(start))
-			return &Lease{conn: conn}, nil
-		case <-acquireCtx.Done():
-			return nil, errors.Join(ErrAcquireTimeout, acquireCtx.Err())
+	select {
+	case conn := <-w.conn:
+		return ep.delivered(conn, start)
+	case <-acquireCtx.Done():
+	}
+	ep.mu.Lock()
+	if w.elem == nil {
+		// 超时与交付同时发生：连接已交付，照常借出。
+		ep.mu.Unlock()
+		return ep.delivered(<-w.conn, start)
+	}
+	ep.waiters.Remove(w.elem)
+	w.elem = nil
+	ep.waitersChangedLocked()
+	ep.mu.Unlock()
+	ep.parent.acquireWaits.add(time.Since(start))
+	reason := AcquireFailTimeout
+	if ctx.Err() != nil {
+		// 调用方先放弃，不代表池饱和。
+		reason = AcquireFailCanceled
+	}
+	return nil, ep.parent.acquireFailed(ep.target.ID, reason, ep.timeoutError(acquireCtx.Err()))
+}
+
+// delivered 处理排队期间交付的连接；nil 表示目标已关闭或被隔离。
+func (ep *enclavePool) delivered(conn *connWrapper, start time.Time) (*Lease, error) {
+	if conn == nil {
+		if ep.quarantined() {
+			return nil, ep.parent.acquireFailed(ep.target.ID, AcquireFailQuarantined, ErrTargetQuarantined)
 		}
+		return nil, ep.parent.acquireFailed(ep.target.ID, AcquireFailDraining, ErrPoolDraining)
+	}
+	ep.observeAcquire(time.Since(start))
+	return ep.newLease(conn), nil
+}
+
+// usable 检查取出的空闲连接，不健康或超龄的连接就地关闭并返回 false。
+func (ep *enclavePool) usable(conn *connWrapper, cfg Config) bool {
+	switch {
+	case conn.unhealthy.Load():
+		conn.close()
+		ep.decrement()
+		go ep.maybeOpen(ep.parent.ctx)
+		return false
+	case conn.expired(cfg, time.Now()):
+		ep.recycle(conn, RecycleMaxAge)
+		return false
+	}
+	return true
+}
+
+// timeoutError 构造 Acquire 超时错误；最近一次拨号失败时附带其原文，直接指向根因。
+func (ep *enclavePool) timeoutError(cause error) error {
+	if last, ok := ep.dials.last(); ok && last.Error != "" {
+		return fmt.Errorf("%w: last dial error: %s (endpoint %s): %w", ErrAcquireTimeout, last.Error, last.Endpoint, cause)
+	}
+	return errors.Join(ErrAcquireTimeout, cause)
+}
+
+func (ep *enclavePool) observeAcquire(wait time.Duration) {
+	ep.parent.metrics.observeAcquire(ep.target.ID, wait)
+	ep.parent.acquireWaits.add(wait)
+}
+
+func (ep *enclavePool) waitersChangedLocked() {
+	ep.parent.metrics.setWaiters(ep.target.ID, float64(ep.waiters.Len()))
+}
+
+// reserveLocked 在持锁时占用一个连接名额，已达上限、已关闭或隔离中时返回 false。
+func (ep *enclavePool) reserveLocked() bool {
+	if ep.closed || ep.total >= ep.maxConns || ep.quarantinedLocked(time.Now()) {
+		return false
+	}
+	ep.total++
+	return true
+}
+
+// openForWaiters 为排队者使用已占用的名额后台建连，建好的连接交给队首。
+func (ep *enclavePool) openForWaiters() {
+	if err := ep.openReserved(ep.parent.ctx); err != nil {
+		ep.parent.logs.Warn(ep.target.ID, "open connection failed", "enclave", ep.target.ID, "err", err)
 	}
 }
 
 func (ep *enclavePool) maybeOpen(ctx context.Context) error {
 	ep.mu.Lock()
-	cfg := ep.parent.Config()
-	if ep.total >= cfg.MaxConns {
-		ep.mu.Unlock()
+	ok := ep.reserveLocked()
+	ep.mu.Unlock()
+	if !ok {
 		return nil
 	}
-	ep.total++
-	ep.mu.Unlock()
-	if err := ep.openConnection(ctx); err != nil {
+	return ep.openReserved(ctx)
+}
+
+// openReserved 为已占用的名额建立连接并放入池中，失败时归还名额。
+func (ep *enclavePool) openReserved(ctx context.Context) error {
+	conn, err := ep.openConnection(ctx)
+	if err != nil {
 		ep.decrement()
 		return err
 	}
+	ep.put(conn)
 	return nil
 }
 
-func (ep *enclavePool) openConnection(ctx context.Context) error {
+func (ep *enclavePool) openConnection(ctx context.Context) (*connWrapper, error) {
 	cfg := ep.parent.Config()
 	dialCtx, cancel := context.WithTimeout(ctx, cfg.DialTimeout)
 	defer cancel()
+	start := time.Now()
 	conn, err := ep.parent.dialer(dialCtx, ep.target, cfg)
+	var rejected *attestationError
+	if err == nil && ep.parent.attestation != nil {
+		if err = ep.parent.attest(ctx, conn); err != nil {
+			_ = conn.Close()
+			errors.As(err, &rejected)
+		}
+	}
+	if err == nil && cfg.Prewarm {
+		if err = ep.prewarm(ctx, conn, ep.target, cfg); err != nil {
+			_ = conn.Close()
+		}
+	}
+	attempt := DialAttempt{
+		At:             start,
+		Endpoint:       ep.target.Endpoint,
+		DurationMs:     float64(time.Since(start).Microseconds()) / 1000,
+		BreakerTripped: ep.breaker.State() != BreakerHealthy,
+	}
 	if err != nil {
-		return err
+		attempt.Error = err.Error()
+		attempt.ErrorKind = DialErrorKindOf(err)
 	}
-	wrapper := &connWrapper{conn: conn, pool: ep, target: ep.target}
+	ep.dials.add(attempt)
+	event := DialEvent{EnclaveID: ep.target.ID, Endpoint: ep.target.Endpoint, Duration: time.Since(start)}
+	if err != nil {
+		event.Err, event.Kind = err, attempt.ErrorKind
+		ep.parent.emit(func(l EventListener) { l.OnDialError(event) })
+	} else {
+		ep.parent.emit(func(l EventListener) { l.OnDial(event) })
+	}
+	if rejected != nil {
+		ep.quarantine(rejected)
+	}
+	if err != nil {
+		return nil, err
+	}
+	wrapper := &connWrapper{conn: conn, pool: ep, target: ep.target, created: time.Now(), ageJitter: rand.Float64()}
+	wrapper.idleSince.Store(wrapper.created.UnixNano())
 	wrapper.start()
-	select {
-	case ep.conns <- wrapper:
-		if cfg.MaxConns > 0 {
-			ep.parent.metrics.setActive(ep.target.ID, float64(ep.total))
+	if cfg.Prewarm && cfg.WarmStreams {
+		// 建流失败不影响连接入池，首次 Sign 时会重新建流。
+		if _, err := wrapper.warmStream(); err != nil {
+			ep.parent.logs.Warn(ep.target.ID, "prewarm sign stream failed", "enclave", ep.target.ID, "err", err)
 		}
-		return nil
-	case <-ep.parent.ctx.Done():
-		wrapper.close()
+	}
+	ep.mu.Lock()
+	total := ep.total
+	ep.mu.Unlock()
+	ep.parent.metrics.setActive(ep.target.ID, float64(total))
+	return wrapper, nil
+}
+
+// prewarm 在连接入池前发送一次健康检查 RPC，完成 HTTP/2 握手收尾并唤醒 Enclave 服务端。
+func (ep *enclavePool) prewarm(ctx context.Context, conn *grpc.ClientConn, target Target, cfg Config) error {
+	if err := ep.checkHealth(ctx, conn, target, cfg); err != nil {
+		return fmt.Errorf("prewarm health check: %w", err)
+	}
+	return nil
+}
+
+// put 将可用连接交给队首的 waiter，无人排队时放回空闲队尾；目标已关闭或超出上限时关闭连接。
+func (ep *enclavePool) put(conn *connWrapper) {
+	ep.mu.Lock()
+	if ep.closed || ep.total > ep.maxConns {
+		ep.mu.Unlock()
+		conn.close()
 		ep.decrement()
-		return ep.parent.ctx.Err()
+		return
+	}
+	if front := ep.waiters.Front(); front != nil {
+		w := ep.waiters.Remove(front).(*waiter)
+		w.elem = nil
+		w.conn <- conn
+		ep.waitersChangedLocked()
+		ep.mu.Unlock()
+		return
 	}
+	conn.idleSince.Store(time.Now().UnixNano())
+	ep.idle = append(ep.idle, conn)
+	ep.mu.Unlock()
 }
 
 func (ep *enclavePool) release(conn *connWrapper, err error) {
-	if err != nil {
+	if isConnectionError(err) {
 		conn.unhealthy.Store(true)
 	}
 	if conn.unhealthy.Load() {
@@ -481,23 +822,69 @@ func (ep *enclavePool) release(conn *connWrapper, err error) {
 		go ep.maybeOpen(ep.parent.ctx)
 		return
 	}
+	if conn.expired(ep.parent.Config(), time.Now()) {
+		ep.recycle(conn, RecycleMaxAge)
+		return
+	}
+	ep.put(conn)
+}
+
+// recycle 关闭一条健康但超龄的连接，并在低于 MinConns 时补齐。
+func (ep *enclavePool) recycle(conn *connWrapper, reason string) {
+	conn.close()
+	ep.decrement()
+	ep.parent.metrics.incRecycled(ep.target.ID, reason)
+	go ep.ensureMin(ep.parent.Config().MinConns)
+}
+
+// reap 检查池中的空闲连接：超龄的全部重建，空闲超时的只关闭 MinConns 以上的部分。
+// 空闲队列按归还顺序排列，空闲最久的连接先被回收。
+func (ep *enclavePool) reap(cfg Config, now time.Time) {
 	ep.mu.Lock()
 	if ep.closed {
 		ep.mu.Unlock()
+		return
+	}
+	var aged, stale []*connWrapper
+	kept := ep.idle[:0]
+	for _, conn := range ep.idle {
+		switch {
+		case conn.expired(cfg, now):
+			aged = append(aged, conn)
+		case cfg.MaxIdleTime > 0 && ep.total-len(stale) > cfg.MinConns &&
+			now.Sub(time.Unix(0, conn.idleSince.Load())) >= cfg.MaxIdleTime:
+			stale = append(stale, conn)
+		default:
+			kept = append(kept, conn)
+		}
+	}
+	clear(ep.idle[len(kept):])
+	ep.idle = kept
+	ep.mu.Unlock()
+	for _, conn := range stale {
 		conn.close()
 		ep.decrement()
-		return
+		ep.parent.metrics.incRecycled(ep.target.ID, RecycleIdle)
 	}
-	select {
-	case ep.conns <- conn:
-		// connection returned
-	default:
-		ep.mu.Unlock()
+	for _, conn := range aged {
 		conn.close()
 		ep.decrement()
-		return
+		ep.parent.metrics.incRecycled(ep.target.ID, RecycleMaxAge)
 	}
-	ep.mu.Unlock()
+	if len(aged) > 0 {
+		go ep.ensureMin(cfg.MinConns)
+	}
+}
+
+// isConnectionError 判断调用失败是否意味着连接本身不可用。
+func isConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if st, ok := status.FromError(err); ok && st.Code() == codes.Canceled {
+		return false
+	}
+	return true
 }
 
 func (ep *enclavePool) decrement() {
@@ -505,9 +892,10 @@ func (ep *enclavePool) decrement() {
 	if ep.total > 0 {
 		ep.total--
 	}
+	total := ep.total
 	ep.mu.Unlock()
 	if ep.parent != nil {
-		ep.parent.metrics.setActive(ep.target.ID, float64(ep.total))
+		ep.parent.metrics.setActive(ep.target.ID, float64(total))
 	}
 }
 
@@ -523,23 +911,29 @@ func (ep *enclavePool) close() error {
 		return nil
 	}
 	ep.closed = true
-	for {
-		select {
-		case conn := <-ep.conns:
-			if conn != nil {
-				conn.close()
-			}
-		default:
-			goto done
-		}
+	for _, conn := range ep.idle {
+		conn.close()
+	}
+	ep.idle = nil
+	// 唤醒排队者，使其立即失败而不是等到超时。
+	for e := ep.waiters.Front(); e != nil; e = e.Next() {
+		w := e.Value.(*waiter)
+		w.elem = nil
+		w.conn <- nil
 	}
-done:
+	ep.waiters.Init()
+	ep.waitersChangedLocked()
 	ep.total = 0
 	return nil
 }
 
-// defaultDialer 使用 gRPC keepalive 配置并启用双向流。
-func defaultDialer(ctx context.Context, target Target, cfg Config) (*grpc.ClientConn, error) {
+// defaultDial 为默认拨号器加上按方法与目标记录 Enclave RPC 耗时的拦截器。
+func (p *Pool) defaultDial(ctx context.Context, target Target, cfg Config) (*grpc.ClientConn, error) {
+	return defaultDialer(ctx, target, cfg, p.metrics.rpcDialOptions(target.ID)...)
+}
+
+// defaultDialer 使用 gRPC keepalive 配置并启用双向流，extra 追加在默认选项之后。
+func defaultDialer(ctx context.Context, target Target, cfg Config, extra ...grpc.DialOption) (*grpc.ClientConn, error) {
 	params := keepalive.ClientParameters{
 		Time:                cfg.KeepaliveTime,
 		Timeout:             cfg.KeepaliveTimeout,
@@ -549,17 +943,36 @@ func defaultDialer(ctx context.Context, target Target, cfg Config) (*grpc.Client
 	if methodTimeout <= 0 {
 		methodTimeout = 2 * time.Second
 	}
-	serviceConfig := fmt.Sprintf(`{"methodConfig":[{"name":[{"service":"%s"}],"timeout":"%s"}]}`, cfg.ServiceName, methodTimeout.String())
+	// service config 的时长只接受秒数形式（如 "0.25s"），不能用 Duration.String()。
+	serviceConfig := fmt.Sprintf(`{"methodConfig":[{"name":[{"service":"%s"}],"timeout":"%ss"}]}`,
+		cfg.ServiceName, strconv.FormatFloat(methodTimeout.Seconds(), 'f', -1, 64))
+	creds, err := TransportCredentials(target, cfg)
+	if err != nil {
+		return nil, err
+	}
+	var lastDialErr atomic.Pointer[DialError]
 	dopts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(creds),
 		grpc.WithKeepaliveParams(params),
 		grpc.WithDefaultServiceConfig(serviceConfig),
 		grpc.WithContextDialer(func(ctx context.Context, endpoint string) (net.Conn, error) {
-			return dialEndpoint(ctx, endpoint)
+			conn, err := dialEndpoint(ctx, endpoint)
+			var de *DialError
+			if errors.As(err, &de) {
+				lastDialErr.Store(de)
+			}
+			return conn, err
 		}),
 		grpc.WithBlock(),
 	}
-	return grpc.DialContext(ctx, target.Endpoint, dopts...)
+	dopts = append(dopts, TracingDialOptions()...)
+	dopts = append(dopts, extra...)
+	conn, err := grpc.DialContext(ctx, target.Endpoint, dopts...)
+	// 阻塞拨号超时只返回 ctx 错误，这里补上最后一次 DialError，使调用方能区分 Enclave 未就绪与配置错误。
+	if last := lastDialErr.Load(); err != nil && last != nil && DialErrorKindOf(err) == "" {
+		return nil, fmt.Errorf("%w: %w", err, last)
+	}
+	return conn, err
 }
 
 func dialEndpoint(ctx context.Context, endpoint string) (net.Conn, error) {
@@ -576,33 +989,3 @@ func dialEndpoint(ctx context.Context, endpoint string) (net.Conn, error) {
 		return (&net.Dialer{}).DialContext(ctx, "tcp", endpoint)
 	}
 }
-
-func dialVsock(ctx context.Context, target string) (net.Conn, error) {
-	parts := strings.Split(target, ":")
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid vsock endpoint: %s", target)
-	}
-	cid, err := strconv.ParseUint(parts[0], 10, 32)
-	if err != nil {
-		return nil, fmt.Errorf("invalid vsock cid: %w", err)
-	}
-	port, err := strconv.ParseUint(parts[1], 10, 32)
-	if err != nil {
-		return nil, fmt.Errorf("invalid vsock port: %w", err)
-	}
-	type dialResult struct {
-		conn net.Conn
-		err  error
-	}
-	resultCh := make(chan dialResult, 1)
-	go func() {
-		conn, dialErr := vsock.Dial(uint32(cid), uint32(port), nil)
-		resultCh <- dialResult{conn: conn, err: dialErr}
-	}()
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case res := <-resultCh:
-		return res.conn, res.err
-	}
-}
@@ -4,15 +4,25 @@ import (
 	"context"
 	"errors"
 	"net"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/infra/metricsopts"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 )
 
@@ -69,6 +79,10 @@ func TestPoolAcquireAndRelease(t *testing.T) {
 	resp, err := client.Sign(ctx, &signerv1.SignRequest{KeyId: "k1", Digest: make([]byte, 32)})
 	require.NoError(t, err)
 	require.Equal(t, "sig", string(resp.GetSignature()))
+	stats := pool.Stats()
+	require.Len(t, stats, 1)
+	require.Equal(t, "enclave-a", stats[0].ID)
+	require.Equal(t, 1, stats[0].InUse)
 	lease.Release(nil)
 	pool.Resize(2, 3)
 	require.Equal(t, 2, pool.Config().MinConns)
@@ -81,8 +95,10 @@ func TestPoolDrainPreventsAcquire(t *testing.T) {
 	cfg.MinConns = 1
 	cfg.MaxConns = 1
 	cfg.HealthCheckInterval = time.Second
+	var drained []string
 	pool, err := NewPool(cfg,
 		WithRegisterer(prometheus.NewRegistry()),
+		WithDrainHook(func(id string) { drained = append(drained, id) }),
 		WithDialer(func(ctx context.Context, target Target, _ Config) (*grpc.ClientConn, error) {
 			return grpc.DialContext(ctx, target.Endpoint,
 				grpc.WithTransportCredentials(insecure.NewCredentials()),
@@ -92,12 +108,144 @@ func TestPoolDrainPreventsAcquire(t *testing.T) {
 	require.NoError(t, err)
 	t.Cleanup(func() { _ = pool.Close() })
 	pool.RegisterTarget(Target{ID: "enclave-b", Endpoint: "buf"})
+	require.True(t, pool.Routable("enclave-b"))
 	require.NoError(t, pool.Drain("enclave-b"))
+	require.False(t, pool.Routable("enclave-b"))
+	require.False(t, pool.Routable("missing"))
 	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
 	defer cancel()
 	_, err = pool.Acquire(ctx, "enclave-b")
 	require.Error(t, err)
 	require.True(t, errors.Is(err, ErrPoolDraining))
+	require.Equal(t, []string{"enclave-b"}, drained)
+	require.ErrorIs(t, pool.Drain("missing"), ErrTargetNotFound)
+	require.Equal(t, []string{"enclave-b"}, drained)
+}
+
+func TestAcquireFailureReasons(t *testing.T) {
+	srv, lis := setupBufConn(t)
+	t.Cleanup(srv.Stop)
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	cfg.HealthCheckInterval = time.Second
+	cfg.AcquireTimeout = 50 * time.Millisecond
+	pool, err := NewPool(cfg,
+		WithRegisterer(prometheus.NewRegistry()),
+		WithDialer(func(ctx context.Context, target Target, _ Config) (*grpc.ClientConn, error) {
+			return grpc.DialContext(ctx, target.Endpoint,
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			)
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	pool.RegisterTarget(Target{ID: "enclave-a", Endpoint: "buf"})
+	pool.RegisterTarget(Target{ID: "enclave-b", Endpoint: "buf"})
+	ctx := context.Background()
+	failures := func(id, reason string) float64 {
+		return testutil.ToFloat64(pool.metrics.acquireFailures.WithLabelValues(id, reason))
+	}
+
+	_, err = pool.Acquire(ctx, "enclave-typo")
+	require.ErrorIs(t, err, ErrTargetNotFound)
+	require.Contains(t, err.Error(), "enclave-typo (target_not_found)")
+	require.Equal(t, 1.0, failures("enclave-typo", AcquireFailTargetNotFound))
+
+	// 唯一连接被借出后再次 Acquire 只能等待到超时。
+	lease, err := pool.Acquire(ctx, "enclave-a")
+	require.NoError(t, err)
+	_, err = pool.Acquire(ctx, "enclave-a")
+	require.ErrorIs(t, err, ErrAcquireTimeout)
+	require.Contains(t, err.Error(), "(timeout)")
+	require.Equal(t, 1.0, failures("enclave-a", AcquireFailTimeout))
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = pool.Acquire(canceled, "enclave-a")
+	require.Error(t, err)
+	require.Equal(t, 1.0, failures("enclave-a", AcquireFailCanceled))
+	require.Equal(t, 1.0, failures("enclave-a", AcquireFailTimeout))
+	lease.Release(nil)
+
+	require.NoError(t, pool.Drain("enclave-b"))
+	_, err = pool.Acquire(ctx, "enclave-b")
+	require.ErrorIs(t, err, ErrPoolDraining)
+	require.Contains(t, err.Error(), "enclave-b (draining)")
+	require.Equal(t, 1.0, failures("enclave-b", AcquireFailDraining))
+}
+
+func TestDialRingRecordsAttempts(t *testing.T) {
+	srv, lis := setupBufConn(t)
+	t.Cleanup(srv.Stop)
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	cfg.HealthCheckInterval = time.Second
+	cfg.AcquireTimeout = 20 * time.Millisecond
+	var refused atomic.Bool
+	refused.Store(true)
+	pool, err := NewPool(cfg,
+		WithRegisterer(prometheus.NewRegistry()),
+		WithDialer(func(ctx context.Context, target Target, _ Config) (*grpc.ClientConn, error) {
+			if refused.Load() {
+				return nil, errors.New("connection refused")
+			}
+			return grpc.DialContext(ctx, target.Endpoint,
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			)
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	pool.RegisterTarget(Target{ID: "enclave-b", Endpoint: "vsock:5:8000"})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err = pool.Acquire(ctx, "enclave-b")
+		require.ErrorIs(t, err, ErrAcquireTimeout)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Contains(t, err.Error(), "last dial error: connection refused (endpoint vsock:5:8000)")
+	}
+	st := pool.Stats()[0]
+	require.GreaterOrEqual(t, st.DialFailures, uint64(3))
+	require.Equal(t, int(st.DialFailures), st.ConsecutiveDialFailures)
+	failed := st.Dials
+	require.GreaterOrEqual(t, len(failed), 3)
+	for _, d := range failed {
+		require.Equal(t, "vsock:5:8000", d.Endpoint)
+		require.Equal(t, "connection refused", d.Error)
+		require.False(t, d.At.IsZero())
+	}
+
+	refused.Store(false)
+	lease, err := pool.Acquire(ctx, "enclave-b")
+	require.NoError(t, err)
+	lease.Release(nil)
+	st = pool.Stats()[0]
+	require.Empty(t, st.Dials[len(st.Dials)-1].Error)
+	require.Zero(t, st.ConsecutiveDialFailures)
+	require.GreaterOrEqual(t, st.DialFailures, uint64(3))
+}
+
+func TestDialRingWrapsAround(t *testing.T) {
+	var ring dialRing
+	_, ok := ring.last()
+	require.False(t, ok)
+	for i := 0; i < dialRingSize+5; i++ {
+		ring.add(DialAttempt{DurationMs: float64(i), Error: "refused"})
+	}
+	// 计数不受环形缓冲长度限制。
+	failures, consecutive := ring.failureCounts()
+	require.Equal(t, uint64(dialRingSize+5), failures)
+	require.Equal(t, dialRingSize+5, consecutive)
+	got := ring.snapshot()
+	require.Len(t, got, dialRingSize)
+	require.Equal(t, 5.0, got[0].DurationMs)
+	last, ok := ring.last()
+	require.True(t, ok)
+	require.Equal(t, float64(dialRingSize+4), last.DurationMs)
+	require.Equal(t, last, got[len(got)-1])
 }
 
 func TestConnPoolRace(t *testing.T) {
@@ -137,16 +285,201 @@ func TestConnPoolRace(t *testing.T) {
 	wg.Wait()
 }
 
+func newReapPool(t *testing.T, cfg Config) *Pool {
+	t.Helper()
+	srv, lis := setupBufConn(t)
+	t.Cleanup(srv.Stop)
+	pool, err := NewPool(cfg,
+		WithRegisterer(prometheus.NewRegistry()),
+		WithDialer(func(ctx context.Context, target Target, _ Config) (*grpc.ClientConn, error) {
+			return grpc.DialContext(ctx, target.Endpoint,
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			)
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	pool.RegisterTarget(Target{ID: "reap", Endpoint: "buf"})
+	return pool
+}
+
+func TestPoolReapsIdleConnsAboveMin(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 3
+	cfg.MaxIdleTime = 40 * time.Millisecond
+	pool := newReapPool(t, cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var leases []*Lease
+	for i := 0; i < 3; i++ {
+		lease, err := pool.Acquire(ctx, "reap")
+		require.NoError(t, err)
+		leases = append(leases, lease)
+	}
+	require.Equal(t, 3, pool.Stats()[0].Open)
+	for _, lease := range leases {
+		lease.Release(nil)
+	}
+	// 空闲超时后收缩回 MinConns，不会低于下限。
+	require.Eventually(t, func() bool { return pool.Stats()[0].Open == 1 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, 2.0, testutil.ToFloat64(pool.metrics.connsRecycled.WithLabelValues("reap", RecycleIdle)))
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, 1, pool.Stats()[0].Open)
+}
+
+func TestPoolRecyclesConnsPastMaxAge(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	cfg.MaxConnAge = 50 * time.Millisecond
+	pool := newReapPool(t, cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	lease, err := pool.Acquire(ctx, "reap")
+	require.NoError(t, err)
+	first := lease.Conn()
+	// 借出期间到期的连接在归还时重建。
+	time.Sleep(60 * time.Millisecond)
+	lease.Release(nil)
+	require.Equal(t, connectivity.Shutdown, first.GetState())
+
+	require.Eventually(t, func() bool {
+		lease, err := pool.Acquire(ctx, "reap")
+		if err != nil {
+			return false
+		}
+		defer lease.Release(nil)
+		return lease.Conn() != first
+	}, time.Second, 10*time.Millisecond)
+	require.GreaterOrEqual(t, testutil.ToFloat64(pool.metrics.connsRecycled.WithLabelValues("reap", RecycleMaxAge)), 1.0)
+	require.Equal(t, 1, pool.Stats()[0].Open)
+}
+
+func TestPoolReclaimsLeakedLeases(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	cfg.MaxLeaseHold = 40 * time.Millisecond
+	pool := newReapPool(t, cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	leaked, err := pool.Acquire(ctx, "reap")
+	require.NoError(t, err)
+	first := leaked.Conn()
+	// 未 Release 的租约超时后被收回，容量恢复，迟到的 Release 为空操作。
+	lease, err := pool.Acquire(ctx, "reap")
+	require.NoError(t, err)
+	require.NotSame(t, first, lease.Conn())
+	require.Equal(t, connectivity.Shutdown, first.GetState())
+	require.Equal(t, 1.0, testutil.ToFloat64(pool.metrics.leasesReclaimed.WithLabelValues("reap")))
+	leaked.Release(nil)
+	lease.Release(nil)
+	require.Equal(t, 1, pool.Stats()[0].Open)
+}
+
+func TestPoolServesWaitersInOrder(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	cfg.AcquireTimeout = 2 * time.Second
+	pool := newReapPool(t, cfg)
+	ctx := context.Background()
+	held, err := pool.Acquire(ctx, "reap")
+	require.NoError(t, err)
+
+	const waiters = 5
+	order := make(chan int, waiters)
+	var wg sync.WaitGroup
+	for i := 0; i < waiters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			lease, err := pool.Acquire(ctx, "reap")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			order <- i
+			lease.Release(nil)
+		}(i)
+		// 等上一个调用入队后再发起下一个，确定到达顺序。
+		require.Eventually(t, func() bool { return pool.Stats()[0].Waiters == i+1 }, time.Second, time.Millisecond)
+	}
+	require.Equal(t, float64(waiters), testutil.ToFloat64(pool.metrics.acquireWaiters.WithLabelValues("reap")))
+	held.Release(nil)
+	wg.Wait()
+	close(order)
+	var got []int
+	for i := range order {
+		got = append(got, i)
+	}
+	require.Equal(t, []int{0, 1, 2, 3, 4}, got)
+	require.Equal(t, 0.0, testutil.ToFloat64(pool.metrics.acquireWaiters.WithLabelValues("reap")))
+	require.Equal(t, 1, pool.Stats()[0].Open)
+}
+
+func TestPoolRejectsWhenWaiterQueueFull(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	cfg.MaxWaiters = 1
+	cfg.AcquireTimeout = 2 * time.Second
+	pool := newReapPool(t, cfg)
+	ctx := context.Background()
+	held, err := pool.Acquire(ctx, "reap")
+	require.NoError(t, err)
+	defer held.Release(nil)
+
+	queued := make(chan error, 1)
+	go func() {
+		_, err := pool.Acquire(ctx, "reap")
+		queued <- err
+	}()
+	require.Eventually(t, func() bool { return pool.Stats()[0].Waiters == 1 }, time.Second, time.Millisecond)
+
+	_, err = pool.Acquire(ctx, "reap")
+	require.ErrorIs(t, err, ErrAcquireQueueFull)
+	require.ErrorIs(t, err, ErrAcquireTimeout)
+	require.Equal(t, 1.0, testutil.ToFloat64(pool.metrics.acquireRejected.WithLabelValues("reap")))
+	require.Equal(t, 1.0, testutil.ToFloat64(pool.metrics.acquireFailures.WithLabelValues("reap", AcquireFailQueueFull)))
+
+	// 移除目标时排队者立即失败，而不是等到超时。
+	pool.RemoveTarget("reap")
+	select {
+	case err := <-queued:
+		require.ErrorIs(t, err, ErrPoolDraining)
+	case <-time.After(time.Second):
+		t.Fatal("queued acquire was not woken on close")
+	}
+}
+
 func TestLoadConfigFromEnv(t *testing.T) {
 	t.Setenv("SIGN_CONN_POOL_MIN", "8")
 	t.Setenv("SIGN_CONN_POOL_MAX", "16")
 	t.Setenv("SIGN_CONN_POOL_ACQUIRE_TIMEOUT", "500ms")
 	t.Setenv("SIGN_CONN_POOL_RETRY_JITTER", "0.1")
+	t.Setenv("SIGN_CONN_POOL_MAX_IDLE_TIME", "5m")
+	t.Setenv("SIGN_CONN_POOL_MAX_CONN_AGE", "1h")
+	t.Setenv("SIGN_CONN_POOL_MAX_WAITERS", "64")
+	t.Setenv("SIGN_CONN_POOL_TLS_CA_FILE", "/tls/enclave-ca.pem")
+	t.Setenv("SIGN_CONN_POOL_TLS_SPIFFE_ID", "spiffe://prod/enclave-signer")
+	t.Setenv("SIGN_CONN_POOL_WARM_STREAMS", "false")
+	t.Setenv("SIGN_CONN_POOL_PREWARM", "true")
+	t.Setenv("SIGN_CONN_POOL_HEALTH_METHOD", "/signer.v1.EnclaveService/Ping")
 	cfg := LoadConfigFromEnv()
 	require.Equal(t, 8, cfg.MinConns)
 	require.Equal(t, 16, cfg.MaxConns)
 	require.Equal(t, 500*time.Millisecond, cfg.AcquireTimeout)
 	require.InDelta(t, 0.1, cfg.Backoff.Jitter, 0.001)
+	require.Equal(t, 5*time.Minute, cfg.MaxIdleTime)
+	require.Equal(t, time.Hour, cfg.MaxConnAge)
+	require.Equal(t, 64, cfg.MaxWaiters)
+	require.Equal(t, TLSConfig{CAFile: "/tls/enclave-ca.pem", SPIFFEID: "spiffe://prod/enclave-signer"}, cfg.TLS)
+	require.False(t, cfg.WarmStreams)
+	require.True(t, DefaultConfig().WarmStreams)
+	require.True(t, cfg.Prewarm)
+	require.Equal(t, "/signer.v1.EnclaveService/Ping", cfg.HealthMethod)
 }
 
 func TestBackoffGrowth(t *testing.T) {
@@ -163,14 +496,307 @@ func TestBackoffGrowth(t *testing.T) {
 }
 
 func TestCircuitBreakerTransition(t *testing.T) {
-	cb := newCircuitBreaker(2, 10*time.Millisecond)
-	require.Equal(t, stateHealthy, cb.State())
+	var transitions []BreakerState
+	cb := newCircuitBreaker(BreakerConfig{Threshold: 2, Cooldown: 10 * time.Millisecond, Probes: 2},
+		func(_, to BreakerState) { transitions = append(transitions, to) })
+	require.Equal(t, BreakerHealthy, cb.State())
 	require.False(t, cb.Failure())
 	require.True(t, cb.Failure())
-	require.Equal(t, stateDegraded, cb.State())
+	require.Equal(t, BreakerDegraded, cb.State())
+	require.False(t, cb.Routable())
 	require.True(t, cb.Allow())
+	// 冷却期满后不会自行恢复，也不会被连接恢复的 Success 关闭。
+	require.False(t, cb.startProbing())
 	time.Sleep(20 * time.Millisecond)
-	require.True(t, cb.Allow())
+	cb.Success()
+	require.False(t, cb.Routable())
+
+	// half_open 中任一探测失败重新打开；连续成功 Probes 次才恢复。
+	require.True(t, cb.startProbing())
+	require.Equal(t, BreakerHalfOpen, cb.probeResult(true))
+	require.Equal(t, BreakerDegraded, cb.probeResult(false))
+	time.Sleep(20 * time.Millisecond)
+	require.True(t, cb.startProbing())
+	require.Equal(t, BreakerHalfOpen, cb.probeResult(true))
+	require.Equal(t, BreakerHealthy, cb.probeResult(true))
+	require.True(t, cb.Routable())
+
 	cb.Drain()
 	require.False(t, cb.Allow())
+	require.False(t, cb.Routable())
+	require.Equal(t, []BreakerState{BreakerDegraded, BreakerHalfOpen, BreakerDegraded, BreakerHalfOpen, BreakerHealthy, BreakerDraining}, transitions)
+}
+
+func TestLatencyWindowQuantile(t *testing.T) {
+	var w latencyWindow
+	require.Zero(t, w.quantile(0.95))
+	for i := 1; i <= 100; i++ {
+		w.add(time.Duration(i) * time.Millisecond)
+	}
+	require.Equal(t, 95*time.Millisecond, w.quantile(0.95))
+	// 超过窗口容量后只保留最近的样本。
+	for i := 0; i < acquireWindowSize; i++ {
+		w.add(time.Millisecond)
+	}
+	require.Equal(t, time.Millisecond, w.quantile(0.95))
+}
+
+func TestIsConnectionError(t *testing.T) {
+	require.False(t, isConnectionError(nil))
+	require.False(t, isConnectionError(context.Canceled))
+	require.False(t, isConnectionError(status.Error(codes.Canceled, "client went away")))
+	require.True(t, isConnectionError(status.Error(codes.Unavailable, "transport is closing")))
+	require.True(t, isConnectionError(errors.New("boom")))
+}
+
+func TestMetricsOptionsAllowSharedRegistry(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	primary, err := NewMetricsWithOptions(reg, metricsopts.Options{})
+	require.NoError(t, err)
+	shadow, err := NewMetricsWithOptions(reg, metricsopts.Options{
+		Namespace:   "shadow",
+		ConstLabels: prometheus.Labels{"deployment": "canary"},
+	})
+	require.NoError(t, err)
+	primary.incStreamReset("e1")
+	shadow.incStreamReset("e1")
+	shadow.incStreamReset("e1")
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	names := map[string]bool{}
+	for _, mf := range families {
+		names[mf.GetName()] = true
+	}
+	require.True(t, names["signer_enclave_pool_grpc_stream_resets_total"])
+	require.True(t, names["shadow_enclave_pool_grpc_stream_resets_total"])
+	require.Equal(t, 2.0, testutil.ToFloat64(shadow.streamResets.WithLabelValues("e1")))
+
+	_, err = NewMetricsWithOptions(reg, metricsopts.Options{Namespace: "shadow", ConstLabels: prometheus.Labels{"deployment": "canary"}})
+	require.ErrorContains(t, err, "shadow_enclave_pool_active_conns")
+}
+
+func TestPoolProbesBeforeClosingBreaker(t *testing.T) {
+	lis := bufconn.Listen(bufSize)
+	srv := grpc.NewServer()
+	signerv1.RegisterSignerServiceServer(srv, mockSignerServer{})
+	hs := health.NewServer()
+	hs.SetServingStatus("signer.v1.SignerService", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	cfg.Breaker = BreakerConfig{Threshold: 1, Cooldown: 20 * time.Millisecond, Probes: 2}
+	pool, err := NewPool(cfg,
+		WithRegisterer(prometheus.NewRegistry()),
+		WithDialer(func(ctx context.Context, target Target, _ Config) (*grpc.ClientConn, error) {
+			return grpc.DialContext(ctx, target.Endpoint,
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			)
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	pool.RegisterTarget(Target{ID: "probe", Endpoint: "buf"})
+	ep := pool.targets["probe"]
+
+	ep.breakerFailure()
+	require.False(t, pool.Routable("probe"))
+	// 健康检查失败时探测反复失败，熔断保持打开。
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(pool.metrics.breakerChanges.WithLabelValues("probe", string(BreakerHalfOpen))) >= 2
+	}, 2*time.Second, 5*time.Millisecond)
+	require.False(t, pool.Routable("probe"))
+
+	hs.SetServingStatus("signer.v1.SignerService", healthpb.HealthCheckResponse_SERVING)
+	require.Eventually(t, func() bool { return pool.Routable("probe") }, 2*time.Second, 5*time.Millisecond)
+	st := pool.Stats()[0]
+	require.Equal(t, string(BreakerHealthy), st.Breaker)
+	require.Zero(t, st.BreakerFailures)
+	require.Equal(t, "SERVING", st.LastHealthCheck.Status)
+	require.True(t, st.Available())
+	require.Equal(t, 1.0, testutil.ToFloat64(pool.metrics.breakerState.WithLabelValues("probe", string(BreakerHealthy))))
+	require.Equal(t, 0.0, testutil.ToFloat64(pool.metrics.breakerState.WithLabelValues("probe", string(BreakerDegraded))))
+}
+
+func TestPoolPrewarmsNewConns(t *testing.T) {
+	lis := bufconn.Listen(bufSize)
+	srv := grpc.NewServer()
+	signerv1.RegisterSignerServiceServer(srv, &echoStreamServer{cancelled: make(chan struct{})})
+	hs := health.NewServer()
+	hs.SetServingStatus("signer.v1.SignerService", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	cfg.Prewarm = true
+	pool, err := NewPool(cfg,
+		WithRegisterer(prometheus.NewRegistry()),
+		WithDialer(func(ctx context.Context, target Target, _ Config) (*grpc.ClientConn, error) {
+			return grpc.DialContext(ctx, target.Endpoint,
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			)
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	pool.RegisterTarget(Target{ID: "warm", Endpoint: "buf"})
+
+	// 预热失败的连接不入池，按拨号失败记录。
+	require.Eventually(t, func() bool { return pool.Stats()[0].DialFailures > 0 }, time.Second, 5*time.Millisecond)
+	st := pool.Stats()[0]
+	require.Zero(t, st.Idle)
+	require.Contains(t, st.Dials[len(st.Dials)-1].Error, "prewarm health check")
+
+	hs.SetServingStatus("signer.v1.SignerService", healthpb.HealthCheckResponse_SERVING)
+	require.Eventually(t, func() bool { return pool.Stats()[0].Idle == 1 }, 2*time.Second, 5*time.Millisecond)
+	// 启用 WarmStreams 时常驻流在入池前已建立，首个签名直接复用。
+	require.Equal(t, 1.0, testutil.ToFloat64(pool.metrics.streamsOpened.WithLabelValues("warm")))
+	lease, err := pool.Acquire(context.Background(), "warm")
+	require.NoError(t, err)
+	_, err = lease.Sign(context.Background(), &signerv1.SignRequest{KeyId: "k1", Digest: []byte{1}})
+	lease.Release(err)
+	require.NoError(t, err)
+	require.Equal(t, 1.0, testutil.ToFloat64(pool.metrics.streamsOpened.WithLabelValues("warm")))
+}
+
+func TestPoolRecordsEnclaveRPCMetrics(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	signerv1.RegisterSignerServiceServer(srv, mockSignerServer{})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	pool, err := NewPool(cfg, WithRegisterer(prometheus.NewRegistry()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	pool.RegisterTarget(Target{ID: "rpc", Endpoint: lis.Addr().String()})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	lease, err := pool.Acquire(ctx, "rpc")
+	require.NoError(t, err)
+	_, err = lease.Client().Create(ctx, &signerv1.CreateRequest{})
+	require.NoError(t, err)
+	// mock 未实现 SignStream，签名以 Unimplemented 失败。
+	_, signErr := lease.Sign(ctx, &signerv1.SignRequest{KeyId: "k1", Digest: []byte{1}})
+	require.Equal(t, codes.Unimplemented, status.Code(signErr))
+	lease.Release(nil)
+
+	hist := &dto.Metric{}
+	require.NoError(t, pool.metrics.rpcDuration.WithLabelValues("Create", "rpc", "OK").(prometheus.Histogram).Write(hist))
+	require.Equal(t, uint64(1), hist.GetHistogram().GetSampleCount())
+	require.Equal(t, 0.0, testutil.ToFloat64(pool.metrics.rpcErrors.WithLabelValues("Create", "rpc", "OK")))
+	require.Equal(t, 1.0, testutil.ToFloat64(pool.metrics.rpcErrors.WithLabelValues("SignStream", "rpc", "Unimplemented")))
+}
+
+func TestPoolUsesConfiguredHealthCheck(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	signerv1.RegisterSignerServiceServer(srv, mockSignerServer{})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	cfg.Prewarm = true
+	// 未注册 grpc.health.v1 的服务端，以领域 RPC 作为健康检查即可通过预热。
+	cfg.HealthMethod = "/signer.v1.SignerService/Create"
+	pool, err := NewPool(cfg, WithRegisterer(prometheus.NewRegistry()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	pool.RegisterTarget(Target{ID: "ping", Endpoint: lis.Addr().String()})
+	require.Eventually(t, func() bool { return pool.Stats()[0].Idle == 1 }, 2*time.Second, 5*time.Millisecond)
+	require.Equal(t, "OK", pool.Stats()[0].LastHealthCheck.Status)
+
+	// 自定义检查器优先于 HealthMethod，拒绝时连接不入池。
+	var ready atomic.Bool
+	custom, err := NewPool(cfg, WithRegisterer(prometheus.NewRegistry()),
+		WithHealthChecker(func(ctx context.Context, conn grpc.ClientConnInterface, target Target, cfg Config) (string, error) {
+			if !ready.Load() {
+				return "KEYSTORE_LOCKED", errors.New("key store not ready")
+			}
+			return GRPCHealthChecker(ctx, conn, target, cfg)
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = custom.Close() })
+	custom.RegisterTarget(Target{ID: "locked", Endpoint: lis.Addr().String()})
+	require.Eventually(t, func() bool { return custom.Stats()[0].DialFailures > 0 }, time.Second, 5*time.Millisecond)
+	st := custom.Stats()[0]
+	require.Zero(t, st.Idle)
+	require.Equal(t, "KEYSTORE_LOCKED", st.LastHealthCheck.Status)
+	require.Contains(t, st.Dials[len(st.Dials)-1].Error, "key store not ready")
+
+	// 检查器委托给 grpc.health.v1，而服务端未注册 Health，仍不健康。
+	ready.Store(true)
+	require.Eventually(t, func() bool {
+		last := custom.Stats()[0].LastHealthCheck
+		return last != nil && strings.Contains(last.Error, "Unimplemented")
+	}, 2*time.Second, 5*time.Millisecond)
+	require.Zero(t, custom.Stats()[0].Idle)
+}
+
+func TestPoolNotifiesEventListeners(t *testing.T) {
+	srv, lis := setupBufConn(t)
+	t.Cleanup(srv.Stop)
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	// mock 未注册 grpc.health.v1，健康检查必然失败，首次失败即熔断。
+	cfg.HealthCheckInterval = 20 * time.Millisecond
+	cfg.Breaker.Threshold = 1
+	cfg.Breaker.Cooldown = time.Minute
+	var mu sync.Mutex
+	var dials, dialErrors []DialEvent
+	var trips []BreakerEvent
+	var drained []string
+	pool, err := NewPool(cfg,
+		WithRegisterer(prometheus.NewRegistry()),
+		WithEventListener(EventListenerFuncs{
+			Dial:        func(e DialEvent) { mu.Lock(); dials = append(dials, e); mu.Unlock() },
+			DialError:   func(e DialEvent) { mu.Lock(); dialErrors = append(dialErrors, e); mu.Unlock() },
+			BreakerTrip: func(e BreakerEvent) { mu.Lock(); trips = append(trips, e); mu.Unlock() },
+			Drain:       func(id string) { mu.Lock(); drained = append(drained, id); mu.Unlock() },
+		}),
+		WithDialer(func(ctx context.Context, target Target, _ Config) (*grpc.ClientConn, error) {
+			if target.ID == "unreachable" {
+				return nil, &DialError{Kind: DialErrBadCID, Endpoint: target.Endpoint, Err: errors.New("no route to host")}
+			}
+			return grpc.DialContext(ctx, target.Endpoint,
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			)
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	pool.RegisterTarget(Target{ID: "ok", Endpoint: "buf"})
+	pool.RegisterTarget(Target{ID: "unreachable", Endpoint: "vsock://9:5000"})
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(dials) > 0 && len(dialErrors) > 0 && len(trips) > 0
+	}, 2*time.Second, 5*time.Millisecond)
+	require.NoError(t, pool.Drain("ok"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, "ok", dials[0].EnclaveID)
+	require.NoError(t, dials[0].Err)
+	require.Equal(t, "unreachable", dialErrors[0].EnclaveID)
+	require.Equal(t, DialErrBadCID, dialErrors[0].Kind)
+	require.Equal(t, BreakerEvent{EnclaveID: "ok", From: BreakerHealthy}, trips[0])
+	require.Equal(t, []string{"ok"}, drained)
 }
@@ -0,0 +1,96 @@
+package enclaveclient
+
+import (
+	"slices"
+	"sync"
+)
+
+// Placement 描述 key 的归属：Primary 为创建该 key 的 Enclave，Replicas 为持有其密文副本的 Enclave，按优先级排列。
+type Placement struct {
+	Primary  string
+	Replicas []string
+}
+
+// ReplicaGroup 在 Pool 之上记录 key 的归属，并结合连接池的健康状态为签名选择目标：
+// 优先主 Enclave，主 Enclave 不可路由时顺延到首个可路由的副本。
+// 归属只保存在内存中，重启后由上层回退到 hash 环路由；副本须已持有该 key 的密文才能接收签名。
+type ReplicaGroup struct {
+	pool *Pool
+
+	mu   sync.RWMutex
+	keys map[string]Placement
+}
+
+// NewReplicaGroup 创建共享 pool 健康状态的副本组。
+func NewReplicaGroup(pool *Pool) *ReplicaGroup {
+	return &ReplicaGroup{pool: pool, keys: make(map[string]Placement)}
+}
+
+// Assign 登记或覆盖 key 的归属；副本中与主 Enclave 重复或重复出现的 ID 被忽略。
+func (g *ReplicaGroup) Assign(keyID string, placement Placement) {
+	replicas := make([]string, 0, len(placement.Replicas))
+	for _, id := range placement.Replicas {
+		if id != "" && id != placement.Primary && !slices.Contains(replicas, id) {
+			replicas = append(replicas, id)
+		}
+	}
+	placement.Replicas = replicas
+	g.mu.Lock()
+	g.keys[keyID] = placement
+	g.mu.Unlock()
+}
+
+// Forget 删除 key 的归属。
+func (g *ReplicaGroup) Forget(keyID string) {
+	g.mu.Lock()
+	delete(g.keys, keyID)
+	g.mu.Unlock()
+}
+
+// Placement 返回 key 的归属副本，调用方可自由修改。
+func (g *ReplicaGroup) Placement(keyID string) (Placement, bool) {
+	g.mu.RLock()
+	placement, ok := g.keys[keyID]
+	g.mu.RUnlock()
+	placement.Replicas = slices.Clone(placement.Replicas)
+	return placement, ok
+}
+
+// Len 返回已登记归属的 key 数。
+func (g *ReplicaGroup) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.keys)
+}
+
+// Route 返回 key 的签名目标，未登记时 ok 为 false。主 Enclave 与全部副本都不可路由时仍返回主 Enclave，
+// 由 Acquire 给出 ENCLAVE_UNAVAILABLE。
+func (g *ReplicaGroup) Route(keyID string) (string, bool) {
+	placement, ok := g.Placement(keyID)
+	if !ok {
+		return "", false
+	}
+	if g.pool.Routable(placement.Primary) {
+		return placement.Primary, true
+	}
+	for _, id := range placement.Replicas {
+		if g.pool.Routable(id) {
+			return id, true
+		}
+	}
+	return placement.Primary, true
+}
+
+// Replica 返回 exclude 之外首个可路由的副本，用作对冲或重试的备选目标；没有时 ok 为 false。
+func (g *ReplicaGroup) Replica(keyID, exclude string) (string, bool) {
+	placement, ok := g.Placement(keyID)
+	if !ok {
+		return "", false
+	}
+	for _, id := range placement.Replicas {
+		if id != exclude && g.pool.Routable(id) {
+			return id, true
+		}
+	}
+	return "", false
+}
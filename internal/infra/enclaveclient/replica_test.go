@@ -0,0 +1,52 @@
+package enclaveclient
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaGroupRoutesToPrimaryThenReplicas(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	pool, err := NewPool(cfg, WithRegisterer(prometheus.NewRegistry()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	for _, id := range []string{"a", "b", "c"} {
+		pool.RegisterTarget(Target{ID: id, Endpoint: "127.0.0.1:1"})
+	}
+	group := NewReplicaGroup(pool)
+
+	_, ok := group.Route("k1")
+	require.False(t, ok)
+	group.Assign("k1", Placement{Primary: "a", Replicas: []string{"a", "b", "b", "", "c"}})
+	placement, ok := group.Placement("k1")
+	require.True(t, ok)
+	require.Equal(t, []string{"b", "c"}, placement.Replicas)
+	require.Equal(t, 1, group.Len())
+
+	target, ok := group.Route("k1")
+	require.True(t, ok)
+	require.Equal(t, "a", target)
+	replica, ok := group.Replica("k1", "a")
+	require.True(t, ok)
+	require.Equal(t, "b", replica)
+
+	require.NoError(t, pool.Drain("a"))
+	require.NoError(t, pool.Drain("b"))
+	target, _ = group.Route("k1")
+	require.Equal(t, "c", target)
+	_, ok = group.Replica("k1", "c")
+	require.False(t, ok)
+
+	// 全部不可路由时仍返回主 Enclave，由 Acquire 报错。
+	require.NoError(t, pool.Drain("c"))
+	target, _ = group.Route("k1")
+	require.Equal(t, "a", target)
+
+	group.Forget("k1")
+	_, ok = group.Route("k1")
+	require.False(t, ok)
+}
@@ -0,0 +1,254 @@
+package enclaveclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// acquireWindowSize 控制用于估算 p95 的最近 Acquire 样本数。
+	acquireWindowSize = 256
+	// dialRingSize 为每个目标保留的最近拨号记录数。
+	dialRingSize = 32
+)
+
+// latencyWindow 以环形缓冲保存最近的耗时样本，仅在需要时排序求分位数。
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples [acquireWindowSize]time.Duration
+	next    int
+	filled  bool
+	// added 为累计写入的样本数，用于判断窗口自上次读取后是否有新样本。
+	added uint64
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.mu.Lock()
+	w.added++
+	w.samples[w.next] = d
+	w.next++
+	if w.next == len(w.samples) {
+		w.next = 0
+		w.filled = true
+	}
+	w.mu.Unlock()
+}
+
+func (w *latencyWindow) count() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.added
+}
+
+func (w *latencyWindow) quantile(q float64) time.Duration {
+	w.mu.Lock()
+	n := w.next
+	if w.filled {
+		n = len(w.samples)
+	}
+	buf := make([]time.Duration, n)
+	copy(buf, w.samples[:n])
+	w.mu.Unlock()
+	if n == 0 {
+		return 0
+	}
+	sort.Slice(buf, func(i, j int) bool { return buf[i] < buf[j] })
+	idx := int(q*float64(n)+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= n {
+		idx = n - 1
+	}
+	return buf[idx]
+}
+
+// AcquireWaitP95 返回最近 Acquire 等待时间（含超时）的 p95，用于估算退避提示。
+func (p *Pool) AcquireWaitP95() time.Duration {
+	return p.acquireWaits.quantile(0.95)
+}
+
+// TargetStats 是单个 Enclave 连接池的瞬时状态。
+type TargetStats struct {
+	ID       string `json:"id"`
+	Endpoint string `json:"endpoint"`
+	// Open 为已建立的连接数，Idle 为空闲可借用的连接数，InUse = Open - Idle，
+	// Waiters 为排队等待连接的 Acquire 数。
+	Open     int `json:"open"`
+	Idle     int `json:"idle"`
+	InUse    int `json:"inUse"`
+	MaxConns int `json:"maxConns"`
+	Waiters  int `json:"waiters"`
+	// Breaker 为熔断状态（healthy/degraded/half_open/draining），BreakerSince 为进入该状态的时间；
+	// BreakerFailures 为 healthy 下的连续失败数，ProbeSuccesses 为 half_open 下已连续成功的探测数。
+	Breaker         string    `json:"breaker"`
+	BreakerSince    time.Time `json:"breakerSince"`
+	BreakerFailures int       `json:"breakerFailures"`
+	ProbeSuccesses  int       `json:"probeSuccesses,omitempty"`
+	// QuarantinedUntil 非 nil 表示目标因 attestation 失败被隔离至该时间，QuarantineReason 为失败原因。
+	QuarantinedUntil *time.Time `json:"quarantinedUntil,omitempty"`
+	QuarantineReason string     `json:"quarantineReason,omitempty"`
+	// LastHealthCheck 为任一连接最近一次健康检查的结果，尚未检查时为 nil。
+	LastHealthCheck *HealthCheckResult `json:"lastHealthCheck,omitempty"`
+	// DialFailures 为累计拨号失败次数，ConsecutiveDialFailures 为最近连续失败次数（成功后清零）。
+	DialFailures            uint64 `json:"dialFailures"`
+	ConsecutiveDialFailures int    `json:"consecutiveDialFailures"`
+	// Dials 为最近的拨号记录（由旧到新，最多 32 条），用于回溯抖动原因。
+	Dials []DialAttempt `json:"dials,omitempty"`
+	// Outlier 为离群检测状态，未启用时为 nil。被摘除的目标仍可用，只是不接收新路由。
+	Outlier *OutlierStats `json:"outlier,omitempty"`
+}
+
+// Available 报告目标能否承接流量：只有已摘除或 attestation 隔离中的目标不可用，
+// degraded/half_open 仍放行以便熔断器探测恢复。
+func (s TargetStats) Available() bool {
+	return s.Breaker != string(BreakerDraining) && s.QuarantinedUntil == nil
+}
+
+// HealthCheckResult 记录一次健康检查 RPC 的结果。
+type HealthCheckResult struct {
+	At time.Time `json:"at"`
+	// Status 为检查器返回的状态：grpc.health.v1 为服务状态（如 SERVING），领域健康 RPC 为 OK；RPC 失败时为空。
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// DialAttempt 记录一次拨号尝试。
+type DialAttempt struct {
+	At         time.Time `json:"at"`
+	Endpoint   string    `json:"endpoint"`
+	DurationMs float64   `json:"durationMs"`
+	Error      string    `json:"error,omitempty"`
+	// ErrorKind 为 vsock 拨号失败的分类（not_ready/bad_cid/...），其余失败为空。
+	ErrorKind DialErrorKind `json:"errorKind,omitempty"`
+	// BreakerTripped 表示拨号时熔断器不处于 healthy 状态。
+	BreakerTripped bool `json:"breakerTripped,omitempty"`
+}
+
+// dialRing 是定长环形缓冲，写入只做一次短暂加锁与结构体拷贝，内存占用固定。
+type dialRing struct {
+	mu      sync.Mutex
+	entries [dialRingSize]DialAttempt
+	next    int
+	filled  bool
+	// failures 为累计失败次数，consecutive 为最近连续失败次数，不受环形缓冲长度限制。
+	failures    uint64
+	consecutive int
+}
+
+func (r *dialRing) add(a DialAttempt) {
+	r.mu.Lock()
+	if a.Error != "" {
+		r.failures++
+		r.consecutive++
+	} else {
+		r.consecutive = 0
+	}
+	r.entries[r.next] = a
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.filled = true
+	}
+	r.mu.Unlock()
+}
+
+// snapshot 按时间顺序返回记录。
+func (r *dialRing) snapshot() []DialAttempt {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.filled {
+		return append([]DialAttempt(nil), r.entries[:r.next]...)
+	}
+	out := make([]DialAttempt, 0, len(r.entries))
+	out = append(out, r.entries[r.next:]...)
+	return append(out, r.entries[:r.next]...)
+}
+
+// failureCounts 返回累计与连续拨号失败次数。
+func (r *dialRing) failureCounts() (uint64, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failures, r.consecutive
+}
+
+// last 返回最近一次拨号记录。
+func (r *dialRing) last() (DialAttempt, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next == 0 && !r.filled {
+		return DialAttempt{}, false
+	}
+	idx := r.next - 1
+	if idx < 0 {
+		idx = len(r.entries) - 1
+	}
+	return r.entries[idx], true
+}
+
+// Stats 返回各目标的连接池状态（按 ID 排序），每个目标只短暂持有自身的锁。
+func (p *Pool) Stats() []TargetStats {
+	p.mu.RLock()
+	pools := make([]*enclavePool, 0, len(p.targets))
+	for _, ep := range p.targets {
+		pools = append(pools, ep)
+	}
+	p.mu.RUnlock()
+	stats := make([]TargetStats, 0, len(pools))
+	for _, ep := range pools {
+		stats = append(stats, ep.stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ID < stats[j].ID })
+	return stats
+}
+
+func (ep *enclavePool) stats() TargetStats {
+	ep.mu.Lock()
+	st := TargetStats{
+		ID:       ep.target.ID,
+		Endpoint: ep.target.Endpoint,
+		Open:     ep.total,
+		Idle:     len(ep.idle),
+		MaxConns: ep.maxConns,
+		Waiters:  ep.waiters.Len(),
+	}
+	if ep.lastHealth != nil {
+		health := *ep.lastHealth
+		st.LastHealthCheck = &health
+	}
+	if ep.quarantinedLocked(time.Now()) {
+		until := ep.quarantinedUntil
+		st.QuarantinedUntil = &until
+		st.QuarantineReason = ep.quarantineReason
+	}
+	ep.mu.Unlock()
+	st.InUse = max(st.Open-st.Idle, 0)
+	state, since, failures, probes := ep.breaker.snapshot()
+	st.Breaker, st.BreakerSince, st.BreakerFailures, st.ProbeSuccesses = string(state), since, failures, probes
+	st.Dials = ep.dials.snapshot()
+	st.DialFailures, st.ConsecutiveDialFailures = ep.dials.failureCounts()
+	st.Outlier = ep.outlierStats(time.Now())
+	return st
+}
+
+// DebugHandler 返回 /debug/enclaves 所需的 handler，输出 Stats。
+func (p *Pool) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(p.Stats())
+	})
+}
+
+// recordHealth 记录一次健康检查结果，供 Stats 展示。
+func (ep *enclavePool) recordHealth(status string, err error) {
+	result := &HealthCheckResult{At: time.Now(), Status: status}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	ep.mu.Lock()
+	ep.lastHealth = result
+	ep.mu.Unlock()
+}
@@ -0,0 +1,198 @@
+package enclaveclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// errStreamClosed 表示 Enclave 正常结束了常驻 SignStream，按连接级错误处理。
+var errStreamClosed = status.Error(codes.Unavailable, "enclave closed the sign stream")
+
+// Sign 在借出的连接上执行一次签名。启用 WarmStreams 时复用连接上常驻的 SignStream，
+// 以 sequence 关联响应；否则为本次请求新建一条流。req 不会被修改。上游已有 trace 时以 client span 记录签名耗时，
+// 耗时同时计入 enclave_rpc_duration_ms{method="SignStream"}。
+func (l *Lease) Sign(ctx context.Context, req *signerv1.SignRequest) (resp *signerv1.SignResponse, err error) {
+	warm := l.conn.pool.parent.Config().WarmStreams
+	start := time.Now()
+	defer func() { l.conn.pool.parent.metrics.observeRPC("SignStream", l.conn.target.ID, time.Since(start), err) }()
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		var span trace.Span
+		ctx, span = tracer.Start(ctx, "enclaveclient.Sign", trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrEnclaveID.String(l.conn.target.ID), attribute.Bool("enclave.warm_stream", warm)))
+		defer func() {
+			endSpan(span, err)
+			span.End()
+		}()
+	}
+	if !warm {
+		return signOnce(ctx, l.Client(), req)
+	}
+	return l.conn.signWarm(ctx, req)
+}
+
+// signOnce 新建 SignStream 发送单个请求后立即半关闭，ctx 取消时流随之释放。
+func signOnce(ctx context.Context, client signerv1.SignerServiceClient, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.SignStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Send(req); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return stream.Recv()
+}
+
+// warmStream 是连接上常驻的 SignStream：请求以递增的 sequence 发送，
+// 接收协程按回显的 sequence 把响应交给对应的等待者，未知 sequence 的响应（已放弃的请求）被丢弃。
+type warmStream struct {
+	stream signerv1.SignerService_SignStreamClient
+	cancel context.CancelFunc
+	// sendMu 串行化 Send，gRPC 流不允许并发发送。
+	sendMu sync.Mutex
+
+	mu      sync.Mutex
+	seq     uint64
+	pending map[uint64]chan signResult
+	// err 非 nil 表示流已结束，之后的请求需新建流。
+	err error
+}
+
+type signResult struct {
+	resp *signerv1.SignResponse
+	err  error
+}
+
+// warmStream 返回连接当前可用的常驻流，不存在或已结束时新建。
+func (cw *connWrapper) warmStream() (*warmStream, error) {
+	cw.streamMu.Lock()
+	defer cw.streamMu.Unlock()
+	if ws := cw.stream; ws != nil && ws.alive() {
+		return ws, nil
+	}
+	// 流的生命周期跟随连接而非单次请求，连接关闭或连接池关闭时结束。
+	ctx, cancel := context.WithCancel(cw.pool.parent.ctx)
+	stream, err := signerv1.NewSignerServiceClient(cw.conn).SignStream(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	ws := &warmStream{stream: stream, cancel: cancel, pending: make(map[uint64]chan signResult)}
+	cw.stream = ws
+	cw.pool.parent.metrics.incSignStreamOpened(cw.target.ID)
+	go ws.recvLoop()
+	return ws, nil
+}
+
+// signWarm 在常驻流上发送请求；取到的流恰好已结束时换新流重试一次。
+func (cw *connWrapper) signWarm(ctx context.Context, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+	for attempt := 0; ; attempt++ {
+		ws, err := cw.warmStream()
+		if err != nil {
+			return nil, err
+		}
+		r, sent := ws.sign(ctx, req)
+		if sent || attempt > 0 {
+			return r.resp, r.err
+		}
+	}
+}
+
+func (ws *warmStream) alive() bool {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.err == nil
+}
+
+// sign 登记并发送一个请求后等待响应；sent 为 false 表示流在登记前已结束，请求未发出。
+// ctx 结束时放弃该请求：流上已无其他等待者时关闭流，使 Enclave 感知取消，否则迟到的响应由接收协程丢弃。
+func (ws *warmStream) sign(ctx context.Context, req *signerv1.SignRequest) (_ signResult, sent bool) {
+	ws.mu.Lock()
+	if ws.err != nil {
+		err := ws.err
+		ws.mu.Unlock()
+		return signResult{err: err}, false
+	}
+	ws.seq++
+	seq := ws.seq
+	ch := make(chan signResult, 1)
+	ws.pending[seq] = ch
+	ws.mu.Unlock()
+
+	// 调用方的 sequence 用于其自身 SignStream 的回显，发往 Enclave 的是副本。
+	msg := proto.Clone(req).(*signerv1.SignRequest)
+	msg.Sequence = seq
+	ws.sendMu.Lock()
+	err := ws.stream.Send(msg)
+	ws.sendMu.Unlock()
+	// io.EOF 表示流已被对端结束，真实原因由接收协程经 ch 交付。
+	if err != nil && !errors.Is(err, io.EOF) {
+		ws.fail(err)
+	}
+	select {
+	case r := <-ch:
+		return r, true
+	case <-ctx.Done():
+		ws.abandon(seq, ctx.Err())
+		return signResult{err: status.FromContextError(ctx.Err()).Err()}, true
+	}
+}
+
+func (ws *warmStream) recvLoop() {
+	for {
+		resp, err := ws.stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errStreamClosed
+			}
+			ws.fail(err)
+			return
+		}
+		ws.mu.Lock()
+		ch, ok := ws.pending[resp.GetSequence()]
+		delete(ws.pending, resp.GetSequence())
+		ws.mu.Unlock()
+		if ok {
+			ch <- signResult{resp: resp}
+		}
+	}
+}
+
+// fail 结束流并把 err 交给所有等待中的请求。
+func (ws *warmStream) fail(err error) {
+	ws.mu.Lock()
+	if ws.err == nil {
+		ws.err = err
+	}
+	pending := ws.pending
+	ws.pending = make(map[uint64]chan signResult)
+	ws.mu.Unlock()
+	ws.cancel()
+	for _, ch := range pending {
+		ch <- signResult{err: err}
+	}
+}
+
+func (ws *warmStream) abandon(seq uint64, cause error) {
+	ws.mu.Lock()
+	delete(ws.pending, seq)
+	idle := len(ws.pending) == 0
+	ws.mu.Unlock()
+	if idle {
+		ws.fail(status.FromContextError(cause).Err())
+	}
+}
@@ -0,0 +1,126 @@
+package enclaveclient
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// echoStreamServer 在 SignStream 中回显 digest 与 sequence；digest 为 "stall" 时不响应，直到流被取消。
+type echoStreamServer struct {
+	mockSignerServer
+	opened    atomic.Int64
+	cancelled chan struct{}
+}
+
+func (s *echoStreamServer) SignStream(stream signerv1.SignerService_SignStreamServer) error {
+	s.opened.Add(1)
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if string(req.GetDigest()) == "stall" {
+			<-stream.Context().Done()
+			close(s.cancelled)
+			return stream.Context().Err()
+		}
+		if err := stream.Send(&signerv1.SignResponse{Signature: req.GetDigest(), Sequence: req.GetSequence()}); err != nil {
+			return err
+		}
+	}
+}
+
+func newStreamPool(t *testing.T, srv signerv1.SignerServiceServer, warm bool) *Pool {
+	t.Helper()
+	lis := bufconn.Listen(bufSize)
+	gs := grpc.NewServer()
+	signerv1.RegisterSignerServiceServer(gs, srv)
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	cfg.WarmStreams = warm
+	pool, err := NewPool(cfg,
+		WithRegisterer(prometheus.NewRegistry()),
+		WithDialer(func(ctx context.Context, target Target, _ Config) (*grpc.ClientConn, error) {
+			return grpc.DialContext(ctx, target.Endpoint,
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			)
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	pool.RegisterTarget(Target{ID: "enc", Endpoint: "buf"})
+	return pool
+}
+
+func signOnLease(ctx context.Context, t *testing.T, pool *Pool, req *signerv1.SignRequest) (*signerv1.SignResponse, error) {
+	t.Helper()
+	lease, err := pool.Acquire(context.Background(), "enc")
+	require.NoError(t, err)
+	resp, err := lease.Sign(ctx, req)
+	lease.Release(err)
+	return resp, err
+}
+
+func TestLeaseSignReusesWarmStream(t *testing.T) {
+	srv := &echoStreamServer{cancelled: make(chan struct{})}
+	pool := newStreamPool(t, srv, true)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		req := &signerv1.SignRequest{KeyId: "k1", Digest: []byte{byte(i)}, Sequence: 42}
+		resp, err := signOnLease(ctx, t, pool, req)
+		require.NoError(t, err)
+		require.Equal(t, []byte{byte(i)}, resp.GetSignature())
+		require.EqualValues(t, 42, req.GetSequence(), "caller's request must not be mutated")
+	}
+	require.EqualValues(t, 1, srv.opened.Load())
+	require.Equal(t, 1.0, testutil.ToFloat64(pool.metrics.streamsOpened.WithLabelValues("enc")))
+
+	// 调用方超时后流被关闭，Enclave 感知取消；下一次签名重建流。
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	lease, err := pool.Acquire(ctx, "enc")
+	require.NoError(t, err)
+	_, err = lease.Sign(timeoutCtx, &signerv1.SignRequest{KeyId: "k1", Digest: []byte("stall")})
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	select {
+	case <-srv.cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("enclave did not observe the cancellation")
+	}
+	lease.Release(err)
+	resp, err := signOnLease(ctx, t, pool, &signerv1.SignRequest{KeyId: "k1", Digest: []byte("after")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("after"), resp.GetSignature())
+	require.EqualValues(t, 2, srv.opened.Load())
+}
+
+func TestLeaseSignWithoutWarmStreams(t *testing.T) {
+	srv := &echoStreamServer{cancelled: make(chan struct{})}
+	pool := newStreamPool(t, srv, false)
+	for i := 0; i < 3; i++ {
+		_, err := signOnLease(context.Background(), t, pool, &signerv1.SignRequest{KeyId: "k1", Digest: []byte("d")})
+		require.NoError(t, err)
+	}
+	require.EqualValues(t, 3, srv.opened.Load())
+}
@@ -0,0 +1,119 @@
+package enclaveclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// TLSConfig 描述连接 Enclave 的客户端 TLS。全部字段为空表示明文，适用于 vsock/unix 等本机通道；
+// 跨主机部署的 Enclave 应至少配置 CAFile 或 SPIFFEID。
+type TLSConfig struct {
+	// CAFile 为校验服务端证书的 CA（PEM，可含多张）；为空时使用系统根证书。
+	CAFile string `json:"caFile,omitempty"`
+	// CertFile 与 KeyFile 为可选的客户端证书，用于 mTLS。
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+	// ServerName 覆盖 SNI 与主机名校验使用的名称，默认取 endpoint 的主机部分。
+	ServerName string `json:"serverName,omitempty"`
+	// SPIFFEID 非空时要求服务端证书的 URI SAN 含该 ID（如 spiffe://prod/enclave-signer），
+	// 并以此代替主机名校验；证书链仍须由 CAFile 签发。
+	SPIFFEID string `json:"spiffeId,omitempty"`
+}
+
+// Enabled 表示是否启用 TLS。
+func (c TLSConfig) Enabled() bool {
+	return c.CAFile != "" || c.CertFile != "" || c.SPIFFEID != "" || c.ServerName != ""
+}
+
+// TransportCredentials 返回连接 target 使用的 gRPC 传输凭证，自定义 Dialer 也应通过它取得凭证：
+// cfg.Credentials 非 nil 时优先使用（如 ALTS）；其次是 target.TLS，再次是 cfg.TLS；均未启用时为明文。
+// 证书文件在每次拨号时读取，轮换后新建的连接即生效。
+func TransportCredentials(target Target, cfg Config) (credentials.TransportCredentials, error) {
+	if cfg.Credentials != nil {
+		return cfg.Credentials(target)
+	}
+	tlsCfg := cfg.TLS
+	if target.TLS != nil {
+		tlsCfg = *target.TLS
+	}
+	if !tlsCfg.Enabled() {
+		return insecure.NewCredentials(), nil
+	}
+	conf, err := tlsCfg.clientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("enclave %s tls: %w", target.ID, err)
+	}
+	return credentials.NewTLS(conf), nil
+}
+
+func (c TLSConfig) clientConfig() (*tls.Config, error) {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, errors.New("client cert and key files must be set together")
+	}
+	conf := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: c.ServerName}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA: %w", err)
+		}
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("CA file contains no certificates")
+		}
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client key pair: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	if c.SPIFFEID != "" {
+		// SPIFFE 证书通常不含 DNS SAN：关闭内置主机名校验，改为手动校验证书链与 URI SAN。
+		roots := conf.RootCAs
+		conf.InsecureSkipVerify = true
+		conf.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifySPIFFE(rawCerts, roots, c.SPIFFEID)
+		}
+	}
+	return conf, nil
+}
+
+// verifySPIFFE 以 roots 校验证书链（roots 为 nil 时使用系统根证书），并要求叶子证书的 URI SAN 含 id。
+func verifySPIFFE(rawCerts [][]byte, roots *x509.CertPool, id string) error {
+	if len(rawCerts) == 0 {
+		return errors.New("enclave presented no certificate")
+	}
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("parse enclave certificate: %w", err)
+		}
+		certs[i] = cert
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	leaf := certs[0]
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}); err != nil {
+		return fmt.Errorf("verify enclave certificate: %w", err)
+	}
+	for _, uri := range leaf.URIs {
+		if uri.String() == id {
+			return nil
+		}
+	}
+	return fmt.Errorf("enclave certificate does not carry SPIFFE ID %s", id)
+}
@@ -0,0 +1,163 @@
+package enclaveclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issueCert 签发测试证书，parent 为 nil 时自签为 CA；spiffeID 非空时写入 URI SAN。
+func issueCert(t *testing.T, parent *testCert, spiffeID string) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "enclave-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if spiffeID != "" {
+		uri, err := url.Parse(spiffeID)
+		require.NoError(t, err)
+		tmpl.URIs = []*url.URL{uri}
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func writeCAFile(t *testing.T, ca *testCert) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600))
+	return path
+}
+
+// serveTLSEnclave 在本地端口上以 TLS 提供 SignerService，返回监听地址。
+func serveTLSEnclave(t *testing.T, leaf *testCert) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{leaf.cert.Raw}, PrivateKey: leaf.key}},
+	})))
+	signerv1.RegisterSignerServiceServer(srv, mockSignerServer{})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func TestTransportCredentialsSelection(t *testing.T) {
+	ca := issueCert(t, nil, "")
+	caFile := writeCAFile(t, ca)
+
+	creds, err := TransportCredentials(Target{ID: "a"}, Config{})
+	require.NoError(t, err)
+	require.Equal(t, "insecure", creds.Info().SecurityProtocol)
+
+	cfg := Config{TLS: TLSConfig{CAFile: caFile}}
+	creds, err = TransportCredentials(Target{ID: "a"}, cfg)
+	require.NoError(t, err)
+	require.Equal(t, "tls", creds.Info().SecurityProtocol)
+
+	// 目标级配置覆盖全局配置，零值表示该目标走明文。
+	creds, err = TransportCredentials(Target{ID: "local", TLS: &TLSConfig{}}, cfg)
+	require.NoError(t, err)
+	require.Equal(t, "insecure", creds.Info().SecurityProtocol)
+
+	_, err = TransportCredentials(Target{ID: "a", TLS: &TLSConfig{CertFile: "client.crt"}}, cfg)
+	require.ErrorContains(t, err, "enclave a tls")
+
+	cfg.Credentials = func(Target) (credentials.TransportCredentials, error) { return insecure.NewCredentials(), nil }
+	creds, err = TransportCredentials(Target{ID: "a"}, cfg)
+	require.NoError(t, err)
+	require.Equal(t, "insecure", creds.Info().SecurityProtocol)
+}
+
+func TestPoolDialsEnclaveOverTLSWithSPIFFEID(t *testing.T) {
+	const spiffeID = "spiffe://aegis.test/enclave-signer"
+	ca := issueCert(t, nil, "")
+	// 叶子证书只有 URI SAN，没有可匹配 127.0.0.1 的 IP/DNS SAN。
+	addr := serveTLSEnclave(t, issueCert(t, ca, spiffeID))
+
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	cfg.DialTimeout = 2 * time.Second
+	cfg.TLS = TLSConfig{CAFile: writeCAFile(t, ca), SPIFFEID: spiffeID}
+	pool, err := NewPool(cfg, WithRegisterer(prometheus.NewRegistry()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	pool.RegisterTarget(Target{ID: "remote", Endpoint: addr})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	lease, err := pool.Acquire(ctx, "remote")
+	require.NoError(t, err)
+	resp, err := lease.Client().Sign(ctx, &signerv1.SignRequest{KeyId: "k1", Digest: make([]byte, 32)})
+	lease.Release(err)
+	require.NoError(t, err)
+	require.Equal(t, "sig", string(resp.GetSignature()))
+
+	// SPIFFE ID 不符或未按主机名签发时握手失败，不会退回明文。
+	for _, tlsCfg := range []TLSConfig{
+		{CAFile: cfg.TLS.CAFile, SPIFFEID: "spiffe://aegis.test/other"},
+		{CAFile: cfg.TLS.CAFile},
+	} {
+		dialCfg := cfg
+		dialCfg.TLS = tlsCfg
+		dialCtx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		_, err := defaultDialer(dialCtx, Target{ID: "remote", Endpoint: addr}, dialCfg)
+		cancel()
+		require.Error(t, err)
+	}
+}
+
+func TestVerifySPIFFERejectsUntrustedChain(t *testing.T) {
+	const spiffeID = "spiffe://aegis.test/enclave-signer"
+	trusted, rogue := issueCert(t, nil, ""), issueCert(t, nil, "")
+	leaf := issueCert(t, rogue, spiffeID)
+	roots := x509.NewCertPool()
+	roots.AddCert(trusted.cert)
+	require.ErrorContains(t, verifySPIFFE([][]byte{leaf.cert.Raw}, roots, spiffeID), "verify enclave certificate")
+
+	roots.AddCert(rogue.cert)
+	require.NoError(t, verifySPIFFE([][]byte{leaf.cert.Raw}, roots, spiffeID))
+	require.ErrorContains(t, verifySPIFFE([][]byte{leaf.cert.Raw}, roots, "spiffe://aegis.test/other"), "SPIFFE ID")
+}
@@ -0,0 +1,64 @@
+package enclaveclient
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aegis-sign/wallet/internal/infra/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// tracer 取自全局 TracerProvider，未安装时为空操作。
+var tracer = otel.Tracer("github.com/aegis-sign/wallet/internal/infra/enclaveclient")
+
+const attrEnclaveID = attribute.Key("enclave.id")
+
+// TracingDialOptions 返回为 Enclave RPC 传播 trace 上下文的拦截器，默认拨号器已启用，自定义 Dialer 应一并加上：
+// unary 调用在上游已有 trace 时创建 client span；流只传播上下文，签名耗时由 Lease.Sign 的 span 记录，
+// 避免常驻流产生与连接同寿命的 span。
+func TracingDialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(tracingUnaryInterceptor),
+		grpc.WithChainStreamInterceptor(tracingStreamInterceptor),
+	}
+}
+
+func tracingUnaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	// 健康检查等后台调用不属于任何请求，不单独成 trace。
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	service, name, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	ctx, span := tracer.Start(ctx, strings.TrimPrefix(method, "/"), trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", name),
+			attribute.String("server.address", cc.Target()),
+		))
+	defer span.End()
+	err := invoker(tracing.Inject(ctx), method, req, reply, cc, opts...)
+	endSpan(span, err)
+	return err
+}
+
+func tracingStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = tracing.Inject(ctx)
+	}
+	return streamer(ctx, desc, cc, method, opts...)
+}
+
+// endSpan 记录 gRPC 状态码，失败时标记 span 为错误。
+func endSpan(span trace.Span, err error) {
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(status.Code(err))))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+}
@@ -0,0 +1,84 @@
+package enclaveclient
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	signerv1 "github.com/aegis-sign/wallet/docs/api/gen/go"
+	"github.com/aegis-sign/wallet/internal/infra/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// traceServer 记录 Create 收到的 traceparent。
+type traceServer struct {
+	echoStreamServer
+	traceparent chan string
+}
+
+func (s *traceServer) Create(ctx context.Context, _ *signerv1.CreateRequest) (*signerv1.CreateResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.traceparent <- tracing.MetadataCarrier(md).Get("traceparent")
+	return &signerv1.CreateResponse{KeyId: "k1"}, nil
+}
+
+func TestTracedAcquireAndRPCShareTrace(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	srv := &traceServer{echoStreamServer: echoStreamServer{cancelled: make(chan struct{})}, traceparent: make(chan string, 1)}
+	lis := bufconn.Listen(bufSize)
+	gs := grpc.NewServer()
+	signerv1.RegisterSignerServiceServer(gs, srv)
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+	cfg := DefaultConfig()
+	cfg.MinConns = 1
+	cfg.MaxConns = 1
+	pool, err := NewPool(cfg,
+		WithRegisterer(prometheus.NewRegistry()),
+		WithDialer(func(ctx context.Context, target Target, _ Config) (*grpc.ClientConn, error) {
+			opts := append([]grpc.DialOption{
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			}, TracingDialOptions()...)
+			return grpc.DialContext(ctx, target.Endpoint, opts...)
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Close() })
+	pool.RegisterTarget(Target{ID: "enc", Endpoint: "buf"})
+
+	ctx, root := tp.Tracer("test").Start(context.Background(), "request")
+	lease, err := pool.Acquire(ctx, "enc")
+	require.NoError(t, err)
+	_, err = lease.Sign(ctx, &signerv1.SignRequest{KeyId: "k1", Digest: []byte{1}})
+	require.NoError(t, err)
+	_, err = lease.Client().Create(ctx, &signerv1.CreateRequest{})
+	require.NoError(t, err)
+	lease.Release(nil)
+	root.End()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range rec.Ended() {
+		require.Equal(t, root.SpanContext().TraceID(), s.SpanContext().TraceID(), s.Name())
+		spans[s.Name()] = s
+	}
+	for _, name := range []string{"enclaveclient.Acquire", "enclaveclient.Sign", "signer.v1.SignerService/Create"} {
+		require.Contains(t, spans, name)
+		require.Equal(t, root.SpanContext().SpanID(), spans[name].Parent().SpanID(), name)
+	}
+	// Enclave 收到的 traceparent 指向 client span。
+	create := spans["signer.v1.SignerService/Create"].SpanContext()
+	require.Equal(t, "00-"+create.TraceID().String()+"-"+create.SpanID().String()+"-01", <-srv.traceparent)
+}
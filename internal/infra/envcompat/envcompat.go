@@ -0,0 +1,340 @@
+// Package envcompat 在启动时兼容旧环境变量名，并检测疑似拼写错误的配置项。
+package envcompat
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Alias 描述一次环境变量改名，Old 与 New 语义与单位相同。
+type Alias struct {
+	Old string
+	New string
+}
+
+// DefaultAliases 是历史上改过名的变量。
+var DefaultAliases = []Alias{
+	{Old: "SIGNER_ADDR", New: "SIGNER_HTTP_ADDR"},
+	{Old: "SIGN_CONN_POOL_MIN_CONNS", New: "SIGN_CONN_POOL_MIN"},
+	{Old: "SIGN_CONN_POOL_MAX_CONNS", New: "SIGN_CONN_POOL_MAX"},
+	{Old: "UNLOCK_QUEUE_SIZE", New: "UNLOCK_MAX_QUEUE"},
+	{Old: "UNLOCK_WORKER_COUNT", New: "UNLOCK_WORKERS"},
+}
+
+// DefaultPrefixes 是需要做拼写检查的变量前缀。
+var DefaultPrefixes = []string{"SIGNER_", "UNLOCK_", "SIGN_CONN_POOL_"}
+
+// SupportedKeys 列出当前会被读取的全部变量；新增配置项时需同步追加。
+var SupportedKeys = []string{
+	"SIGNER_ADMIN_ADDR",
+	"SIGNER_ADMIN_CREDENTIALS_FILE",
+	"SIGNER_ADMIN_GRPC_ADDR",
+	"SIGNER_ADMIN_ROLE",
+	"SIGNER_AUDIT_FAIL_CLOSED",
+	"SIGNER_AUDIT_FILE",
+	"SIGNER_AUDIT_FILE_SYNC",
+	"SIGNER_AUDIT_SINKS",
+	"SIGNER_AUTH_CREDENTIALS_FILE",
+	"SIGNER_BACKUP_ADMIN_ROLE",
+	"SIGNER_BACKUP_EXPORT",
+	"SIGNER_BATCH_CONCURRENCY",
+	"SIGNER_BATCH_MAX_ITEMS",
+	"SIGNER_BATCH_STREAM_MAX_ITEMS",
+	"SIGNER_CALL_TIMEOUT_MS",
+	"SIGNER_DEADLINE_EWMA_ALPHA",
+	"SIGNER_DEADLINE_MIN_SAMPLES",
+	"SIGNER_DEADLINE_PRECHECK",
+	"SIGNER_DEADLINE_SAFETY_MARGIN_MS",
+	"SIGNER_DRAIN_TIMEOUT_MS",
+	"SIGNER_ENCLAVES",
+	"SIGNER_ENCLAVE_ATTESTATION",
+	"SIGNER_ENCLAVE_ATTESTATION_PCRS",
+	"SIGNER_ENCLAVE_ATTESTATION_QUARANTINE_MS",
+	"SIGNER_ENCLAVE_ATTESTATION_ROOT_FILE",
+	"SIGNER_ENCLAVE_ATTESTATION_TIMEOUT_MS",
+	"SIGNER_ENCLAVE_DISCOVERY",
+	"SIGNER_ENCLAVE_DISCOVERY_INTERVAL_MS",
+	"SIGNER_ENCLAVE_DISCOVERY_TARGET",
+	"SIGNER_ENCLAVE_WEIGHTS",
+	"SIGNER_ENV_STRICT",
+	"SIGNER_GRPC_ADDR",
+	"SIGNER_GRPC_AUTH_TOKENS",
+	"SIGNER_GRPC_HEALTH_INTERVAL_MS",
+	"SIGNER_GRPC_INTERCEPTORS",
+	"SIGNER_GRPC_MAX_CONCURRENT_STREAMS",
+	"SIGNER_GRPC_MAX_CONNS",
+	"SIGNER_GRPC_MAX_CONN_AGE",
+	"SIGNER_GRPC_MAX_CONN_AGE_GRACE",
+	"SIGNER_GRPC_MAX_CONN_IDLE",
+	"SIGNER_GRPC_REFLECTION",
+	"SIGNER_HEDGE_DELAY_MS",
+	"SIGNER_HEDGE_ENABLED",
+	"SIGNER_HEDGE_MAX_IN_FLIGHT",
+	"SIGNER_HEDGE_MIN_DELAY_MS",
+	"SIGNER_HEDGE_QUANTILE",
+	"SIGNER_HTTP_ADDR",
+	"SIGNER_HTTP_ALLOW_UNKNOWN_FIELDS",
+	"SIGNER_HTTP_COMPRESSION",
+	"SIGNER_HTTP_COMPRESSION_MIN_BYTES",
+	"SIGNER_HTTP_DISABLE_HTTP2",
+	"SIGNER_HTTP_GATEWAY",
+	"SIGNER_HTTP_IDLE_TIMEOUT",
+	"SIGNER_HTTP_INTERNAL_ADDR",
+	"SIGNER_HTTP_LEGACY_ROUTES",
+	"SIGNER_HTTP_LEGACY_SUNSET",
+	"SIGNER_HTTP_LISTENERS",
+	"SIGNER_HTTP_MAX_BATCH_BODY_BYTES",
+	"SIGNER_HTTP_MAX_BODY_BYTES",
+	"SIGNER_HTTP_MAX_CONNS",
+	"SIGNER_HTTP_MAX_HEADER_BYTES",
+	"SIGNER_HTTP_MAX_STREAM_BODY_BYTES",
+	"SIGNER_HTTP_READ_HEADER_TIMEOUT",
+	"SIGNER_HTTP_READ_TIMEOUT",
+	"SIGNER_HTTP_WEBSOCKET",
+	"SIGNER_HTTP_WEBSOCKET_ALLOWED_ORIGINS",
+	"SIGNER_HTTP_WEBSOCKET_MAX_MESSAGE_BYTES",
+	"SIGNER_HTTP_WEBSOCKET_PING_INTERVAL_MS",
+	"SIGNER_HTTP_WEBSOCKET_WRITE_TIMEOUT_MS",
+	"SIGNER_HTTP_WRITE_TIMEOUT",
+	"SIGNER_IDEMPOTENCY_ENABLED",
+	"SIGNER_IDEMPOTENCY_MAX_KEYS",
+	"SIGNER_IDEMPOTENCY_TTL_MS",
+	"SIGNER_IMPORT_RATE_BURST",
+	"SIGNER_IMPORT_RATE_LIMIT",
+	"SIGNER_KEY_CACHE",
+	"SIGNER_KEY_QUOTA",
+	"SIGNER_KEY_RATE_BURST",
+	"SIGNER_KEY_RATE_LIMIT",
+	"SIGNER_KEY_USAGE_MAX_KEYS",
+	"SIGNER_KEY_USAGE_SNAPSHOT_INTERVAL_MS",
+	"SIGNER_KEY_USAGE_SNAPSHOT_PATH",
+	"SIGNER_METRICS_CONST_LABELS",
+	"SIGNER_METRICS_NAMESPACE",
+	"SIGNER_POLICY_FILE",
+	"SIGNER_READY_QUEUE_SATURATION",
+	"SIGNER_READ_ONLY",
+	"SIGNER_REPLICA_COUNT",
+	"SIGNER_REPLICA_GROUPS",
+	"SIGNER_RETRY_HINT_MAX_MS",
+	"SIGNER_RETRY_HINT_MIN_MS",
+	"SIGNER_RETRY_BACKOFF_MS",
+	"SIGNER_RETRY_BUDGET_BURST",
+	"SIGNER_RETRY_BUDGET_RATIO",
+	"SIGNER_RETRY_CODES",
+	"SIGNER_RETRY_ENABLED",
+	"SIGNER_RETRY_MAX_ATTEMPTS",
+	"SIGNER_RETRY_SIGN_FAILOVER",
+	"SIGNER_SELFCHECK_CURVE",
+	"SIGNER_SELFCHECK_INTERVAL_MS",
+	"SIGNER_SHADOW_SAMPLE_RATE",
+	"SIGNER_SHADOW_TIMEOUT_MS",
+	"SIGNER_SHADOW_URL",
+	"SIGNER_STATUS_MAX_KEYS",
+	"SIGNER_STICKY_FAILOVER",
+	"SIGNER_STICKY_VNODES",
+	"SIGNER_STREAM_MAX_INFLIGHT",
+	"SIGNER_STREAM_PERMIT_MULTIPLIER",
+	"SIGNER_TENANT_KEYSPACES",
+	"SIGNER_TENANT_QUOTA",
+	"SIGNER_TENANT_QUOTA_OVERRIDES",
+	"SIGNER_TENANT_RATE_BURST",
+	"SIGNER_TENANT_RATE_LIMIT",
+	"SIGNER_TENANT_RATE_OVERRIDES",
+	"SIGNER_TLS_CERT_FILE",
+	"SIGNER_TLS_CLIENT_CA_FILE",
+	"SIGNER_TLS_KEY_FILE",
+	"SIGNER_TLS_RELOAD_INTERVAL",
+	"SIGNER_TLS_REQUIRE_CLIENT_CERT",
+	"SIGNER_TRACING_ENDPOINT",
+	"SIGNER_TRACING_INSECURE",
+	"SIGNER_TRACING_SAMPLE_RATIO",
+	"SIGNER_TRACING_SERVICE_NAME",
+	"SIGNER_USAGE_ACCOUNTING",
+	"SIGNER_USAGE_MAX_KEYS",
+	"SIGNER_USAGE_WINDOW_MS",
+	"SIGN_CONN_POOL_ACQUIRE_TIMEOUT",
+	"SIGN_CONN_POOL_AUTOSCALE",
+	"SIGN_CONN_POOL_AUTOSCALE_CEILING",
+	"SIGN_CONN_POOL_AUTOSCALE_FLOOR",
+	"SIGN_CONN_POOL_AUTOSCALE_INTERVAL",
+	"SIGN_CONN_POOL_AUTOSCALE_LATENCY",
+	"SIGN_CONN_POOL_AUTOSCALE_QUIET",
+	"SIGN_CONN_POOL_AUTOSCALE_STEP",
+	"SIGN_CONN_POOL_AUTOSCALE_WAITERS",
+	"SIGN_CONN_POOL_BREAKER_COOLDOWN",
+	"SIGN_CONN_POOL_BREAKER_PROBES",
+	"SIGN_CONN_POOL_BREAKER_THRESHOLD",
+	"SIGN_CONN_POOL_DIAL_TIMEOUT",
+	"SIGN_CONN_POOL_HEALTH_INTERVAL",
+	"SIGN_CONN_POOL_HEALTH_METHOD",
+	"SIGN_CONN_POOL_KEEPALIVE_TIME",
+	"SIGN_CONN_POOL_KEEPALIVE_TIMEOUT",
+	"SIGN_CONN_POOL_LEASE_DEBUG",
+	"SIGN_CONN_POOL_MAX",
+	"SIGN_CONN_POOL_MAX_CONN_AGE",
+	"SIGN_CONN_POOL_MAX_IDLE_TIME",
+	"SIGN_CONN_POOL_MAX_LEASE_HOLD",
+	"SIGN_CONN_POOL_MAX_WAITERS",
+	"SIGN_CONN_POOL_MIN",
+	"SIGN_CONN_POOL_OUTLIER",
+	"SIGN_CONN_POOL_OUTLIER_ALPHA",
+	"SIGN_CONN_POOL_OUTLIER_EJECTION_TIME",
+	"SIGN_CONN_POOL_OUTLIER_ERROR_RATE",
+	"SIGN_CONN_POOL_OUTLIER_LATENCY",
+	"SIGN_CONN_POOL_OUTLIER_MAX_EJECTION_PERCENT",
+	"SIGN_CONN_POOL_OUTLIER_MAX_EJECTION_TIME",
+	"SIGN_CONN_POOL_OUTLIER_MIN_REQUESTS",
+	"SIGN_CONN_POOL_OUTLIER_RAMP_UP",
+	"SIGN_CONN_POOL_PREWARM",
+	"SIGN_CONN_POOL_RETRY_INITIAL",
+	"SIGN_CONN_POOL_RETRY_JITTER",
+	"SIGN_CONN_POOL_RETRY_MAX",
+	"SIGN_CONN_POOL_SERVICE",
+	"SIGN_CONN_POOL_TLS_CA_FILE",
+	"SIGN_CONN_POOL_TLS_CERT_FILE",
+	"SIGN_CONN_POOL_TLS_KEY_FILE",
+	"SIGN_CONN_POOL_TLS_SERVER_NAME",
+	"SIGN_CONN_POOL_TLS_SPIFFE_ID",
+	"SIGN_CONN_POOL_WARM_STREAMS",
+	"UNLOCK_KEYSPACE",
+	"UNLOCK_KMS_MOCK_KEY",
+	"UNLOCK_MAX_QUEUE",
+	"UNLOCK_RATE_BURST",
+	"UNLOCK_RATE_LIMIT",
+	"UNLOCK_RETRY_HORIZON_MAX_MS",
+	"UNLOCK_RETRY_HORIZON_MIN_MS",
+	"UNLOCK_RETRY_MAX_MS",
+	"UNLOCK_RETRY_MIN_MS",
+	"UNLOCK_SHUTDOWN_TIMEOUT_MS",
+	"UNLOCK_STATUS_MAX_ENTRIES",
+	"UNLOCK_STATUS_RETENTION_MS",
+	"UNLOCK_WAL_MAX_BYTES",
+	"UNLOCK_WAL_PATH",
+	"UNLOCK_WAL_SYNC",
+	"UNLOCK_WATCH",
+	"UNLOCK_WATCH_MAX_WAIT_MS",
+	"UNLOCK_WATCH_MAX_WATCHERS",
+	"UNLOCK_WATCH_RETENTION_MS",
+	"UNLOCK_WORKERS",
+}
+
+// Config 配置兼容层，零值使用上面的默认表。
+type Config struct {
+	Aliases  []Alias
+	Known    []string
+	Prefixes []string
+	// Strict 为 true 时，疑似拼写错误的变量会导致 Apply 返回错误。
+	Strict bool
+	Logger *slog.Logger
+}
+
+// Report 汇总一次 Apply 的结果。
+type Report struct {
+	// Migrated 为已按新名生效的旧变量。
+	Migrated []Alias
+	// Shadowed 为新旧名同时设置、旧值被忽略的变量。
+	Shadowed []Alias
+	// Unknown 为带受检前缀但不被任何配置读取的变量，已排序。
+	Unknown []string
+}
+
+// Apply 把仍在使用的旧变量名写到新名上（新名已设置时以新名为准），
+// 并报告不被识别的变量；须在读取任何配置之前调用。
+func Apply(cfg Config) (Report, error) {
+	if cfg.Aliases == nil {
+		cfg.Aliases = DefaultAliases
+	}
+	if cfg.Known == nil {
+		cfg.Known = SupportedKeys
+	}
+	if cfg.Prefixes == nil {
+		cfg.Prefixes = DefaultPrefixes
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	var report Report
+	known := make(map[string]bool, len(cfg.Known)+len(cfg.Aliases))
+	for _, key := range cfg.Known {
+		known[key] = true
+	}
+	for _, alias := range cfg.Aliases {
+		known[alias.Old] = true
+		oldValue, ok := os.LookupEnv(alias.Old)
+		if !ok {
+			continue
+		}
+		if _, set := os.LookupEnv(alias.New); set {
+			report.Shadowed = append(report.Shadowed, alias)
+			cfg.Logger.Warn("deprecated env variable ignored", slog.String("old", alias.Old), slog.String("new", alias.New))
+			continue
+		}
+		if err := os.Setenv(alias.New, oldValue); err != nil {
+			return report, fmt.Errorf("migrate %s to %s: %w", alias.Old, alias.New, err)
+		}
+		report.Migrated = append(report.Migrated, alias)
+		cfg.Logger.Warn("deprecated env variable", slog.String("old", alias.Old), slog.String("new", alias.New))
+	}
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if known[key] || !hasAnyPrefix(key, cfg.Prefixes) {
+			continue
+		}
+		report.Unknown = append(report.Unknown, key)
+	}
+	sort.Strings(report.Unknown)
+	for _, key := range report.Unknown {
+		attrs := []any{slog.String("key", key)}
+		if guess := closest(key, cfg.Known); guess != "" {
+			attrs = append(attrs, slog.String("did_you_mean", guess))
+		}
+		cfg.Logger.Warn("unknown env variable, probable typo", attrs...)
+	}
+	if cfg.Strict && len(report.Unknown) > 0 {
+		return report, fmt.Errorf("unknown env variables: %s", strings.Join(report.Unknown, ", "))
+	}
+	return report, nil
+}
+
+func hasAnyPrefix(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// closest 返回编辑距离不超过 3 的最接近的已知变量名。
+func closest(key string, known []string) string {
+	best, bestDist := "", 4
+	for _, candidate := range known {
+		if d := editDistance(key, candidate); d < bestDist {
+			best, bestDist = candidate, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
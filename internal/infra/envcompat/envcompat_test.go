@@ -0,0 +1,59 @@
+package envcompat
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() Config {
+	return Config{
+		Aliases:  []Alias{{Old: "TESTCOMPAT_OLD", New: "TESTCOMPAT_NEW"}},
+		Known:    []string{"TESTCOMPAT_NEW", "TESTCOMPAT_WORKERS"},
+		Prefixes: []string{"TESTCOMPAT_"},
+	}
+}
+
+func TestApplyMigratesAlias(t *testing.T) {
+	t.Setenv("TESTCOMPAT_OLD", "42")
+	t.Cleanup(func() { os.Unsetenv("TESTCOMPAT_NEW") })
+	report, err := Apply(testConfig())
+	require.NoError(t, err)
+	require.Equal(t, "42", os.Getenv("TESTCOMPAT_NEW"))
+	require.Equal(t, []Alias{{Old: "TESTCOMPAT_OLD", New: "TESTCOMPAT_NEW"}}, report.Migrated)
+	require.Empty(t, report.Unknown)
+}
+
+func TestApplyPrefersNewName(t *testing.T) {
+	t.Setenv("TESTCOMPAT_OLD", "1")
+	t.Setenv("TESTCOMPAT_NEW", "2")
+	report, err := Apply(testConfig())
+	require.NoError(t, err)
+	require.Equal(t, "2", os.Getenv("TESTCOMPAT_NEW"))
+	require.Len(t, report.Shadowed, 1)
+	require.Empty(t, report.Migrated)
+}
+
+func TestApplyReportsUnknown(t *testing.T) {
+	t.Setenv("TESTCOMPAT_WORKRES", "4")
+	report, err := Apply(testConfig())
+	require.NoError(t, err)
+	require.Equal(t, []string{"TESTCOMPAT_WORKRES"}, report.Unknown)
+	require.Equal(t, "TESTCOMPAT_WORKERS", closest("TESTCOMPAT_WORKRES", testConfig().Known))
+	require.Empty(t, closest("TESTCOMPAT_SOMETHING_ELSE", testConfig().Known))
+}
+
+func TestApplyStrictRejectsUnknown(t *testing.T) {
+	t.Setenv("TESTCOMPAT_WORKRES", "4")
+	cfg := testConfig()
+	cfg.Strict = true
+	_, err := Apply(cfg)
+	require.ErrorContains(t, err, "TESTCOMPAT_WORKRES")
+
+	// 旧变量名不算未知项，strict 下仍可启动。
+	os.Unsetenv("TESTCOMPAT_WORKRES")
+	t.Setenv("TESTCOMPAT_OLD", "1")
+	_, err = Apply(cfg)
+	require.NoError(t, err)
+}
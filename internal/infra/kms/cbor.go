@@ -0,0 +1,225 @@
+package kms
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// 仅实现解析 Nitro attestation 文档所需的 CBOR 子集（RFC 8949）：定长的整数、字节串、文本、数组、
+// 映射、标签与 false/true/null，不支持浮点与不定长编码。
+
+const (
+	cborUint   = 0
+	cborNegint = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+
+	// cborMaxDepth 限制嵌套深度，防止恶意文档耗尽栈。
+	cborMaxDepth = 16
+)
+
+var errCBORTruncated = errors.New("cbor: unexpected end of data")
+
+// cborTagged 是带标签的数据项。
+type cborTagged struct {
+	Number uint64
+	Value  any
+}
+
+// cborDecode 解析单个数据项，要求 data 无多余字节。整数解码为 uint64（负数为 int64），
+// 映射为 map[any]any，键须为整数或文本。
+func cborDecode(data []byte) (any, error) {
+	v, rest, err := cborDecodeItem(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("cbor: %d trailing bytes", len(rest))
+	}
+	return v, nil
+}
+
+func cborDecodeItem(data []byte, depth int) (any, []byte, error) {
+	if depth > cborMaxDepth {
+		return nil, nil, errors.New("cbor: nesting too deep")
+	}
+	if len(data) == 0 {
+		return nil, nil, errCBORTruncated
+	}
+	major, info := data[0]>>5, data[0]&0x1f
+	data = data[1:]
+	if major == cborSimple {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22:
+			return nil, data, nil
+		default:
+			return nil, nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+		}
+	}
+	var arg uint64
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(data) < size {
+			return nil, nil, errCBORTruncated
+		}
+		buf := make([]byte, 8)
+		copy(buf[8-size:], data[:size])
+		arg = binary.BigEndian.Uint64(buf)
+		data = data[size:]
+	default:
+		return nil, nil, fmt.Errorf("cbor: unsupported additional info %d", info)
+	}
+	switch major {
+	case cborUint:
+		return arg, data, nil
+	case cborNegint:
+		if arg > 1<<63-1 {
+			return nil, nil, errors.New("cbor: negative integer overflows int64")
+		}
+		return -1 - int64(arg), data, nil
+	case cborBytes, cborText:
+		if arg > uint64(len(data)) {
+			return nil, nil, errCBORTruncated
+		}
+		raw := data[:arg]
+		if major == cborText {
+			return string(raw), data[arg:], nil
+		}
+		return append([]byte(nil), raw...), data[arg:], nil
+	case cborArray:
+		// 每个元素至少 1 字节，先据此校验长度，避免按伪造的长度预分配。
+		if arg > uint64(len(data)) {
+			return nil, nil, errCBORTruncated
+		}
+		items := make([]any, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var item any
+			var err error
+			if item, data, err = cborDecodeItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+	case cborMap:
+		if arg > uint64(len(data))/2 {
+			return nil, nil, errCBORTruncated
+		}
+		m := make(map[any]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, val any
+			var err error
+			if key, data, err = cborDecodeItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case uint64, int64, string:
+			default:
+				return nil, nil, fmt.Errorf("cbor: unsupported map key type %T", key)
+			}
+			if val, data, err = cborDecodeItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			m[key] = val
+		}
+		return m, data, nil
+	default: // cborTag
+		val, rest, err := cborDecodeItem(data, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		return cborTagged{Number: arg, Value: val}, rest, nil
+	}
+}
+
+// cborEncode 编码 cborDecode 支持的类型（另接受 int），映射的键按编码后的字节序排列。
+func cborEncode(v any) ([]byte, error) {
+	return cborAppend(nil, v)
+}
+
+func cborAppend(buf []byte, v any) ([]byte, error) {
+	switch x := v.(type) {
+	case nil:
+		return append(buf, 0xf6), nil
+	case bool:
+		if x {
+			return append(buf, 0xf5), nil
+		}
+		return append(buf, 0xf4), nil
+	case uint64:
+		return cborAppendHead(buf, cborUint, x), nil
+	case int:
+		return cborAppend(buf, int64(x))
+	case int64:
+		if x >= 0 {
+			return cborAppendHead(buf, cborUint, uint64(x)), nil
+		}
+		return cborAppendHead(buf, cborNegint, uint64(-1-x)), nil
+	case []byte:
+		return append(cborAppendHead(buf, cborBytes, uint64(len(x))), x...), nil
+	case string:
+		return append(cborAppendHead(buf, cborText, uint64(len(x))), x...), nil
+	case []any:
+		buf = cborAppendHead(buf, cborArray, uint64(len(x)))
+		for _, item := range x {
+			var err error
+			if buf, err = cborAppend(buf, item); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[any]any:
+		type entry struct{ key, val []byte }
+		entries := make([]entry, 0, len(x))
+		for k, val := range x {
+			kb, err := cborEncode(k)
+			if err != nil {
+				return nil, err
+			}
+			vb, err := cborEncode(val)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry{kb, vb})
+		}
+		sort.Slice(entries, func(i, j int) bool { return string(entries[i].key) < string(entries[j].key) })
+		buf = cborAppendHead(buf, cborMap, uint64(len(x)))
+		for _, e := range entries {
+			buf = append(append(buf, e.key...), e.val...)
+		}
+		return buf, nil
+	case cborTagged:
+		return cborAppend(cborAppendHead(buf, cborTag, x.Number), x.Value)
+	default:
+		return nil, fmt.Errorf("cbor: unsupported type %T", v)
+	}
+}
+
+func cborAppendHead(buf []byte, major byte, arg uint64) []byte {
+	head := major << 5
+	switch {
+	case arg < 24:
+		return append(buf, head|byte(arg))
+	case arg <= 0xff:
+		return append(buf, head|24, byte(arg))
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(buf, head|25), uint16(arg))
+	case arg <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(buf, head|26), uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, head|27), arg)
+	}
+}
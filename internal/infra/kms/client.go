@@ -53,14 +53,55 @@ type Client struct {
 	cacheMu sync.Mutex
 	cache   attestationCache
 
+	healthMu      sync.Mutex
+	lastErr       error
+	lastErrAt     time.Time
+	lastSuccessAt time.Time
+
 	randMu sync.Mutex
 	rnd    *rand.Rand
 }
 
 // attestationCache 缓存 document 及过期时间。
 type attestationCache struct {
-	doc      []byte
-	expireAt time.Time
+	doc       []byte
+	expireAt  time.Time
+	fetchedAt time.Time
+}
+
+// Health 是 KMS 调用的健康摘要。
+type Health struct {
+	// LastError 为最近一次失败的原因，之后的成功调用不会清除它，以 LastErrorAt 与 LastSuccessAt 判断是否已恢复。
+	LastError     string
+	LastErrorAt   time.Time
+	LastSuccessAt time.Time
+	// AttestedAt 为缓存中 attestation 文档的获取时间，尚未获取时为零值。
+	AttestedAt time.Time
+}
+
+// Health 返回最近的调用结果与 attestation 获取时间，不发起任何调用。
+func (c *Client) Health() Health {
+	c.healthMu.Lock()
+	h := Health{LastErrorAt: c.lastErrAt, LastSuccessAt: c.lastSuccessAt}
+	if c.lastErr != nil {
+		h.LastError = c.lastErr.Error()
+	}
+	c.healthMu.Unlock()
+	c.cacheMu.Lock()
+	h.AttestedAt = c.cache.fetchedAt
+	c.cacheMu.Unlock()
+	return h
+}
+
+func (c *Client) recordResult(err error) {
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+	if err == nil {
+		c.lastSuccessAt = time.Now()
+		return
+	}
+	c.lastErr = err
+	c.lastErrAt = time.Now()
 }
 
 // NewClient 构造 Client。
@@ -119,11 +160,13 @@ func (c *Client) retry(ctx context.Context, fn func([]byte) ([]byte, error)) ([]
 		} else {
 			result, execErr := fn(doc)
 			if execErr == nil {
+				c.recordResult(nil)
 				return result, nil
 			}
 			lastErr = execErr
 			c.logWarn("kms call failed", attempt, execErr)
 		}
+		c.recordResult(lastErr)
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
@@ -154,7 +197,8 @@ func (c *Client) attestation(ctx context.Context) ([]byte, error) {
 	}
 	c.cacheMu.Lock()
 	c.cache.doc = append([]byte(nil), doc...)
-	c.cache.expireAt = time.Now().Add(c.cfg.CacheTTL)
+	c.cache.fetchedAt = time.Now()
+	c.cache.expireAt = c.cache.fetchedAt.Add(c.cfg.CacheTTL)
 	c.cacheMu.Unlock()
 	return doc, nil
 }
@@ -20,6 +20,11 @@ func TestClientRetriesUntilSuccess(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "plain", string(data))
 	require.Equal(t, int64(3), provider.decryptCalls.Load())
+
+	health := client.Health()
+	require.Equal(t, "kms error", health.LastError)
+	require.False(t, health.LastSuccessAt.Before(health.LastErrorAt))
+	require.False(t, health.AttestedAt.IsZero())
 }
 
 func TestClientFailsAfterMaxAttempts(t *testing.T) {
@@ -0,0 +1,264 @@
+package kms
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// coseSign1Tag 为 COSE_Sign1 的 CBOR 标签（RFC 8152），Nitro 文档可带可不带。
+	coseSign1Tag = 18
+	// coseAlgES384 为 COSE 算法 ES384，Nitro 文档固定使用该算法。
+	coseAlgES384 = -35
+)
+
+// NitroDocument 是解析后的 Nitro attestation 文档载荷。
+type NitroDocument struct {
+	ModuleID    string
+	Digest      string
+	Timestamp   time.Time
+	PCRs        map[int][]byte
+	Certificate []byte
+	// CABundle 由根证书到签发 Certificate 的中间证书排列。
+	CABundle  [][]byte
+	PublicKey []byte
+	UserData  []byte
+	Nonce     []byte
+
+	protected []byte
+	payload   []byte
+	signature []byte
+}
+
+// ParseNitroDocument 解析 COSE_Sign1 编码的 attestation 文档，不做任何校验。
+func ParseNitroDocument(document []byte) (*NitroDocument, error) {
+	v, err := cborDecode(document)
+	if err != nil {
+		return nil, fmt.Errorf("decode attestation document: %w", err)
+	}
+	if tagged, ok := v.(cborTagged); ok && tagged.Number == coseSign1Tag {
+		v = tagged.Value
+	}
+	msg, ok := v.([]any)
+	if !ok || len(msg) != 4 {
+		return nil, errors.New("attestation document is not a COSE_Sign1 message")
+	}
+	protected, ok1 := msg[0].([]byte)
+	payload, ok2 := msg[2].([]byte)
+	signature, ok3 := msg[3].([]byte)
+	if !ok1 || !ok2 || !ok3 {
+		return nil, errors.New("malformed COSE_Sign1 message")
+	}
+	fields, err := cborDecode(payload)
+	if err != nil {
+		return nil, fmt.Errorf("decode attestation payload: %w", err)
+	}
+	m, ok := fields.(map[any]any)
+	if !ok {
+		return nil, errors.New("attestation payload is not a map")
+	}
+	doc := &NitroDocument{protected: protected, payload: payload, signature: signature, PCRs: map[int][]byte{}}
+	doc.ModuleID, _ = m["module_id"].(string)
+	doc.Digest, _ = m["digest"].(string)
+	if ms, ok := m["timestamp"].(uint64); ok {
+		doc.Timestamp = time.UnixMilli(int64(ms))
+	}
+	doc.Certificate, _ = m["certificate"].([]byte)
+	doc.PublicKey, _ = m["public_key"].([]byte)
+	doc.UserData, _ = m["user_data"].([]byte)
+	doc.Nonce, _ = m["nonce"].([]byte)
+	pcrs, _ := m["pcrs"].(map[any]any)
+	for k, val := range pcrs {
+		idx, ok1 := k.(uint64)
+		value, ok2 := val.([]byte)
+		if !ok1 || !ok2 || idx > 31 {
+			return nil, errors.New("malformed pcrs field")
+		}
+		doc.PCRs[int(idx)] = value
+	}
+	bundle, _ := m["cabundle"].([]any)
+	for _, item := range bundle {
+		cert, ok := item.([]byte)
+		if !ok {
+			return nil, errors.New("malformed cabundle field")
+		}
+		doc.CABundle = append(doc.CABundle, cert)
+	}
+	if doc.ModuleID == "" || len(doc.Certificate) == 0 || len(doc.CABundle) == 0 || len(doc.PCRs) == 0 {
+		return nil, errors.New("attestation payload misses mandatory fields")
+	}
+	return doc, nil
+}
+
+// NitroVerifier 校验 Nitro attestation 文档：以 Roots 校验证书链、以叶子证书校验 ES384 签名，
+// 再比对 PCR 白名单。仅实现 Attestor 的 Verify 侧，供父机校验 Enclave。
+type NitroVerifier struct {
+	// Roots 为受信的 Nitro 根证书（AWS 发布的 root.pem）。
+	Roots *x509.CertPool
+	// PCRs 为白名单：文档中每个列出的 PCR 须等于其允许值之一，未列出的 PCR 不检查。
+	PCRs map[int][][]byte
+	// Now 用于证书有效期判断，默认 time.Now。
+	Now func() time.Time
+}
+
+// Verify 校验文档的签名、证书链与 PCR。
+func (v *NitroVerifier) Verify(document []byte) error {
+	_, err := v.verify(document)
+	return err
+}
+
+// VerifyNonce 在 Verify 的基础上要求文档携带的 nonce 与 nonce 一致。
+func (v *NitroVerifier) VerifyNonce(document, nonce []byte) error {
+	doc, err := v.verify(document)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(doc.Nonce, nonce) {
+		return errors.New("attestation nonce mismatch")
+	}
+	return nil
+}
+
+func (v *NitroVerifier) verify(document []byte) (*NitroDocument, error) {
+	if v.Roots == nil {
+		return nil, errors.New("nitro verifier has no root certificates")
+	}
+	if len(v.PCRs) == 0 {
+		return nil, errors.New("nitro verifier has no PCR allowlist")
+	}
+	doc, err := ParseNitroDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Digest != "SHA384" {
+		return nil, fmt.Errorf("unsupported attestation digest %q", doc.Digest)
+	}
+	leaf, err := v.verifyChain(doc)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyCOSESignature(doc, leaf); err != nil {
+		return nil, err
+	}
+	if err := v.verifyPCRs(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (v *NitroVerifier) verifyChain(doc *NitroDocument) (*x509.Certificate, error) {
+	leaf, err := x509.ParseCertificate(doc.Certificate)
+	if err != nil {
+		return nil, fmt.Errorf("parse attestation certificate: %w", err)
+	}
+	// cabundle[0] 为根证书，只信任 Roots 中的根，其余作为中间证书。
+	intermediates := x509.NewCertPool()
+	for _, raw := range doc.CABundle[1:] {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parse attestation cabundle: %w", err)
+		}
+		intermediates.AddCert(cert)
+	}
+	now := time.Now
+	if v.Now != nil {
+		now = v.Now
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         v.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, fmt.Errorf("verify attestation certificate: %w", err)
+	}
+	return leaf, nil
+}
+
+// verifyCOSESignature 校验 Sig_structure ["Signature1", protected, 空 external_aad, payload] 上的 ES384 签名。
+func verifyCOSESignature(doc *NitroDocument, leaf *x509.Certificate) error {
+	header, err := cborDecode(doc.protected)
+	if err != nil {
+		return fmt.Errorf("decode protected header: %w", err)
+	}
+	if h, ok := header.(map[any]any); !ok || h[uint64(1)] != int64(coseAlgES384) {
+		return errors.New("attestation document is not signed with ES384")
+	}
+	pub, ok := leaf.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("attestation certificate key is not ECDSA")
+	}
+	if len(doc.signature) != 96 {
+		return fmt.Errorf("invalid ES384 signature length %d", len(doc.signature))
+	}
+	sigStructure, err := cborEncode([]any{"Signature1", doc.protected, []byte{}, doc.payload})
+	if err != nil {
+		return err
+	}
+	digest := sha512.Sum384(sigStructure)
+	r := new(big.Int).SetBytes(doc.signature[:48])
+	s := new(big.Int).SetBytes(doc.signature[48:])
+	if !ecdsa.Verify(pub, digest[:], r, s) {
+		return errors.New("attestation signature verification failed")
+	}
+	return nil
+}
+
+func (v *NitroVerifier) verifyPCRs(doc *NitroDocument) error {
+	indexes := make([]int, 0, len(v.PCRs))
+	for idx := range v.PCRs {
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+	for _, idx := range indexes {
+		got, ok := doc.PCRs[idx]
+		if !ok {
+			return fmt.Errorf("attestation document misses PCR%d", idx)
+		}
+		matched := false
+		for _, want := range v.PCRs[idx] {
+			if bytes.Equal(got, want) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("PCR%d %s is not in the allowlist", idx, hex.EncodeToString(got))
+		}
+	}
+	return nil
+}
+
+// ParsePCRAllowlist 解析 "0=hex|hex,8=hex" 格式的 PCR 白名单，同一 PCR 以 | 分隔多个允许值（便于滚动发布新镜像）。
+func ParsePCRAllowlist(raw string) (map[int][][]byte, error) {
+	out := map[int][][]byte{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		idxRaw, values, found := strings.Cut(part, "=")
+		idx, err := strconv.Atoi(strings.TrimSpace(idxRaw))
+		if !found || err != nil || idx < 0 || idx > 31 {
+			return nil, fmt.Errorf("invalid PCR entry %q", part)
+		}
+		for _, value := range strings.Split(values, "|") {
+			decoded, err := hex.DecodeString(strings.TrimSpace(value))
+			if err != nil || len(decoded) == 0 {
+				return nil, fmt.Errorf("invalid PCR%d value %q", idx, value)
+			}
+			out[idx] = append(out[idx], decoded)
+		}
+	}
+	return out, nil
+}
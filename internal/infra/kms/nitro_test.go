@@ -0,0 +1,130 @@
+package kms
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type nitroTestPKI struct {
+	root, leaf *x509.Certificate
+	leafKey    *ecdsa.PrivateKey
+}
+
+func newNitroTestPKI(t *testing.T) nitroTestPKI {
+	t.Helper()
+	rootKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	leafKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	rootTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "aws.nitro-enclaves"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTmpl, rootTmpl, &rootKey.PublicKey, rootKey)
+	require.NoError(t, err)
+	root, err := x509.ParseCertificate(rootDER)
+	require.NoError(t, err)
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "i-0123.enclave"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, root, &leafKey.PublicKey, rootKey)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(leafDER)
+	require.NoError(t, err)
+	return nitroTestPKI{root: root, leaf: leaf, leafKey: leafKey}
+}
+
+// document 按 Nitro 格式构造带 tag 18 的 COSE_Sign1 文档。
+func (p nitroTestPKI) document(t *testing.T, pcr0, nonce []byte) []byte {
+	t.Helper()
+	payload, err := cborEncode(map[any]any{
+		"module_id":   "i-0123-enc0123",
+		"digest":      "SHA384",
+		"timestamp":   uint64(time.Now().UnixMilli()),
+		"pcrs":        map[any]any{uint64(0): pcr0, uint64(1): bytes.Repeat([]byte{1}, 48)},
+		"certificate": p.leaf.Raw,
+		"cabundle":    []any{p.root.Raw},
+		"public_key":  nil,
+		"user_data":   nil,
+		"nonce":       nonce,
+	})
+	require.NoError(t, err)
+	protected, err := cborEncode(map[any]any{uint64(1): int64(coseAlgES384)})
+	require.NoError(t, err)
+	sigStructure, err := cborEncode([]any{"Signature1", protected, []byte{}, payload})
+	require.NoError(t, err)
+	digest := sha512.Sum384(sigStructure)
+	r, s, err := ecdsa.Sign(rand.Reader, p.leafKey, digest[:])
+	require.NoError(t, err)
+	sig := make([]byte, 96)
+	r.FillBytes(sig[:48])
+	s.FillBytes(sig[48:])
+	doc, err := cborEncode(cborTagged{Number: coseSign1Tag, Value: []any{protected, map[any]any{}, payload, sig}})
+	require.NoError(t, err)
+	return doc
+}
+
+func TestNitroVerifierChecksSignatureChainAndPCRs(t *testing.T) {
+	pki := newNitroTestPKI(t)
+	pcr0 := bytes.Repeat([]byte{0xab}, 48)
+	nonce := []byte("nonce-1")
+	roots := x509.NewCertPool()
+	roots.AddCert(pki.root)
+	verifier := &NitroVerifier{Roots: roots, PCRs: map[int][][]byte{0: {bytes.Repeat([]byte{0xcd}, 48), pcr0}}}
+
+	doc := pki.document(t, pcr0, nonce)
+	parsed, err := ParseNitroDocument(doc)
+	require.NoError(t, err)
+	require.Equal(t, "i-0123-enc0123", parsed.ModuleID)
+	require.Equal(t, pcr0, parsed.PCRs[0])
+	require.NoError(t, verifier.Verify(doc))
+	require.NoError(t, verifier.VerifyNonce(doc, nonce))
+	require.ErrorContains(t, verifier.VerifyNonce(doc, []byte("replayed")), "nonce mismatch")
+
+	// 度量值不在白名单内。
+	require.ErrorContains(t, verifier.Verify(pki.document(t, bytes.Repeat([]byte{0xee}, 48), nonce)), "PCR0")
+
+	// 篡改载荷后签名失效。
+	tampered := bytes.Replace(doc, []byte("i-0123-enc0123"), []byte("i-0123-enc9999"), 1)
+	require.ErrorContains(t, verifier.Verify(tampered), "signature verification failed")
+
+	// 证书链不是由受信根签发。
+	other := newNitroTestPKI(t)
+	untrusted := x509.NewCertPool()
+	untrusted.AddCert(other.root)
+	require.ErrorContains(t, (&NitroVerifier{Roots: untrusted, PCRs: verifier.PCRs}).Verify(doc), "verify attestation certificate")
+
+	require.ErrorContains(t, (&NitroVerifier{Roots: roots}).Verify(doc), "no PCR allowlist")
+	_, err = ParseNitroDocument([]byte{0x83, 0x01})
+	require.Error(t, err)
+}
+
+func TestParsePCRAllowlist(t *testing.T) {
+	pcrs, err := ParsePCRAllowlist(" 0=aabb|ccdd , 8=ff ")
+	require.NoError(t, err)
+	require.Equal(t, map[int][][]byte{0: {{0xaa, 0xbb}, {0xcc, 0xdd}}, 8: {{0xff}}}, pcrs)
+
+	for _, raw := range []string{"0", "x=aa", "32=aa", "0=zz", "0=aa|"} {
+		_, err := ParsePCRAllowlist(raw)
+		require.Error(t, err, raw)
+	}
+}
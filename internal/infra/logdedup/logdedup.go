@@ -0,0 +1,157 @@
+// Package logdedup 抑制短时间内重复出现的相同日志：首条照常输出，其余只计数，
+// 窗口结束时输出一条 "repeated N times" 摘要，避免故障期间日志被同一行刷屏。
+package logdedup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const defaultWindow = 30 * time.Second
+
+// Config 配置去重窗口。
+type Config struct {
+	// Window 为同一条日志的合并窗口，默认 30s；后台按该周期输出摘要。
+	Window time.Duration
+	Now    func() time.Time
+}
+
+type entry struct {
+	level      slog.Level
+	msg        string
+	args       []any
+	first      time.Time
+	suppressed int
+}
+
+// Logger 按 "消息 + key"（通常为 enclave/key ID）去重，可并发使用；nil 时退化为不记录。
+type Logger struct {
+	logger *slog.Logger
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+
+	stopCh    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New 构造 Logger 并启动周期性摘要输出，使用完毕需调用 Close。
+func New(logger *slog.Logger, cfg Config) *Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	l := &Logger{
+		logger:  logger,
+		window:  cfg.Window,
+		now:     cfg.Now,
+		entries: make(map[string]*entry),
+		stopCh:  make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go l.loop()
+	return l
+}
+
+// Info 以 Info 级别记录去重日志。
+func (l *Logger) Info(key, msg string, args ...any) {
+	l.Log(slog.LevelInfo, key, msg, args...)
+}
+
+// Warn 以 Warn 级别记录去重日志。
+func (l *Logger) Warn(key, msg string, args ...any) {
+	l.Log(slog.LevelWarn, key, msg, args...)
+}
+
+// Log 在窗口内首次出现时输出，之后仅计数；args 取首条日志的值。
+func (l *Logger) Log(level slog.Level, key, msg string, args ...any) {
+	if l == nil {
+		return
+	}
+	id := msg + "\x00" + key
+	now := l.now()
+	l.mu.Lock()
+	e, ok := l.entries[id]
+	if ok && now.Sub(e.first) < l.window {
+		e.suppressed++
+		l.mu.Unlock()
+		return
+	}
+	l.entries[id] = &entry{level: level, msg: msg, args: args, first: now}
+	l.mu.Unlock()
+	if ok {
+		l.summarize(e)
+	}
+	l.logger.Log(context.Background(), level, msg, args...)
+}
+
+// Flush 立即输出所有待汇总的摘要并清空状态，用于停机前。
+func (l *Logger) Flush() {
+	if l == nil {
+		return
+	}
+	l.flush(func(*entry) bool { return true })
+}
+
+// Close 停止后台输出并 Flush，可重复调用。
+func (l *Logger) Close() {
+	if l == nil {
+		return
+	}
+	l.closeOnce.Do(func() {
+		close(l.stopCh)
+		<-l.done
+		l.Flush()
+	})
+}
+
+func (l *Logger) loop() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stopCh:
+			return
+		case <-ticker.C:
+			now := l.now()
+			l.flush(func(e *entry) bool { return now.Sub(e.first) >= l.window })
+		}
+	}
+}
+
+func (l *Logger) flush(expired func(*entry) bool) {
+	var out []*entry
+	l.mu.Lock()
+	for id, e := range l.entries {
+		if !expired(e) {
+			continue
+		}
+		delete(l.entries, id)
+		out = append(out, e)
+	}
+	l.mu.Unlock()
+	for _, e := range out {
+		l.summarize(e)
+	}
+}
+
+func (l *Logger) summarize(e *entry) {
+	if e.suppressed == 0 {
+		return
+	}
+	msg := fmt.Sprintf("%s (repeated %d times in the last %s)", e.msg, e.suppressed, l.window)
+	args := append(append([]any(nil), e.args...), "repeated", e.suppressed)
+	l.logger.Log(context.Background(), e.level, msg, args...)
+}
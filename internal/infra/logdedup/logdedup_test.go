@@ -0,0 +1,110 @@
+package logdedup
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer 让并发写日志的测试可安全读取输出。
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := strings.TrimSpace(b.buf.String())
+	if out == "" {
+		return nil
+	}
+	return strings.Split(out, "\n")
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func TestLoggerSummarizesRepeats(t *testing.T) {
+	out := &syncBuffer{}
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	l := New(slog.New(slog.NewTextHandler(out, nil)), Config{Window: time.Hour, Now: clock.Now})
+	defer l.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Warn("enclave-a", "open connection failed", "enclave", "enclave-a")
+		}()
+	}
+	wg.Wait()
+	l.Warn("enclave-b", "open connection failed", "enclave", "enclave-b")
+	require.Len(t, out.Lines(), 2, "only first occurrence per key is logged")
+
+	// 窗口过后的下一条日志先输出上一窗口的摘要。
+	clock.Advance(time.Hour)
+	l.Warn("enclave-a", "open connection failed", "enclave", "enclave-a")
+	lines := out.Lines()
+	require.Len(t, lines, 4)
+	require.Contains(t, lines[2], "repeated 49 times in the last 1h0m0s")
+	require.Contains(t, lines[2], "repeated=49")
+	require.Contains(t, lines[2], "enclave=enclave-a")
+
+	l.Warn("enclave-a", "open connection failed", "enclave", "enclave-a")
+	l.Close()
+	lines = out.Lines()
+	require.Len(t, lines, 5, "Close flushes pending summaries")
+	require.Contains(t, lines[4], "repeated 1 times")
+}
+
+func TestLoggerPeriodicFlush(t *testing.T) {
+	out := &syncBuffer{}
+	l := New(slog.New(slog.NewTextHandler(out, nil)), Config{Window: 20 * time.Millisecond})
+	defer l.Close()
+
+	for i := 0; i < 5; i++ {
+		l.Info("key-1", "unlock retry scheduled")
+	}
+	l.Info("key-2", "unlock retry scheduled")
+	require.Eventually(t, func() bool {
+		for _, line := range out.Lines() {
+			if strings.Contains(line, "repeated 4 times") {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 5*time.Millisecond)
+	// key-2 没有重复，不输出摘要。
+	require.Len(t, out.Lines(), 3)
+
+	var nilLogger *Logger
+	nilLogger.Warn("k", "ignored")
+	nilLogger.Close()
+}
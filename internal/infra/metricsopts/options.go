@@ -0,0 +1,120 @@
+// Package metricsopts 统一各模块 Prometheus 指标的命名空间、子系统与常量标签，
+// 便于同一进程内部署多个实例或在共享注册器中区分来源。
+package metricsopts
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Options 配置指标名前缀与常量标签；零值保持各模块原有的指标名。
+type Options struct {
+	// Namespace 非空时覆盖模块默认命名空间。
+	Namespace string
+	// Subsystem 非空时覆盖模块默认子系统。
+	Subsystem string
+	// ConstLabels 附加到该模块全部指标上，不能与指标自身的变量标签重名。
+	ConstLabels prometheus.Labels
+}
+
+// WithDefaults 用模块默认的命名空间与子系统补齐未设置的字段。
+func (o Options) WithDefaults(namespace, subsystem string) Options {
+	if o.Namespace == "" {
+		o.Namespace = namespace
+	}
+	if o.Subsystem == "" {
+		o.Subsystem = subsystem
+	}
+	return o
+}
+
+// Counter 生成 CounterOpts。
+func (o Options) Counter(name, help string) prometheus.CounterOpts {
+	return prometheus.CounterOpts{Namespace: o.Namespace, Subsystem: o.Subsystem, Name: name, Help: help, ConstLabels: o.ConstLabels}
+}
+
+// Gauge 生成 GaugeOpts。
+func (o Options) Gauge(name, help string) prometheus.GaugeOpts {
+	return prometheus.GaugeOpts{Namespace: o.Namespace, Subsystem: o.Subsystem, Name: name, Help: help, ConstLabels: o.ConstLabels}
+}
+
+// Histogram 生成 HistogramOpts。
+func (o Options) Histogram(name, help string, buckets []float64) prometheus.HistogramOpts {
+	return prometheus.HistogramOpts{Namespace: o.Namespace, Subsystem: o.Subsystem, Name: name, Help: help, ConstLabels: o.ConstLabels, Buckets: buckets}
+}
+
+// Summary 生成 SummaryOpts。
+func (o Options) Summary(name, help string, objectives map[float64]float64) prometheus.SummaryOpts {
+	return prometheus.SummaryOpts{Namespace: o.Namespace, Subsystem: o.Subsystem, Name: name, Help: help, ConstLabels: o.ConstLabels, Objectives: objectives}
+}
+
+// ParseConstLabels 解析 "k=v,k2=v2" 形式的常量标签，空串返回 nil。
+func ParseConstLabels(raw string) (prometheus.Labels, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	labels := prometheus.Labels{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		k, v, found := strings.Cut(part, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !found || k == "" {
+			return nil, fmt.Errorf("invalid const label: %s", part)
+		}
+		if _, dup := labels[k]; dup {
+			return nil, fmt.Errorf("duplicate const label: %s", k)
+		}
+		labels[k] = v
+	}
+	return labels, nil
+}
+
+var fqNamePattern = regexp.MustCompile(`fqName: "([^"]*)"`)
+
+// Register 依次注册 collectors；任一失败时回滚本批已注册的 collector，
+// 并返回带指标全名的错误，避免裸 panic 难以定位冲突来源。
+func Register(reg prometheus.Registerer, collectors ...prometheus.Collector) error {
+	for i, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			for _, done := range collectors[:i] {
+				reg.Unregister(done)
+			}
+			name := collectorName(c)
+			var already prometheus.AlreadyRegisteredError
+			if errors.As(err, &already) {
+				return fmt.Errorf("metric %s already registered; set a distinct namespace or const labels: %w", name, err)
+			}
+			return fmt.Errorf("register metric %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// MustRegister 同 Register，失败时 panic。
+func MustRegister(reg prometheus.Registerer, collectors ...prometheus.Collector) {
+	if err := Register(reg, collectors...); err != nil {
+		panic(err)
+	}
+}
+
+func collectorName(c prometheus.Collector) string {
+	descs := make(chan *prometheus.Desc, 8)
+	go func() {
+		c.Describe(descs)
+		close(descs)
+	}()
+	name := "<unknown>"
+	for d := range descs {
+		if m := fqNamePattern.FindStringSubmatch(d.String()); m != nil && name == "<unknown>" {
+			name = m[1]
+		}
+	}
+	return name
+}
@@ -0,0 +1,55 @@
+package metricsopts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterNamesCollidingMetric(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	opts := Options{}.WithDefaults("signer", "demo")
+	first := prometheus.NewCounter(opts.Counter("a_total", "a"))
+	require.NoError(t, Register(reg, first))
+
+	other := prometheus.NewCounter(opts.Counter("b_total", "b"))
+	dup := prometheus.NewCounter(opts.Counter("a_total", "a"))
+	err := Register(reg, other, dup)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "signer_demo_a_total")
+
+	// 失败时已注册的 other 被回滚，可再次注册。
+	require.NoError(t, Register(reg, other))
+}
+
+func TestRegisterRejectsConstLabelClash(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	opts := Options{ConstLabels: prometheus.Labels{"keyspace": "x"}}
+	vec := prometheus.NewCounterVec(opts.Counter("clash_total", "c"), []string{"keyspace"})
+	err := Register(reg, vec)
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "clash_total"), err.Error())
+}
+
+func TestWithDefaultsKeepsOverrides(t *testing.T) {
+	opts := Options{Namespace: "shadow"}.WithDefaults("signer", "enclave_pool")
+	require.Equal(t, "shadow", opts.Namespace)
+	require.Equal(t, "enclave_pool", opts.Subsystem)
+}
+
+func TestParseConstLabels(t *testing.T) {
+	labels, err := ParseConstLabels(" service=signer, region = eu ")
+	require.NoError(t, err)
+	require.Equal(t, prometheus.Labels{"service": "signer", "region": "eu"}, labels)
+
+	labels, err = ParseConstLabels("")
+	require.NoError(t, err)
+	require.Nil(t, labels)
+
+	for _, raw := range []string{"service", "=x", "a=1,a=2"} {
+		_, err := ParseConstLabels(raw)
+		require.Error(t, err, raw)
+	}
+}
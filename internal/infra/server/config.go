@@ -0,0 +1,131 @@
+// Package server 集中构造对外监听的 HTTP/gRPC server，统一超时、头部与连接数限制。
+package server
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config 汇总 HTTP 与 gRPC 监听器的加固参数。
+type Config struct {
+	HTTP HTTPConfig
+	GRPC GRPCConfig
+	// TLS 同时作用于 gRPC 与未单独配置证书的 TCP HTTP 监听器。
+	TLS TLSConfig
+}
+
+// HTTPConfig 控制 http.Server 的超时、头部大小与协议。
+type HTTPConfig struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	DisableHTTP2      bool
+	// MaxConns 为 0 表示不限制并发连接数。
+	MaxConns int
+}
+
+// GRPCConfig 控制 gRPC server 的并发流与连接生命周期。
+type GRPCConfig struct {
+	MaxConcurrentStreams  uint32
+	MaxConnectionIdle     time.Duration
+	MaxConnectionAge      time.Duration
+	MaxConnectionAgeGrace time.Duration
+	// MaxConns 为 0 表示不限制并发连接数。
+	MaxConns int
+}
+
+// DefaultConfig 返回防 slowloris 的保守默认值；ReadTimeout/WriteTimeout 默认关闭以兼容流式响应。
+func DefaultConfig() Config {
+	return Config{
+		HTTP: HTTPConfig{
+			ReadHeaderTimeout: 5 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			MaxHeaderBytes:    64 << 10,
+		},
+		GRPC: GRPCConfig{
+			MaxConcurrentStreams:  1024,
+			MaxConnectionIdle:     5 * time.Minute,
+			MaxConnectionAgeGrace: 30 * time.Second,
+		},
+	}
+}
+
+// LoadConfigFromEnv 在默认值基础上读取 SIGNER_HTTP_* / SIGNER_GRPC_* 环境变量。
+func LoadConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	if d := readDuration("SIGNER_HTTP_READ_HEADER_TIMEOUT"); d > 0 {
+		cfg.HTTP.ReadHeaderTimeout = d
+	}
+	if d := readDuration("SIGNER_HTTP_READ_TIMEOUT"); d > 0 {
+		cfg.HTTP.ReadTimeout = d
+	}
+	if d := readDuration("SIGNER_HTTP_WRITE_TIMEOUT"); d > 0 {
+		cfg.HTTP.WriteTimeout = d
+	}
+	if d := readDuration("SIGNER_HTTP_IDLE_TIMEOUT"); d > 0 {
+		cfg.HTTP.IdleTimeout = d
+	}
+	if v := readInt("SIGNER_HTTP_MAX_HEADER_BYTES"); v > 0 {
+		cfg.HTTP.MaxHeaderBytes = v
+	}
+	if v := readInt("SIGNER_HTTP_MAX_CONNS"); v > 0 {
+		cfg.HTTP.MaxConns = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("SIGNER_HTTP_DISABLE_HTTP2")); err == nil {
+		cfg.HTTP.DisableHTTP2 = v
+	}
+	if v := readInt("SIGNER_GRPC_MAX_CONCURRENT_STREAMS"); v > 0 {
+		cfg.GRPC.MaxConcurrentStreams = uint32(v)
+	}
+	if d := readDuration("SIGNER_GRPC_MAX_CONN_IDLE"); d > 0 {
+		cfg.GRPC.MaxConnectionIdle = d
+	}
+	if d := readDuration("SIGNER_GRPC_MAX_CONN_AGE"); d > 0 {
+		cfg.GRPC.MaxConnectionAge = d
+	}
+	if d := readDuration("SIGNER_GRPC_MAX_CONN_AGE_GRACE"); d > 0 {
+		cfg.GRPC.MaxConnectionAgeGrace = d
+	}
+	if v := readInt("SIGNER_GRPC_MAX_CONNS"); v > 0 {
+		cfg.GRPC.MaxConns = v
+	}
+	cfg.TLS.CertFile = os.Getenv("SIGNER_TLS_CERT_FILE")
+	cfg.TLS.KeyFile = os.Getenv("SIGNER_TLS_KEY_FILE")
+	cfg.TLS.ClientCAFile = os.Getenv("SIGNER_TLS_CLIENT_CA_FILE")
+	// 配置了客户端 CA 时默认强制 mTLS。
+	cfg.TLS.RequireClientCert = cfg.TLS.ClientCAFile != ""
+	if v, err := strconv.ParseBool(os.Getenv("SIGNER_TLS_REQUIRE_CLIENT_CERT")); err == nil {
+		cfg.TLS.RequireClientCert = v
+	}
+	if d := readDuration("SIGNER_TLS_RELOAD_INTERVAL"); d > 0 {
+		cfg.TLS.ReloadInterval = d
+	}
+	return cfg
+}
+
+func readInt(key string) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func readDuration(key string) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// NewHTTPServer 按配置构造 http.Server。
+func NewHTTPServer(addr string, handler http.Handler, cfg HTTPConfig) *http.Server {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+	if cfg.DisableHTTP2 {
+		// 非 nil 的空 map 会关闭 TLS 上的 h2 自动协商。
+		srv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+	return srv
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mdlayher/vsock"
+)
+
+// LimitListener 限制同时存活的连接数，超限连接在 Accept 后立即关闭而不是排队，
+// 避免慢连接把 backlog 占满后拖垮正常请求。n<=0 时原样返回 l。
+func LimitListener(l net.Listener, n int) net.Listener {
+	if n <= 0 {
+		return l
+	}
+	return &limitListener{Listener: l, sem: make(chan struct{}, n)}
+}
+
+type limitListener struct {
+	net.Listener
+	sem chan struct{}
+}
+
+func (l *limitListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case l.sem <- struct{}{}:
+			return &limitConn{Conn: conn, release: func() { <-l.sem }}, nil
+		default:
+			_ = conn.Close()
+		}
+	}
+}
+
+type limitConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+// Endpoint 是监听地址：Network 为 tcp、unix 或 vsock。
+type Endpoint struct {
+	Network string
+	Addr    string
+}
+
+func (e Endpoint) String() string {
+	return e.Network + "://" + e.Addr
+}
+
+// ParseEndpoint 解析监听地址，支持与 Enclave 拨号一致的写法：
+// `host:port`、`unix:///path`（或 `unix:/path`）、`vsock://cid:port`（或 `vsock:cid:port`，cid 为空表示本机任意 CID）。
+func ParseEndpoint(raw string) (Endpoint, error) {
+	raw = strings.TrimSpace(raw)
+	var ep Endpoint
+	switch {
+	case strings.HasPrefix(raw, "unix://"):
+		ep = Endpoint{Network: "unix", Addr: strings.TrimPrefix(raw, "unix://")}
+	case strings.HasPrefix(raw, "unix:"):
+		ep = Endpoint{Network: "unix", Addr: strings.TrimPrefix(raw, "unix:")}
+	case strings.HasPrefix(raw, "vsock://"):
+		ep = Endpoint{Network: "vsock", Addr: strings.TrimPrefix(raw, "vsock://")}
+	case strings.HasPrefix(raw, "vsock:"):
+		ep = Endpoint{Network: "vsock", Addr: strings.TrimPrefix(raw, "vsock:")}
+	default:
+		ep = Endpoint{Network: "tcp", Addr: raw}
+	}
+	if ep.Addr == "" {
+		return Endpoint{}, fmt.Errorf("listen address is empty: %q", raw)
+	}
+	if ep.Network == "vsock" {
+		if _, _, err := parseVsockAddr(ep.Addr); err != nil {
+			return Endpoint{}, err
+		}
+	}
+	return ep, nil
+}
+
+// parseVsockAddr 解析 `cid:port`，cid 为空时返回 nil。
+func parseVsockAddr(addr string) (cid *uint32, port uint32, err error) {
+	rawCID, rawPort, ok := strings.Cut(addr, ":")
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid vsock address %q, want cid:port", addr)
+	}
+	p, err := strconv.ParseUint(rawPort, 10, 32)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid vsock port: %w", err)
+	}
+	if rawCID == "" {
+		return nil, uint32(p), nil
+	}
+	c, err := strconv.ParseUint(rawCID, 10, 32)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid vsock cid: %w", err)
+	}
+	c32 := uint32(c)
+	return &c32, uint32(p), nil
+}
+
+// Listen 绑定 ep；unix socket 会先删除上次异常退出残留的文件。
+func Listen(ep Endpoint) (net.Listener, error) {
+	switch ep.Network {
+	case "unix":
+		_ = os.Remove(ep.Addr)
+		return net.Listen("unix", ep.Addr)
+	case "vsock":
+		cid, port, err := parseVsockAddr(ep.Addr)
+		if err != nil {
+			return nil, err
+		}
+		if cid == nil {
+			return vsock.Listen(port, nil)
+		}
+		return vsock.ListenContextID(*cid, port, nil)
+	default:
+		return net.Listen(ep.Network, ep.Addr)
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPServerAppliesConfig(t *testing.T) {
+	cfg := DefaultConfig().HTTP
+	cfg.WriteTimeout = 3 * time.Second
+	cfg.DisableHTTP2 = true
+	srv := NewHTTPServer(":0", http.NotFoundHandler(), cfg)
+	require.Equal(t, 5*time.Second, srv.ReadHeaderTimeout)
+	require.Equal(t, 60*time.Second, srv.IdleTimeout)
+	require.Equal(t, 3*time.Second, srv.WriteTimeout)
+	require.Equal(t, 64<<10, srv.MaxHeaderBytes)
+	require.NotNil(t, srv.TLSNextProto)
+	require.Empty(t, srv.TLSNextProto)
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("SIGNER_HTTP_READ_HEADER_TIMEOUT", "2s")
+	t.Setenv("SIGNER_HTTP_MAX_CONNS", "10")
+	t.Setenv("SIGNER_HTTP_DISABLE_HTTP2", "true")
+	t.Setenv("SIGNER_GRPC_MAX_CONCURRENT_STREAMS", "64")
+	t.Setenv("SIGNER_GRPC_MAX_CONN_AGE", "30m")
+	cfg := LoadConfigFromEnv()
+	require.Equal(t, 2*time.Second, cfg.HTTP.ReadHeaderTimeout)
+	require.Equal(t, 10, cfg.HTTP.MaxConns)
+	require.True(t, cfg.HTTP.DisableHTTP2)
+	require.Equal(t, uint32(64), cfg.GRPC.MaxConcurrentStreams)
+	require.Equal(t, 30*time.Minute, cfg.GRPC.keepaliveParams().MaxConnectionAge)
+	require.Len(t, GRPCServerOptions(cfg.GRPC), 2)
+	require.False(t, cfg.TLS.Enabled())
+
+	t.Setenv("SIGNER_TLS_CERT_FILE", "/tls/cert.pem")
+	t.Setenv("SIGNER_TLS_KEY_FILE", "/tls/key.pem")
+	t.Setenv("SIGNER_TLS_CLIENT_CA_FILE", "/tls/ca.pem")
+	cfg = LoadConfigFromEnv()
+	require.True(t, cfg.TLS.Enabled())
+	require.True(t, cfg.TLS.RequireClientCert)
+	t.Setenv("SIGNER_TLS_REQUIRE_CLIENT_CERT", "false")
+	require.False(t, LoadConfigFromEnv().TLS.RequireClientCert)
+}
+
+func TestLimitListenerRefusesOverLimit(t *testing.T) {
+	raw, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l := LimitListener(raw, 1)
+	defer l.Close()
+
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	first, err := net.Dial("tcp", raw.Addr().String())
+	require.NoError(t, err)
+	defer first.Close()
+	held := <-accepted
+
+	second, err := net.Dial("tcp", raw.Addr().String())
+	require.NoError(t, err)
+	defer second.Close()
+	_ = second.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = second.Read(make([]byte, 1))
+	require.ErrorIs(t, err, io.EOF, "over-limit connection must be closed")
+
+	// 释放名额后新连接可被接受。
+	require.NoError(t, held.Close())
+	third, err := net.Dial("tcp", raw.Addr().String())
+	require.NoError(t, err)
+	defer third.Close()
+	select {
+	case conn := <-accepted:
+		_ = conn.Close()
+	case <-time.After(time.Second):
+		t.Fatal("connection not accepted after slot released")
+	}
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const defaultTLSReloadInterval = 30 * time.Second
+
+// TLSConfig 描述监听器证书；ClientCAFile 非空时启用 mTLS。
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile 为校验客户端证书的 CA（PEM，可含多张）。
+	ClientCAFile string
+	// RequireClientCert 为 true 时拒绝未出示证书的客户端，否则仅校验出示的证书。
+	RequireClientCert bool
+	// ReloadInterval 为检查证书文件变更的周期，默认 30s。
+	ReloadInterval time.Duration
+}
+
+// Enabled 表示是否配置了证书。
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+type tlsSnapshot struct {
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	stamp     string
+}
+
+// CertReloader 持有当前证书与客户端 CA，并在文件变更后原子替换，已建立的连接不受影响。
+// 基于文件修改时间与大小轮询，兼容 Kubernetes Secret 卷的符号链接切换。
+type CertReloader struct {
+	cfg     TLSConfig
+	logger  *slog.Logger
+	current atomic.Pointer[tlsSnapshot]
+}
+
+// NewCertReloader 立即加载一次证书，失败时返回错误。
+func NewCertReloader(cfg TLSConfig, logger *slog.Logger) (*CertReloader, error) {
+	if !cfg.Enabled() {
+		return nil, errors.New("tls cert and key files are required")
+	}
+	if cfg.RequireClientCert && cfg.ClientCAFile == "" {
+		return nil, errors.New("tls client CA file is required when client certificates are required")
+	}
+	if cfg.ReloadInterval <= 0 {
+		cfg.ReloadInterval = defaultTLSReloadInterval
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	r := &CertReloader{cfg: cfg, logger: logger}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload 在文件有变化时重新加载，返回是否发生替换；加载失败时保留旧证书。
+func (r *CertReloader) Reload() (bool, error) {
+	stamp, err := r.stamp()
+	if err != nil {
+		return false, err
+	}
+	if cur := r.current.Load(); cur != nil && cur.stamp == stamp {
+		return false, nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return false, fmt.Errorf("load tls key pair: %w", err)
+	}
+	snap := &tlsSnapshot{cert: &cert, stamp: stamp}
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return false, fmt.Errorf("read tls client CA: %w", err)
+		}
+		snap.clientCAs = x509.NewCertPool()
+		if !snap.clientCAs.AppendCertsFromPEM(pem) {
+			return false, errors.New("tls client CA file contains no certificates")
+		}
+	}
+	r.current.Store(snap)
+	return true, nil
+}
+
+// stamp 汇总各文件的修改时间与大小，任一变化即触发重新加载。
+func (r *CertReloader) stamp() (string, error) {
+	var stamp string
+	for _, path := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("stat %s: %w", path, err)
+		}
+		stamp += fmt.Sprintf("%s:%d:%d;", path, info.ModTime().UnixNano(), info.Size())
+	}
+	return stamp, nil
+}
+
+// Run 按 ReloadInterval 轮询证书文件，直到 ctx 结束。
+func (r *CertReloader) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.ReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := r.Reload()
+			switch {
+			case err != nil:
+				r.logger.Error("tls certificate reload failed, keeping previous certificate", "cert", r.cfg.CertFile, "error", err)
+			case changed:
+				r.logger.Info("tls certificate reloaded", "cert", r.cfg.CertFile)
+			}
+		}
+	}
+}
+
+// ServerConfig 返回每次握手都读取最新证书与客户端 CA 的 tls.Config；
+// nextProtos 为 ALPN 协议（gRPC 为 h2，HTTP 为 h2 与 http/1.1）。
+func (r *CertReloader) ServerConfig(nextProtos ...string) *tls.Config {
+	clientAuth := tls.NoClientCert
+	switch {
+	case r.cfg.RequireClientCert:
+		clientAuth = tls.RequireAndVerifyClientCert
+	case r.cfg.ClientCAFile != "":
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			snap := r.current.Load()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   nextProtos,
+				Certificates: []tls.Certificate{*snap.cert},
+				ClientAuth:   clientAuth,
+				ClientCAs:    snap.clientCAs,
+			}, nil
+		},
+	}
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pair tls.Certificate
+}
+
+// issueCert 签发测试证书，parent 为 nil 时自签为 CA。
+func issueCert(t *testing.T, serial int64, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "signer-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key, pair: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}}
+}
+
+func writeCert(t *testing.T, dir string, c *testCert) (string, string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	certPath, keyPath := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certPath, keyPath
+}
+
+// serveTLS 在本地端口上完成握手后关闭连接。
+func serveTLS(t *testing.T, cfg *tls.Config) string {
+	t.Helper()
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+	return lis.Addr().String()
+}
+
+func TestCertReloaderMutualTLSAndReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueCert(t, 1, nil, x509.ExtKeyUsageAny)
+	serverCert := issueCert(t, 2, ca, x509.ExtKeyUsageServerAuth)
+	clientCert := issueCert(t, 3, ca, x509.ExtKeyUsageClientAuth)
+	certPath, keyPath := writeCert(t, dir, serverCert)
+	caPath := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600))
+
+	reloader, err := NewCertReloader(TLSConfig{CertFile: certPath, KeyFile: keyPath, ClientCAFile: caPath, RequireClientCert: true}, nil)
+	require.NoError(t, err)
+	addr := serveTLS(t, reloader.ServerConfig("h2"))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	dial := func(certs ...tls.Certificate) (*x509.Certificate, error) {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots, Certificates: certs, NextProtos: []string{"h2"}})
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		// TLS 1.3 下客户端证书在首次读取时才被服务端校验。
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		require.Equal(t, "h2", conn.ConnectionState().NegotiatedProtocol)
+		return conn.ConnectionState().PeerCertificates[0], nil
+	}
+
+	peer, err := dial(clientCert.pair)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), peer.SerialNumber.Int64())
+	_, err = dial()
+	require.Error(t, err)
+
+	// 未变化时不重新加载；替换文件后新握手使用新证书。
+	changed, err := reloader.Reload()
+	require.NoError(t, err)
+	require.False(t, changed)
+	writeCert(t, dir, issueCert(t, 4, ca, x509.ExtKeyUsageServerAuth))
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certPath, future, future))
+	changed, err = reloader.Reload()
+	require.NoError(t, err)
+	require.True(t, changed)
+	peer, err = dial(clientCert.pair)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), peer.SerialNumber.Int64())
+
+	// 损坏的文件不会替换当前证书。
+	require.NoError(t, os.WriteFile(keyPath, []byte("broken"), 0o600))
+	_, err = reloader.Reload()
+	require.Error(t, err)
+	peer, err = dial(clientCert.pair)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), peer.SerialNumber.Int64())
+}
+
+func TestNewCertReloaderValidates(t *testing.T) {
+	_, err := NewCertReloader(TLSConfig{}, nil)
+	require.Error(t, err)
+	_, err = NewCertReloader(TLSConfig{CertFile: "c", KeyFile: "k", RequireClientCert: true}, nil)
+	require.Error(t, err)
+	_, err = NewCertReloader(TLSConfig{CertFile: filepath.Join(t.TempDir(), "missing"), KeyFile: "k"}, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,66 @@
+// Package snapshot 提供本地快照文件的原子写入与读取，供缓存/统计类组件跨重启保留状态。
+package snapshot
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrNoSnapshot 表示快照文件不存在（首次启动属正常情况）。
+var ErrNoSnapshot = errors.New("snapshot: file not found")
+
+// Save 将 write 产生的内容写入临时文件，fsync 后原子 rename 到 path，
+// 写入中途失败不会破坏已有快照。
+func Save(path string, write func(io.Writer) error) (err error) {
+	if path == "" {
+		return errors.New("snapshot: path is required")
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("snapshot: create dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("snapshot: create temp: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	buf := bufio.NewWriter(tmp)
+	if err = write(buf); err != nil {
+		return err
+	}
+	if err = buf.Flush(); err != nil {
+		return fmt.Errorf("snapshot: flush: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("snapshot: sync: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("snapshot: close: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("snapshot: rename: %w", err)
+	}
+	return nil
+}
+
+// Load 打开 path 并交给 read 解析，文件不存在时返回 ErrNoSnapshot。
+func Load(path string, read func(io.Reader) error) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNoSnapshot
+	}
+	if err != nil {
+		return fmt.Errorf("snapshot: open: %w", err)
+	}
+	defer f.Close()
+	return read(bufio.NewReader(f))
+}
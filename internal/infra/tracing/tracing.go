@@ -0,0 +1,103 @@
+// Package tracing 初始化 OpenTelemetry 链路追踪，并提供 gRPC metadata 的上下文传播载体，
+// 使 HTTP/gRPC 入口、连接池借用与 Enclave RPC 落在同一条 trace 中。
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"google.golang.org/grpc/metadata"
+)
+
+// Config 描述 span 的导出方式；Endpoint 为空时不导出，span 仍会传播上游 trace 上下文。
+type Config struct {
+	// Endpoint 为 OTLP/HTTP collector 地址（host:port）。
+	Endpoint string
+	// Insecure 为 true 时以明文 HTTP 导出。
+	Insecure bool
+	// SampleRatio 为根 span 的采样比例，取值 (0,1]；带上游 trace 的请求沿用其采样决定。
+	SampleRatio    float64
+	ServiceName    string
+	ServiceVersion string
+}
+
+// Setup 安装 W3C traceparent/baggage 传播器，Endpoint 非空时再安装导出 OTLP 的全局 TracerProvider。
+// 返回的 shutdown 在退出前刷出缓冲中的 span。
+func Setup(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	if cfg.SampleRatio <= 0 || cfg.SampleRatio > 1 {
+		return nil, errors.New("tracing sample ratio must be in (0, 1]")
+	}
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+	)
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// MetadataCarrier 让 propagation.TextMapPropagator 读写 gRPC metadata。
+type MetadataCarrier metadata.MD
+
+// Get 返回 key 的首个值。
+func (c MetadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Set 覆盖 key 的值。
+func (c MetadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+// Keys 返回全部键。
+func (c MetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, strings.ToLower(k))
+	}
+	return keys
+}
+
+// Extract 从入站 metadata 中取出上游 trace 上下文。
+func Extract(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return otel.GetTextMapPropagator().Extract(ctx, MetadataCarrier(md))
+}
+
+// Inject 将 ctx 中的 trace 上下文写入出站 metadata，保留已有的键。
+func Inject(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, MetadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
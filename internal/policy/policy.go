@@ -0,0 +1,100 @@
+// Package policy 维护租户到可用 key 的授权关系，防止多租户部署中
+// 租户 A 使用租户 B 的 key 签名。
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Rule 描述一个租户可使用的 key：KeyIDs 精确匹配，KeyPrefixes 按前缀匹配。
+type Rule struct {
+	TenantID    string   `json:"tenantId"`
+	KeyIDs      []string `json:"keyIds,omitempty"`
+	KeyPrefixes []string `json:"keyPrefixes,omitempty"`
+}
+
+type grant struct {
+	keys     map[string]struct{}
+	prefixes []string
+}
+
+// Policy 是不可变的授权表，可被并发读取。
+type Policy struct {
+	tenants map[string]grant
+}
+
+// New 由规则构造 Policy；租户重复、前缀为空时返回错误。
+func New(rules []Rule) (*Policy, error) {
+	p := &Policy{tenants: make(map[string]grant, len(rules))}
+	for _, r := range rules {
+		if r.TenantID == "" {
+			return nil, errors.New("policy rule tenantId is required")
+		}
+		if _, dup := p.tenants[r.TenantID]; dup {
+			return nil, fmt.Errorf("policy for tenant %q is declared twice", r.TenantID)
+		}
+		g := grant{keys: make(map[string]struct{}, len(r.KeyIDs))}
+		for _, id := range r.KeyIDs {
+			if id == "" {
+				return nil, fmt.Errorf("policy for tenant %q has an empty keyId", r.TenantID)
+			}
+			g.keys[id] = struct{}{}
+		}
+		for _, prefix := range r.KeyPrefixes {
+			// 空前缀会匹配全部 key，视为配置错误。
+			if prefix == "" {
+				return nil, fmt.Errorf("policy for tenant %q has an empty key prefix", r.TenantID)
+			}
+			g.prefixes = append(g.prefixes, prefix)
+		}
+		p.tenants[r.TenantID] = g
+	}
+	return p, nil
+}
+
+// File 是策略文件的 JSON 结构。
+type File struct {
+	Tenants []Rule `json:"tenants"`
+}
+
+// Load 从 JSON 文件加载 Policy，未知字段视为错误。
+func Load(path string) (*Policy, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+	var file File
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("parse policy: %w", err)
+	}
+	return New(file.Tenants)
+}
+
+// HasTenant 表示租户是否声明了策略。
+func (p *Policy) HasTenant(tenantID string) bool {
+	_, ok := p.tenants[tenantID]
+	return ok
+}
+
+// Allowed 表示租户能否使用 keyID；未声明的租户一律拒绝。
+func (p *Policy) Allowed(tenantID, keyID string) bool {
+	g, ok := p.tenants[tenantID]
+	if !ok || keyID == "" {
+		return false
+	}
+	if _, ok := g.keys[keyID]; ok {
+		return true
+	}
+	for _, prefix := range g.prefixes {
+		if strings.HasPrefix(keyID, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyAllowed(t *testing.T) {
+	p, err := New([]Rule{
+		{TenantID: "a", KeyIDs: []string{"k-shared"}, KeyPrefixes: []string{"a/"}},
+		{TenantID: "b", KeyPrefixes: []string{"b/"}},
+		{TenantID: "empty"},
+	})
+	require.NoError(t, err)
+
+	require.True(t, p.Allowed("a", "a/1"))
+	require.True(t, p.Allowed("a", "k-shared"))
+	require.False(t, p.Allowed("a", "b/1"))
+	require.False(t, p.Allowed("b", "k-shared"))
+	require.False(t, p.Allowed("empty", "a/1"))
+	require.False(t, p.Allowed("unknown", "a/1"))
+	require.False(t, p.Allowed("a", ""))
+	require.True(t, p.HasTenant("empty"))
+	require.False(t, p.HasTenant("unknown"))
+
+	for _, rules := range [][]Rule{
+		{{KeyIDs: []string{"k"}}},
+		{{TenantID: "a"}, {TenantID: "a"}},
+		{{TenantID: "a", KeyPrefixes: []string{""}}},
+		{{TenantID: "a", KeyIDs: []string{""}}},
+	} {
+		_, err := New(rules)
+		require.Error(t, err, rules)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"tenants":[{"tenantId":"a","keyPrefixes":["a/"]}]}`), 0o600))
+	p, err := Load(path)
+	require.NoError(t, err)
+	require.True(t, p.Allowed("a", "a/1"))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"tenants":[],"extra":true}`), 0o600))
+	_, err = Load(path)
+	require.Error(t, err)
+	_, err = Load(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
@@ -0,0 +1,211 @@
+// Package status 汇总连接池、解锁队列、key cache 与 KMS 的瞬时状态，
+// 供事故期间通过 GET /admin/status 一次性获取，而无需抓取 Prometheus。
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/aegis-sign/wallet/internal/gateway/unlock"
+	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+	"github.com/aegis-sign/wallet/internal/infra/kms"
+)
+
+const defaultMaxKeys = 100
+
+// PoolSource 提供连接池状态，*enclaveclient.Pool 满足。
+type PoolSource interface {
+	Stats() []enclaveclient.TargetStats
+}
+
+// DispatcherSource 提供解锁队列状态，*unlock.Dispatcher 满足。
+type DispatcherSource interface {
+	Snapshot() unlock.Snapshot
+	History() unlock.History
+}
+
+// KeyCacheSource 提供 key cache 状态，*keycache.Store 满足。
+type KeyCacheSource interface {
+	Stats() keycache.StoreStats
+	Range(fn func(*keycache.Entry) bool)
+}
+
+// KMSSource 提供 KMS 健康摘要，*kms.Client 满足。
+type KMSSource interface {
+	Health() kms.Health
+}
+
+// Config 配置 Collector，未设置的来源在报告中省略。
+type Config struct {
+	Version string
+	Commit  string
+
+	Pool       PoolSource
+	Dispatcher DispatcherSource
+	KeyCache   KeyCacheSource
+	KMS        KMSSource
+
+	// MaxKeys 限制 verbose 模式下每个分区输出的 key 明细数，默认 100。
+	MaxKeys int
+	// Now 用于生成时间戳，默认 time.Now。
+	Now func() time.Time
+}
+
+// Report 是 /admin/status 的响应体。
+type Report struct {
+	GeneratedAt time.Time                   `json:"generatedAt"`
+	Build       Build                       `json:"build"`
+	Pool        []enclaveclient.TargetStats `json:"pool,omitempty"`
+	Dispatcher  *Dispatcher                 `json:"dispatcher,omitempty"`
+	KeyCache    *KeyCache                   `json:"keyCache,omitempty"`
+	KMS         *KMS                        `json:"kms,omitempty"`
+}
+
+// Build 描述构建信息。
+type Build struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Dispatcher 是解锁队列的状态与累计结果；Jobs 仅在 verbose 模式下输出。
+type Dispatcher struct {
+	QueueDepth    int                  `json:"queueDepth"`
+	InFlight      int                  `json:"inFlight"`
+	Workers       int                  `json:"workers"`
+	RateLimit     float64              `json:"rateLimit"`
+	History       unlock.History       `json:"history"`
+	Jobs          []unlock.JobSnapshot `json:"jobs,omitempty"`
+	JobsTruncated bool                 `json:"jobsTruncated,omitempty"`
+}
+
+// KeyCache 是 key cache 的状态计数；Entries 仅在 verbose 模式下输出。
+type KeyCache struct {
+	keycache.StoreStats
+	Entries          []keycache.EntryInfo `json:"entries,omitempty"`
+	EntriesTruncated bool                 `json:"entriesTruncated,omitempty"`
+}
+
+// KMS 是 KMS 客户端的健康摘要。
+type KMS struct {
+	LastError     string     `json:"lastError,omitempty"`
+	LastErrorAt   *time.Time `json:"lastErrorAt,omitempty"`
+	LastSuccessAt *time.Time `json:"lastSuccessAt,omitempty"`
+	// AttestationAgeMs 为 attestation 文档的年龄，尚未获取时为 -1。
+	AttestationAgeMs int64 `json:"attestationAgeMs"`
+}
+
+// Collector 从各来源收集状态，只调用各自的快照接口，不持有长时间的锁。
+type Collector struct {
+	cfg Config
+}
+
+// NewCollector 构造 Collector。
+func NewCollector(cfg Config) *Collector {
+	if cfg.Version == "" {
+		cfg.Version = "dev"
+	}
+	if cfg.MaxKeys <= 0 {
+		cfg.MaxKeys = defaultMaxKeys
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Collector{cfg: cfg}
+}
+
+// Collect 生成一次报告，verbose 时附带按 key 排序、截断到 MaxKeys 的明细。
+func (c *Collector) Collect(verbose bool) Report {
+	now := c.cfg.Now()
+	report := Report{
+		GeneratedAt: now,
+		Build:       Build{Version: c.cfg.Version, Commit: c.cfg.Commit, GoVersion: runtime.Version()},
+	}
+	if c.cfg.Pool != nil {
+		report.Pool = c.cfg.Pool.Stats()
+	}
+	if c.cfg.Dispatcher != nil {
+		report.Dispatcher = c.dispatcher(verbose)
+	}
+	if c.cfg.KeyCache != nil {
+		report.KeyCache = c.keyCache(verbose)
+	}
+	if c.cfg.KMS != nil {
+		report.KMS = kmsReport(c.cfg.KMS.Health(), now)
+	}
+	return report
+}
+
+func (c *Collector) dispatcher(verbose bool) *Dispatcher {
+	snap := c.cfg.Dispatcher.Snapshot()
+	out := &Dispatcher{
+		QueueDepth: snap.QueueDepth,
+		InFlight:   snap.InFlight,
+		Workers:    snap.Workers,
+		RateLimit:  snap.RateLimit,
+		History:    c.cfg.Dispatcher.History(),
+	}
+	if verbose {
+		out.Jobs = snap.Jobs
+		if len(out.Jobs) > c.cfg.MaxKeys {
+			out.Jobs = out.Jobs[:c.cfg.MaxKeys]
+			out.JobsTruncated = true
+		}
+	}
+	return out
+}
+
+func (c *Collector) keyCache(verbose bool) *KeyCache {
+	out := &KeyCache{StoreStats: c.cfg.KeyCache.Stats()}
+	if !verbose {
+		return out
+	}
+	var entries []*keycache.Entry
+	c.cfg.KeyCache.Range(func(e *keycache.Entry) bool {
+		entries = append(entries, e)
+		return true
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].KeyID() < entries[j].KeyID() })
+	if len(entries) > c.cfg.MaxKeys {
+		entries = entries[:c.cfg.MaxKeys]
+		out.EntriesTruncated = true
+	}
+	out.Entries = make([]keycache.EntryInfo, 0, len(entries))
+	for _, e := range entries {
+		out.Entries = append(out.Entries, e.Info())
+	}
+	return out
+}
+
+func kmsReport(h kms.Health, now time.Time) *KMS {
+	out := &KMS{LastError: h.LastError, AttestationAgeMs: -1}
+	if !h.LastErrorAt.IsZero() {
+		out.LastErrorAt = &h.LastErrorAt
+	}
+	if !h.LastSuccessAt.IsZero() {
+		out.LastSuccessAt = &h.LastSuccessAt
+	}
+	if !h.AttestedAt.IsZero() {
+		out.AttestationAgeMs = now.Sub(h.AttestedAt).Milliseconds()
+	}
+	return out
+}
+
+// ServeHTTP 处理 GET /admin/status，?verbose=1 时附带 key 明细。
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "GET required", http.StatusMethodNotAllowed)
+		return
+	}
+	verbose := false
+	switch r.URL.Query().Get("verbose") {
+	case "1", "true":
+		verbose = true
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c.Collect(verbose))
+}
@@ -0,0 +1,137 @@
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aegis-sign/wallet/internal/app/backend/keycache"
+	"github.com/aegis-sign/wallet/internal/gateway/unlock"
+	"github.com/aegis-sign/wallet/internal/infra/enclaveclient"
+	"github.com/aegis-sign/wallet/internal/infra/kms"
+	"github.com/stretchr/testify/require"
+)
+
+type stubPool []enclaveclient.TargetStats
+
+func (p stubPool) Stats() []enclaveclient.TargetStats { return p }
+
+type stubDispatcher struct {
+	snap    unlock.Snapshot
+	history unlock.History
+}
+
+func (d stubDispatcher) Snapshot() unlock.Snapshot { return d.snap }
+func (d stubDispatcher) History() unlock.History   { return d.history }
+
+type stubKMS kms.Health
+
+func (k stubKMS) Health() kms.Health { return kms.Health(k) }
+
+var statusNow = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func newTestCollector(t *testing.T) *Collector {
+	t.Helper()
+	store := keycache.NewStore(keycache.StoreConfig{})
+	for i := 0; i < 5; i++ {
+		keyspace := "prod"
+		if i%2 == 1 {
+			keyspace = "staging"
+		}
+		e, err := keycache.NewEntry(keycache.EntryConfig{
+			KeyID:       fmt.Sprintf("k%d", 4-i),
+			Enclave:     "enc-a",
+			Keyspace:    keyspace,
+			HasPlainKey: i < 2,
+			CipherBlob:  []byte("blob"),
+		})
+		require.NoError(t, err)
+		store.Put(e)
+	}
+	var jobs []unlock.JobSnapshot
+	for i := 0; i < 4; i++ {
+		jobs = append(jobs, unlock.JobSnapshot{Key: fmt.Sprintf("j%d", i), Attempts: 1})
+	}
+	return NewCollector(Config{
+		Version: "1.2.3",
+		Pool:    stubPool{{ID: "enc-a", Open: 4, Idle: 1, InUse: 3, MaxConns: 8, Breaker: "healthy"}},
+		Dispatcher: stubDispatcher{
+			snap:    unlock.Snapshot{QueueDepth: 2, InFlight: 4, Workers: 16, Jobs: jobs},
+			history: unlock.History{Executed: 10, Failed: 1, Retried: 3},
+		},
+		KeyCache: store,
+		KMS:      stubKMS{LastError: "throttled", LastErrorAt: statusNow.Add(-time.Minute), AttestedAt: statusNow.Add(-90 * time.Second)},
+		MaxKeys:  3,
+		Now:      func() time.Time { return statusNow },
+	})
+}
+
+func getStatus(t *testing.T, c *Collector, query string) map[string]any {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	c.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/status"+query, nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	return body
+}
+
+func TestStatusReportShape(t *testing.T) {
+	body := getStatus(t, newTestCollector(t), "")
+	require.Equal(t, "2026-01-02T03:04:05Z", body["generatedAt"])
+	require.Equal(t, "1.2.3", body["build"].(map[string]any)["version"])
+
+	pool := body["pool"].([]any)
+	require.Len(t, pool, 1)
+	require.Equal(t, "enc-a", pool[0].(map[string]any)["id"])
+	require.Equal(t, 3.0, pool[0].(map[string]any)["inUse"])
+
+	dispatcher := body["dispatcher"].(map[string]any)
+	require.Equal(t, 2.0, dispatcher["queueDepth"])
+	require.Equal(t, 10.0, dispatcher["history"].(map[string]any)["executed"])
+	require.NotContains(t, dispatcher, "jobs")
+
+	cache := body["keyCache"].(map[string]any)
+	require.Equal(t, 5.0, cache["total"])
+	require.Equal(t, map[string]any{"WARM": 2.0, "COOL": 3.0}, cache["byState"])
+	require.Equal(t, map[string]any{"WARM": 1.0, "COOL": 2.0}, cache["byKeyspace"].(map[string]any)["prod"])
+	require.NotContains(t, cache, "entries")
+
+	kmsBody := body["kms"].(map[string]any)
+	require.Equal(t, "throttled", kmsBody["lastError"])
+	require.Equal(t, 90000.0, kmsBody["attestationAgeMs"])
+	require.NotContains(t, kmsBody, "lastSuccessAt")
+}
+
+func TestStatusVerboseCapsPerKeyDetails(t *testing.T) {
+	body := getStatus(t, newTestCollector(t), "?verbose=1")
+
+	dispatcher := body["dispatcher"].(map[string]any)
+	require.Len(t, dispatcher["jobs"], 3)
+	require.Equal(t, true, dispatcher["jobsTruncated"])
+
+	cache := body["keyCache"].(map[string]any)
+	entries := cache["entries"].([]any)
+	require.Len(t, entries, 3)
+	require.Equal(t, true, cache["entriesTruncated"])
+	var keys []string
+	for _, e := range entries {
+		keys = append(keys, e.(map[string]any)["keyId"].(string))
+	}
+	require.Equal(t, []string{"k0", "k1", "k2"}, keys)
+	require.NotContains(t, entries[0], "cipherBlob")
+}
+
+func TestStatusOmitsMissingSources(t *testing.T) {
+	body := getStatus(t, NewCollector(Config{}), "?verbose=1")
+	require.Equal(t, "dev", body["build"].(map[string]any)["version"])
+	for _, section := range []string{"pool", "dispatcher", "keyCache", "kms"} {
+		require.NotContains(t, body, section)
+	}
+	rr := httptest.NewRecorder()
+	NewCollector(Config{}).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/status", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
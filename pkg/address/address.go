@@ -0,0 +1,228 @@
+// Package address 由 secp256k1 公钥确定性地派生各链地址。服务端在 Create 时按调用方请求的格式
+// 返回地址，调用方不再各自实现校验和、前缀与哈希方式。
+package address
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aegis-sign/wallet/pkg/sigverify"
+	"golang.org/x/crypto/ripemd160"
+	"golang.org/x/crypto/sha3"
+)
+
+// Format 是地址格式名。
+type Format string
+
+const (
+	// FormatEthereum 为 EIP-55 大小写校验和的 0x 地址。
+	FormatEthereum Format = "eth"
+	// FormatTron 为 TRON 主网 base58check 地址（0x41 前缀，以 T 开头）。
+	FormatTron Format = "tron"
+	// FormatBech32 为比特币主网 BIP-173 P2WPKH 地址（bc1q 开头），基于压缩公钥。
+	FormatBech32 Format = "bech32"
+)
+
+// Curve 是所有地址格式要求的公钥曲线。
+const Curve = "secp256k1"
+
+// ErrUnsupportedFormat 表示地址格式未登记。
+var ErrUnsupportedFormat = errors.New("unsupported address format")
+
+// derivers 登记所有受支持格式，新增格式只需在此追加一项。
+var derivers = map[Format]func(pub []byte) (string, error){
+	FormatEthereum: Ethereum,
+	FormatTron:     Tron,
+	FormatBech32:   func(pub []byte) (string, error) { return SegwitV0("bc", pub) },
+}
+
+// ParseFormat 按名称（忽略大小写与首尾空白）查找格式。
+func ParseFormat(name string) (Format, error) {
+	f := Format(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := derivers[f]; !ok {
+		return "", fmt.Errorf("%w %q (supported: %s)", ErrUnsupportedFormat, name, strings.Join(Formats(), ", "))
+	}
+	return f, nil
+}
+
+// Formats 返回按字典序排列的格式名。
+func Formats() []string {
+	names := make([]string, 0, len(derivers))
+	for f := range derivers {
+		names = append(names, string(f))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Derive 按格式由压缩（33B）或未压缩（65B）secp256k1 公钥派生地址。
+func Derive(f Format, pub []byte) (string, error) {
+	derive, ok := derivers[f]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnsupportedFormat, f)
+	}
+	return derive(pub)
+}
+
+// Ethereum 返回 EIP-55 校验和地址。
+func Ethereum(pub []byte) (string, error) {
+	addr, err := sigverify.EthereumAddress(pub)
+	if err != nil {
+		return "", err
+	}
+	return ChecksumEthereum(addr)
+}
+
+// ChecksumEthereum 将 40 位十六进制地址（可带 0x，大小写不限）转换为 EIP-55 校验和形式。
+func ChecksumEthereum(addr string) (string, error) {
+	lower := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(addr, "0x"), "0X"))
+	if len(lower) != 40 {
+		return "", fmt.Errorf("ethereum address must be 20 bytes, got %q", addr)
+	}
+	if _, err := hex.DecodeString(lower); err != nil {
+		return "", fmt.Errorf("ethereum address must be hex: %w", err)
+	}
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(lower))
+	hash := h.Sum(nil)
+	out := []byte(lower)
+	for i, c := range out {
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if c >= 'a' && nibble >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(out), nil
+}
+
+// Tron 返回 TRON 地址：base58check(0x41 || keccak256(X||Y)[12:])，与以太坊共用账户哈希。
+func Tron(pub []byte) (string, error) {
+	addr, err := sigverify.EthereumAddress(pub)
+	if err != nil {
+		return "", err
+	}
+	raw, _ := hex.DecodeString(strings.TrimPrefix(addr, "0x"))
+	return base58Check(append([]byte{0x41}, raw...)), nil
+}
+
+// SegwitV0 返回 hrp 网络上的 P2WPKH 地址：bech32(hrp, 0, HASH160(压缩公钥))。
+func SegwitV0(hrp string, pub []byte) (string, error) {
+	x, y, err := sigverify.ParseSecp256k1PublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	compressed := make([]byte, 33)
+	compressed[0] = 0x02 | byte(y.Bit(0))
+	x.FillBytes(compressed[1:])
+	sum := sha256.Sum256(compressed)
+	h := ripemd160.New()
+	h.Write(sum[:])
+	program := convertBits(h.Sum(nil), 8, 5)
+	return bech32Encode(hrp, append([]byte{0}, program...)), nil
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Check 追加双 SHA-256 的前 4 字节校验和后按 base58 编码。
+func base58Check(payload []byte) string {
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	data := append(append([]byte{}, payload...), second[:4]...)
+	zeros := 0
+	for zeros < len(data) && data[zeros] == 0 {
+		zeros++
+	}
+	// 逐字节做大数除以 58，digits 为小端的 base58 位。
+	var digits []byte
+	for _, b := range data {
+		carry := int(b)
+		for i := range digits {
+			carry += int(digits[i]) << 8
+			digits[i] = byte(carry % 58)
+			carry /= 58
+		}
+		for carry > 0 {
+			digits = append(digits, byte(carry%58))
+			carry /= 58
+		}
+	}
+	out := make([]byte, 0, zeros+len(digits))
+	for range zeros {
+		out = append(out, base58Alphabet[0])
+	}
+	for i := len(digits) - 1; i >= 0; i-- {
+		out = append(out, base58Alphabet[digits[i]])
+	}
+	return string(out)
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32Encode 按 BIP-173 编码 5 bit 分组的数据。
+func bech32Encode(hrp string, data []byte) string {
+	values := append(bech32HRPExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ 1
+	var b strings.Builder
+	b.WriteString(hrp)
+	b.WriteByte('1')
+	for _, d := range data {
+		b.WriteByte(bech32Charset[d])
+	}
+	for i := 0; i < 6; i++ {
+		b.WriteByte(bech32Charset[(polymod>>(5*(5-i)))&31])
+	}
+	return b.String()
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	out := make([]byte, 0, 2*len(hrp)+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+// convertBits 将 from 位分组重新打包为 to 位分组，末尾不足时补零。
+func convertBits(data []byte, from, to uint) []byte {
+	var acc, bits uint
+	maxv := uint(1)<<to - 1
+	out := make([]byte, 0, len(data)*int(from)/int(to)+1)
+	for _, b := range data {
+		acc = acc<<from | uint(b)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if bits > 0 {
+		out = append(out, byte(acc<<(to-bits)&maxv))
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package address
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// 私钥 1 的公钥（生成元 G），各格式的地址均为公开测试向量。
+const (
+	generatorCompressed   = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	generatorUncompressed = "0479be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"
+)
+
+func TestDeriveKnownVectors(t *testing.T) {
+	want := map[Format]string{
+		FormatEthereum: "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf",
+		FormatTron:     "TMVQGm1qAQYVdetCeGRRkTWYYrLXuHK2HC",
+		FormatBech32:   "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+	}
+	for _, encoded := range []string{generatorCompressed, generatorUncompressed} {
+		pub, _ := hex.DecodeString(encoded)
+		for format, addr := range want {
+			got, err := Derive(format, pub)
+			if err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			if got != addr {
+				t.Fatalf("%s: got %s want %s", format, got, addr)
+			}
+		}
+	}
+	if _, err := Derive(FormatTron, make([]byte, 33)); err == nil {
+		t.Fatal("invalid public key must be rejected")
+	}
+}
+
+func TestChecksumEthereum(t *testing.T) {
+	// EIP-55 规范中的示例。
+	for _, addr := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		got, err := ChecksumEthereum(addr[2:])
+		if err != nil || got != addr {
+			t.Fatalf("checksum %s: got %s err %v", addr, got, err)
+		}
+	}
+	if _, err := ChecksumEthereum("0x1234"); err == nil {
+		t.Fatal("short address must be rejected")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat(" ETH "); err != nil || f != FormatEthereum {
+		t.Fatalf("got %q err %v", f, err)
+	}
+	if _, err := ParseFormat("solana"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
@@ -12,31 +12,45 @@ import (
 type Code string
 
 const (
-	CodeInvalidArgument Code = "INVALID_ARGUMENT"
-	CodeRetryLater      Code = "RETRY_LATER"
-	CodeUnlockRequired  Code = "UNLOCK_REQUIRED"
-	CodeInvalidKey      Code = "INVALID_KEY"
+	CodeInvalidArgument    Code = "INVALID_ARGUMENT"
+	CodeRetryLater         Code = "RETRY_LATER"
+	CodeUnlockRequired     Code = "UNLOCK_REQUIRED"
+	CodeInvalidKey         Code = "INVALID_KEY"
+	CodeReadOnly           Code = "READ_ONLY"
+	CodeEnclaveUnavailable Code = "ENCLAVE_UNAVAILABLE"
+	CodeUnauthenticated    Code = "UNAUTHENTICATED"
+	CodePermissionDenied   Code = "PERMISSION_DENIED"
 )
 
 var httpStatusMap = map[Code]int{
-	CodeInvalidArgument: 400,
-	CodeRetryLater:      429,
-	CodeUnlockRequired:  503,
-	CodeInvalidKey:      404,
+	CodeInvalidArgument:    400,
+	CodeRetryLater:         429,
+	CodeUnlockRequired:     503,
+	CodeInvalidKey:         404,
+	CodeReadOnly:           503,
+	CodeEnclaveUnavailable: 503,
+	CodeUnauthenticated:    401,
+	CodePermissionDenied:   403,
 }
 
 var grpcStatusMap = map[Code]codes.Code{
-	CodeInvalidArgument: codes.InvalidArgument,
-	CodeRetryLater:      codes.ResourceExhausted,
-	CodeUnlockRequired:  codes.Unavailable,
-	CodeInvalidKey:      codes.NotFound,
+	CodeInvalidArgument:    codes.InvalidArgument,
+	CodeRetryLater:         codes.ResourceExhausted,
+	CodeUnlockRequired:     codes.Unavailable,
+	CodeInvalidKey:         codes.NotFound,
+	CodeReadOnly:           codes.Unavailable,
+	CodeEnclaveUnavailable: codes.Unavailable,
+	CodeUnauthenticated:    codes.Unauthenticated,
+	CodePermissionDenied:   codes.PermissionDenied,
 }
 
 // Error 表示带统一错误码的业务错误。
 type Error struct {
-	Code       Code
-	Message    string
-	retryAfter time.Duration
+	Code          Code
+	Message       string
+	retryAfter    time.Duration
+	retryAfterSet bool // 区分"未设置"与显式的 0（可立即重试）
+	cause         error
 }
 
 // New 创建一个新的业务错误。
@@ -44,17 +58,50 @@ func New(code Code, message string) *Error {
 	return &Error{Code: code, Message: message}
 }
 
-// WithRetryAfter 设置 Retry-After 提示，返回自身方便链式调用。
+// Wrap 创建携带底层原因的业务错误，errors.Is/As 可穿透到 cause。
+func Wrap(code Code, message string, cause error) *Error {
+	return &Error{Code: code, Message: message, cause: cause}
+}
+
+// Unwrap 返回底层原因。
+func (e *Error) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.cause
+}
+
+// RetryAfter 返回显式设置的退避时长，未设置时为 0。
+func (e *Error) RetryAfter() time.Duration {
+	if e == nil {
+		return 0
+	}
+	return e.retryAfter
+}
+
+// WithRetryAfter 设置 Retry-After 提示，返回自身方便链式调用；d<=0 表示可立即重试。
 func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	if d < 0 {
+		d = 0
+	}
 	e.retryAfter = d
+	e.retryAfterSet = true
 	return e
 }
 
+// HasRetryAfter 表示是否显式设置过 Retry-After（包括 0）。
+func (e *Error) HasRetryAfter() bool {
+	return e != nil && e.retryAfterSet
+}
+
 // RetryAfterHint 以秒为单位返回 Retry-After 提示文本。
 func (e *Error) RetryAfterHint() string {
-	if e == nil || e.retryAfter <= 0 {
+	if e == nil || !e.retryAfterSet {
 		return ""
 	}
+	if e.retryAfter <= 0 {
+		return "0"
+	}
 	seconds := int((e.retryAfter + time.Second - 1) / time.Second)
 	if seconds <= 0 {
 		seconds = 1
@@ -1,6 +1,7 @@
 package apierrors
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -10,11 +11,15 @@ import (
 
 func TestHTTPStatus(t *testing.T) {
 	cases := map[Code]int{
-		CodeInvalidArgument: 400,
-		CodeRetryLater:      429,
-		CodeUnlockRequired:  503,
-		CodeInvalidKey:      404,
-		Code("UNKNOWN"):     500,
+		CodeInvalidArgument:    400,
+		CodeRetryLater:         429,
+		CodeUnlockRequired:     503,
+		CodeInvalidKey:         404,
+		CodeReadOnly:           503,
+		CodeEnclaveUnavailable: 503,
+		CodeUnauthenticated:    401,
+		CodePermissionDenied:   403,
+		Code("UNKNOWN"):        500,
 	}
 
 	for code, want := range cases {
@@ -26,11 +31,15 @@ func TestHTTPStatus(t *testing.T) {
 
 func TestGRPCStatus(t *testing.T) {
 	cases := map[Code]codes.Code{
-		CodeInvalidArgument: codes.InvalidArgument,
-		CodeRetryLater:      codes.ResourceExhausted,
-		CodeUnlockRequired:  codes.Unavailable,
-		CodeInvalidKey:      codes.NotFound,
-		Code("UNKNOWN"):     codes.Internal,
+		CodeInvalidArgument:    codes.InvalidArgument,
+		CodeRetryLater:         codes.ResourceExhausted,
+		CodeUnlockRequired:     codes.Unavailable,
+		CodeInvalidKey:         codes.NotFound,
+		CodeReadOnly:           codes.Unavailable,
+		CodeEnclaveUnavailable: codes.Unavailable,
+		CodeUnauthenticated:    codes.Unauthenticated,
+		CodePermissionDenied:   codes.PermissionDenied,
+		Code("UNKNOWN"):        codes.Internal,
 	}
 
 	for code, want := range cases {
@@ -63,6 +72,10 @@ func TestErrorRetryAfterHint(t *testing.T) {
 	if hint := New(CodeRetryLater, "").RetryAfterHint(); hint != "" {
 		t.Fatalf("expected empty hint, got %q", hint)
 	}
+	immediate := New(CodeRetryLater, "").WithRetryAfter(0)
+	if !immediate.HasRetryAfter() || immediate.RetryAfterHint() != "0" {
+		t.Fatalf("explicit zero retry-after lost, hint=%q", immediate.RetryAfterHint())
+	}
 }
 
 func TestFromError(t *testing.T) {
@@ -77,3 +90,14 @@ func TestFromError(t *testing.T) {
 		t.Fatal("should not unwrap plain error")
 	}
 }
+
+func TestWrapExposesCause(t *testing.T) {
+	cause := errors.New("acquire timeout")
+	err := Wrap(CodeRetryLater, "enclave pool saturated", cause)
+	if !errors.Is(err, cause) {
+		t.Fatal("expected errors.Is to reach cause")
+	}
+	if err.Error() != "enclave pool saturated" {
+		t.Fatalf("unexpected Error(): %s", err.Error())
+	}
+}
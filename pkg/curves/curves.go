@@ -0,0 +1,71 @@
+// Package curves 是签名服务支持曲线的唯一登记处，校验、API 与 OpenAPI 均以此为准。
+package curves
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultName 是请求未指定曲线时使用的曲线。
+const DefaultName = "secp256k1"
+
+// ErrUnsupportedCurve 表示曲线未在登记处注册。
+var ErrUnsupportedCurve = errors.New("unsupported curve")
+
+// Curve 描述一条曲线在签名路径上的属性。
+type Curve struct {
+	Name string
+	// DigestSize 是待签名摘要的字节数。
+	DigestSize int
+	// SignatureSize 是紧凑格式（r||s）签名的字节数，不含恢复 id。
+	SignatureSize int
+	// HasRecoveryID 表示签名是否附带可用于恢复公钥的 recId。
+	HasRecoveryID bool
+}
+
+// registry 登记所有受支持曲线，新增曲线只需在此追加一项。
+var registry = map[string]Curve{
+	"secp256k1": {Name: "secp256k1", DigestSize: 32, SignatureSize: 64, HasRecoveryID: true},
+	"ed25519":   {Name: "ed25519", DigestSize: 32, SignatureSize: 64},
+}
+
+// Lookup 按名称（忽略大小写，空串视为默认曲线）查找曲线。
+func Lookup(name string) (Curve, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" {
+		key = DefaultName
+	}
+	c, ok := registry[key]
+	if !ok {
+		return Curve{}, fmt.Errorf("%w %q (supported: %s)", ErrUnsupportedCurve, name, strings.Join(Names(), ", "))
+	}
+	return c, nil
+}
+
+// Default 返回默认曲线。
+func Default() Curve {
+	return registry[DefaultName]
+}
+
+// Names 返回按字典序排列的曲线名称。
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidDigestSize 判断 n 是否为任一受支持曲线的摘要长度。
+// Sign 请求不携带曲线，只能按登记处的并集校验。
+func ValidDigestSize(n int) bool {
+	for _, c := range registry {
+		if c.DigestSize == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package curves
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	c, err := Lookup("")
+	if err != nil || c.Name != DefaultName {
+		t.Fatalf("empty name should resolve to default, got %+v err=%v", c, err)
+	}
+	c, err = Lookup("SECP256K1")
+	if err != nil {
+		t.Fatalf("lookup should ignore case: %v", err)
+	}
+	if c.DigestSize != 32 || c.SignatureSize != 64 || !c.HasRecoveryID {
+		t.Fatalf("unexpected secp256k1 properties %+v", c)
+	}
+	if c, err := Lookup("Ed25519"); err != nil || c.HasRecoveryID || c.SignatureSize != 64 {
+		t.Fatalf("unexpected ed25519 properties %+v err=%v", c, err)
+	}
+	if _, err := Lookup("p-521"); !errors.Is(err, ErrUnsupportedCurve) {
+		t.Fatalf("expected ErrUnsupportedCurve, got %v", err)
+	}
+}
+
+func TestValidDigestSize(t *testing.T) {
+	if !ValidDigestSize(32) {
+		t.Fatal("32 bytes must be valid")
+	}
+	if ValidDigestSize(20) {
+		t.Fatal("20 bytes must be rejected")
+	}
+}
@@ -0,0 +1,400 @@
+// Package eip712 按 EIP-712 规范计算 typed data 的签名摘要：
+// keccak256(0x19 0x01 || domainSeparator || hashStruct(primaryType, message))。
+package eip712
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// DomainType 是 domain 的类型名，types 中必须声明。
+const DomainType = "EIP712Domain"
+
+// Field 是结构体类型中的一个成员。
+type Field struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData 对应 eth_signTypedData_v4 的 JSON 载荷。
+type TypedData struct {
+	Types       map[string][]Field `json:"types"`
+	PrimaryType string             `json:"primaryType"`
+	Domain      map[string]any     `json:"domain"`
+	Message     map[string]any     `json:"message"`
+}
+
+var (
+	typeNamePattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+	arrayPattern    = regexp.MustCompile(`^(.+)\[([0-9]*)\]$`)
+)
+
+// Parse 解析并校验 typed data；数字以 json.Number 保留，避免 uint256 经 float64 丢失精度。
+func Parse(raw []byte) (*TypedData, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var td TypedData
+	if err := dec.Decode(&td); err != nil {
+		return nil, fmt.Errorf("invalid typed data JSON: %w", err)
+	}
+	if err := td.validate(); err != nil {
+		return nil, err
+	}
+	return &td, nil
+}
+
+func (td *TypedData) validate() error {
+	if _, ok := td.Types[DomainType]; !ok {
+		return fmt.Errorf("types must declare %s", DomainType)
+	}
+	if _, ok := td.Types[td.PrimaryType]; !ok {
+		return fmt.Errorf("primaryType %q is not declared in types", td.PrimaryType)
+	}
+	for name, fields := range td.Types {
+		if !typeNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid type name %q", name)
+		}
+		seen := make(map[string]struct{}, len(fields))
+		for _, f := range fields {
+			if f.Name == "" {
+				return fmt.Errorf("type %s has a field without name", name)
+			}
+			if _, dup := seen[f.Name]; dup {
+				return fmt.Errorf("type %s declares field %q twice", name, f.Name)
+			}
+			seen[f.Name] = struct{}{}
+			if !td.knownType(f.Type) {
+				return fmt.Errorf("type %s field %s: unknown type %q", name, f.Name, f.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// knownType 判断 typ 是否为原子类型、已声明的结构体或它们的数组。
+func (td *TypedData) knownType(typ string) bool {
+	if m := arrayPattern.FindStringSubmatch(typ); m != nil {
+		return td.knownType(m[1])
+	}
+	if _, ok := td.Types[typ]; ok {
+		return true
+	}
+	_, ok := atomicSize(typ)
+	return ok || typ == "string" || typ == "bytes"
+}
+
+// Hash 返回待签名的 32 字节摘要；primaryType 为 EIP712Domain 时仅包含 domainSeparator。
+func (td *TypedData) Hash() ([32]byte, error) {
+	domain, err := td.DomainSeparator()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	payload := append([]byte{0x19, 0x01}, domain[:]...)
+	if td.PrimaryType != DomainType {
+		msg, err := td.HashStruct(td.PrimaryType, td.Message)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		payload = append(payload, msg[:]...)
+	}
+	return keccak256(payload), nil
+}
+
+// DomainSeparator 返回 hashStruct(EIP712Domain, domain)。
+func (td *TypedData) DomainSeparator() ([32]byte, error) {
+	sep, err := td.HashStruct(DomainType, td.Domain)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("domain: %w", err)
+	}
+	return sep, nil
+}
+
+// HashStruct 返回 keccak256(typeHash || encodeData(data))。
+func (td *TypedData) HashStruct(typ string, data map[string]any) ([32]byte, error) {
+	fields, ok := td.Types[typ]
+	if !ok {
+		return [32]byte{}, fmt.Errorf("unknown type %q", typ)
+	}
+	encType, err := td.EncodeType(typ)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	typeHash := keccak256([]byte(encType))
+	buf := make([]byte, 0, 32*(len(fields)+1))
+	buf = append(buf, typeHash[:]...)
+	for _, f := range fields {
+		v, ok := data[f.Name]
+		if !ok {
+			return [32]byte{}, fmt.Errorf("%s: missing field %q", typ, f.Name)
+		}
+		word, err := td.encodeValue(f.Type, v)
+		if err != nil {
+			return [32]byte{}, fmt.Errorf("%s.%s: %w", typ, f.Name, err)
+		}
+		buf = append(buf, word[:]...)
+	}
+	if len(data) != len(fields) {
+		for name := range data {
+			if !hasField(fields, name) {
+				return [32]byte{}, fmt.Errorf("%s: unknown field %q", typ, name)
+			}
+		}
+	}
+	return keccak256(buf), nil
+}
+
+// EncodeType 返回 typ 的类型串：自身定义在前，引用到的结构体按名称排序拼接在后。
+func (td *TypedData) EncodeType(typ string) (string, error) {
+	deps := make(map[string]struct{})
+	td.collectDeps(typ, deps)
+	delete(deps, typ)
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range append([]string{typ}, names...) {
+		fields, ok := td.Types[name]
+		if !ok {
+			return "", fmt.Errorf("unknown type %q", name)
+		}
+		b.WriteString(name)
+		b.WriteByte('(')
+		for i, f := range fields {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(f.Type)
+			b.WriteByte(' ')
+			b.WriteString(f.Name)
+		}
+		b.WriteByte(')')
+	}
+	return b.String(), nil
+}
+
+func (td *TypedData) collectDeps(typ string, deps map[string]struct{}) {
+	typ = baseType(typ)
+	if _, seen := deps[typ]; seen {
+		return
+	}
+	fields, ok := td.Types[typ]
+	if !ok {
+		return
+	}
+	deps[typ] = struct{}{}
+	for _, f := range fields {
+		td.collectDeps(f.Type, deps)
+	}
+}
+
+// encodeValue 把单个成员编码为 32 字节：动态类型与结构体、数组取哈希，原子类型左/右填充。
+func (td *TypedData) encodeValue(typ string, v any) ([32]byte, error) {
+	if m := arrayPattern.FindStringSubmatch(typ); m != nil {
+		items, ok := v.([]any)
+		if !ok {
+			return [32]byte{}, fmt.Errorf("expected array for %s", typ)
+		}
+		if m[2] != "" {
+			if n, _ := strconv.Atoi(m[2]); n != len(items) {
+				return [32]byte{}, fmt.Errorf("%s expects %d items, got %d", typ, n, len(items))
+			}
+		}
+		buf := make([]byte, 0, 32*len(items))
+		for i, item := range items {
+			word, err := td.encodeValue(m[1], item)
+			if err != nil {
+				return [32]byte{}, fmt.Errorf("[%d]: %w", i, err)
+			}
+			buf = append(buf, word[:]...)
+		}
+		return keccak256(buf), nil
+	}
+	if _, ok := td.Types[typ]; ok {
+		data, ok := v.(map[string]any)
+		if !ok {
+			return [32]byte{}, fmt.Errorf("expected object for %s", typ)
+		}
+		return td.HashStruct(typ, data)
+	}
+	switch typ {
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return [32]byte{}, errors.New("expected string")
+		}
+		return keccak256([]byte(s)), nil
+	case "bytes":
+		b, err := decodeHex(v)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		return keccak256(b), nil
+	}
+	return encodeAtomic(typ, v)
+}
+
+func encodeAtomic(typ string, v any) ([32]byte, error) {
+	var word [32]byte
+	switch {
+	case typ == "bool":
+		b, ok := v.(bool)
+		if !ok {
+			return word, errors.New("expected bool")
+		}
+		if b {
+			word[31] = 1
+		}
+		return word, nil
+	case typ == "address":
+		b, err := decodeHex(v)
+		if err != nil {
+			return word, err
+		}
+		if len(b) != 20 {
+			return word, fmt.Errorf("address must be 20 bytes, got %d", len(b))
+		}
+		copy(word[12:], b)
+		return word, nil
+	case strings.HasPrefix(typ, "bytes"):
+		n, _ := atomicSize(typ)
+		b, err := decodeHex(v)
+		if err != nil {
+			return word, err
+		}
+		if len(b) != n {
+			return word, fmt.Errorf("%s must be %d bytes, got %d", typ, n, len(b))
+		}
+		copy(word[:], b)
+		return word, nil
+	}
+	bits, ok := atomicSize(typ)
+	if !ok {
+		return word, fmt.Errorf("unsupported type %q", typ)
+	}
+	n, err := parseInteger(v)
+	if err != nil {
+		return word, err
+	}
+	signed := strings.HasPrefix(typ, "int")
+	if !inRange(n, bits, signed) {
+		return word, fmt.Errorf("value %s out of range for %s", n, typ)
+	}
+	if n.Sign() < 0 {
+		// 负数按 256 位补码编码。
+		n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+	n.FillBytes(word[:])
+	return word, nil
+}
+
+// atomicSize 返回 bytesN 的字节数或 uintN/intN 的位数。
+func atomicSize(typ string) (int, bool) {
+	var digits string
+	var lo, hi, step int
+	switch {
+	case strings.HasPrefix(typ, "bytes"):
+		digits, lo, hi, step = typ[len("bytes"):], 1, 32, 1
+	case strings.HasPrefix(typ, "uint"):
+		digits, lo, hi, step = typ[len("uint"):], 8, 256, 8
+	case strings.HasPrefix(typ, "int"):
+		digits, lo, hi, step = typ[len("int"):], 8, 256, 8
+	case typ == "bool" || typ == "address":
+		return 0, true
+	default:
+		return 0, false
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil || strconv.Itoa(n) != digits || n < lo || n > hi || n%step != 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// parseInteger 接受 JSON 整数、十进制字符串或 0x 前缀十六进制字符串。
+func parseInteger(v any) (*big.Int, error) {
+	var s string
+	switch x := v.(type) {
+	case json.Number:
+		s = x.String()
+	case string:
+		s = strings.TrimSpace(x)
+	default:
+		return nil, errors.New("expected integer")
+	}
+	neg := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(s, "-")
+	base := 10
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		digits, base = digits[2:], 16
+	}
+	n, ok := new(big.Int).SetString(digits, base)
+	if !ok || digits == "" {
+		return nil, fmt.Errorf("invalid integer %q", s)
+	}
+	if neg {
+		n.Neg(n)
+	}
+	return n, nil
+}
+
+func inRange(n *big.Int, bits int, signed bool) bool {
+	if !signed {
+		return n.Sign() >= 0 && n.BitLen() <= bits
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+	return n.Cmp(new(big.Int).Neg(limit)) >= 0 && n.Cmp(limit) < 0
+}
+
+func decodeHex(v any) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, errors.New("expected hex string")
+	}
+	if len(s) >= 2 && (s[:2] == "0x" || s[:2] == "0X") {
+		s = s[2:]
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex: %w", err)
+	}
+	return b, nil
+}
+
+func baseType(typ string) string {
+	for {
+		m := arrayPattern.FindStringSubmatch(typ)
+		if m == nil {
+			return typ
+		}
+		typ = m[1]
+	}
+}
+
+func hasField(fields []Field, name string) bool {
+	for _, f := range fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func keccak256(b []byte) [32]byte {
+	var out [32]byte
+	h := sha3.NewLegacyKeccak256()
+	h.Write(b)
+	h.Sum(out[:0])
+	return out
+}
@@ -0,0 +1,134 @@
+package eip712
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// mailExample 是 EIP-712 规范中的示例载荷。
+const mailExample = `{
+  "types": {
+    "EIP712Domain": [
+      {"name": "name", "type": "string"},
+      {"name": "version", "type": "string"},
+      {"name": "chainId", "type": "uint256"},
+      {"name": "verifyingContract", "type": "address"}
+    ],
+    "Person": [
+      {"name": "name", "type": "string"},
+      {"name": "wallet", "type": "address"}
+    ],
+    "Mail": [
+      {"name": "from", "type": "Person"},
+      {"name": "to", "type": "Person"},
+      {"name": "contents", "type": "string"}
+    ]
+  },
+  "primaryType": "Mail",
+  "domain": {
+    "name": "Ether Mail",
+    "version": "1",
+    "chainId": 1,
+    "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+  },
+  "message": {
+    "from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+    "to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+    "contents": "Hello, Bob!"
+  }
+}`
+
+func TestMailExampleVector(t *testing.T) {
+	td, err := Parse([]byte(mailExample))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	encType, err := td.EncodeType("Mail")
+	if err != nil || encType != "Mail(Person from,Person to,string contents)Person(string name,address wallet)" {
+		t.Fatalf("encodeType=%q err=%v", encType, err)
+	}
+	domain, err := td.DomainSeparator()
+	if err != nil {
+		t.Fatalf("domain: %v", err)
+	}
+	if got := hex.EncodeToString(domain[:]); got != "f2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f" {
+		t.Fatalf("domain separator %s", got)
+	}
+	msg, err := td.HashStruct("Mail", td.Message)
+	if err != nil {
+		t.Fatalf("hashStruct: %v", err)
+	}
+	if got := hex.EncodeToString(msg[:]); got != "c52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e" {
+		t.Fatalf("message hash %s", got)
+	}
+	digest, err := td.Hash()
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if got := hex.EncodeToString(digest[:]); got != "be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2" {
+		t.Fatalf("digest %s", got)
+	}
+}
+
+func TestEncodeAtomicTypes(t *testing.T) {
+	for _, tc := range []struct {
+		typ  string
+		val  any
+		want string
+	}{
+		{"uint8", "0xff", strings.Repeat("00", 31) + "ff"},
+		{"int16", "-1", strings.Repeat("ff", 32)},
+		{"bool", true, strings.Repeat("00", 31) + "01"},
+		{"bytes4", "0xdeadbeef", "deadbeef" + strings.Repeat("00", 28)},
+	} {
+		word, err := encodeAtomic(tc.typ, tc.val)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.typ, err)
+		}
+		if got := hex.EncodeToString(word[:]); got != tc.want {
+			t.Fatalf("%s: got %s", tc.typ, got)
+		}
+	}
+	for _, tc := range []struct {
+		typ string
+		val any
+	}{
+		{"uint8", "256"},
+		{"uint256", "-1"},
+		{"int8", "128"},
+		{"bytes4", "0xdead"},
+		{"address", "0x1234"},
+		{"uint7", "1"},
+		{"bool", "true"},
+	} {
+		if _, err := encodeAtomic(tc.typ, tc.val); err == nil {
+			t.Fatalf("%s(%v): expected error", tc.typ, tc.val)
+		}
+	}
+}
+
+func TestParseRejectsMalformedTypedData(t *testing.T) {
+	for name, payload := range map[string]string{
+		"no domain type":  `{"types":{"Mail":[]},"primaryType":"Mail","domain":{},"message":{}}`,
+		"unknown primary": `{"types":{"EIP712Domain":[]},"primaryType":"Mail","domain":{},"message":{}}`,
+		"unknown field":   `{"types":{"EIP712Domain":[],"Mail":[{"name":"a","type":"Person"}]},"primaryType":"Mail","domain":{},"message":{}}`,
+		"duplicate field": `{"types":{"EIP712Domain":[],"Mail":[{"name":"a","type":"string"},{"name":"a","type":"string"}]},"primaryType":"Mail","domain":{},"message":{}}`,
+		"invalid json":    `{"types":`,
+	} {
+		if _, err := Parse([]byte(payload)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+	td, err := Parse([]byte(`{"types":{"EIP712Domain":[{"name":"name","type":"string"}],"Mail":[{"name":"a","type":"string"}]},"primaryType":"Mail","domain":{"name":"x"},"message":{"a":"x","b":"y"}}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if _, err := td.Hash(); err == nil {
+		t.Fatal("extra message field must be rejected")
+	}
+	td.Message = map[string]any{}
+	if _, err := td.Hash(); err == nil {
+		t.Fatal("missing message field must be rejected")
+	}
+}
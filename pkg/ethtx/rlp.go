@@ -0,0 +1,156 @@
+package ethtx
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// errNonCanonical 表示 RLP 编码可解析但不是规范形式，同一交易存在多种编码会导致签名哈希不唯一。
+var errNonCanonical = errors.New("rlp: non-canonical encoding")
+
+// rlpItem 是解析出的单个 RLP 项：raw 含头部，可直接拼接回列表；content 为去掉头部的内容。
+type rlpItem struct {
+	raw     []byte
+	content []byte
+	list    bool
+}
+
+// readItem 解析 b 开头的一个 RLP 项并返回剩余字节。
+func readItem(b []byte) (rlpItem, []byte, error) {
+	if len(b) == 0 {
+		return rlpItem{}, nil, errors.New("rlp: unexpected end of input")
+	}
+	var (
+		prefix     = b[0]
+		headerSize int
+		size       uint64
+		list       bool
+	)
+	switch {
+	case prefix < 0x80:
+		return rlpItem{raw: b[:1], content: b[:1]}, b[1:], nil
+	case prefix <= 0xb7:
+		headerSize, size = 1, uint64(prefix-0x80)
+		if size == 1 && len(b) > 1 && b[1] < 0x80 {
+			return rlpItem{}, nil, errNonCanonical
+		}
+	case prefix < 0xc0:
+		headerSize, size = readLongSize(b, prefix-0xb7)
+	case prefix <= 0xf7:
+		headerSize, size, list = 1, uint64(prefix-0xc0), true
+	default:
+		headerSize, size = readLongSize(b, prefix-0xf7)
+		list = true
+	}
+	if headerSize < 0 {
+		return rlpItem{}, nil, errNonCanonical
+	}
+	if size > uint64(len(b)-headerSize) {
+		return rlpItem{}, nil, errors.New("rlp: value exceeds input length")
+	}
+	end := headerSize + int(size)
+	return rlpItem{raw: b[:end], content: b[headerSize:end], list: list}, b[end:], nil
+}
+
+// readLongSize 读取长格式头部中 n 字节的长度；长度字节有前导零或长度不超过 55 时返回 -1。
+func readLongSize(b []byte, n byte) (int, uint64) {
+	headerSize := 1 + int(n)
+	if n > 8 || len(b) < headerSize || b[1] == 0 {
+		return -1, 0
+	}
+	var buf [8]byte
+	copy(buf[8-n:], b[1:headerSize])
+	size := binary.BigEndian.Uint64(buf[:])
+	if size <= 55 {
+		return -1, 0
+	}
+	return headerSize, size
+}
+
+// readList 要求 b 恰好是一个 RLP 列表，返回其中的各项。
+func readList(b []byte) ([]rlpItem, error) {
+	outer, rest, err := readItem(b)
+	if err != nil {
+		return nil, err
+	}
+	if !outer.list {
+		return nil, errors.New("rlp: expected list")
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("rlp: trailing bytes after list")
+	}
+	var items []rlpItem
+	for content := outer.content; len(content) > 0; {
+		var item rlpItem
+		if item, content, err = readItem(content); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func appendHeader(dst []byte, size int, offset byte) []byte {
+	if size <= 55 {
+		return append(dst, offset+byte(size))
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(size))
+	i := 0
+	for buf[i] == 0 {
+		i++
+	}
+	dst = append(dst, offset+55+byte(8-i))
+	return append(dst, buf[i:]...)
+}
+
+// encodeString 按 RLP 规则编码字节串。
+func encodeString(b []byte) []byte {
+	if len(b) == 1 && b[0] < 0x80 {
+		return []byte{b[0]}
+	}
+	return append(appendHeader(make([]byte, 0, len(b)+9), len(b), 0x80), b...)
+}
+
+// encodeUint 以无前导零的大端字节串编码整数，0 编码为空串。
+func encodeUint(v uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	return encodeString(trimLeadingZeros(buf[:]))
+}
+
+// encodeList 将已编码的各项拼接为 RLP 列表。
+func encodeList(items [][]byte) []byte {
+	size := 0
+	for _, item := range items {
+		size += len(item)
+	}
+	out := appendHeader(make([]byte, 0, size+9), size, 0xc0)
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func trimLeadingZeros(b []byte) []byte {
+	for len(b) > 0 && b[0] == 0 {
+		b = b[1:]
+	}
+	return b
+}
+
+// decodeUint 解析 RLP 整数字段：必须是无前导零、不超过 8 字节的字节串。
+func decodeUint(item rlpItem) (uint64, error) {
+	if item.list {
+		return 0, errors.New("rlp: expected integer, got list")
+	}
+	if len(item.content) > 8 {
+		return 0, errors.New("rlp: integer overflows uint64")
+	}
+	if len(item.content) > 0 && item.content[0] == 0 {
+		return 0, errNonCanonical
+	}
+	var buf [8]byte
+	copy(buf[8-len(item.content):], item.content)
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
@@ -0,0 +1,213 @@
+// Package ethtx 解析未签名的以太坊交易（legacy/EIP-155、EIP-2930、EIP-1559），
+// 计算签名哈希并在拿到 secp256k1 签名后组装可直接广播的已签名交易。
+package ethtx
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Type 是 EIP-2718 交易类型，legacy 为 0。
+type Type byte
+
+const (
+	LegacyTxType     Type = 0x00
+	AccessListTxType Type = 0x01
+	DynamicFeeTxType Type = 0x02
+)
+
+func (t Type) String() string {
+	switch t {
+	case LegacyTxType:
+		return "legacy"
+	case AccessListTxType:
+		return "eip2930"
+	case DynamicFeeTxType:
+		return "eip1559"
+	}
+	return fmt.Sprintf("type(0x%02x)", byte(t))
+}
+
+var (
+	// ErrChainIDRequired 表示 legacy 交易既未内嵌 EIP-155 chainId 也未由调用方给出；不支持无重放保护的签名。
+	ErrChainIDRequired = errors.New("chainId is required for legacy transactions")
+	// ErrChainIDMismatch 表示调用方给出的 chainId 与交易内的 chainId 不一致。
+	ErrChainIDMismatch = errors.New("chainId does not match transaction")
+	// ErrInvalidSignature 表示签名不是 64/65 字节 r||s[||v] 或 recId 无法映射到 yParity。
+	ErrInvalidSignature = errors.New("invalid secp256k1 signature")
+)
+
+type fieldKind uint8
+
+const (
+	kindUint fieldKind = iota
+	kindAddress
+	kindBytes
+	kindList
+)
+
+// 各类型签名前字段的布局；typed 交易的首个字段为 chainId。
+var layouts = map[Type][]fieldKind{
+	LegacyTxType:     {kindUint, kindUint, kindUint, kindAddress, kindUint, kindBytes},
+	AccessListTxType: {kindUint, kindUint, kindUint, kindUint, kindAddress, kindUint, kindBytes, kindList},
+	DynamicFeeTxType: {kindUint, kindUint, kindUint, kindUint, kindUint, kindAddress, kindUint, kindBytes, kindList},
+}
+
+// secp256k1 阶的一半，s 超过该值时按 EIP-2 规范化为低 s。
+var (
+	secpN, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	secpHalfN = new(big.Int).Rsh(secpN, 1)
+)
+
+// Tx 是解析后的未签名交易，字段保留原始 RLP 编码，保证签名与输入逐字节对应。
+type Tx struct {
+	Type    Type
+	ChainID uint64
+	fields  [][]byte
+}
+
+// Decode 解析未签名交易：typed 交易为 type || rlp([...])，legacy 为 rlp([6 个字段]) 或 EIP-155 签名载荷 rlp([6 个字段, chainId, 0, 0])。
+// chainID 非 0 时必须与交易内的 chainId 一致；legacy 交易未内嵌 chainId 时使用它。
+func Decode(raw []byte, chainID uint64) (*Tx, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("transaction is empty")
+	}
+	typ, payload := LegacyTxType, raw
+	if raw[0] <= 0x7f {
+		typ, payload = Type(raw[0]), raw[1:]
+		if _, ok := layouts[typ]; !ok || typ == LegacyTxType {
+			return nil, fmt.Errorf("unsupported transaction type 0x%02x", raw[0])
+		}
+	}
+	items, err := readList(payload)
+	if err != nil {
+		return nil, err
+	}
+	layout := layouts[typ]
+	tx := &Tx{Type: typ}
+	if typ == LegacyTxType && len(items) == len(layout)+3 {
+		if tx.ChainID, err = decodeEIP155Suffix(items[len(layout):]); err != nil {
+			return nil, err
+		}
+		items = items[:len(layout)]
+	}
+	if len(items) != len(layout) {
+		return nil, fmt.Errorf("%s transaction must have %d fields, got %d", typ, len(layout), len(items))
+	}
+	for i, item := range items {
+		if err := checkField(layout[i], item); err != nil {
+			return nil, fmt.Errorf("%s transaction field %d: %w", typ, i, err)
+		}
+		tx.fields = append(tx.fields, item.raw)
+	}
+	if typ != LegacyTxType {
+		if tx.ChainID, err = decodeUint(items[0]); err != nil {
+			return nil, fmt.Errorf("chainId: %w", err)
+		}
+	}
+	switch {
+	case tx.ChainID == 0 && typ == LegacyTxType:
+		if chainID == 0 {
+			return nil, ErrChainIDRequired
+		}
+		tx.ChainID = chainID
+	case chainID != 0 && chainID != tx.ChainID:
+		return nil, fmt.Errorf("%w: transaction has %d, request has %d", ErrChainIDMismatch, tx.ChainID, chainID)
+	}
+	return tx, nil
+}
+
+// decodeEIP155Suffix 解析 EIP-155 签名载荷末尾的 [chainId, 0, 0]。
+func decodeEIP155Suffix(items []rlpItem) (uint64, error) {
+	chainID, err := decodeUint(items[0])
+	if err != nil {
+		return 0, fmt.Errorf("chainId: %w", err)
+	}
+	if chainID == 0 || len(items[1].raw) != 1 || items[1].raw[0] != 0x80 || len(items[2].raw) != 1 || items[2].raw[0] != 0x80 {
+		return 0, errors.New("legacy transaction with 9 fields must end with [chainId, 0, 0]")
+	}
+	return chainID, nil
+}
+
+func checkField(kind fieldKind, item rlpItem) error {
+	if item.list != (kind == kindList) {
+		if item.list {
+			return errors.New("unexpected list")
+		}
+		return errors.New("expected list")
+	}
+	switch kind {
+	case kindUint:
+		if len(item.content) > 32 {
+			return errors.New("integer exceeds 256 bits")
+		}
+		if len(item.content) > 0 && item.content[0] == 0 {
+			return errNonCanonical
+		}
+	case kindAddress:
+		if n := len(item.content); n != 0 && n != 20 {
+			return fmt.Errorf("to must be empty or 20 bytes, got %d", n)
+		}
+	}
+	return nil
+}
+
+// SigningHash 返回待签名的 keccak256 摘要：legacy 按 EIP-155 追加 [chainId, 0, 0]，typed 交易对 type || rlp(fields) 取哈希。
+func (tx *Tx) SigningHash() [32]byte {
+	var payload []byte
+	if tx.Type == LegacyTxType {
+		fields := append(tx.fields[:len(tx.fields):len(tx.fields)], encodeUint(tx.ChainID), encodeUint(0), encodeUint(0))
+		payload = encodeList(fields)
+	} else {
+		payload = append([]byte{byte(tx.Type)}, encodeList(tx.fields)...)
+	}
+	return keccak256(payload)
+}
+
+// WithSignature 用 64B r||s（或 65B r||s||v）与 recId 组装已签名交易；s 为高位时规范化为低 s 并翻转 yParity。
+// legacy 交易的 v 为 chainId*2 + 35 + yParity，typed 交易直接写入 yParity。
+func (tx *Tx) WithSignature(sig []byte, recID uint32) ([]byte, error) {
+	if len(sig) != 64 && len(sig) != 65 {
+		return nil, ErrInvalidSignature
+	}
+	if recID >= 27 {
+		recID -= 27
+	}
+	if recID > 1 {
+		return nil, fmt.Errorf("%w: recId %d cannot be encoded", ErrInvalidSignature, recID)
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	if r.Sign() == 0 || s.Sign() == 0 || r.Cmp(secpN) >= 0 || s.Cmp(secpN) >= 0 {
+		return nil, ErrInvalidSignature
+	}
+	if s.Cmp(secpHalfN) > 0 {
+		s.Sub(secpN, s)
+		recID ^= 1
+	}
+	v := uint64(recID)
+	if tx.Type == LegacyTxType {
+		v += tx.ChainID*2 + 35
+	}
+	fields := append(tx.fields[:len(tx.fields):len(tx.fields)], encodeUint(v), encodeString(r.Bytes()), encodeString(s.Bytes()))
+	if tx.Type == LegacyTxType {
+		return encodeList(fields), nil
+	}
+	return append([]byte{byte(tx.Type)}, encodeList(fields)...), nil
+}
+
+// Hash 返回已签名交易的哈希（即链上 tx hash）。
+func Hash(signed []byte) [32]byte {
+	return keccak256(signed)
+}
+
+func keccak256(b []byte) [32]byte {
+	var out [32]byte
+	h := sha3.NewLegacyKeccak256()
+	h.Write(b)
+	h.Sum(out[:0])
+	return out
+}
@@ -0,0 +1,149 @@
+package ethtx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("hex: %v", err)
+	}
+	return b
+}
+
+// EIP-155 规范中的示例交易：nonce=9, gasPrice=20 gwei, gas=21000, to=0x3535..., value=1 ether, chainId=1。
+const (
+	eip155Unsigned = "e9098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080"
+	eip155Payload  = "ec098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080018080"
+	eip155Hash     = "daf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53"
+	eip155R        = "28ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276"
+	eip155S        = "67cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+	eip155Signed   = "f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+)
+
+func TestLegacyEIP155Vector(t *testing.T) {
+	sig := mustHex(t, eip155R+eip155S)
+	for _, tc := range []struct {
+		raw     string
+		chainID uint64
+	}{
+		{eip155Unsigned, 1},
+		{eip155Payload, 0},
+		{eip155Payload, 1},
+	} {
+		tx, err := Decode(mustHex(t, tc.raw), tc.chainID)
+		if err != nil {
+			t.Fatalf("decode %s: %v", tc.raw[:8], err)
+		}
+		if tx.Type != LegacyTxType || tx.ChainID != 1 {
+			t.Fatalf("unexpected tx type=%v chainId=%d", tx.Type, tx.ChainID)
+		}
+		if h := tx.SigningHash(); hex.EncodeToString(h[:]) != eip155Hash {
+			t.Fatalf("signing hash %x", h)
+		}
+		signed, err := tx.WithSignature(sig, 0)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		if hex.EncodeToString(signed) != eip155Signed {
+			t.Fatalf("signed tx %x", signed)
+		}
+	}
+}
+
+func TestDynamicFeeTxNormalizesHighS(t *testing.T) {
+	to := bytes.Repeat([]byte{0x35}, 20)
+	unsigned := append([]byte{byte(DynamicFeeTxType)}, encodeList([][]byte{
+		encodeUint(5), encodeUint(0), encodeUint(1e9), encodeUint(30e9), encodeUint(21000),
+		encodeString(to), encodeUint(1e18), encodeString(nil), encodeList(nil),
+	})...)
+	tx, err := Decode(unsigned, 5)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if tx.Type != DynamicFeeTxType || tx.ChainID != 5 {
+		t.Fatalf("unexpected tx type=%v chainId=%d", tx.Type, tx.ChainID)
+	}
+	wantHash := keccak256(unsigned)
+	if tx.SigningHash() != wantHash {
+		t.Fatal("typed signing hash must cover type || rlp(fields)")
+	}
+
+	r := big.NewInt(0x1234)
+	lowS := big.NewInt(0x5678)
+	highS := new(big.Int).Sub(secpN, lowS)
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	highS.FillBytes(sig[32:])
+	signed, err := tx.WithSignature(sig, 28)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if signed[0] != byte(DynamicFeeTxType) {
+		t.Fatalf("signed tx must keep type prefix, got 0x%02x", signed[0])
+	}
+	items, err := readList(signed[1:])
+	if err != nil || len(items) != 12 {
+		t.Fatalf("signed fields=%d err=%v", len(items), err)
+	}
+	if parity, _ := decodeUint(items[9]); parity != 0 {
+		t.Fatalf("high s must flip yParity 1 -> 0, got %d", parity)
+	}
+	if got := new(big.Int).SetBytes(items[11].content); got.Cmp(lowS) != 0 {
+		t.Fatalf("s not normalized: %x", got)
+	}
+	if !bytes.Equal(encodeList(itemsRaw(items[:9])), encodeList(tx.fields)) {
+		t.Fatal("signed tx must carry the unsigned fields verbatim")
+	}
+}
+
+func itemsRaw(items []rlpItem) [][]byte {
+	out := make([][]byte, len(items))
+	for i, item := range items {
+		out[i] = item.raw
+	}
+	return out
+}
+
+func TestDecodeRejectsInvalidTransactions(t *testing.T) {
+	accessList := append([]byte{byte(AccessListTxType)}, encodeList([][]byte{
+		encodeUint(1), encodeUint(0), encodeUint(1), encodeUint(21000),
+		encodeString(nil), encodeUint(0), encodeString([]byte{0xde, 0xad}), encodeList(nil),
+	})...)
+	if _, err := Decode(accessList, 0); err != nil {
+		t.Fatalf("eip2930 contract creation should decode: %v", err)
+	}
+	if _, err := Decode(accessList, 2); !errors.Is(err, ErrChainIDMismatch) {
+		t.Fatalf("expected chainId mismatch, got %v", err)
+	}
+	if _, err := Decode(mustHex(t, eip155Unsigned), 0); !errors.Is(err, ErrChainIDRequired) {
+		t.Fatalf("expected chainId required, got %v", err)
+	}
+	for name, raw := range map[string][]byte{
+		"empty":          nil,
+		"unknown type":   append([]byte{0x03}, accessList[1:]...),
+		"not a list":     encodeString([]byte("tx")),
+		"trailing bytes": append(mustHex(t, eip155Unsigned), 0x00),
+		"field count":    encodeList([][]byte{encodeUint(1)}),
+		"bad to":         encodeList([][]byte{encodeUint(0), encodeUint(1), encodeUint(1), encodeString([]byte{1, 2}), encodeUint(0), encodeString(nil)}),
+		"leading zero":   encodeList([][]byte{encodeString([]byte{0, 1}), encodeUint(1), encodeUint(1), encodeString(nil), encodeUint(0), encodeString(nil)}),
+		"non-canonical":  mustHex(t, "c6"+"8101"+"01"+"01"+"80"+"80"+"80"),
+	} {
+		if _, err := Decode(raw, 1); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+	tx, _ := Decode(mustHex(t, eip155Unsigned), 1)
+	if _, err := tx.WithSignature(make([]byte, 63), 0); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected invalid signature, got %v", err)
+	}
+	if _, err := tx.WithSignature(mustHex(t, eip155R+eip155S), 2); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("recId 2 must be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+package sigverify
+
+import (
+	"crypto/ed25519"
+)
+
+// VerifyEd25519 校验 Ed25519 签名，digest 按原文参与验签（签名服务只对 32 字节摘要签名）。
+func VerifyEd25519(pub, digest, sig []byte) (bool, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return false, ErrInvalidPublicKey
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return false, ErrInvalidSignature
+	}
+	return ed25519.Verify(ed25519.PublicKey(pub), digest, sig), nil
+}
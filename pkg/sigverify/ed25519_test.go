@@ -0,0 +1,36 @@
+package sigverify
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// RFC 8032 §7.1 TEST 1（空消息）。
+func TestVerifyEd25519RFC8032Vector(t *testing.T) {
+	pub := mustHex(t, "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")
+	sig := mustHex(t, "e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b")
+
+	if ok, err := VerifyEd25519(pub, nil, sig); err != nil || !ok {
+		t.Fatalf("expected valid signature, ok=%v err=%v", ok, err)
+	}
+	tampered := append([]byte(nil), sig...)
+	tampered[10] ^= 0x01
+	if ok, _ := VerifyEd25519(pub, nil, tampered); ok {
+		t.Fatal("tampered signature must not verify")
+	}
+	if _, err := VerifyEd25519(pub[:31], nil, sig); err != ErrInvalidPublicKey {
+		t.Fatalf("expected ErrInvalidPublicKey, got %v", err)
+	}
+	if _, err := VerifyEd25519(pub, nil, sig[:63]); err != ErrInvalidSignature {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("decode hex: %v", err)
+	}
+	return b
+}
@@ -0,0 +1,73 @@
+package sigverify
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ErrRecoveryFailed 表示无法从签名与 recId 恢复出公钥。
+var ErrRecoveryFailed = errors.New("public key recovery failed")
+
+// RecoverSecp256k1 由 32 字节摘要、r||s 签名与 recId（0-3，兼容 27/28）恢复未压缩公钥（65B）。
+func RecoverSecp256k1(digest, sig []byte, recID byte) ([]byte, error) {
+	if len(digest) != 32 {
+		return nil, fmt.Errorf("digest must be 32 bytes, got %d", len(digest))
+	}
+	if len(sig) != 64 && len(sig) != 65 {
+		return nil, ErrInvalidSignature
+	}
+	if recID >= 27 {
+		recID -= 27
+	}
+	if recID > 3 {
+		return nil, ErrRecoveryFailed
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(secpN) >= 0 || s.Cmp(secpN) >= 0 {
+		return nil, ErrRecoveryFailed
+	}
+	x := new(big.Int).Set(r)
+	if recID&2 != 0 {
+		x.Add(x, secpN)
+	}
+	y := decompressY(x, recID&1 == 1)
+	if y == nil {
+		return nil, ErrRecoveryFailed
+	}
+	// Q = r⁻¹(sR - zG)
+	rInv := new(big.Int).ModInverse(r, secpN)
+	u1 := new(big.Int).SetBytes(digest)
+	u1.Neg(u1)
+	u1.Mul(u1, rInv)
+	u1.Mod(u1, secpN)
+	u2 := new(big.Int).Mul(s, rInv)
+	u2.Mod(u2, secpN)
+	q := addPoints(scalarMult(point{secpGx, secpGy}, u1), scalarMult(point{x, y}, u2))
+	if q.x == nil {
+		return nil, ErrRecoveryFailed
+	}
+	out := make([]byte, 65)
+	out[0] = 0x04
+	q.x.FillBytes(out[1:33])
+	q.y.FillBytes(out[33:])
+	return out, nil
+}
+
+// EthereumAddress 返回 secp256k1 公钥（压缩或未压缩）对应的 0x 前缀小写地址。
+func EthereumAddress(pub []byte) (string, error) {
+	x, y, err := ParseSecp256k1PublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, 64)
+	x.FillBytes(buf[:32])
+	y.FillBytes(buf[32:])
+	h := sha3.NewLegacyKeccak256()
+	h.Write(buf)
+	return "0x" + hex.EncodeToString(h.Sum(nil)[12:]), nil
+}
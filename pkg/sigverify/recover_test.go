@@ -0,0 +1,44 @@
+package sigverify
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+func TestEthereumAddressKnownVector(t *testing.T) {
+	// 私钥 1 对应的地址是公开的测试向量。
+	addr, err := EthereumAddress(testPublicKey(big.NewInt(1), true))
+	if err != nil {
+		t.Fatalf("address: %v", err)
+	}
+	if addr != "0x7e5f4552091a69125d5dfcb7b8c2659029395bdf" {
+		t.Fatalf("unexpected address %s", addr)
+	}
+}
+
+func TestRecoverSecp256k1(t *testing.T) {
+	priv := big.NewInt(0x1234567)
+	k := big.NewInt(0xabcdef)
+	digest := sha256.Sum256([]byte("aegis"))
+	sig := testSign(priv, k, digest[:])
+	recID := byte(scalarMult(point{secpGx, secpGy}, k).y.Bit(0))
+
+	pub, err := RecoverSecp256k1(digest[:], sig, recID)
+	if err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	if !bytes.Equal(pub, testPublicKey(priv, false)) {
+		t.Fatal("recovered key does not match signer")
+	}
+	if legacy, err := RecoverSecp256k1(digest[:], sig, recID+27); err != nil || !bytes.Equal(legacy, pub) {
+		t.Fatalf("27/28 recId should be accepted, err=%v", err)
+	}
+	if other, err := RecoverSecp256k1(digest[:], sig, recID^1); err == nil && bytes.Equal(other, pub) {
+		t.Fatal("wrong recId must not recover the signer")
+	}
+	if _, err := RecoverSecp256k1(digest[:], sig[:10], recID); err != ErrInvalidSignature {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+}
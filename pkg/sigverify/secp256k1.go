@@ -0,0 +1,186 @@
+package sigverify
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// secp256k1 曲线参数（y² = x³ + 7 mod p）。
+var (
+	secpP, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+	secpN, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	secpGx, _ = new(big.Int).SetString("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798", 16)
+	secpGy, _ = new(big.Int).SetString("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8", 16)
+	secpB     = big.NewInt(7)
+)
+
+var (
+	// ErrInvalidPublicKey 表示公钥编码或坐标不合法。
+	ErrInvalidPublicKey = errors.New("invalid public key")
+	// ErrInvalidSignature 表示签名编码不合法。
+	ErrInvalidSignature = errors.New("invalid signature encoding")
+)
+
+// point 是仿射坐标点，nil 表示无穷远点。
+type point struct {
+	x, y *big.Int
+}
+
+// ParseSecp256k1PublicKey 解析压缩（33B）或未压缩（65B）公钥并校验其在曲线上。
+func ParseSecp256k1PublicKey(pub []byte) (x, y *big.Int, err error) {
+	switch {
+	case len(pub) == 65 && pub[0] == 0x04:
+		x = new(big.Int).SetBytes(pub[1:33])
+		y = new(big.Int).SetBytes(pub[33:])
+	case len(pub) == 33 && (pub[0] == 0x02 || pub[0] == 0x03):
+		x = new(big.Int).SetBytes(pub[1:])
+		y = decompressY(x, pub[0] == 0x03)
+		if y == nil {
+			return nil, nil, ErrInvalidPublicKey
+		}
+	default:
+		return nil, nil, ErrInvalidPublicKey
+	}
+	if x.Cmp(secpP) >= 0 || y.Cmp(secpP) >= 0 || !onCurve(x, y) {
+		return nil, nil, ErrInvalidPublicKey
+	}
+	return x, y, nil
+}
+
+// ParseSignature 接受 64B r||s、65B r||s||v 或 DER 编码，返回 r、s。
+func ParseSignature(sig []byte) (r, s *big.Int, err error) {
+	switch len(sig) {
+	case 64, 65:
+		return new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64]), nil
+	}
+	var der struct {
+		R, S *big.Int
+	}
+	rest, err := asn1.Unmarshal(sig, &der)
+	if err != nil || len(rest) != 0 || der.R == nil || der.S == nil {
+		return nil, nil, ErrInvalidSignature
+	}
+	return der.R, der.S, nil
+}
+
+// VerifySecp256k1 校验 ECDSA(secp256k1) 签名，digest 需为 32 字节摘要。
+func VerifySecp256k1(pub, digest, sig []byte) (bool, error) {
+	if len(digest) != 32 {
+		return false, fmt.Errorf("digest must be 32 bytes, got %d", len(digest))
+	}
+	qx, qy, err := ParseSecp256k1PublicKey(pub)
+	if err != nil {
+		return false, err
+	}
+	r, s, err := ParseSignature(sig)
+	if err != nil {
+		return false, err
+	}
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(secpN) >= 0 || s.Cmp(secpN) >= 0 {
+		return false, nil
+	}
+	z := new(big.Int).SetBytes(digest)
+	w := new(big.Int).ModInverse(s, secpN)
+	u1 := new(big.Int).Mul(z, w)
+	u1.Mod(u1, secpN)
+	u2 := new(big.Int).Mul(r, w)
+	u2.Mod(u2, secpN)
+	sum := addPoints(scalarMult(point{secpGx, secpGy}, u1), scalarMult(point{qx, qy}, u2))
+	if sum.x == nil {
+		return false, nil
+	}
+	v := new(big.Int).Mod(sum.x, secpN)
+	return v.Cmp(r) == 0, nil
+}
+
+func onCurve(x, y *big.Int) bool {
+	lhs := new(big.Int).Mul(y, y)
+	lhs.Mod(lhs, secpP)
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Mul(rhs, x)
+	rhs.Add(rhs, secpB)
+	rhs.Mod(rhs, secpP)
+	return lhs.Cmp(rhs) == 0
+}
+
+func decompressY(x *big.Int, odd bool) *big.Int {
+	if x.Cmp(secpP) >= 0 {
+		return nil
+	}
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Mul(rhs, x)
+	rhs.Add(rhs, secpB)
+	rhs.Mod(rhs, secpP)
+	// p ≡ 3 (mod 4)，平方根为 rhs^((p+1)/4)。
+	exp := new(big.Int).Add(secpP, big.NewInt(1))
+	exp.Rsh(exp, 2)
+	y := new(big.Int).Exp(rhs, exp, secpP)
+	check := new(big.Int).Mul(y, y)
+	check.Mod(check, secpP)
+	if check.Cmp(rhs) != 0 {
+		return nil
+	}
+	if (y.Bit(0) == 1) != odd {
+		y.Sub(secpP, y)
+	}
+	return y
+}
+
+func addPoints(a, b point) point {
+	if a.x == nil {
+		return b
+	}
+	if b.x == nil {
+		return a
+	}
+	if a.x.Cmp(b.x) == 0 {
+		if a.y.Cmp(b.y) == 0 && a.y.Sign() != 0 {
+			return doublePoint(a)
+		}
+		return point{}
+	}
+	num := new(big.Int).Sub(b.y, a.y)
+	den := new(big.Int).Sub(b.x, a.x)
+	den.Mod(den, secpP)
+	lambda := num.Mul(num, den.ModInverse(den, secpP))
+	lambda.Mod(lambda, secpP)
+	return finishAdd(lambda, a, b.x)
+}
+
+func doublePoint(a point) point {
+	if a.x == nil || a.y.Sign() == 0 {
+		return point{}
+	}
+	num := new(big.Int).Mul(a.x, a.x)
+	num.Mul(num, big.NewInt(3))
+	den := new(big.Int).Lsh(a.y, 1)
+	den.Mod(den, secpP)
+	lambda := num.Mul(num, den.ModInverse(den, secpP))
+	lambda.Mod(lambda, secpP)
+	return finishAdd(lambda, a, a.x)
+}
+
+func finishAdd(lambda *big.Int, a point, bx *big.Int) point {
+	x := new(big.Int).Mul(lambda, lambda)
+	x.Sub(x, a.x)
+	x.Sub(x, bx)
+	x.Mod(x, secpP)
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, lambda)
+	y.Sub(y, a.y)
+	y.Mod(y, secpP)
+	return point{x, y}
+}
+
+func scalarMult(p point, k *big.Int) point {
+	var result point
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		result = doublePoint(result)
+		if k.Bit(i) == 1 {
+			result = addPoints(result, p)
+		}
+	}
+	return result
+}
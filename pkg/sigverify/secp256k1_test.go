@@ -0,0 +1,83 @@
+package sigverify
+
+import (
+	"crypto/sha256"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+)
+
+func TestVerifySecp256k1(t *testing.T) {
+	priv := big.NewInt(0x1234567)
+	digest := sha256.Sum256([]byte("aegis"))
+	pub := testPublicKey(priv, false)
+	sig := testSign(priv, big.NewInt(0xabcdef), digest[:])
+
+	ok, err := VerifySecp256k1(pub, digest[:], sig)
+	if err != nil || !ok {
+		t.Fatalf("expected valid signature, ok=%v err=%v", ok, err)
+	}
+	compressed := testPublicKey(priv, true)
+	if ok, err := VerifySecp256k1(compressed, digest[:], sig); err != nil || !ok {
+		t.Fatalf("compressed key should verify, ok=%v err=%v", ok, err)
+	}
+
+	r, s, _ := ParseSignature(sig)
+	der, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		t.Fatalf("marshal der: %v", err)
+	}
+	if ok, err := VerifySecp256k1(pub, digest[:], der); err != nil || !ok {
+		t.Fatalf("der signature should verify, ok=%v err=%v", ok, err)
+	}
+
+	tampered := append([]byte(nil), digest[:]...)
+	tampered[0] ^= 0x01
+	if ok, _ := VerifySecp256k1(pub, tampered, sig); ok {
+		t.Fatal("tampered digest must not verify")
+	}
+	other := testPublicKey(big.NewInt(99), true)
+	if ok, _ := VerifySecp256k1(other, digest[:], sig); ok {
+		t.Fatal("signature must not verify under another key")
+	}
+}
+
+func TestParseSecp256k1PublicKeyRejectsOffCurve(t *testing.T) {
+	pub := testPublicKey(big.NewInt(7), false)
+	pub[64] ^= 0x01
+	if _, _, err := ParseSecp256k1PublicKey(pub); err != ErrInvalidPublicKey {
+		t.Fatalf("expected ErrInvalidPublicKey, got %v", err)
+	}
+	if _, _, err := ParseSecp256k1PublicKey([]byte{0x05, 0x01}); err != ErrInvalidPublicKey {
+		t.Fatalf("expected ErrInvalidPublicKey for bad prefix, got %v", err)
+	}
+}
+
+func testPublicKey(priv *big.Int, compressed bool) []byte {
+	q := scalarMult(point{secpGx, secpGy}, priv)
+	if compressed {
+		out := make([]byte, 33)
+		out[0] = 0x02 + byte(q.y.Bit(0))
+		q.x.FillBytes(out[1:])
+		return out
+	}
+	out := make([]byte, 65)
+	out[0] = 0x04
+	q.x.FillBytes(out[1:33])
+	q.y.FillBytes(out[33:])
+	return out
+}
+
+func testSign(priv, k *big.Int, digest []byte) []byte {
+	kg := scalarMult(point{secpGx, secpGy}, k)
+	r := new(big.Int).Mod(kg.x, secpN)
+	z := new(big.Int).SetBytes(digest)
+	s := new(big.Int).Mul(r, priv)
+	s.Add(s, z)
+	s.Mul(s, new(big.Int).ModInverse(k, secpN))
+	s.Mod(s, secpN)
+	out := make([]byte, 64)
+	r.FillBytes(out[:32])
+	s.FillBytes(out[32:])
+	return out
+}
@@ -6,6 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+
+	"github.com/aegis-sign/wallet/pkg/curves"
 )
 
 // DigestEncoding 描述 digest 字符串的编码。
@@ -30,30 +33,58 @@ func NormalizeEncoding(raw string) (DigestEncoding, error) {
 
 var errDigestNot32Bytes = errors.New("digest must decode to 32 bytes")
 
-// DecodeDigest 将 digest 解码为二进制并验证长度。
+// maxDigestInput 限制进入解码的输入长度，远超任何曲线的 digest 编码长度，避免超长输入触发大块分配。
+const maxDigestInput = 256
+
+// decodeBuf 是解码暂存区：src 存放输入拷贝，dst 存放解码结果，经 decodeBufPool 复用。
+type decodeBuf struct {
+	src [maxDigestInput]byte
+	dst [maxDigestInput]byte
+}
+
+var decodeBufPool = sync.Pool{New: func() any { return new(decodeBuf) }}
+
+// DecodeDigest 将 digest 解码为二进制并验证长度为任一受支持曲线的摘要长度；
+// 只有校验通过的结果才会分配新切片返回。
 func DecodeDigest(digest string, enc DigestEncoding) ([]byte, error) {
-	switch enc {
-	case DigestEncodingHex:
-		decoded, err := hex.DecodeString(digest)
-		if err != nil {
-			return nil, fmt.Errorf("invalid hex digest: %w", err)
-		}
-		if len(decoded) != 32 {
-			return nil, errDigestNot32Bytes
-		}
-		return decoded, nil
-	case DigestEncodingBase64:
-		decoded, err := base64.StdEncoding.DecodeString(digest)
-		if err != nil {
-			return nil, fmt.Errorf("invalid base64 digest: %w", err)
-		}
-		if len(decoded) != 32 {
-			return nil, errDigestNot32Bytes
-		}
-		return decoded, nil
-	default:
+	return decodeDigest(digest, enc, curves.ValidDigestSize, errDigestNot32Bytes)
+}
+
+// DecodeDigestFor 按指定曲线的摘要长度解码并校验 digest。
+func DecodeDigestFor(digest string, enc DigestEncoding, curve curves.Curve) ([]byte, error) {
+	decoded, err := decodeDigest(digest, enc, func(n int) bool { return n == curve.DigestSize }, errDigestNot32Bytes)
+	if errors.Is(err, errDigestNot32Bytes) {
+		return nil, fmt.Errorf("digest must decode to %d bytes for %s", curve.DigestSize, curve.Name)
+	}
+	return decoded, err
+}
+
+func decodeDigest(digest string, enc DigestEncoding, validSize func(int) bool, sizeErr error) ([]byte, error) {
+	if enc != DigestEncodingHex && enc != DigestEncodingBase64 {
 		return nil, fmt.Errorf("unknown encoding %q", enc)
 	}
+	if len(digest) > maxDigestInput {
+		return nil, sizeErr
+	}
+	buf := decodeBufPool.Get().(*decodeBuf)
+	defer decodeBufPool.Put(buf)
+	src := buf.src[:copy(buf.src[:], digest)]
+	var (
+		n   int
+		err error
+	)
+	if enc == DigestEncodingHex {
+		n, err = hex.Decode(buf.dst[:], src)
+	} else {
+		n, err = base64.StdEncoding.Decode(buf.dst[:], src)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s digest: %w", enc, err)
+	}
+	if !validSize(n) {
+		return nil, sizeErr
+	}
+	return append([]byte(nil), buf.dst[:n]...), nil
 }
 
 // ValidateDigest 确保 digest 经解码后为 32 字节。
@@ -1,6 +1,10 @@
 package validator
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/aegis-sign/wallet/pkg/curves"
+)
 
 func TestValidateDigest(t *testing.T) {
 	hexDigest := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
@@ -44,3 +48,32 @@ func TestValidateDigest(t *testing.T) {
 		t.Fatal("expected error for unknown encoding")
 	}
 }
+
+// 预算：DecodeDigest 每次仅分配返回的 digest 切片（1 allocs/op）。
+func BenchmarkDecodeDigestHex(b *testing.B) {
+	hexDigest := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeDigest(hexDigest, DigestEncodingHex); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDecodeDigestForCurve(t *testing.T) {
+	ed, err := curves.Lookup("ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hexDigest := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	if _, err := DecodeDigestFor(hexDigest, DigestEncodingHex, ed); err != nil {
+		t.Fatalf("32B digest should be valid for ed25519: %v", err)
+	}
+	_, err = DecodeDigestFor(hexDigest[:40], DigestEncodingHex, ed)
+	if err == nil || err.Error() != "digest must decode to 32 bytes for ed25519" {
+		t.Fatalf("unexpected error for short digest: %v", err)
+	}
+	if _, err := DecodeDigestFor("zz", DigestEncodingHex, ed); err == nil {
+		t.Fatal("expected error for invalid hex")
+	}
+}
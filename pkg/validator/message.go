@@ -0,0 +1,112 @@
+package validator
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// PayloadType 描述签名请求携带的是摘要还是待服务端哈希的原始消息。
+type PayloadType string
+
+const (
+	PayloadTypeDigest     PayloadType = "digest"
+	PayloadTypeRawMessage PayloadType = "rawMessage"
+)
+
+// HashAlgorithm 是 rawMessage 模式下服务端使用的哈希算法。
+type HashAlgorithm string
+
+const (
+	HashKeccak256 HashAlgorithm = "keccak256"
+	HashSHA256    HashAlgorithm = "sha256"
+)
+
+// MaxMessageSize 是 rawMessage 解码后的最大字节数；更大的载荷应由调用方自行哈希后按 digest 提交。
+const MaxMessageSize = 64 << 10
+
+var errMessageEmpty = errors.New("message is required for rawMessage payload")
+
+// NormalizePayloadType 将用户输入转换为内部常量，空值视为 digest。
+func NormalizePayloadType(raw string) (PayloadType, error) {
+	switch strings.ToLower(raw) {
+	case "", "digest":
+		return PayloadTypeDigest, nil
+	case "rawmessage", "raw_message":
+		return PayloadTypeRawMessage, nil
+	default:
+		return "", fmt.Errorf("unsupported payload type %q", raw)
+	}
+}
+
+// NormalizeHashAlgorithm 将用户输入转换为内部常量；rawMessage 必须显式选择算法，空值报错。
+func NormalizeHashAlgorithm(raw string) (HashAlgorithm, error) {
+	switch strings.ToLower(raw) {
+	case "":
+		return "", errors.New("hash algorithm is required for rawMessage payload (keccak256 or sha256)")
+	case string(HashKeccak256):
+		return HashKeccak256, nil
+	case string(HashSHA256):
+		return HashSHA256, nil
+	default:
+		return "", fmt.Errorf("unsupported hash algorithm %q", raw)
+	}
+}
+
+// DecodeMessage 按 enc 解码原始消息并校验长度不超过 MaxMessageSize；先按编码后长度拒绝超长输入，避免大块分配。
+func DecodeMessage(message string, enc DigestEncoding) ([]byte, error) {
+	var limit int
+	switch enc {
+	case DigestEncodingHex:
+		limit = hex.EncodedLen(MaxMessageSize)
+	case DigestEncodingBase64:
+		limit = base64.StdEncoding.EncodedLen(MaxMessageSize)
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", enc)
+	}
+	if message == "" {
+		return nil, errMessageEmpty
+	}
+	if len(message) > limit {
+		return nil, fmt.Errorf("message exceeds %d bytes", MaxMessageSize)
+	}
+	var (
+		decoded []byte
+		err     error
+	)
+	if enc == DigestEncodingHex {
+		decoded, err = hex.DecodeString(message)
+	} else {
+		decoded, err = base64.StdEncoding.DecodeString(message)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s message: %w", enc, err)
+	}
+	return decoded, nil
+}
+
+// HashMessage 校验消息长度后按 alg 计算 32 字节摘要。
+func HashMessage(message []byte, alg HashAlgorithm) ([]byte, error) {
+	if len(message) == 0 {
+		return nil, errMessageEmpty
+	}
+	if len(message) > MaxMessageSize {
+		return nil, fmt.Errorf("message exceeds %d bytes", MaxMessageSize)
+	}
+	switch alg {
+	case HashKeccak256:
+		h := sha3.NewLegacyKeccak256()
+		h.Write(message)
+		return h.Sum(nil), nil
+	case HashSHA256:
+		sum := sha256.Sum256(message)
+		return sum[:], nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %q", alg)
+	}
+}
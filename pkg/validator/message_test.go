@@ -0,0 +1,66 @@
+package validator
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestHashMessage(t *testing.T) {
+	cases := map[HashAlgorithm]string{
+		HashKeccak256: "1c8aff950685c2ed4bc3174f3472287b56d9517b9c948127319a09a7a36deac8",
+		HashSHA256:    "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+	}
+	for alg, want := range cases {
+		got, err := HashMessage([]byte("hello"), alg)
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		if hex.EncodeToString(got) != want {
+			t.Fatalf("%s digest=%x, want %s", alg, got, want)
+		}
+	}
+	if _, err := HashMessage(nil, HashSHA256); err == nil {
+		t.Fatal("expected error for empty message")
+	}
+	if _, err := HashMessage(make([]byte, MaxMessageSize+1), HashSHA256); err == nil {
+		t.Fatal("expected error for oversized message")
+	}
+	if _, err := HashMessage([]byte("hello"), "sha512"); err == nil {
+		t.Fatal("expected error for unknown algorithm")
+	}
+}
+
+func TestDecodeMessage(t *testing.T) {
+	if msg, err := DecodeMessage("68656c6c6f", DigestEncodingHex); err != nil || string(msg) != "hello" {
+		t.Fatalf("hex decode=%q err=%v", msg, err)
+	}
+	if msg, err := DecodeMessage("aGVsbG8=", DigestEncodingBase64); err != nil || string(msg) != "hello" {
+		t.Fatalf("base64 decode=%q err=%v", msg, err)
+	}
+	if _, err := DecodeMessage(strings.Repeat("00", MaxMessageSize), DigestEncodingHex); err != nil {
+		t.Fatalf("message at the limit should be valid: %v", err)
+	}
+	for _, raw := range []string{"", "zz", strings.Repeat("00", MaxMessageSize+1)} {
+		if _, err := DecodeMessage(raw, DigestEncodingHex); err == nil {
+			t.Fatalf("expected error for %d-char input", len(raw))
+		}
+	}
+}
+
+func TestNormalizePayloadAndHash(t *testing.T) {
+	for raw, want := range map[string]PayloadType{"": PayloadTypeDigest, "digest": PayloadTypeDigest, "rawMessage": PayloadTypeRawMessage, "raw_message": PayloadTypeRawMessage} {
+		if got, err := NormalizePayloadType(raw); err != nil || got != want {
+			t.Fatalf("payload type %q=%q err=%v", raw, got, err)
+		}
+	}
+	if _, err := NormalizePayloadType("typedData"); err == nil {
+		t.Fatal("expected error for unknown payload type")
+	}
+	if got, err := NormalizeHashAlgorithm("KECCAK256"); err != nil || got != HashKeccak256 {
+		t.Fatalf("hash=%q err=%v", got, err)
+	}
+	if _, err := NormalizeHashAlgorithm(""); err == nil {
+		t.Fatal("rawMessage must require an explicit hash algorithm")
+	}
+}
@@ -0,0 +1,94 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aegis-sign/wallet/pkg/curves"
+)
+
+// HardenedOffset 是 BIP-32 硬化索引的起点（2^31）。
+const HardenedOffset uint32 = 1 << 31
+
+// MaxDerivationDepth 限制派生层级，BIP-44 只需 5 级，留出余量的同时避免任意长路径进入 Enclave。
+const MaxDerivationDepth = 10
+
+// maxPathInput 限制进入解析的路径长度，10 级硬化索引的最长写法也远小于该值。
+const maxPathInput = 128
+
+var errPathHardenedOnly = errors.New("ed25519 derivation (SLIP-0010) supports hardened indexes only")
+
+// DerivationPath 是解析后的 BIP-32/SLIP-0010 派生路径，硬化索引已加上 HardenedOffset。
+type DerivationPath []uint32
+
+// ParseDerivationPath 解析形如 m/44'/60'/0'/0/5 的路径：必须以 m 开头、至少 1 级、至多 MaxDerivationDepth 级，
+// 硬化标记可写作 '、h 或 H，索引须小于 2^31。
+func ParseDerivationPath(raw string) (DerivationPath, error) {
+	if len(raw) > maxPathInput {
+		return nil, fmt.Errorf("derivation path exceeds %d characters", maxPathInput)
+	}
+	parts := strings.Split(raw, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("derivation path %q must start with m/", raw)
+	}
+	levels := parts[1:]
+	if len(levels) == 0 {
+		return nil, errors.New("derivation path must have at least one level")
+	}
+	if len(levels) > MaxDerivationDepth {
+		return nil, fmt.Errorf("derivation path exceeds %d levels", MaxDerivationDepth)
+	}
+	path := make(DerivationPath, len(levels))
+	for i, level := range levels {
+		hardened := false
+		if n := len(level); n > 0 && (level[n-1] == '\'' || level[n-1] == 'h' || level[n-1] == 'H') {
+			hardened, level = true, level[:n-1]
+		}
+		// 只接受不带前导零的十进制数字，拒绝 +1、空段等歧义写法，保证同一路径只有一种规范形式。
+		if level == "" || strings.TrimLeft(level, "0123456789") != "" || (len(level) > 1 && level[0] == '0') {
+			return nil, fmt.Errorf("invalid derivation path level %q", levels[i])
+		}
+		index, err := strconv.ParseUint(level, 10, 32)
+		if err != nil || uint32(index) >= HardenedOffset {
+			return nil, fmt.Errorf("derivation path index %q out of range", levels[i])
+		}
+		path[i] = uint32(index)
+		if hardened {
+			path[i] += HardenedOffset
+		}
+	}
+	return path, nil
+}
+
+// String 返回规范写法（硬化索引统一使用 '），作为 key cache 与审计中的路径标识。
+func (p DerivationPath) String() string {
+	var b strings.Builder
+	b.WriteByte('m')
+	for _, index := range p {
+		b.WriteByte('/')
+		b.WriteString(strconv.FormatUint(uint64(index&^HardenedOffset), 10))
+		if index >= HardenedOffset {
+			b.WriteByte('\'')
+		}
+	}
+	return b.String()
+}
+
+// NormalizeDerivationPath 按曲线校验路径并返回规范写法：secp256k1 走 BIP-32，
+// ed25519 走 SLIP-0010，只允许硬化派生。
+func NormalizeDerivationPath(raw string, curve curves.Curve) (string, error) {
+	path, err := ParseDerivationPath(raw)
+	if err != nil {
+		return "", err
+	}
+	if curve.Name == "ed25519" {
+		for _, index := range path {
+			if index < HardenedOffset {
+				return "", errPathHardenedOnly
+			}
+		}
+	}
+	return path.String(), nil
+}
@@ -0,0 +1,52 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/aegis-sign/wallet/pkg/curves"
+)
+
+func TestParseDerivationPath(t *testing.T) {
+	path, err := ParseDerivationPath("m/44h/60'/0H/0/5")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	want := DerivationPath{44 + HardenedOffset, 60 + HardenedOffset, HardenedOffset, 0, 5}
+	if len(path) != len(want) {
+		t.Fatalf("path=%v, want %v", path, want)
+	}
+	for i := range want {
+		if path[i] != want[i] {
+			t.Fatalf("path=%v, want %v", path, want)
+		}
+	}
+	if got := path.String(); got != "m/44'/60'/0'/0/5" {
+		t.Fatalf("canonical=%q", got)
+	}
+
+	for _, raw := range []string{
+		"", "m", "m/", "44'/60'", "M/44'", "m/44'//0", "m/-1", "m/+1", "m/01", "m/2147483648", "m/1''",
+		"m/0/0/0/0/0/0/0/0/0/0/0",
+	} {
+		if _, err := ParseDerivationPath(raw); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+	if _, err := ParseDerivationPath("m/2147483647'"); err != nil {
+		t.Fatalf("max hardened index should be valid: %v", err)
+	}
+}
+
+func TestNormalizeDerivationPath(t *testing.T) {
+	secp, _ := curves.Lookup("secp256k1")
+	ed, _ := curves.Lookup("ed25519")
+	if got, err := NormalizeDerivationPath("m/44h/60h/0h/0/1", secp); err != nil || got != "m/44'/60'/0'/0/1" {
+		t.Fatalf("secp256k1 normalize=%q err=%v", got, err)
+	}
+	if got, err := NormalizeDerivationPath("m/44'/501'/0'", ed); err != nil || got != "m/44'/501'/0'" {
+		t.Fatalf("ed25519 normalize=%q err=%v", got, err)
+	}
+	if _, err := NormalizeDerivationPath("m/44'/501'/0'/0", ed); err == nil {
+		t.Fatal("expected error for non-hardened ed25519 level")
+	}
+}